@@ -0,0 +1,443 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package backupccl
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/ccl/storageccl"
+	"github.com/cockroachdb/cockroach/pkg/cloud"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/ioctx"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+	"github.com/cockroachdb/errors"
+)
+
+// Backup compaction merges a chain of backups -- a full backup and the
+// incremental backups appended to it -- into a new full backup as of the end
+// time of the chain, without reading anything from the cluster. The new
+// backup is equivalent to the chain for a RESTORE as of its end time, and
+// allows new incremental backups to be taken on top of it rather than on top
+// of an ever-growing chain.
+//
+// The key spans of the last backup of the chain are partitioned using the
+// same covering as RESTORE, and for each partition the SSTs of all layers
+// overlapping it are merged, keeping the latest version of each key (and
+// dropping deleted keys), before being written to the SSTs of the new backup.
+
+// validateCompactionChain checks that the given layers, full backup first,
+// form a chain of backups that can be compacted.
+func validateCompactionChain(manifests []BackupManifest) error {
+	if len(manifests) < 2 {
+		return errors.New("backup has no incremental backups to compact")
+	}
+	if manifests[0].isIncremental() {
+		return errors.New("first layer of the backup chain is not a full backup")
+	}
+	for i := range manifests {
+		if len(manifests[i].LocalityKVs) > 0 {
+			return errors.New("compaction of locality-aware backups is not supported")
+		}
+		if i > 0 && !manifests[i].StartTime.Equal(manifests[i-1].EndTime) {
+			return errors.Newf("backup chain is not contiguous: layer %d starts at %s "+
+				"but the previous layer ends at %s", i, manifests[i].StartTime, manifests[i-1].EndTime)
+		}
+	}
+	return nil
+}
+
+// makeCompactedManifest returns the manifest of the full backup compacting a
+// chain whose last layer is last, given the files of the compacted backup.
+func makeCompactedManifest(last BackupManifest, files []BackupManifest_File) BackupManifest {
+	m := last
+	m.StartTime = hlc.Timestamp{}
+	// Only the latest version of each key is kept.
+	m.MVCCFilter = MVCCFilter_Latest
+	m.RevisionStartTime = hlc.Timestamp{}
+	m.DescriptorChanges = nil
+	m.IntroducedSpans = nil
+	m.Files = files
+	m.EntryCounts = roachpb.RowCount{}
+	for i := range files {
+		m.EntryCounts.Add(files[i].EntryCounts)
+	}
+	m.Dir = roachpb.ExternalStorage{}
+	m.ID = uuid.MakeV4()
+	m.PartitionDescriptorFilenames = nil
+	m.LocalityKVs = nil
+	return m
+}
+
+// compactionSink writes the merged keys of a compaction to SSTs of the
+// compacted backup.
+type compactionSink struct {
+	dest     cloud.ExternalStorage
+	enc      *roachpb.FileEncryptionOptions
+	id       base.SQLInstanceID
+	settings *cluster.Settings
+	codec    keys.SQLCodec
+	pkIDs    map[uint64]bool
+
+	sst         storage.SSTWriter
+	out         io.WriteCloser
+	outName     string
+	outChecksum *checksumWriter
+	size        int64
+
+	// files are the files of the compacted backup written so far.
+	files []BackupManifest_File
+}
+
+func (s *compactionSink) open(ctx context.Context) error {
+	s.outName = generateUniqueSSTName(s.id)
+	w, err := s.dest.Writer(ctx, s.outName)
+	if err != nil {
+		return err
+	}
+	s.outChecksum = newChecksumWriter(w)
+	w = s.outChecksum
+	if s.enc != nil {
+		if w, err = storageccl.EncryptingWriter(w, s.enc.Key); err != nil {
+			return err
+		}
+	}
+	s.out = w
+	s.sst = storage.MakeBackupSSTWriter(ctx, s.settings, s.out)
+	s.size = 0
+	return nil
+}
+
+// flush finishes the SST being written, if any.
+func (s *compactionSink) flush() error {
+	if s.out == nil {
+		return nil
+	}
+	if err := s.sst.Finish(); err != nil {
+		return err
+	}
+	if err := s.out.Close(); err != nil {
+		return errors.Wrap(err, "writing SST")
+	}
+	for i := len(s.files) - 1; i >= 0 && s.files[i].Path == s.outName; i-- {
+		s.files[i].Checksum = s.outChecksum.Sum32()
+	}
+	s.out = nil
+	s.outName = ""
+	return nil
+}
+
+// Close releases the resources of the sink. It does not flush it.
+func (s *compactionSink) Close() {
+	if s.out != nil {
+		s.sst.Close()
+		_ = s.out.Close()
+		s.out = nil
+	}
+}
+
+// write merges the keys of iter in the span of entry, as of endTime, into
+// the compacted backup.
+func (s *compactionSink) write(
+	ctx context.Context,
+	iter storage.SimpleMVCCIterator,
+	entry execinfrapb.RestoreSpanEntry,
+	endTime hlc.Timestamp,
+) error {
+	var summary roachpb.BulkOpSummary
+	startKeyMVCC, endKeyMVCC := storage.MVCCKey{Key: entry.Span.Key},
+		storage.MVCCKey{Key: entry.Span.EndKey}
+	for iter.SeekGE(startKeyMVCC); ; {
+		ok, err := iter.Valid()
+		if err != nil {
+			return err
+		}
+		if !ok || !iter.UnsafeKey().Less(endKeyMVCC) {
+			break
+		}
+		if endTime.Less(iter.UnsafeKey().Timestamp) {
+			iter.Next()
+			continue
+		}
+		if len(iter.UnsafeValue()) == 0 {
+			// Value is deleted.
+			iter.NextKey()
+			continue
+		}
+
+		if s.out == nil {
+			if err := s.open(ctx); err != nil {
+				return err
+			}
+		}
+		key, value := iter.UnsafeKey(), iter.UnsafeValue()
+		if err := s.sst.PutMVCC(key, value); err != nil {
+			return err
+		}
+		summary.DataSize += int64(len(key.Key) + len(value))
+		if _, tableID, indexID, err := s.codec.DecodeIndexPrefix(key.Key); err == nil {
+			if summary.EntryCounts == nil {
+				summary.EntryCounts = make(map[uint64]int64)
+			}
+			summary.EntryCounts[roachpb.BulkOpSummaryID(uint64(tableID), uint64(indexID))]++
+		}
+		iter.NextKey()
+	}
+	if summary.DataSize == 0 {
+		return nil
+	}
+
+	// Extend the last file if this entry picks up where it ended.
+	counts := countRows(summary, s.pkIDs)
+	if l := len(s.files) - 1; l >= 0 && s.files[l].Path == s.outName &&
+		s.files[l].Span.EndKey.Equal(entry.Span.Key) {
+		s.files[l].Span.EndKey = entry.Span.EndKey
+		s.files[l].EntryCounts.Add(counts)
+	} else {
+		s.files = append(s.files, BackupManifest_File{
+			Span:        entry.Span,
+			Path:        s.outName,
+			EntryCounts: counts,
+		})
+	}
+	s.size += summary.DataSize
+
+	// Entries of the cover end at key boundaries, so the file can be rotated
+	// after any of them.
+	if s.size > targetFileSize.Get(&s.settings.SV) {
+		log.VEventf(ctx, 2, "flushing compacted backup file %s with size %d", s.outName, s.size)
+		return s.flush()
+	}
+	return nil
+}
+
+// compact is the Resume implementation of the jobs created by COMPACT
+// BACKUP.
+func (b *backupResumer) compact(
+	ctx context.Context, p sql.JobExecContext, details jobspb.BackupDetails,
+) error {
+	execCfg := p.ExecCfg()
+	mkStore := execCfg.DistSQLSrv.ExternalStorageFromURI
+
+	dest, err := mkStore(ctx, details.URI, p.User())
+	if err != nil {
+		return errors.Wrapf(err, "failed to open backup storage location")
+	}
+	defer dest.Close()
+	// The manifest is written last, so if it exists a previous execution of
+	// this job completed the compaction.
+	if done, err := containsManifest(ctx, dest); err != nil {
+		return err
+	} else if done {
+		return nil
+	}
+
+	mem := execCfg.RootMemoryMonitor.MakeBoundAccount()
+	defer mem.Close(ctx)
+	manifests, memSize, err := loadBackupManifests(ctx, &mem, details.IncrementalFrom, p.User(),
+		mkStore, details.EncryptionOptions)
+	if err != nil {
+		return err
+	}
+	defer mem.Shrink(ctx, memSize)
+	if err := validateCompactionChain(manifests); err != nil {
+		return err
+	}
+	last := manifests[len(manifests)-1]
+
+	var enc *roachpb.FileEncryptionOptions
+	if details.EncryptionOptions != nil {
+		key, err := getEncryptionKey(ctx, details.EncryptionOptions, execCfg.Settings,
+			dest.ExternalIOConf())
+		if err != nil {
+			return err
+		}
+		enc = &roachpb.FileEncryptionOptions{Key: key}
+		// The compacted backup is encrypted with the same data key as the chain,
+		// so it shares the encryption info of the full backup.
+		if err := copyBackupFiles(ctx, mkStore, p, details.IncrementalFrom[0], dest,
+			getEncryptionInfoFiles); err != nil {
+			return errors.Wrap(err, "copying encryption info")
+		}
+	}
+
+	// Statistics files are read from the directory of the manifest referencing
+	// them, so copy those of the last layer.
+	if err := copyBackupFiles(ctx, mkStore, p, details.IncrementalFrom[len(manifests)-1], dest,
+		func(context.Context, cloud.ExternalStorage) ([]string, error) {
+			var names []string
+			for _, name := range last.StatisticsFilenames {
+				names = append(names, name)
+			}
+			return names, nil
+		}); err != nil {
+		return errors.Wrap(err, "copying table statistics")
+	}
+
+	pkIDs := make(map[uint64]bool)
+	for i := range last.Descriptors {
+		if t, _, _, _ := descpb.FromDescriptor(&last.Descriptors[i]); t != nil {
+			pkIDs[roachpb.BulkOpSummaryID(uint64(t.ID), uint64(t.PrimaryIndex.ID))] = true
+		}
+	}
+	sink := &compactionSink{
+		dest:     dest,
+		enc:      enc,
+		id:       execCfg.NodeID.SQLInstanceID(),
+		settings: execCfg.Settings,
+		codec:    execCfg.Codec,
+		pkIDs:    pkIDs,
+	}
+	defer sink.Close()
+
+	cover := makeSimpleImportSpans(last.Spans, manifests, nil /* backupLocalityMap */, nil /* lowWaterMark */)
+	for _, entry := range cover {
+		if err := compactSpanEntry(ctx, execCfg, sink, entry, enc, details.EndTime); err != nil {
+			return errors.Wrapf(err, "compacting span %s", entry.Span)
+		}
+	}
+	if err := sink.flush(); err != nil {
+		return err
+	}
+
+	compacted := makeCompactedManifest(last, sink.files)
+	if err := writeBackupManifest(ctx, execCfg.Settings, dest, backupManifestName,
+		details.EncryptionOptions, &compacted); err != nil {
+		return err
+	}
+	b.backupStats = compacted.EntryCounts
+
+	return maybeAdvanceLatestToCompactedBackup(ctx, p, details)
+}
+
+// compactSpanEntry merges the files of one entry of the cover of the chain
+// into the sink.
+func compactSpanEntry(
+	ctx context.Context,
+	execCfg *sql.ExecutorConfig,
+	sink *compactionSink,
+	entry execinfrapb.RestoreSpanEntry,
+	enc *roachpb.FileEncryptionOptions,
+	endTime hlc.Timestamp,
+) error {
+	var iters []storage.SimpleMVCCIterator
+	var dirs []cloud.ExternalStorage
+	defer func() {
+		for _, iter := range iters {
+			iter.Close()
+		}
+		for _, dir := range dirs {
+			if err := dir.Close(); err != nil {
+				log.Warningf(ctx, "close export storage failed %v", err)
+			}
+		}
+	}()
+	for _, file := range entry.Files {
+		dir, err := execCfg.DistSQLSrv.ExternalStorage(ctx, file.Dir)
+		if err != nil {
+			return err
+		}
+		dirs = append(dirs, dir)
+		iter, err := storageccl.ExternalSSTReader(ctx, dir, file.Path, enc)
+		if err != nil {
+			return err
+		}
+		iters = append(iters, iter)
+	}
+	iter := storage.MakeMultiIterator(iters)
+	defer iter.Close()
+	return sink.write(ctx, iter, entry, endTime)
+}
+
+// copyBackupFiles copies the files listed by fileNames from the backup at
+// srcURI to dest, as is.
+func copyBackupFiles(
+	ctx context.Context,
+	mkStore cloud.ExternalStorageFromURIFactory,
+	p sql.JobExecContext,
+	srcURI string,
+	dest cloud.ExternalStorage,
+	fileNames func(context.Context, cloud.ExternalStorage) ([]string, error),
+) error {
+	src, err := mkStore(ctx, srcURI, p.User())
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	names, err := fileNames(ctx, src)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if err := func() error {
+			r, err := src.ReadFile(ctx, name)
+			if err != nil {
+				return err
+			}
+			defer r.Close(ctx)
+			content, err := ioctx.ReadAll(ctx, r)
+			if err != nil {
+				return err
+			}
+			return cloud.WriteFile(ctx, dest, name, bytes.NewReader(content))
+		}(); err != nil {
+			return errors.Wrapf(err, "copying %s", name)
+		}
+	}
+	return nil
+}
+
+// maybeAdvanceLatestToCompactedBackup points the LATEST file of the
+// collection to the compacted backup if it pointed to the compacted chain,
+// so that subsequent incremental backups are appended to the compacted
+// backup.
+func maybeAdvanceLatestToCompactedBackup(
+	ctx context.Context, p sql.JobExecContext, details jobspb.BackupDetails,
+) error {
+	mkStore := p.ExecCfg().DistSQLSrv.ExternalStorageFromURI
+	latest, err := readLatestFile(ctx, details.CollectionURI, mkStore, p.User())
+	if err != nil {
+		log.Warningf(ctx, "not updating LATEST to compacted backup: %v", err)
+		return nil
+	}
+	if strings.TrimPrefix(latest, "/") != strings.TrimPrefix(details.Destination.Subdir, "/") {
+		return nil
+	}
+
+	backupURI, err := url.Parse(details.URI)
+	if err != nil {
+		return err
+	}
+	collectionURI, err := url.Parse(details.CollectionURI)
+	if err != nil {
+		return err
+	}
+	suffix := strings.TrimPrefix(path.Clean(backupURI.Path), path.Clean(collectionURI.Path))
+
+	c, err := mkStore(ctx, details.CollectionURI, p.User())
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	return writeNewLatestFile(ctx, p.ExecCfg().Settings, c, suffix)
+}
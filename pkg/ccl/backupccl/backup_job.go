@@ -402,6 +402,11 @@ func (b *backupResumer) Resume(ctx context.Context, execCtx interface{}) error {
 	details := b.job.Details().(jobspb.BackupDetails)
 	p := execCtx.(sql.JobExecContext)
 
+	// Compaction jobs only read from and write to external storage.
+	if details.Compact {
+		return b.compact(ctx, p, details)
+	}
+
 	var backupManifest *BackupManifest
 
 	// If planning didn't resolve the external destination, then we need to now.
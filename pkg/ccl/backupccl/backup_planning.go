@@ -72,6 +72,9 @@ const (
 	defaultLocalityValue      = "default"
 	backupOptDebugMetadataSST = "debug_dump_metadata_sst"
 	backupOptEncDir           = "encryption_info_dir"
+	backupOptCheckFiles       = "check_files"
+	backupOptCheckContents    = "check_file_contents"
+	backupOptDetached         = "detached"
 )
 
 type tableAndIndex struct {
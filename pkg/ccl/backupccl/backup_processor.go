@@ -618,6 +618,8 @@ type sstSink struct {
 	cancel  func()
 	out     io.WriteCloser
 	outName string
+	// outChecksum computes the checksum of the file being written to out.
+	outChecksum *checksumWriter
 
 	flushedFiles    []BackupManifest_File
 	flushedSize     int64
@@ -748,6 +750,9 @@ func (s *sstSink) flushFile(ctx context.Context) error {
 	}
 	s.outName = ""
 	s.out = nil
+	for i := range s.flushedFiles {
+		s.flushedFiles[i].Checksum = s.outChecksum.Sum32()
+	}
 
 	progDetails := BackupManifest_Progress{
 		RevStartTime:   s.flushedRevStart,
@@ -783,6 +788,8 @@ func (s *sstSink) open(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	s.outChecksum = newChecksumWriter(w)
+	w = s.outChecksum
 	if s.conf.enc != nil {
 		var err error
 		w, err = storageccl.EncryptingWriter(w, s.conf.enc.Key)
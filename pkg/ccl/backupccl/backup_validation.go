@@ -0,0 +1,396 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package backupccl
+
+import (
+	"context"
+	"hash"
+	"hash/crc32"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/storageccl"
+	"github.com/cockroachdb/cockroach/pkg/cloud"
+	"github.com/cockroachdb/cockroach/pkg/jobs"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/security/username"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/ioctx"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/errors"
+)
+
+// Backup validation checks the files referenced by the manifests of a backup
+// chain directly in external storage, without restoring anything. Every file
+// is read back to check that it exists and, if the manifest recorded one, that
+// its checksum matches. Optionally the SSTs are also iterated to check that
+// their keys are ordered, lie within the spans the manifest attributes to the
+// file and are no newer than the end time of the backup, and that their
+// values are valid.
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// checksumWriter computes the checksum of the bytes written through it, which
+// is recorded in the manifest entries of backup files.
+type checksumWriter struct {
+	io.WriteCloser
+	crc hash.Hash32
+}
+
+func newChecksumWriter(w io.WriteCloser) *checksumWriter {
+	return &checksumWriter{WriteCloser: w, crc: crc32.New(crc32cTable)}
+}
+
+// Write implements the io.Writer interface.
+func (w *checksumWriter) Write(p []byte) (int, error) {
+	n, err := w.WriteCloser.Write(p)
+	_, _ = w.crc.Write(p[:n])
+	return n, err
+}
+
+// Sum32 returns the checksum of the bytes written so far.
+func (w *checksumWriter) Sum32() uint32 {
+	return w.crc.Sum32()
+}
+
+// backupFileToCheck is a file referenced by a layer of a backup chain.
+type backupFileToCheck struct {
+	// uri is the URI of the directory storing the file.
+	uri string
+	// displayPath is the path of the file as reported to the user.
+	displayPath string
+	path        string
+	layer       int
+	checksum    uint32
+	// spans are the spans of all the manifest entries referencing the file.
+	spans   []roachpb.Span
+	endTime hlc.Timestamp
+}
+
+// collectBackupFiles returns the files referenced by the manifests of the
+// backup chain described by info, in the order they are first referenced.
+// manifestDirs, if non-nil, are the directories of the layers relative to
+// their collection, used to report the paths of the files.
+func collectBackupFiles(info backupInfo, manifestDirs []string) []backupFileToCheck {
+	var files []backupFileToCheck
+	for layer := range info.manifests {
+		m := &info.manifests[layer]
+		byPath := make(map[string]int)
+		for _, f := range m.Files {
+			uri := info.defaultURIs[layer]
+			if layer < len(info.localityInfo) {
+				if localityURI, ok := info.localityInfo[layer].URIsByOriginalLocalityKV[f.LocalityKV]; ok {
+					uri = localityURI
+				}
+			}
+			key := uri + "\x00" + f.Path
+			if i, ok := byPath[key]; ok {
+				files[i].spans = append(files[i].spans, f.Span)
+				continue
+			}
+			displayPath := f.Path
+			if manifestDirs != nil {
+				displayPath = path.Join(manifestDirs[layer], f.Path)
+			}
+			byPath[key] = len(files)
+			files = append(files, backupFileToCheck{
+				uri:         uri,
+				displayPath: displayPath,
+				path:        f.Path,
+				layer:       layer,
+				checksum:    f.Checksum,
+				spans:       []roachpb.Span{f.Span},
+				endTime:     m.EndTime,
+			})
+		}
+	}
+	return files
+}
+
+// checkBackupFiles checks the given files, calling onChecked after each of
+// them with the error it failed validation with, if any. The files are read
+// from external storage, decrypted with enc if they are checked with
+// checkContents.
+func checkBackupFiles(
+	ctx context.Context,
+	mkStore cloud.ExternalStorageFromURIFactory,
+	user username.SQLUsername,
+	files []backupFileToCheck,
+	enc *roachpb.FileEncryptionOptions,
+	checkContents bool,
+	onChecked func(f backupFileToCheck, checkErr error) error,
+) error {
+	stores := make(map[string]cloud.ExternalStorage)
+	defer func() {
+		for _, store := range stores {
+			if err := store.Close(); err != nil {
+				log.Warningf(ctx, "close export storage failed %v", err)
+			}
+		}
+	}()
+	for _, f := range files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		store, ok := stores[f.uri]
+		if !ok {
+			var err error
+			if store, err = mkStore(ctx, f.uri, user); err != nil {
+				return errors.Wrapf(err, "make storage")
+			}
+			stores[f.uri] = store
+		}
+		checkErr := checkBackupFile(ctx, store, f, enc, checkContents)
+		if checkErr != nil && ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := onChecked(f, checkErr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkBackupFile checks one file of a backup chain.
+func checkBackupFile(
+	ctx context.Context,
+	store cloud.ExternalStorage,
+	f backupFileToCheck,
+	enc *roachpb.FileEncryptionOptions,
+	checkContents bool,
+) error {
+	checksum, err := func() (uint32, error) {
+		r, err := store.ReadFile(ctx, f.path)
+		if err != nil {
+			return 0, err
+		}
+		defer r.Close(ctx)
+		crc := crc32.New(crc32cTable)
+		if _, err := io.Copy(crc, ioctx.ReaderCtxAdapter(ctx, r)); err != nil {
+			return 0, errors.Wrap(err, "reading file")
+		}
+		return crc.Sum32(), nil
+	}()
+	if err != nil {
+		return err
+	}
+	// Files of backups taken before checksums were recorded have none.
+	if f.checksum != 0 && checksum != f.checksum {
+		return errors.Newf("checksum mismatch: manifest has %08x but file has %08x",
+			f.checksum, checksum)
+	}
+	if !checkContents {
+		return nil
+	}
+	return checkBackupSST(ctx, store, f, enc)
+}
+
+// checkBackupSST iterates the keys of the SST of a backup file, checking
+// them against the manifest entries referencing the file.
+func checkBackupSST(
+	ctx context.Context,
+	store cloud.ExternalStorage,
+	f backupFileToCheck,
+	enc *roachpb.FileEncryptionOptions,
+) error {
+	iter, err := storageccl.ExternalSSTReader(ctx, store, f.path, enc)
+	if err != nil {
+		return errors.Wrap(err, "opening SST")
+	}
+	defer iter.Close()
+
+	var prev storage.MVCCKey
+	first := true
+	for iter.SeekGE(storage.MVCCKey{Key: keys.MinKey}); ; iter.Next() {
+		ok, err := iter.Valid()
+		if err != nil {
+			return errors.Wrap(err, "reading SST")
+		}
+		if !ok {
+			return nil
+		}
+		key := iter.UnsafeKey()
+		if !first && !prev.Less(key) {
+			return errors.Newf("key %s is not ordered after the previous key %s", key, prev)
+		}
+		if !spansContainKey(f.spans, key.Key) {
+			return errors.Newf("key %s is outside of the spans of the file in the manifest", key)
+		}
+		if f.endTime.Less(key.Timestamp) {
+			return errors.Newf("key %s is newer than the end time %s of the backup", key, f.endTime)
+		}
+		if err := (roachpb.Value{RawBytes: iter.UnsafeValue()}).Verify(key.Key); err != nil {
+			return errors.Wrapf(err, "invalid value for key %s", key)
+		}
+		prev.Key = append(prev.Key[:0], key.Key...)
+		prev.Timestamp = key.Timestamp
+		first = false
+	}
+}
+
+func spansContainKey(spans []roachpb.Span, key roachpb.Key) bool {
+	for _, sp := range spans {
+		if sp.ContainsKey(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// backupFileEncryption returns the options to decrypt the files of a backup
+// encrypted with the given options, if any.
+func backupFileEncryption(
+	ctx context.Context, encryption *jobspb.BackupEncryptionOptions, execCfg *sql.ExecutorConfig,
+) (*roachpb.FileEncryptionOptions, error) {
+	if encryption == nil {
+		return nil, nil
+	}
+	key, err := getEncryptionKey(ctx, encryption, execCfg.Settings, execCfg.ExternalIODirConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &roachpb.FileEncryptionOptions{Key: key}, nil
+}
+
+// maxReportedValidationFailures is the number of failures of a backup
+// validation job reported in its error.
+const maxReportedValidationFailures = 10
+
+// validationProgressInterval is the minimum interval between updates of the
+// progress of a backup validation job, other than to record failures.
+const validationProgressInterval = 10 * time.Second
+
+type backupValidationResumer struct {
+	job *jobs.Job
+}
+
+var _ jobs.Resumer = &backupValidationResumer{}
+
+// Resume implements the jobs.Resumer interface.
+func (r *backupValidationResumer) Resume(ctx context.Context, execCtx interface{}) error {
+	p := execCtx.(sql.JobExecContext)
+	execCfg := p.ExecCfg()
+	details := r.job.Details().(jobspb.BackupValidationDetails)
+	progress := r.job.Progress().Details.(*jobspb.Progress_BackupValidation).BackupValidation
+	mkStore := execCfg.DistSQLSrv.ExternalStorageFromURI
+
+	mem := execCfg.RootMemoryMonitor.MakeBoundAccount()
+	defer mem.Close(ctx)
+	manifests, memSize, err := loadBackupManifests(ctx, &mem, details.URIs, p.User(), mkStore,
+		details.EncryptionOptions)
+	if err != nil {
+		return err
+	}
+	defer mem.Shrink(ctx, memSize)
+
+	info := backupInfo{
+		defaultURIs:  details.URIs,
+		manifests:    manifests,
+		subdir:       details.Subdir,
+		localityInfo: details.BackupLocalityInfo,
+	}
+	var manifestDirs []string
+	if details.Subdir != "" {
+		if manifestDirs, err = getManifestDirs(details.Subdir, details.URIs); err != nil {
+			return err
+		}
+	}
+	files := collectBackupFiles(info, manifestDirs)
+	enc, err := backupFileEncryption(ctx, details.EncryptionOptions, execCfg)
+	if err != nil {
+		return err
+	}
+
+	// Skip the files checked by a previous execution of the job, whose
+	// failures are recorded in its progress.
+	checked := progress.FilesChecked
+	if checked > int64(len(files)) {
+		checked = int64(len(files))
+	}
+	failures := progress.Failures
+	lastUpdate := timeutil.Now()
+	updateProgress := func() error {
+		fraction := float32(1)
+		if len(files) > 0 {
+			fraction = float32(checked) / float32(len(files))
+		}
+		lastUpdate = timeutil.Now()
+		return r.job.FractionProgressed(ctx, nil, /* txn */
+			func(ctx context.Context, details jobspb.ProgressDetails) float32 {
+				prog := details.(*jobspb.Progress_BackupValidation).BackupValidation
+				prog.FilesChecked = checked
+				prog.Failures = failures
+				return fraction
+			})
+	}
+
+	if err := checkBackupFiles(ctx, mkStore, p.User(), files[checked:], enc, details.CheckContents,
+		func(f backupFileToCheck, checkErr error) error {
+			checked++
+			if checkErr != nil {
+				log.Warningf(ctx, "backup file %s failed validation: %v", f.displayPath, checkErr)
+				failures = append(failures, jobspb.BackupValidationProgress_Failure{
+					Path:  f.displayPath,
+					Error: checkErr.Error(),
+				})
+				return updateProgress()
+			}
+			if timeutil.Since(lastUpdate) > validationProgressInterval {
+				return updateProgress()
+			}
+			return nil
+		}); err != nil {
+		return err
+	}
+	if err := updateProgress(); err != nil {
+		return err
+	}
+
+	if len(failures) == 0 {
+		return nil
+	}
+	var buf strings.Builder
+	for i, failure := range failures {
+		if i == maxReportedValidationFailures {
+			buf.WriteString("\n...")
+			break
+		}
+		buf.WriteString("\n")
+		buf.WriteString(failure.Path)
+		buf.WriteString(": ")
+		buf.WriteString(failure.Error)
+	}
+	return jobs.MarkAsPermanentJobError(errors.Newf("%d of %d files of the backup failed validation:%s",
+		len(failures), len(files), buf.String()))
+}
+
+// OnFailOrCancel implements the jobs.Resumer interface. Validation does not
+// write anything, so there is nothing to clean up.
+func (r *backupValidationResumer) OnFailOrCancel(context.Context, interface{}) error {
+	return nil
+}
+
+func init() {
+	jobs.RegisterConstructor(
+		jobspb.TypeBackupValidation,
+		func(job *jobs.Job, _ *cluster.Settings) jobs.Resumer {
+			return &backupValidationResumer{
+				job: job,
+			}
+		},
+	)
+}
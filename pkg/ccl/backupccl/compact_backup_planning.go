@@ -0,0 +1,319 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package backupccl
+
+import (
+	"context"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/cloud"
+	"github.com/cockroachdb/cockroach/pkg/featureflag"
+	"github.com/cockroachdb/cockroach/pkg/jobs"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/colinfo"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/tracing"
+	"github.com/cockroachdb/errors"
+)
+
+const compactBackupOp = "COMPACT BACKUP"
+
+func compactBackupPlanHook(
+	ctx context.Context, stmt tree.Statement, p sql.PlanHookState,
+) (sql.PlanHookRowFn, colinfo.ResultColumns, []sql.PlanNode, bool, error) {
+	compactStmt, ok := stmt.(*tree.CompactBackup)
+	if !ok {
+		return nil, nil, nil, false, nil
+	}
+
+	if err := featureflag.CheckEnabled(
+		ctx,
+		p.ExecCfg(),
+		featureBackupEnabled,
+		compactBackupOp,
+	); err != nil {
+		return nil, nil, nil, false, err
+	}
+
+	if compactStmt.Options.CaptureRevisionHistory {
+		return nil, nil, nil, false, errors.Newf(
+			"%s option is not supported with %s", backupOptRevisionHistory, compactBackupOp)
+	}
+
+	subdirFn, err := p.TypeAsString(ctx, compactStmt.Subdir, compactBackupOp)
+	if err != nil {
+		return nil, nil, nil, false, err
+	}
+	collectionFn, err := p.TypeAsString(ctx, compactStmt.Collection, compactBackupOp)
+	if err != nil {
+		return nil, nil, nil, false, err
+	}
+	incToFn, err := p.TypeAsStringArray(ctx, tree.Exprs(compactStmt.Options.IncrementalStorage),
+		compactBackupOp)
+	if err != nil {
+		return nil, nil, nil, false, err
+	}
+
+	encryptionParams := jobspb.BackupEncryptionOptions{Mode: jobspb.EncryptionMode_None}
+	var pwFn func() (string, error)
+	if compactStmt.Options.EncryptionPassphrase != nil {
+		pwFn, err = p.TypeAsString(ctx, compactStmt.Options.EncryptionPassphrase, compactBackupOp)
+		if err != nil {
+			return nil, nil, nil, false, err
+		}
+		encryptionParams.Mode = jobspb.EncryptionMode_Passphrase
+	}
+	var kmsFn func() ([]string, error)
+	if compactStmt.Options.EncryptionKMSURI != nil {
+		if encryptionParams.Mode != jobspb.EncryptionMode_None {
+			return nil, nil, nil, false,
+				errors.New("cannot have both encryption_passphrase and kms option set")
+		}
+		kmsFn, err = p.TypeAsStringArray(ctx, tree.Exprs(compactStmt.Options.EncryptionKMSURI),
+			compactBackupOp)
+		if err != nil {
+			return nil, nil, nil, false, err
+		}
+		encryptionParams.Mode = jobspb.EncryptionMode_KMS
+	}
+
+	fn := func(ctx context.Context, _ []sql.PlanNode, resultsCh chan<- tree.Datums) error {
+		ctx, span := tracing.ChildSpan(ctx, stmt.StatementTag())
+		defer span.Finish()
+
+		if !(p.ExtendedEvalContext().TxnIsSingleStmt || compactStmt.Options.Detached) {
+			return errors.Errorf("%s cannot be used inside a multi-statement transaction without DETACHED option",
+				compactBackupOp)
+		}
+
+		// Compaction reads and writes entire backups, regardless of what they
+		// contain, so it is restricted to admins.
+		if err := p.RequireAdminRole(ctx, compactBackupOp); err != nil {
+			return err
+		}
+		if err := requireEnterprise(p.ExecCfg(), "compaction"); err != nil {
+			return err
+		}
+
+		subdir, err := subdirFn()
+		if err != nil {
+			return err
+		}
+		collection, err := collectionFn()
+		if err != nil {
+			return err
+		}
+		incrementalStorage, err := incToFn()
+		if err != nil {
+			return err
+		}
+		switch encryptionParams.Mode {
+		case jobspb.EncryptionMode_Passphrase:
+			if encryptionParams.RawPassphrae, err = pwFn(); err != nil {
+				return err
+			}
+		case jobspb.EncryptionMode_KMS:
+			if encryptionParams.RawKmsUris, err = kmsFn(); err != nil {
+				return err
+			}
+		}
+
+		details, err := resolveCompactionDetails(
+			ctx, p, collection, subdir, incrementalStorage, encryptionParams)
+		if err != nil {
+			return err
+		}
+
+		description, err := compactBackupJobDescription(
+			details, incrementalStorage, encryptionParams.RawKmsUris, compactStmt.Options)
+		if err != nil {
+			return err
+		}
+		jobID := p.ExecCfg().JobRegistry.MakeJobID()
+		jr := jobs.Record{
+			Description: description,
+			Details:     details,
+			Progress:    jobspb.BackupProgress{},
+			Username:    p.User(),
+		}
+		plannerTxn := p.Txn()
+
+		if compactStmt.Options.Detached {
+			if _, err := p.ExecCfg().JobRegistry.CreateAdoptableJobWithTxn(
+				ctx, jr, jobID, plannerTxn); err != nil {
+				return err
+			}
+			resultsCh <- tree.Datums{tree.NewDInt(tree.DInt(jobID))}
+			return nil
+		}
+
+		var sj *jobs.StartableJob
+		if err := func() (err error) {
+			defer func() {
+				if err == nil || sj == nil {
+					return
+				}
+				if cleanupErr := sj.CleanupOnRollback(ctx); cleanupErr != nil {
+					log.Errorf(ctx, "failed to cleanup job: %v", cleanupErr)
+				}
+			}()
+			if err := p.ExecCfg().JobRegistry.CreateStartableJobWithTxn(ctx, &sj, jobID, plannerTxn, jr); err != nil {
+				return err
+			}
+			// We commit the transaction here so that the job can be started. This
+			// is safe because we're in an implicit transaction. If we were in an
+			// explicit transaction the job would have to be run with the detached
+			// option and would have been handled above.
+			return plannerTxn.Commit(ctx)
+		}(); err != nil {
+			return err
+		}
+		if err := sj.Start(ctx); err != nil {
+			return err
+		}
+		if err := sj.AwaitCompletion(ctx); err != nil {
+			return err
+		}
+		return sj.ReportExecutionResults(ctx, resultsCh)
+	}
+
+	if compactStmt.Options.Detached {
+		return fn, jobs.DetachedJobExecutionResultHeader, nil, false, nil
+	}
+	return fn, jobs.BulkJobExecutionResultHeader, nil, false, nil
+}
+
+// resolveCompactionDetails resolves the layers of the backup chain in subdir
+// of the collection, and the destination of the compacted backup, into the
+// details of a compaction job.
+func resolveCompactionDetails(
+	ctx context.Context,
+	p sql.PlanHookState,
+	collection string,
+	subdir string,
+	incrementalStorage []string,
+	encryptionParams jobspb.BackupEncryptionOptions,
+) (jobspb.BackupDetails, error) {
+	execCfg := p.ExecCfg()
+	mkStore := execCfg.DistSQLSrv.ExternalStorageFromURI
+
+	if strings.EqualFold(subdir, latestFileName) {
+		latest, err := readLatestFile(ctx, collection, mkStore, p.User())
+		if err != nil {
+			return jobspb.BackupDetails{}, err
+		}
+		subdir = latest
+	}
+	subdir = "/" + strings.TrimPrefix(subdir, "/")
+
+	fullyResolvedBaseDirectory, err := appendPaths([]string{collection}, subdir)
+	if err != nil {
+		return jobspb.BackupDetails{}, err
+	}
+	fullyResolvedIncrementalsDirectory, err := resolveIncrementalsBackupLocation(
+		ctx, p.User(), execCfg, incrementalStorage, []string{collection}, subdir)
+	if err != nil {
+		return jobspb.BackupDetails{}, err
+	}
+
+	baseStore, err := mkStore(ctx, fullyResolvedBaseDirectory[0], p.User())
+	if err != nil {
+		return jobspb.BackupDetails{}, errors.Wrapf(err, "failed to open backup storage location")
+	}
+	defer baseStore.Close()
+
+	encryption, err := getEncryptionFromBase(ctx, p.User(), mkStore, fullyResolvedBaseDirectory[0],
+		encryptionParams, &backupKMSEnv{settings: execCfg.Settings, conf: &execCfg.ExternalIODirConfig})
+	if err != nil {
+		return jobspb.BackupDetails{}, err
+	}
+
+	mem := execCfg.RootMemoryMonitor.MakeBoundAccount()
+	defer mem.Close(ctx)
+	defaultURIs, manifests, _, memReserved, err := resolveBackupManifests(
+		ctx, &mem, []cloud.ExternalStorage{baseStore}, mkStore, fullyResolvedBaseDirectory,
+		fullyResolvedIncrementalsDirectory, hlc.Timestamp{}, encryption, p.User(),
+	)
+	if err != nil {
+		return jobspb.BackupDetails{}, err
+	}
+	defer mem.Shrink(ctx, memReserved)
+
+	if err := validateCompactionChain(manifests); err != nil {
+		return jobspb.BackupDetails{}, err
+	}
+
+	// The compacted backup is a full backup of the collection, named after its
+	// end time like any other full backup.
+	endTime := manifests[len(manifests)-1].EndTime
+	destination, err := appendPaths([]string{collection}, endTime.GoTime().Format(DateBasedIntoFolderName))
+	if err != nil {
+		return jobspb.BackupDetails{}, err
+	}
+	destStore, err := mkStore(ctx, destination[0], p.User())
+	if err != nil {
+		return jobspb.BackupDetails{}, errors.Wrapf(err, "failed to open backup storage location")
+	}
+	defer destStore.Close()
+	if err := checkForPreviousBackup(ctx, destStore, destination[0]); err != nil {
+		return jobspb.BackupDetails{}, err
+	}
+
+	return jobspb.BackupDetails{
+		Destination: jobspb.BackupDetails_Destination{
+			To:                 []string{collection},
+			Subdir:             subdir,
+			IncrementalStorage: incrementalStorage,
+			Exists:             true,
+		},
+		EndTime:           endTime,
+		URI:               destination[0],
+		CollectionURI:     collection,
+		EncryptionOptions: encryption,
+		IncrementalFrom:   defaultURIs,
+		FullCluster:       manifests[0].DescriptorCoverage == tree.AllDescriptors,
+		Compact:           true,
+	}, nil
+}
+
+// compactBackupJobDescription renders the COMPACT BACKUP statement of a
+// compaction job, with its URIs sanitized.
+func compactBackupJobDescription(
+	details jobspb.BackupDetails,
+	incrementalStorage []string,
+	kmsURIs []string,
+	opts tree.BackupOptions,
+) (string, error) {
+	collection, err := cloud.SanitizeExternalStorageURI(details.CollectionURI, nil /* extraParams */)
+	if err != nil {
+		return "", err
+	}
+	n := &tree.CompactBackup{
+		Subdir:     tree.NewDString(details.Destination.Subdir),
+		Collection: tree.NewDString(collection),
+		Options: tree.BackupOptions{
+			EncryptionPassphrase: opts.EncryptionPassphrase,
+			Detached:             opts.Detached,
+		},
+	}
+	if n.Options.EncryptionKMSURI, err = sanitizeURIList(kmsURIs); err != nil {
+		return "", err
+	}
+	if n.Options.IncrementalStorage, err = sanitizeURIList(incrementalStorage); err != nil {
+		return "", err
+	}
+	return tree.AsString(n), nil
+}
+
+func init() {
+	sql.AddPlanHook("compact backup", compactBackupPlanHook)
+}
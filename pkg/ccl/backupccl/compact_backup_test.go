@@ -0,0 +1,141 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package backupccl
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCompactBackup checks that a chain of backups compacted by COMPACT
+// BACKUP restores to the same data as the chain, and that incremental backups
+// can be appended to the compacted backup.
+func TestCompactBackup(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	const numAccounts = 100
+	_, sqlDB, _, cleanupFn := backupRestoreTestSetup(t, singleNode, numAccounts, InitManualReplication)
+	defer cleanupFn()
+
+	const collection = "nodelocal://0/compact"
+	sqlDB.Exec(t, `BACKUP DATABASE data INTO $1`, collection)
+
+	sqlDB.Exec(t, `UPDATE data.bank SET balance = balance + 1 WHERE id < 10`)
+	sqlDB.Exec(t, `DELETE FROM data.bank WHERE id >= 90`)
+	sqlDB.Exec(t, `BACKUP DATABASE data INTO LATEST IN $1`, collection)
+
+	sqlDB.Exec(t, `INSERT INTO data.bank VALUES (95, 95, 'reinserted'), (200, 200, 'new')`)
+	sqlDB.Exec(t, `CREATE TABLE data.other AS SELECT id FROM data.bank WHERE id < 5`)
+	sqlDB.Exec(t, `BACKUP DATABASE data INTO LATEST IN $1`, collection)
+
+	sqlDB.Exec(t, `COMPACT BACKUP 'LATEST' IN $1`, collection)
+
+	// The collection now holds the compacted backup too, which LATEST points to.
+	sqlDB.CheckQueryResults(t, `SELECT count(*) FROM [SHOW BACKUPS IN $1]`, [][]string{{"2"}}, collection)
+	var latest string
+	sqlDB.QueryRow(t, `SELECT path FROM [SHOW BACKUPS IN $1] ORDER BY path DESC LIMIT 1`, collection).Scan(&latest)
+	sqlDB.CheckQueryResults(t,
+		`SELECT DISTINCT backup_type FROM [SHOW BACKUP $1 IN $2]`, [][]string{{"full"}}, latest, collection)
+
+	sqlDB.Exec(t, `RESTORE DATABASE data FROM LATEST IN $1 WITH new_db_name = 'compacted'`, collection)
+	for _, table := range []string{"bank", "other"} {
+		sqlDB.CheckQueryResults(t, `SELECT * FROM compacted.`+table+` ORDER BY 1`,
+			sqlDB.QueryStr(t, `SELECT * FROM data.`+table+` ORDER BY 1`))
+	}
+
+	// Incremental backups are appended to the compacted backup.
+	sqlDB.Exec(t, `DELETE FROM data.bank WHERE id < 5`)
+	sqlDB.Exec(t, `BACKUP DATABASE data INTO LATEST IN $1`, collection)
+	sqlDB.Exec(t, `RESTORE DATABASE data FROM LATEST IN $1 WITH new_db_name = 'appended'`, collection)
+	sqlDB.CheckQueryResults(t, `SELECT * FROM appended.bank ORDER BY 1`,
+		sqlDB.QueryStr(t, `SELECT * FROM data.bank ORDER BY 1`))
+
+	// The chain of the compacted backup can be compacted again.
+	sqlDB.Exec(t, `COMPACT BACKUP $1 IN $2`, latest, collection)
+	sqlDB.CheckQueryResults(t, `SELECT count(*) FROM [SHOW BACKUPS IN $1]`, [][]string{{"3"}}, collection)
+}
+
+func TestCompactBackupErrors(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	const numAccounts = 1
+	_, sqlDB, _, cleanupFn := backupRestoreTestSetup(t, singleNode, numAccounts, InitManualReplication)
+	defer cleanupFn()
+
+	const collection = "nodelocal://0/compact"
+	sqlDB.Exec(t, `BACKUP DATABASE data INTO $1`, collection)
+	sqlDB.ExpectErr(t, "backup has no incremental backups to compact",
+		`COMPACT BACKUP 'LATEST' IN $1`, collection)
+	sqlDB.ExpectErr(t, "revision_history option is not supported with COMPACT BACKUP",
+		`COMPACT BACKUP 'LATEST' IN $1 WITH revision_history`, collection)
+
+	sqlDB.Exec(t, `BACKUP DATABASE data INTO $1 WITH encryption_passphrase = 'abc'`, "nodelocal://0/encrypted")
+	sqlDB.Exec(t, `BACKUP DATABASE data INTO LATEST IN $1 WITH encryption_passphrase = 'abc'`, "nodelocal://0/encrypted")
+	sqlDB.ExpectErr(t, "file appears encrypted",
+		`COMPACT BACKUP 'LATEST' IN $1`, "nodelocal://0/encrypted")
+	sqlDB.Exec(t, `COMPACT BACKUP 'LATEST' IN $1 WITH encryption_passphrase = 'abc'`, "nodelocal://0/encrypted")
+	sqlDB.Exec(t, `RESTORE DATABASE data FROM LATEST IN $1 WITH encryption_passphrase = 'abc', new_db_name = 'decrypted'`,
+		"nodelocal://0/encrypted")
+}
+
+func TestValidateCompactionChain(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ts := func(wall int64) hlc.Timestamp { return hlc.Timestamp{WallTime: wall} }
+	full := BackupManifest{EndTime: ts(1)}
+	inc1 := BackupManifest{StartTime: ts(1), EndTime: ts(2)}
+	inc2 := BackupManifest{StartTime: ts(2), EndTime: ts(3)}
+
+	require.NoError(t, validateCompactionChain([]BackupManifest{full, inc1, inc2}))
+	require.EqualError(t, validateCompactionChain([]BackupManifest{full}),
+		"backup has no incremental backups to compact")
+	require.EqualError(t, validateCompactionChain([]BackupManifest{inc1, inc2}),
+		"first layer of the backup chain is not a full backup")
+	require.Regexp(t, "backup chain is not contiguous: layer 1",
+		validateCompactionChain([]BackupManifest{full, inc2}))
+
+	partitioned := inc1
+	partitioned.LocalityKVs = []string{"region=east"}
+	require.EqualError(t, validateCompactionChain([]BackupManifest{full, partitioned}),
+		"compaction of locality-aware backups is not supported")
+}
+
+func TestMakeCompactedManifest(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	last := BackupManifest{
+		StartTime:       hlc.Timestamp{WallTime: 1},
+		EndTime:         hlc.Timestamp{WallTime: 2},
+		MVCCFilter:      MVCCFilter_All,
+		IntroducedSpans: []roachpb.Span{{Key: roachpb.Key("a"), EndKey: roachpb.Key("b")}},
+		Spans:           []roachpb.Span{{Key: roachpb.Key("a"), EndKey: roachpb.Key("z")}},
+		Files:           []BackupManifest_File{{Path: "data/1.sst"}},
+		EntryCounts:     roachpb.RowCount{Rows: 1},
+	}
+	files := []BackupManifest_File{
+		{Path: "data/2.sst", EntryCounts: roachpb.RowCount{DataSize: 10, Rows: 2}},
+		{Path: "data/3.sst", EntryCounts: roachpb.RowCount{DataSize: 5, IndexEntries: 1}},
+	}
+	m := makeCompactedManifest(last, files)
+	require.False(t, m.isIncremental())
+	require.Equal(t, last.EndTime, m.EndTime)
+	require.Equal(t, MVCCFilter_Latest, m.MVCCFilter)
+	require.Equal(t, last.Spans, m.Spans)
+	require.Empty(t, m.IntroducedSpans)
+	require.Equal(t, files, m.Files)
+	require.Equal(t, roachpb.RowCount{DataSize: 15, Rows: 2, IndexEntries: 1}, m.EntryCounts)
+	require.NotEqual(t, last.ID, m.ID)
+}
@@ -0,0 +1,87 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package backupccl
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/cloud/vault"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+// TestEncryptedBackupVaultTransitKMS checks that a backup encrypted with a
+// vault-transit KMS URI can be restored with it, using a stand-in for the
+// Vault transit engine.
+func TestEncryptedBackupVaultTransitKMS(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	// The stand-in "encrypts" data keys by tagging them with the key name,
+	// which is enough to check that the right key is used for decryption.
+	const token = "s.test"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]string
+		if r.Header.Get("X-Vault-Token") != token || json.NewDecoder(r.Body).Decode(&req) != nil {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		var data map[string]string
+		switch op, key := splitTransitPath(r.URL.Path); op {
+		case "encrypt":
+			data = map[string]string{"ciphertext": "vault:v1:" + key + ":" + req["plaintext"]}
+		case "decrypt":
+			prefix := "vault:v1:" + key + ":"
+			if !strings.HasPrefix(req["ciphertext"], prefix) {
+				w.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(w).Encode(map[string][]string{"errors": {"wrong key"}})
+				return
+			}
+			data = map[string]string{"plaintext": strings.TrimPrefix(req["ciphertext"], prefix)}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	}))
+	defer srv.Close()
+
+	kmsURI := func(key string) string {
+		q := url.Values{vault.VaultAddrParam: {srv.URL}, vault.VaultTokenParam: {token}}
+		return fmt.Sprintf("vault-transit:///%s?%s", key, q.Encode())
+	}
+
+	const numAccounts = 10
+	_, sqlDB, _, cleanupFn := backupRestoreTestSetup(t, singleNode, numAccounts, InitManualReplication)
+	defer cleanupFn()
+
+	const collection = "nodelocal://0/vault"
+	sqlDB.Exec(t, `BACKUP DATABASE data INTO $1 WITH kms = $2`, collection, kmsURI("backups"))
+	sqlDB.ExpectErr(t, "file appears encrypted",
+		`RESTORE DATABASE data FROM LATEST IN $1 WITH new_db_name = 'restored'`, collection)
+	sqlDB.ExpectErr(t, "one of the provided URIs was not used when encrypting the base BACKUP",
+		`RESTORE DATABASE data FROM LATEST IN $1 WITH kms = $2, new_db_name = 'restored'`,
+		collection, kmsURI("other"))
+	sqlDB.Exec(t, `RESTORE DATABASE data FROM LATEST IN $1 WITH kms = $2, new_db_name = 'restored'`,
+		collection, kmsURI("backups"))
+	sqlDB.CheckQueryResults(t, `SELECT * FROM restored.bank ORDER BY id`,
+		sqlDB.QueryStr(t, `SELECT * FROM data.bank ORDER BY id`))
+}
+
+// splitTransitPath splits a /v1/transit/{op}/{key} request path.
+func splitTransitPath(path string) (op, key string) {
+	parts := strings.Split(strings.TrimPrefix(path, "/v1/transit/"), "/")
+	if len(parts) != 2 {
+		return "", ""
+	}
+	return parts[0], parts[1]
+}
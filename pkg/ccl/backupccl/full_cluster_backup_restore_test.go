@@ -675,6 +675,7 @@ func TestClusterRestoreFailCleanup(t *testing.T) {
 				{"comments"},
 				{"database_role_settings"},
 				{"jobs"},
+				{"ldap_role_members"},
 				{"locations"},
 				{"role_members"},
 				{"role_options"},
@@ -766,6 +767,7 @@ func TestClusterRestoreFailCleanup(t *testing.T) {
 				{"comments"},
 				{"database_role_settings"},
 				{"jobs"},
+				{"ldap_role_members"},
 				{"locations"},
 				{"role_members"},
 				{"role_options"},
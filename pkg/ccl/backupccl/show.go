@@ -16,6 +16,7 @@ import (
 
 	"github.com/cockroachdb/cockroach/pkg/ccl/storageccl"
 	"github.com/cockroachdb/cockroach/pkg/cloud"
+	"github.com/cockroachdb/cockroach/pkg/jobs"
 	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
 	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
@@ -183,6 +184,118 @@ func (m metadataSSTInfoReader) showBackup(
 	return nil
 }
 
+// fileCheckInfoReader checks the files of a backup instead of showing its
+// contents, reporting the files that fail validation, or starts a job
+// checking them if detached.
+type fileCheckInfoReader struct {
+	p             sql.PlanHookState
+	inCollection  bool
+	checkContents bool
+	detached      bool
+}
+
+var _ backupInfoReader = fileCheckInfoReader{}
+
+func (f fileCheckInfoReader) header() colinfo.ResultColumns {
+	if f.detached {
+		return jobs.DetachedJobExecutionResultHeader
+	}
+	return colinfo.ResultColumns{
+		{Name: "path", Typ: types.String},
+		{Name: "backup_type", Typ: types.String},
+		{Name: "error", Typ: types.String},
+	}
+}
+
+func (f fileCheckInfoReader) showBackup(
+	ctx context.Context,
+	mem *mon.BoundAccount,
+	mkStore cloud.ExternalStorageFromURIFactory,
+	info backupInfo,
+	user username.SQLUsername,
+	resultsCh chan<- tree.Datums,
+) error {
+	if f.detached {
+		return f.createValidationJob(ctx, info, resultsCh)
+	}
+	var manifestDirs []string
+	if f.inCollection {
+		var err error
+		if manifestDirs, err = getManifestDirs(info.subdir, info.defaultURIs); err != nil {
+			return err
+		}
+	}
+	enc, err := backupFileEncryption(ctx, info.enc, f.p.ExecCfg())
+	if err != nil {
+		return err
+	}
+	files := collectBackupFiles(info, manifestDirs)
+	return checkBackupFiles(ctx, mkStore, user, files, enc, f.checkContents,
+		func(file backupFileToCheck, checkErr error) error {
+			if checkErr == nil {
+				return nil
+			}
+			backupType := "full"
+			if info.manifests[file.layer].isIncremental() {
+				backupType = "incremental"
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case resultsCh <- tree.Datums{
+				tree.NewDString(file.displayPath),
+				tree.NewDString(backupType),
+				tree.NewDString(checkErr.Error()),
+			}:
+				return nil
+			}
+		})
+}
+
+// createValidationJob creates a job checking the files of the backup.
+func (f fileCheckInfoReader) createValidationJob(
+	ctx context.Context, info backupInfo, resultsCh chan<- tree.Datums,
+) error {
+	details := jobspb.BackupValidationDetails{
+		URIs:               info.defaultURIs,
+		BackupLocalityInfo: info.localityInfo,
+		EncryptionOptions:  info.enc,
+		CheckContents:      f.checkContents,
+	}
+	if f.inCollection {
+		details.Subdir = info.subdir
+	}
+
+	uri, err := cloud.SanitizeExternalStorageURI(info.defaultURIs[0], nil /* extraParams */)
+	if err != nil {
+		return err
+	}
+	opts := tree.KVOptions{{Key: backupOptCheckFiles}}
+	if f.checkContents {
+		opts = append(opts, tree.KVOption{Key: backupOptCheckContents})
+	}
+	opts = append(opts, tree.KVOption{Key: backupOptDetached})
+	description := tree.AsString(&tree.ShowBackup{Path: tree.NewDString(uri), Options: opts})
+
+	jobID := f.p.ExecCfg().JobRegistry.MakeJobID()
+	jr := jobs.Record{
+		Description: description,
+		Details:     details,
+		Progress:    jobspb.BackupValidationProgress{},
+		Username:    f.p.User(),
+	}
+	if _, err := f.p.ExecCfg().JobRegistry.CreateAdoptableJobWithTxn(
+		ctx, jr, jobID, f.p.Txn()); err != nil {
+		return err
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case resultsCh <- tree.Datums{tree.NewDInt(tree.DInt(jobID))}:
+		return nil
+	}
+}
+
 // showBackupPlanHook implements PlanHookFn.
 func showBackupPlanHook(
 	ctx context.Context, stmt tree.Statement, p sql.PlanHookState,
@@ -218,6 +331,9 @@ func showBackupPlanHook(
 		backupOptIncStorage:       sql.KVStringOptRequireValue,
 		backupOptDebugMetadataSST: sql.KVStringOptRequireNoValue,
 		backupOptEncDir:           sql.KVStringOptRequireValue,
+		backupOptCheckFiles:       sql.KVStringOptRequireNoValue,
+		backupOptCheckContents:    sql.KVStringOptRequireNoValue,
+		backupOptDetached:         sql.KVStringOptRequireNoValue,
 	}
 	optsFn, err := p.TypeAsStringOpts(ctx, backup.Options, expected)
 	if err != nil {
@@ -228,8 +344,28 @@ func showBackupPlanHook(
 		return nil, nil, nil, false, err
 	}
 
+	_, checkFiles := opts[backupOptCheckFiles]
+	_, checkContents := opts[backupOptCheckContents]
+	checkFiles = checkFiles || checkContents
+	_, detached := opts[backupOptDetached]
+	if detached && !checkFiles {
+		return nil, nil, nil, false, errors.Newf("%s option is only supported with %s",
+			backupOptDetached, backupOptCheckFiles)
+	}
+	if checkFiles && backup.Details != tree.BackupDefaultDetails {
+		return nil, nil, nil, false, errors.Newf(
+			"%s option is not supported with SHOW BACKUP RANGES, FILES or SCHEMAS", backupOptCheckFiles)
+	}
+
 	var infoReader backupInfoReader
-	if _, dumpSST := opts[backupOptDebugMetadataSST]; dumpSST {
+	if checkFiles {
+		infoReader = fileCheckInfoReader{
+			p:             p,
+			inCollection:  backup.InCollection != nil,
+			checkContents: checkContents,
+			detached:      detached,
+		}
+	} else if _, dumpSST := opts[backupOptDebugMetadataSST]; dumpSST {
 		infoReader = metadataSSTInfoReader{}
 	} else if _, asJSON := opts[backupOptAsJSON]; asJSON {
 		infoReader = manifestInfoReader{shower: jsonShower}
@@ -402,6 +538,7 @@ you must pass the 'encryption_info_dir' parameter that points to the directory o
 			memReserved int64
 		)
 		info.subdir = computedSubdir
+		info.enc = encryption
 
 		mkStore := p.ExecCfg().DistSQLSrv.ExternalStorageFromURI
 
@@ -23,6 +23,7 @@ import (
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
 	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/security"
@@ -30,6 +31,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/desctestutils"
 	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
 	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/jobutils"
 	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
 	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
@@ -837,3 +839,67 @@ func TestShowBackupPathIsCollectionRoot(t *testing.T) {
 	sqlDB.ExpectErr(t, "The specified path is the root of a backup collection.",
 		"SHOW BACKUP $1", localFoo)
 }
+
+func TestShowBackupCheckFiles(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	const numAccounts = 100
+	_, sqlDB, dir, cleanupFn := backupRestoreTestSetup(t, singleNode, numAccounts, InitManualReplication)
+	defer cleanupFn()
+
+	sqlDB.Exec(t, `BACKUP DATABASE data INTO $1`, localFoo)
+	sqlDB.Exec(t, `UPDATE data.bank SET balance = balance + 1`)
+	sqlDB.Exec(t, `BACKUP DATABASE data INTO LATEST IN $1`, localFoo)
+
+	const checkQuery = `SELECT path, backup_type, error FROM [SHOW BACKUP LATEST IN $1 WITH %s]`
+	for _, opt := range []string{"check_files", "check_file_contents"} {
+		sqlDB.CheckQueryResults(t, fmt.Sprintf(checkQuery, opt), [][]string{}, localFoo)
+	}
+	var jobID jobspb.JobID
+	sqlDB.QueryRow(t, `SHOW BACKUP LATEST IN $1 WITH check_file_contents, detached`, localFoo).Scan(&jobID)
+	jobutils.WaitForJobToSucceed(t, sqlDB, jobID)
+
+	sqlDB.ExpectErr(t, "detached option is only supported with check_files",
+		`SHOW BACKUP LATEST IN $1 WITH detached`, localFoo)
+	sqlDB.ExpectErr(t, "check_files option is not supported with SHOW BACKUP RANGES, FILES or SCHEMAS",
+		`SHOW BACKUP FILES FROM LATEST IN $1 WITH check_files`, localFoo)
+
+	// Corrupt an SST of the full backup, and remove one of the incremental
+	// backup.
+	collectionDir := filepath.Join(dir, "foo")
+	fullSSTs, err := filepath.Glob(filepath.Join(collectionDir, "*", "*", "*", "data", "*.sst"))
+	require.NoError(t, err)
+	require.NotEmpty(t, fullSSTs)
+	incSSTs, err := filepath.Glob(filepath.Join(
+		collectionDir, DefaultIncrementalsSubdir, "*", "*", "*", "*", "*", "data", "*.sst"))
+	require.NoError(t, err)
+	require.NotEmpty(t, incSSTs)
+
+	corrupted := fullSSTs[0]
+	content, err := ioutil.ReadFile(corrupted)
+	require.NoError(t, err)
+	content[len(content)/2] ^= 0xff
+	require.NoError(t, ioutil.WriteFile(corrupted, content, 0644))
+	removed := incSSTs[0]
+	require.NoError(t, os.Remove(removed))
+
+	for _, opt := range []string{"check_files", "check_file_contents"} {
+		rows := sqlDB.QueryStr(t, fmt.Sprintf(checkQuery, opt), localFoo)
+		require.Len(t, rows, 2)
+		require.Equal(t, strings.TrimPrefix(corrupted, collectionDir), rows[0][0])
+		require.Equal(t, "full", rows[0][1])
+		require.Regexp(t, "checksum mismatch", rows[0][2])
+		require.True(t, strings.HasSuffix(removed, rows[1][0]), "%s is not %s", rows[1][0], removed)
+		require.Equal(t, "incremental", rows[1][1])
+		require.Regexp(t, "file doesn't exist", rows[1][2])
+	}
+
+	sqlDB.QueryRow(t, `SHOW BACKUP LATEST IN $1 WITH check_files, detached`, localFoo).Scan(&jobID)
+	sqlDB.CheckQueryResultsRetry(t,
+		fmt.Sprintf(`SELECT status FROM [SHOW JOB %d]`, jobID), [][]string{{"failed"}})
+	var jobErr string
+	sqlDB.QueryRow(t, `SELECT error FROM [SHOW JOB $1]`, jobID).Scan(&jobErr)
+	require.Regexp(t, "2 of [0-9]+ files of the backup failed validation", jobErr)
+	require.Regexp(t, "checksum mismatch", jobErr)
+}
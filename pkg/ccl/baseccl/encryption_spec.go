@@ -31,14 +31,24 @@ const plaintextFieldValue = "plain"
 // StoreEncryptionSpec contains the details that can be specified in the cli via
 // the --enterprise-encryption flag.
 type StoreEncryptionSpec struct {
-	Path           string
-	KeyPath        string
-	OldKeyPath     string
+	Path       string
+	KeyPath    string
+	OldKeyPath string
+	// KMSURI is set, instead of KeyPath and OldKeyPath, when the store keys are
+	// generated by the node and wrapped by a KMS master key.
+	KMSURI         string
 	RotationPeriod time.Duration
 }
 
 // Convert to a serialized EncryptionOptions protobuf.
 func (es StoreEncryptionSpec) toEncryptionOptions() ([]byte, error) {
+	if es.KMSURI != "" {
+		return protoutil.Marshal(&EncryptionOptions{
+			KeySource:             EncryptionKeySource_KMS,
+			KMS:                   &EncryptionKMS{URI: es.KMSURI},
+			DataKeyRotationPeriod: int64(es.RotationPeriod / time.Second),
+		})
+	}
 	opts := EncryptionOptions{
 		KeySource: EncryptionKeySource_KeyFiles,
 		KeyFiles: &EncryptionKeyFiles{
@@ -53,6 +63,10 @@ func (es StoreEncryptionSpec) toEncryptionOptions() ([]byte, error) {
 
 // String returns a fully parsable version of the encryption spec.
 func (es StoreEncryptionSpec) String() string {
+	if es.KMSURI != "" {
+		return fmt.Sprintf("path=%s,kms=%s,rotation-period=%s",
+			es.Path, es.KMSURI, es.RotationPeriod)
+	}
 	// All fields are set.
 	return fmt.Sprintf("path=%s,key=%s,old-key=%s,rotation-period=%s",
 		es.Path, es.KeyPath, es.OldKeyPath, es.RotationPeriod)
@@ -116,6 +130,8 @@ func NewStoreEncryptionSpec(value string) (StoreEncryptionSpec, error) {
 					return StoreEncryptionSpec{}, err
 				}
 			}
+		case "kms":
+			es.KMSURI = value
 		case "rotation-period":
 			var err error
 			es.RotationPeriod, err = time.ParseDuration(value)
@@ -131,6 +147,12 @@ func NewStoreEncryptionSpec(value string) (StoreEncryptionSpec, error) {
 	if es.Path == "" {
 		return StoreEncryptionSpec{}, fmt.Errorf("no path specified")
 	}
+	if es.KMSURI != "" {
+		if es.KeyPath != "" || es.OldKeyPath != "" {
+			return StoreEncryptionSpec{}, fmt.Errorf("key and old-key cannot be specified with kms")
+		}
+		return es, nil
+	}
 	if es.KeyPath == "" {
 		return StoreEncryptionSpec{}, fmt.Errorf("no key specified")
 	}
@@ -39,6 +39,11 @@ func TestNewStoreEncryptionSpec(t *testing.T) {
 		{"path=data", "no key specified", StoreEncryptionSpec{}},
 		{"path=data,key=new.key", "no old-key specified", StoreEncryptionSpec{}},
 
+		// KMS.
+		{"path=data,kms=", "no value specified for kms", StoreEncryptionSpec{}},
+		{"path=data,key=new.key,kms=aws:///key", "key and old-key cannot be specified with kms", StoreEncryptionSpec{}},
+		{"path=data,old-key=plain,kms=aws:///key", "key and old-key cannot be specified with kms", StoreEncryptionSpec{}},
+
 		// Rotation period.
 		{"path=data,key=new.key,old-key=old.key,rotation-period", "field not in the form <key>=<value>: rotation-period", StoreEncryptionSpec{}},
 		{"path=data,key=new.key,old-key=old.key,rotation-period=", "no value specified for rotation-period", StoreEncryptionSpec{}},
@@ -50,6 +55,8 @@ func TestNewStoreEncryptionSpec(t *testing.T) {
 		{"path=/data,key=/new.key,old-key=/old.key,rotation-period=1h", "", StoreEncryptionSpec{Path: "/data", KeyPath: "/new.key", OldKeyPath: "/old.key", RotationPeriod: time.Hour}},
 		{"path=/data,key=plain,old-key=/old.key,rotation-period=1h", "", StoreEncryptionSpec{Path: "/data", KeyPath: "plain", OldKeyPath: "/old.key", RotationPeriod: time.Hour}},
 		{"path=/data,key=/new.key,old-key=plain,rotation-period=1h", "", StoreEncryptionSpec{Path: "/data", KeyPath: "/new.key", OldKeyPath: "plain", RotationPeriod: time.Hour}},
+		{"path=/data,kms=aws:///key?AUTH=implicit&REGION=us-east-1", "", StoreEncryptionSpec{Path: "/data", KMSURI: "aws:///key?AUTH=implicit&REGION=us-east-1", RotationPeriod: DefaultRotationPeriod}},
+		{"path=/data,kms=gs:///key,rotation-period=1h", "", StoreEncryptionSpec{Path: "/data", KMSURI: "gs:///key", RotationPeriod: time.Hour}},
 	}
 
 	for i, testCase := range testCases {
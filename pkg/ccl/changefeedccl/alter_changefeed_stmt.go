@@ -81,6 +81,11 @@ func alterChangefeedPlanHook(
 			return errors.Errorf(`job %d is not paused`, jobID)
 		}
 
+		if prevDetails.Select != `` {
+			return errors.Errorf(`ALTER CHANGEFEED is not supported for changefeed %d `+
+				`created with a CDC query`, jobID)
+		}
+
 		newChangefeedStmt := &tree.CreateChangefeed{}
 
 		prevOpts, err := getPrevOpts(job.Payload().Description, prevDetails.Opts)
@@ -0,0 +1,335 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package changefeedccl
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/colinfo"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/schemaexpr"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/eval"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sessiondata"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+)
+
+// A CDC query is a changefeed created with CREATE CHANGEFEED ... AS SELECT.
+// Its SELECT clause watches a single table: the WHERE clause filters the rows
+// that are emitted, and the target list replaces the table columns in the
+// emitted values. Both are evaluated by the change aggregators, so filtered
+// rows and columns left out of the projection never reach the sink.
+
+// validateCDCQuery checks that the SELECT clause of a CDC query only uses the
+// features supported by changefeeds, and returns the table it watches.
+func validateCDCQuery(sc *tree.SelectClause) (*tree.TableName, error) {
+	unsupported := func(what string) error {
+		return pgerror.Newf(pgcode.FeatureNotSupported, "%s is not supported in CDC queries", what)
+	}
+	switch {
+	case sc.Distinct || sc.DistinctOn != nil:
+		return nil, unsupported("DISTINCT")
+	case len(sc.GroupBy) > 0:
+		return nil, unsupported("GROUP BY")
+	case sc.Having != nil:
+		return nil, unsupported("HAVING")
+	case len(sc.Window) > 0:
+		return nil, unsupported("WINDOW")
+	case sc.From.AsOf.Expr != nil:
+		return nil, unsupported("AS OF SYSTEM TIME")
+	}
+	if len(sc.From.Tables) != 1 {
+		return nil, pgerror.Newf(pgcode.FeatureNotSupported,
+			"CDC queries must select from exactly one table")
+	}
+	tn, ok := sc.From.Tables[0].(*tree.TableName)
+	if !ok {
+		return nil, pgerror.Newf(pgcode.FeatureNotSupported,
+			"CDC queries must select from a table, found %s", tree.AsString(sc.From.Tables[0]))
+	}
+	return tn, nil
+}
+
+// parseCDCQuery parses the SELECT clause of a CDC query, as serialized in the
+// Select field of the changefeed details.
+func parseCDCQuery(sel string) (*tree.SelectClause, error) {
+	stmt, err := parser.ParseOne(sel)
+	if err != nil {
+		return nil, err
+	}
+	if s, ok := stmt.AST.(*tree.Select); ok {
+		if sc, ok := s.Select.(*tree.SelectClause); ok {
+			return sc, nil
+		}
+	}
+	return nil, errors.AssertionFailedf("expected SELECT clause in CDC query, found %s", sel)
+}
+
+// projectedRow is the result of evaluating the target list of a CDC query on
+// a row.
+type projectedRow struct {
+	names  []string
+	datums tree.Datums
+}
+
+// cdcQueryEvaluator evaluates a CDC query on the rows of the table it watches.
+// The query is type-checked again whenever a row is interpreted under a new
+// version of the table descriptor, so schema changes that are compatible with
+// the query are transparent, and those that are not (e.g. dropping a column
+// the query references) fail the changefeed.
+type cdcQueryEvaluator struct {
+	sc      *tree.SelectClause
+	tn      tree.TableName
+	evalCtx *eval.Context
+
+	// compiled holds the query compiled against the most recently seen
+	// descriptor versions. Two of them are kept because changefeeds using
+	// the diff option may interpret the previous value of a row under an
+	// older version of the descriptor.
+	compiled [2]*compiledCDCQuery
+}
+
+func newCDCQueryEvaluator(
+	sc *tree.SelectClause, evalCtx *eval.Context,
+) (*cdcQueryEvaluator, error) {
+	tn, err := validateCDCQuery(sc)
+	if err != nil {
+		return nil, err
+	}
+	return &cdcQueryEvaluator{sc: sc, tn: *tn, evalCtx: evalCtx}, nil
+}
+
+// apply evaluates the CDC query on the given row. It returns false if the
+// WHERE clause filters out the row. Otherwise, it sets the projections of the
+// row and of its previous value, if any.
+//
+// A row is emitted if its new value matches the WHERE clause. When the
+// previous value of the row is known, which is the case with the diff option,
+// a row whose previous value matched is emitted too, so that the deletions and
+// the updates that take a row out of the filtered set are visible. Without the
+// previous value, deleted rows only carry their primary key, so the WHERE
+// clause cannot be evaluated for them and they are always emitted.
+func (e *cdcQueryEvaluator) apply(ctx context.Context, r *encodeRow) (bool, error) {
+	var q *compiledCDCQuery
+	matches := false
+	if !r.deleted {
+		var err error
+		if q, err = e.forDesc(ctx, r.tableDesc); err != nil {
+			return false, err
+		}
+		if matches, err = q.matches(e.evalCtx, r.datums); err != nil {
+			return false, err
+		}
+	}
+	var prevQ *compiledCDCQuery
+	prevMatches := false
+	if r.prevDatums != nil && !r.prevDeleted {
+		var err error
+		if prevQ, err = e.forDesc(ctx, r.prevTableDesc); err != nil {
+			return false, err
+		}
+		if prevMatches, err = prevQ.matches(e.evalCtx, r.prevDatums); err != nil {
+			return false, err
+		}
+	}
+	if !matches && !prevMatches && !(r.deleted && r.prevDatums == nil) {
+		return false, nil
+	}
+
+	var err error
+	if q != nil {
+		if r.projection, err = q.project(e.evalCtx, r.datums); err != nil {
+			return false, err
+		}
+	}
+	if prevQ != nil {
+		if r.prevProjection, err = prevQ.project(e.evalCtx, r.prevDatums); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// forDesc returns the query compiled against the given table descriptor.
+func (e *cdcQueryEvaluator) forDesc(
+	ctx context.Context, desc catalog.TableDescriptor,
+) (*compiledCDCQuery, error) {
+	oldest := 0
+	for i, q := range e.compiled {
+		if q == nil {
+			oldest = i
+			continue
+		}
+		if q.version == desc.GetVersion() {
+			return q, nil
+		}
+		if e.compiled[oldest] != nil && q.version < e.compiled[oldest].version {
+			oldest = i
+		}
+	}
+	q, err := compileCDCQuery(ctx, e.sc, &e.tn, desc)
+	if err != nil {
+		return nil, err
+	}
+	e.compiled[oldest] = q
+	return q, nil
+}
+
+// compiledCDCQuery is a CDC query type-checked against a version of the table
+// descriptor.
+type compiledCDCQuery struct {
+	version descpb.DescriptorVersion
+	row     cdcQueryRow
+	// names and projection are the names and expressions of the target list,
+	// with stars expanded.
+	names      []string
+	projection []tree.TypedExpr
+	// filter is the WHERE clause, or nil if there is none.
+	filter tree.TypedExpr
+}
+
+// compileCDCQuery resolves the column references of the query against the
+// public columns of the table, and type-checks its expressions.
+func compileCDCQuery(
+	ctx context.Context, sc *tree.SelectClause, tn *tree.TableName, desc catalog.TableDescriptor,
+) (*compiledCDCQuery, error) {
+	if desc.NumFamilies() > 1 {
+		return nil, pgerror.Newf(pgcode.FeatureNotSupported,
+			"CDC queries are not supported on tables with multiple column families")
+	}
+	cols := desc.PublicColumns()
+	q := &compiledCDCQuery{
+		version: desc.GetVersion(),
+		row:     cdcQueryRow{cols: cols},
+	}
+	source := colinfo.NewSourceInfoForSingleTable(
+		*tn, colinfo.ResultColumnsFromColumns(desc.GetID(), cols),
+	)
+	ivarHelper := tree.MakeIndexedVarHelper(&q.row, len(cols))
+	semaCtx := tree.MakeSemaContext()
+	semaCtx.IVarContainer = &q.row
+	semaCtx.SearchPath = &sessiondata.DefaultSearchPath
+	semaCtx.Properties.Require("CDC queries",
+		tree.RejectSpecial|tree.RejectSubqueries|tree.RejectVolatileFunctions)
+
+	resolve := func(expr tree.Expr) (tree.Expr, error) {
+		var v schemaexpr.NameResolutionVisitor
+		return schemaexpr.ResolveNamesUsingVisitor(
+			&v, expr, source, ivarHelper, sessiondata.DefaultSearchPath,
+		)
+	}
+
+	for _, target := range sc.Exprs {
+		if _, ok := target.Expr.(tree.UnqualifiedStar); ok {
+			for i, col := range cols {
+				// Virtual columns are not computed by changefeeds.
+				if col.IsHidden() || col.IsVirtual() {
+					continue
+				}
+				q.names = append(q.names, col.GetName())
+				q.projection = append(q.projection, ivarHelper.IndexedVar(i))
+			}
+			continue
+		}
+		name, err := tree.GetRenderColName(semaCtx.SearchPath, target)
+		if err != nil {
+			return nil, err
+		}
+		expr, err := resolve(target.Expr)
+		if err != nil {
+			return nil, err
+		}
+		typedExpr, err := tree.TypeCheck(ctx, expr, &semaCtx, types.Any)
+		if err != nil {
+			return nil, err
+		}
+		q.names = append(q.names, name)
+		q.projection = append(q.projection, typedExpr)
+	}
+
+	if sc.Where != nil {
+		expr, err := resolve(sc.Where.Expr)
+		if err != nil {
+			return nil, err
+		}
+		if q.filter, err = tree.TypeCheckAndRequire(ctx, expr, &semaCtx, types.Bool, "WHERE"); err != nil {
+			return nil, err
+		}
+	}
+	return q, nil
+}
+
+// matches returns whether the given row passes the WHERE clause of the query.
+func (q *compiledCDCQuery) matches(evalCtx *eval.Context, datums rowenc.EncDatumRow) (bool, error) {
+	if q.filter == nil {
+		return true, nil
+	}
+	q.row.datums = datums
+	evalCtx.PushIVarContainer(&q.row)
+	defer evalCtx.PopIVarContainer()
+	d, err := eval.Expr(evalCtx, q.filter)
+	if err != nil {
+		return false, err
+	}
+	return d == tree.DBoolTrue, nil
+}
+
+// project evaluates the target list of the query on the given row.
+func (q *compiledCDCQuery) project(
+	evalCtx *eval.Context, datums rowenc.EncDatumRow,
+) (*projectedRow, error) {
+	q.row.datums = datums
+	evalCtx.PushIVarContainer(&q.row)
+	defer evalCtx.PopIVarContainer()
+	res := &projectedRow{names: q.names, datums: make(tree.Datums, len(q.projection))}
+	for i, expr := range q.projection {
+		d, err := eval.Expr(evalCtx, expr)
+		if err != nil {
+			return nil, err
+		}
+		res.datums[i] = d
+	}
+	return res, nil
+}
+
+// cdcQueryRow exposes the datums of a row to the expressions of a CDC query.
+// It implements eval.IndexedVarContainer.
+type cdcQueryRow struct {
+	cols   []catalog.Column
+	datums rowenc.EncDatumRow
+	alloc  tree.DatumAlloc
+}
+
+var _ eval.IndexedVarContainer = &cdcQueryRow{}
+
+// IndexedVarEval implements the eval.IndexedVarContainer interface.
+func (r *cdcQueryRow) IndexedVarEval(idx int, _ tree.ExprEvaluator) (tree.Datum, error) {
+	d := &r.datums[idx]
+	if err := d.EnsureDecoded(r.cols[idx].GetType(), &r.alloc); err != nil {
+		return nil, err
+	}
+	return d.Datum, nil
+}
+
+// IndexedVarResolvedType implements the tree.IndexedVarContainer interface.
+func (r *cdcQueryRow) IndexedVarResolvedType(idx int) *types.T {
+	return r.cols[idx].GetType()
+}
+
+// IndexedVarNodeFormatter implements the tree.IndexedVarContainer interface.
+func (r *cdcQueryRow) IndexedVarNodeFormatter(idx int) tree.NodeFormatter {
+	n := tree.Name(r.cols[idx].GetName())
+	return &n
+}
@@ -37,6 +37,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/row"
 	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/eval"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/util/bufalloc"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
@@ -305,9 +306,14 @@ func (ca *changeAggregator) Start(ctx context.Context) {
 	if ca.spec.Feed.Opts[changefeedbase.OptFormat] == string(changefeedbase.OptFormatNative) {
 		ca.eventConsumer = newNativeKVConsumer(ca.sink)
 	} else {
-		ca.eventConsumer = newKVEventToRowConsumer(
-			ctx, ca.flowCtx.Cfg, ca.frontier.SpanFrontier(), kvFeedHighWater,
+		ca.eventConsumer, err = newKVEventToRowConsumer(
+			ctx, ca.flowCtx.Cfg, ca.flowCtx.EvalCtx, ca.frontier.SpanFrontier(), kvFeedHighWater,
 			ca.sink, ca.encoder, ca.spec.Feed, ca.knobs, ca.topicNamer)
+		if err != nil {
+			ca.MoveToDraining(err)
+			ca.cancel()
+			return
+		}
 	}
 }
 
@@ -674,6 +680,9 @@ type kvEventToRowConsumer struct {
 	kvFetcher            row.SpanKVFetcher
 	topicDescriptorCache map[TopicIdentifier]TopicDescriptor
 	topicNamer           *TopicNamer
+	// query evaluates the CDC query of the changefeed, if it was created with
+	// one.
+	query *cdcQueryEvaluator
 }
 
 var _ kvEventConsumer = &kvEventToRowConsumer{}
@@ -681,6 +690,7 @@ var _ kvEventConsumer = &kvEventToRowConsumer{}
 func newKVEventToRowConsumer(
 	ctx context.Context,
 	cfg *execinfra.ServerConfig,
+	evalCtx *eval.Context,
 	frontier *span.Frontier,
 	cursor hlc.Timestamp,
 	sink Sink,
@@ -688,7 +698,20 @@ func newKVEventToRowConsumer(
 	details jobspb.ChangefeedDetails,
 	knobs TestingKnobs,
 	topicNamer *TopicNamer,
-) kvEventConsumer {
+) (kvEventConsumer, error) {
+	var query *cdcQueryEvaluator
+	if details.Select != `` {
+		sc, err := parseCDCQuery(details.Select)
+		if err != nil {
+			return nil, err
+		}
+		// The evaluation context is copied because the query binds its own
+		// indexed var container to it.
+		if query, err = newCDCQueryEvaluator(sc, evalCtx.Copy()); err != nil {
+			return nil, err
+		}
+	}
+
 	rfCache := newRowFetcherCache(
 		ctx,
 		cfg.Codec,
@@ -708,7 +731,8 @@ func newKVEventToRowConsumer(
 		knobs:                knobs,
 		topicDescriptorCache: make(map[TopicIdentifier]TopicDescriptor),
 		topicNamer:           topicNamer,
-	}
+		query:                query,
+	}, nil
 }
 
 type tableDescriptorTopic struct {
@@ -873,6 +897,12 @@ func (c *kvEventToRowConsumer) ConsumeEvent(ctx context.Context, ev kvevent.Even
 		return err
 	}
 
+	if c.query != nil {
+		if keep, err := c.query.apply(ctx, &r); err != nil || !keep {
+			return err
+		}
+	}
+
 	topic, err := c.topicForRow(r)
 	if err != nil {
 		return err
@@ -895,6 +925,9 @@ func (c *kvEventToRowConsumer) ConsumeEvent(ctx context.Context, ev kvevent.Even
 			"or equal to the local frontier %s.", r.updated, c.frontier.Frontier())
 		return nil
 	}
+	if pe, ok := c.encoder.(*parquetEncoder); ok {
+		return c.emitParquetRow(ctx, pe, topic, r, ev.DetachAlloc())
+	}
 	var keyCopy, valueCopy []byte
 	encodedKey, err := c.encoder.EncodeKey(ctx, r)
 	if err != nil {
@@ -924,6 +957,27 @@ func (c *kvEventToRowConsumer) ConsumeEvent(ctx context.Context, ev kvevent.Even
 	return nil
 }
 
+// emitParquetRow hands the parquet record of the given row to the sink.
+func (c *kvEventToRowConsumer) emitParquetRow(
+	ctx context.Context, e *parquetEncoder, topic TopicDescriptor, r encodeRow, alloc kvevent.Alloc,
+) error {
+	sink, ok := c.sink.(parquetSink)
+	if !ok {
+		return errors.Errorf(`this sink is incompatible with %s=%s`,
+			changefeedbase.OptFormat, changefeedbase.OptFormatParquet)
+	}
+	schema, record, err := e.encodeRecord(r)
+	if err != nil {
+		return err
+	}
+	if c.knobs.BeforeEmitRow != nil {
+		if err := c.knobs.BeforeEmitRow(ctx); err != nil {
+			return err
+		}
+	}
+	return sink.EmitParquetRow(ctx, topic, schema, record, r.updated, r.mvccTimestamp, alloc)
+}
+
 func (c *kvEventToRowConsumer) eventToRow(
 	ctx context.Context, event kvevent.Event,
 ) (encodeRow, error) {
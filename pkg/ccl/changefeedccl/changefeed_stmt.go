@@ -346,8 +346,18 @@ func createChangefeedJobRecord(
 		}
 	}
 
+	rawTargets := changefeedStmt.Targets
+	if changefeedStmt.Select != nil {
+		// The target of a CDC query is the table in its FROM clause.
+		tn, err := validateCDCQuery(changefeedStmt.Select)
+		if err != nil {
+			return nil, err
+		}
+		rawTargets = tree.ChangefeedTargets{{TableName: tn}}
+	}
+
 	tableOnlyTargetList := tree.TargetList{}
-	for _, t := range changefeedStmt.Targets {
+	for _, t := range rawTargets {
 		tableOnlyTargetList.Tables = append(tableOnlyTargetList.Tables, t.TableName)
 	}
 
@@ -357,7 +367,7 @@ func createChangefeedJobRecord(
 		return nil, err
 	}
 
-	targets, tables, err := getTargetsAndTables(ctx, p, targetDescs, changefeedStmt.Targets, changefeedStmt.originalSpecs, opts)
+	targets, tables, err := getTargetsAndTables(ctx, p, targetDescs, rawTargets, changefeedStmt.originalSpecs, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -381,6 +391,14 @@ func createChangefeedJobRecord(
 		TargetSpecifications: targets,
 	}
 
+	if changefeedStmt.Select != nil {
+		if details.Select, err = validateCDCQueryForTable(
+			ctx, changefeedStmt.Select, targetDescs[rawTargets[0].TableName],
+		); err != nil {
+			return nil, err
+		}
+	}
+
 	// TODO(dan): In an attempt to present the most helpful error message to the
 	// user, the ordering requirements between all these usage validations have
 	// become extremely fragile and non-obvious.
@@ -437,6 +455,27 @@ func createChangefeedJobRecord(
 		}
 	}
 
+	if details.Select != `` {
+		if format := changefeedbase.FormatType(details.Opts[changefeedbase.OptFormat]); format != changefeedbase.OptFormatJSON {
+			return nil, pgerror.Newf(pgcode.FeatureNotSupported,
+				`%s=%s is not supported in CDC queries`, changefeedbase.OptFormat, format)
+		}
+	}
+
+	if changefeedbase.FormatType(details.Opts[changefeedbase.OptFormat]) == changefeedbase.OptFormatParquet {
+		if !isCloudStorageSink(parsedSink) {
+			return nil, errors.Errorf(`%s=%s is only usable with cloud storage sinks`,
+				changefeedbase.OptFormat, changefeedbase.OptFormatParquet)
+		}
+		for _, desc := range targetDescs {
+			if table, isTable := desc.(catalog.TableDescriptor); isTable {
+				if err := validateParquetTable(table, details.Opts); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
 	if _, err := getEncoder(details.Opts, AllTargets(details)); err != nil {
 		return nil, err
 	}
@@ -729,6 +768,7 @@ func changefeedJobDescription(
 	c := &tree.CreateChangefeed{
 		Targets: changefeed.Targets,
 		SinkURI: tree.NewDString(cleanedSinkURI),
+		Select:  changefeed.Select,
 	}
 	for k, v := range opts {
 		if k == changefeedbase.OptWebhookAuthHeader {
@@ -846,6 +886,8 @@ func validateDetails(details jobspb.ChangefeedDetails) (jobspb.ChangefeedDetails
 			details.Opts[opt] = string(changefeedbase.OptFormatCSV)
 		case changefeedbase.OptFormatAvro, changefeedbase.DeprecatedOptFormatAvro:
 			// No-op.
+		case changefeedbase.OptFormatParquet:
+			details.Opts[opt] = string(changefeedbase.OptFormatParquet)
 		default:
 			return jobspb.ChangefeedDetails{}, errors.Errorf(
 				`unknown %s: %s`, opt, v)
@@ -898,6 +940,21 @@ func validateDetails(details jobspb.ChangefeedDetails) (jobspb.ChangefeedDetails
 	return details, nil
 }
 
+// validateCDCQueryForTable type-checks the CDC query against the descriptor of
+// the table it watches, and returns its serialized form.
+func validateCDCQueryForTable(
+	ctx context.Context, sc *tree.SelectClause, desc catalog.Descriptor,
+) (string, error) {
+	td, ok := desc.(catalog.TableDescriptor)
+	if !ok {
+		return "", errors.AssertionFailedf("expected table descriptor, found %T", desc)
+	}
+	if _, err := compileCDCQuery(ctx, sc, sc.From.Tables[0].(*tree.TableName), td); err != nil {
+		return "", err
+	}
+	return tree.AsStringWithFlags(sc, tree.FmtParsable), nil
+}
+
 func validatePrimaryKeyFilterExpression(
 	ctx context.Context,
 	execCtx sql.JobExecContext,
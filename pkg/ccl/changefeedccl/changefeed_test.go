@@ -141,6 +141,75 @@ func TestChangefeedBasics(t *testing.T) {
 	// cloudStorageTest is a regression test for #36994.
 }
 
+func TestChangefeedCDCQuery(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	testFn := func(t *testing.T, db *gosql.DB, f cdctest.TestFeedFactory) {
+		sqlDB := sqlutils.MakeSQLRunner(db)
+		sqlDB.Exec(t, `CREATE TABLE foo (a INT PRIMARY KEY, b STRING, secret STRING)`)
+		sqlDB.Exec(t, `INSERT INTO foo VALUES (0, 'zero', 's0'), (1, 'one', 's1')`)
+
+		foo := feed(t, f, `CREATE CHANGEFEED WITH diff AS SELECT a, upper(b) AS b FROM foo WHERE a > 0 AND b != 'gone'`)
+		defer closeFeed(t, foo)
+
+		// The secret column is never emitted, and rows that do not pass the
+		// WHERE clause are filtered out.
+		assertPayloads(t, foo, []string{
+			`foo: [1]->{"after": {"a": 1, "b": "ONE"}, "before": null}`,
+		})
+
+		sqlDB.Exec(t, `UPSERT INTO foo VALUES (0, 'zero', 's2'), (1, 'uno', 's3'), (2, 'two', 's4')`)
+		assertPayloads(t, foo, []string{
+			`foo: [1]->{"after": {"a": 1, "b": "UNO"}, "before": {"a": 1, "b": "ONE"}}`,
+			`foo: [2]->{"after": {"a": 2, "b": "TWO"}, "before": null}`,
+		})
+
+		// With the diff option, the WHERE clause is evaluated on the previous
+		// value of deleted rows, so only the deletions of rows that matched are
+		// emitted.
+		sqlDB.Exec(t, `DELETE FROM foo WHERE a IN (0, 2)`)
+		assertPayloads(t, foo, []string{
+			`foo: [2]->{"after": null, "before": {"a": 2, "b": "TWO"}}`,
+		})
+
+		// An update that takes a row out of the filtered set is emitted, but
+		// later changes to the row are not.
+		sqlDB.Exec(t, `UPDATE foo SET b = 'gone' WHERE a = 1`)
+		sqlDB.Exec(t, `UPDATE foo SET b = 'gone' WHERE a = 1`)
+		sqlDB.Exec(t, `DELETE FROM foo WHERE a = 1`)
+
+		// Schema changes that do not affect the query are transparent.
+		sqlDB.Exec(t, `ALTER TABLE foo ADD COLUMN c INT`)
+		sqlDB.Exec(t, `INSERT INTO foo VALUES (3, 'three', 's5', 3)`)
+		assertPayloads(t, foo, []string{
+			`foo: [1]->{"after": {"a": 1, "b": "GONE"}, "before": {"a": 1, "b": "UNO"}}`,
+			`foo: [3]->{"after": {"a": 3, "b": "THREE"}, "before": null}`,
+		})
+
+		// Without the diff option, deleted rows only carry their primary key,
+		// so they are emitted without evaluating the WHERE clause.
+		bar := feed(t, f, `CREATE CHANGEFEED WITH no_initial_scan AS SELECT a FROM foo WHERE a > 3`)
+		defer closeFeed(t, bar)
+		sqlDB.Exec(t, `DELETE FROM foo WHERE a = 3`)
+		assertPayloads(t, bar, []string{
+			`foo: [3]->{"after": null}`,
+		})
+
+		sqlDB.ExpectErr(t, `column "nope" does not exist`,
+			`CREATE CHANGEFEED AS SELECT nope FROM foo`)
+		sqlDB.ExpectErr(t, `argument of WHERE must be type bool, not type string`,
+			`CREATE CHANGEFEED AS SELECT a FROM foo WHERE b`)
+		sqlDB.ExpectErr(t, `aggregate functions are not allowed in CDC queries`,
+			`CREATE CHANGEFEED AS SELECT max(a) FROM foo`)
+		sqlDB.ExpectErr(t, `format=avro is not supported in CDC queries`,
+			`CREATE CHANGEFEED WITH format = avro, confluent_schema_registry = 'localhost' AS SELECT a FROM foo`)
+	}
+
+	t.Run(`sinkless`, sinklessTest(testFn))
+	t.Run(`enterprise`, enterpriseTest(testFn))
+}
+
 // TestChangefeedSendError validates that SendErrors do not fail the changefeed
 // as they can occur in normal situations such as a cluster update
 func TestChangefeedIdleness(t *testing.T) {
@@ -2145,6 +2214,38 @@ func TestChangefeedColumnFamilyAvro(t *testing.T) {
 	t.Run(`kafka`, kafkaTest(testFn))
 }
 
+func TestChangefeedColumnFamilyParquet(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	testFn := func(t *testing.T, db *gosql.DB, f cdctest.TestFeedFactory) {
+		sqlDB := sqlutils.MakeSQLRunner(db)
+
+		// The primary key is not in the justc family, but it must be in the
+		// records of both families.
+		sqlDB.Exec(t, `CREATE TABLE foo (a INT PRIMARY KEY, b STRING, c STRING, FAMILY most (a,b), FAMILY justc (c))`)
+		sqlDB.Exec(t, `INSERT INTO foo values (0, 'dog', 'cat')`)
+		foo := feed(t, f, `CREATE CHANGEFEED FOR foo WITH split_column_families, format=parquet`)
+		defer closeFeed(t, foo)
+		assertPayloads(t, foo, []string{
+			`foo.most: ->{"__crdb__event_type": "upsert", "a": 0, "b": "dog"}`,
+			`foo.justc: ->{"__crdb__event_type": "upsert", "a": 0, "c": "cat"}`,
+		})
+
+		sqlDB.Exec(t, `UPDATE foo SET c = 'lion' WHERE a = 0`)
+		assertPayloads(t, foo, []string{
+			`foo.justc: ->{"__crdb__event_type": "upsert", "a": 0, "c": "lion"}`,
+		})
+
+		sqlDB.Exec(t, `DELETE FROM foo WHERE a = 0`)
+		assertPayloads(t, foo, []string{
+			`foo.most: ->{"__crdb__event_type": "delete", "a": 0, "b": null}`,
+			`foo.justc: ->{"__crdb__event_type": "delete", "a": 0, "c": null}`,
+		})
+	}
+	t.Run(`cloudstorage`, cloudStorageTest(testFn))
+}
+
 func TestChangefeedAuthorization(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	defer log.Scope(t).Close(t)
@@ -3954,6 +4055,15 @@ func TestChangefeedErrors(t *testing.T) {
 		`CREATE CHANGEFEED FOR foo INTO $1 WITH format = csv`, `kafka://nope`,
 	)
 
+	sqlDB.ExpectErr(
+		t, `format=parquet is only usable with cloud storage sinks`,
+		`CREATE CHANGEFEED FOR foo INTO $1 WITH format = parquet`, `kafka://nope`,
+	)
+	sqlDB.ExpectErr(
+		t, `diff is not supported with format=parquet`,
+		`CREATE CHANGEFEED FOR foo INTO $1 WITH format = parquet, diff`, `nodelocal://0/nope`,
+	)
+
 	var tsCurrent string
 	sqlDB.QueryRow(t, `SELECT cluster_logical_timestamp()`).Scan(&tsCurrent)
 
@@ -120,6 +120,9 @@ const (
 	OptFormatJSON FormatType = `json`
 	OptFormatAvro FormatType = `avro`
 	OptFormatCSV  FormatType = `csv`
+	// OptFormatParquet writes columnar parquet files. It is only supported by
+	// cloud storage sinks.
+	OptFormatParquet FormatType = `parquet`
 
 	OptFormatNative FormatType = `native`
 
@@ -147,7 +150,9 @@ const (
 	SinkParamClientKey              = `client_key`
 	SinkParamFileSize               = `file_size`
 	SinkParamPartitionFormat        = `partition_format`
+	SinkParamParquetRowGroupSize    = `parquet_row_group_size`
 	SinkParamSchemaTopic            = `schema_topic`
+	SinkParamTableFormat            = `table_format`
 	SinkParamTLSEnabled             = `tls_enabled`
 	SinkParamSkipTLSVerify          = `insecure_tls_skip_verify`
 	SinkParamTopicPrefix            = `topic_prefix`
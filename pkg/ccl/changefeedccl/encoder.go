@@ -54,6 +54,13 @@ type encodeRow struct {
 	prevFamilyID descpb.FamilyID
 	// topic is set to the string to be included if TopicInValue is true
 	topic string
+	// projection is set for changefeeds created with a CDC query. It holds the
+	// result of evaluating the target list of the query on `datums`, and
+	// replaces the table columns in the encoded value.
+	projection *projectedRow
+	// prevProjection is the result of evaluating the target list of the query
+	// on `prevDatums`.
+	prevProjection *projectedRow
 }
 
 // Encoder turns a row into a serialized changefeed key, value, or resolved
@@ -87,6 +94,8 @@ func getEncoder(
 		return &nativeEncoder{}, nil
 	case changefeedbase.OptFormatCSV:
 		return newCSVEncoder(opts), nil
+	case changefeedbase.OptFormatParquet:
+		return newParquetEncoder(opts, targets)
 	default:
 		return nil, errors.Errorf(`unknown %s: %s`, changefeedbase.OptFormat, opts[changefeedbase.OptFormat])
 	}
@@ -132,7 +132,12 @@ func (e *jsonEncoder) EncodeValue(_ context.Context, row encodeRow) ([]byte, err
 	}
 
 	var after map[string]interface{}
-	if !row.deleted {
+	if row.projection != nil {
+		var err error
+		if after, err = e.encodeProjection(row.projection); err != nil {
+			return nil, err
+		}
+	} else if !row.deleted {
 		family, err := row.tableDesc.FindFamilyByID(row.familyID)
 		if err != nil {
 			return nil, err
@@ -164,7 +169,12 @@ func (e *jsonEncoder) EncodeValue(_ context.Context, row encodeRow) ([]byte, err
 	}
 
 	var before map[string]interface{}
-	if row.prevDatums != nil && !row.prevDeleted {
+	if row.prevProjection != nil {
+		var err error
+		if before, err = e.encodeProjection(row.prevProjection); err != nil {
+			return nil, err
+		}
+	} else if row.prevDatums != nil && !row.prevDeleted {
 		family, err := row.prevTableDesc.FindFamilyByID(row.prevFamilyID)
 		if err != nil {
 			return nil, err
@@ -248,6 +258,23 @@ func (e *jsonEncoder) EncodeValue(_ context.Context, row encodeRow) ([]byte, err
 	return e.buf.Bytes(), nil
 }
 
+// encodeProjection returns the JSON object mapping the names of the target
+// list of a CDC query to their values.
+func (e *jsonEncoder) encodeProjection(p *projectedRow) (map[string]interface{}, error) {
+	res := make(map[string]interface{}, len(p.names))
+	for i, name := range p.names {
+		var err error
+		if res[name], err = tree.AsJSON(
+			p.datums[i],
+			sessiondatapb.DataConversionConfig{},
+			time.UTC,
+		); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
 // EncodeResolvedTimestamp implements the Encoder interface.
 func (e *jsonEncoder) EncodeResolvedTimestamp(
 	_ context.Context, _ string, resolved hlc.Timestamp,
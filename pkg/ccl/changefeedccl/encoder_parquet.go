@@ -0,0 +1,250 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package changefeedccl
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/changefeedbase"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/importer"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/errors"
+	"github.com/fraugster/parquet-go/parquetschema"
+)
+
+// Names of the metadata columns added to every parquet record.
+const (
+	parquetEventTypeColName     = `__crdb__event_type`
+	parquetUpdatedColName       = `__crdb__updated`
+	parquetMVCCTimestampColName = `__crdb__mvcc_timestamp`
+
+	parquetEventTypeUpsert = `upsert`
+	parquetEventTypeDelete = `delete`
+)
+
+// parquetEncoder encodes changefeed rows as records of parquet files. Parquet
+// is a columnar format, so rows cannot be serialized one at a time like the
+// other formats do: instead, the encoder turns each row into a record that
+// the sink buffers into row groups (see parquetSink). Every record holds the
+// primary key columns, the columns of the row's family, and a
+// `__crdb__event_type` column set to `upsert` or `delete`. Deleted rows only
+// have their primary key columns set.
+//
+// Resolved timestamps are written by the sink in their own files, so they are
+// encoded in JSON like for the other cloud storage formats.
+type parquetEncoder struct {
+	updatedField, mvccTimestampField bool
+	virtualColumnVisibility          string
+
+	alloc    tree.DatumAlloc
+	resolved *jsonEncoder
+
+	// schemas caches the parquet schema of the latest version of each table
+	// family seen so far.
+	schemas map[TopicIdentifier]*parquetSchema
+}
+
+var _ Encoder = &parquetEncoder{}
+
+func newParquetEncoder(
+	opts map[string]string, targets []jobspb.ChangefeedTargetSpecification,
+) (*parquetEncoder, error) {
+	if changefeedbase.EnvelopeType(opts[changefeedbase.OptEnvelope]) != changefeedbase.OptEnvelopeWrapped {
+		return nil, errors.Errorf(`%s=%s is not supported with %s=%s`,
+			changefeedbase.OptEnvelope, opts[changefeedbase.OptEnvelope],
+			changefeedbase.OptFormat, changefeedbase.OptFormatParquet)
+	}
+	if _, ok := opts[changefeedbase.OptDiff]; ok {
+		return nil, errors.Errorf(`%s is not supported with %s=%s`,
+			changefeedbase.OptDiff, changefeedbase.OptFormat, changefeedbase.OptFormatParquet)
+	}
+	resolved, err := makeJSONEncoder(opts, targets)
+	if err != nil {
+		return nil, err
+	}
+	e := &parquetEncoder{
+		virtualColumnVisibility: opts[changefeedbase.OptVirtualColumns],
+		resolved:                resolved,
+		schemas:                 make(map[TopicIdentifier]*parquetSchema),
+	}
+	_, e.updatedField = opts[changefeedbase.OptUpdatedTimestamps]
+	_, e.mvccTimestampField = opts[changefeedbase.OptMVCCTimestamps]
+	return e, nil
+}
+
+// EncodeKey implements the Encoder interface.
+func (e *parquetEncoder) EncodeKey(context.Context, encodeRow) ([]byte, error) {
+	return nil, errors.AssertionFailedf("EncodeKey unexpectedly called on parquetEncoder")
+}
+
+// EncodeValue implements the Encoder interface.
+func (e *parquetEncoder) EncodeValue(context.Context, encodeRow) ([]byte, error) {
+	return nil, errors.AssertionFailedf("EncodeValue unexpectedly called on parquetEncoder")
+}
+
+// EncodeResolvedTimestamp implements the Encoder interface.
+func (e *parquetEncoder) EncodeResolvedTimestamp(
+	ctx context.Context, topic string, resolved hlc.Timestamp,
+) ([]byte, error) {
+	return e.resolved.EncodeResolvedTimestamp(ctx, topic, resolved)
+}
+
+// encodeRecord returns the parquet record of the given row, along with the
+// schema of the file it must be written to.
+func (e *parquetEncoder) encodeRecord(row encodeRow) (*parquetSchema, map[string]interface{}, error) {
+	s, err := e.schemaForRow(row)
+	if err != nil {
+		return nil, nil, err
+	}
+	record := make(map[string]interface{}, len(s.cols)+len(s.meta))
+	for i, ord := range s.ords {
+		col := &s.cols[i]
+		if row.deleted && !s.key[i] {
+			record[col.Name()] = nil
+			continue
+		}
+		datum := row.datums[ord]
+		if err := datum.EnsureDecoded(row.tableDesc.PublicColumns()[ord].GetType(), &e.alloc); err != nil {
+			return nil, nil, err
+		}
+		if record[col.Name()], err = col.Encode(datum.Datum); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	setMeta := func(name, value string) (err error) {
+		record[name], err = s.meta[name].Encode(tree.NewDString(value))
+		return err
+	}
+	eventType := parquetEventTypeUpsert
+	if row.deleted {
+		eventType = parquetEventTypeDelete
+	}
+	if err := setMeta(parquetEventTypeColName, eventType); err != nil {
+		return nil, nil, err
+	}
+	if e.updatedField {
+		if err := setMeta(parquetUpdatedColName, row.updated.AsOfSystemTime()); err != nil {
+			return nil, nil, err
+		}
+	}
+	if e.mvccTimestampField {
+		if err := setMeta(parquetMVCCTimestampColName, row.mvccTimestamp.AsOfSystemTime()); err != nil {
+			return nil, nil, err
+		}
+	}
+	return s, record, nil
+}
+
+// schemaForRow returns the parquet schema of the family of the given row,
+// building it if the row's table descriptor version was not seen before.
+func (e *parquetEncoder) schemaForRow(row encodeRow) (*parquetSchema, error) {
+	id := TopicIdentifier{TableID: row.tableDesc.GetID(), FamilyID: row.familyID}
+	if s, ok := e.schemas[id]; ok && s.version == row.tableDesc.GetVersion() {
+		return s, nil
+	}
+	family, err := row.tableDesc.FindFamilyByID(row.familyID)
+	if err != nil {
+		return nil, err
+	}
+	s, err := makeParquetSchema(row.tableDesc, family, e.virtualColumnVisibility, e.updatedField, e.mvccTimestampField)
+	if err != nil {
+		return nil, err
+	}
+	e.schemas[id] = s
+	return s, nil
+}
+
+// parquetSchema is the schema of the parquet files written for a version of a
+// table family.
+type parquetSchema struct {
+	version descpb.DescriptorVersion
+	def     *parquetschema.SchemaDefinition
+
+	// cols are the parquet columns of the table columns in the schema, and
+	// ords the ordinals of these columns in the rows. key is set for the
+	// primary key columns.
+	cols []importer.ParquetColumn
+	ords []int
+	key  []bool
+
+	// meta holds the parquet columns of the metadata added to every record.
+	meta map[string]*importer.ParquetColumn
+}
+
+// makeParquetSchema builds the parquet schema of a table family. It returns an
+// error if the family has columns of a type that parquet files cannot hold.
+func makeParquetSchema(
+	desc catalog.TableDescriptor,
+	family *descpb.ColumnFamilyDescriptor,
+	virtualColumnVisibility string,
+	updatedField, mvccTimestampField bool,
+) (*parquetSchema, error) {
+	include := make(map[descpb.ColumnID]struct{}, len(family.ColumnIDs))
+	for _, colID := range family.ColumnIDs {
+		include[colID] = struct{}{}
+	}
+	keyCols := desc.GetPrimaryIndex().CollectKeyColumnIDs()
+
+	s := &parquetSchema{
+		version: desc.GetVersion(),
+		meta:    make(map[string]*importer.ParquetColumn),
+	}
+	for i, col := range desc.PublicColumns() {
+		// The primary key columns are written in the records of every family,
+		// since they are needed to tell which row a record belongs to.
+		_, inFamily := include[col.GetID()]
+		isKey := keyCols.Contains(col.GetID())
+		virtual := col.IsVirtual() && virtualColumnVisibility == string(changefeedbase.OptVirtualColumnsNull)
+		if !inFamily && !isKey && !virtual {
+			continue
+		}
+		pc, err := importer.NewParquetColumn(col.GetType(), col.GetName(), true /* nullable */)
+		if err != nil {
+			return nil, errors.Wrapf(err, "column %s of table %s", col.GetName(), desc.GetName())
+		}
+		s.cols = append(s.cols, pc)
+		s.ords = append(s.ords, i)
+		s.key = append(s.key, isKey)
+	}
+
+	allCols := append([]importer.ParquetColumn(nil), s.cols...)
+	metaNames := []string{parquetEventTypeColName}
+	if updatedField {
+		metaNames = append(metaNames, parquetUpdatedColName)
+	}
+	if mvccTimestampField {
+		metaNames = append(metaNames, parquetMVCCTimestampColName)
+	}
+	for _, name := range metaNames {
+		pc, err := importer.NewParquetColumn(types.String, name, false /* nullable */)
+		if err != nil {
+			return nil, err
+		}
+		s.meta[name] = &pc
+		allCols = append(allCols, pc)
+	}
+	s.def = importer.NewParquetSchema(allCols)
+	return s, nil
+}
+
+// validateParquetTable returns an error if the given table has columns that
+// cannot be written to parquet files.
+func validateParquetTable(desc catalog.TableDescriptor, opts map[string]string) error {
+	return desc.ForeachFamily(func(family *descpb.ColumnFamilyDescriptor) error {
+		_, err := makeParquetSchema(desc, family, opts[changefeedbase.OptVirtualColumns],
+			false /* updatedField */, false /* mvccTimestampField */)
+		return err
+	})
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/cdctest"
 	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/changefeedbase"
 	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/testutils"
@@ -259,6 +260,55 @@ func TestEncoders(t *testing.T) {
 	}
 }
 
+func TestParquetSchemaColumnFamilies(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	tableDesc, err := parseTableDesc(
+		`CREATE TABLE foo (a INT, b STRING, c STRING, d INT, PRIMARY KEY (d, a))`)
+	require.NoError(t, err)
+	colID := func(name string) descpb.ColumnID {
+		col, err := tableDesc.FindColumnWithName(tree.Name(name))
+		require.NoError(t, err)
+		return col.GetID()
+	}
+
+	// The records of every family must hold the primary key columns, even if
+	// the family does not contain them, to tell which row they belong to.
+	for _, tc := range []struct {
+		family   descpb.ColumnFamilyDescriptor
+		expected []string
+	}{
+		{
+			family: descpb.ColumnFamilyDescriptor{
+				ID: 0, Name: `most`, ColumnIDs: []descpb.ColumnID{colID(`a`), colID(`b`)},
+			},
+			expected: []string{`a (key)`, `b`, `d (key)`},
+		},
+		{
+			family: descpb.ColumnFamilyDescriptor{
+				ID: 1, Name: `justc`, ColumnIDs: []descpb.ColumnID{colID(`c`)},
+			},
+			expected: []string{`a (key)`, `c`, `d (key)`},
+		},
+	} {
+		t.Run(tc.family.Name, func(t *testing.T) {
+			s, err := makeParquetSchema(tableDesc, &tc.family, ``,
+				false /* updatedField */, false /* mvccTimestampField */)
+			require.NoError(t, err)
+			var actual []string
+			for i := range s.cols {
+				name := s.cols[i].Name()
+				if s.key[i] {
+					name += ` (key)`
+				}
+				actual = append(actual, name)
+			}
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
 func TestAvroEncoder(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	defer log.Scope(t).Close(t)
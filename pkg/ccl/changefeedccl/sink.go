@@ -61,6 +61,23 @@ type SinkWithTopics interface {
 	Topics() []string
 }
 
+// parquetSink is implemented by the sinks that can write the records of the
+// parquet format. Records are not serialized one at a time like the rows of
+// the other formats, so they cannot go through EmitRow.
+type parquetSink interface {
+	Sink
+	// EmitParquetRow enqueues a parquet record for delivery on the sink. All
+	// the records of a file share the schema of their topic version.
+	EmitParquetRow(
+		ctx context.Context,
+		topic TopicDescriptor,
+		schema *parquetSchema,
+		record map[string]interface{},
+		updated, mvcc hlc.Timestamp,
+		alloc kvevent.Alloc,
+	) error
+}
+
 func getSink(
 	ctx context.Context,
 	serverCfg *execinfra.ServerConfig,
@@ -244,6 +261,25 @@ func (s errorWrapperSink) EmitRow(
 	return nil
 }
 
+// EmitParquetRow implements the parquetSink interface.
+func (s errorWrapperSink) EmitParquetRow(
+	ctx context.Context,
+	topic TopicDescriptor,
+	schema *parquetSchema,
+	record map[string]interface{},
+	updated, mvcc hlc.Timestamp,
+	alloc kvevent.Alloc,
+) error {
+	ps, ok := s.wrapped.(parquetSink)
+	if !ok {
+		return errors.AssertionFailedf("sink %T does not support parquet", s.wrapped)
+	}
+	if err := ps.EmitParquetRow(ctx, topic, schema, record, updated, mvcc, alloc); err != nil {
+		return changefeedbase.MarkRetryableError(err)
+	}
+	return nil
+}
+
 // EmitResolvedTimestamp implements Sink interface.
 func (s errorWrapperSink) EmitResolvedTimestamp(
 	ctx context.Context, encoder Encoder, resolved hlc.Timestamp,
@@ -33,9 +33,12 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/humanizeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/iceberg"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
 	"github.com/cockroachdb/errors"
+	goparquet "github.com/fraugster/parquet-go"
+	"github.com/fraugster/parquet-go/parquet"
 	"github.com/google/btree"
 )
 
@@ -69,6 +72,11 @@ type cloudStorageSinkFile struct {
 	buf         bytes.Buffer
 	alloc       kvevent.Alloc
 	oldestMVCC  hlc.Timestamp
+
+	// parquetWriter buffers the records of files written in the parquet
+	// format. It is created with the first record, whose schema is shared
+	// by all the records of the file.
+	parquetWriter *goparquet.FileWriter
 }
 
 var _ io.Writer = &cloudStorageSinkFile{}
@@ -175,9 +183,10 @@ func (f *cloudStorageSinkFile) Write(p []byte) (int, error) {
 // by a given `<sink_id>` and <session_id> is a unique identifying string for the job
 // session running the `changeAggregator` that owns this sink.
 //
-// `<ext>` implies the format of the file: currently the only option is
-// `ndjson`, which means a text file conforming to the "Newline Delimited JSON"
-// spec.
+// `<ext>` implies the format of the file: `ndjson`, which means a text file
+// conforming to the "Newline Delimited JSON" spec, `csv`, or `parquet`. Parquet
+// files hold the records of a single schema version, split into row groups of
+// at most `parquet_row_group_size` bytes.
 //
 // This naming convention of data files is carefully chosen in order to preserve
 // the external ordering guarantees of CDC. Naming output files in this fashion
@@ -199,6 +208,11 @@ func (f *cloudStorageSinkFile) Write(p []byte) (int, error) {
 // deleted, included in hive queries, etc). A typical user of cloudStorageSink
 // would periodically do exactly this.
 //
+// With the `table_format=iceberg` parameter, the parquet files of each topic
+// are also committed to an Iceberg table as resolved timestamps are emitted,
+// so that engines reading Iceberg tables can query them in place (see
+// cloudStorageIcebergCommitter).
+//
 // Still TODO is writing out data schemas, Avro support, bounding memory usage.
 //
 // Now what follows is a proof of why the above is correct even in the presence
@@ -291,6 +305,15 @@ type cloudStorageSink struct {
 
 	compression string
 
+	// parquetRowGroupSize is the target size of the row groups of parquet
+	// files. It is only set when the sink writes parquet files.
+	parquetRowGroupSize int64
+
+	// iceberg commits the parquet files written by the sink to Iceberg tables
+	// when resolved timestamps are emitted. It is only set with the
+	// table_format=iceberg sink parameter.
+	iceberg *cloudStorageIcebergCommitter
+
 	es cloud.ExternalStorage
 
 	// These are fields to track information needed to output files based on the naming
@@ -311,6 +334,14 @@ type cloudStorageSink struct {
 
 const sinkCompressionGzip = "gzip"
 
+// sinkTableFormatIceberg is the value of the table_format sink parameter which
+// maintains Iceberg tables of the files written by the sink.
+const sinkTableFormatIceberg = "iceberg"
+
+// defaultParquetRowGroupSize is the default target size of the row groups of
+// parquet files.
+const defaultParquetRowGroupSize = 8 << 20 // 8MB
+
 var cloudStorageSinkIDAtomic int64
 
 // Files that are emitted can be partitioned by their earliest event time,
@@ -342,6 +373,17 @@ func makeCloudStorageSink(
 			return nil, pgerror.Wrapf(err, pgcode.Syntax, `parsing %s`, fileSizeParam)
 		}
 	}
+	var parquetRowGroupSize int64 = defaultParquetRowGroupSize
+	rowGroupSizeParam := u.consumeParam(changefeedbase.SinkParamParquetRowGroupSize)
+	if rowGroupSizeParam != `` {
+		var err error
+		if parquetRowGroupSize, err = humanizeutil.ParseBytes(rowGroupSizeParam); err != nil {
+			return nil, pgerror.Wrapf(err, pgcode.Syntax, `parsing %s`, rowGroupSizeParam)
+		}
+		if parquetRowGroupSize <= 0 {
+			return nil, errors.Errorf(`%s must be positive`, changefeedbase.SinkParamParquetRowGroupSize)
+		}
+	}
 	u.Scheme = strings.TrimPrefix(u.Scheme, `experimental-`)
 
 	sinkID := atomic.AddInt64(&cloudStorageSinkIDAtomic, 1)
@@ -397,10 +439,32 @@ func makeCloudStorageSink(
 		// would require a bit of refactoring.
 		s.ext = `.csv`
 		s.rowDelimiter = []byte{'\n'}
+	case changefeedbase.OptFormatParquet:
+		s.ext = `.parquet`
+		s.parquetRowGroupSize = parquetRowGroupSize
 	default:
 		return nil, errors.Errorf(`this sink is incompatible with %s=%s`,
 			changefeedbase.OptFormat, opts[changefeedbase.OptFormat])
 	}
+	if rowGroupSizeParam != `` && s.parquetRowGroupSize == 0 {
+		return nil, errors.Errorf(`%s is only usable with %s=%s`,
+			changefeedbase.SinkParamParquetRowGroupSize, changefeedbase.OptFormat, changefeedbase.OptFormatParquet)
+	}
+
+	if tableFormat := u.consumeParam(changefeedbase.SinkParamTableFormat); tableFormat != `` {
+		if tableFormat != sinkTableFormatIceberg {
+			return nil, errors.Errorf(`unsupported %s %q`, changefeedbase.SinkParamTableFormat, tableFormat)
+		}
+		if s.parquetRowGroupSize == 0 {
+			return nil, errors.Errorf(`%s=%s is only usable with %s=%s`, changefeedbase.SinkParamTableFormat,
+				tableFormat, changefeedbase.OptFormat, changefeedbase.OptFormatParquet)
+		}
+		location, err := iceberg.Location(u.String())
+		if err != nil {
+			return nil, err
+		}
+		s.iceberg = newCloudStorageIcebergCommitter(location)
+	}
 
 	switch changefeedbase.EnvelopeType(opts[changefeedbase.OptEnvelope]) {
 	case changefeedbase.OptEnvelopeWrapped:
@@ -416,7 +480,11 @@ func makeCloudStorageSink(
 	if codec, ok := opts[changefeedbase.OptCompression]; ok && codec != "" {
 		if strings.EqualFold(codec, "gzip") {
 			s.compression = sinkCompressionGzip
-			s.ext = s.ext + ".gz"
+			// Parquet files compress their pages themselves, so that they
+			// remain readable by parquet readers.
+			if s.parquetRowGroupSize == 0 {
+				s.ext = s.ext + ".gz"
+			}
 		} else {
 			return nil, errors.Errorf(`unsupported compression codec %q`, codec)
 		}
@@ -448,7 +516,9 @@ func (s *cloudStorageSink) getOrCreateFile(
 	}
 	switch s.compression {
 	case sinkCompressionGzip:
-		f.codec = gzip.NewWriter(&f.buf)
+		if s.parquetRowGroupSize == 0 {
+			f.codec = gzip.NewWriter(&f.buf)
+		}
 	}
 	s.files.ReplaceOrInsert(f)
 	return f
@@ -485,6 +555,58 @@ func (s *cloudStorageSink) EmitRow(
 	return nil
 }
 
+// EmitParquetRow implements the parquetSink interface.
+func (s *cloudStorageSink) EmitParquetRow(
+	ctx context.Context,
+	topic TopicDescriptor,
+	schema *parquetSchema,
+	record map[string]interface{},
+	updated, mvcc hlc.Timestamp,
+	alloc kvevent.Alloc,
+) error {
+	if s.files == nil {
+		return errors.New(`cannot EmitRow on a closed sink`)
+	}
+	if s.parquetRowGroupSize == 0 {
+		return errors.AssertionFailedf("EmitParquetRow called on a sink writing %s files", s.ext)
+	}
+
+	file := s.getOrCreateFile(topic, mvcc)
+	file.alloc.Merge(&alloc)
+
+	if file.parquetWriter == nil {
+		codec := parquet.CompressionCodec_UNCOMPRESSED
+		if s.compression == sinkCompressionGzip {
+			codec = parquet.CompressionCodec_GZIP
+		}
+		file.parquetWriter = goparquet.NewFileWriter(&file.buf,
+			goparquet.WithCompressionCodec(codec),
+			goparquet.WithSchemaDefinition(schema.def),
+			goparquet.WithMaxRowGroupSize(s.parquetRowGroupSize),
+		)
+	}
+	sizeBefore := file.parquetWriter.CurrentRowGroupSize()
+	if err := file.parquetWriter.AddData(record); err != nil {
+		return err
+	}
+	// The writer flushes row groups into the buffer by itself once they reach
+	// the target size, so the size of the record is only an estimate.
+	recordSize := file.parquetWriter.CurrentRowGroupSize() - sizeBefore
+	if recordSize < 0 {
+		recordSize = 0
+	}
+	file.rawSize += int(recordSize)
+	file.numMessages++
+	s.metrics.recordMessageSize(recordSize)
+
+	if file.parquetWriter.CurrentFileSize()+file.parquetWriter.CurrentRowGroupSize() > s.targetMaxFileSize {
+		if err := s.flushTopicVersions(ctx, file.topic, file.schemaID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // EmitResolvedTimestamp implements the Sink interface.
 func (s *cloudStorageSink) EmitResolvedTimestamp(
 	ctx context.Context, encoder Encoder, resolved hlc.Timestamp,
@@ -502,6 +624,14 @@ func (s *cloudStorageSink) EmitResolvedTimestamp(
 	}
 	// Don't need to copy payload because we never buffer it anywhere.
 
+	// The Iceberg tables are committed first, so that once the resolved file
+	// exists the tables hold every file it covers.
+	if s.iceberg != nil {
+		if err := s.iceberg.commit(ctx, s.es, s.partitionFormat, s.ext, resolved); err != nil {
+			return err
+		}
+	}
+
 	part := resolved.GoTime().Format(s.partitionFormat)
 	filename := fmt.Sprintf(`%s.RESOLVED`, cloudStorageFormatTime(resolved))
 	if log.V(1) {
@@ -576,12 +706,18 @@ func (s *cloudStorageSink) Flush(ctx context.Context) error {
 func (s *cloudStorageSink) flushFile(ctx context.Context, file *cloudStorageSinkFile) error {
 	defer file.alloc.Release(ctx)
 
-	if file.rawSize == 0 {
+	if file.numMessages == 0 {
 		// This method shouldn't be called with an empty file, but be defensive
 		// about not writing empty files anyway.
 		return nil
 	}
 
+	if file.parquetWriter != nil {
+		// Closing the writer flushes the last row group and the file footer.
+		if err := file.parquetWriter.Close(); err != nil {
+			return err
+		}
+	}
 	if file.codec != nil {
 		if err := file.codec.Close(); err != nil {
 			return err
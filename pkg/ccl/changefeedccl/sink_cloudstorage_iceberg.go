@@ -0,0 +1,270 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package changefeedccl
+
+import (
+	"context"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/cloud"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/iceberg"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/errors"
+	"github.com/fraugster/parquet-go/parquetschema"
+)
+
+const (
+	// icebergDir is the directory of the cloud storage sink holding the
+	// Iceberg table of each topic.
+	icebergDir = "iceberg"
+	// icebergResolvedProperty is the property of the summary of the snapshots
+	// of a table holding the resolved timestamp that the snapshot commits,
+	// formatted like the timestamps of the file names.
+	icebergResolvedProperty = "crdb.resolved"
+)
+
+// cloudStorageIcebergCommitter maintains an Iceberg table of the parquet files
+// of each topic of a cloudStorageSink, in the iceberg/<topic> directory of the
+// sink. Each resolved timestamp emitted by the sink commits a snapshot to the
+// table of each topic with new files, which appends the data files that the
+// resolved timestamp covers. The tables thus only ever contain resolved data.
+//
+// The files covered by a resolved timestamp R are the data files whose
+// timestamp is at most R: the sink writes them all before R is emitted, and
+// never writes such a file after (see the comment on cloudStorageSink). Each
+// snapshot records R in its summary, so that the files committed by a
+// previous job session are not committed again.
+type cloudStorageIcebergCommitter struct {
+	// location is the URI of the sink, which the tables use to reference the
+	// data files.
+	location string
+	// committed is the last resolved timestamp committed by this sink, or
+	// empty if it has not committed yet. All the data files of a lesser or
+	// equal timestamp are committed.
+	committed hlc.Timestamp
+	// resolved caches the last resolved timestamp committed to the table of
+	// each topic, formatted like the timestamps of the file names.
+	resolved map[string]string
+}
+
+func newCloudStorageIcebergCommitter(location string) *cloudStorageIcebergCommitter {
+	return &cloudStorageIcebergCommitter{location: location, resolved: make(map[string]string)}
+}
+
+// cloudStorageDataFile is a data file written by a cloudStorageSink.
+type cloudStorageDataFile struct {
+	// name is the path of the file relative to the sink.
+	name     string
+	ts       string
+	topic    string
+	schemaID int64
+}
+
+// parseCloudStorageDataFile parses the name of a data file written by a
+// cloudStorageSink, which has the given extension. It returns false if the
+// name is not the name of such a file.
+func parseCloudStorageDataFile(name, ext string) (cloudStorageDataFile, bool) {
+	base := path.Base(name)
+	if !strings.HasSuffix(base, ext) {
+		return cloudStorageDataFile{}, false
+	}
+	// The file name is <ts>-<session_id>-<node_id>-<sink_id>-<file_id>-
+	// <topic>-<schema_id><ext>, and the topic is the only part which can hold
+	// dashes.
+	parts := strings.SplitN(strings.TrimSuffix(base, ext), "-", 6)
+	if len(parts) != 6 {
+		return cloudStorageDataFile{}, false
+	}
+	i := strings.LastIndexByte(parts[5], '-')
+	if i < 0 {
+		return cloudStorageDataFile{}, false
+	}
+	schemaID, err := strconv.ParseInt(parts[5][i+1:], 16, 64)
+	if err != nil {
+		return cloudStorageDataFile{}, false
+	}
+	return cloudStorageDataFile{
+		name:     name,
+		ts:       parts[0],
+		topic:    parts[5][:i],
+		schemaID: schemaID,
+	}, true
+}
+
+// commit commits the data files covered by the resolved timestamp to the
+// tables of their topics.
+func (c *cloudStorageIcebergCommitter) commit(
+	ctx context.Context,
+	es cloud.ExternalStorage,
+	partitionFormat, ext string,
+	resolved hlc.Timestamp,
+) error {
+	resolvedTs := cloudStorageFormatTime(resolved)
+	files, err := c.listDataFiles(ctx, es, partitionFormat, ext, resolved)
+	if err != nil {
+		return err
+	}
+	byTopic := make(map[string][]cloudStorageDataFile)
+	for _, f := range files {
+		if f.ts <= resolvedTs {
+			byTopic[f.topic] = append(byTopic[f.topic], f)
+		}
+	}
+	topics := make([]string, 0, len(byTopic))
+	for topic := range byTopic {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	for _, topic := range topics {
+		if err := c.commitTopic(ctx, es, topic, byTopic[topic], resolvedTs); err != nil {
+			return errors.Wrapf(err, "committing to iceberg table of topic %s", topic)
+		}
+	}
+	c.committed = resolved
+	return nil
+}
+
+// flatFileHourFormat formats the hour of a timestamp like the start of the
+// names of the data files written at that timestamp (see
+// cloudStorageFormatTime), so that the files of a flat sink can be listed by
+// hour.
+const flatFileHourFormat = "2006010215"
+
+// listDataFiles lists the data files of the sink which may not be committed
+// yet.
+func (c *cloudStorageIcebergCommitter) listDataFiles(
+	ctx context.Context,
+	es cloud.ExternalStorage,
+	partitionFormat, ext string,
+	resolved hlc.Timestamp,
+) ([]cloudStorageDataFile, error) {
+	flat := partitionFormat == partitionDateFormats["flat"]
+	var prefixes []string
+	var delimiter string
+	if flat {
+		// The data files are in the root directory of the sink, next to the
+		// directory of the iceberg tables.
+		delimiter = "/"
+	}
+	switch {
+	case c.committed.IsEmpty():
+		// The sink has not committed yet, so the files of any partition may not
+		// be committed.
+		prefixes = []string{""}
+	default:
+		// Data files are in the partition of their timestamp, or named after it
+		// when the sink is flat, so only the partitions (or hours) between the
+		// last commit and the resolved timestamp can hold files which are not
+		// committed.
+		format := partitionFormat
+		if flat {
+			format = flatFileHourFormat
+		}
+		end := resolved.GoTime()
+		for t := c.committed.GoTime().Truncate(time.Hour); !t.After(end); t = t.Add(time.Hour) {
+			if p := t.Format(format); len(prefixes) == 0 || prefixes[len(prefixes)-1] != p {
+				prefixes = append(prefixes, p)
+			}
+		}
+	}
+
+	var files []cloudStorageDataFile
+	for _, prefix := range prefixes {
+		if err := es.List(ctx, prefix, delimiter, func(name string) error {
+			// Listed names are relative to the prefix, which is a directory unless
+			// the sink is flat.
+			if flat {
+				name = prefix + name
+			} else {
+				name = strings.TrimPrefix(path.Join(prefix, name), "/")
+			}
+			if strings.HasPrefix(name, icebergDir+"/") {
+				return nil
+			}
+			if f, ok := parseCloudStorageDataFile(name, ext); ok {
+				files = append(files, f)
+			}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// commitTopic commits the given data files of the topic which are not
+// committed yet to the table of the topic, in a snapshot of the resolved
+// timestamp.
+func (c *cloudStorageIcebergCommitter) commitTopic(
+	ctx context.Context,
+	es cloud.ExternalStorage,
+	topic string,
+	files []cloudStorageDataFile,
+	resolvedTs string,
+) error {
+	dir := path.Join(icebergDir, topic)
+	table := iceberg.NewTable(es, dir, c.location+"/"+dir)
+	committed, ok := c.resolved[topic]
+	if !ok {
+		summary, err := table.CurrentSnapshotSummary(ctx)
+		if err != nil {
+			return err
+		}
+		committed = summary[icebergResolvedProperty]
+		c.resolved[topic] = committed
+	}
+
+	// The schema of the table evolves through the schemas of the files in the
+	// order of their versions.
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].schemaID != files[j].schemaID {
+			return files[i].schemaID < files[j].schemaID
+		}
+		return files[i].name < files[j].name
+	})
+	var dataFiles []iceberg.DataFile
+	var defs []*parquetschema.SchemaDefinition
+	lastSchemaID := int64(-1)
+	for _, f := range files {
+		if f.ts <= committed {
+			continue
+		}
+		records, size, def, err := iceberg.ReadParquetFooter(ctx, es, f.name)
+		if err != nil {
+			return err
+		}
+		if f.schemaID != lastSchemaID {
+			defs = append(defs, def)
+			lastSchemaID = f.schemaID
+		}
+		dataFiles = append(dataFiles, iceberg.DataFile{
+			Path:        c.location + "/" + f.name,
+			RecordCount: records,
+			SizeBytes:   size,
+		})
+	}
+	if len(dataFiles) == 0 {
+		return nil
+	}
+
+	if log.V(1) {
+		log.Infof(ctx, "committing %d files to iceberg table %s at %s", len(dataFiles), dir, resolvedTs)
+	}
+	properties := map[string]string{icebergResolvedProperty: resolvedTs}
+	if err := table.Append(ctx, defs, dataFiles, properties); err != nil {
+		return err
+	}
+	c.resolved[topic] = resolvedTs
+	return nil
+}
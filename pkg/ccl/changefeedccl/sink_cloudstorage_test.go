@@ -13,6 +13,7 @@ import (
 	"compress/gzip"
 	"context"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"math"
 	"net/url"
@@ -35,11 +36,14 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/tabledesc"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
 	"github.com/cockroachdb/cockroach/pkg/testutils"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/iceberg"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/span"
+	goparquet "github.com/fraugster/parquet-go"
 	"github.com/stretchr/testify/require"
 )
 
@@ -711,4 +715,232 @@ func TestCloudStorageSink(t *testing.T) {
 			"w1\n",
 		}, slurpDir(t, dir))
 	})
+
+	t.Run(`parquet`, func(t *testing.T) {
+		tableDesc, err := parseTableDesc(`CREATE TABLE foo (a INT PRIMARY KEY, b STRING)`)
+		require.NoError(t, err)
+		rows, err := parseValues(tableDesc, `VALUES (1, 'one'), (2, 'two')`)
+		require.NoError(t, err)
+		topic := &tableDescriptorTopic{
+			tableDesc: tableDesc,
+			spec: jobspb.ChangefeedTargetSpecification{
+				Type:              jobspb.ChangefeedTargetSpecification_PRIMARY_FAMILY_ONLY,
+				TableID:           tableDesc.GetID(),
+				StatementTimeName: `foo`,
+			},
+		}
+		parquetOpts := map[string]string{
+			changefeedbase.OptFormat:            string(changefeedbase.OptFormatParquet),
+			changefeedbase.OptEnvelope:          string(changefeedbase.OptEnvelopeWrapped),
+			changefeedbase.OptKeyInValue:        ``,
+			changefeedbase.OptUpdatedTimestamps: ``,
+			changefeedbase.OptCompression:       sinkCompressionGzip,
+		}
+		enc, err := newParquetEncoder(parquetOpts, nil /* targets */)
+		require.NoError(t, err)
+
+		testSpan := roachpb.Span{Key: []byte("a"), EndKey: []byte("b")}
+		sf, err := span.MakeFrontier(testSpan)
+		require.NoError(t, err)
+		timestampOracle := &changeAggregatorLowerBoundOracle{sf: sf}
+		sinkDir := `parquet`
+		u := sinkURI(sinkDir, unlimitedFileSize)
+		u.addParam(changefeedbase.SinkParamParquetRowGroupSize, `1KiB`)
+		s, err := makeCloudStorageSink(
+			ctx, u, 1, settings, parquetOpts, timestampOracle, externalStorageFromURI, user, nil,
+		)
+		require.NoError(t, err)
+		defer func() { require.NoError(t, s.Close()) }()
+
+		emit := func(datums rowenc.EncDatumRow, deleted bool, updated hlc.Timestamp) {
+			schema, record, err := enc.encodeRecord(encodeRow{
+				datums:    datums,
+				deleted:   deleted,
+				updated:   updated,
+				tableDesc: tableDesc,
+			})
+			require.NoError(t, err)
+			require.NoError(t, s.(parquetSink).EmitParquetRow(
+				ctx, topic, schema, record, updated, updated, zeroAlloc,
+			))
+		}
+		emit(rows[0], false /* deleted */, ts(1))
+		emit(rows[1], false /* deleted */, ts(2))
+		emit(rows[0], true /* deleted */, ts(3))
+		require.NoError(t, s.Flush(ctx))
+
+		// Parquet files are compressed internally, so their extension does not
+		// change with the compression option.
+		paths, err := filepath.Glob(filepath.Join(dir, sinkDir, `*`, `*.parquet`))
+		require.NoError(t, err)
+		require.Len(t, paths, 1)
+		f, err := os.Open(paths[0])
+		require.NoError(t, err)
+		defer f.Close()
+		fr, err := goparquet.NewFileReader(f)
+		require.NoError(t, err)
+		require.EqualValues(t, 3, fr.NumRows())
+
+		var records []string
+		for {
+			row, err := fr.NextRow()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			b, _ := row[`b`].([]byte)
+			records = append(records, fmt.Sprintf(`%v,%s,%s,%s`,
+				row[`a`], b, row[parquetEventTypeColName], row[parquetUpdatedColName]))
+		}
+		require.Equal(t, []string{
+			`1,one,upsert,` + ts(1).AsOfSystemTime(),
+			`2,two,upsert,` + ts(2).AsOfSystemTime(),
+			`1,,delete,` + ts(3).AsOfSystemTime(),
+		}, records)
+
+		// Resolved timestamps are written in JSON, like for the other formats.
+		require.NoError(t, s.EmitResolvedTimestamp(ctx, enc, ts(5)))
+		resolvedFile, err := ioutil.ReadFile(filepath.Join(
+			dir, sinkDir, `1970-01-01`, `197001010000000000000050000000000.RESOLVED`))
+		require.NoError(t, err)
+		require.Equal(t, `{"resolved":"5.0000000000"}`, string(resolvedFile))
+
+		// The row group size is only usable with parquet files.
+		u = sinkURI(sinkDir, unlimitedFileSize)
+		u.addParam(changefeedbase.SinkParamParquetRowGroupSize, `1KiB`)
+		_, err = makeCloudStorageSink(
+			ctx, u, 1, settings, opts, timestampOracle, externalStorageFromURI, user, nil,
+		)
+		require.EqualError(t, err, `parquet_row_group_size is only usable with format=parquet`)
+	})
+
+	t.Run(`iceberg`, func(t *testing.T) {
+		tableDesc, err := parseTableDesc(`CREATE TABLE foo (a INT PRIMARY KEY, b STRING)`)
+		require.NoError(t, err)
+		rows, err := parseValues(tableDesc, `VALUES (1, 'one'), (2, 'two'), (3, 'three')`)
+		require.NoError(t, err)
+		topic := &tableDescriptorTopic{
+			tableDesc: tableDesc,
+			spec: jobspb.ChangefeedTargetSpecification{
+				Type:              jobspb.ChangefeedTargetSpecification_PRIMARY_FAMILY_ONLY,
+				TableID:           tableDesc.GetID(),
+				StatementTimeName: `foo`,
+			},
+		}
+		parquetOpts := map[string]string{
+			changefeedbase.OptFormat:     string(changefeedbase.OptFormatParquet),
+			changefeedbase.OptEnvelope:   string(changefeedbase.OptEnvelopeWrapped),
+			changefeedbase.OptKeyInValue: ``,
+		}
+		enc, err := newParquetEncoder(parquetOpts, nil /* targets */)
+		require.NoError(t, err)
+
+		testSpan := roachpb.Span{Key: []byte("a"), EndKey: []byte("b")}
+		sf, err := span.MakeFrontier(testSpan)
+		require.NoError(t, err)
+		timestampOracle := &changeAggregatorLowerBoundOracle{sf: sf}
+		sinkDir := `iceberg-sink`
+		makeSink := func() *cloudStorageSink {
+			u := sinkURI(sinkDir, unlimitedFileSize)
+			u.addParam(changefeedbase.SinkParamTableFormat, sinkTableFormatIceberg)
+			s, err := makeCloudStorageSink(
+				ctx, u, 1, settings, parquetOpts, timestampOracle, externalStorageFromURI, user, nil,
+			)
+			require.NoError(t, err)
+			return s.(*cloudStorageSink)
+		}
+		s := makeSink()
+		defer func() { require.NoError(t, s.Close()) }()
+
+		emit := func(datums rowenc.EncDatumRow, updated hlc.Timestamp) {
+			schema, record, err := enc.encodeRecord(encodeRow{
+				datums:    datums,
+				updated:   updated,
+				tableDesc: tableDesc,
+			})
+			require.NoError(t, err)
+			require.NoError(t, s.EmitParquetRow(ctx, topic, schema, record, updated, updated, zeroAlloc))
+		}
+		table := iceberg.NewTable(s.es, `iceberg/foo`, `nodelocal://0/`+sinkDir+`/iceberg/foo`)
+		summary := func() map[string]string {
+			summary, err := table.CurrentSnapshotSummary(ctx)
+			require.NoError(t, err)
+			return summary
+		}
+
+		// Files are only committed to the table once a resolved timestamp
+		// covers them.
+		emit(rows[0], ts(1))
+		emit(rows[1], ts(2))
+		require.NoError(t, s.Flush(ctx))
+		require.Nil(t, summary())
+		require.NoError(t, s.EmitResolvedTimestamp(ctx, enc, ts(5)))
+		require.Equal(t, "1", summary()["total-data-files"])
+		require.Equal(t, "2", summary()["total-records"])
+		require.Equal(t, cloudStorageFormatTime(ts(5)), summary()[icebergResolvedProperty])
+
+		// Each resolved timestamp appends the files it covers in a new snapshot.
+		_, err = sf.Forward(testSpan, ts(5))
+		require.NoError(t, err)
+		require.NoError(t, s.Flush(ctx))
+		emit(rows[2], ts(6))
+		require.NoError(t, s.Flush(ctx))
+		require.NoError(t, s.EmitResolvedTimestamp(ctx, enc, ts(7)))
+		require.Equal(t, "1", summary()["added-data-files"])
+		require.Equal(t, "2", summary()["total-data-files"])
+		require.Equal(t, "3", summary()["total-records"])
+		require.Equal(t, cloudStorageFormatTime(ts(7)), summary()[icebergResolvedProperty])
+
+		// A sink of a new job session does not commit the files again.
+		s2 := makeSink()
+		defer func() { require.NoError(t, s2.Close()) }()
+		require.NoError(t, s2.EmitResolvedTimestamp(ctx, enc, ts(8)))
+		require.Equal(t, "2", summary()["total-data-files"])
+		require.Equal(t, cloudStorageFormatTime(ts(7)), summary()[icebergResolvedProperty])
+
+		// A flat sink lists its files by the hours of their names once it has
+		// committed.
+		flatSinkDir := `iceberg-flat-sink`
+		u := sinkURI(flatSinkDir, unlimitedFileSize)
+		u.addParam(changefeedbase.SinkParamTableFormat, sinkTableFormatIceberg)
+		u.addParam(changefeedbase.SinkParamPartitionFormat, `flat`)
+		flatSink, err := makeCloudStorageSink(
+			ctx, u, 1, settings, parquetOpts, timestampOracle, externalStorageFromURI, user, nil,
+		)
+		require.NoError(t, err)
+		defer func() { require.NoError(t, flatSink.Close()) }()
+		hour := func(h int64) hlc.Timestamp { return hlc.Timestamp{WallTime: h * time.Hour.Nanoseconds()} }
+		flatTable := iceberg.NewTable(
+			flatSink.(*cloudStorageSink).es, `iceberg/foo`, `nodelocal://0/`+flatSinkDir+`/iceberg/foo`)
+		flatSummary := func() map[string]string {
+			summary, err := flatTable.CurrentSnapshotSummary(ctx)
+			require.NoError(t, err)
+			return summary
+		}
+		for i, row := range rows {
+			updated := hour(int64(2 * (i + 1)))
+			_, err = sf.Forward(testSpan, updated)
+			require.NoError(t, err)
+			require.NoError(t, flatSink.Flush(ctx))
+			schema, record, err := enc.encodeRecord(encodeRow{
+				datums:    row,
+				updated:   updated,
+				tableDesc: tableDesc,
+			})
+			require.NoError(t, err)
+			require.NoError(t, flatSink.EmitParquetRow(ctx, topic, schema, record, updated, updated, zeroAlloc))
+			require.NoError(t, flatSink.Flush(ctx))
+			require.NoError(t, flatSink.EmitResolvedTimestamp(ctx, enc, updated))
+			require.Equal(t, "1", flatSummary()["added-data-files"])
+			require.Equal(t, strconv.Itoa(i+1), flatSummary()["total-data-files"])
+		}
+
+		// Iceberg tables are only supported for parquet files.
+		u = sinkURI(sinkDir, unlimitedFileSize)
+		u.addParam(changefeedbase.SinkParamTableFormat, sinkTableFormatIceberg)
+		_, err = makeCloudStorageSink(
+			ctx, u, 1, settings, opts, timestampOracle, externalStorageFromURI, user, nil,
+		)
+		require.EqualError(t, err, `table_format=iceberg is only usable with format=parquet`)
+	})
 }
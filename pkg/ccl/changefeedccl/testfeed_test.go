@@ -16,6 +16,7 @@ import (
 	"encoding/base64"
 	gojson "encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"math/rand"
 	"net/url"
@@ -31,6 +32,7 @@ import (
 	"github.com/cockroachdb/cockroach-go/v2/crdb"
 	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/cdctest"
 	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/changefeedbase"
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/kvevent"
 	"github.com/cockroachdb/cockroach/pkg/jobs"
 	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
 	"github.com/cockroachdb/cockroach/pkg/kv"
@@ -50,6 +52,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
 	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
 	"github.com/cockroachdb/errors"
+	goparquet "github.com/fraugster/parquet-go"
 	"github.com/jackc/pgx/v4"
 )
 
@@ -479,7 +482,24 @@ func (s *notifyFlushSink) Flush(ctx context.Context) error {
 	return s.Sink.Flush(ctx)
 }
 
+// EmitParquetRow implements the parquetSink interface.
+func (s *notifyFlushSink) EmitParquetRow(
+	ctx context.Context,
+	topic TopicDescriptor,
+	schema *parquetSchema,
+	record map[string]interface{},
+	updated, mvcc hlc.Timestamp,
+	alloc kvevent.Alloc,
+) error {
+	ps, ok := s.Sink.(parquetSink)
+	if !ok {
+		return errors.AssertionFailedf("sink %T does not support parquet", s.Sink)
+	}
+	return ps.EmitParquetRow(ctx, topic, schema, record, updated, mvcc, alloc)
+}
+
 var _ Sink = (*notifyFlushSink)(nil)
+var _ parquetSink = (*notifyFlushSink)(nil)
 
 // feedInjectable is the subset of the
 // TestServerInterface/TestTenantInterface needed for depInjector to
@@ -944,7 +964,7 @@ func (c *cloudFeed) Next() (*cdctest.TestFeedMessage, error) {
 					}
 					m.Resolved = nil
 					return m, nil
-				case changefeedbase.OptFormatCSV:
+				case changefeedbase.OptFormatCSV, changefeedbase.OptFormatParquet:
 					return m, nil
 				default:
 					return nil, errors.Errorf(`unknown %s: %s`, changefeedbase.OptFormat, v)
@@ -1017,6 +1037,9 @@ func (c *cloudFeed) walkDir(path string, info os.FileInfo, err error) error {
 		return err
 	}
 	defer f.Close()
+	if strings.HasSuffix(path, `.parquet`) {
+		return c.readParquetFile(f, topic)
+	}
 	// NB: This is the logic for JSON. Avro will involve parsing an
 	// "Object Container File".
 	s := bufio.NewScanner(f)
@@ -1029,6 +1052,41 @@ func (c *cloudFeed) walkDir(path string, info os.FileInfo, err error) error {
 	return nil
 }
 
+// readParquetFile appends the records of a parquet file to the rows of the
+// feed. Each record is formatted as a JSON object of its columns, like the
+// values of format=json.
+func (c *cloudFeed) readParquetFile(f *os.File, topic string) error {
+	fr, err := goparquet.NewFileReader(f)
+	if err != nil {
+		return err
+	}
+	for {
+		row, err := fr.NextRow()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		for k, v := range row {
+			if b, ok := v.([]byte); ok {
+				row[k] = string(b)
+			}
+		}
+		// Null values are omitted from the rows of the reader.
+		for _, col := range fr.Columns() {
+			if _, ok := row[col.Name()]; !ok {
+				row[col.Name()] = nil
+			}
+		}
+		value, err := reformatJSON(row)
+		if err != nil {
+			return err
+		}
+		c.rows = append(c.rows, cloudFeedEntry{topic: topic, value: value})
+	}
+}
+
 // teeGroup facilitates reading messages from input channel
 // and sending them to one or more output channels.
 type teeGroup struct {
@@ -33,12 +33,19 @@ Valid fields:
 * path    (required): must match the path of one of the stores
 * key     (required): path to the current key file, or "plain"
 * old-key (required): path to the previous key file, or "plain"
+* kms               : URI of a KMS master key, instead of key and old-key
 * rotation-period   : amount of time after which data keys should be rotated
 
 </PRE>
-example:
+With kms, the node generates AES-256 store keys itself and stores them in
+the store directory wrapped by the KMS master key, which is used to unwrap
+them when the node starts. Such store keys can be rotated without a restart.
+The KMS URI must not contain commas.
+
+examples:
 <PRE>
-  --enterprise-encryption=path=cockroach-data,key=/keys/aes-128.key,old-key=plain</PRE>
+  --enterprise-encryption=path=cockroach-data,key=/keys/aes-128.key,old-key=plain
+  --enterprise-encryption=path=cockroach-data,kms=aws:///{key-arn}?AUTH=implicit&REGION=us-east-1</PRE>
 `,
 	}
 )
@@ -0,0 +1,192 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+// Package jwtauthccl implements the "jwt" HBA authentication method, which
+// authenticates SQL clients using a JSON Web Token (JWT) issued by an
+// external identity provider, passed in place of the password.
+package jwtauthccl
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/utilccl"
+	"github.com/cockroachdb/cockroach/pkg/security"
+	"github.com/cockroachdb/cockroach/pkg/security/username"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/hba"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/identmap"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/errors"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// authTypeCleartextPassword is the pgwire auth request code asking the
+// client for its password in cleartext.
+const authTypeCleartextPassword int32 = 3
+
+// authJWT is the AuthMethod constructor for HBA method "jwt": the client
+// sends a JWT in place of its password. The token must be signed by one
+// of the keys of the server.jwt_authentication.jwks setting, be issued by
+// one of the server.jwt_authentication.issuers for the
+// server.jwt_authentication.audience, and be unexpired. The value of the
+// server.jwt_authentication.claim claim of the token, mapped through the
+// "map" option of the HBA entry if any, must then match the requested
+// SQL user.
+func authJWT(
+	_ context.Context,
+	c pgwire.AuthConn,
+	_ tls.ConnectionState,
+	execCfg *sql.ExecutorConfig,
+	entry *hba.Entry,
+	identMap *identmap.Conf,
+) (*pgwire.AuthBehaviors, error) {
+	behaviors := &pgwire.AuthBehaviors{}
+	behaviors.SetRoleMapper(pgwire.UseProvidedIdentity)
+	tokenMapper := pgwire.HbaMapper(entry, identMap)
+	behaviors.SetAuthenticator(func(
+		ctx context.Context,
+		systemIdentity username.SQLUsername,
+		_ bool,
+		_ pgwire.PasswordRetrievalFn,
+	) error {
+		if err := c.SendAuthRequest(authTypeCleartextPassword, nil /* data */); err != nil {
+			return err
+		}
+		pwdData, err := c.GetPwdData()
+		if err != nil {
+			return err
+		}
+		if bytes.IndexByte(pwdData, 0) != len(pwdData)-1 {
+			return errors.New("expected 0-terminated byte array")
+		}
+		token := string(pwdData[:len(pwdData)-1])
+
+		if err := verifyToken(ctx, &execCfg.Settings.SV, tokenMapper, token, systemIdentity, timeutil.Now()); err != nil {
+			// The details of the failure are only logged, so as to not
+			// disclose the configuration to clients.
+			c.LogAuthInfof(ctx, "JWT authentication failed: %v", err)
+			return security.NewErrPasswordUserAuthFailed(systemIdentity)
+		}
+
+		// As for GSS, do the license check last so that administrators are
+		// able to test whether their JWT configuration is correct.
+		return utilccl.CheckEnterpriseEnabled(
+			execCfg.Settings, execCfg.LogicalClusterID(), execCfg.Organization(), "JWT authentication")
+	})
+	return behaviors, nil
+}
+
+// verifyToken checks that token is a valid JWT according to the cluster
+// settings, and that its identity claim maps to user.
+func verifyToken(
+	ctx context.Context,
+	sv *settings.Values,
+	mapper pgwire.RoleMapper,
+	token string,
+	user username.SQLUsername,
+	now time.Time,
+) error {
+	tok, err := jwt.ParseSigned(token)
+	if err != nil {
+		return errors.Wrap(err, "could not parse JWT")
+	}
+	jwks, err := parseJWKS(JWTAuthJWKS.Get(sv))
+	if err != nil {
+		return err
+	}
+
+	// Select the candidate keys using the key ID of the token, if any.
+	var kid, alg string
+	if len(tok.Headers) > 0 {
+		kid, alg = tok.Headers[0].KeyID, tok.Headers[0].Algorithm
+	}
+	keys := jwks.Keys
+	if kid != "" {
+		keys = jwks.Key(kid)
+	}
+	var claims jwt.Claims
+	var allClaims map[string]interface{}
+	verified := false
+	for i := range keys {
+		if keys[i].Algorithm != "" && keys[i].Algorithm != alg {
+			continue
+		}
+		if tok.Claims(keys[i].Key, &claims, &allClaims) == nil {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return errors.Newf("JWT signature could not be verified with any key of the JWKS (kid %q)", kid)
+	}
+
+	issuerOK := false
+	for _, iss := range splitIssuers(JWTAuthIssuers.Get(sv)) {
+		issuerOK = issuerOK || iss == claims.Issuer
+	}
+	if !issuerOK {
+		return errors.Newf("JWT issuer %q is not accepted", claims.Issuer)
+	}
+	audience := JWTAuthAudience.Get(sv)
+	if audience == "" {
+		return errors.Newf("%s is not set", JWTAuthAudienceSettingName)
+	}
+	if claims.Expiry == nil {
+		return errors.New("JWT has no expiration time")
+	}
+	if err := claims.ValidateWithLeeway(jwt.Expected{
+		Audience: jwt.Audience{audience},
+		Time:     now,
+	}, jwt.DefaultLeeway); err != nil {
+		return err
+	}
+
+	claimName := JWTAuthClaim.Get(sv)
+	principal, ok := allClaims[claimName].(string)
+	if !ok || principal == "" {
+		return errors.Newf("JWT has no string claim %q", claimName)
+	}
+	principalUser, err := username.MakeSQLUsernameFromUserInput(principal, username.PurposeValidation)
+	if err != nil {
+		return err
+	}
+	users, err := mapper(ctx, principalUser)
+	if err != nil {
+		return err
+	}
+	for _, u := range users {
+		if u == user {
+			return nil
+		}
+	}
+	return errors.Newf("JWT principal %q does not map to user %s", principal, user)
+}
+
+// checkEntry validates the options of an HBA entry using the "jwt" method.
+func checkEntry(_ *settings.Values, entry hba.Entry) error {
+	for _, op := range entry.Options {
+		switch op[0] {
+		case "map":
+		// OK.
+		default:
+			return errors.Errorf("unsupported option %s", op[0])
+		}
+	}
+	return nil
+}
+
+func init() {
+	// The token is a bearer credential, so only accept it over TLS
+	// connections.
+	pgwire.RegisterAuthMethod("jwt", authJWT, hba.ConnHostSSL, checkEntry)
+}
@@ -0,0 +1,218 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package jwtauthccl
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/security/username"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/hba"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/identmap"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+const (
+	testIssuer   = "https://accounts.example.com"
+	testAudience = "cockroach"
+)
+
+func makeSigner(t *testing.T, key *rsa.PrivateKey, kid string) jose.Signer {
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.RS256, Key: jose.JSONWebKey{Key: key, KeyID: kid}},
+		(&jose.SignerOptions{}).WithType("JWT"))
+	require.NoError(t, err)
+	return signer
+}
+
+func makeToken(t *testing.T, signer jose.Signer, claims jwt.Claims, extra map[string]interface{}) string {
+	token, err := jwt.Signed(signer).Claims(claims).Claims(extra).CompactSerialize()
+	require.NoError(t, err)
+	return token
+}
+
+func TestVerifyToken(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+
+	key1, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	key2, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	jwks, err := json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+		{Key: &key1.PublicKey, KeyID: "key1", Algorithm: string(jose.RS256), Use: "sig"},
+	}})
+	require.NoError(t, err)
+
+	st := cluster.MakeTestingClusterSettings()
+	JWTAuthIssuers.Override(ctx, &st.SV, "https://other.example.com, "+testIssuer)
+	JWTAuthAudience.Override(ctx, &st.SV, testAudience)
+	JWTAuthJWKS.Override(ctx, &st.SV, string(jwks))
+
+	identMap, err := identmap.From(strings.NewReader(`jwt /^(.*)@example.com$ \1`))
+	require.NoError(t, err)
+	mapped := pgwire.HbaMapper(&hba.Entry{Options: [][2]string{{"map", "jwt"}}}, identMap)
+
+	now := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+	validClaims := jwt.Claims{
+		Issuer:   testIssuer,
+		Subject:  "carl",
+		Audience: jwt.Audience{testAudience},
+		Expiry:   jwt.NewNumericDate(now.Add(5 * time.Minute)),
+		IssuedAt: jwt.NewNumericDate(now.Add(-time.Minute)),
+	}
+	withClaims := func(f func(c *jwt.Claims)) jwt.Claims {
+		c := validClaims
+		f(&c)
+		return c
+	}
+	signer1 := makeSigner(t, key1, "key1")
+	carl := username.MakeSQLUsernameFromPreNormalizedString("carl")
+
+	testCases := []struct {
+		name   string
+		token  string
+		mapper pgwire.RoleMapper
+		claim  string
+		err    string
+	}{
+		{
+			name:  "valid",
+			token: makeToken(t, signer1, validClaims, nil),
+		},
+		{
+			name:  "malformed",
+			token: "not.a.token",
+			err:   "could not parse JWT",
+		},
+		{
+			name:  "unknown key",
+			token: makeToken(t, makeSigner(t, key2, "key2"), validClaims, nil),
+			err:   `JWT signature could not be verified with any key of the JWKS (kid "key2")`,
+		},
+		{
+			name:  "wrong key",
+			token: makeToken(t, makeSigner(t, key2, "key1"), validClaims, nil),
+			err:   `JWT signature could not be verified with any key of the JWKS (kid "key1")`,
+		},
+		{
+			name: "wrong issuer",
+			token: makeToken(t, signer1, withClaims(func(c *jwt.Claims) {
+				c.Issuer = "https://evil.example.com"
+			}), nil),
+			err: `JWT issuer "https://evil.example.com" is not accepted`,
+		},
+		{
+			name: "wrong audience",
+			token: makeToken(t, signer1, withClaims(func(c *jwt.Claims) {
+				c.Audience = jwt.Audience{"other"}
+			}), nil),
+			err: "invalid audience claim",
+		},
+		{
+			name: "expired",
+			token: makeToken(t, signer1, withClaims(func(c *jwt.Claims) {
+				c.Expiry = jwt.NewNumericDate(now.Add(-time.Hour))
+			}), nil),
+			err: "token is expired",
+		},
+		{
+			name: "no expiry",
+			token: makeToken(t, signer1, withClaims(func(c *jwt.Claims) {
+				c.Expiry = nil
+			}), nil),
+			err: "JWT has no expiration time",
+		},
+		{
+			name: "other user",
+			token: makeToken(t, signer1, withClaims(func(c *jwt.Claims) {
+				c.Subject = "dora"
+			}), nil),
+			err: `JWT principal "dora" does not map to user carl`,
+		},
+		{
+			name:  "missing claim",
+			token: makeToken(t, signer1, validClaims, nil),
+			claim: "email",
+			err:   `JWT has no string claim "email"`,
+		},
+		{
+			name:   "mapped claim",
+			token:  makeToken(t, signer1, validClaims, map[string]interface{}{"email": "carl@example.com"}),
+			mapper: mapped,
+			claim:  "email",
+		},
+		{
+			name:   "unmapped claim",
+			token:  makeToken(t, signer1, validClaims, map[string]interface{}{"email": "carl@evil.com"}),
+			mapper: mapped,
+			claim:  "email",
+			err:    `JWT principal "carl@evil.com" does not map to user carl`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mapper := tc.mapper
+			if mapper == nil {
+				mapper = pgwire.UseProvidedIdentity
+			}
+			claim := tc.claim
+			if claim == "" {
+				claim = "sub"
+			}
+			JWTAuthClaim.Override(ctx, &st.SV, claim)
+			err := verifyToken(ctx, &st.SV, mapper, tc.token, carl, now)
+			if tc.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.err)
+			}
+		})
+	}
+}
+
+func TestJWKSSetting(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	private, err := json.Marshal(jose.JSONWebKey{Key: key, KeyID: "private"})
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		jwks string
+		err  string
+	}{
+		{jwks: `{"keys":[]}`},
+		{jwks: `{"keys":`, err: "JWKS not valid"},
+		{jwks: `{"keys":[` + string(private) + `]}`, err: `JWKS key "private" is not a public key`},
+	} {
+		_, err := parseJWKS(tc.jwks)
+		if tc.err == "" {
+			require.NoError(t, err)
+		} else {
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.err)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package jwtauthccl
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/errors"
+	"gopkg.in/square/go-jose.v2"
+)
+
+// All cluster settings necessary for the JWT authentication feature.
+const (
+	baseJWTAuthSettingName     = "server.jwt_authentication."
+	JWTAuthIssuersSettingName  = baseJWTAuthSettingName + "issuers"
+	JWTAuthAudienceSettingName = baseJWTAuthSettingName + "audience"
+	JWTAuthJWKSSettingName     = baseJWTAuthSettingName + "jwks"
+	JWTAuthClaimSettingName    = baseJWTAuthSettingName + "claim"
+)
+
+// JWTAuthIssuers is the list of issuers whose tokens are accepted.
+var JWTAuthIssuers = func() *settings.StringSetting {
+	s := settings.RegisterValidatedStringSetting(
+		settings.TenantWritable,
+		JWTAuthIssuersSettingName,
+		"sets the comma-separated list of accepted issuers of JWTs (the iss claim)",
+		"",
+		func(_ *settings.Values, s string) error {
+			for _, iss := range splitIssuers(s) {
+				if iss == "" {
+					return errors.New("JWT issuers cannot be empty")
+				}
+			}
+			return nil
+		},
+	).WithPublic()
+	s.SetReportable(true)
+	return s
+}()
+
+// JWTAuthAudience is the audience that JWTs must be issued for.
+var JWTAuthAudience = func() *settings.StringSetting {
+	s := settings.RegisterStringSetting(
+		settings.TenantWritable,
+		JWTAuthAudienceSettingName,
+		"sets the audience that JWTs must be issued for (the aud claim); usually "+
+			"the client ID of the cluster at the identity provider",
+		"",
+	).WithPublic()
+	s.SetReportable(true)
+	return s
+}()
+
+// JWTAuthJWKS is the JSON Web Key Set of the public keys used to verify
+// the signature of JWTs.
+var JWTAuthJWKS = func() *settings.StringSetting {
+	s := settings.RegisterValidatedStringSetting(
+		settings.TenantWritable,
+		JWTAuthJWKSSettingName,
+		"sets the JSON Web Key Set used to verify the signature of JWTs, "+
+			"as published by the issuers (usually at {issuer}/.well-known/jwks.json)",
+		`{"keys":[]}`,
+		func(_ *settings.Values, s string) error {
+			_, err := parseJWKS(s)
+			return err
+		},
+	).WithPublic()
+	s.SetReportable(false)
+	return s
+}()
+
+// JWTAuthClaim is the claim of JWTs identifying the user.
+var JWTAuthClaim = func() *settings.StringSetting {
+	s := settings.RegisterValidatedStringSetting(
+		settings.TenantWritable,
+		JWTAuthClaimSettingName,
+		"sets the JWT claim identifying the user (usually sub or email); its value "+
+			"is mapped to a SQL user with the map option of the HBA rule, if any",
+		"sub",
+		func(_ *settings.Values, s string) error {
+			if s == "" {
+				return errors.New("JWT claim cannot be empty")
+			}
+			return nil
+		},
+	).WithPublic()
+	s.SetReportable(true)
+	return s
+}()
+
+// splitIssuers splits the value of the JWTAuthIssuers setting.
+func splitIssuers(s string) []string {
+	if s == "" {
+		return nil
+	}
+	issuers := strings.Split(s, ",")
+	for i := range issuers {
+		issuers[i] = strings.TrimSpace(issuers[i])
+	}
+	return issuers
+}
+
+// parseJWKS parses the value of the JWTAuthJWKS setting.
+func parseJWKS(s string) (*jose.JSONWebKeySet, error) {
+	var jwks jose.JSONWebKeySet
+	if err := json.Unmarshal([]byte(s), &jwks); err != nil {
+		return nil, errors.Wrap(err, "JWKS not valid")
+	}
+	for _, k := range jwks.Keys {
+		if !k.Valid() {
+			return nil, errors.Newf("JWKS contains invalid key %q", k.KeyID)
+		}
+		if !k.IsPublic() {
+			return nil, errors.Newf("JWKS key %q is not a public key", k.KeyID)
+		}
+	}
+	return &jwks, nil
+}
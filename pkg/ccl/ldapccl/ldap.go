@@ -0,0 +1,242 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package ldapccl
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/utilccl"
+	"github.com/cockroachdb/cockroach/pkg/security"
+	"github.com/cockroachdb/cockroach/pkg/security/username"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/hba"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/identmap"
+	"github.com/cockroachdb/errors"
+	"gopkg.in/ldap.v2"
+)
+
+// authTypeCleartextPassword is the pgwire auth request code asking the
+// client for its password in cleartext.
+const authTypeCleartextPassword int32 = 3
+
+// ldapRequestTimeout bounds the duration of each request to the LDAP
+// server.
+const ldapRequestTimeout = 10 * time.Second
+
+// ldapConn is the subset of the LDAP client used during authentication.
+type ldapConn interface {
+	StartTLS(config *tls.Config) error
+	Bind(username, password string) error
+	Search(searchRequest *ldap.SearchRequest) (*ldap.SearchResult, error)
+	Close()
+}
+
+// dialLDAP opens a connection to the LDAP server configured in the HBA
+// entry. It is a variable so that tests can substitute a stub server.
+var dialLDAP = func(opts *hba.LDAPOptions) (ldapConn, error) {
+	addr := net.JoinHostPort(opts.Server, strconv.Itoa(opts.Port))
+	var conn *ldap.Conn
+	var err error
+	if opts.Scheme == "ldaps" {
+		conn, err = ldap.DialTLS("tcp", addr, &tls.Config{ServerName: opts.Server})
+	} else {
+		conn, err = ldap.Dial("tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(ldapRequestTimeout)
+	return conn, nil
+}
+
+// authLDAP performs LDAP authentication: the cleartext password of the
+// client is verified by binding to the LDAP server, either directly
+// (simple bind mode) or after looking up the DN of the user (search+bind
+// mode). See:
+// https://github.com/postgres/postgres/blob/REL_14_STABLE/src/backend/libpq/auth.c#L2330
+func authLDAP(
+	_ context.Context,
+	c pgwire.AuthConn,
+	_ tls.ConnectionState,
+	execCfg *sql.ExecutorConfig,
+	entry *hba.Entry,
+	_ *identmap.Conf,
+) (*pgwire.AuthBehaviors, error) {
+	opts, err := hba.ParseLDAPOptions(*entry)
+	if err != nil {
+		return nil, err
+	}
+
+	behaviors := &pgwire.AuthBehaviors{}
+	behaviors.SetRoleMapper(pgwire.UseProvidedIdentity)
+	behaviors.SetAuthenticator(func(
+		ctx context.Context,
+		systemIdentity username.SQLUsername,
+		_ bool,
+		_ pgwire.PasswordRetrievalFn,
+	) error {
+		if err := c.SendAuthRequest(authTypeCleartextPassword, nil /* data */); err != nil {
+			return err
+		}
+		pwdData, err := c.GetPwdData()
+		if err != nil {
+			return err
+		}
+		password, err := passwordString(pwdData)
+		if err != nil {
+			return err
+		}
+
+		groups, err := authenticate(opts, systemIdentity.Normalized(), password)
+		if err != nil {
+			// The details of the failure are only logged, so as to not
+			// disclose the structure of the directory to clients.
+			c.LogAuthInfof(ctx, "LDAP authentication failed: %v", err)
+			return security.NewErrPasswordUserAuthFailed(systemIdentity)
+		}
+
+		// As for GSS, do the license check last so that administrators are
+		// able to test whether their LDAP configuration is correct.
+		if err := utilccl.CheckEnterpriseEnabled(
+			execCfg.Settings, execCfg.LogicalClusterID(), execCfg.Organization(), "LDAP authentication",
+		); err != nil {
+			return err
+		}
+
+		if opts.GroupAttribute == "" {
+			return nil
+		}
+		roles := groupRoleNames(groups)
+		c.LogAuthInfof(ctx, "LDAP groups of user map to roles %s", roles)
+		return syncRoleMembership(ctx, execCfg, systemIdentity, roles)
+	})
+	return behaviors, nil
+}
+
+func passwordString(pwdData []byte) (string, error) {
+	if bytes.IndexByte(pwdData, 0) != len(pwdData)-1 {
+		return "", errors.New("expected 0-terminated byte array")
+	}
+	return string(pwdData[:len(pwdData)-1]), nil
+}
+
+// authenticate verifies the password of user against the LDAP server. If
+// the HBA entry sets ldapgroupattribute, the values of that attribute of
+// the user's entry are returned.
+func authenticate(opts *hba.LDAPOptions, user, password string) (groups []string, _ error) {
+	if password == "" {
+		// A bind with a DN and an empty password is an unauthenticated
+		// bind, which servers report as successful (RFC 4513, section
+		// 5.1.2). Reject it before contacting the server.
+		return nil, errors.New("empty password")
+	}
+
+	conn, err := dialLDAP(opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not connect to LDAP server")
+	}
+	defer conn.Close()
+	if opts.TLS {
+		if err := conn.StartTLS(&tls.Config{ServerName: opts.Server}); err != nil {
+			return nil, errors.Wrap(err, "could not start TLS session with LDAP server")
+		}
+	}
+
+	var userDN string
+	if opts.SearchBind() {
+		if userDN, err = searchUserDN(conn, opts, user); err != nil {
+			return nil, err
+		}
+	} else {
+		// The user name is spliced into the DN as-is, so reject the
+		// characters that would change its structure.
+		if strings.ContainsAny(user, `,+"\<>;=#`) {
+			return nil, errors.Newf("invalid character in user name %q for LDAP authentication", user)
+		}
+		userDN = opts.Prefix + user + opts.Suffix
+	}
+
+	if err := conn.Bind(userDN, password); err != nil {
+		return nil, errors.Wrapf(err, "LDAP bind failed for %q", userDN)
+	}
+
+	if opts.GroupAttribute == "" {
+		return nil, nil
+	}
+	return lookupGroups(conn, opts, userDN)
+}
+
+// searchUserDN returns the DN of the single entry under the base DN
+// matching user.
+func searchUserDN(conn ldapConn, opts *hba.LDAPOptions, user string) (string, error) {
+	if opts.BindDN != "" {
+		if err := conn.Bind(opts.BindDN, opts.BindPasswd); err != nil {
+			return "", errors.Wrapf(err, "LDAP bind failed for search user %q", opts.BindDN)
+		}
+	}
+
+	escaped := ldap.EscapeFilter(user)
+	filter := "(" + opts.SearchAttribute + "=" + escaped + ")"
+	if opts.SearchFilter != "" {
+		filter = strings.ReplaceAll(opts.SearchFilter, "$username", escaped)
+	}
+	res, err := conn.Search(ldap.NewSearchRequest(
+		opts.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
+		0 /* sizeLimit */, int(ldapRequestTimeout.Seconds()), false, /* typesOnly */
+		filter, []string{"dn"}, nil, /* controls */
+	))
+	if err != nil {
+		return "", errors.Wrapf(err, "could not search LDAP for filter %q", filter)
+	}
+	switch len(res.Entries) {
+	case 0:
+		return "", errors.Newf("LDAP user %q does not exist", user)
+	case 1:
+		return res.Entries[0].DN, nil
+	default:
+		return "", errors.Newf("LDAP user %q is not unique (%d matches)", user, len(res.Entries))
+	}
+}
+
+// lookupGroups returns the values of the group attribute of the entry
+// with the given DN.
+func lookupGroups(conn ldapConn, opts *hba.LDAPOptions, userDN string) ([]string, error) {
+	res, err := conn.Search(ldap.NewSearchRequest(
+		userDN, ldap.ScopeBaseObject, ldap.NeverDerefAliases,
+		0 /* sizeLimit */, int(ldapRequestTimeout.Seconds()), false, /* typesOnly */
+		"(objectClass=*)", []string{opts.GroupAttribute}, nil, /* controls */
+	))
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not retrieve LDAP groups of %q", userDN)
+	}
+	if len(res.Entries) != 1 {
+		return nil, errors.Newf("LDAP entry %q not found", userDN)
+	}
+	return res.Entries[0].GetAttributeValues(opts.GroupAttribute), nil
+}
+
+// checkEntry validates the options of an HBA entry using the "ldap" method.
+func checkEntry(_ *settings.Values, entry hba.Entry) error {
+	_, err := hba.ParseLDAPOptions(entry)
+	return err
+}
+
+func init() {
+	// The client password is sent in cleartext, so only accept LDAP
+	// authentication over TLS connections.
+	pgwire.RegisterAuthMethod("ldap", authLDAP, hba.ConnHostSSL, checkEntry)
+}
@@ -0,0 +1,194 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package ldapccl
+
+import (
+	"crypto/tls"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/security/username"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/hba"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ldap.v2"
+)
+
+// stubLDAPServer is an in-memory LDAP directory standing in for an LDAP
+// server. It only supports equality filters of the form (attr=value).
+type stubLDAPServer struct {
+	// entries maps the DN of each entry to its attributes.
+	entries map[string]map[string][]string
+	// passwords maps the DN of each entry to its password.
+	passwords map[string]string
+	// startedTLS is set once StartTLS has been called.
+	startedTLS bool
+}
+
+var _ ldapConn = (*stubLDAPServer)(nil)
+
+func (s *stubLDAPServer) StartTLS(*tls.Config) error {
+	s.startedTLS = true
+	return nil
+}
+
+func (s *stubLDAPServer) Bind(dn, password string) error {
+	if pw, ok := s.passwords[dn]; !ok || pw != password {
+		return ldap.NewError(ldap.LDAPResultInvalidCredentials, errors.New("invalid credentials"))
+	}
+	return nil
+}
+
+func (s *stubLDAPServer) Search(req *ldap.SearchRequest) (*ldap.SearchResult, error) {
+	res := &ldap.SearchResult{}
+	for dn, attrs := range s.entries {
+		if req.Scope == ldap.ScopeBaseObject {
+			if dn != req.BaseDN {
+				continue
+			}
+		} else {
+			if !strings.HasSuffix(dn, ","+req.BaseDN) {
+				continue
+			}
+			kv := strings.SplitN(strings.Trim(req.Filter, "()"), "=", 2)
+			if len(kv) != 2 {
+				return nil, errors.Newf("unsupported filter %q", req.Filter)
+			}
+			matched := false
+			for _, v := range attrs[kv[0]] {
+				matched = matched || v == kv[1]
+			}
+			if !matched {
+				continue
+			}
+		}
+		retAttrs := make(map[string][]string)
+		for _, a := range req.Attributes {
+			if v, ok := attrs[a]; ok {
+				retAttrs[a] = v
+			}
+		}
+		res.Entries = append(res.Entries, ldap.NewEntry(dn, retAttrs))
+	}
+	sort.Slice(res.Entries, func(i, j int) bool { return res.Entries[i].DN < res.Entries[j].DN })
+	return res, nil
+}
+
+func (s *stubLDAPServer) Close() {}
+
+func TestAuthenticate(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	const (
+		carlDN = "uid=carl,ou=people,dc=example,dc=com"
+		dupDN1 = "uid=dup,ou=people,dc=example,dc=com"
+		dupDN2 = "uid=dup,ou=admins,dc=example,dc=com"
+		svcDN  = "cn=search,dc=example,dc=com"
+	)
+	newServer := func() *stubLDAPServer {
+		return &stubLDAPServer{
+			entries: map[string]map[string][]string{
+				carlDN: {
+					"uid":      {"carl"},
+					"mail":     {"carl@example.com"},
+					"memberOf": {"cn=analysts,ou=groups,dc=example,dc=com"},
+				},
+				dupDN1: {"uid": {"dup"}},
+				dupDN2: {"uid": {"dup"}},
+			},
+			passwords: map[string]string{
+				carlDN: "carlpw",
+				svcDN:  "svcpw",
+			},
+		}
+	}
+	var server *stubLDAPServer
+	defer func(prev func(*hba.LDAPOptions) (ldapConn, error)) { dialLDAP = prev }(dialLDAP)
+	dialLDAP = func(*hba.LDAPOptions) (ldapConn, error) { return server, nil }
+
+	simpleBind := &hba.LDAPOptions{
+		Server: "ldap.example.com", Prefix: "uid=", Suffix: ",ou=people,dc=example,dc=com",
+	}
+	searchBind := &hba.LDAPOptions{
+		Server: "ldap.example.com", BaseDN: "dc=example,dc=com",
+		BindDN: svcDN, BindPasswd: "svcpw", SearchAttribute: "uid",
+	}
+	withGroups := *searchBind
+	withGroups.GroupAttribute = "memberOf"
+	withTLS := *simpleBind
+	withTLS.TLS = true
+	badSearchUser := *searchBind
+	badSearchUser.BindPasswd = "wrong"
+	byMail := *searchBind
+	byMail.SearchAttribute = "mail"
+
+	testCases := []struct {
+		name     string
+		opts     *hba.LDAPOptions
+		user     string
+		password string
+		groups   []string
+		err      string
+	}{
+		{name: "simple bind", opts: simpleBind, user: "carl", password: "carlpw"},
+		{name: "simple bind wrong password", opts: simpleBind, user: "carl", password: "nope",
+			err: `LDAP bind failed for "uid=carl,ou=people,dc=example,dc=com"`},
+		{name: "empty password", opts: simpleBind, user: "carl", password: "",
+			err: "empty password"},
+		{name: "simple bind DN injection", opts: simpleBind, user: "carl,ou=people", password: "carlpw",
+			err: "invalid character in user name"},
+		{name: "starttls", opts: &withTLS, user: "carl", password: "carlpw"},
+		{name: "search bind", opts: searchBind, user: "carl", password: "carlpw"},
+		{name: "search bind wrong password", opts: searchBind, user: "carl", password: "nope",
+			err: `LDAP bind failed for "uid=carl,ou=people,dc=example,dc=com"`},
+		{name: "search bind by attribute", opts: &byMail, user: "carl@example.com", password: "carlpw"},
+		{name: "search bind unknown user", opts: searchBind, user: "dora", password: "dorapw",
+			err: `LDAP user "dora" does not exist`},
+		{name: "search bind wildcard", opts: searchBind, user: "*", password: "carlpw",
+			err: `LDAP user "*" does not exist`},
+		{name: "search bind ambiguous user", opts: searchBind, user: "dup", password: "duppw",
+			err: `LDAP user "dup" is not unique (2 matches)`},
+		{name: "search bind wrong search password", opts: &badSearchUser, user: "carl", password: "carlpw",
+			err: `LDAP bind failed for search user "cn=search,dc=example,dc=com"`},
+		{name: "groups", opts: &withGroups, user: "carl", password: "carlpw",
+			groups: []string{"cn=analysts,ou=groups,dc=example,dc=com"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server = newServer()
+			groups, err := authenticate(tc.opts, tc.user, tc.password)
+			if tc.err != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.groups, groups)
+			require.Equal(t, tc.opts.TLS, server.startedTLS)
+		})
+	}
+}
+
+func TestGroupRoleNames(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	roles := groupRoleNames([]string{
+		"cn=Analysts,ou=groups,dc=example,dc=com",
+		"cn=writers,ou=groups,dc=example,dc=com",
+		"cn=analysts,ou=other,dc=example,dc=com",
+		"cn=public,ou=groups,dc=example,dc=com",
+		"not a dn",
+	})
+	require.Equal(t, []username.SQLUsername{
+		username.MakeSQLUsernameFromPreNormalizedString("analysts"),
+		username.MakeSQLUsernameFromPreNormalizedString("writers"),
+	}, roles)
+}
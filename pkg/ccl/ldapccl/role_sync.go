@@ -0,0 +1,174 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package ldapccl
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/cockroachdb/cockroach/pkg/clusterversion"
+	"github.com/cockroachdb/cockroach/pkg/kv"
+	"github.com/cockroachdb/cockroach/pkg/security/username"
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sessiondata"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/errors"
+	"gopkg.in/ldap.v2"
+)
+
+// groupRoleNames maps the DNs of LDAP groups to the names of SQL roles:
+// the role of a group is named after the value of the first RDN of the
+// group's DN, e.g. "cn=Analysts,ou=groups,dc=example,dc=com" maps to
+// the role "analysts". Groups that do not map to a valid role name are
+// skipped.
+func groupRoleNames(groupDNs []string) []username.SQLUsername {
+	seen := make(map[username.SQLUsername]bool, len(groupDNs))
+	var roles []username.SQLUsername
+	for _, groupDN := range groupDNs {
+		dn, err := ldap.ParseDN(groupDN)
+		if err != nil || len(dn.RDNs) == 0 || len(dn.RDNs[0].Attributes) == 0 {
+			continue
+		}
+		role, err := username.MakeSQLUsernameFromUserInput(
+			dn.RDNs[0].Attributes[0].Value, username.PurposeValidation)
+		if err != nil || role.Undefined() || role.IsReserved() || seen[role] {
+			continue
+		}
+		seen[role] = true
+		roles = append(roles, role)
+	}
+	sort.Slice(roles, func(i, j int) bool { return roles[i].LessThan(roles[j]) })
+	return roles
+}
+
+// syncRoleMembership makes the role memberships of user that were
+// granted by LDAP match the given roles: the user is granted the roles
+// which exist and it is not yet a member of, and the memberships
+// previously granted by LDAP in other roles are revoked. The memberships
+// granted by LDAP are tracked in system.ldap_role_members, so that
+// memberships granted by an operator are never revoked. Membership of the
+// admin role is never changed.
+func syncRoleMembership(
+	ctx context.Context,
+	execCfg *sql.ExecutorConfig,
+	user username.SQLUsername,
+	roles []username.SQLUsername,
+) error {
+	if !execCfg.Settings.Version.IsActive(ctx, clusterversion.LDAPRoleMembersTable) {
+		log.Infof(ctx, "not synchronizing LDAP group roles of %s: cluster version is too old", user)
+		return nil
+	}
+	ie := execCfg.InternalExecutor
+	override := sessiondata.InternalExecutorOverride{User: username.NodeUserName()}
+	queryRoles := func(
+		ctx context.Context, txn *kv.Txn, opName, stmt string,
+	) (map[username.SQLUsername]bool, error) {
+		rows, err := ie.QueryBufferedEx(ctx, opName, txn, override, stmt, user)
+		if err != nil {
+			return nil, err
+		}
+		res := make(map[username.SQLUsername]bool, len(rows))
+		for _, row := range rows {
+			res[username.MakeSQLUsernameFromPreNormalizedString(string(tree.MustBeDString(row[0])))] = true
+		}
+		return res, nil
+	}
+
+	return execCfg.DB.Txn(ctx, func(ctx context.Context, txn *kv.Txn) error {
+		exists, err := queryRoles(ctx, txn, "ldap-get-roles",
+			`SELECT username FROM system.users WHERE "isRole" AND username != $1`)
+		if err != nil {
+			return errors.Wrap(err, "could not retrieve roles")
+		}
+		isMember, err := queryRoles(ctx, txn, "ldap-get-memberships",
+			`SELECT "role" FROM system.role_members WHERE "member" = $1`)
+		if err != nil {
+			return errors.Wrapf(err, "could not retrieve role memberships of %s", user)
+		}
+		granted, err := queryRoles(ctx, txn, "ldap-get-granted",
+			`SELECT "role" FROM system.ldap_role_members WHERE member = $1`)
+		if err != nil {
+			return errors.Wrapf(err, "could not retrieve LDAP role memberships of %s", user)
+		}
+
+		// Forget the memberships that no longer exist, e.g. because an operator
+		// revoked them or dropped the role.
+		for role := range granted {
+			if isMember[role] && exists[role] {
+				continue
+			}
+			if err := forgetLDAPRoleMembership(ctx, ie, txn, user, role); err != nil {
+				return err
+			}
+			delete(granted, role)
+		}
+
+		wanted := make(map[username.SQLUsername]bool, len(roles))
+		for _, role := range roles {
+			wanted[role] = true
+			// A membership that the user already has was either granted by an
+			// earlier synchronization or by an operator. In the latter case it is
+			// left untracked, so that it survives the removal of the user from
+			// the LDAP group.
+			if role.IsAdminRole() || role == user || isMember[role] {
+				continue
+			}
+			if !exists[role] {
+				log.Infof(ctx, "not granting LDAP group role %s to %s: role does not exist", role, user)
+				continue
+			}
+			if _, err := ie.ExecEx(ctx, "ldap-grant-role", txn, override,
+				fmt.Sprintf("GRANT %s TO %s", role.SQLIdentifier(), user.SQLIdentifier()),
+			); err != nil {
+				return errors.Wrapf(err, "could not grant role %s to %s", role, user)
+			}
+			if _, err := ie.ExecEx(ctx, "ldap-record-role", txn, override,
+				`UPSERT INTO system.ldap_role_members (member, "role") VALUES ($1, $2)`,
+				user, role,
+			); err != nil {
+				return errors.Wrapf(err, "could not record LDAP role membership of %s in %s", user, role)
+			}
+		}
+
+		for role := range granted {
+			if role.IsAdminRole() || wanted[role] {
+				continue
+			}
+			if _, err := ie.ExecEx(ctx, "ldap-revoke-role", txn, override,
+				fmt.Sprintf("REVOKE %s FROM %s", role.SQLIdentifier(), user.SQLIdentifier()),
+			); err != nil {
+				return errors.Wrapf(err, "could not revoke role %s from %s", role, user)
+			}
+			if err := forgetLDAPRoleMembership(ctx, ie, txn, user, role); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// forgetLDAPRoleMembership removes the record of the membership of user in
+// role from system.ldap_role_members.
+func forgetLDAPRoleMembership(
+	ctx context.Context,
+	ie *sql.InternalExecutor,
+	txn *kv.Txn,
+	user, role username.SQLUsername,
+) error {
+	if _, err := ie.ExecEx(ctx, "ldap-forget-role", txn,
+		sessiondata.InternalExecutorOverride{User: username.NodeUserName()},
+		`DELETE FROM system.ldap_role_members WHERE member = $1 AND "role" = $2`,
+		user, role,
+	); err != nil {
+		return errors.Wrapf(err, "could not forget LDAP role membership of %s in %s", user, role)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package ldapccl
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/security/username"
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSyncRoleMembership verifies that the LDAP group synchronization only
+// revokes the role memberships that it granted itself.
+func TestSyncRoleMembership(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	s, db, _ := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(ctx)
+	sqlDB := sqlutils.MakeSQLRunner(db)
+	execCfg := s.ExecutorConfig().(sql.ExecutorConfig)
+
+	sqlDB.Exec(t, `CREATE USER alice`)
+	sqlDB.Exec(t, `CREATE ROLE analysts`)
+	sqlDB.Exec(t, `CREATE ROLE auditors`)
+	sqlDB.Exec(t, `CREATE ROLE ops`)
+	// auditors is granted by an operator, not by LDAP.
+	sqlDB.Exec(t, `GRANT auditors TO alice`)
+
+	alice := username.MakeSQLUsernameFromPreNormalizedString("alice")
+	roles := func(names ...string) []username.SQLUsername {
+		var res []username.SQLUsername
+		for _, name := range names {
+			res = append(res, username.MakeSQLUsernameFromPreNormalizedString(name))
+		}
+		return res
+	}
+	checkMemberships := func(memberOf, grantedByLDAP [][]string) {
+		t.Helper()
+		sqlDB.CheckQueryResults(t,
+			`SELECT "role" FROM system.role_members WHERE member = 'alice' ORDER BY 1`, memberOf)
+		sqlDB.CheckQueryResults(t,
+			`SELECT "role" FROM system.ldap_role_members WHERE member = 'alice' ORDER BY 1`, grantedByLDAP)
+	}
+
+	// The membership in auditors already exists, so it is not recorded as
+	// granted by LDAP. The group of the missing role is ignored.
+	require.NoError(t, syncRoleMembership(ctx, &execCfg, alice, roles("analysts", "auditors", "missing")))
+	checkMemberships([][]string{{"analysts"}, {"auditors"}}, [][]string{{"analysts"}})
+
+	// Leaving the auditors group does not revoke the operator's grant.
+	require.NoError(t, syncRoleMembership(ctx, &execCfg, alice, roles("ops")))
+	checkMemberships([][]string{{"auditors"}, {"ops"}}, [][]string{{"ops"}})
+
+	// A membership granted by LDAP and revoked by an operator is forgotten.
+	sqlDB.Exec(t, `REVOKE ops FROM alice`)
+	require.NoError(t, syncRoleMembership(ctx, &execCfg, alice, nil /* roles */))
+	checkMemberships([][]string{{"auditors"}}, [][]string{})
+
+	// Dropping a role granted by LDAP forgets the membership.
+	require.NoError(t, syncRoleMembership(ctx, &execCfg, alice, roles("analysts")))
+	checkMemberships([][]string{{"analysts"}, {"auditors"}}, [][]string{{"analysts"}})
+	sqlDB.Exec(t, `DROP ROLE analysts`)
+	sqlDB.CheckQueryResults(t, `SELECT count(*) FROM system.ldap_role_members`, [][]string{{"0"}})
+}
@@ -12,12 +12,17 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/cockroachdb/cockroach/pkg/base"
 	"github.com/cockroachdb/cockroach/pkg/ccl/baseccl"
 	"github.com/cockroachdb/cockroach/pkg/ccl/storageccl/engineccl/enginepbccl"
+	"github.com/cockroachdb/cockroach/pkg/cloud"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/storage"
 	"github.com/cockroachdb/cockroach/pkg/storage/enginepb"
 	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
 	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/errors/oserror"
 	"github.com/cockroachdb/pebble/vfs"
 )
 
@@ -40,7 +45,9 @@ import (
 //   about encryption settings used for the file, including the key id.
 // - The StoreKeyManager uses the base-FS to read the user-specified store keys at startup.
 //   These are in two key files: the active key file and the old key file, which contain the
-//   key id and the key.
+//   key id and the key. Alternatively, the KMSStoreKeyManager generates the store keys itself
+//   and uses the base-FS to persist them wrapped by a KMS master key, which is used to unwrap
+//   them at startup. Such store keys can be rotated while the store is open.
 // - The store-FS is used only for storing the key file for the generated keys. It is used by
 //   the DataKeyManager. These keys are rotated periodically in a simple manner -- a new
 //   active key is generated for future file writes. Existing files are not affected.
@@ -109,6 +116,11 @@ type encryptedFS struct {
 	vfs.FS
 	fileRegistry  *storage.PebbleFileRegistry
 	streamCreator *FileCipherStreamCreator
+	// replaceMu is held exclusively while a file is replaced by its
+	// re-encrypted copy, since the file and its registry entry cannot be
+	// changed atomically. It is held in shared mode by the operations which
+	// could observe or modify a file while it is being replaced.
+	replaceMu syncutil.RWMutex
 }
 
 // Create implements vfs.FS.Create.
@@ -149,6 +161,8 @@ func (fs *encryptedFS) Create(name string) (vfs.File, error) {
 
 // Link implements vfs.FS.Link.
 func (fs *encryptedFS) Link(oldname, newname string) error {
+	fs.replaceMu.RLock()
+	defer fs.replaceMu.RUnlock()
 	if err := fs.FS.Link(oldname, newname); err != nil {
 		return err
 	}
@@ -157,6 +171,8 @@ func (fs *encryptedFS) Link(oldname, newname string) error {
 
 // Open implements vfs.FS.Open.
 func (fs *encryptedFS) Open(name string, opts ...vfs.OpenOption) (vfs.File, error) {
+	fs.replaceMu.RLock()
+	defer fs.replaceMu.RUnlock()
 	f, err := fs.FS.Open(name, opts...)
 	if err != nil {
 		return f, err
@@ -186,10 +202,9 @@ func (fs *encryptedFS) Open(name string, opts ...vfs.OpenOption) (vfs.File, erro
 
 // Remove implements vfs.FS.Remove.
 func (fs *encryptedFS) Remove(name string) error {
-	if err := fs.FS.Remove(name); err != nil {
-		return err
-	}
-	return fs.fileRegistry.MaybeDeleteEntry(name)
+	fs.replaceMu.RLock()
+	defer fs.replaceMu.RUnlock()
+	return fs.removeLocked(name)
 }
 
 // Rename implements vfs.FS.Rename. A rename operation needs to both
@@ -208,6 +223,8 @@ func (fs *encryptedFS) Remove(name string) error {
 // will contain a dangling entry for the old path. The dangling entry
 // will be elided when the file registry is loaded again.
 func (fs *encryptedFS) Rename(oldname, newname string) error {
+	fs.replaceMu.RLock()
+	defer fs.replaceMu.RUnlock()
 	// First copy the metadata from the old name to the new name. If a
 	// file exists at newname, this copy action will make the file at
 	// newname unlegible, because the encryption-at-rest metadata will
@@ -226,6 +243,42 @@ func (fs *encryptedFS) Rename(oldname, newname string) error {
 	return fs.fileRegistry.MaybeDeleteEntry(oldname)
 }
 
+// replaceWithCopy replaces the file at name by its copy at copyName, which may
+// be encrypted with a different key, unless there is no longer a file at name.
+// The copy is removed in that case.
+//
+// Like Rename, it first copies the copy's file registry entry to name, which
+// leaves the file at name unreadable until the filesystem rename. A crash in
+// between is recovered from by recoverReplacedFiles.
+func (fs *encryptedFS) replaceWithCopy(copyName, name string) error {
+	fs.replaceMu.Lock()
+	defer fs.replaceMu.Unlock()
+	if _, err := fs.FS.Stat(name); err != nil {
+		if oserror.IsNotExist(err) {
+			// The file was removed while it was being copied.
+			return errors.CombineErrors(err, fs.removeLocked(copyName))
+		}
+		return err
+	}
+	prevEntry := fs.fileRegistry.GetFileEntry(name)
+	if err := fs.fileRegistry.MaybeCopyEntry(copyName, name); err != nil {
+		return err
+	}
+	if err := fs.FS.Rename(copyName, name); err != nil {
+		// Restore the registry entry of the file at name, which was left alone.
+		return errors.CombineErrors(err, fs.fileRegistry.SetFileEntry(name, prevEntry))
+	}
+	return fs.fileRegistry.MaybeDeleteEntry(copyName)
+}
+
+// removeLocked is Remove, for callers already holding replaceMu.
+func (fs *encryptedFS) removeLocked(name string) error {
+	if err := fs.FS.Remove(name); err != nil {
+		return err
+	}
+	return fs.fileRegistry.MaybeDeleteEntry(name)
+}
+
 // ReuseForWrite implements vfs.FS.ReuseForWrite.
 //
 // We cannot change any of the key/iv/nonce and reuse the same file since RocksDB does not
@@ -242,16 +295,20 @@ func (fs *encryptedFS) ReuseForWrite(oldname, newname string) (vfs.File, error)
 }
 
 type encryptionStatsHandler struct {
-	storeKM *StoreKeyManager
+	storeKM PebbleKeyManager
 	dataKM  *DataKeyManager
 }
 
 func (e *encryptionStatsHandler) GetEncryptionStatus() ([]byte, error) {
 	var s enginepbccl.EncryptionStatus
-	if e.storeKM.activeKey != nil {
-		s.ActiveStoreKey = e.storeKM.activeKey.Info
+	k, err := e.storeKM.ActiveKey(context.TODO())
+	if err != nil {
+		return nil, err
 	}
-	k, err := e.dataKM.ActiveKey(context.TODO())
+	if k != nil {
+		s.ActiveStoreKey = k.Info
+	}
+	k, err = e.dataKM.ActiveKey(context.TODO())
 	if err != nil {
 		return nil, err
 	}
@@ -278,8 +335,8 @@ func (e *encryptionStatsHandler) GetActiveDataKeyID() (string, error) {
 }
 
 func (e *encryptionStatsHandler) GetActiveStoreKeyType() int32 {
-	if e.storeKM.activeKey != nil {
-		return int32(e.storeKM.activeKey.Info.EncryptionType)
+	if k, err := e.storeKM.ActiveKey(context.TODO()); err == nil && k != nil {
+		return int32(k.Info.EncryptionType)
 	}
 	return int32(enginepbccl.EncryptionType_Plaintext)
 }
@@ -292,6 +349,63 @@ func (e *encryptionStatsHandler) GetKeyIDFromSettings(settings []byte) (string,
 	return s.KeyId, nil
 }
 
+// kmsStoreKeyRotator rotates the store keys of a KMSStoreKeyManager, and then
+// the data keys encrypted with them. Implements storage.StoreKeyRotator.
+type kmsStoreKeyRotator struct {
+	storeKM *KMSStoreKeyManager
+	dataKM  *DataKeyManager
+	// mu serializes rotations, so that the data keys registry always ends up
+	// encrypted with the active store key.
+	mu syncutil.Mutex
+}
+
+// RotateStoreKey implements storage.StoreKeyRotator.
+func (r *kmsStoreKeyRotator) RotateStoreKey(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	info, err := r.storeKM.Rotate(ctx)
+	if err != nil {
+		return err
+	}
+	if err := r.dataKM.SetActiveStoreKeyInfo(ctx, info); err != nil {
+		return err
+	}
+	return pruneKMSStoreKeys(ctx, r.storeKM, r.dataKM)
+}
+
+// pruneKMSStoreKeys drops the KMS store keys that are older than the active
+// one, once the data keys registry is confirmed to be encrypted with the
+// active key. If the registry was not rewritten, e.g. because a rotation was
+// interrupted, all keys are retained, since the registry may be encrypted with
+// any of them.
+func pruneKMSStoreKeys(
+	ctx context.Context, storeKM *KMSStoreKeyManager, dataKM *DataKeyManager,
+) error {
+	active, err := storeKM.ActiveKey(ctx)
+	if err != nil {
+		return err
+	}
+	if dataKM.activeStoreKeyID() != active.Info.KeyId {
+		return nil
+	}
+	return storeKM.PruneKeys(ctx, active.Info.KeyId)
+}
+
+// encryptedEnvCloser closes the key managers of an encrypted environment.
+type encryptedEnvCloser struct {
+	dataKM  *DataKeyManager
+	storeKM *KMSStoreKeyManager
+}
+
+// Close implements io.Closer.
+func (c *encryptedEnvCloser) Close() error {
+	err := c.dataKM.Close()
+	if c.storeKM != nil {
+		err = errors.CombineErrors(err, c.storeKM.Close())
+	}
+	return err
+}
+
 // init initializes function hooks used in non-CCL code.
 func init() {
 	storage.NewEncryptedEnvFunc = newEncryptedEnv
@@ -304,22 +418,54 @@ func init() {
 //
 // See the comment at the top of this file for the structure of this environment.
 func newEncryptedEnv(
-	fs vfs.FS, fr *storage.PebbleFileRegistry, dbDir string, readOnly bool, optionBytes []byte,
+	fs vfs.FS,
+	fr *storage.PebbleFileRegistry,
+	dbDir string,
+	readOnly bool,
+	optionBytes []byte,
+	st *cluster.Settings,
 ) (*storage.EncryptionEnv, error) {
 	options := &baseccl.EncryptionOptions{}
 	if err := protoutil.Unmarshal(optionBytes, options); err != nil {
 		return nil, err
 	}
-	if options.KeySource != baseccl.EncryptionKeySource_KeyFiles {
-		return nil, fmt.Errorf("unknown encryption key source: %d", options.KeySource)
+	if st == nil {
+		st = cluster.MakeClusterSettings()
 	}
-	storeKeyManager := &StoreKeyManager{
-		fs:                fs,
-		activeKeyFilename: options.KeyFiles.CurrentKey,
-		oldKeyFilename:    options.KeyFiles.OldKey,
-	}
-	if err := storeKeyManager.Load(context.TODO()); err != nil {
-		return nil, err
+	var storeKeyManager PebbleKeyManager
+	var kmsStoreKeyManager *KMSStoreKeyManager
+	switch options.KeySource {
+	case baseccl.EncryptionKeySource_KeyFiles:
+		m := &StoreKeyManager{
+			fs:                fs,
+			activeKeyFilename: options.KeyFiles.CurrentKey,
+			oldKeyFilename:    options.KeyFiles.OldKey,
+		}
+		if err := m.Load(context.TODO()); err != nil {
+			return nil, err
+		}
+		storeKeyManager = m
+	case baseccl.EncryptionKeySource_KMS:
+		// The KMS URI is provided by the operator on the command line, so the
+		// restrictions that the external IO flags place on SQL users do not
+		// apply to it.
+		kms, err := cloud.KMSFromURI(options.KMS.URI, &kmsEnv{settings: st})
+		if err != nil {
+			return nil, errors.Wrap(err, "opening store key KMS")
+		}
+		kmsStoreKeyManager = &KMSStoreKeyManager{
+			fs:       fs,
+			dbDir:    dbDir,
+			kms:      kms,
+			readOnly: readOnly,
+		}
+		if err := kmsStoreKeyManager.Load(context.TODO()); err != nil {
+			_ = kms.Close()
+			return nil, err
+		}
+		storeKeyManager = kmsStoreKeyManager
+	default:
+		return nil, fmt.Errorf("unknown encryption key source: %d", options.KeySource)
 	}
 	storeFS := &encryptedFS{
 		FS:           fs,
@@ -355,16 +501,51 @@ func newEncryptedEnv(
 		if err := dataKeyManager.SetActiveStoreKeyInfo(context.TODO(), key.Info); err != nil {
 			return nil, err
 		}
+		if kmsStoreKeyManager != nil {
+			if err := pruneKMSStoreKeys(context.TODO(), kmsStoreKeyManager, dataKeyManager); err != nil {
+				return nil, err
+			}
+		}
 	}
 
-	return &storage.EncryptionEnv{
-		Closer: dataKeyManager,
+	env := &storage.EncryptionEnv{
+		Closer: &encryptedEnvCloser{dataKM: dataKeyManager, storeKM: kmsStoreKeyManager},
 		FS:     dataFS,
 		StatsHandler: &encryptionStatsHandler{
 			storeKM: storeKeyManager,
 			dataKM:  dataKeyManager,
 		},
-	}, nil
+	}
+	if kmsStoreKeyManager != nil && !readOnly {
+		env.StoreKeyRotator = &kmsStoreKeyRotator{
+			storeKM: kmsStoreKeyManager,
+			dataKM:  dataKeyManager,
+		}
+	}
+	if !readOnly {
+		if err := recoverReplacedFiles(context.TODO(), dataFS, dbDir); err != nil {
+			return nil, err
+		}
+		env.Reencryptor = newReencryptor(dataFS, dbDir, dataKeyManager, st)
+	}
+	return env, nil
+}
+
+// kmsEnv is the cloud.KMSEnv used for store key KMSs.
+type kmsEnv struct {
+	settings *cluster.Settings
+}
+
+var _ cloud.KMSEnv = &kmsEnv{}
+
+// ClusterSettings implements cloud.KMSEnv.
+func (e *kmsEnv) ClusterSettings() *cluster.Settings {
+	return e.settings
+}
+
+// KMSConfig implements cloud.KMSEnv.
+func (e *kmsEnv) KMSConfig() *base.ExternalIODirConfig {
+	return &base.ExternalIODirConfig{}
 }
 
 func canRegistryElide(entry *enginepb.FileEntry) bool {
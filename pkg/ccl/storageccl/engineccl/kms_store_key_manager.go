@@ -0,0 +1,271 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package engineccl
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/storageccl/engineccl/enginepbccl"
+	"github.com/cockroachdb/cockroach/pkg/cloud"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/errors/oserror"
+	"github.com/cockroachdb/pebble/vfs"
+	"github.com/gogo/protobuf/proto"
+)
+
+const (
+	// The filename used for writing the KMS-wrapped store keys by the
+	// KMSStoreKeyManager.
+	kmsStoreKeysFilename = "COCKROACHDB_KMS_STORE_KEYS"
+	// The encryption type of the store keys generated by the
+	// KMSStoreKeyManager.
+	kmsStoreKeyEncryptionType = enginepbccl.EncryptionType_AES256_CTR
+	// The length of the store keys generated by the KMSStoreKeyManager.
+	kmsStoreKeyLength = 32
+)
+
+var _ PebbleKeyManager = &KMSStoreKeyManager{}
+
+// KMSStoreKeyManager manages store keys that are generated by the node and
+// persisted in the store directory wrapped by a KMS master key, unlike the
+// StoreKeyManager whose keys are user-provided files. Implements
+// PebbleKeyManager.
+type KMSStoreKeyManager struct {
+	// Initialize the following before calling Load().
+	fs       vfs.FS
+	dbDir    string
+	kms      cloud.KMS
+	readOnly bool
+
+	mu struct {
+		syncutil.Mutex
+		// The unwrapped store keys, from oldest to newest, and their wrapped form
+		// as persisted. Non-empty after a successful call to Load().
+		keys    []*enginepbccl.SecretKey
+		wrapped []*enginepbccl.WrappedStoreKey
+	}
+}
+
+// Load must be called before calling other functions. It unwraps the
+// persisted store keys using the KMS, generating the first store key if there
+// are none yet.
+func (m *KMSStoreKeyManager) Load(ctx context.Context) error {
+	masterKeyID, err := m.kms.MasterKeyID()
+	if err != nil {
+		return errors.Wrap(err, "getting KMS master key ID")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	f, err := m.fs.Open(m.fs.PathJoin(m.dbDir, kmsStoreKeysFilename))
+	if oserror.IsNotExist(err) {
+		if m.readOnly {
+			return errors.Newf("no KMS-wrapped store keys found in %s", m.dbDir)
+		}
+		// First run.
+		return m.rotateLocked(ctx, masterKeyID)
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	b, err := ioutil.ReadAll(f)
+	if err != nil {
+		return err
+	}
+	var storeKeys enginepbccl.KMSStoreKeys
+	if err := protoutil.Unmarshal(b, &storeKeys); err != nil {
+		return err
+	}
+	if len(storeKeys.Keys) == 0 {
+		return errors.Newf("%s contains no store keys", kmsStoreKeysFilename)
+	}
+	for _, w := range storeKeys.Keys {
+		if w.MasterKeyId != masterKeyID {
+			return errors.Newf("store key %s was wrapped by KMS master key %s, but the KMS master key is %s",
+				w.Info.KeyId, w.MasterKeyId, masterKeyID)
+		}
+		raw, err := m.kms.Decrypt(ctx, w.WrappedKey)
+		if err != nil {
+			return errors.Wrapf(err, "unwrapping store key %s", w.Info.KeyId)
+		}
+		if len(raw) != kmsStoreKeyLength {
+			return fmt.Errorf("store key %s of unsupported length: %d", w.Info.KeyId, len(raw))
+		}
+		m.mu.keys = append(m.mu.keys, &enginepbccl.SecretKey{Info: w.Info, Key: raw})
+	}
+	m.mu.wrapped = storeKeys.Keys
+	log.Infof(ctx, "loaded active KMS store key: %s",
+		proto.CompactTextString(m.mu.keys[len(m.mu.keys)-1].Info))
+	return nil
+}
+
+// ActiveKey implements PebbleKeyManager.ActiveKey.
+func (m *KMSStoreKeyManager) ActiveKey(ctx context.Context) (*enginepbccl.SecretKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.mu.keys[len(m.mu.keys)-1], nil
+}
+
+// GetKey implements PebbleKeyManager.GetKey.
+func (m *KMSStoreKeyManager) GetKey(id string) (*enginepbccl.SecretKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, key := range m.mu.keys {
+		if key.Info.KeyId == id {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("store key ID %s was not found", id)
+}
+
+// Rotate generates a new store key, persists it wrapped by the KMS master key
+// and makes it the active key. The previous keys are retained so that files
+// encrypted with them remain readable until they are rewritten. Only the data
+// keys registry is encrypted with store keys, so the caller should follow up
+// by passing the new key to DataKeyManager.SetActiveStoreKeyInfo, and then
+// call PruneKeys once the registry is rewritten.
+func (m *KMSStoreKeyManager) Rotate(ctx context.Context) (*enginepbccl.KeyInfo, error) {
+	if m.readOnly {
+		return nil, errors.New("read only")
+	}
+	masterKeyID, err := m.kms.MasterKeyID()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting KMS master key ID")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.rotateLocked(ctx, masterKeyID); err != nil {
+		return nil, err
+	}
+	return m.mu.keys[len(m.mu.keys)-1].Info, nil
+}
+
+// PruneKeys drops the store keys older than the key with the given ID, which
+// must be the key that the data keys registry is encrypted with. Since the
+// registry is the only file encrypted with store keys, the older keys are no
+// longer needed to read the store.
+func (m *KMSStoreKeyManager) PruneKeys(ctx context.Context, inUseKeyID string) error {
+	if m.readOnly {
+		return errors.New("read only")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i := 0
+	for ; i < len(m.mu.keys); i++ {
+		if m.mu.keys[i].Info.KeyId == inUseKeyID {
+			break
+		}
+	}
+	if i == len(m.mu.keys) {
+		return errors.Newf("store key ID %s was not found", inUseKeyID)
+	}
+	if i == 0 {
+		return nil
+	}
+	wrapped := m.mu.wrapped[i:]
+	if err := m.writeStoreKeys(&enginepbccl.KMSStoreKeys{Keys: wrapped}); err != nil {
+		return err
+	}
+	m.mu.keys = m.mu.keys[i:]
+	m.mu.wrapped = wrapped
+	log.Infof(ctx, "pruned %d KMS store keys older than %s", i, inUseKeyID)
+	return nil
+}
+
+// Close releases the KMS.
+func (m *KMSStoreKeyManager) Close() error {
+	return m.kms.Close()
+}
+
+// REQUIRES: m.mu is held.
+func (m *KMSStoreKeyManager) rotateLocked(ctx context.Context, masterKeyID string) (err error) {
+	defer func() {
+		if err != nil {
+			log.Infof(ctx, "error while attempting to rotate KMS store key: %s", err)
+		} else {
+			log.Infof(ctx, "rotated to new active KMS store key: %s",
+				proto.CompactTextString(m.mu.keys[len(m.mu.keys)-1].Info))
+		}
+	}()
+
+	key := &enginepbccl.SecretKey{Info: &enginepbccl.KeyInfo{}}
+	key.Key = make([]byte, kmsStoreKeyLength)
+	if _, err := rand.Read(key.Key); err != nil {
+		return err
+	}
+	keyID := make([]byte, keyIDLength)
+	if _, err := rand.Read(keyID); err != nil {
+		return err
+	}
+	// Hex encoding to make it human readable.
+	key.Info.KeyId = hex.EncodeToString(keyID)
+	key.Info.EncryptionType = kmsStoreKeyEncryptionType
+	key.Info.CreationTime = kmTimeNow().Unix()
+	key.Info.Source = "KMS " + masterKeyID
+	if len(m.mu.keys) > 0 {
+		key.Info.ParentKeyId = m.mu.keys[len(m.mu.keys)-1].Info.KeyId
+	}
+
+	wrappedKey, err := m.kms.Encrypt(ctx, key.Key)
+	if err != nil {
+		return errors.Wrap(err, "wrapping store key")
+	}
+	wrapped := append(append([]*enginepbccl.WrappedStoreKey(nil), m.mu.wrapped...),
+		&enginepbccl.WrappedStoreKey{Info: key.Info, WrappedKey: wrappedKey, MasterKeyId: masterKeyID})
+	keys := append(append([]*enginepbccl.SecretKey(nil), m.mu.keys...), key)
+	if err := m.writeStoreKeys(&enginepbccl.KMSStoreKeys{Keys: wrapped}); err != nil {
+		return err
+	}
+	m.mu.keys = keys
+	m.mu.wrapped = wrapped
+	return nil
+}
+
+// writeStoreKeys durably replaces the persisted store keys, by writing them to
+// a temporary file that is then renamed over the existing one.
+func (m *KMSStoreKeyManager) writeStoreKeys(storeKeys *enginepbccl.KMSStoreKeys) error {
+	b, err := protoutil.Marshal(storeKeys)
+	if err != nil {
+		return err
+	}
+	filename := m.fs.PathJoin(m.dbDir, kmsStoreKeysFilename)
+	tmpFilename := filename + ".tmp"
+	f, err := m.fs.Create(tmpFilename)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := m.fs.Rename(tmpFilename, filename); err != nil {
+		return err
+	}
+	dir, err := m.fs.OpenDir(m.dbDir)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
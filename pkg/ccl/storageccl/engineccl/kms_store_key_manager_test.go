@@ -0,0 +1,326 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package engineccl
+
+import (
+	"bytes"
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/ccl/baseccl"
+	"github.com/cockroachdb/cockroach/pkg/ccl/storageccl/engineccl/enginepbccl"
+	"github.com/cockroachdb/cockroach/pkg/cloud"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/pebble"
+	"github.com/cockroachdb/pebble/vfs"
+	"github.com/stretchr/testify/require"
+)
+
+const testKMSScheme = "engineccl-test-kms"
+
+// testKMS is a KMS which "wraps" data by prefixing it with the name of its
+// master key.
+type testKMS struct {
+	keyName string
+}
+
+var _ cloud.KMS = &testKMS{}
+
+func init() {
+	cloud.RegisterKMSFromURIFactory(func(uri string, _ cloud.KMSEnv) (cloud.KMS, error) {
+		u, err := url.Parse(uri)
+		if err != nil {
+			return nil, err
+		}
+		return &testKMS{keyName: strings.Trim(u.Path, "/")}, nil
+	}, testKMSScheme)
+}
+
+func testKMSURI(keyName string) string {
+	return testKMSScheme + ":///" + keyName
+}
+
+func (k *testKMS) MasterKeyID() (string, error) {
+	return k.keyName, nil
+}
+
+func (k *testKMS) Encrypt(_ context.Context, data []byte) ([]byte, error) {
+	return append([]byte(k.keyName+":"), data...), nil
+}
+
+func (k *testKMS) Decrypt(_ context.Context, data []byte) ([]byte, error) {
+	prefix := []byte(k.keyName + ":")
+	if !bytes.HasPrefix(data, prefix) {
+		return nil, errors.New("wrong master key")
+	}
+	return data[len(prefix):], nil
+}
+
+func (k *testKMS) Close() error {
+	return nil
+}
+
+func TestKMSStoreKeyManager(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	memFS := vfs.NewMem()
+	require.NoError(t, memFS.MkdirAll("store", 0755))
+	makeManager := func(keyName string, readOnly bool) *KMSStoreKeyManager {
+		return &KMSStoreKeyManager{
+			fs: memFS, dbDir: "store", kms: &testKMS{keyName: keyName}, readOnly: readOnly,
+		}
+	}
+
+	// A read-only store cannot generate its first store key.
+	require.EqualError(t, makeManager("key1", true /* readOnly */).Load(ctx),
+		"no KMS-wrapped store keys found in store")
+
+	// The first Load generates and persists a store key.
+	km := makeManager("key1", false /* readOnly */)
+	require.NoError(t, km.Load(ctx))
+	first, err := km.ActiveKey(ctx)
+	require.NoError(t, err)
+	require.Equal(t, enginepbccl.EncryptionType_AES256_CTR, first.Info.EncryptionType)
+	require.Equal(t, kmsStoreKeyLength, len(first.Key))
+	require.Equal(t, "KMS key1", first.Info.Source)
+	require.Equal(t, "", first.Info.ParentKeyId)
+
+	// The persisted key is wrapped.
+	f, err := memFS.Open(memFS.PathJoin("store", kmsStoreKeysFilename))
+	require.NoError(t, err)
+	var b bytes.Buffer
+	_, err = b.ReadFrom(f)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	var storeKeys enginepbccl.KMSStoreKeys
+	require.NoError(t, protoutil.Unmarshal(b.Bytes(), &storeKeys))
+	require.Equal(t, 1, len(storeKeys.Keys))
+	require.Equal(t, "key1", storeKeys.Keys[0].MasterKeyId)
+	require.Equal(t, append([]byte("key1:"), first.Key...), storeKeys.Keys[0].WrappedKey)
+
+	// Loading again unwraps the same key.
+	km = makeManager("key1", false /* readOnly */)
+	require.NoError(t, km.Load(ctx))
+	k, err := km.ActiveKey(ctx)
+	require.NoError(t, err)
+	require.Equal(t, first.String(), k.String())
+
+	// Rotating retains the previous key.
+	second, err := km.Rotate(ctx)
+	require.NoError(t, err)
+	require.Equal(t, first.Info.KeyId, second.ParentKeyId)
+	k, err = km.ActiveKey(ctx)
+	require.NoError(t, err)
+	require.Equal(t, second.KeyId, k.Info.KeyId)
+	_, err = km.GetKey(first.Info.KeyId)
+	require.NoError(t, err)
+
+	// Rotating again still retains all the keys.
+	third, err := km.Rotate(ctx)
+	require.NoError(t, err)
+	_, err = km.GetKey(first.Info.KeyId)
+	require.NoError(t, err)
+
+	// Pruning drops the keys older than the given one.
+	require.Regexp(t, "store key ID unknown was not found", km.PruneKeys(ctx, "unknown"))
+	require.NoError(t, km.PruneKeys(ctx, second.KeyId))
+	_, err = km.GetKey(first.Info.KeyId)
+	require.Error(t, err)
+	_, err = km.GetKey(second.KeyId)
+	require.NoError(t, err)
+
+	// The rotations survive a reload, also in read-only mode.
+	km = makeManager("key1", true /* readOnly */)
+	require.NoError(t, km.Load(ctx))
+	k, err = km.ActiveKey(ctx)
+	require.NoError(t, err)
+	require.Equal(t, third.KeyId, k.Info.KeyId)
+	_, err = km.GetKey(second.KeyId)
+	require.NoError(t, err)
+	_, err = km.GetKey(first.Info.KeyId)
+	require.Error(t, err)
+	_, err = km.Rotate(ctx)
+	require.EqualError(t, err, "read only")
+	require.EqualError(t, km.PruneKeys(ctx, third.KeyId), "read only")
+
+	// The store keys cannot be unwrapped with another master key.
+	require.Regexp(t, "was wrapped by KMS master key key1, but the KMS master key is key2",
+		makeManager("key2", false /* readOnly */).Load(ctx))
+}
+
+func TestPebbleEncryptionKMS(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	memFS := vfs.NewMem()
+	var encOptions baseccl.EncryptionOptions
+	encOptions.KeySource = baseccl.EncryptionKeySource_KMS
+	encOptions.KMS = &baseccl.EncryptionKMS{URI: testKMSURI("key1")}
+	encOptions.DataKeyRotationPeriod = 1000 // arbitrary seconds
+	encOptionsBytes, err := protoutil.Marshal(&encOptions)
+	require.NoError(t, err)
+
+	open := func() *storage.Pebble {
+		opts := storage.DefaultPebbleOptions()
+		opts.Cache = pebble.NewCache(1 << 20)
+		defer opts.Cache.Unref()
+		opts.FS = memFS
+		db, err := storage.NewPebble(ctx, storage.PebbleConfig{
+			StorageConfig: base.StorageConfig{
+				Attrs:             roachpb.Attributes{},
+				MaxSize:           512 << 20,
+				Settings:          cluster.MakeTestingClusterSettings(),
+				UseFileRegistry:   true,
+				EncryptionOptions: encOptionsBytes,
+			},
+			Opts: opts,
+		})
+		require.NoError(t, err)
+		return db
+	}
+	encryptionStatus := func(db *storage.Pebble) enginepbccl.EncryptionStatus {
+		stats, err := db.GetEnvStats()
+		require.NoError(t, err)
+		var s enginepbccl.EncryptionStatus
+		require.NoError(t, protoutil.Unmarshal(stats.EncryptionStatus, &s))
+		return s
+	}
+
+	db := open()
+	before := encryptionStatus(db)
+	require.Equal(t, "KMS key1", before.ActiveStoreKey.Source)
+
+	batch := db.NewUnindexedBatch(true /* writeOnly */)
+	require.NoError(t, batch.PutUnversioned(roachpb.Key("a"), []byte("a")))
+	require.NoError(t, batch.Commit(true))
+	require.NoError(t, db.Flush())
+
+	// Rotating the store key also rotates the active data key, which is
+	// encrypted with the new store key.
+	require.NoError(t, db.RotateStoreKey(ctx))
+	after := encryptionStatus(db)
+	require.NotEqual(t, before.ActiveStoreKey.KeyId, after.ActiveStoreKey.KeyId)
+	require.Equal(t, before.ActiveStoreKey.KeyId, after.ActiveStoreKey.ParentKeyId)
+	require.Equal(t, after.ActiveStoreKey.KeyId, after.ActiveDataKey.ParentKeyId)
+	db.Close()
+
+	// The store is readable after a restart with the rotated store key.
+	db = open()
+	defer db.Close()
+	require.Equal(t, after.ActiveStoreKey.KeyId, encryptionStatus(db).ActiveStoreKey.KeyId)
+	val, err := db.MVCCGet(storage.MVCCKey{Key: roachpb.Key("a")})
+	require.NoError(t, err)
+	require.Equal(t, "a", string(val))
+}
+
+// failRegistryWritesFS fails the creation of data keys registry files while
+// fail is set.
+type failRegistryWritesFS struct {
+	vfs.FS
+	fail *bool
+}
+
+func (fs failRegistryWritesFS) Create(name string) (vfs.File, error) {
+	if *fs.fail && strings.Contains(name, keyRegistryFilename) {
+		return nil, errors.New("injected registry write failure")
+	}
+	return fs.FS.Create(name)
+}
+
+// TestPebbleEncryptionKMSInterruptedRotation verifies that the store keys are
+// only pruned once the data keys registry is rewritten with the active store
+// key, so that the store remains readable after interrupted rotations.
+func TestPebbleEncryptionKMSInterruptedRotation(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	memFS := vfs.NewMem()
+	var fail bool
+	var encOptions baseccl.EncryptionOptions
+	encOptions.KeySource = baseccl.EncryptionKeySource_KMS
+	encOptions.KMS = &baseccl.EncryptionKMS{URI: testKMSURI("key1")}
+	encOptions.DataKeyRotationPeriod = 1000 // arbitrary seconds
+	encOptionsBytes, err := protoutil.Marshal(&encOptions)
+	require.NoError(t, err)
+
+	open := func() *storage.Pebble {
+		opts := storage.DefaultPebbleOptions()
+		opts.Cache = pebble.NewCache(1 << 20)
+		defer opts.Cache.Unref()
+		opts.FS = failRegistryWritesFS{FS: memFS, fail: &fail}
+		db, err := storage.NewPebble(ctx, storage.PebbleConfig{
+			StorageConfig: base.StorageConfig{
+				Attrs:             roachpb.Attributes{},
+				MaxSize:           512 << 20,
+				Settings:          cluster.MakeTestingClusterSettings(),
+				UseFileRegistry:   true,
+				EncryptionOptions: encOptionsBytes,
+			},
+			Opts: opts,
+		})
+		require.NoError(t, err)
+		return db
+	}
+	numStoreKeys := func() int {
+		f, err := memFS.Open(kmsStoreKeysFilename)
+		require.NoError(t, err)
+		defer f.Close()
+		var b bytes.Buffer
+		_, err = b.ReadFrom(f)
+		require.NoError(t, err)
+		var storeKeys enginepbccl.KMSStoreKeys
+		require.NoError(t, protoutil.Unmarshal(b.Bytes(), &storeKeys))
+		return len(storeKeys.Keys)
+	}
+
+	db := open()
+	require.Equal(t, 1, numStoreKeys())
+	batch := db.NewUnindexedBatch(true /* writeOnly */)
+	require.NoError(t, batch.PutUnversioned(roachpb.Key("a"), []byte("a")))
+	require.NoError(t, batch.Commit(true))
+	require.NoError(t, db.Flush())
+
+	// Interrupt two rotations after the new store key is persisted, but before
+	// the data keys registry is rewritten with it. The registry is still
+	// encrypted with the first store key, which must be retained.
+	fail = true
+	require.Regexp(t, "injected registry write failure", db.RotateStoreKey(ctx))
+	require.Regexp(t, "injected registry write failure", db.RotateStoreKey(ctx))
+	require.Equal(t, 3, numStoreKeys())
+	db.Close()
+
+	// The store is readable after a restart, which rewrites the registry with
+	// the active store key and prunes the older ones.
+	fail = false
+	db = open()
+	require.Equal(t, 1, numStoreKeys())
+	val, err := db.MVCCGet(storage.MVCCKey{Key: roachpb.Key("a")})
+	require.NoError(t, err)
+	require.Equal(t, "a", string(val))
+
+	// A successful rotation prunes the previous store key.
+	require.NoError(t, db.RotateStoreKey(ctx))
+	require.Equal(t, 1, numStoreKeys())
+	db.Close()
+
+	db = open()
+	defer db.Close()
+	val, err = db.MVCCGet(storage.MVCCKey{Key: roachpb.Key("a")})
+	require.NoError(t, err)
+	require.Equal(t, "a", string(val))
+}
@@ -334,6 +334,14 @@ func (m *DataKeyManager) SetActiveStoreKeyInfo(
 	return nil
 }
 
+// activeStoreKeyID returns the ID of the store key that the persisted data keys
+// registry is encrypted with, or "" if no registry was written yet.
+func (m *DataKeyManager) activeStoreKeyID() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.mu.keyRegistry.ActiveStoreKeyId
+}
+
 func (m *DataKeyManager) getScrubbedRegistry() *enginepbccl.DataKeysRegistry {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -0,0 +1,405 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package engineccl
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/storageccl/engineccl/enginepbccl"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/storage/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+	"github.com/cockroachdb/cockroach/pkg/util/quotapool"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/errors/oserror"
+	"github.com/cockroachdb/logtags"
+	"github.com/gogo/protobuf/proto"
+)
+
+// reencryptionRate is the rate at which files are re-encrypted.
+var reencryptionRate = settings.RegisterByteSizeSetting(
+	settings.SystemOnly,
+	"storage.encryption.reencryption.max_rate",
+	"the rate limit (bytes/sec) at which each store rewrites the files that are not "+
+		"encrypted with its active data key, once requested",
+	8<<20, // 8MB
+	settings.PositiveInt,
+)
+
+const (
+	// reencryptCopySuffix is appended to the name of a file to get the name of
+	// its re-encrypted copy.
+	reencryptCopySuffix = ".reencrypt"
+	// reencryptChunkSize is the size of the chunks in which files are copied.
+	reencryptChunkSize = 256 << 10
+	// reencryptBurstFactor scales the burst of the rate limiter based on its
+	// rate.
+	reencryptBurstFactor = 8
+)
+
+var _ storage.Reencryptor = &reencryptor{}
+
+// reencryptor rewrites the files of the store that are not encrypted with the
+// active data key, either because they were written before the data key was
+// rotated, or before encryption-at-rest was enabled. Pebble does not
+// necessarily ever compact cold SSTables, so instead of relying on compactions
+// the files which are never modified once written are copied through the
+// data-FS, which encrypts the copy with the active data key, and then replaced
+// by the copy. The WAL is switched to a new file by the flush which precedes
+// the rewrite, and the MANIFEST is only replaced when the store is restarted,
+// so the files which remain encrypted with other keys are reported at the end
+// of the rewrite. Implements storage.Reencryptor.
+type reencryptor struct {
+	fs      *encryptedFS
+	dbDir   string
+	dataKM  *DataKeyManager
+	st      *cluster.Settings
+	limiter *quotapool.RateLimiter
+
+	rewrittenFiles uint64 // accessed atomically
+	rewrittenBytes uint64 // accessed atomically
+	remainingFiles uint64 // accessed atomically
+
+	mu struct {
+		syncutil.Mutex
+		// cancel is set while files are being rewritten.
+		cancel context.CancelFunc
+	}
+	wg sync.WaitGroup
+}
+
+func newReencryptor(
+	fs *encryptedFS, dbDir string, dataKM *DataKeyManager, st *cluster.Settings,
+) *reencryptor {
+	return &reencryptor{
+		fs:      fs,
+		dbDir:   dbDir,
+		dataKM:  dataKM,
+		st:      st,
+		limiter: quotapool.NewRateLimiter("reencryption", 0, 0),
+	}
+}
+
+// Start implements storage.Reencryptor.
+func (r *reencryptor) Start(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.mu.cancel != nil {
+		return errors.New("files are already being re-encrypted")
+	}
+	// The rewrite outlives the request which started it.
+	ctx, cancel := context.WithCancel(logtags.AddTags(context.Background(), logtags.FromContext(ctx)))
+	r.mu.cancel = cancel
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer func() {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.mu.cancel = nil
+			cancel()
+		}()
+		if err := r.run(ctx); err != nil {
+			log.Warningf(ctx, "re-encryption failed: %v", err)
+		}
+	}()
+	return nil
+}
+
+// Stop implements storage.Reencryptor.
+func (r *reencryptor) Stop() {
+	r.mu.Lock()
+	if r.mu.cancel != nil {
+		r.mu.cancel()
+	}
+	r.mu.Unlock()
+	r.wg.Wait()
+}
+
+// Stats implements storage.Reencryptor.
+func (r *reencryptor) Stats() storage.ReencryptionStats {
+	r.mu.Lock()
+	running := r.mu.cancel != nil
+	r.mu.Unlock()
+	return storage.ReencryptionStats{
+		Running:        running,
+		RewrittenFiles: atomic.LoadUint64(&r.rewrittenFiles),
+		RewrittenBytes: atomic.LoadUint64(&r.rewrittenBytes),
+		RemainingFiles: atomic.LoadUint64(&r.remainingFiles),
+	}
+}
+
+// run rewrites the files which are not encrypted with the data key that is
+// active at the time of the call, and then reports the files which are still
+// not encrypted with it.
+func (r *reencryptor) run(ctx context.Context) error {
+	activeKey, err := r.dataKM.ActiveKey(ctx)
+	if err != nil {
+		return err
+	}
+	activeKeyID := plainKeyID
+	if activeKey != nil {
+		activeKeyID = activeKey.Info.KeyId
+	}
+	log.Infof(ctx, "re-encrypting files not encrypted with data key %s", activeKeyID)
+	names, err := r.oldKeyFiles(activeKeyID)
+	if err != nil {
+		return err
+	}
+
+	var files, bytes int64
+	for _, name := range names {
+		if !isImmutableFile(name) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		path := r.path(name)
+		n, err := r.reencryptFile(ctx, path)
+		if oserror.IsNotExist(err) {
+			// The file was compacted away in the meantime.
+			continue
+		}
+		if err != nil {
+			return errors.Wrapf(err, "re-encrypting %s", path)
+		}
+		files++
+		bytes += n
+		atomic.AddUint64(&r.rewrittenFiles, 1)
+		atomic.AddUint64(&r.rewrittenBytes, uint64(n))
+	}
+	log.Infof(ctx, "re-encrypted %d files (%d bytes) with data key %s", files, bytes, activeKeyID)
+
+	remaining, err := r.oldKeyFiles(activeKeyID)
+	if err != nil {
+		return err
+	}
+	atomic.StoreUint64(&r.remainingFiles, uint64(len(remaining)))
+	if len(remaining) == 0 {
+		log.Infof(ctx, "all files are encrypted with data key %s", activeKeyID)
+	} else {
+		log.Infof(ctx, "%d files cannot be rewritten while the store is open and remain "+
+			"encrypted with other data keys: %s", len(remaining), strings.Join(remaining, ", "))
+	}
+	return nil
+}
+
+// oldKeyFiles returns the names, relative to the DB directory, of the
+// non-empty data files which are not encrypted with the given data key. These
+// are the files of the data environment in the file registry, and the files
+// of the DB directory written by Pebble before encryption-at-rest was enabled.
+func (r *reencryptor) oldKeyFiles(activeKeyID string) ([]string, error) {
+	listed, err := r.fs.List(r.dbDir)
+	if err != nil {
+		return nil, err
+	}
+	candidates := make(map[string]struct{}, len(listed))
+	for _, name := range listed {
+		if isImmutableFile(name) || isAppendedFile(name) {
+			candidates[name] = struct{}{}
+		}
+	}
+	for _, name := range r.fs.fileRegistry.ListFiles() {
+		if entry := r.fs.fileRegistry.GetFileEntry(name); entry != nil &&
+			entry.EnvType == enginepb.EnvType_Data {
+			candidates[name] = struct{}{}
+		}
+	}
+
+	var names []string
+	for name := range candidates {
+		if strings.HasSuffix(name, reencryptCopySuffix) {
+			continue
+		}
+		path := r.path(name)
+		keyID, err := r.fileKeyID(path)
+		if err != nil {
+			return nil, err
+		}
+		if keyID == activeKeyID {
+			continue
+		}
+		info, err := r.fs.Stat(path)
+		if oserror.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		// Empty files, like the markers, contain no data to protect.
+		if info.IsDir() || info.Size() == 0 {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// path returns the path of the file with the given name, which is relative to
+// the DB directory unless the file is outside of it.
+func (r *reencryptor) path(name string) string {
+	if filepath.IsAbs(name) {
+		return name
+	}
+	return r.fs.PathJoin(r.dbDir, name)
+}
+
+// isImmutableFile returns whether the file with the given name, relative to
+// the DB directory, is one of Pebble's files which are never modified once
+// written, so that it can be replaced by a re-encrypted copy. These are the
+// SSTables and the OPTIONS files.
+func isImmutableFile(name string) bool {
+	if strings.ContainsRune(name, os.PathSeparator) {
+		// SSTables being received in snapshots are written in subdirectories.
+		return false
+	}
+	return strings.HasSuffix(name, ".sst") || strings.HasPrefix(name, "OPTIONS-")
+}
+
+// isAppendedFile returns whether the file with the given name, relative to the
+// DB directory, is one of Pebble's files which are appended to, i.e. the
+// MANIFEST and WAL files.
+func isAppendedFile(name string) bool {
+	if strings.ContainsRune(name, os.PathSeparator) {
+		return false
+	}
+	return strings.HasPrefix(name, "MANIFEST-") || strings.HasSuffix(name, ".log")
+}
+
+// fileKeyID returns the ID of the data key the file is encrypted with.
+func (r *reencryptor) fileKeyID(path string) (string, error) {
+	entry := r.fs.fileRegistry.GetFileEntry(path)
+	if entry == nil {
+		return plainKeyID, nil
+	}
+	var s enginepbccl.EncryptionSettings
+	if err := protoutil.Unmarshal(entry.EncryptionSettings, &s); err != nil {
+		return "", err
+	}
+	if s.EncryptionType == enginepbccl.EncryptionType_Plaintext {
+		return plainKeyID, nil
+	}
+	return s.KeyId, nil
+}
+
+// reencryptFile copies the file with the active data key, at the configured
+// rate, and replaces the file by the copy. It returns the size of the file.
+func (r *reencryptor) reencryptFile(ctx context.Context, path string) (int64, error) {
+	rate := reencryptionRate.Get(&r.st.SV)
+	r.limiter.UpdateLimit(quotapool.Limit(rate), rate*reencryptBurstFactor)
+
+	copyPath := path + reencryptCopySuffix
+	n, err := func() (int64, error) {
+		src, err := r.fs.Open(path)
+		if err != nil {
+			return 0, err
+		}
+		defer src.Close()
+		dst, err := r.fs.Create(copyPath)
+		if err != nil {
+			return 0, err
+		}
+		var n int64
+		buf := make([]byte, reencryptChunkSize)
+		for {
+			k, err := io.ReadFull(src, buf)
+			if k > 0 {
+				if err := r.limiter.WaitN(ctx, int64(k)); err != nil {
+					_ = dst.Close()
+					return 0, err
+				}
+				if _, err := dst.Write(buf[:k]); err != nil {
+					_ = dst.Close()
+					return 0, err
+				}
+				n += int64(k)
+			}
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				break
+			}
+			if err != nil {
+				_ = dst.Close()
+				return 0, err
+			}
+		}
+		if err := dst.Sync(); err != nil {
+			_ = dst.Close()
+			return 0, err
+		}
+		return n, dst.Close()
+	}()
+	if err != nil {
+		if !oserror.IsNotExist(err) {
+			_ = r.fs.Remove(copyPath)
+		}
+		return 0, err
+	}
+	if err := r.fs.replaceWithCopy(copyPath, path); err != nil {
+		return 0, err
+	}
+	dir, err := r.fs.OpenDir(r.dbDir)
+	if err != nil {
+		return 0, err
+	}
+	defer dir.Close()
+	return n, dir.Sync()
+}
+
+// recoverReplacedFiles completes or rolls back the replacements of files by
+// their re-encrypted copies that were interrupted by a crash. It must be
+// called before the store is opened.
+func recoverReplacedFiles(ctx context.Context, fs *encryptedFS, dbDir string) error {
+	names, err := fs.List(dbDir)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if !strings.HasSuffix(name, reencryptCopySuffix) {
+			continue
+		}
+		copyPath := fs.PathJoin(dbDir, name)
+		path := strings.TrimSuffix(copyPath, reencryptCopySuffix)
+		// The registry entry of the copy is only copied over the file's once the
+		// copy is complete, so matching entries mean that the file is unreadable
+		// until it is replaced by the copy.
+		_, err := fs.FS.Stat(path)
+		if err != nil && !oserror.IsNotExist(err) {
+			return err
+		}
+		if err == nil &&
+			proto.Equal(fs.fileRegistry.GetFileEntry(copyPath), fs.fileRegistry.GetFileEntry(path)) {
+			log.Infof(ctx, "completing the replacement of %s by its re-encrypted copy", path)
+			if err := fs.FS.Rename(copyPath, path); err != nil {
+				return err
+			}
+			if err := fs.fileRegistry.MaybeDeleteEntry(copyPath); err != nil {
+				return err
+			}
+			continue
+		}
+		log.Infof(ctx, "removing the incomplete re-encrypted copy of %s", path)
+		if err := fs.Remove(copyPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
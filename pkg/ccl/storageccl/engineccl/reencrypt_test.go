@@ -0,0 +1,241 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package engineccl
+
+import (
+	"context"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/ccl/baseccl"
+	"github.com/cockroachdb/cockroach/pkg/ccl/storageccl/engineccl/enginepbccl"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/storage/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/errors/oserror"
+	"github.com/cockroachdb/pebble"
+	"github.com/cockroachdb/pebble/vfs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPebbleReencryption(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	memFS := vfs.NewMem()
+	var encOptions baseccl.EncryptionOptions
+	encOptions.KeySource = baseccl.EncryptionKeySource_KMS
+	encOptions.KMS = &baseccl.EncryptionKMS{URI: testKMSURI("key1")}
+	encOptions.DataKeyRotationPeriod = 1000 // arbitrary seconds
+	encOptionsBytes, err := protoutil.Marshal(&encOptions)
+	require.NoError(t, err)
+
+	open := func() *storage.Pebble {
+		opts := storage.DefaultPebbleOptions()
+		opts.Cache = pebble.NewCache(1 << 20)
+		defer opts.Cache.Unref()
+		opts.FS = memFS
+		db, err := storage.NewPebble(ctx, storage.PebbleConfig{
+			StorageConfig: base.StorageConfig{
+				Attrs:             roachpb.Attributes{},
+				MaxSize:           512 << 20,
+				Settings:          cluster.MakeTestingClusterSettings(),
+				UseFileRegistry:   true,
+				EncryptionOptions: encOptionsBytes,
+			},
+			Opts: opts,
+		})
+		require.NoError(t, err)
+		return db
+	}
+	// fileKeyIDs returns the IDs of the data keys the data files are encrypted
+	// with, and the ID of the active data key.
+	fileKeyIDs := func(db *storage.Pebble) (map[string]string, string) {
+		r, err := db.GetEncryptionRegistries()
+		require.NoError(t, err)
+		var fileRegistry enginepb.FileRegistry
+		require.NoError(t, protoutil.Unmarshal(r.FileRegistry, &fileRegistry))
+		keyIDs := make(map[string]string)
+		for name, entry := range fileRegistry.Files {
+			if entry.EnvType != enginepb.EnvType_Data {
+				continue
+			}
+			var s enginepbccl.EncryptionSettings
+			require.NoError(t, protoutil.Unmarshal(entry.EncryptionSettings, &s))
+			keyIDs[name] = s.KeyId
+		}
+		stats, err := db.GetEnvStats()
+		require.NoError(t, err)
+		var status enginepbccl.EncryptionStatus
+		require.NoError(t, protoutil.Unmarshal(stats.EncryptionStatus, &status))
+		return keyIDs, status.ActiveDataKey.KeyId
+	}
+	sstKeyIDs := func(db *storage.Pebble) (map[string]string, string) {
+		keyIDs, activeKeyID := fileKeyIDs(db)
+		for name := range keyIDs {
+			if !strings.HasSuffix(name, ".sst") {
+				delete(keyIDs, name)
+			}
+		}
+		return keyIDs, activeKeyID
+	}
+	waitForReencryption := func(db *storage.Pebble) storage.ReencryptionStats {
+		var stats *storage.EnvStats
+		testutils.SucceedsSoon(t, func() (err error) {
+			stats, err = db.GetEnvStats()
+			if err != nil {
+				return err
+			}
+			if stats.Reencryption.Running {
+				return errors.New("re-encryption still running")
+			}
+			return nil
+		})
+		return stats.Reencryption
+	}
+
+	// A single sstable is flushed, so that it is not compacted.
+	db := open()
+	batch := db.NewUnindexedBatch(true /* writeOnly */)
+	for _, k := range []string{"a", "b"} {
+		require.NoError(t, batch.PutUnversioned(roachpb.Key(k), []byte(k)))
+	}
+	require.NoError(t, batch.Commit(true))
+	require.NoError(t, db.Flush())
+
+	// Rotating the store key rotates the data key, which leaves the existing
+	// sstable encrypted with the previous data key.
+	require.NoError(t, db.RotateStoreKey(ctx))
+	keyIDs, activeKeyID := sstKeyIDs(db)
+	require.Equal(t, 1, len(keyIDs))
+	for name, keyID := range keyIDs {
+		require.NotEqual(t, activeKeyID, keyID, name)
+	}
+
+	// The sstable and the OPTIONS file are rewritten. The MANIFEST, which is
+	// appended to, is reported as remaining encrypted with an older key.
+	require.NoError(t, db.StartReencryption(ctx))
+	stats := waitForReencryption(db)
+	require.Equal(t, uint64(2), stats.RewrittenFiles)
+	require.Less(t, uint64(0), stats.RewrittenBytes)
+	require.LessOrEqual(t, uint64(1), stats.RemainingFiles)
+	keyIDs, activeKeyID = sstKeyIDs(db)
+	require.Equal(t, 1, len(keyIDs))
+	for name, keyID := range keyIDs {
+		require.Equal(t, activeKeyID, keyID, name)
+	}
+	keyIDs, activeKeyID = fileKeyIDs(db)
+	for name, keyID := range keyIDs {
+		if keyID == activeKeyID {
+			continue
+		}
+		info, err := memFS.Stat(name)
+		if oserror.IsNotExist(err) || (err == nil && info.Size() == 0) {
+			continue
+		}
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(name, "MANIFEST-") || strings.HasSuffix(name, ".log"), name)
+	}
+	for _, k := range []string{"a", "b"} {
+		val, err := db.MVCCGet(storage.MVCCKey{Key: roachpb.Key(k)})
+		require.NoError(t, err)
+		require.Equal(t, k, string(val))
+	}
+	db.Close()
+
+	// The re-encrypted sstable is readable after a restart.
+	db = open()
+	defer db.Close()
+	for _, k := range []string{"a", "b"} {
+		val, err := db.MVCCGet(storage.MVCCKey{Key: roachpb.Key(k)})
+		require.NoError(t, err)
+		require.Equal(t, k, string(val))
+	}
+
+	// The restart replaced the MANIFEST, so once the obsolete files are
+	// deleted no file remains encrypted with an older key.
+	testutils.SucceedsSoon(t, func() error {
+		require.NoError(t, db.StartReencryption(ctx))
+		if stats := waitForReencryption(db); stats.RemainingFiles != 0 {
+			return errors.Newf("%d files remain encrypted with older keys", stats.RemainingFiles)
+		}
+		return nil
+	})
+}
+
+func TestRecoverReplacedFiles(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	memFS := vfs.NewMem()
+	require.NoError(t, memFS.MkdirAll("/db", 0755))
+	fileRegistry := &storage.PebbleFileRegistry{FS: memFS, DBDir: "/db"}
+	require.NoError(t, fileRegistry.Load())
+	writeToFile(t, memFS, "keyfile", []byte(keyFile128))
+	keyManager := &StoreKeyManager{fs: memFS, activeKeyFilename: "keyfile", oldKeyFilename: "plain"}
+	require.NoError(t, keyManager.Load(ctx))
+	fs := &encryptedFS{
+		FS:            memFS,
+		fileRegistry:  fileRegistry,
+		streamCreator: &FileCipherStreamCreator{keyManager: keyManager, envType: enginepb.EnvType_Data},
+	}
+
+	readFile := func(name string) string {
+		f, err := fs.Open(name)
+		require.NoError(t, err)
+		defer f.Close()
+		b, err := ioutil.ReadAll(f)
+		require.NoError(t, err)
+		return string(b)
+	}
+	const copySuffix = reencryptCopySuffix
+
+	// A copy that was not complete when the node crashed is removed.
+	writeToFile(t, fs, "/db/000001.sst", []byte("one"))
+	writeToFile(t, fs, "/db/000001.sst"+copySuffix, []byte("o"))
+	// A copy whose registry entry had been copied over the file's when the
+	// node crashed replaces the file.
+	writeToFile(t, fs, "/db/000002.sst", []byte("two"))
+	writeToFile(t, fs, "/db/000002.sst"+copySuffix, []byte("two"))
+	require.NoError(t, fileRegistry.MaybeCopyEntry("/db/000002.sst"+copySuffix, "/db/000002.sst"))
+	// A copy of a file that was removed is removed.
+	writeToFile(t, fs, "/db/000003.sst"+copySuffix, []byte("three"))
+
+	require.NoError(t, recoverReplacedFiles(ctx, fs, "/db"))
+	names, err := memFS.List("/db")
+	require.NoError(t, err)
+	for _, name := range names {
+		require.False(t, strings.HasSuffix(name, copySuffix), name)
+	}
+	require.Equal(t, "one", readFile("/db/000001.sst"))
+	require.Equal(t, "two", readFile("/db/000002.sst"))
+	for _, name := range []string{"000001.sst", "000002.sst", "000003.sst"} {
+		require.Nil(t, fileRegistry.GetFileEntry("/db/"+name+copySuffix), name)
+	}
+
+	// Replacing a file that was removed in the meantime removes the copy.
+	writeToFile(t, fs, "/db/000004.sst"+copySuffix, []byte("four"))
+	require.True(t, oserror.IsNotExist(fs.replaceWithCopy("/db/000004.sst"+copySuffix, "/db/000004.sst")))
+	_, err = memFS.Stat("/db/000004.sst" + copySuffix)
+	require.True(t, oserror.IsNotExist(err))
+
+	// Replacing a file makes it use the settings of the copy.
+	writeToFile(t, fs, "/db/000001.sst"+copySuffix, []byte("one"))
+	copyEntry := fileRegistry.GetFileEntry("/db/000001.sst" + copySuffix)
+	require.NoError(t, fs.replaceWithCopy("/db/000001.sst"+copySuffix, "/db/000001.sst"))
+	require.Equal(t, copyEntry, fileRegistry.GetFileEntry("/db/000001.sst"))
+	require.Equal(t, "one", readFile("/db/000001.sst"))
+}
@@ -53,3 +53,15 @@ var StreamReplicationMinCheckpointFrequency = settings.RegisterDurationSetting(
 	10*time.Second,
 	settings.NonNegativeDuration,
 )
+
+// StreamReplicationFailbackEnabled controls whether a replication stream can
+// fail back into the tenant it was replicated from. Writes to that tenant are
+// not rejected while it ingests the stream, so it must not be in use.
+var StreamReplicationFailbackEnabled = settings.RegisterBoolSetting(
+	settings.SystemOnly,
+	"stream_replication.failback.enabled",
+	"enables replication streams WITH failback, which revert and overwrite the tenant they "+
+		"ingest into; writes to the tenant are not rejected while the stream runs, so its "+
+		"SQL servers must be stopped first",
+	false,
+)
@@ -16,6 +16,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/jobs"
 	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
 	"github.com/cockroachdb/cockroach/pkg/kv"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/protectedts"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/sql"
@@ -25,6 +26,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
 	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
 	"github.com/cockroachdb/errors"
 )
 
@@ -55,17 +57,10 @@ func completeStreamIngestion(
 		return errors.Newf("job %d: not of expected type StreamIngest", streamID)
 	}
 
-	// Check that the supplied cutover time is a valid one.
-	// TODO(adityamaru): This will change once we allow a future cutover time to
-	// be specified.
-	hw := progress.GetHighWater()
-	if hw == nil || hw.Less(cutoverTimestamp) {
-		var highWaterTimestamp hlc.Timestamp
-		if hw != nil {
-			highWaterTimestamp = *hw
-		}
-		return errors.Newf("cannot cutover to a timestamp %s that is after the latest resolved time"+
-			" %s for job %d", cutoverTimestamp.String(), highWaterTimestamp.String(), streamID)
+	// The cutover time may be after the latest resolved time of the job, in
+	// which case the job keeps ingesting until it resolves the cutover time.
+	if cutoverTimestamp.IsEmpty() {
+		return errors.Newf("cannot cutover job %d to an empty timestamp", streamID)
 	}
 
 	// Reject setting a cutover time, if an earlier request to cutover has already
@@ -104,6 +99,7 @@ func ingest(
 	oldTenantID roachpb.TenantID,
 	newTenantID roachpb.TenantID,
 	startTime hlc.Timestamp,
+	minIngestionTime hlc.Timestamp,
 	progress jobspb.Progress,
 	ingestionJobID jobspb.JobID,
 ) error {
@@ -144,7 +140,8 @@ func ingest(
 
 		// Construct stream ingestion processor specs.
 		streamIngestionSpecs, streamIngestionFrontierSpec, err := distStreamIngestionPlanSpecs(
-			streamAddress, topology, sqlInstanceIDs, initialHighWater, ingestionJobID, streamID, oldTenantID, newTenantID)
+			streamAddress, topology, sqlInstanceIDs, initialHighWater, ingestionJobID, streamID, oldTenantID, newTenantID,
+			minIngestionTime)
 		if err != nil {
 			return err
 		}
@@ -173,9 +170,80 @@ func (s *streamIngestionResumer) Resume(resumeCtx context.Context, execCtx inter
 	details := s.job.Details().(jobspb.StreamIngestionDetails)
 	p := execCtx.(sql.JobExecContext)
 
+	// A failback stream reverts the keyspace of the tenant before it ingests
+	// anything, and only once, since the revert time bounds the timestamps of
+	// the ingested events.
+	var minIngestionTime hlc.Timestamp
+	if sp := s.job.Progress().GetStreamIngest(); sp != nil {
+		minIngestionTime = sp.FailbackRevertTime
+	}
+	if details.Failback && minIngestionTime.IsEmpty() {
+		var err error
+		if minIngestionTime, err = s.revertForFailback(resumeCtx, p, details); err != nil {
+			return err
+		}
+	}
+
 	// Start ingesting KVs from the replication stream.
 	streamAddress := streamingccl.StreamAddress(details.StreamAddress)
-	return ingest(resumeCtx, p, streamAddress, details.TenantID, details.NewTenantID, details.StartTime, s.job.Progress(), s.job.ID())
+	if err := ingest(resumeCtx, p, streamAddress, details.TenantID, details.NewTenantID,
+		details.StartTime, minIngestionTime, s.job.Progress(), s.job.ID()); err != nil {
+		return err
+	}
+	// The tenant has been reverted to the cutover time, so its history no
+	// longer needs to be protected.
+	return releaseProtectedTimestamp(resumeCtx, p.ExecCfg(), details.ProtectedTimestampRecordID)
+}
+
+// revertForFailback reverts the keyspace of the tenant of a failback stream to
+// the start time of the stream, which is the time that the tenant was cut over
+// from this cluster. The changes made to the tenant since then are discarded,
+// as they are not part of the history of the cluster that the tenant fails
+// back from. It records the time of the revert in the job progress and returns
+// it.
+//
+// The revert writes at the current time, which may be above the timestamps of
+// the changes of the stream. The stream thus ingests the changes at or below
+// the revert time at the revert time, so that they are not shadowed by the
+// revert. The history of the tenant between the start time of the stream and
+// the revert time is collapsed as a result.
+func (s *streamIngestionResumer) revertForFailback(
+	ctx context.Context, p sql.JobExecContext, details jobspb.StreamIngestionDetails,
+) (hlc.Timestamp, error) {
+	if err := revertSpanToTimestamp(ctx, p.ExecCfg().DB, details.Span, details.StartTime); err != nil {
+		return hlc.Timestamp{}, errors.Wrap(err, "reverting tenant for failback")
+	}
+	// The clock is above the timestamps of the writes of the revert once it
+	// has completed.
+	revertTime := p.ExecCfg().Clock.Now()
+	if err := s.job.Update(ctx, nil /* txn */, func(
+		txn *kv.Txn, md jobs.JobMetadata, ju *jobs.JobUpdater,
+	) error {
+		md.Progress.GetStreamIngest().FailbackRevertTime = revertTime
+		ju.UpdateProgress(md.Progress)
+		return nil
+	}); err != nil {
+		return hlc.Timestamp{}, err
+	}
+	return revertTime, nil
+}
+
+// releaseProtectedTimestamp releases the protected timestamp record of a
+// stream ingestion job, if it has one.
+func releaseProtectedTimestamp(
+	ctx context.Context, execCfg *sql.ExecutorConfig, ptsID *uuid.UUID,
+) error {
+	if ptsID == nil {
+		return nil
+	}
+	return execCfg.DB.Txn(ctx, func(ctx context.Context, txn *kv.Txn) error {
+		err := execCfg.ProtectedTimestampProvider.Release(ctx, txn, *ptsID)
+		if errors.Is(err, protectedts.ErrNotExists) {
+			// The record was already released by a previous attempt.
+			return nil
+		}
+		return err
+	})
 }
 
 // revertToCutoverTimestamp reads the job progress for the cutover time and
@@ -209,7 +277,15 @@ func revertToCutoverTimestamp(
 			"cannot revert to a consistent state")
 	}
 
-	spans := []roachpb.Span{sd.Span}
+	return revertSpanToTimestamp(ctx, db, sd.Span, sp.StreamIngest.CutoverTime)
+}
+
+// revertSpanToTimestamp issues RevertRangeRequests to revert the span to its
+// state as of the target time.
+func revertSpanToTimestamp(
+	ctx context.Context, db *kv.DB, span roachpb.Span, targetTime hlc.Timestamp,
+) error {
+	spans := []roachpb.Span{span}
 	for len(spans) != 0 {
 		var b kv.Batch
 		for _, span := range spans {
@@ -218,7 +294,7 @@ func revertToCutoverTimestamp(
 					Key:    span.Key,
 					EndKey: span.EndKey,
 				},
-				TargetTime:                          sp.StreamIngest.CutoverTime,
+				TargetTime:                          targetTime,
 				EnableTimeBoundIteratorOptimization: true,
 			})
 		}
@@ -251,8 +327,10 @@ func revertToCutoverTimestamp(
 // TODO(adityamaru): Add ClearRange logic once we have introduced
 // synchronization between the flow tearing down and the job transitioning to a
 // failed/canceled state.
-func (s *streamIngestionResumer) OnFailOrCancel(_ context.Context, _ interface{}) error {
-	return nil
+func (s *streamIngestionResumer) OnFailOrCancel(ctx context.Context, execCtx interface{}) error {
+	details := s.job.Details().(jobspb.StreamIngestionDetails)
+	p := execCtx.(sql.JobExecContext)
+	return releaseProtectedTimestamp(ctx, p.ExecCfg(), details.ProtectedTimestampRecordID)
 }
 
 var _ jobs.Resumer = &streamIngestionResumer{}
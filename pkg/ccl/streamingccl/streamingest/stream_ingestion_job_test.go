@@ -182,14 +182,6 @@ func TestCutoverBuiltin(t *testing.T) {
 	require.True(t, ok)
 	require.True(t, sp.StreamIngest.CutoverTime.IsEmpty())
 
-	// This should fail since no highwatermark is set on the progress.
-	cutoverTime := timeutil.Now().Round(time.Microsecond)
-	_, err = db.ExecContext(
-		ctx,
-		`SELECT crdb_internal.complete_stream_ingestion_job($1, $2)`,
-		job.ID(), cutoverTime)
-	require.Error(t, err, "cannot cutover to a timestamp")
-
 	var highWater time.Time
 	err = job.Update(ctx, nil, func(_ *kv.Txn, md jobs.JobMetadata, ju *jobs.JobUpdater) error {
 		highWater = timeutil.Now().Round(time.Microsecond)
@@ -198,15 +190,6 @@ func TestCutoverBuiltin(t *testing.T) {
 	})
 	require.NoError(t, err)
 
-	// This should fail since the highwatermark is less than the cutover time
-	// passed to the builtin.
-	cutoverTime = timeutil.Now().Round(time.Microsecond)
-	_, err = db.ExecContext(
-		ctx,
-		`SELECT crdb_internal.complete_stream_ingestion_job($1, $2)`,
-		job.ID(), cutoverTime)
-	require.Error(t, err, "cannot cutover to a timestamp")
-
 	// Ensure that the builtin runs locally.
 	var explain string
 	err = db.QueryRowContext(ctx,
@@ -215,12 +198,15 @@ func TestCutoverBuiltin(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, "distribution: local", explain)
 
-	// This should succeed since the highwatermark is equal to the cutover time.
+	// This should succeed even though the cutover time is after the
+	// highwatermark, since the job keeps ingesting until it reaches the cutover
+	// time.
+	cutoverTime := highWater.Add(time.Hour)
 	var jobID int64
 	err = db.QueryRowContext(
 		ctx,
 		`SELECT crdb_internal.complete_stream_ingestion_job($1, $2)`,
-		job.ID(), highWater).Scan(&jobID)
+		job.ID(), cutoverTime).Scan(&jobID)
 	require.NoError(t, err)
 	require.Equal(t, job.ID(), jobspb.JobID(jobID))
 
@@ -238,5 +224,5 @@ func TestCutoverBuiltin(t *testing.T) {
 	progress = sj.Progress()
 	sp, ok = progress.GetDetails().(*jobspb.Progress_StreamIngest)
 	require.True(t, ok)
-	require.Equal(t, hlc.Timestamp{WallTime: highWater.UnixNano()}, sp.StreamIngest.CutoverTime)
+	require.Equal(t, hlc.Timestamp{WallTime: cutoverTime.UnixNano()}, sp.StreamIngest.CutoverTime)
 }
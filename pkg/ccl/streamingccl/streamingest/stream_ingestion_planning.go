@@ -17,7 +17,9 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/ccl/utilccl"
 	"github.com/cockroachdb/cockroach/pkg/jobs"
 	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobsprotectedts"
 	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/protectedts/ptpb"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/sql"
@@ -27,9 +29,16 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/tracing"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
 	"github.com/cockroachdb/errors"
 )
 
+const streamIngestionOptionFailback = "failback"
+
+var streamIngestionOptionExpectValues = map[string]sql.KVStringOptValidate{
+	streamIngestionOptionFailback: sql.KVStringOptRequireNoValue,
+}
+
 func streamIngestionJobDescription(
 	p sql.PlanHookState, streamIngestion *tree.StreamIngestion,
 ) (string, error) {
@@ -103,6 +112,11 @@ func ingestionPlanHook(
 		return nil, nil, nil, false, err
 	}
 
+	optsFn, err := p.TypeAsStringOpts(ctx, ingestionStmt.Options, streamIngestionOptionExpectValues)
+	if err != nil {
+		return nil, nil, nil, false, err
+	}
+
 	fn := func(ctx context.Context, _ []sql.PlanNode, resultsCh chan<- tree.Datums) error {
 		ctx, span := tracing.ChildSpan(ctx, stmt.StatementTag())
 		defer span.Finish()
@@ -119,6 +133,11 @@ func ingestionPlanHook(
 			return err
 		}
 
+		opts, err := optsFn()
+		if err != nil {
+			return err
+		}
+
 		// We only support a TENANT target, so error out if that is nil.
 		if !ingestionStmt.Targets.TenantID.IsSet() {
 			return errors.Newf("no tenant specified in ingestion query: %s", ingestionStmt.String())
@@ -173,13 +192,33 @@ func ingestionPlanHook(
 				oldTenantID.ToUint64(), newTenantID.ToUint64())
 		}
 
+		// A failback streams the changes made to a tenant since it was cut over
+		// from this cluster back into the tenant it was replicated from, so it
+		// needs the cutover time to start from.
+		_, failback := opts[streamIngestionOptionFailback]
+		if failback && startTime.IsEmpty() {
+			return errors.Newf("%s requires AS OF SYSTEM TIME set to the cutover time of the tenant",
+				streamIngestionOptionFailback)
+		}
+		// Nothing rejects the writes of the SQL servers of the tenant while it
+		// ingests the stream, so a failback must be explicitly enabled.
+		if failback && !streamingccl.StreamReplicationFailbackEnabled.Get(&p.ExecCfg().Settings.SV) {
+			return errors.WithHint(
+				errors.Newf("%s is disabled", streamIngestionOptionFailback),
+				"Stop the SQL servers of the tenant, then SET CLUSTER SETTING "+
+					"stream_replication.failback.enabled = true.")
+		}
+
 		prefix := keys.MakeTenantPrefix(newTenantID)
+		ptsID := uuid.MakeV4()
 		streamIngestionDetails := jobspb.StreamIngestionDetails{
-			StreamAddress: string(streamAddress),
-			TenantID:      oldTenantID,
-			Span:          roachpb.Span{Key: prefix, EndKey: prefix.PrefixEnd()},
-			StartTime:     startTime,
-			NewTenantID:   newTenantID,
+			StreamAddress:              string(streamAddress),
+			TenantID:                   oldTenantID,
+			Span:                       roachpb.Span{Key: prefix, EndKey: prefix.PrefixEnd()},
+			StartTime:                  startTime,
+			NewTenantID:                newTenantID,
+			Failback:                   failback,
+			ProtectedTimestampRecordID: &ptsID,
 		}
 
 		jobDescription, err := streamIngestionJobDescription(p, ingestionStmt)
@@ -201,6 +240,20 @@ func ingestionPlanHook(
 		if err != nil {
 			return err
 		}
+
+		// Protect the history of the tenant from the start of the stream, so
+		// that it can be queried as of the replicated time, and reverted to the
+		// cutover time. The job advances the record as it replicates.
+		protectTime := startTime
+		if protectTime.IsEmpty() {
+			protectTime = p.ExecCfg().Clock.Now()
+		}
+		pts := jobsprotectedts.MakeRecord(ptsID, int64(jobID), protectTime,
+			roachpb.Spans{streamIngestionDetails.Span}, jobsprotectedts.Jobs,
+			ptpb.MakeTenantsTarget([]roachpb.TenantID{newTenantID}))
+		if err := p.ExecCfg().ProtectedTimestampProvider.Protect(ctx, p.Txn(), pts); err != nil {
+			return err
+		}
 		resultsCh <- tree.Datums{tree.NewDInt(tree.DInt(sj.ID()))}
 		return nil
 	}
@@ -378,20 +378,13 @@ func (sip *streamIngestionProcessor) checkForCutoverSignal(
 			}
 			// Job has been signaled to complete.
 			if !sp.StreamIngest.CutoverTime.IsEmpty() {
-				// Sanity check that the requested cutover time is less than equal to
-				// the resolved ts recorded in the job progress. This should already
-				// have been enforced when the cutover was signaled via the builtin.
-				// TODO(adityamaru): Remove this when we allow users to specify a
-				// cutover time in the future.
+				// The cutover time may be after the resolved ts recorded in the job
+				// progress, in which case ingestion continues until the resolved ts
+				// reaches it, so that the job can revert to a consistent state as of
+				// the cutover time.
 				resolvedTimestamp := progress.GetHighWater()
-				if resolvedTimestamp == nil {
-					return errors.AssertionFailedf("cutover has been requested before job %d has had a chance to"+
-						" record a resolved ts", jobID)
-				}
-				if resolvedTimestamp.Less(sp.StreamIngest.CutoverTime) {
-					return errors.AssertionFailedf("requested cutover time %s is before the resolved time %s recorded"+
-						" in job %d", sp.StreamIngest.CutoverTime.String(), resolvedTimestamp.String(),
-						jobID)
+				if resolvedTimestamp == nil || resolvedTimestamp.Less(sp.StreamIngest.CutoverTime) {
+					continue
 				}
 				sip.cutoverCh <- struct{}{}
 				return nil
@@ -566,6 +559,8 @@ func (sip *streamIngestionProcessor) bufferKV(event partitionEvent) error {
 		kv.Value.InitChecksum(kv.Key)
 	}
 
+	// The timestamp is raised to the minimum ingestion time when the KVs are
+	// flushed, as this may merge versions of the key.
 	mvccKey := storage.MVCCKey{
 		Key:       kv.Key,
 		Timestamp: kv.Value.Timestamp,
@@ -596,7 +591,19 @@ func (sip *streamIngestionProcessor) flush() (*jobspb.ResolvedSpans, error) {
 	sort.Sort(sip.curBatch)
 
 	totalSize := 0
+	minTime := sip.spec.MinIngestionTime
+	var prevKey storage.MVCCKey
 	for _, kv := range sip.curBatch {
+		// Versions below the minimum ingestion time are ingested at it. The batch
+		// is sorted with the newest versions of a key first, so only the newest of
+		// the versions which are raised to the same timestamp is ingested.
+		if kv.Key.Timestamp.Less(minTime) {
+			kv.Key.Timestamp = minTime
+			if kv.Key.Equal(prevKey) {
+				continue
+			}
+		}
+		prevKey = kv.Key
 		if err := sip.batcher.AddMVCCKey(sip.Ctx, kv.Key, kv.Value); err != nil { // problem is here
 			return nil, errors.Wrapf(err, "adding key %+v", kv)
 		}
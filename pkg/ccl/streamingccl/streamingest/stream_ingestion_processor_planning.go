@@ -17,6 +17,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/jobs"
 	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
 	"github.com/cockroachdb/cockroach/pkg/kv"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/protectedts"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/sql"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
@@ -38,6 +39,7 @@ func distStreamIngestionPlanSpecs(
 	streamID streaming.StreamID,
 	oldTenantID roachpb.TenantID,
 	newTenantID roachpb.TenantID,
+	minIngestionTime hlc.Timestamp,
 ) ([]*execinfrapb.StreamIngestionDataSpec, *execinfrapb.StreamIngestionFrontierSpec, error) {
 
 	// For each stream partition in the topology, assign it to a node.
@@ -59,6 +61,7 @@ func distStreamIngestionPlanSpecs(
 					OldID: oldTenantID,
 					NewID: newTenantID,
 				},
+				MinIngestionTime: minIngestionTime,
 			}
 			streamIngestionSpecs = append(streamIngestionSpecs, spec)
 		}
@@ -139,7 +142,7 @@ func distStreamIngest(
 	p.PlanToStreamColMap = []int{0}
 	dsp.FinalizePlan(planCtx, p)
 
-	rw := makeStreamIngestionResultWriter(ctx, jobID, execCfg.JobRegistry)
+	rw := makeStreamIngestionResultWriter(ctx, jobID, execCfg.JobRegistry, execCfg.ProtectedTimestampProvider)
 
 	recv := sql.MakeDistSQLReceiver(
 		ctx,
@@ -163,18 +166,20 @@ func distStreamIngest(
 type streamIngestionResultWriter struct {
 	ctx          context.Context
 	registry     *jobs.Registry
+	ptsProvider  protectedts.Provider
 	jobID        jobspb.JobID
 	rowsAffected int
 	err          error
 }
 
 func makeStreamIngestionResultWriter(
-	ctx context.Context, jobID jobspb.JobID, registry *jobs.Registry,
+	ctx context.Context, jobID jobspb.JobID, registry *jobs.Registry, ptsProvider protectedts.Provider,
 ) *streamIngestionResultWriter {
 	return &streamIngestionResultWriter{
-		ctx:      ctx,
-		registry: registry,
-		jobID:    jobID,
+		ctx:         ctx,
+		registry:    registry,
+		ptsProvider: ptsProvider,
+		jobID:       jobID,
 	}
 }
 
@@ -195,7 +200,27 @@ func (s *streamIngestionResultWriter) AddRow(ctx context.Context, row tree.Datum
 	}
 	return s.registry.UpdateJobWithTxn(ctx, s.jobID, nil /* txn */, false, /* useReadLock */
 		func(txn *kv.Txn, md jobs.JobMetadata, ju *jobs.JobUpdater) error {
-			return jobs.UpdateHighwaterProgressed(ingestedHighWatermark, md, ju)
+			if err := jobs.UpdateHighwaterProgressed(ingestedHighWatermark, md, ju); err != nil {
+				return err
+			}
+			// Advance the protected timestamp of the tenant to the replicated time,
+			// but not beyond the cutover time, which the tenant is reverted to.
+			ptsID := md.Payload.GetStreamIngestion().ProtectedTimestampRecordID
+			if ptsID == nil {
+				return nil
+			}
+			protectTime := ingestedHighWatermark
+			if cutover := md.Progress.GetStreamIngest().CutoverTime; !cutover.IsEmpty() && cutover.Less(protectTime) {
+				protectTime = cutover
+			}
+			record, err := s.ptsProvider.GetRecord(ctx, txn, *ptsID)
+			if err != nil {
+				return err
+			}
+			if record.Timestamp.Less(protectTime) {
+				return s.ptsProvider.UpdateTimestamp(ctx, txn, *ptsID, protectTime)
+			}
+			return nil
 		})
 }
 
@@ -27,6 +27,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/testutils/skip"
 	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
 	"github.com/cockroachdb/cockroach/pkg/testutils/testcluster"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/errors"
@@ -54,6 +55,7 @@ type tenantStreamingClusters struct {
 
 	destSysSQL    *sqlutils.SQLRunner
 	destTenantSQL *sqlutils.SQLRunner
+	destURL       url.URL
 }
 
 func (c *tenantStreamingClusters) compareResult(query string) {
@@ -133,7 +135,7 @@ func createtenantStreamingClusters(
 	// Start the source cluster.
 	sourceSysSQL, sourceTenantSQL, srcURL, srcCleanup := startTestClusterWithTenant(ctx, t, serverArgs, args.srcTenantID, args.srcNumNodes)
 	// Start the destination cluster.
-	destSysSQL, destTenantSQL, _, destCleanup := startTestClusterWithTenant(ctx, t, serverArgs, args.destTenantID, args.destNumNodes)
+	destSysSQL, destTenantSQL, destURL, destCleanup := startTestClusterWithTenant(ctx, t, serverArgs, args.destTenantID, args.destNumNodes)
 
 	args.srcInitFunc(t, sourceSysSQL, sourceTenantSQL)
 	args.destInitFunc(t, destSysSQL, destTenantSQL)
@@ -145,6 +147,7 @@ func createtenantStreamingClusters(
 			srcURL:        srcURL,
 			destSysSQL:    destSysSQL,
 			destTenantSQL: destTenantSQL,
+			destURL:       destURL,
 		}, func() {
 			destCleanup()
 			srcCleanup()
@@ -234,3 +237,150 @@ func TestPartitionedTenantStreamingEndToEnd(t *testing.T) {
 		testTenantStreaming(t, false /* withInitialScan */)
 	})
 }
+
+func TestTenantStreamingFutureCutoverAndStandbyReads(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	skip.UnderRace(t, "slow under race")
+	skip.UnderStress(t, "slow under stress")
+
+	ctx := context.Background()
+	c, cleanup := createtenantStreamingClusters(ctx, t, tenantStreamingClustersArgs{
+		srcTenantID: roachpb.MakeTenantID(10),
+		srcInitFunc: func(t *testing.T, sysSQL *sqlutils.SQLRunner, tenantSQL *sqlutils.SQLRunner) {
+			sysSQL.ExecMultiple(t, strings.Split(srcClusterSetting, ";")...)
+			tenantSQL.Exec(t, `
+	CREATE DATABASE d;
+	CREATE TABLE d.t(i int primary key);
+	INSERT INTO d.t VALUES (1);
+	`)
+		},
+		srcNumNodes:  1,
+		destTenantID: roachpb.MakeTenantID(20),
+		destInitFunc: func(t *testing.T, sysSQL *sqlutils.SQLRunner, tenantSQL *sqlutils.SQLRunner) {
+			sysSQL.ExecMultiple(t, strings.Split(destClusterSetting, ";")...)
+		},
+		destNumNodes: 1,
+	})
+	defer cleanup()
+
+	// A failback needs the cutover time to start from.
+	c.destSysSQL.ExpectErr(t, "failback requires AS OF SYSTEM TIME",
+		fmt.Sprintf("RESTORE TENANT 10 FROM REPLICATION STREAM FROM '%s' AS TENANT 30 WITH failback",
+			c.srcURL.String()))
+
+	producerJobID, ingestionJobID := c.startStreamReplication("")
+
+	// Cut over to a time in the future of the replicated time of the stream.
+	var cutoverTime time.Time
+	c.srcSysSQL.QueryRow(t, "SELECT clock_timestamp() + '5s'::INTERVAL").Scan(&cutoverTime)
+	c.destSysSQL.Exec(t, `SELECT crdb_internal.complete_stream_ingestion_job($1, $2)`,
+		ingestionJobID, cutoverTime)
+	c.srcExec(func(t *testing.T, sysSQL *sqlutils.SQLRunner, tenantSQL *sqlutils.SQLRunner) {
+		tenantSQL.Exec(t, `INSERT INTO d.t VALUES (2)`)
+	})
+
+	// The standby tenant can be read as of the replicated time of the stream.
+	testutils.SucceedsSoon(t, func() error {
+		progress := jobutils.GetJobProgress(t, c.destSysSQL, jobspb.JobID(ingestionJobID))
+		hw := progress.GetHighWater()
+		if hw == nil || hw.IsEmpty() {
+			return errors.New("stream ingestion has not recorded any progress yet")
+		}
+		c.destTenantSQL.CheckQueryResults(t,
+			fmt.Sprintf("SELECT count(*) > 0 FROM d.t AS OF SYSTEM TIME %s", hw.AsOfSystemTime()),
+			[][]string{{"true"}})
+		return nil
+	})
+
+	// The writes made before the cutover time are ingested before the job
+	// completes.
+	jobutils.WaitForJobToSucceed(t, c.destSysSQL, jobspb.JobID(ingestionJobID))
+	c.srcSysSQL.CheckQueryResultsRetry(t,
+		fmt.Sprintf("SELECT status FROM [SHOW JOBS] WHERE job_id = %d", producerJobID), [][]string{{"succeeded"}})
+	c.destTenantSQL.CheckQueryResults(t, "SELECT * FROM d.t ORDER BY i", [][]string{{"1"}, {"2"}})
+
+	// The protected timestamp record of the job is released once it completes.
+	c.destSysSQL.CheckQueryResults(t,
+		"SELECT count(*) FROM system.protected_ts_records WHERE meta_type = 'jobs'", [][]string{{"0"}})
+}
+
+func TestTenantStreamingFailback(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	skip.UnderRace(t, "slow under race")
+	skip.UnderStress(t, "slow under stress")
+
+	// Both clusters produce and ingest a stream: the destination cluster
+	// streams the tenant back into the source cluster once it is cut over.
+	clusterSettings := strings.Split(srcClusterSetting+";"+destClusterSetting, ";")
+	ctx := context.Background()
+	c, cleanup := createtenantStreamingClusters(ctx, t, tenantStreamingClustersArgs{
+		srcTenantID: roachpb.MakeTenantID(10),
+		srcInitFunc: func(t *testing.T, sysSQL *sqlutils.SQLRunner, tenantSQL *sqlutils.SQLRunner) {
+			sysSQL.ExecMultiple(t, clusterSettings...)
+			tenantSQL.Exec(t, `
+	CREATE DATABASE d;
+	CREATE TABLE d.t(i int primary key, s string);
+	INSERT INTO d.t VALUES (1, 'one'), (2, 'two');
+	`)
+		},
+		srcNumNodes:  1,
+		destTenantID: roachpb.MakeTenantID(20),
+		destInitFunc: func(t *testing.T, sysSQL *sqlutils.SQLRunner, tenantSQL *sqlutils.SQLRunner) {
+			sysSQL.ExecMultiple(t, clusterSettings...)
+		},
+		destNumNodes: 1,
+	})
+	defer cleanup()
+
+	var cutoverTime time.Time
+	c.srcSysSQL.QueryRow(t, "SELECT clock_timestamp()").Scan(&cutoverTime)
+	producerJobID, ingestionJobID := c.startStreamReplication("")
+	c.cutover(producerJobID, ingestionJobID, cutoverTime)
+	c.compareResult("SELECT * FROM d.t ORDER BY i")
+
+	// The destination tenant is the new primary. The writes made to the source
+	// tenant after the cutover time are discarded by the failback.
+	c.destTenantSQL.Exec(t, `
+	INSERT INTO d.t VALUES (3, 'three');
+	UPDATE d.t SET s = 'uno' WHERE i = 1;
+	DELETE FROM d.t WHERE i = 2;
+	`)
+	c.srcTenantSQL.Exec(t, `INSERT INTO d.t VALUES (4, 'four')`)
+
+	failbackStmt := fmt.Sprintf(
+		"RESTORE TENANT %s FROM REPLICATION STREAM FROM '%s' AS OF SYSTEM TIME %s AS TENANT %s WITH failback",
+		c.args.destTenantID, c.destURL.String(),
+		hlc.Timestamp{WallTime: cutoverTime.UnixNano()}.AsOfSystemTime(), c.args.srcTenantID)
+	c.srcSysSQL.ExpectErr(t, "failback is disabled", failbackStmt)
+	c.srcSysSQL.Exec(t, "SET CLUSTER SETTING stream_replication.failback.enabled = true")
+
+	// Fail back into the source tenant, with the clusters swapping roles.
+	var failbackJobID, failbackProducerJobID int
+	c.srcSysSQL.QueryRow(t, failbackStmt).Scan(&failbackJobID)
+	c.destSysSQL.CheckQueryResultsRetry(t,
+		"SELECT count(*) FROM [SHOW JOBS] WHERE job_type = 'STREAM REPLICATION'", [][]string{{"1"}})
+	c.destSysSQL.QueryRow(t, "SELECT job_id FROM [SHOW JOBS] WHERE job_type = 'STREAM REPLICATION'").
+		Scan(&failbackProducerJobID)
+	c.destTenantSQL.Exec(t, `INSERT INTO d.t VALUES (5, 'five')`)
+	var failbackCutoverTime time.Time
+	c.destSysSQL.QueryRow(t, "SELECT clock_timestamp()").Scan(&failbackCutoverTime)
+
+	failback := &tenantStreamingClusters{
+		t:             t,
+		srcSysSQL:     c.destSysSQL,
+		srcTenantSQL:  c.destTenantSQL,
+		destSysSQL:    c.srcSysSQL,
+		destTenantSQL: c.srcTenantSQL,
+	}
+	failback.cutover(failbackProducerJobID, failbackJobID, failbackCutoverTime)
+
+	// The source tenant has the writes made to the new primary, and not the ones
+	// made to it after the cutover time.
+	c.srcTenantSQL.CheckQueryResults(t, "SELECT * FROM d.t ORDER BY i",
+		[][]string{{"1", "uno"}, {"3", "three"}, {"5", "five"}})
+	c.compareResult("SELECT * FROM d.t ORDER BY i")
+}
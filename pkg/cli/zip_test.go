@@ -73,10 +73,12 @@ table_name NOT IN (
 	'cluster_contended_indexes',
 	'cluster_contended_tables',
 	'cluster_inflight_traces',
+	'cluster_lock_waits',
 	'cross_db_references',
 	'databases',
 	'forward_dependencies',
 	'index_columns',
+	'kv_store_encryption',
 	'lost_descriptors_with_data',
 	'table_columns',
 	'table_row_statistics',
@@ -0,0 +1,358 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package azure
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/cockroachdb/cockroach/pkg/cloud"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/errors"
+)
+
+const (
+	azureKMSScheme = "azure-kms"
+
+	// AzureVaultNameParam is the query parameter for the name of the Key Vault
+	// holding the key in an azure-kms URI.
+	AzureVaultNameParam = "AZURE_VAULT_NAME"
+	// AzureVaultURLParam is the query parameter overriding the URL of the Key
+	// Vault, which is otherwise derived from the vault name and environment.
+	AzureVaultURLParam = "AZURE_VAULT_URL"
+	// AzureTenantIDParam is the query parameter for the Azure AD tenant of the
+	// client in an azure-kms URI.
+	AzureTenantIDParam = "AZURE_TENANT_ID"
+	// AzureClientIDParam is the query parameter for the Azure AD client (the
+	// application ID of the service principal) in an azure-kms URI.
+	AzureClientIDParam = "AZURE_CLIENT_ID"
+	// AzureClientSecretParam is the query parameter for the secret of the Azure
+	// AD client in an azure-kms URI.
+	AzureClientSecretParam = "AZURE_CLIENT_SECRET"
+	// AzureADEndpointParam is the query parameter overriding the Azure AD
+	// endpoint, which is otherwise taken from the environment.
+	AzureADEndpointParam = "AZURE_AD_ENDPOINT"
+
+	// azureKMSAPIVersion is the version of the Key Vault REST API used.
+	azureKMSAPIVersion = "7.3"
+	// azureKMSAlgorithm is the key wrapping algorithm used with the RSA key.
+	azureKMSAlgorithm = "RSA-OAEP-256"
+	// azureIMDSTokenURL is the endpoint of the instance metadata service from
+	// which managed identity tokens are obtained when using implicit auth.
+	azureIMDSTokenURL = "http://169.254.169.254/metadata/identity/oauth2/token"
+	// azureTokenExpiryMargin is how long before its expiry a token is renewed.
+	azureTokenExpiryMargin = time.Minute
+)
+
+type azureKMS struct {
+	client   *http.Client
+	keyURL   string
+	keyID    string
+	getToken func(ctx context.Context) (azureToken, error)
+
+	mu struct {
+		syncutil.Mutex
+		token azureToken
+	}
+}
+
+type azureToken struct {
+	accessToken string
+	expiry      time.Time
+}
+
+var _ cloud.KMS = &azureKMS{}
+
+func init() {
+	cloud.RegisterKMSFromURIFactory(MakeAzureKMS, azureKMSScheme)
+	cloud.RegisterKMSRedactedParams(cloud.RedactedParams(AzureClientSecretParam))
+}
+
+type azureKMSURIParams struct {
+	vaultName    string
+	vaultURL     string
+	tenantID     string
+	clientID     string
+	clientSecret string
+	adEndpoint   string
+	environment  string
+	auth         string
+}
+
+func resolveAzureKMSURIParams(kmsURI url.URL) azureKMSURIParams {
+	q := kmsURI.Query()
+	return azureKMSURIParams{
+		vaultName:    q.Get(AzureVaultNameParam),
+		vaultURL:     q.Get(AzureVaultURLParam),
+		tenantID:     q.Get(AzureTenantIDParam),
+		clientID:     q.Get(AzureClientIDParam),
+		clientSecret: q.Get(AzureClientSecretParam),
+		adEndpoint:   q.Get(AzureADEndpointParam),
+		environment:  q.Get(AzureEnvironmentKeyParam),
+		auth:         q.Get(cloud.AuthParam),
+	}
+}
+
+// MakeAzureKMS is the factory method which returns a configured, ready-to-use
+// Azure Key Vault KMS object. The URI is of the form
+// azure-kms:///{key-name}/{key-version}?AZURE_VAULT_NAME={vault}&... and must
+// name an RSA key, which is used to wrap data keys with RSA-OAEP-256.
+func MakeAzureKMS(uri string, env cloud.KMSEnv) (cloud.KMS, error) {
+	if env.KMSConfig().DisableOutbound {
+		return nil, errors.New("external IO must be enabled to use Azure KMS")
+	}
+	kmsURI, err := url.ParseRequestURI(uri)
+	if err != nil {
+		return nil, err
+	}
+	params := resolveAzureKMSURIParams(*kmsURI)
+
+	// The key version is required, rather than defaulting to the current
+	// version, since a data key must be decrypted with the version of the key
+	// that encrypted it and the ciphertext does not record it.
+	keyPath := strings.Split(strings.Trim(kmsURI.Path, "/"), "/")
+	if len(keyPath) != 2 || keyPath[0] == "" || keyPath[1] == "" {
+		return nil, errors.Newf(
+			"azure-kms URI path must be of the form /{key-name}/{key-version}, got %q", kmsURI.Path)
+	}
+
+	if params.environment == "" {
+		params.environment = azure.PublicCloud.Name
+	}
+	azureEnv, err := azure.EnvironmentFromName(params.environment)
+	if err != nil {
+		return nil, errors.Wrap(err, "azure environment")
+	}
+
+	if params.vaultURL != "" || params.adEndpoint != "" {
+		if env.KMSConfig().DisableHTTP {
+			return nil, errors.New(
+				"custom endpoints disallowed for azure kms due to --external-io-disable-http flag")
+		}
+	}
+	vaultURL := params.vaultURL
+	if vaultURL == "" {
+		if params.vaultName == "" {
+			return nil, errors.Errorf("azure-kms URI missing %q parameter", AzureVaultNameParam)
+		}
+		vaultURL = "https://" + params.vaultName + "." + azureEnv.KeyVaultDNSSuffix
+	}
+	adEndpoint := params.adEndpoint
+	if adEndpoint == "" {
+		adEndpoint = azureEnv.ActiveDirectoryEndpoint
+	}
+
+	client, err := cloud.MakeHTTPClient(env.ClusterSettings())
+	if err != nil {
+		return nil, err
+	}
+
+	k := &azureKMS{
+		client: client,
+		keyURL: strings.TrimSuffix(vaultURL, "/") + "/keys/" +
+			url.PathEscape(keyPath[0]) + "/" + url.PathEscape(keyPath[1]),
+		keyID: keyPath[0] + "/" + keyPath[1],
+	}
+	resource := azureEnv.ResourceIdentifiers.KeyVault
+
+	switch params.auth {
+	case "", cloud.AuthParamSpecified:
+		for _, p := range []struct{ name, val string }{
+			{AzureTenantIDParam, params.tenantID},
+			{AzureClientIDParam, params.clientID},
+			{AzureClientSecretParam, params.clientSecret},
+		} {
+			if p.val == "" {
+				return nil, errors.Errorf(
+					"%s is set to '%s', but %s is not set",
+					cloud.AuthParam,
+					cloud.AuthParamSpecified,
+					p.name,
+				)
+			}
+		}
+		tokenURL := strings.TrimSuffix(adEndpoint, "/") + "/" +
+			url.PathEscape(params.tenantID) + "/oauth2/v2.0/token"
+		form := url.Values{
+			"grant_type":    {"client_credentials"},
+			"client_id":     {params.clientID},
+			"client_secret": {params.clientSecret},
+			"scope":         {strings.TrimSuffix(resource, "/") + "/.default"},
+		}
+		k.getToken = func(ctx context.Context) (azureToken, error) {
+			req, err := http.NewRequestWithContext(
+				ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+			if err != nil {
+				return azureToken{}, err
+			}
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			return k.fetchToken(req)
+		}
+	case cloud.AuthParamImplicit:
+		if env.KMSConfig().DisableImplicitCredentials {
+			return nil, errors.New(
+				"implicit credentials disallowed for azure due to --external-io-implicit-credentials flag")
+		}
+		// Implicit auth uses the managed identity of the VM, optionally picking
+		// a user-assigned identity by its client ID.
+		q := url.Values{"api-version": {"2018-02-01"}, "resource": {resource}}
+		if params.clientID != "" {
+			q.Set("client_id", params.clientID)
+		}
+		tokenURL := azureIMDSTokenURL + "?" + q.Encode()
+		k.getToken = func(ctx context.Context) (azureToken, error) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, tokenURL, nil)
+			if err != nil {
+				return azureToken{}, err
+			}
+			req.Header.Set("Metadata", "true")
+			return k.fetchToken(req)
+		}
+	default:
+		return nil, errors.Errorf("unsupported value %s for %s", params.auth, cloud.AuthParam)
+	}
+
+	return k, nil
+}
+
+// fetchToken sends an OAuth2 token request and parses the response, which has
+// the same shape for both Azure AD and the instance metadata service.
+func (k *azureKMS) fetchToken(req *http.Request) (azureToken, error) {
+	var res struct {
+		AccessToken string      `json:"access_token"`
+		ExpiresIn   json.Number `json:"expires_in"`
+	}
+	if err := k.do(req, &res); err != nil {
+		return azureToken{}, errors.Wrap(err, "fetching azure access token")
+	}
+	expiresIn, err := res.ExpiresIn.Int64()
+	if err != nil {
+		return azureToken{}, errors.Wrap(err, "parsing azure access token expiry")
+	}
+	return azureToken{
+		accessToken: res.AccessToken,
+		expiry:      timeutil.Now().Add(time.Duration(expiresIn) * time.Second),
+	}, nil
+}
+
+// token returns a cached access token for Key Vault, fetching a new one if the
+// cached one is missing or about to expire.
+func (k *azureKMS) token(ctx context.Context) (string, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.mu.token.accessToken == "" ||
+		timeutil.Now().Add(azureTokenExpiryMargin).After(k.mu.token.expiry) {
+		tok, err := k.getToken(ctx)
+		if err != nil {
+			return "", err
+		}
+		k.mu.token = tok
+	}
+	return k.mu.token.accessToken, nil
+}
+
+// do sends req and decodes the JSON response into res, turning non-2xx
+// responses into errors carrying the service's error message.
+func (k *azureKMS) do(req *http.Request, res interface{}) error {
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		// Key Vault reports errors as {"error": {"code": ..., "message": ...}}
+		// while Azure AD uses {"error": ..., "error_description": ...}.
+		var kvErr struct {
+			Error struct {
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		var adErr struct {
+			Error       string `json:"error"`
+			Description string `json:"error_description"`
+		}
+		if json.Unmarshal(body, &kvErr) == nil && kvErr.Error.Message != "" {
+			return errors.Newf("%s: %s: %s", resp.Status, kvErr.Error.Code, kvErr.Error.Message)
+		}
+		if json.Unmarshal(body, &adErr) == nil && adErr.Error != "" {
+			return errors.Newf("%s: %s: %s", resp.Status, adErr.Error, adErr.Description)
+		}
+		return errors.Newf("%s", resp.Status)
+	}
+	return json.Unmarshal(body, res)
+}
+
+// keyOperation performs the named Key Vault key operation (encrypt or
+// decrypt) on data.
+func (k *azureKMS) keyOperation(ctx context.Context, op string, data []byte) ([]byte, error) {
+	token, err := k.token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reqBody, err := json.Marshal(struct {
+		Alg   string `json:"alg"`
+		Value string `json:"value"`
+	}{Alg: azureKMSAlgorithm, Value: base64.RawURLEncoding.EncodeToString(data)})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		k.keyURL+"/"+op+"?api-version="+azureKMSAPIVersion, bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	var res struct {
+		Value string `json:"value"`
+	}
+	if err := k.do(req, &res); err != nil {
+		return nil, errors.Wrapf(err, "azure key vault %s", op)
+	}
+	// Key Vault returns unpadded base64url, but tolerate padding.
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(res.Value, "="))
+}
+
+// MasterKeyID implements the KMS interface.
+func (k *azureKMS) MasterKeyID() (string, error) {
+	return k.keyID, nil
+}
+
+// Encrypt implements the KMS interface.
+func (k *azureKMS) Encrypt(ctx context.Context, data []byte) ([]byte, error) {
+	return k.keyOperation(ctx, "encrypt", data)
+}
+
+// Decrypt implements the KMS interface.
+func (k *azureKMS) Decrypt(ctx context.Context, data []byte) ([]byte, error) {
+	return k.keyOperation(ctx, "decrypt", data)
+}
+
+// Close implements the KMS interface.
+func (k *azureKMS) Close() error {
+	k.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,220 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package azure
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/cloud"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeKeyVault is a stand-in for Azure AD and Key Vault which "encrypts" by
+// prefixing the data with the key it was encrypted with.
+type fakeKeyVault struct {
+	tokenRequests int32
+}
+
+const fakeAzureToken = "fake-token"
+
+func (f *fakeKeyVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	writeErr := func(code int, msg string) {
+		w.WriteHeader(code)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"error": map[string]string{"code": "Fake", "message": msg},
+		})
+	}
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/oauth2/v2.0/token"):
+		atomic.AddInt32(&f.tokenRequests, 1)
+		if r.PostFormValue("client_secret") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"error": "invalid_client", "error_description": "bad secret",
+			})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": fakeAzureToken, "expires_in": 3600,
+		})
+	case strings.HasPrefix(r.URL.Path, "/keys/"):
+		if r.Header.Get("Authorization") != "Bearer "+fakeAzureToken {
+			writeErr(http.StatusUnauthorized, "missing token")
+			return
+		}
+		var req struct{ Alg, Value string }
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Alg != azureKMSAlgorithm {
+			writeErr(http.StatusBadRequest, "bad request")
+			return
+		}
+		data, err := base64.RawURLEncoding.DecodeString(req.Value)
+		if err != nil {
+			writeErr(http.StatusBadRequest, err.Error())
+			return
+		}
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/keys/"), "/")
+		prefix := []byte(parts[0] + "/" + parts[1] + ":")
+		switch parts[2] {
+		case "encrypt":
+			data = append(prefix, data...)
+		case "decrypt":
+			if !strings.HasPrefix(string(data), string(prefix)) {
+				writeErr(http.StatusBadRequest, "wrong key")
+				return
+			}
+			data = data[len(prefix):]
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"kid": r.URL.Path, "value": base64.RawURLEncoding.EncodeToString(data),
+		})
+	default:
+		writeErr(http.StatusNotFound, "not found")
+	}
+}
+
+func TestEncryptDecryptAzure(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	fake := &fakeKeyVault{}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	kmsURI := func(path string, params map[string]string) string {
+		q := url.Values{
+			AzureVaultURLParam:     {srv.URL},
+			AzureADEndpointParam:   {srv.URL},
+			AzureTenantIDParam:     {"tenant"},
+			AzureClientIDParam:     {"client"},
+			AzureClientSecretParam: {"secret"},
+		}
+		for k, v := range params {
+			if v == "" {
+				q.Del(k)
+			} else {
+				q.Set(k, v)
+			}
+		}
+		return fmt.Sprintf("azure-kms://%s?%s", path, q.Encode())
+	}
+	env := cloud.TestKMSEnv{
+		Settings:         cluster.MakeTestingClusterSettings(),
+		ExternalIOConfig: &base.ExternalIODirConfig{},
+	}
+
+	t.Run("encrypt-decrypt", func(t *testing.T) {
+		cloud.KMSEncryptDecrypt(t, kmsURI("/key/v1", nil), env)
+	})
+
+	t.Run("token-cached", func(t *testing.T) {
+		ctx := context.Background()
+		kms, err := cloud.KMSFromURI(kmsURI("/key/v2", nil), &env)
+		require.NoError(t, err)
+		defer func() { require.NoError(t, kms.Close()) }()
+
+		id, err := kms.MasterKeyID()
+		require.NoError(t, err)
+		require.Equal(t, "key/v2", id)
+
+		before := atomic.LoadInt32(&fake.tokenRequests)
+		for i := 0; i < 3; i++ {
+			ciphertext, err := kms.Encrypt(ctx, []byte("data key"))
+			require.NoError(t, err)
+			plaintext, err := kms.Decrypt(ctx, ciphertext)
+			require.NoError(t, err)
+			require.Equal(t, "data key", string(plaintext))
+		}
+		require.Equal(t, before+1, atomic.LoadInt32(&fake.tokenRequests))
+
+		other, err := cloud.KMSFromURI(kmsURI("/key/v3", nil), &env)
+		require.NoError(t, err)
+		defer func() { require.NoError(t, other.Close()) }()
+		ciphertext, err := kms.Encrypt(ctx, []byte("data key"))
+		require.NoError(t, err)
+		_, err = other.Decrypt(ctx, ciphertext)
+		require.Regexp(t, "azure key vault decrypt: 400 Bad Request: Fake: wrong key", err)
+	})
+
+	t.Run("bad-credentials", func(t *testing.T) {
+		kms, err := cloud.KMSFromURI(kmsURI("/key/v1", map[string]string{AzureClientSecretParam: "wrong"}), &env)
+		require.NoError(t, err)
+		_, err = kms.Encrypt(context.Background(), []byte("data key"))
+		require.Regexp(t, "fetching azure access token: 401 Unauthorized: invalid_client: bad secret", err)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		for _, tc := range []struct {
+			uri string
+			env cloud.TestKMSEnv
+			err string
+		}{
+			{
+				uri: kmsURI("/key", nil),
+				env: env,
+				err: `azure-kms URI path must be of the form /{key-name}/{key-version}, got "/key"`,
+			},
+			{
+				uri: kmsURI("/key/v1", map[string]string{AzureClientSecretParam: ""}),
+				env: env,
+				err: fmt.Sprintf("%s is set to '%s', but %s is not set",
+					cloud.AuthParam, cloud.AuthParamSpecified, AzureClientSecretParam),
+			},
+			{
+				uri: kmsURI("/key/v1", map[string]string{AzureVaultURLParam: "", AzureADEndpointParam: ""}),
+				env: env,
+				err: fmt.Sprintf("azure-kms URI missing %q parameter", AzureVaultNameParam),
+			},
+			{
+				uri: kmsURI("/key/v1", map[string]string{cloud.AuthParam: "bogus"}),
+				env: env,
+				err: fmt.Sprintf("unsupported value bogus for %s", cloud.AuthParam),
+			},
+			{
+				uri: kmsURI("/key/v1", nil),
+				env: cloud.TestKMSEnv{
+					Settings:         env.Settings,
+					ExternalIOConfig: &base.ExternalIODirConfig{DisableHTTP: true},
+				},
+				err: "custom endpoints disallowed for azure kms due to --external-io-disable-http flag",
+			},
+			{
+				uri: kmsURI("/key/v1", map[string]string{cloud.AuthParam: cloud.AuthParamImplicit}),
+				env: cloud.TestKMSEnv{
+					Settings:         env.Settings,
+					ExternalIOConfig: &base.ExternalIODirConfig{DisableImplicitCredentials: true},
+				},
+				err: "implicit credentials disallowed for azure due to --external-io-implicit-credentials flag",
+			},
+		} {
+			env := tc.env
+			_, err := cloud.KMSFromURI(tc.uri, &env)
+			require.EqualError(t, err, tc.err)
+		}
+	})
+
+	t.Run("redacted", func(t *testing.T) {
+		redacted, err := cloud.RedactKMSURI(kmsURI("/key/v1", nil))
+		require.NoError(t, err)
+		require.NotContains(t, redacted, "secret")
+		require.Contains(t, redacted, AzureClientSecretParam+"=redacted")
+	})
+}
@@ -358,3 +358,95 @@ func WriteFile(ctx context.Context, dest ExternalStorage, basename string, src i
 	}
 	return errors.Wrap(w.Close(), "closing object")
 }
+
+// ReadSeeker is an io.ReadSeeker over a file in an ExternalStorage, for
+// readers of formats which need random access to files, like parquet. Only
+// the parts of the file that are read through it are read from the storage.
+type ReadSeeker struct {
+	ctx   context.Context
+	store ExternalStorage
+	name  string
+	// size is the size of the file, or -1 until the file is opened.
+	size int64
+	pos  int64
+	// r reads the file from offset pos, if it is open.
+	r ioctx.ReadCloserCtx
+}
+
+var _ io.ReadSeeker = &ReadSeeker{}
+
+// NewReadSeeker returns a ReadSeeker over the given file of store. It must be
+// closed after use.
+func NewReadSeeker(ctx context.Context, store ExternalStorage, name string) *ReadSeeker {
+	return &ReadSeeker{ctx: ctx, store: store, name: name, size: -1}
+}
+
+// Size returns the size of the file.
+func (s *ReadSeeker) Size() (int64, error) {
+	if s.size < 0 {
+		if err := s.open(); err != nil {
+			return 0, err
+		}
+	}
+	return s.size, nil
+}
+
+// Read implements the io.Reader interface.
+func (s *ReadSeeker) Read(p []byte) (int, error) {
+	if s.size >= 0 && s.pos >= s.size {
+		return 0, io.EOF
+	}
+	if s.r == nil {
+		if err := s.open(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := s.r.Read(s.ctx, p)
+	s.pos += int64(n)
+	return n, err
+}
+
+// Seek implements the io.Seeker interface.
+func (s *ReadSeeker) Seek(offset int64, whence int) (int64, error) {
+	var pos int64
+	switch whence {
+	case io.SeekStart:
+		pos = offset
+	case io.SeekCurrent:
+		pos = s.pos + offset
+	case io.SeekEnd:
+		size, err := s.Size()
+		if err != nil {
+			return 0, err
+		}
+		pos = size + offset
+	default:
+		return 0, errors.Newf("invalid whence %d", whence)
+	}
+	if pos < 0 {
+		return 0, errors.New("negative position")
+	}
+	if pos != s.pos {
+		s.Close()
+		s.pos = pos
+	}
+	return pos, nil
+}
+
+func (s *ReadSeeker) open() error {
+	r, size, err := s.store.ReadFileAt(s.ctx, s.name, s.pos)
+	if err != nil {
+		return err
+	}
+	s.r, s.size = r, size
+	return nil
+}
+
+// Close closes the reader of the file, if it is open. The ReadSeeker reopens
+// it if it is read again.
+func (s *ReadSeeker) Close() {
+	if s.r != nil {
+		_ = s.r.Close(s.ctx)
+		s.r = nil
+	}
+}
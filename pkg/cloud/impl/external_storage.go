@@ -24,4 +24,5 @@ import (
 	_ "github.com/cockroachdb/cockroach/pkg/cloud/nodelocal"
 	_ "github.com/cockroachdb/cockroach/pkg/cloud/nullsink"
 	_ "github.com/cockroachdb/cockroach/pkg/cloud/userfile"
+	_ "github.com/cockroachdb/cockroach/pkg/cloud/vault"
 )
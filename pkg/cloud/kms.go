@@ -56,6 +56,15 @@ func RegisterKMSFromURIFactory(factory KMSFromURIFactory, scheme string) {
 	kmsFactoryMap[scheme] = factory
 }
 
+// RegisterKMSRedactedParams is used by KMS implementations whose URIs carry
+// secrets in query parameters that are not also used by an external storage
+// provider, so that RedactKMSURI redacts them.
+func RegisterKMSRedactedParams(params map[string]struct{}) {
+	for param := range params {
+		redactedQueryParams[param] = struct{}{}
+	}
+}
+
 // KMSFromURI is the method used to create a KMS instance from the provided URI.
 func KMSFromURI(uri string, env KMSEnv) (KMS, error) {
 	var kmsURL *url.URL
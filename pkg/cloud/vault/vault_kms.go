@@ -0,0 +1,237 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+// Package vault implements the KMS interface on top of the transit secrets
+// engine of HashiCorp Vault.
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/cloud"
+	"github.com/cockroachdb/errors"
+)
+
+const (
+	vaultTransitScheme = "vault-transit"
+
+	// VaultAddrParam is the query parameter for the address of the Vault server,
+	// e.g. https://vault.example.com:8200, in a vault-transit URI.
+	VaultAddrParam = "VAULT_ADDR"
+	// VaultTokenParam is the query parameter for the Vault token used to
+	// authenticate in a vault-transit URI.
+	VaultTokenParam = "VAULT_TOKEN"
+	// VaultNamespaceParam is the query parameter for the (enterprise) Vault
+	// namespace of the transit engine in a vault-transit URI.
+	VaultNamespaceParam = "VAULT_NAMESPACE"
+	// VaultMountParam is the query parameter for the path at which the transit
+	// secrets engine is mounted in a vault-transit URI. It defaults to
+	// "transit".
+	VaultMountParam = "VAULT_MOUNT"
+
+	defaultTransitMount = "transit"
+)
+
+type vaultKMS struct {
+	client    *http.Client
+	baseURL   string
+	keyName   string
+	keyID     string
+	token     string
+	namespace string
+}
+
+var _ cloud.KMS = &vaultKMS{}
+
+func init() {
+	cloud.RegisterKMSFromURIFactory(MakeVaultKMS, vaultTransitScheme)
+	cloud.RegisterKMSRedactedParams(cloud.RedactedParams(VaultTokenParam))
+}
+
+type kmsURIParams struct {
+	addr      string
+	token     string
+	namespace string
+	mount     string
+	auth      string
+}
+
+func resolveKMSURIParams(kmsURI url.URL) kmsURIParams {
+	q := kmsURI.Query()
+	params := kmsURIParams{
+		addr:      q.Get(VaultAddrParam),
+		token:     q.Get(VaultTokenParam),
+		namespace: q.Get(VaultNamespaceParam),
+		mount:     q.Get(VaultMountParam),
+		auth:      q.Get(cloud.AuthParam),
+	}
+	if params.mount == "" {
+		params.mount = defaultTransitMount
+	}
+	return params
+}
+
+// MakeVaultKMS is the factory method which returns a configured, ready-to-use
+// Vault transit KMS object. The URI is of the form
+// vault-transit:///{key-name}?VAULT_ADDR={addr}&VAULT_TOKEN={token}.
+func MakeVaultKMS(uri string, env cloud.KMSEnv) (cloud.KMS, error) {
+	if env.KMSConfig().DisableOutbound {
+		return nil, errors.New("external IO must be enabled to use Vault KMS")
+	}
+	kmsURI, err := url.ParseRequestURI(uri)
+	if err != nil {
+		return nil, err
+	}
+	params := resolveKMSURIParams(*kmsURI)
+
+	keyName := strings.Trim(kmsURI.Path, "/")
+	if keyName == "" || strings.Contains(keyName, "/") {
+		return nil, errors.Newf(
+			"vault-transit URI path must be of the form /{key-name}, got %q", kmsURI.Path)
+	}
+
+	if params.addr == "" {
+		return nil, errors.Errorf("vault-transit URI missing %q parameter", VaultAddrParam)
+	}
+	addr, err := url.Parse(params.addr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing %s", VaultAddrParam)
+	}
+	switch addr.Scheme {
+	case "https":
+	case "http":
+		if env.KMSConfig().DisableHTTP {
+			return nil, errors.New(
+				"http vault addresses disallowed for vault kms due to --external-io-disable-http flag")
+		}
+	default:
+		return nil, errors.Errorf("unsupported scheme %q for %s", addr.Scheme, VaultAddrParam)
+	}
+
+	switch params.auth {
+	case "", cloud.AuthParamSpecified:
+		if params.token == "" {
+			return nil, errors.Errorf(
+				"%s is set to '%s', but %s is not set",
+				cloud.AuthParam,
+				cloud.AuthParamSpecified,
+				VaultTokenParam,
+			)
+		}
+	default:
+		// Vault has no notion of ambient credentials of the node akin to an
+		// instance profile, so only explicitly specified tokens are supported.
+		return nil, errors.Errorf("unsupported value %s for %s", params.auth, cloud.AuthParam)
+	}
+
+	client, err := cloud.MakeHTTPClient(env.ClusterSettings())
+	if err != nil {
+		return nil, err
+	}
+
+	mount := strings.Trim(params.mount, "/")
+	return &vaultKMS{
+		client:    client,
+		baseURL:   strings.TrimSuffix(params.addr, "/") + "/v1/" + mount,
+		keyName:   keyName,
+		keyID:     mount + "/" + keyName,
+		token:     params.token,
+		namespace: params.namespace,
+	}, nil
+}
+
+// transit performs the named transit engine operation (encrypt or decrypt) on
+// the key, sending req and decoding the "data" of the response into res.
+func (k *vaultKMS) transit(ctx context.Context, op string, req, res interface{}) error {
+	reqBody, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		k.baseURL+"/"+op+"/"+url.PathEscape(k.keyName), bytes.NewReader(reqBody))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("X-Vault-Token", k.token)
+	if k.namespace != "" {
+		httpReq.Header.Set("X-Vault-Namespace", k.namespace)
+	}
+
+	resp, err := k.client.Do(httpReq)
+	if err != nil {
+		return errors.Wrapf(err, "vault transit %s", op)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrapf(err, "vault transit %s", op)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var vaultErr struct {
+			Errors []string `json:"errors"`
+		}
+		if json.Unmarshal(body, &vaultErr) == nil && len(vaultErr.Errors) > 0 {
+			return errors.Newf("vault transit %s: %s: %s",
+				op, resp.Status, strings.Join(vaultErr.Errors, "; "))
+		}
+		return errors.Newf("vault transit %s: %s", op, resp.Status)
+	}
+	wrapped := struct {
+		Data interface{} `json:"data"`
+	}{Data: res}
+	return errors.Wrapf(json.Unmarshal(body, &wrapped), "vault transit %s", op)
+}
+
+// MasterKeyID implements the KMS interface.
+func (k *vaultKMS) MasterKeyID() (string, error) {
+	return k.keyID, nil
+}
+
+// Encrypt implements the KMS interface. The returned ciphertext is the
+// "vault:v{N}:..." string produced by Vault, which records the version of the
+// key used, so that data keys remain decryptable after the key is rotated.
+func (k *vaultKMS) Encrypt(ctx context.Context, data []byte) ([]byte, error) {
+	var res struct {
+		Ciphertext string `json:"ciphertext"`
+	}
+	if err := k.transit(ctx, "encrypt", map[string]string{
+		"plaintext": base64.StdEncoding.EncodeToString(data),
+	}, &res); err != nil {
+		return nil, err
+	}
+	return []byte(res.Ciphertext), nil
+}
+
+// Decrypt implements the KMS interface.
+func (k *vaultKMS) Decrypt(ctx context.Context, data []byte) ([]byte, error) {
+	var res struct {
+		Plaintext string `json:"plaintext"`
+	}
+	if err := k.transit(ctx, "decrypt", map[string]string{
+		"ciphertext": string(data),
+	}, &res); err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(res.Plaintext)
+}
+
+// Close implements the KMS interface.
+func (k *vaultKMS) Close() error {
+	k.client.CloseIdleConnections()
+	return nil
+}
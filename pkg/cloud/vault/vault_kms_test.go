@@ -0,0 +1,189 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package vault
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/cloud"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/stretchr/testify/require"
+)
+
+const fakeVaultToken = "s.fake"
+
+// fakeTransit is a stand-in for the Vault transit engine mounted at "transit",
+// which "encrypts" by base64-encoding the plaintext again under a versioned,
+// key-specific prefix.
+func fakeTransit(w http.ResponseWriter, r *http.Request) {
+	writeErr := func(code int, msg string) {
+		w.WriteHeader(code)
+		_ = json.NewEncoder(w).Encode(map[string][]string{"errors": {msg}})
+	}
+	if r.Header.Get("X-Vault-Token") != fakeVaultToken {
+		writeErr(http.StatusForbidden, "permission denied")
+		return
+	}
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/v1/transit/"), "/")
+	if len(parts) != 2 {
+		writeErr(http.StatusNotFound, "no handler for route")
+		return
+	}
+	op, key := parts[0], parts[1]
+	prefix := "vault:v1:" + key + ":"
+	var req map[string]string
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(http.StatusBadRequest, err.Error())
+		return
+	}
+	var data map[string]string
+	switch op {
+	case "encrypt":
+		data = map[string]string{"ciphertext": prefix + req["plaintext"]}
+	case "decrypt":
+		if !strings.HasPrefix(req["ciphertext"], prefix) {
+			writeErr(http.StatusBadRequest, "cipher: message authentication failed")
+			return
+		}
+		data = map[string]string{"plaintext": strings.TrimPrefix(req["ciphertext"], prefix)}
+	default:
+		writeErr(http.StatusNotFound, "no handler for route")
+		return
+	}
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+}
+
+func TestEncryptDecryptVault(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	srv := httptest.NewServer(http.HandlerFunc(fakeTransit))
+	defer srv.Close()
+
+	kmsURI := func(key string, params map[string]string) string {
+		q := url.Values{
+			VaultAddrParam:  {srv.URL},
+			VaultTokenParam: {fakeVaultToken},
+		}
+		for k, v := range params {
+			if v == "" {
+				q.Del(k)
+			} else {
+				q.Set(k, v)
+			}
+		}
+		return fmt.Sprintf("vault-transit:///%s?%s", key, q.Encode())
+	}
+	env := cloud.TestKMSEnv{
+		Settings:         cluster.MakeTestingClusterSettings(),
+		ExternalIOConfig: &base.ExternalIODirConfig{},
+	}
+
+	t.Run("encrypt-decrypt", func(t *testing.T) {
+		cloud.KMSEncryptDecrypt(t, kmsURI("backups", nil), env)
+	})
+
+	t.Run("ciphertext", func(t *testing.T) {
+		ctx := context.Background()
+		kms, err := cloud.KMSFromURI(kmsURI("backups", nil), &env)
+		require.NoError(t, err)
+		defer func() { require.NoError(t, kms.Close()) }()
+
+		id, err := kms.MasterKeyID()
+		require.NoError(t, err)
+		require.Equal(t, "transit/backups", id)
+
+		ciphertext, err := kms.Encrypt(ctx, []byte("data key"))
+		require.NoError(t, err)
+		require.Equal(t,
+			"vault:v1:backups:"+base64.StdEncoding.EncodeToString([]byte("data key")), string(ciphertext))
+
+		other, err := cloud.KMSFromURI(kmsURI("other", nil), &env)
+		require.NoError(t, err)
+		defer func() { require.NoError(t, other.Close()) }()
+		_, err = other.Decrypt(ctx, ciphertext)
+		require.EqualError(t, err,
+			"vault transit decrypt: 400 Bad Request: cipher: message authentication failed")
+	})
+
+	t.Run("bad-token", func(t *testing.T) {
+		kms, err := cloud.KMSFromURI(kmsURI("backups", map[string]string{VaultTokenParam: "wrong"}), &env)
+		require.NoError(t, err)
+		_, err = kms.Encrypt(context.Background(), []byte("data key"))
+		require.EqualError(t, err, "vault transit encrypt: 403 Forbidden: permission denied")
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		for _, tc := range []struct {
+			uri string
+			env cloud.TestKMSEnv
+			err string
+		}{
+			{
+				uri: kmsURI("", nil),
+				env: env,
+				err: `vault-transit URI path must be of the form /{key-name}, got "/"`,
+			},
+			{
+				uri: kmsURI("backups", map[string]string{VaultAddrParam: ""}),
+				env: env,
+				err: fmt.Sprintf("vault-transit URI missing %q parameter", VaultAddrParam),
+			},
+			{
+				uri: kmsURI("backups", map[string]string{VaultTokenParam: ""}),
+				env: env,
+				err: fmt.Sprintf("%s is set to '%s', but %s is not set",
+					cloud.AuthParam, cloud.AuthParamSpecified, VaultTokenParam),
+			},
+			{
+				uri: kmsURI("backups", map[string]string{cloud.AuthParam: cloud.AuthParamImplicit}),
+				env: env,
+				err: fmt.Sprintf("unsupported value implicit for %s", cloud.AuthParam),
+			},
+			{
+				uri: kmsURI("backups", nil),
+				env: cloud.TestKMSEnv{
+					Settings:         env.Settings,
+					ExternalIOConfig: &base.ExternalIODirConfig{DisableHTTP: true},
+				},
+				err: "http vault addresses disallowed for vault kms due to --external-io-disable-http flag",
+			},
+			{
+				uri: kmsURI("backups", nil),
+				env: cloud.TestKMSEnv{
+					Settings:         env.Settings,
+					ExternalIOConfig: &base.ExternalIODirConfig{DisableOutbound: true},
+				},
+				err: "external IO must be enabled to use Vault KMS",
+			},
+		} {
+			env := tc.env
+			_, err := cloud.KMSFromURI(tc.uri, &env)
+			require.EqualError(t, err, tc.err)
+		}
+	})
+
+	t.Run("redacted", func(t *testing.T) {
+		redacted, err := cloud.RedactKMSURI(kmsURI("backups", nil))
+		require.NoError(t, err)
+		require.NotContains(t, redacted, fakeVaultToken)
+		require.Contains(t, redacted, VaultTokenParam+"=redacted")
+	})
+}
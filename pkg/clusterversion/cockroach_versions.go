@@ -349,6 +349,28 @@ const (
 	// V22_1 is CockroachDB v22.1. It's used for all v22.1.x patch releases.
 	V22_1
 
+	// v22.2 versions.
+	//
+	// Start22_2 demarcates work towards CockroachDB v22.2.
+	Start22_2
+	// LDAPRoleMembersTable adds system.ldap_role_members to track the role
+	// memberships granted by LDAP group synchronization.
+	LDAPRoleMembersTable
+	// ImportParquet allows IMPORT of Parquet files, whose import specs older
+	// nodes cannot process.
+	ImportParquet
+	// SharedLockConflicts makes writes and intent resolution look for the
+	// replicated shared locks of other transactions.
+	SharedLockConflicts
+	// SharedLocks makes SELECT FOR SHARE acquire Shared locks instead of
+	// unreplicated Exclusive locks. It follows SharedLockConflicts, so that all
+	// the nodes look for shared locks before any node acquires one.
+	SharedLocks
+	// DeferrableConstraints allows foreign key and UNIQUE WITHOUT INDEX
+	// constraints to be declared DEFERRABLE, which older nodes would check at
+	// the end of each statement instead of at COMMIT.
+	DeferrableConstraints
+
 	// *************************************************
 	// Step (1): Add new versions here.
 	// Do not add new versions to a patch release.
@@ -604,6 +626,32 @@ var versionsSingleton = keyedVersions{
 		Version: roachpb.Version{Major: 22, Minor: 1},
 	},
 
+	// v22.2 versions. Internal versions must be even.
+	{
+		Key:     Start22_2,
+		Version: roachpb.Version{Major: 22, Minor: 1, Internal: 2},
+	},
+	{
+		Key:     LDAPRoleMembersTable,
+		Version: roachpb.Version{Major: 22, Minor: 1, Internal: 4},
+	},
+	{
+		Key:     ImportParquet,
+		Version: roachpb.Version{Major: 22, Minor: 1, Internal: 6},
+	},
+	{
+		Key:     SharedLockConflicts,
+		Version: roachpb.Version{Major: 22, Minor: 1, Internal: 8},
+	},
+	{
+		Key:     SharedLocks,
+		Version: roachpb.Version{Major: 22, Minor: 1, Internal: 10},
+	},
+	{
+		Key:     DeferrableConstraints,
+		Version: roachpb.Version{Major: 22, Minor: 1, Internal: 12},
+	},
+
 	// *************************************************
 	// Step (2): Add new versions here.
 	// Do not add new versions to a patch release.
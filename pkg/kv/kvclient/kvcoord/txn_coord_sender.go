@@ -139,6 +139,10 @@ type TxnCoordSender struct {
 		// This field is only populated on rootTxns.
 		userPriority roachpb.UserPriority
 
+		// isoLevel is the txn's isolation level. This field is only populated on
+		// rootTxns.
+		isoLevel kv.IsolationLevel
+
 		// commitWaitDeferred is set to true when the transaction commit-wait
 		// state is deferred and should not be run automatically. Instead, the
 		// caller of DeferCommitWait has assumed responsibility for performing
@@ -812,6 +816,9 @@ func (tc *TxnCoordSender) handleRetryableErrLocked(
 		tc.metrics.RestartsUnknown.Inc()
 	}
 	errTxnID := pErr.GetTxn().ID
+	if tc.mu.isoLevel.PerStatementReadSnapshot() && isStatementRetryableErr(pErr) {
+		return tc.handleStatementRetryableErrLocked(ctx, pErr)
+	}
 	newTxn := roachpb.PrepareTransactionForRetry(ctx, pErr, tc.mu.userPriority, tc.clock)
 
 	// We'll pass a TransactionRetryWithProtoRefreshError up to the next layer.
@@ -851,6 +858,51 @@ func (tc *TxnCoordSender) handleRetryableErrLocked(
 	return retErr
 }
 
+// isStatementRetryableErr returns whether the retryable error only requires
+// the statement that encountered it to be retried in a transaction that reads
+// from a new snapshot at each statement. These are the errors caused by a
+// conflict with a write above the read timestamp of the statement, which the
+// statement can avoid by reading at a higher timestamp. Other errors (e.g. the
+// transaction being aborted) still require a transaction restart.
+func isStatementRetryableErr(pErr *roachpb.Error) bool {
+	switch tErr := pErr.GetDetail().(type) {
+	case *roachpb.WriteTooOldError, *roachpb.ReadWithinUncertaintyIntervalError:
+		return true
+	case *roachpb.TransactionRetryError:
+		return tErr.Reason == roachpb.RETRY_WRITE_TOO_OLD || tErr.Reason == roachpb.RETRY_SERIALIZABLE
+	default:
+		return false
+	}
+}
+
+// handleStatementRetryableErrLocked handles a retryable error which only
+// requires the current statement to be retried. Unlike
+// handleRetryableErrLocked, the transaction keeps its epoch, so the writes of
+// the previous statements remain valid. Its timestamp is moved above the
+// conflict, which establishes the new read snapshot of the statement.
+//
+// The TxnCoordSender moves to the txnRetryableError state. The client is
+// expected to roll back to a savepoint taken before the statement, which
+// discards the writes of the statement and moves the TxnCoordSender back to
+// the txnPending state, and to retry the statement. If the client decides to
+// restart the transaction instead, ClearTxnRetryableErr bumps the epoch.
+func (tc *TxnCoordSender) handleStatementRetryableErrLocked(
+	ctx context.Context, pErr *roachpb.Error,
+) *roachpb.TransactionRetryWithProtoRefreshError {
+	retryTxn := roachpb.PrepareTransactionForRetry(ctx, pErr, tc.mu.userPriority, tc.clock)
+	tc.mu.txn.Refresh(retryTxn.WriteTimestamp)
+	tc.interceptorAlloc.txnSpanRefresher.resetRefreshSpansLocked(tc.mu.txn.ReadTimestamp)
+	log.VEventf(ctx, 2, "retrying statement at %s after retryable error: %s",
+		tc.mu.txn.ReadTimestamp, pErr)
+
+	retErr := roachpb.NewTransactionRetryWithProtoRefreshError(
+		pErr.String(), tc.mu.txn.ID, *tc.mu.txn.Clone())
+	retErr.StatementRetry = true
+	tc.mu.txnState = txnRetryableError
+	tc.mu.storedRetryableErr = retErr
+	return retErr
+}
+
 // updateStateLocked updates the transaction state in both the success and error
 // cases. It also updates retryable errors with the updated transaction for use
 // by client restarts.
@@ -1024,6 +1076,27 @@ func (tc *TxnCoordSender) SetUserPriority(pri roachpb.UserPriority) error {
 	return nil
 }
 
+// SetIsoLevel is part of the client.TxnSender interface.
+func (tc *TxnCoordSender) SetIsoLevel(isoLevel kv.IsolationLevel) error {
+	if tc.typ != kv.RootTxn {
+		return errors.AssertionFailedf("cannot set the isolation level of a non-root txn")
+	}
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	if tc.mu.active && isoLevel != tc.mu.isoLevel {
+		return errors.New("cannot change the isolation level of a running transaction")
+	}
+	tc.mu.isoLevel = isoLevel
+	return nil
+}
+
+// IsoLevel is part of the client.TxnSender interface.
+func (tc *TxnCoordSender) IsoLevel() kv.IsolationLevel {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	return tc.mu.isoLevel
+}
+
 // SetDebugName is part of the client.TxnSender interface.
 func (tc *TxnCoordSender) SetDebugName(name string) {
 	tc.mu.Lock()
@@ -1139,10 +1212,12 @@ func (tc *TxnCoordSender) IsSerializablePushAndRefreshNotPossible() bool {
 	defer tc.mu.Unlock()
 
 	isTxnPushed := tc.mu.txn.WriteTimestamp != tc.mu.txn.ReadTimestamp
-	refreshAttemptNotPossible := tc.interceptorAlloc.txnSpanRefresher.refreshInvalid ||
-		tc.mu.txn.CommitTimestampFixed
+	refreshAttemptNotPossible := (tc.interceptorAlloc.txnSpanRefresher.refreshInvalid &&
+		!tc.mu.isoLevel.PerStatementReadSnapshot()) || tc.mu.txn.CommitTimestampFixed
 	// We check CommitTimestampFixed here because, if that's set, refreshing
-	// of reads is not performed.
+	// of reads is not performed. A transaction which reads from a new snapshot
+	// at each statement doesn't need to refresh the reads of its previous
+	// statements, so it can always move to a new snapshot.
 	return isTxnPushed && refreshAttemptNotPossible
 }
 
@@ -1311,6 +1386,31 @@ func (tc *TxnCoordSender) Step(ctx context.Context) error {
 	return tc.interceptorAlloc.txnSeqNumAllocator.stepLocked(ctx)
 }
 
+// StepReadTimestamp is part of the TxnSender interface.
+func (tc *TxnCoordSender) StepReadTimestamp(ctx context.Context) error {
+	if tc.typ != kv.RootTxn {
+		return errors.AssertionFailedf("cannot step the read timestamp of a non-root txn")
+	}
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	if !tc.mu.isoLevel.PerStatementReadSnapshot() || tc.mu.txn.CommitTimestampFixed {
+		return nil
+	}
+	if tc.mu.txnState != txnPending {
+		// The next request will return the error of the transaction.
+		return nil
+	}
+	// Read from a snapshot at the current time, with a new uncertainty
+	// interval. The observed timestamps were established for the previous
+	// read timestamp, so they are discarded.
+	now := tc.clock.Now()
+	tc.mu.txn.Refresh(now)
+	tc.mu.txn.GlobalUncertaintyLimit.Forward(now.Add(tc.clock.MaxOffset().Nanoseconds(), 0))
+	tc.mu.txn.ResetObservedTimestamps()
+	tc.interceptorAlloc.txnSpanRefresher.resetRefreshSpansLocked(tc.mu.txn.ReadTimestamp)
+	return nil
+}
+
 // SetReadSeqNum is part of the TxnSender interface.
 func (tc *TxnCoordSender) SetReadSeqNum(seq enginepb.TxnSeq) error {
 	tc.mu.Lock()
@@ -1399,6 +1499,16 @@ func (tc *TxnCoordSender) ClearTxnRetryableErr(ctx context.Context) {
 	tc.mu.Lock()
 	defer tc.mu.Unlock()
 	if tc.mu.txnState == txnRetryableError {
+		if tc.mu.storedRetryableErr.StatementRetry {
+			// The statement is not going to be retried by rolling back to a
+			// savepoint, so the transaction needs to restart: the writes of the
+			// failed statement must not survive into the next attempt.
+			tc.mu.txn.Restart(tc.mu.userPriority, 0 /* upgradePriority */, tc.mu.txn.WriteTimestamp)
+			log.VEventf(ctx, 2, "restarting transaction after statement retryable error")
+			for _, reqInt := range tc.interceptorStack {
+				reqInt.epochBumpedLocked()
+			}
+		}
 		tc.mu.storedRetryableErr = nil
 		tc.mu.txnState = txnPending
 	}
@@ -132,6 +132,14 @@ func (tc *TxnCoordSender) RollbackToSavepoint(ctx context.Context, s kv.Savepoin
 		return err
 	}
 
+	// A retryable error which only requires a statement to be retried is
+	// cleared by rolling back the writes of the statement. The transaction
+	// keeps the timestamp that the error moved it to.
+	if tc.mu.txnState == txnRetryableError && tc.mu.storedRetryableErr.StatementRetry {
+		tc.mu.storedRetryableErr = nil
+		tc.mu.txnState = txnPending
+	}
+
 	tc.mu.active = sp.active
 
 	for _, reqInt := range tc.interceptorStack {
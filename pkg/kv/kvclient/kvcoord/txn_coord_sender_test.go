@@ -2952,3 +2952,56 @@ func TestTxnCoordSenderSetFixedTimestamp(t *testing.T) {
 		})
 	}
 }
+
+// TestTxnCoordSenderReadCommittedStatementRetry verifies that a transaction
+// that reads from a new snapshot at each statement only needs to retry the
+// statement that ran into a conflict, and keeps the writes of the previous
+// statements.
+func TestTxnCoordSenderReadCommittedStatementRetry(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	s := createTestDB(t)
+	defer s.Stop()
+
+	ctx := context.Background()
+	txn := kv.NewTxn(ctx, s.DB, 0 /* gatewayNodeID */)
+	require.NoError(t, txn.SetIsoLevel(kv.ReadCommittedIsolation))
+	require.Equal(t, kv.ReadCommittedIsolation, txn.IsoLevel())
+
+	// The first statement writes "a".
+	require.NoError(t, txn.StepReadTimestamp(ctx))
+	require.NoError(t, txn.Put(ctx, "a", "1"))
+	require.Error(t, txn.SetIsoLevel(kv.SerializableIsolation))
+
+	// The second statement reads "b", which is then written by another
+	// transaction, and writes it. The write can't be performed at the read
+	// timestamp of the statement, and its read can't be refreshed.
+	require.NoError(t, txn.StepReadTimestamp(ctx))
+	sp, err := txn.CreateSavepoint(ctx)
+	require.NoError(t, err)
+	_, err = txn.Get(ctx, "b")
+	require.NoError(t, err)
+	require.NoError(t, s.DB.Put(ctx, "b", "other"))
+	err = txn.Put(ctx, "b", "2")
+	var retryErr *roachpb.TransactionRetryWithProtoRefreshError
+	require.True(t, errors.As(err, &retryErr), "unexpected error: %v", err)
+	require.True(t, retryErr.StatementRetry)
+	require.Equal(t, txn.ID(), retryErr.TxnID)
+	require.Equal(t, enginepb.TxnEpoch(0), txn.Epoch())
+
+	// The statement is retried after rolling back to the savepoint. It reads
+	// from a snapshot which includes the conflicting write.
+	require.NoError(t, txn.RollbackToSavepoint(ctx, sp))
+	res, err := txn.Get(ctx, "b")
+	require.NoError(t, err)
+	require.Equal(t, []byte("other"), res.ValueBytes())
+	require.NoError(t, txn.Put(ctx, "b", "2"))
+	require.NoError(t, txn.Commit(ctx))
+
+	// The writes of both statements were committed.
+	for key, exp := range map[string]string{"a": "1", "b": "2"} {
+		res, err := s.DB.Get(ctx, key)
+		require.NoError(t, err)
+		require.Equal(t, []byte(exp), res.ValueBytes())
+	}
+}
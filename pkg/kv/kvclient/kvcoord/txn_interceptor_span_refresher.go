@@ -681,6 +681,17 @@ func (sr *txnSpanRefresher) importLeafFinalState(
 	}
 }
 
+// resetRefreshSpansLocked discards the refresh spans of the transaction and
+// moves its refreshed timestamp to the given read timestamp. It is used when a
+// transaction which reads from a new snapshot at each statement moves to a new
+// snapshot: the reads performed from previous snapshots don't need to be
+// refreshed.
+func (sr *txnSpanRefresher) resetRefreshSpansLocked(readTimestamp hlc.Timestamp) {
+	sr.refreshFootprint.clear()
+	sr.refreshInvalid = false
+	sr.refreshedTimestamp = readTimestamp
+}
+
 // epochBumpedLocked implements the txnInterceptor interface.
 func (sr *txnSpanRefresher) epochBumpedLocked() {
 	sr.refreshFootprint.clear()
@@ -428,7 +428,7 @@ type QPSScorerOptions struct {
 	// 2. Transferring this replica to another store would also transfer all of
 	// this replica's load onto that receiving store.
 	//
-	// See comment inside `StoreRebalancer.chooseRangeToRebalance()` for why these
+	// See comment inside `StoreRebalancer.ChooseRangeToRebalance()` for why these
 	// assumptions are justified in the case of replica rebalancing. The second
 	// assumption is not always valid for lease transfers because only the
 	// non-follower-read traffic will move to the target replica, but we don't
@@ -0,0 +1,683 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package asim
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/config/zonepb"
+	"github.com/cockroachdb/cockroach/pkg/gossip"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/allocator"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/allocator/allocatorimpl"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/allocator/storepool"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/liveness/livenesspb"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/replicastats"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/rpc"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/testutils/gossiputil"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/metric"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"go.etcd.io/etcd/raft/v3"
+	"go.etcd.io/etcd/raft/v3/tracker"
+)
+
+// simAllocator runs the decision logic of the replicate queue and of the store
+// rebalancer against the simulated state. It uses the real allocator, backed
+// by a real store pool, which learns about the simulated stores over gossip
+// just like it would in a cluster. The clock of the store pool and of the
+// replica stats is driven by the simulated time.
+type simAllocator struct {
+	state     *State
+	st        *cluster.Settings
+	stopper   *stop.Stopper
+	mc        *hlc.ManualClock
+	clock     *hlc.Clock
+	sp        *storepool.StorePool
+	gossiper  *gossiputil.StoreGossiper
+	allocator allocatorimpl.Allocator
+	// storeRebalancer makes the decisions of the store rebalancer of every
+	// simulated store.
+	storeRebalancer *kvserver.StoreRebalancer
+
+	// rangeLoad tracks the load of each range, the same way the leaseholder
+	// replica of the range tracks it in a cluster.
+	rangeLoad map[roachpb.RangeID]*rangeLoad
+}
+
+// rangeLoad is the load of a range, as seen by the allocators.
+type rangeLoad struct {
+	// qps tracks the rate of requests to the range.
+	qps *replicastats.ReplicaStats
+	// last is the load of the range as of the previous tick.
+	last  ReplicaLoad
+	usage allocator.RangeUsageInfo
+}
+
+func newSimAllocator(
+	ctx context.Context, st *cluster.Settings, state *State, start time.Time,
+) *simAllocator {
+	stopper := stop.NewStopper()
+	mc := hlc.NewManualClock(start.UnixNano())
+	clock := hlc.NewClock(mc.UnixNano, time.Nanosecond)
+	ambientCtx := log.MakeTestingAmbientContext(stopper.Tracer())
+	rpcContext := rpc.NewContext(ctx,
+		rpc.ContextOptions{
+			TenantID: roachpb.SystemTenantID,
+			Config:   &base.Config{Insecure: true},
+			Clock:    clock,
+			Stopper:  stopper,
+			Settings: st,
+		})
+	server := rpc.NewServer(rpcContext) // never started
+	g := gossip.NewTest(
+		1, rpcContext, server, stopper, metric.NewRegistry(), zonepb.DefaultZoneConfigRef(),
+	)
+	// TODO: simulate node liveness, to be able to simulate dead and
+	// decommissioning nodes.
+	mnl := storepool.NewMockNodeLiveness(livenesspb.NodeLivenessStatus_LIVE)
+	sp := storepool.NewStorePool(
+		ambientCtx,
+		st,
+		g,
+		clock,
+		func() int { return len(state.Nodes) },
+		mnl.NodeLivenessFunc,
+		true, /* deterministic */
+	)
+	a := allocatorimpl.MakeAllocator(sp, func(string) (time.Duration, bool) {
+		return 0, true
+	}, nil)
+	return &simAllocator{
+		state:   state,
+		st:      st,
+		stopper: stopper,
+		mc:      mc,
+		clock:   clock,
+		sp:      sp,
+		// NB: Gossip callbacks run in the order they were registered, so the
+		// store pool is up to date by the time the gossiper returns.
+		gossiper:        gossiputil.NewStoreGossiper(g),
+		allocator:       a,
+		storeRebalancer: kvserver.NewSimulatorStoreRebalancer(ambientCtx, st, a, clock),
+		rangeLoad:       make(map[roachpb.RangeID]*rangeLoad),
+	}
+}
+
+func (sa *simAllocator) stop(ctx context.Context) {
+	sa.stopper.Stop(ctx)
+}
+
+// ranges returns the ranges of the simulated state that have a leaseholder.
+func (sa *simAllocator) ranges() []*Range {
+	if sa.state.Ranges == nil {
+		return nil
+	}
+	var ranges []*Range
+	for _, r := range sa.state.Ranges.Ranges() {
+		if r.Leaseholder != nil {
+			ranges = append(ranges, r)
+		}
+	}
+	return ranges
+}
+
+// nodeIDs returns the IDs of the simulated nodes, in ascending order.
+func (sa *simAllocator) nodeIDs() []int {
+	nodeIDs := make([]int, 0, len(sa.state.Nodes))
+	for nodeID := range sa.state.Nodes {
+		nodeIDs = append(nodeIDs, nodeID)
+	}
+	sort.Ints(nodeIDs)
+	return nodeIDs
+}
+
+// updateStores advances the clock to the tick, records the load applied to
+// each range since the previous tick and gossips the resulting store
+// descriptors, which are returned as samples of the load of each store.
+func (sa *simAllocator) updateStores(
+	ctx context.Context, tick time.Time, interval time.Duration,
+) []StoreSample {
+	// The load of new ranges is tracked from the previous tick, when the load
+	// we are about to record started being applied.
+	for _, r := range sa.ranges() {
+		if _, ok := sa.rangeLoad[r.Desc.RangeID]; !ok {
+			sa.rangeLoad[r.Desc.RangeID] = &rangeLoad{
+				qps: replicastats.NewReplicaStats(sa.clock, nil),
+			}
+		}
+	}
+	sa.mc.Set(tick.UnixNano())
+
+	for _, r := range sa.ranges() {
+		rl := sa.rangeLoad[r.Desc.RangeID]
+		writes := r.Load.WriteKeys - rl.last.WriteKeys
+		requests := writes + r.Load.ReadKeys - rl.last.ReadKeys
+		rl.qps.RecordCount(float64(requests), r.Leaseholder.replDesc.NodeID)
+		rl.last = r.Load
+		rl.usage.QueriesPerSecond, _ = rl.qps.AverageRatePerSecond()
+		rl.usage.WritesPerSecond = float64(writes) / interval.Seconds()
+		rl.usage.LogicalBytes = r.LogicalBytes
+	}
+
+	descs := make([]*roachpb.StoreDescriptor, 0, len(sa.state.Nodes))
+	for _, nodeID := range sa.nodeIDs() {
+		node := sa.state.Nodes[nodeID]
+		store := node.Stores[0]
+		desc := &roachpb.StoreDescriptor{
+			StoreID: roachpb.StoreID(nodeID),
+			Node:    *node.nodeDesc,
+		}
+		capacity := &desc.Capacity
+		capacity.Capacity = store.capacityBytes
+		capacity.RangeCount = int32(len(store.Replicas))
+		for rangeID, repl := range store.Replicas {
+			rl, ok := sa.rangeLoad[roachpb.RangeID(rangeID)]
+			if !ok {
+				continue
+			}
+			if repl.leaseHolder {
+				capacity.LeaseCount++
+				capacity.QueriesPerSecond += rl.usage.QueriesPerSecond
+			}
+			capacity.WritesPerSecond += rl.usage.WritesPerSecond
+			capacity.LogicalBytes += rl.usage.LogicalBytes
+		}
+		capacity.Used = capacity.LogicalBytes
+		if capacity.Available = capacity.Capacity - capacity.Used; capacity.Available < 0 {
+			capacity.Available = 0
+		}
+		descs = append(descs, desc)
+	}
+
+	storeIDs := make([]roachpb.StoreID, len(descs))
+	for i, desc := range descs {
+		storeIDs[i] = desc.StoreID
+	}
+	sa.gossiper.GossipWithFunction(storeIDs, func() {
+		for _, desc := range descs {
+			key := gossip.MakeStoreKey(desc.StoreID)
+			if err := sa.sp.Gossip.AddInfoProto(key, desc, 0 /* ttl */); err != nil {
+				log.Fatalf(ctx, "unable to gossip store descriptor for s%d: %v", desc.StoreID, err)
+			}
+		}
+	})
+
+	samples := make([]StoreSample, len(descs))
+	for i, desc := range descs {
+		samples[i] = StoreSample{
+			Tick:            tick,
+			StoreID:         desc.StoreID,
+			QPS:             desc.Capacity.QueriesPerSecond,
+			WritesPerSecond: desc.Capacity.WritesPerSecond,
+			RangeCount:      desc.Capacity.RangeCount,
+			LeaseCount:      desc.Capacity.LeaseCount,
+			LogicalBytes:    desc.Capacity.LogicalBytes,
+		}
+	}
+	return samples
+}
+
+// simReplica is the leaseholder replica of a simulated range, as seen by the
+// allocator and the store rebalancer.
+type simReplica struct {
+	r     *Range
+	load  *rangeLoad
+	usage allocator.RangeUsageInfo
+}
+
+var _ kvserver.CandidateReplica = simReplica{}
+
+// replica returns the leaseholder replica of the range.
+func (sa *simAllocator) replica(r *Range) simReplica {
+	return simReplica{r: r, load: sa.rangeLoad[r.Desc.RangeID], usage: sa.usage(r)}
+}
+
+// OwnsValidLease returns true, since the simulator doesn't simulate lease
+// expiration.
+func (sr simReplica) OwnsValidLease(context.Context, hlc.ClockTimestamp) bool {
+	return true
+}
+
+// RaftStatus returns a raft status in which the leaseholder is the raft
+// leader and all the other replicas are up to date, since the simulator
+// doesn't simulate replication.
+func (sr simReplica) RaftStatus() *raft.Status {
+	status := &raft.Status{Progress: make(map[uint64]tracker.Progress)}
+	status.Lead = uint64(sr.r.Leaseholder.replDesc.ReplicaID)
+	status.RaftState = raft.StateLeader
+	status.Commit = 1
+	for _, repl := range sr.r.Desc.Replicas().Descriptors() {
+		status.Progress[uint64(repl.ReplicaID)] = tracker.Progress{
+			State: tracker.StateReplicate,
+			Match: status.Commit,
+		}
+	}
+	return status
+}
+
+// StoreID returns the store of the leaseholder replica.
+func (sr simReplica) StoreID() roachpb.StoreID {
+	return sr.r.Leaseholder.replDesc.StoreID
+}
+
+// GetRangeID returns the ID of the range.
+func (sr simReplica) GetRangeID() roachpb.RangeID {
+	return sr.r.Desc.RangeID
+}
+
+// Desc returns the descriptor of the range.
+func (sr simReplica) Desc() *roachpb.RangeDescriptor {
+	return sr.r.Desc
+}
+
+// DescAndSpanConfig returns the descriptor of the range and the span config
+// of its leaseholder.
+func (sr simReplica) DescAndSpanConfig() (*roachpb.RangeDescriptor, roachpb.SpanConfig) {
+	return sr.r.Desc, *sr.r.Leaseholder.spanConf
+}
+
+// LeaseholderStats returns the stats of the requests to the range.
+func (sr simReplica) LeaseholderStats() *replicastats.ReplicaStats {
+	if sr.load == nil {
+		return nil
+	}
+	return sr.load.qps
+}
+
+// RangeUsageInfo returns the usage info of the range, as of the last tick.
+func (sr simReplica) RangeUsageInfo() allocator.RangeUsageInfo {
+	return sr.usage
+}
+
+// QPS returns the QPS of the range, as of the last tick.
+func (sr simReplica) QPS() float64 {
+	return sr.usage.QueriesPerSecond
+}
+
+// runReplicateQueue processes every range the way the replicate queue of its
+// leaseholder store would, and applies the resulting changes to the state.
+//
+// TODO: we should pace the processing of ranges. The replicate queue tries to
+// process all replicas at a steady pace, to complete a pass within 10 minutes.
+// We should have similar logic here (using simulated time).
+func (sa *simAllocator) runReplicateQueue(
+	ctx context.Context, tick time.Time,
+) []RebalanceEvent {
+	var events []RebalanceEvent
+	for _, r := range sa.ranges() {
+		conf := *r.Leaseholder.spanConf
+		voters := r.Desc.Replicas().DeepCopy().VoterDescriptors()
+		nonVoters := r.Desc.Replicas().DeepCopy().NonVoterDescriptors()
+		usage := sa.usage(r)
+
+		action, _ := sa.allocator.ComputeAction(ctx, conf, r.Desc)
+		switch action {
+		case allocatorimpl.AllocatorAddVoter:
+			target, details, err := sa.allocator.AllocateVoter(ctx, conf, voters, nonVoters)
+			if err != nil {
+				log.VEventf(ctx, 2, "unable to allocate a voter for r%d: %v", r.Desc.RangeID, err)
+				continue
+			}
+			sa.addVoter(r, target.StoreID, usage)
+			events = append(events, RebalanceEvent{
+				Tick: tick, RangeID: r.Desc.RangeID, Source: ReplicateQueue,
+				Op: OpAddVoter, To: target.StoreID, Details: details,
+			})
+
+		case allocatorimpl.AllocatorRemoveVoter:
+			target, details, err := sa.allocator.RemoveVoter(
+				ctx, conf, voters, voters, nonVoters, sa.allocator.ScorerOptions(ctx),
+			)
+			if err != nil {
+				log.VEventf(ctx, 2, "unable to find a voter to remove from r%d: %v", r.Desc.RangeID, err)
+				continue
+			}
+			if target.StoreID == r.Leaseholder.replDesc.StoreID {
+				event, ok := sa.transferLeaseAway(ctx, tick, r, conf, voters, usage)
+				if !ok {
+					continue
+				}
+				events = append(events, event)
+			}
+			sa.removeVoter(r, target.StoreID, usage)
+			events = append(events, RebalanceEvent{
+				Tick: tick, RangeID: r.Desc.RangeID, Source: ReplicateQueue,
+				Op: OpRemoveVoter, From: target.StoreID, Details: details,
+			})
+
+		case allocatorimpl.AllocatorConsiderRebalance, allocatorimpl.AllocatorNoop:
+			add, remove, details, ok := sa.allocator.RebalanceVoter(
+				ctx,
+				conf,
+				sa.replica(r).RaftStatus(),
+				voters,
+				nonVoters,
+				usage,
+				storepool.StoreFilterThrottled,
+				sa.allocator.ScorerOptions(ctx),
+			)
+			if ok {
+				events = append(events, sa.rebalanceVoter(
+					ctx, tick, r, conf, add.StoreID, remove.StoreID, usage, ReplicateQueue, details,
+				)...)
+				continue
+			}
+			target := sa.allocator.TransferLeaseTarget(
+				ctx,
+				conf,
+				voters,
+				sa.replica(r),
+				sa.rangeLoad[r.Desc.RangeID].qps,
+				false, /* forceDecisionWithoutStats */
+				allocator.TransferLeaseOptions{
+					Goal:                   allocator.FollowTheWorkload,
+					CheckCandidateFullness: true,
+				},
+			)
+			if target == (roachpb.ReplicaDescriptor{}) {
+				continue
+			}
+			events = append(events, sa.transferLease(tick, r, target.StoreID, usage, ReplicateQueue))
+
+		default:
+			// TODO: simulate the other actions, which replace dead or
+			// decommissioning replicas and manage non-voters.
+			log.VEventf(ctx, 2, "unsupported action %s for r%d", action, r.Desc.RangeID)
+		}
+	}
+	return events
+}
+
+// runStoreRebalancer runs the store rebalancer of every store whose QPS is
+// above the overfull threshold. Like the real store rebalancer, it first sheds
+// the leases of its hottest ranges and then, if the store is still overfull,
+// moves the replicas of its hottest ranges to other stores. The decisions are
+// made by the StoreRebalancer itself, and applied to the simulated state.
+func (sa *simAllocator) runStoreRebalancer(
+	ctx context.Context, tick time.Time,
+) []RebalanceEvent {
+	var events []RebalanceEvent
+	for _, nodeID := range sa.nodeIDs() {
+		events = append(events, sa.rebalanceStore(ctx, tick, roachpb.StoreID(nodeID))...)
+	}
+	return events
+}
+
+// rebalanceStore runs the store rebalancer of the store, following
+// StoreRebalancer.rebalanceStore.
+func (sa *simAllocator) rebalanceStore(
+	ctx context.Context, tick time.Time, storeID roachpb.StoreID,
+) []RebalanceEvent {
+	options := sa.qpsScorerOptions(ctx)
+	storeList, _, _ := sa.sp.GetStoreList(storepool.StoreFilterSuspect)
+	var localDesc *roachpb.StoreDescriptor
+	for i := range storeList.Stores {
+		if storeList.Stores[i].StoreID == storeID {
+			localDesc = &storeList.Stores[i]
+			break
+		}
+	}
+	if localDesc == nil {
+		return nil
+	}
+	qpsMaxThreshold := allocatorimpl.OverfullQPSThreshold(
+		options, storeList.CandidateQueriesPerSecond.Mean,
+	)
+	if !(localDesc.Capacity.QueriesPerSecond > qpsMaxThreshold) {
+		return nil
+	}
+
+	var events []RebalanceEvent
+	var replicasToMaybeRebalance []kvserver.CandidateReplica
+	storeMap := storeList.ToMap()
+	hottestRanges := sa.hottestRanges(storeID)
+	for localDesc.Capacity.QueriesPerSecond > qpsMaxThreshold {
+		candidateReplica, target, considerForRebalance := sa.storeRebalancer.ChooseLeaseToTransfer(
+			ctx, &hottestRanges, localDesc, storeList, storeMap, sa.qpsScorerOptions(ctx),
+		)
+		replicasToMaybeRebalance = append(replicasToMaybeRebalance, considerForRebalance...)
+		if candidateReplica == nil {
+			break
+		}
+		r := candidateReplica.(simReplica).r
+		events = append(events, sa.transferLease(
+			tick, r, target.StoreID, candidateReplica.RangeUsageInfo(), StoreRebalancer,
+		))
+		localDesc.Capacity.LeaseCount--
+		localDesc.Capacity.QueriesPerSecond -= candidateReplica.QPS()
+		if otherDesc := storeMap[target.StoreID]; otherDesc != nil {
+			otherDesc.Capacity.LeaseCount++
+			otherDesc.Capacity.QueriesPerSecond += candidateReplica.QPS()
+		}
+	}
+
+	replicasToMaybeRebalance = append(replicasToMaybeRebalance, hottestRanges...)
+	for localDesc.Capacity.QueriesPerSecond > qpsMaxThreshold {
+		candidateReplica, voterTargets, nonVoterTargets := sa.storeRebalancer.ChooseRangeToRebalance(
+			ctx, &replicasToMaybeRebalance, localDesc, storeList, sa.qpsScorerOptions(ctx),
+		)
+		if candidateReplica == nil {
+			break
+		}
+		if len(nonVoterTargets) > 0 {
+			// TODO: simulate non-voters.
+			log.VEventf(ctx, 2, "unsupported non-voter targets %v for r%d",
+				nonVoterTargets, candidateReplica.GetRangeID())
+			continue
+		}
+		r := candidateReplica.(simReplica).r
+		replicasBeforeRebalance := r.Desc.Replicas().DeepCopy().Descriptors()
+		events = append(events, sa.relocateRange(
+			tick, r, voterTargets, candidateReplica.RangeUsageInfo(),
+		)...)
+
+		for i := range replicasBeforeRebalance {
+			if storeDesc := storeMap[replicasBeforeRebalance[i].StoreID]; storeDesc != nil {
+				storeDesc.Capacity.RangeCount--
+			}
+		}
+		localDesc.Capacity.LeaseCount--
+		localDesc.Capacity.QueriesPerSecond -= candidateReplica.QPS()
+		for i := range voterTargets {
+			if storeDesc := storeMap[voterTargets[i].StoreID]; storeDesc != nil {
+				storeDesc.Capacity.RangeCount++
+				if i == 0 {
+					storeDesc.Capacity.LeaseCount++
+					storeDesc.Capacity.QueriesPerSecond += candidateReplica.QPS()
+				}
+			}
+		}
+	}
+	return events
+}
+
+// qpsScorerOptions returns the scorer options used by the store rebalancer.
+func (sa *simAllocator) qpsScorerOptions(
+	ctx context.Context,
+) *allocatorimpl.QPSScorerOptions {
+	return &allocatorimpl.QPSScorerOptions{
+		StoreHealthOptions:    sa.allocator.StoreHealthOptions(ctx),
+		Deterministic:         sa.sp.Deterministic,
+		QPSRebalanceThreshold: allocator.QPSRebalanceThreshold.Get(&sa.st.SV),
+		MinRequiredQPSDiff:    allocator.MinQPSDifferenceForTransfers.Get(&sa.st.SV),
+	}
+}
+
+// hottestRanges returns the leaseholder replicas of the ranges whose lease is
+// on the store, ordered by decreasing QPS.
+func (sa *simAllocator) hottestRanges(storeID roachpb.StoreID) []kvserver.CandidateReplica {
+	var replicas []kvserver.CandidateReplica
+	for _, r := range sa.ranges() {
+		if r.Leaseholder.replDesc.StoreID == storeID {
+			replicas = append(replicas, sa.replica(r))
+		}
+	}
+	sort.SliceStable(replicas, func(i, j int) bool {
+		return replicas[i].QPS() > replicas[j].QPS()
+	})
+	return replicas
+}
+
+// usage returns the usage info of the range, as of the last tick.
+func (sa *simAllocator) usage(r *Range) allocator.RangeUsageInfo {
+	if rl, ok := sa.rangeLoad[r.Desc.RangeID]; ok {
+		return rl.usage
+	}
+	return allocator.RangeUsageInfo{LogicalBytes: r.LogicalBytes}
+}
+
+// rebalanceVoter moves the voter of the range from one store to another,
+// transferring the lease first if the leaseholder is the voter being moved.
+func (sa *simAllocator) rebalanceVoter(
+	ctx context.Context,
+	tick time.Time,
+	r *Range,
+	conf roachpb.SpanConfig,
+	add, remove roachpb.StoreID,
+	usage allocator.RangeUsageInfo,
+	source string,
+	details string,
+) []RebalanceEvent {
+	var events []RebalanceEvent
+	sa.addVoter(r, add, usage)
+	if remove == r.Leaseholder.replDesc.StoreID {
+		voters := r.Desc.Replicas().DeepCopy().VoterDescriptors()
+		event, ok := sa.transferLeaseAway(ctx, tick, r, conf, voters, usage)
+		if !ok {
+			// We can't remove the leaseholder, leave the range up-replicated for
+			// the replicate queue to fix up.
+			return []RebalanceEvent{{
+				Tick: tick, RangeID: r.Desc.RangeID, Source: source,
+				Op: OpAddVoter, To: add, Details: details,
+			}}
+		}
+		events = append(events, event)
+	}
+	sa.removeVoter(r, remove, usage)
+	return append(events, RebalanceEvent{
+		Tick: tick, RangeID: r.Desc.RangeID, Source: source,
+		Op: OpRebalanceVoter, From: remove, To: add, Details: details,
+	})
+}
+
+// relocateRange moves the voters of the range to the targets, and transfers
+// its lease to the first target, like AdminRelocateRange does for the store
+// rebalancer.
+func (sa *simAllocator) relocateRange(
+	tick time.Time,
+	r *Range,
+	voterTargets []roachpb.ReplicationTarget,
+	usage allocator.RangeUsageInfo,
+) []RebalanceEvent {
+	voters := r.Desc.Replicas().DeepCopy().VoterDescriptors()
+	var adds, removes []roachpb.StoreID
+	for _, target := range voterTargets {
+		if _, ok := r.Desc.GetReplicaDescriptor(target.StoreID); !ok {
+			adds = append(adds, target.StoreID)
+		}
+	}
+	for _, voter := range voters {
+		if !allocatorimpl.StoreHasReplica(voter.StoreID, voterTargets) {
+			removes = append(removes, voter.StoreID)
+		}
+	}
+
+	var events []RebalanceEvent
+	for _, storeID := range adds {
+		sa.addVoter(r, storeID, usage)
+	}
+	if to := voterTargets[0].StoreID; to != r.Leaseholder.replDesc.StoreID {
+		events = append(events, sa.transferLease(tick, r, to, usage, StoreRebalancer))
+	}
+	for _, storeID := range removes {
+		sa.removeVoter(r, storeID, usage)
+	}
+	for i := 0; i < len(adds) || i < len(removes); i++ {
+		event := RebalanceEvent{Tick: tick, RangeID: r.Desc.RangeID, Source: StoreRebalancer}
+		switch {
+		case i < len(adds) && i < len(removes):
+			event.Op, event.From, event.To = OpRebalanceVoter, removes[i], adds[i]
+		case i < len(adds):
+			event.Op, event.To = OpAddVoter, adds[i]
+		default:
+			event.Op, event.From = OpRemoveVoter, removes[i]
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+// transferLeaseAway transfers the lease of the range to another voter, ahead
+// of the removal of the leaseholder.
+func (sa *simAllocator) transferLeaseAway(
+	ctx context.Context,
+	tick time.Time,
+	r *Range,
+	conf roachpb.SpanConfig,
+	voters []roachpb.ReplicaDescriptor,
+	usage allocator.RangeUsageInfo,
+) (RebalanceEvent, bool) {
+	target := sa.allocator.TransferLeaseTarget(
+		ctx,
+		conf,
+		voters,
+		sa.replica(r),
+		sa.rangeLoad[r.Desc.RangeID].qps,
+		false, /* forceDecisionWithoutStats */
+		allocator.TransferLeaseOptions{
+			Goal:                   allocator.LeaseCountConvergence,
+			ExcludeLeaseRepl:       true,
+			CheckCandidateFullness: true,
+		},
+	)
+	if target == (roachpb.ReplicaDescriptor{}) {
+		log.VEventf(ctx, 2, "unable to find a lease transfer target for r%d", r.Desc.RangeID)
+		return RebalanceEvent{}, false
+	}
+	return sa.transferLease(tick, r, target.StoreID, usage, ReplicateQueue), true
+}
+
+// transferLease transfers the lease of the range to the store, updating the
+// store pool so that the following decisions take the transfer into account.
+func (sa *simAllocator) transferLease(
+	tick time.Time, r *Range, to roachpb.StoreID, usage allocator.RangeUsageInfo, source string,
+) RebalanceEvent {
+	from := r.Leaseholder.replDesc.StoreID
+	sa.state.TransferLease(r, int(to))
+	sa.sp.UpdateLocalStoresAfterLeaseTransfer(from, to, usage.QueriesPerSecond)
+	return RebalanceEvent{
+		Tick: tick, RangeID: r.Desc.RangeID, Source: source,
+		Op: OpTransferLease, From: from, To: to,
+	}
+}
+
+// addVoter adds a voter for the range on the store.
+func (sa *simAllocator) addVoter(
+	r *Range, storeID roachpb.StoreID, usage allocator.RangeUsageInfo,
+) {
+	sa.state.AddReplica(r, int(storeID))
+	sa.sp.UpdateLocalStoreAfterRebalance(storeID, usage, roachpb.ADD_VOTER)
+}
+
+// removeVoter removes the voter of the range from the store.
+func (sa *simAllocator) removeVoter(
+	r *Range, storeID roachpb.StoreID, usage allocator.RangeUsageInfo,
+) {
+	sa.state.RemoveReplica(r, int(storeID))
+	sa.sp.UpdateLocalStoreAfterRebalance(storeID, usage, roachpb.REMOVE_VOTER)
+}
@@ -15,8 +15,9 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/allocator/allocatorimpl"
+	"github.com/cockroachdb/cockroach/pkg/config/zonepb"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/google/btree"
 )
 
@@ -26,6 +27,11 @@ type Range struct {
 	MinKey      string
 	Leaseholder *Replica
 	Desc        *roachpb.RangeDescriptor
+	// Load is the load applied to the range over the simulation, regardless of
+	// which replica held the lease at the time.
+	Load ReplicaLoad
+	// LogicalBytes is the logical size of the range.
+	LogicalBytes int64
 }
 
 // Less is part of the btree.Item interface.
@@ -36,6 +42,7 @@ func (r *Range) Less(than btree.Item) bool {
 // RangeMap (unlike a regular map) can return the Range responsible for a key.
 type RangeMap struct {
 	ranges      *btree.BTree
+	rangesByID  map[roachpb.RangeID]*Range
 	lastRangeID int
 }
 
@@ -48,7 +55,7 @@ func (rm *RangeMap) nextRangeID() int {
 
 // NewRangeMap returns a valid empty RangeMap.
 func NewRangeMap() *RangeMap {
-	return &RangeMap{ranges: btree.New(8)}
+	return &RangeMap{ranges: btree.New(8), rangesByID: make(map[roachpb.RangeID]*Range)}
 }
 
 // AddRange adds a range to the RangeMap. The inserted range has it's end key
@@ -57,7 +64,20 @@ func NewRangeMap() *RangeMap {
 // This operation is the same as splitting a range at min key [start, end) into
 // [start,minKey) [minKey, end).
 func (rm *RangeMap) AddRange(minKey string) *Range {
-	r := &Range{MinKey: minKey, Desc: &roachpb.RangeDescriptor{RangeID: roachpb.RangeID(rm.nextRangeID())}}
+	return rm.AddRangeWithID(minKey, roachpb.RangeID(rm.nextRangeID()))
+}
+
+// AddRangeWithID is like AddRange, but uses the given range ID instead of
+// assigning the next available one. It is used to recreate the ranges of an
+// existing cluster, see LoadSnapshot.
+func (rm *RangeMap) AddRangeWithID(minKey string, rangeID roachpb.RangeID) *Range {
+	if _, ok := rm.rangesByID[rangeID]; ok {
+		panic(fmt.Sprintf("Range with ID %s already exists within the range map, unable to add new range", rangeID))
+	}
+	if int(rangeID) > rm.lastRangeID {
+		rm.lastRangeID = int(rangeID)
+	}
+	r := &Range{MinKey: minKey, Desc: &roachpb.RangeDescriptor{RangeID: rangeID}}
 
 	endKey := roachpb.RKeyMax
 	// Find the sucessor range in the range map, to determine the endkey.
@@ -87,6 +107,7 @@ func (rm *RangeMap) AddRange(minKey string) *Range {
 	r.Desc.EndKey = endKey
 	r.Desc.StartKey = roachpb.RKey(r.MinKey)
 	rm.ranges.ReplaceOrInsert(r)
+	rm.rangesByID[rangeID] = r
 	return r
 }
 
@@ -105,6 +126,22 @@ func (rm *RangeMap) GetRange(key string) *Range {
 	return rng
 }
 
+// GetRangeByID returns the range with the given ID, or nil if it doesn't
+// exist.
+func (rm *RangeMap) GetRangeByID(rangeID roachpb.RangeID) *Range {
+	return rm.rangesByID[rangeID]
+}
+
+// Ranges returns the ranges in the RangeMap, ordered by MinKey.
+func (rm *RangeMap) Ranges() []*Range {
+	ranges := make([]*Range, 0, rm.ranges.Len())
+	rm.ranges.Ascend(func(i btree.Item) bool {
+		ranges = append(ranges, i.(*Range))
+		return true
+	})
+	return ranges
+}
+
 // ReplicaLoad is the sum of all key accesses and size of bytes, both written
 // and read.
 // TODO(kvoli): In the non-simulated code, replica_stats currently maintains
@@ -118,17 +155,22 @@ type ReplicaLoad struct {
 	ReadBytes  int64
 }
 
-// applyReplicaLoad applies a load event onto a replica.
-func (r *Replica) applyReplicaLoad(le LoadEvent) {
+// apply adds a load event onto the load counters.
+func (rl *ReplicaLoad) apply(le LoadEvent) {
 	if le.isWrite {
-		r.ReplicaLoad.WriteBytes += le.size
-		r.ReplicaLoad.WriteKeys++
+		rl.WriteBytes += le.size
+		rl.WriteKeys += le.keys()
 	} else {
-		r.ReplicaLoad.ReadBytes += le.size
-		r.ReplicaLoad.ReadKeys++
+		rl.ReadBytes += le.size
+		rl.ReadKeys += le.keys()
 	}
 }
 
+// applyReplicaLoad applies a load event onto a replica.
+func (r *Replica) applyReplicaLoad(le LoadEvent) {
+	r.ReplicaLoad.apply(le)
+}
+
 // Replica represents a replica of a range.
 type Replica struct {
 	spanConf    *roachpb.SpanConfig
@@ -138,14 +180,18 @@ type Replica struct {
 	leaseHolder bool
 }
 
+// defaultStoreCapacityBytes is the disk capacity of stores when the cluster
+// config doesn't specify one.
+const defaultStoreCapacityBytes = 1 << 40 // 1 TiB
+
 // Store simulates a store within a node.
 type Store struct {
-	Replicas  map[int]*Replica
-	allocator allocatorimpl.Allocator
+	Replicas map[int]*Replica
+	// capacityBytes is the disk capacity of the store.
+	capacityBytes int64
 }
 
 // Node represents a node within the cluster.
-// TODO: add regions.
 type Node struct {
 	nodeDesc *roachpb.NodeDescriptor
 	Stores   []*Store
@@ -177,10 +223,20 @@ func NewState() *State {
 
 // AddNode adds a node with a single store to the cluster.
 func (s *State) AddNode() (nodeID int) {
-	s.lastNodeID++
-	nodeID = s.lastNodeID
+	return s.AddNodeWithLocality(s.lastNodeID+1, roachpb.Locality{})
+}
+
+// AddNodeWithLocality adds a node with the given ID and locality, and a single
+// store, to the cluster.
+func (s *State) AddNodeWithLocality(nodeID int, locality roachpb.Locality) int {
+	if _, ok := s.Nodes[nodeID]; ok {
+		panic(fmt.Sprintf("Node %d already exists, unable to add it", nodeID))
+	}
+	if nodeID > s.lastNodeID {
+		s.lastNodeID = nodeID
+	}
 	n := NewNode()
-	n.nodeDesc = &roachpb.NodeDescriptor{NodeID: roachpb.NodeID(nodeID)}
+	n.nodeDesc = &roachpb.NodeDescriptor{NodeID: roachpb.NodeID(nodeID), Locality: locality}
 	s.Nodes[nodeID] = n
 	s.AddStore(nodeID)
 	return nodeID
@@ -190,16 +246,12 @@ func (s *State) AddNode() (nodeID int) {
 // TODO(lidorcarmel,kvoli): Add storeID parameter to support multi-store
 // configurations.
 func (s *State) AddStore(node int) {
-	allocator := allocatorimpl.MakeAllocator(
-		nil,
-		func(string) (time.Duration, bool) {
-			return 0, true
-		},
-		nil,
-	)
 	store := &Store{
-		allocator: allocator,
-		Replicas:  make(map[int]*Replica),
+		Replicas:      make(map[int]*Replica),
+		capacityBytes: defaultStoreCapacityBytes,
+	}
+	if s.Cluster != nil && s.Cluster.DiskCapacityGB > 0 {
+		store.capacityBytes = int64(s.Cluster.DiskCapacityGB) << 30
 	}
 	s.Nodes[node].Stores = append(s.Nodes[node].Stores, store)
 }
@@ -217,8 +269,9 @@ func (s *State) AddReplica(r *Range, node int) int {
 	// Initially we assume that there is one store per node.
 	desc := r.Desc.AddReplica(roachpb.NodeID(node), roachpb.StoreID(node), roachpb.VOTER_FULL)
 
+	spanConf := zonepb.DefaultZoneConfig().AsSpanConfig()
 	repl := &Replica{
-		spanConf:    &roachpb.SpanConfig{},
+		spanConf:    &spanConf,
 		rangeDesc:   r.Desc,
 		replDesc:    &desc,
 		ReplicaLoad: ReplicaLoad{},
@@ -247,6 +300,36 @@ func (s *State) AddReplica(r *Range, node int) int {
 	return int(desc.ReplicaID)
 }
 
+// RemoveReplica removes the replica of a range from the first store on the
+// node. The leaseholder replica cannot be removed, its lease has to be
+// transferred first.
+func (s *State) RemoveReplica(r *Range, node int) {
+	store := s.Nodes[node].Stores[0]
+	repl, ok := store.Replicas[int(r.Desc.RangeID)]
+	if !ok {
+		panic(fmt.Sprintf("No replica for range %s exists on node %d, unable to remove it", r.Desc.RangeID, node))
+	}
+	if repl.leaseHolder {
+		panic(fmt.Sprintf("Replica for range %s on node %d is the leaseholder, unable to remove it", r.Desc.RangeID, node))
+	}
+	r.Desc.RemoveReplica(roachpb.NodeID(node), roachpb.StoreID(node))
+	delete(store.Replicas, int(r.Desc.RangeID))
+}
+
+// TransferLease moves the lease of a range to its replica on the first store
+// of the node.
+func (s *State) TransferLease(r *Range, node int) {
+	repl, ok := s.Nodes[node].Stores[0].Replicas[int(r.Desc.RangeID)]
+	if !ok {
+		panic(fmt.Sprintf("No replica for range %s exists on node %d, unable to transfer the lease to it", r.Desc.RangeID, node))
+	}
+	if r.Leaseholder != nil {
+		r.Leaseholder.leaseHolder = false
+	}
+	r.Leaseholder = repl
+	repl.leaseHolder = true
+}
+
 // StoreLoad represents the current load of the store.
 type StoreLoad struct {
 	WriteKeys  int64
@@ -279,19 +362,25 @@ func (s *Store) GetStoreLoad() StoreLoad {
 	return storeLoad
 }
 
-// ApplyAllocatorAction updates the state with allocator ops such as
-// moving/adding/removing replicas.
-func (s *State) ApplyAllocatorAction(
-	ctx context.Context, action allocatorimpl.AllocatorAction, priority float64,
-) {
-}
-
 // ApplyLoad updates the state replicas with the LoadEvent info. These events
 // are in the form of "key, read/write, size" and are incrementing counters such
 // as QPS for replicas. Note that this means we don't store which keys were
-// written and therefore reads never fail.
+// written and therefore reads never fail. Events replayed from a load trace
+// apply to the range with their range ID instead, see
+// ReplayWorkloadGenerator.
 func (s *State) ApplyLoad(ctx context.Context, le LoadEvent) {
-	r := s.Ranges.GetRange(fmt.Sprintf("%d", le.Key))
+	var r *Range
+	if le.rangeID != 0 {
+		r = s.Ranges.GetRangeByID(le.rangeID)
+		if r == nil {
+			// The load was recorded against a range that isn't part of the
+			// state, e.g. a range that was merged away since.
+			return
+		}
+	} else {
+		r = s.Ranges.GetRange(fmt.Sprintf("%d", le.Key))
+	}
+	r.Load.apply(le)
 
 	// Apply the load event to the leaseholder replica of the range this key is contained in.
 	//
@@ -302,29 +391,6 @@ func (s *State) ApplyLoad(ctx context.Context, le LoadEvent) {
 	r.Leaseholder.applyReplicaLoad(le)
 }
 
-func shouldRun(time.Time) bool {
-	return false
-}
-
-// RunAllocator runs the allocator code for some replicas as needed.
-func RunAllocator(
-	ctx context.Context,
-	allocator allocatorimpl.Allocator,
-	spanConf roachpb.SpanConfig,
-	desc *roachpb.RangeDescriptor,
-	tick time.Time,
-) (done bool, action allocatorimpl.AllocatorAction, priority float64) {
-	// TODO: we should pace the calls to ComputeAction. The replicate queue tries
-	// to call ComputeAction for all replicas at a steady pace, to complete a pass
-	// within 10 minutes. We should have similar logic here (using simulated
-	// time).
-	if !shouldRun(tick) {
-		return true, allocatorimpl.AllocatorNoop, 0
-	}
-	action, priority = allocator.ComputeAction(ctx, spanConf, desc)
-	return false, action, priority
-}
-
 // Simulator simulates an entire cluster, and runs the allocators of each store
 // in that cluster.
 type Simulator struct {
@@ -335,6 +401,12 @@ type Simulator struct {
 	// The simulator can run multiple workload Generators in parallel.
 	generators []WorkloadGenerator
 	state      *State
+
+	// settings are the cluster settings that the allocators run with. They may
+	// be changed before running the simulation, to evaluate the effect of
+	// allocator settings on a cluster.
+	settings *cluster.Settings
+	history  History
 }
 
 // NewSimulator constructs a valid Simulator.
@@ -347,9 +419,21 @@ func NewSimulator(
 		interval:   interval,
 		generators: wgs,
 		state:      initialState,
+		settings:   cluster.MakeTestingClusterSettings(),
 	}
 }
 
+// Settings returns the cluster settings used by the allocators.
+func (s *Simulator) Settings() *cluster.Settings {
+	return s.settings
+}
+
+// History returns the timeline of the allocation decisions made and of the
+// load of each store during the simulation.
+func (s *Simulator) History() *History {
+	return &s.history
+}
+
 // GetNextTickTime returns a simulated tick time, or an indication that the
 // simulation is done.
 func (s *Simulator) GetNextTickTime() (done bool, tick time.Time) {
@@ -364,16 +448,20 @@ func (s *Simulator) GetNextTickTime() (done bool, tick time.Time) {
 // executed by "ticks" - we run a full tick and then move to next one. In each
 // tick we first apply the state changes such as adding or removing Nodes, then
 // we apply the load changes such as updating the QPS for replicas, and last, we
-// run the actual allocator code. The input for the allocator is the state we
-// updated, and the operations recommended by the allocator (rebalances,
-// adding/removing replicas, etc.) are applied on a new state. This means that
-// the allocators view a stale state without the recent updates form other
-// allocators. Note that we are currently ignoring gossip delays, meaning all
-// allocators view the exact same state in each tick.
+// run the actual allocator code. The allocators view the stores through a
+// store pool that is updated over gossip once per tick, so they make their
+// decisions on the store descriptors as of the start of the tick, while the
+// decisions themselves (rebalances, adding/removing replicas, lease transfers,
+// etc.) are applied to the state immediately. Note that we are currently
+// ignoring gossip delays, meaning all allocators view the exact same state in
+// each tick.
 //
 // TODO: simulation run settings should be loaded from a config such as a yaml
 // file or a "datadriven" style file.
 func (s *Simulator) RunSim(ctx context.Context) {
+	sa := newSimAllocator(ctx, s.settings, s.state, s.curr)
+	defer sa.stop(ctx)
+
 	for {
 		done, tick := s.GetNextTickTime()
 		if done {
@@ -391,22 +479,8 @@ func (s *Simulator) RunSim(ctx context.Context) {
 		}
 
 		// Done with config and load updates, the state is ready for the allocators.
-		stateForAlloc := s.state
-
-		for _, node := range stateForAlloc.Nodes {
-			for _, store := range node.Stores {
-				for _, r := range store.Replicas {
-					// Run the real allocator code. Note that the input is from the
-					// "frozen" state which is not affected by rebalancing decisions.
-					done, action, priority := RunAllocator(ctx, store.allocator, *r.spanConf, r.rangeDesc, tick)
-					if done {
-						break
-					}
-
-					// The allocator ops are applied.
-					s.state.ApplyAllocatorAction(ctx, action, priority)
-				}
-			}
-		}
+		s.history.Stores = append(s.history.Stores, sa.updateStores(ctx, tick, s.interval)...)
+		s.history.Changes = append(s.history.Changes, sa.runReplicateQueue(ctx, tick)...)
+		s.history.Changes = append(s.history.Changes, sa.runStoreRebalancer(ctx, tick)...)
 	}
 }
@@ -12,6 +12,7 @@ package asim_test
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
@@ -39,6 +40,56 @@ func TestRunAllocatorSimulator(t *testing.T) {
 	sim.RunSim(ctx)
 }
 
+// TestSimulatorRebalancesLoad asserts that running the allocators against a
+// cluster whose load is concentrated on a store spreads that load across the
+// stores.
+func TestSimulatorRebalancesLoad(t *testing.T) {
+	ctx := context.Background()
+	cs := &asim.ClusterSnapshot{}
+	for storeID := roachpb.StoreID(1); storeID <= 5; storeID++ {
+		cs.Stores = append(cs.Stores, asim.StoreSnapshot{StoreID: storeID, NodeID: roachpb.NodeID(storeID)})
+	}
+	// All the ranges have their replicas on the first three stores, and their
+	// leases on the first store.
+	for rangeID := roachpb.RangeID(1); rangeID <= 10; rangeID++ {
+		cs.Ranges = append(cs.Ranges, asim.RangeSnapshot{
+			RangeID:     rangeID,
+			StartKey:    roachpb.RKey(fmt.Sprintf("%03d", rangeID)),
+			Voters:      []roachpb.StoreID{1, 2, 3},
+			Leaseholder: 1,
+			QPS:         100,
+		})
+	}
+	s, err := cs.State()
+	require.NoError(t, err)
+
+	start := time.Date(2022, 03, 21, 11, 0, 0, 0, time.UTC)
+	end := start.Add(10 * time.Minute)
+	interval := 10 * time.Second
+	wgs := []asim.WorkloadGenerator{asim.NewReplayWorkloadGenerator(start, cs.LoadTrace())}
+	sim := asim.NewSimulator(start, end, interval, wgs, s)
+	sim.RunSim(ctx)
+
+	history := sim.History()
+	var leaseTransfers, rebalances int
+	for _, change := range history.Changes {
+		switch change.Op {
+		case asim.OpTransferLease:
+			leaseTransfers++
+		case asim.OpRebalanceVoter:
+			rebalances++
+		}
+	}
+	require.Greater(t, leaseTransfers, 0, "%s", history)
+	require.Greater(t, rebalances, 0, "%s", history)
+
+	lb := history.LoadBalance()
+	first, last := lb[0], lb[len(lb)-1]
+	require.Equal(t, 1000.0, first.MaxQPS)
+	require.Less(t, last.MaxQPS, first.MaxQPS, "%s", history)
+	require.Greater(t, last.MinRangeCount, int32(0), "%s", history)
+}
+
 func TestRangeMap(t *testing.T) {
 	m := asim.NewRangeMap()
 
@@ -0,0 +1,191 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package asim
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+)
+
+// The components that make allocation decisions in the simulation.
+const (
+	ReplicateQueue  = "replicate queue"
+	StoreRebalancer = "store rebalancer"
+)
+
+// RebalanceOp is the kind of change made by an allocation decision.
+type RebalanceOp int
+
+const (
+	// OpAddVoter adds a voter to a range.
+	OpAddVoter RebalanceOp = iota
+	// OpRemoveVoter removes a voter from a range.
+	OpRemoveVoter
+	// OpRebalanceVoter moves a voter of a range from a store to another.
+	OpRebalanceVoter
+	// OpTransferLease moves the lease of a range from a store to another.
+	OpTransferLease
+)
+
+func (op RebalanceOp) String() string {
+	switch op {
+	case OpAddVoter:
+		return "add voter"
+	case OpRemoveVoter:
+		return "remove voter"
+	case OpRebalanceVoter:
+		return "rebalance voter"
+	case OpTransferLease:
+		return "transfer lease"
+	default:
+		return fmt.Sprintf("unknown op %d", int(op))
+	}
+}
+
+// RebalanceEvent is an allocation decision that was applied to the simulated
+// state.
+type RebalanceEvent struct {
+	Tick    time.Time
+	RangeID roachpb.RangeID
+	// Source is the component that made the decision, either ReplicateQueue or
+	// StoreRebalancer.
+	Source string
+	Op     RebalanceOp
+	// From and To are the stores the replica or lease is moved from and to. From
+	// is zero when adding a voter, To is zero when removing one.
+	From, To roachpb.StoreID
+	// Details is the allocator's explanation of the decision, if any.
+	Details string
+}
+
+func (e RebalanceEvent) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: r%d %s", e.Source, e.RangeID, e.Op)
+	if e.From != 0 {
+		fmt.Fprintf(&b, " from s%d", e.From)
+	}
+	if e.To != 0 {
+		fmt.Fprintf(&b, " to s%d", e.To)
+	}
+	return b.String()
+}
+
+// StoreSample is the load of a store at a tick, as gossiped to the allocators.
+type StoreSample struct {
+	Tick            time.Time
+	StoreID         roachpb.StoreID
+	QPS             float64
+	WritesPerSecond float64
+	RangeCount      int32
+	LeaseCount      int32
+	LogicalBytes    int64
+}
+
+// History is the timeline of a simulation: the allocation decisions and the
+// load of each store at every tick.
+type History struct {
+	Changes []RebalanceEvent
+	Stores  []StoreSample
+}
+
+// LoadBalance summarizes how balanced the load of the stores is at a tick.
+type LoadBalance struct {
+	Tick time.Time
+	// MeanQPS, MinQPS and MaxQPS are the mean, minimum and maximum QPS of the
+	// stores.
+	MeanQPS, MinQPS, MaxQPS float64
+	// QPSCoefficientOfVariation is the standard deviation of the QPS of the
+	// stores, relative to their mean. It is zero when the stores are perfectly
+	// balanced.
+	QPSCoefficientOfVariation float64
+	// MinRangeCount and MaxRangeCount are the minimum and maximum number of
+	// replicas on a store.
+	MinRangeCount, MaxRangeCount int32
+	// MinLeaseCount and MaxLeaseCount are the minimum and maximum number of
+	// leases on a store.
+	MinLeaseCount, MaxLeaseCount int32
+}
+
+// LoadBalance returns the load balance of the stores at every tick.
+func (h *History) LoadBalance() []LoadBalance {
+	var result []LoadBalance
+	for i := 0; i < len(h.Stores); {
+		tick := h.Stores[i].Tick
+		j := i
+		for j < len(h.Stores) && h.Stores[j].Tick.Equal(tick) {
+			j++
+		}
+		result = append(result, makeLoadBalance(tick, h.Stores[i:j]))
+		i = j
+	}
+	return result
+}
+
+func makeLoadBalance(tick time.Time, samples []StoreSample) LoadBalance {
+	lb := LoadBalance{
+		Tick:          tick,
+		MinQPS:        math.MaxFloat64,
+		MinRangeCount: math.MaxInt32,
+		MinLeaseCount: math.MaxInt32,
+	}
+	for _, s := range samples {
+		lb.MeanQPS += s.QPS
+		lb.MinQPS = math.Min(lb.MinQPS, s.QPS)
+		lb.MaxQPS = math.Max(lb.MaxQPS, s.QPS)
+		if s.RangeCount < lb.MinRangeCount {
+			lb.MinRangeCount = s.RangeCount
+		}
+		if s.RangeCount > lb.MaxRangeCount {
+			lb.MaxRangeCount = s.RangeCount
+		}
+		if s.LeaseCount < lb.MinLeaseCount {
+			lb.MinLeaseCount = s.LeaseCount
+		}
+		if s.LeaseCount > lb.MaxLeaseCount {
+			lb.MaxLeaseCount = s.LeaseCount
+		}
+	}
+	lb.MeanQPS /= float64(len(samples))
+	if lb.MeanQPS > 0 {
+		var variance float64
+		for _, s := range samples {
+			variance += (s.QPS - lb.MeanQPS) * (s.QPS - lb.MeanQPS)
+		}
+		variance /= float64(len(samples))
+		lb.QPSCoefficientOfVariation = math.Sqrt(variance) / lb.MeanQPS
+	}
+	return lb
+}
+
+// String returns the timeline of the simulation, listing at every tick the
+// load balance of the stores as seen by the allocators, followed by the
+// allocation decisions they made.
+func (h *History) String() string {
+	var b strings.Builder
+	changes := h.Changes
+	for _, lb := range h.LoadBalance() {
+		fmt.Fprintf(&b, "%s\n", lb.Tick.Format(time.RFC3339))
+		fmt.Fprintf(&b,
+			"  qps mean=%.2f min=%.2f max=%.2f cv=%.2f ranges min=%d max=%d leases min=%d max=%d\n",
+			lb.MeanQPS, lb.MinQPS, lb.MaxQPS, lb.QPSCoefficientOfVariation,
+			lb.MinRangeCount, lb.MaxRangeCount, lb.MinLeaseCount, lb.MaxLeaseCount,
+		)
+		for len(changes) > 0 && !changes[0].Tick.After(lb.Tick) {
+			fmt.Fprintf(&b, "  %s\n", changes[0])
+			changes = changes[1:]
+		}
+	}
+	return b.String()
+}
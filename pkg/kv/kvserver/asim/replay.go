@@ -0,0 +1,197 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package asim
+
+import (
+	"encoding/csv"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/errors"
+)
+
+// LoadSample is the load of a range, from Offset since the start of the trace
+// until the next sample of the range.
+type LoadSample struct {
+	Offset              time.Duration
+	RangeID             roachpb.RangeID
+	QPS                 float64
+	WritesPerSecond     float64
+	WriteBytesPerSecond float64
+}
+
+// LoadTrace is a time series of the load of ranges, e.g. recorded from a
+// cluster.
+type LoadTrace []LoadSample
+
+// loadTraceHeader is the header of a load trace in CSV format.
+var loadTraceHeader = []string{
+	"offset_seconds", "range_id", "qps", "writes_per_second", "write_bytes_per_second",
+}
+
+// LoadTraceFromCSV reads a load trace in CSV format. The first line is the
+// header, followed by a line per sample:
+//
+//   offset_seconds,range_id,qps,writes_per_second,write_bytes_per_second
+//   0,1,100,10,1024
+//   60,1,200,20,2048
+//
+func LoadTraceFromCSV(r io.Reader) (LoadTrace, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = len(loadTraceHeader)
+	header, err := cr.Read()
+	if err != nil {
+		return nil, errors.Wrap(err, "reading load trace header")
+	}
+	for i := range header {
+		if header[i] != loadTraceHeader[i] {
+			return nil, errors.Errorf("unexpected load trace header %v, expected %v", header, loadTraceHeader)
+		}
+	}
+
+	var trace LoadTrace
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "reading load trace")
+		}
+		var values [5]float64
+		for i, field := range record {
+			if values[i], err = strconv.ParseFloat(field, 64); err != nil {
+				return nil, errors.Wrapf(err, "parsing %s in load trace", loadTraceHeader[i])
+			}
+		}
+		trace = append(trace, LoadSample{
+			Offset:              time.Duration(values[0] * float64(time.Second)),
+			RangeID:             roachpb.RangeID(values[1]),
+			QPS:                 values[2],
+			WritesPerSecond:     values[3],
+			WriteBytesPerSecond: values[4],
+		})
+	}
+	return trace, nil
+}
+
+// ReplayWorkloadGenerator replays a load trace. The load of each range is
+// constant between two samples of the range, and is applied to the range
+// regardless of its keys.
+type ReplayWorkloadGenerator struct {
+	start   time.Time
+	lastRun time.Time
+	trace   LoadTrace
+	// next is the index of the first sample of the trace that is not applied
+	// yet.
+	next int
+	// rates is the current load of each range.
+	rates map[roachpb.RangeID]LoadSample
+	// pending is the load of each range that was not turned into load events
+	// yet, because it amounts to less than a key access.
+	pending  map[roachpb.RangeID]*pendingLoad
+	opBuffer []LoadEvent
+}
+
+type pendingLoad struct {
+	reads, writes, writeBytes float64
+}
+
+// NewReplayWorkloadGenerator returns a generator replaying the load trace
+// from start.
+func NewReplayWorkloadGenerator(start time.Time, trace LoadTrace) *ReplayWorkloadGenerator {
+	sorted := make(LoadTrace, len(trace))
+	copy(sorted, trace)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Offset < sorted[j].Offset
+	})
+	return &ReplayWorkloadGenerator{
+		start:   start,
+		lastRun: start,
+		trace:   sorted,
+		rates:   make(map[roachpb.RangeID]LoadSample),
+		pending: make(map[roachpb.RangeID]*pendingLoad),
+	}
+}
+
+// GetNext is part of the WorkloadGenerator interface.
+func (g *ReplayWorkloadGenerator) GetNext(maxTime time.Time) (done bool, event LoadEvent) {
+	if len(g.opBuffer) == 0 {
+		g.updateBuffer(maxTime)
+	}
+	if len(g.opBuffer) == 0 {
+		return true, LoadEvent{}
+	}
+	event = g.opBuffer[0]
+	g.opBuffer = g.opBuffer[1:]
+	return false, event
+}
+
+// updateBuffer accumulates the load of each range between the last run and
+// maxTime, and fills the operation buffer with a read and a write event per
+// range aggregating that load.
+func (g *ReplayWorkloadGenerator) updateBuffer(maxTime time.Time) {
+	for g.lastRun.Before(maxTime) {
+		for g.next < len(g.trace) && !g.start.Add(g.trace[g.next].Offset).After(g.lastRun) {
+			sample := g.trace[g.next]
+			g.rates[sample.RangeID] = sample
+			g.next++
+		}
+		end := maxTime
+		if g.next < len(g.trace) {
+			if sampleTime := g.start.Add(g.trace[g.next].Offset); sampleTime.Before(end) {
+				end = sampleTime
+			}
+		}
+		seconds := end.Sub(g.lastRun).Seconds()
+		for rangeID, rate := range g.rates {
+			pending, ok := g.pending[rangeID]
+			if !ok {
+				pending = &pendingLoad{}
+				g.pending[rangeID] = pending
+			}
+			// Each write is a request, the remaining requests are reads.
+			pending.reads += math.Max(rate.QPS-rate.WritesPerSecond, 0) * seconds
+			pending.writes += rate.WritesPerSecond * seconds
+			pending.writeBytes += rate.WriteBytesPerSecond * seconds
+		}
+		g.lastRun = end
+	}
+
+	rangeIDs := make([]roachpb.RangeID, 0, len(g.pending))
+	for rangeID := range g.pending {
+		rangeIDs = append(rangeIDs, rangeID)
+	}
+	sort.Slice(rangeIDs, func(i, j int) bool {
+		return rangeIDs[i] < rangeIDs[j]
+	})
+	for _, rangeID := range rangeIDs {
+		pending := g.pending[rangeID]
+		if reads := math.Floor(pending.reads); reads >= 1 {
+			g.opBuffer = append(g.opBuffer, LoadEvent{rangeID: rangeID, count: int64(reads)})
+			pending.reads -= reads
+		}
+		if writes := math.Floor(pending.writes); writes >= 1 {
+			g.opBuffer = append(g.opBuffer, LoadEvent{
+				isWrite: true,
+				rangeID: rangeID,
+				count:   int64(writes),
+				size:    int64(pending.writeBytes),
+			})
+			pending.writes -= writes
+			pending.writeBytes -= math.Floor(pending.writeBytes)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package asim_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadTraceFromCSV(t *testing.T) {
+	trace, err := asim.LoadTraceFromCSV(strings.NewReader(
+		`offset_seconds,range_id,qps,writes_per_second,write_bytes_per_second
+0,1,100,10,1024
+0.5,2,1.5,0,0
+`))
+	require.NoError(t, err)
+	require.Equal(t, asim.LoadTrace{
+		{Offset: 0, RangeID: 1, QPS: 100, WritesPerSecond: 10, WriteBytesPerSecond: 1024},
+		{Offset: 500 * time.Millisecond, RangeID: 2, QPS: 1.5},
+	}, trace)
+
+	_, err = asim.LoadTraceFromCSV(strings.NewReader("offset,range,qps,wps,wbps\n"))
+	require.Error(t, err)
+	_, err = asim.LoadTraceFromCSV(strings.NewReader(
+		"offset_seconds,range_id,qps,writes_per_second,write_bytes_per_second\n0,1,a,0,0\n"))
+	require.Error(t, err)
+}
+
+// TestReplayWorkloadGenerator asserts that replaying a trace applies the load
+// of each sample to its range, until the next sample of the range.
+func TestReplayWorkloadGenerator(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2022, 03, 21, 11, 0, 0, 0, time.UTC)
+
+	s := asim.NewState()
+	s.Ranges = asim.NewRangeMap()
+	n1 := s.AddNode()
+	r1 := s.Ranges.AddRangeWithID("a", 1)
+	r2 := s.Ranges.AddRangeWithID("b", 2)
+	s.AddReplica(r1, n1)
+	s.AddReplica(r2, n1)
+
+	trace := asim.LoadTrace{
+		{Offset: 0, RangeID: 1, QPS: 10, WritesPerSecond: 2, WriteBytesPerSecond: 100},
+		{Offset: 0, RangeID: 2, QPS: 0.5},
+		{Offset: time.Minute, RangeID: 1, QPS: 1},
+		// The load of ranges missing from the state is ignored.
+		{Offset: time.Minute, RangeID: 3, QPS: 1000},
+	}
+	g := asim.NewReplayWorkloadGenerator(start, trace)
+	end := start.Add(2 * time.Minute)
+	for tick := start.Add(10 * time.Second); !tick.After(end); tick = tick.Add(10 * time.Second) {
+		for {
+			done, event := g.GetNext(tick)
+			if done {
+				break
+			}
+			s.ApplyLoad(ctx, event)
+		}
+	}
+
+	// r1 serves 8 reads and 2 writes per second for a minute, then a read per
+	// second for a minute.
+	require.Equal(t, asim.ReplicaLoad{
+		ReadKeys:   8*60 + 60,
+		WriteKeys:  2 * 60,
+		WriteBytes: 100 * 60,
+	}, r1.Load)
+	require.Equal(t, r1.Load, r1.Leaseholder.ReplicaLoad)
+	// r2 serves a read every other second.
+	require.Equal(t, asim.ReplicaLoad{ReadKeys: 60}, r2.Load)
+}
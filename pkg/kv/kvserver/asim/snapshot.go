@@ -0,0 +1,265 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package asim
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/server/serverpb"
+	"github.com/cockroachdb/cockroach/pkg/server/status/statuspb"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/errors/oserror"
+)
+
+// ClusterSnapshot describes the stores and ranges of a cluster, along with
+// the load of each range, at some point in time. It is used to simulate an
+// existing cluster, see State().
+type ClusterSnapshot struct {
+	Stores []StoreSnapshot `json:"stores"`
+	Ranges []RangeSnapshot `json:"ranges"`
+}
+
+// StoreSnapshot describes a store of the cluster.
+type StoreSnapshot struct {
+	StoreID  roachpb.StoreID  `json:"store_id"`
+	NodeID   roachpb.NodeID   `json:"node_id"`
+	Locality roachpb.Locality `json:"locality"`
+	// CapacityBytes is the disk capacity of the store.
+	CapacityBytes int64 `json:"capacity_bytes"`
+}
+
+// RangeSnapshot describes a range of the cluster and its load.
+type RangeSnapshot struct {
+	RangeID  roachpb.RangeID `json:"range_id"`
+	StartKey roachpb.RKey    `json:"start_key"`
+	// Voters are the stores holding a voting replica of the range.
+	Voters []roachpb.StoreID `json:"voters"`
+	// Leaseholder is the store holding the lease of the range. If it is zero,
+	// the lease is held by the first voter.
+	Leaseholder         roachpb.StoreID `json:"leaseholder"`
+	LogicalBytes        int64           `json:"logical_bytes"`
+	QPS                 float64         `json:"qps"`
+	WritesPerSecond     float64         `json:"writes_per_second"`
+	WriteBytesPerSecond float64         `json:"write_bytes_per_second"`
+}
+
+// LoadSnapshot reads a snapshot in the JSON format written by
+// ClusterSnapshot.Write.
+func LoadSnapshot(r io.Reader) (*ClusterSnapshot, error) {
+	var cs ClusterSnapshot
+	if err := json.NewDecoder(r).Decode(&cs); err != nil {
+		return nil, errors.Wrap(err, "decoding cluster snapshot")
+	}
+	return &cs, nil
+}
+
+// Write writes the snapshot in JSON format.
+func (cs *ClusterSnapshot) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(cs)
+}
+
+// LoadSnapshotFromDebugZip builds a snapshot from the node statuses and the
+// range reports in an extracted `cockroach debug zip`, rooted at dir. Each
+// range is reported by all of its replicas: the snapshot uses the most recent
+// descriptor among the reports, and the load reported by the leaseholder.
+func LoadSnapshotFromDebugZip(dir string) (*ClusterSnapshot, error) {
+	nodesDir := filepath.Join(dir, "debug", "nodes")
+	entries, err := os.ReadDir(nodesDir)
+	if err != nil {
+		return nil, errors.Wrap(err, "listing nodes in debug zip")
+	}
+
+	var cs ClusterSnapshot
+	type rangeReport struct {
+		generation    roachpb.RangeGeneration
+		byLeaseholder bool
+	}
+	reports := make(map[roachpb.RangeID]rangeReport)
+	ranges := make(map[roachpb.RangeID]*RangeSnapshot)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		nodeDir := filepath.Join(nodesDir, entry.Name())
+
+		var status statuspb.NodeStatus
+		if err := readJSONFile(filepath.Join(nodeDir, "status.json"), &status); err != nil {
+			if oserror.IsNotExist(err) {
+				// The node status could not be retrieved when the debug zip was
+				// created.
+				continue
+			}
+			return nil, err
+		}
+		if status.Desc.NodeID == 0 {
+			// This is not the status of a KV node.
+			continue
+		}
+		for _, ss := range status.StoreStatuses {
+			cs.Stores = append(cs.Stores, StoreSnapshot{
+				StoreID:       ss.Desc.StoreID,
+				NodeID:        status.Desc.NodeID,
+				Locality:      status.Desc.Locality,
+				CapacityBytes: ss.Desc.Capacity.Capacity,
+			})
+		}
+
+		rangeFiles, err := filepath.Glob(filepath.Join(nodeDir, "ranges", "*.json"))
+		if err != nil {
+			return nil, err
+		}
+		for _, rangeFile := range rangeFiles {
+			var info serverpb.RangeInfo
+			if err := readJSONFile(rangeFile, &info); err != nil {
+				return nil, err
+			}
+			desc := info.State.Desc
+			if desc == nil {
+				continue
+			}
+			report := rangeReport{
+				generation: desc.Generation,
+				byLeaseholder: info.State.Lease != nil &&
+					info.State.Lease.Replica.StoreID == info.SourceStoreID,
+			}
+			if prev, ok := reports[desc.RangeID]; ok {
+				if report.generation < prev.generation ||
+					(report.generation == prev.generation && !report.byLeaseholder) {
+					continue
+				}
+			}
+			reports[desc.RangeID] = report
+
+			rs := &RangeSnapshot{
+				RangeID:             desc.RangeID,
+				StartKey:            desc.StartKey,
+				QPS:                 info.Stats.QueriesPerSecond,
+				WritesPerSecond:     info.Stats.WritesPerSecond,
+				WriteBytesPerSecond: info.Stats.WriteBytesPerSecond,
+			}
+			for _, repl := range desc.Replicas().VoterDescriptors() {
+				rs.Voters = append(rs.Voters, repl.StoreID)
+			}
+			if info.State.Lease != nil {
+				rs.Leaseholder = info.State.Lease.Replica.StoreID
+			}
+			if info.State.Stats != nil {
+				rs.LogicalBytes = info.State.Stats.Total()
+			}
+			ranges[desc.RangeID] = rs
+		}
+	}
+
+	sort.Slice(cs.Stores, func(i, j int) bool {
+		return cs.Stores[i].StoreID < cs.Stores[j].StoreID
+	})
+	for _, rs := range ranges {
+		cs.Ranges = append(cs.Ranges, *rs)
+	}
+	sort.Slice(cs.Ranges, func(i, j int) bool {
+		return cs.Ranges[i].RangeID < cs.Ranges[j].RangeID
+	})
+	return &cs, nil
+}
+
+func readJSONFile(path string, v interface{}) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return errors.Wrapf(err, "decoding %s", path)
+	}
+	return nil
+}
+
+// State constructs a simulator state with the stores and ranges of the
+// snapshot.
+//
+// The simulator models nodes with a single store, so every store of the
+// snapshot is simulated as a node whose node ID is the store ID, with the
+// locality of the node the store belongs to.
+func (cs *ClusterSnapshot) State() (*State, error) {
+	s := NewState()
+	s.Ranges = NewRangeMap()
+
+	for _, store := range cs.Stores {
+		if _, ok := s.Nodes[int(store.StoreID)]; ok {
+			return nil, errors.Errorf("duplicate store s%d", store.StoreID)
+		}
+		nodeID := s.AddNodeWithLocality(int(store.StoreID), store.Locality)
+		if store.CapacityBytes > 0 {
+			s.Nodes[nodeID].Stores[0].capacityBytes = store.CapacityBytes
+		}
+	}
+
+	ranges := make([]RangeSnapshot, len(cs.Ranges))
+	copy(ranges, cs.Ranges)
+	sort.Slice(ranges, func(i, j int) bool {
+		return bytes.Compare(ranges[i].StartKey, ranges[j].StartKey) < 0
+	})
+	for i, rs := range ranges {
+		if s.Ranges.GetRangeByID(rs.RangeID) != nil {
+			return nil, errors.Errorf("duplicate range r%d", rs.RangeID)
+		}
+		if i > 0 && ranges[i-1].StartKey.Equal(rs.StartKey) {
+			return nil, errors.Errorf(
+				"r%d and r%d have the same start key %s", ranges[i-1].RangeID, rs.RangeID, rs.StartKey,
+			)
+		}
+		if len(rs.Voters) == 0 {
+			return nil, errors.Errorf("r%d has no voters", rs.RangeID)
+		}
+		r := s.Ranges.AddRangeWithID(string(rs.StartKey), rs.RangeID)
+		r.LogicalBytes = rs.LogicalBytes
+		for _, storeID := range rs.Voters {
+			if _, ok := s.Nodes[int(storeID)]; !ok {
+				return nil, errors.Errorf("r%d has a replica on unknown store s%d", rs.RangeID, storeID)
+			}
+			if _, ok := r.Desc.GetReplicaDescriptor(storeID); ok {
+				return nil, errors.Errorf("r%d has more than one replica on s%d", rs.RangeID, storeID)
+			}
+			s.AddReplica(r, int(storeID))
+		}
+		if rs.Leaseholder != 0 {
+			if _, ok := r.Desc.GetReplicaDescriptor(rs.Leaseholder); !ok {
+				return nil, errors.Errorf(
+					"r%d leaseholder s%d is not one of its voters %v", rs.RangeID, rs.Leaseholder, rs.Voters,
+				)
+			}
+			s.TransferLease(r, int(rs.Leaseholder))
+		}
+	}
+	return s, nil
+}
+
+// LoadTrace returns a trace of the load of the ranges of the snapshot, which
+// replays that load at a constant rate.
+func (cs *ClusterSnapshot) LoadTrace() LoadTrace {
+	trace := make(LoadTrace, 0, len(cs.Ranges))
+	for _, rs := range cs.Ranges {
+		trace = append(trace, LoadSample{
+			RangeID:             rs.RangeID,
+			QPS:                 rs.QPS,
+			WritesPerSecond:     rs.WritesPerSecond,
+			WriteBytesPerSecond: rs.WriteBytesPerSecond,
+		})
+	}
+	return trace
+}
@@ -0,0 +1,144 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package asim_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/server/serverpb"
+	"github.com/cockroachdb/cockroach/pkg/server/status/statuspb"
+	"github.com/cockroachdb/cockroach/pkg/storage/enginepb"
+	"github.com/stretchr/testify/require"
+)
+
+func testSnapshot() *asim.ClusterSnapshot {
+	locality := func(zone string) roachpb.Locality {
+		return roachpb.Locality{Tiers: []roachpb.Tier{{Key: "zone", Value: zone}}}
+	}
+	return &asim.ClusterSnapshot{
+		Stores: []asim.StoreSnapshot{
+			{StoreID: 1, NodeID: 1, Locality: locality("a"), CapacityBytes: 1 << 30},
+			{StoreID: 2, NodeID: 2, Locality: locality("b"), CapacityBytes: 1 << 30},
+			{StoreID: 3, NodeID: 3, Locality: locality("c"), CapacityBytes: 1 << 30},
+		},
+		Ranges: []asim.RangeSnapshot{
+			{RangeID: 2, StartKey: roachpb.RKey("m"), Voters: []roachpb.StoreID{1, 2, 3}, Leaseholder: 3, QPS: 10},
+			{RangeID: 1, StartKey: roachpb.RKeyMin, Voters: []roachpb.StoreID{1, 2, 3}, Leaseholder: 2, QPS: 100},
+		},
+	}
+}
+
+func TestSnapshotState(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, testSnapshot().Write(&buf))
+	cs, err := asim.LoadSnapshot(&buf)
+	require.NoError(t, err)
+	require.Equal(t, testSnapshot(), cs)
+
+	s, err := cs.State()
+	require.NoError(t, err)
+	require.Len(t, s.Nodes, 3)
+	for _, n := range s.Nodes {
+		require.Len(t, n.Stores[0].Replicas, 2)
+	}
+
+	r1 := s.Ranges.GetRangeByID(1)
+	r2 := s.Ranges.GetRangeByID(2)
+	require.Equal(t, roachpb.RKey("m"), r1.Desc.EndKey)
+	require.Equal(t, roachpb.RKeyMax, r2.Desc.EndKey)
+	require.Equal(t, r2, s.Ranges.GetRange("z"))
+	require.Len(t, r1.Desc.Replicas().VoterDescriptors(), 3)
+
+	// The leases are on the stores of the snapshot.
+	require.Equal(t, int64(1), s.Nodes[2].Stores[0].GetStoreLoad().LeaseCount)
+	require.Equal(t, int64(1), s.Nodes[3].Stores[0].GetStoreLoad().LeaseCount)
+	require.Equal(t, int64(0), s.Nodes[1].Stores[0].GetStoreLoad().LeaseCount)
+
+	// A snapshot with a replica on an unknown store is rejected.
+	cs.Ranges[0].Voters = append(cs.Ranges[0].Voters, 4)
+	_, err = cs.State()
+	require.Error(t, err)
+}
+
+func TestLoadSnapshotFromDebugZip(t *testing.T) {
+	dir := t.TempDir()
+	writeJSON := func(path string, v interface{}) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		b, err := json.MarshalIndent(v, "", "  ")
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(path, b, 0644))
+	}
+
+	desc := roachpb.RangeDescriptor{
+		RangeID:    7,
+		StartKey:   roachpb.RKey("a"),
+		EndKey:     roachpb.RKey("b"),
+		Generation: 2,
+	}
+	desc.AddReplica(1, 1, roachpb.VOTER_FULL)
+	desc.AddReplica(2, 2, roachpb.VOTER_FULL)
+	desc.AddReplica(3, 3, roachpb.NON_VOTER)
+	// The descriptor in an earlier generation, before the range was
+	// rebalanced from s4 to s2.
+	oldDesc := desc
+	oldDesc.Generation = 1
+	oldDesc.InternalReplicas = []roachpb.ReplicaDescriptor{
+		{NodeID: 1, StoreID: 1, ReplicaID: 1},
+		{NodeID: 4, StoreID: 4, ReplicaID: 4},
+	}
+
+	for nodeID := 1; nodeID <= 4; nodeID++ {
+		nodeDir := filepath.Join(dir, "debug", "nodes", fmt.Sprintf("%d", nodeID))
+		var status statuspb.NodeStatus
+		status.Desc.NodeID = roachpb.NodeID(nodeID)
+		status.StoreStatuses = []statuspb.StoreStatus{{Desc: roachpb.StoreDescriptor{
+			StoreID:  roachpb.StoreID(nodeID),
+			Capacity: roachpb.StoreCapacity{Capacity: 1 << 30},
+		}}}
+		writeJSON(filepath.Join(nodeDir, "status.json"), status)
+
+		var info serverpb.RangeInfo
+		info.SourceNodeID = roachpb.NodeID(nodeID)
+		info.SourceStoreID = roachpb.StoreID(nodeID)
+		info.State.Desc = &desc
+		if nodeID == 4 {
+			info.State.Desc = &oldDesc
+		}
+		info.State.Lease = &roachpb.Lease{Replica: desc.InternalReplicas[0]}
+		info.State.Stats = &enginepb.MVCCStats{KeyBytes: 10, ValBytes: 90}
+		if nodeID == 1 {
+			// Only the leaseholder reports the load of the range.
+			info.Stats.QueriesPerSecond = 50
+			info.Stats.WritesPerSecond = 5
+		}
+		writeJSON(filepath.Join(nodeDir, "ranges", "7.json"), info)
+	}
+
+	cs, err := asim.LoadSnapshotFromDebugZip(dir)
+	require.NoError(t, err)
+	require.Len(t, cs.Stores, 4)
+	require.Equal(t, []asim.RangeSnapshot{{
+		RangeID:         7,
+		StartKey:        roachpb.RKey("a"),
+		Voters:          []roachpb.StoreID{1, 2},
+		Leaseholder:     1,
+		LogicalBytes:    100,
+		QPS:             50,
+		WritesPerSecond: 5,
+	}}, cs.Ranges)
+}
@@ -15,6 +15,7 @@ import (
 	"math/rand"
 	"time"
 
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
 )
 
@@ -23,6 +24,20 @@ type LoadEvent struct {
 	isWrite bool
 	size    int64
 	Key     int64
+	// rangeID, when set, is the range the load applies to, instead of the
+	// range containing Key. It is used to replay load recorded per range.
+	rangeID roachpb.RangeID
+	// count is the number of key accesses the event represents, with size
+	// being their total size. A zero count represents a single key access.
+	count int64
+}
+
+// keys returns the number of key accesses the event represents.
+func (le LoadEvent) keys() int64 {
+	if le.count == 0 {
+		return 1
+	}
+	return le.count
 }
 
 // WorkloadGenerator generates workload where each op contains: key,
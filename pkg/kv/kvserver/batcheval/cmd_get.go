@@ -101,7 +101,12 @@ func Get(
 
 	var res result.Result
 	if args.KeyLocking != lock.None && h.Txn != nil && val != nil {
-		acq := roachpb.MakeLockAcquisition(h.Txn, args.Key, lock.Unreplicated)
+		str, dur := keyLockingForVersion(
+			ctx, cArgs.EvalCtx.ClusterSettings(), args.KeyLocking, args.KeyLockingDurability)
+		acq, err := acquireLockOnKey(ctx, reader, h.Txn, str, dur, args.Key)
+		if err != nil {
+			return result.Result{}, err
+		}
 		res.Local.AcquiredLocks = []roachpb.LockAcquisition{acq}
 	}
 	res.Local.EncounteredIntents = intents
@@ -103,7 +103,8 @@ func computeMinIntentTimestamp(
 ) (hlc.Timestamp, []roachpb.Intent, error) {
 	ltStart, _ := keys.LockTableSingleKey(span.Key, nil)
 	ltEnd, _ := keys.LockTableSingleKey(span.EndKey, nil)
-	iter := reader.NewEngineIterator(storage.IterOptions{LowerBound: ltStart, UpperBound: ltEnd})
+	iter := storage.NewIntentLockTableIterator(
+		reader.NewEngineIterator(storage.IterOptions{LowerBound: ltStart, UpperBound: ltEnd}))
 	defer iter.Close()
 
 	var meta enginepb.MVCCMetadata
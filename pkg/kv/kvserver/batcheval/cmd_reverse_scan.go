@@ -96,7 +96,9 @@ func ReverseScan(
 	}
 
 	if args.KeyLocking != lock.None && h.Txn != nil {
-		err = acquireUnreplicatedLocksOnKeys(&res, h.Txn, args.ScanFormat, &scanRes)
+		str, dur := keyLockingForVersion(
+			ctx, cArgs.EvalCtx.ClusterSettings(), args.KeyLocking, args.KeyLockingDurability)
+		err = acquireLocksOnKeys(ctx, reader, &res, h.Txn, str, dur, args.ScanFormat, &scanRes)
 		if err != nil {
 			return result.Result{}, err
 		}
@@ -13,9 +13,11 @@ package batcheval
 import (
 	"context"
 
+	"github.com/cockroachdb/cockroach/pkg/clusterversion"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/batcheval/result"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/concurrency/lock"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/storage"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/errors"
@@ -103,11 +105,35 @@ func readProvisionalVal(
 
 }
 
-// acquireUnreplicatedLocksOnKeys adds an unreplicated lock acquisition by the
-// transaction to the provided result.Result for each key in the scan result.
-func acquireUnreplicatedLocksOnKeys(
+// keyLockingForVersion returns the strength and durability of the locks
+// acquired by a locking read with the given key locking. Until the SharedLocks
+// cluster version is active, nodes may not support Shared locks, so they are
+// acquired as unreplicated Exclusive locks instead.
+func keyLockingForVersion(
+	ctx context.Context, st *cluster.Settings, str lock.Strength, dur lock.Durability,
+) (lock.Strength, lock.Durability) {
+	if str == lock.Shared && !st.Version.IsActive(ctx, clusterversion.SharedLocks) {
+		return lock.Exclusive, lock.Unreplicated
+	}
+	return str, dur
+}
+
+// acquireLocksOnKeys adds a lock acquisition of the given strength and
+// durability by the transaction to the provided result.Result for each key in
+// the scan result.
+//
+// Unreplicated locks are only tracked by the in-memory lock table. Replicated
+// locks are additionally written to the lock table keyspace, which is only
+// possible when the request is evaluated as a write (see
+// roachpb.GetRequest.KeyLockingDurability). Only Shared locks can be acquired
+// with Replicated durability: Replicated Exclusive locks are intents.
+func acquireLocksOnKeys(
+	ctx context.Context,
+	reader storage.Reader,
 	res *result.Result,
 	txn *roachpb.Transaction,
+	str lock.Strength,
+	dur lock.Durability,
 	scanFmt roachpb.ScanFormat,
 	scanRes *storage.MVCCScanResult,
 ) error {
@@ -116,13 +142,21 @@ func acquireUnreplicatedLocksOnKeys(
 	case roachpb.BATCH_RESPONSE:
 		var i int
 		return storage.MVCCScanDecodeKeyValues(scanRes.KVData, func(key storage.MVCCKey, _ []byte) error {
-			res.Local.AcquiredLocks[i] = roachpb.MakeLockAcquisition(txn, copyKey(key.Key), lock.Unreplicated)
+			acq, err := acquireLockOnKey(ctx, reader, txn, str, dur, copyKey(key.Key))
+			if err != nil {
+				return err
+			}
+			res.Local.AcquiredLocks[i] = acq
 			i++
 			return nil
 		})
 	case roachpb.KEY_VALUES:
 		for i, row := range scanRes.KVs {
-			res.Local.AcquiredLocks[i] = roachpb.MakeLockAcquisition(txn, copyKey(row.Key), lock.Unreplicated)
+			acq, err := acquireLockOnKey(ctx, reader, txn, str, dur, copyKey(row.Key))
+			if err != nil {
+				return err
+			}
+			res.Local.AcquiredLocks[i] = acq
 		}
 		return nil
 	default:
@@ -130,8 +164,43 @@ func acquireUnreplicatedLocksOnKeys(
 	}
 }
 
+// acquireLockOnKey acquires a lock of the given strength and durability on the
+// key on behalf of the transaction, and returns the corresponding lock
+// acquisition. See acquireLocksOnKeys.
+func acquireLockOnKey(
+	ctx context.Context,
+	reader storage.Reader,
+	txn *roachpb.Transaction,
+	str lock.Strength,
+	dur lock.Durability,
+	key roachpb.Key,
+) (roachpb.LockAcquisition, error) {
+	if dur == lock.Replicated {
+		if str != lock.Shared {
+			return roachpb.LockAcquisition{}, errors.AssertionFailedf(
+				"cannot acquire replicated lock with strength %s", str)
+		}
+		rw, ok := reader.(storage.ReadWriter)
+		if !ok {
+			return roachpb.LockAcquisition{}, errors.AssertionFailedf(
+				"replicated lock acquisition on key %s must be evaluated as a write", key)
+		}
+		if err := storage.MVCCAcquireSharedLock(ctx, rw, txn, key); err != nil {
+			return roachpb.LockAcquisition{}, err
+		}
+	} else if str == lock.Exclusive {
+		// Unreplicated exclusive locks are not written to the lock table
+		// keyspace, but they must still conflict with replicated shared locks
+		// held by other transactions.
+		if err := storage.MVCCCheckForSharedLocks(reader, key, txn); err != nil {
+			return roachpb.LockAcquisition{}, err
+		}
+	}
+	return roachpb.MakeLockAcquisition(txn, key, str, dur), nil
+}
+
 // copyKey copies the provided roachpb.Key into a new byte slice, returning the
-// copy. It is used in acquireUnreplicatedLocksOnKeys for two reasons:
+// copy. It is used in acquireLocksOnKeys for two reasons:
 // 1. the keys in an MVCCScanResult, regardless of the scan format used, point
 //    to a small number of large, contiguous byte slices. These "MVCCScan
 //    batches" contain keys and their associated values in the same backing
@@ -14,6 +14,8 @@ import (
 	"context"
 	"testing"
 
+	"github.com/cockroachdb/cockroach/pkg/clusterversion"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/concurrency/lock"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/storage"
@@ -167,3 +169,27 @@ func TestCollectIntentsUsesSameIterator(t *testing.T) {
 		})
 	}
 }
+
+// TestKeyLockingForVersion verifies that Shared locks are only acquired once
+// the SharedLocks cluster version is active.
+func TestKeyLockingForVersion(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	v := clusterversion.ByKey(clusterversion.SharedLocks - 1)
+	oldSt := cluster.MakeTestingClusterSettingsWithVersions(v, v, true)
+	newSt := cluster.MakeTestingClusterSettings()
+
+	str, dur := keyLockingForVersion(ctx, oldSt, lock.Shared, lock.Replicated)
+	require.Equal(t, lock.Exclusive, str)
+	require.Equal(t, lock.Unreplicated, dur)
+
+	str, dur = keyLockingForVersion(ctx, oldSt, lock.Exclusive, lock.Unreplicated)
+	require.Equal(t, lock.Exclusive, str)
+	require.Equal(t, lock.Unreplicated, dur)
+
+	str, dur = keyLockingForVersion(ctx, newSt, lock.Shared, lock.Replicated)
+	require.Equal(t, lock.Shared, str)
+	require.Equal(t, lock.Replicated, dur)
+}
@@ -654,14 +654,17 @@ type lockTable interface {
 	// the lockTable initially. It must only be called in the evaluation phase
 	// before calling Dequeue, which means all the latches needed by the request
 	// are held. The key must be in the request's SpanSet with the appropriate
-	// SpanAccess: currently the strength is always Exclusive, so the span
-	// containing this key must be SpanReadWrite. This contract ensures that the
-	// lock is not held in a conflicting manner by a different transaction.
-	// Acquiring a lock that is already held by this transaction upgrades the
-	// lock's timestamp and strength, if necessary.
+	// SpanAccess: the strength is either Exclusive or Shared, and in both cases
+	// the span containing this key must be SpanReadWrite. This contract ensures
+	// that the lock is not held in a conflicting manner by a different
+	// transaction. Acquiring a lock that is already held by this transaction
+	// upgrades the lock's timestamp and strength, if necessary. A Shared lock
+	// can only be upgraded to an Exclusive lock if no other transaction holds a
+	// Shared lock on the key.
 	//
 	// For replicated locks, this must be called after the corresponding write
-	// intent has been applied to the replicated state machine.
+	// intent (or replicated Shared lock) has been applied to the replicated
+	// state machine.
 	AcquireLock(*enginepb.TxnMeta, roachpb.Key, lock.Strength, lock.Durability) error
 
 	// UpdateLocks informs the lockTable that an existing lock or range of locks
@@ -494,7 +494,7 @@ func (m *managerImpl) HandleTransactionPushError(
 
 // OnLockAcquired implements the LockManager interface.
 func (m *managerImpl) OnLockAcquired(ctx context.Context, acq *roachpb.LockAcquisition) {
-	if err := m.lt.AcquireLock(&acq.Txn, acq.Key, lock.Exclusive, acq.Durability); err != nil {
+	if err := m.lt.AcquireLock(&acq.Txn, acq.Key, acq.LockStrength(), acq.Durability); err != nil {
 		log.Fatalf(ctx, "%v", err)
 	}
 }
@@ -619,6 +619,36 @@ func (r *Request) isSingle(m roachpb.Method) bool {
 	return r.Requests[0].GetInner().Method() == m
 }
 
+// lockStrength returns the strength of the locks that the request may acquire
+// on the keys that it declares with SpanReadWrite access. This is Shared if
+// each of the requests in the batch is either a non-locking read or a read
+// that acquires Shared locks, and Exclusive otherwise.
+func (r *Request) lockStrength() lock.Strength {
+	if len(r.Requests) == 0 {
+		return lock.Exclusive
+	}
+	for _, ru := range r.Requests {
+		var str lock.Strength
+		switch req := ru.GetInner().(type) {
+		case *roachpb.GetRequest:
+			str = req.KeyLocking
+		case *roachpb.ScanRequest:
+			str = req.KeyLocking
+		case *roachpb.ReverseScanRequest:
+			str = req.KeyLocking
+		default:
+			if !roachpb.IsReadOnly(req) || roachpb.IsLocking(req) {
+				return lock.Exclusive
+			}
+			continue
+		}
+		if str != lock.None && str != lock.Shared {
+			return lock.Exclusive
+		}
+	}
+	return lock.Shared
+}
+
 // Used to avoid allocations.
 var guardPool = sync.Pool{
 	New: func() interface{} { return new(Guard) },
@@ -365,6 +365,10 @@ type lockTableGuardImpl struct {
 	ts                 hlc.Timestamp
 	spans              *spanset.SpanSet
 	maxWaitQueueLength int
+	// The strength of the locks that the request may acquire on the keys that
+	// it declares with SpanReadWrite access. Requests that acquire Shared locks
+	// do not conflict with the holders of other Shared locks.
+	str lock.Strength
 
 	// Snapshots of the trees for which this request has some spans. Note that
 	// the lockStates in these snapshots may have been removed from
@@ -740,6 +744,38 @@ func (lh *lockHolderInfo) isEmpty() bool {
 	return lh.txn == nil && lh.seqs == nil && lh.ts.IsEmpty()
 }
 
+// Information about a transaction holding a lock with Shared strength. Like
+// the holder of an Exclusive lock, it is tracked separately for each
+// durability level.
+type sharedLockHolder struct {
+	holder [lock.MaxDurability + 1]lockHolderInfo
+
+	// The start time of the transaction being marked as a holder of the lock in
+	// the lock table.
+	startTime time.Time
+}
+
+// Returns the transaction holding the Shared lock and the lower of the
+// timestamps at which it is held. See lockState.getLockHolder.
+func (sh *sharedLockHolder) getLockHolder() (*enginepb.TxnMeta, hlc.Timestamp) {
+	index := lock.Replicated
+	if sh.holder[index].txn == nil || (sh.holder[lock.Unreplicated].txn != nil &&
+		sh.holder[lock.Unreplicated].ts.Less(sh.holder[lock.Replicated].ts)) {
+		index = lock.Unreplicated
+	}
+	return sh.holder[index].txn, sh.holder[index].ts
+}
+
+// Returns true iff the Shared lock is no longer held at any durability.
+func (sh *sharedLockHolder) isEmpty() bool {
+	for i := range sh.holder {
+		if sh.holder[i].txn != nil {
+			return false
+		}
+	}
+	return true
+}
+
 // Per lock state in lockTableImpl.
 //
 // NOTE: we can't easily pool lockState objects without some form of reference
@@ -759,11 +795,14 @@ type lockState struct {
 	mu syncutil.Mutex // Protects everything below.
 
 	// Invariant summary (see detailed comments below):
-	// - both holder.locked and waitQ.reservation != nil cannot be true.
+	// - at most one of holder.locked, len(sharedHolders) > 0 and
+	//   waitQ.reservation != nil can be true.
 	// - if holder.locked and multiple holderInfos have txn != nil: all the
 	//   txns must have the same txn.ID.
 	// - !holder.locked => waitingReaders.Len() == 0. That is, readers wait
-	//   only if the lock is held. They do not wait for a reservation.
+	//   only if the lock is held with Exclusive strength. They do not wait for
+	//   a reservation or for Shared lock holders.
+	// - a txn appears at most once in sharedHolders.
 	// - If reservation != nil, that request is not in queuedWriters.
 
 	// Information about whether the lock is held and the holder. We track
@@ -772,7 +811,8 @@ type lockState struct {
 	// replicated and unreplicated mode at different stages.
 	holder struct {
 		locked bool
-		// LockStrength is always Exclusive
+		// The lock is held with Exclusive strength. Shared locks are tracked in
+		// sharedHolders.
 		holder [lock.MaxDurability + 1]lockHolderInfo
 
 		// The start time of the lockholder being marked as held in the lock table.
@@ -784,6 +824,20 @@ type lockState struct {
 		startTime time.Time
 	}
 
+	// Information about the transactions holding the lock with Shared strength,
+	// in the order in which they acquired it. Shared locks are compatible with
+	// each other, so any number of transactions can hold one at the same time,
+	// but they conflict with requests that write to the key or acquire an
+	// Exclusive lock on it. Such requests wait in queuedWriters until all of
+	// the Shared locks held by other transactions have been released. Requests
+	// that acquire Shared locks themselves do not wait on Shared lock holders,
+	// unless they queue behind such a waiting request (see
+	// sharedLockerMustQueue). Non-locking readers never wait on them.
+	//
+	// A transaction that holds the only Shared lock on the key can upgrade it
+	// to an Exclusive lock, at which point it is moved to holder.
+	sharedHolders []*sharedLockHolder
+
 	// Information about the requests waiting on the lock.
 	lockWaitQueue
 
@@ -1004,11 +1058,17 @@ func (l *lockState) safeFormat(sb *redact.StringBuilder, finalizedTxnCache *txnC
 		sb.Printf("txn: %v, ts: %v, seq: %v\n",
 			redact.Safe(txn.ID), redact.Safe(ts), redact.Safe(txn.Sequence))
 	}
-	writeHolderInfo := func(sb *redact.StringBuilder, txn *enginepb.TxnMeta, ts hlc.Timestamp) {
-		sb.Printf("  holder: txn: %v, ts: %v, info: ", redact.Safe(txn.ID), redact.Safe(ts))
+	writeHolderInfo := func(
+		sb *redact.StringBuilder,
+		prefix redact.SafeString,
+		holder *[lock.MaxDurability + 1]lockHolderInfo,
+		txn *enginepb.TxnMeta,
+		ts hlc.Timestamp,
+	) {
+		sb.Printf("  %s: txn: %v, ts: %v, info: ", prefix, redact.Safe(txn.ID), redact.Safe(ts))
 		first := true
-		for i := range l.holder.holder {
-			h := &l.holder.holder[i]
+		for i := range holder {
+			h := &holder[i]
 			if h.txn == nil {
 				continue
 			}
@@ -1042,12 +1102,16 @@ func (l *lockState) safeFormat(sb *redact.StringBuilder, finalizedTxnCache *txnC
 		}
 		sb.SafeString("\n")
 	}
-	txn, ts := l.getLockHolder()
-	if txn == nil {
+	if txn, ts := l.getLockHolder(); txn != nil {
+		writeHolderInfo(sb, "holder", &l.holder.holder, txn, ts)
+	} else if len(l.sharedHolders) > 0 {
+		for _, sh := range l.sharedHolders {
+			txn, ts := sh.getLockHolder()
+			writeHolderInfo(sb, "shared holder", &sh.holder, txn, ts)
+		}
+	} else {
 		sb.Printf("  res: req: %d, ", l.reservation.seqNum)
 		writeResInfo(sb, l.reservation.txn, l.reservation.ts)
-	} else {
-		writeHolderInfo(sb, txn, ts)
 	}
 	// TODO(sumeer): Add an optional `description string` field to Request and
 	// lockTableGuardImpl that tests can set to avoid relying on the seqNum to
@@ -1112,12 +1176,17 @@ func (l *lockState) lockStateInfo(now time.Time) roachpb.LockStateInfo {
 	var txnHolder *enginepb.TxnMeta
 
 	durability := lock.Unreplicated
-	if l.holder.locked {
-		if l.holder.holder[lock.Replicated].txn != nil {
+	holder := &l.holder.holder
+	if !l.holder.locked && len(l.sharedHolders) > 0 {
+		// Report the first transaction to have acquired a Shared lock.
+		holder = &l.sharedHolders[0].holder
+	}
+	if l.holder.locked || len(l.sharedHolders) > 0 {
+		if holder[lock.Replicated].txn != nil {
 			durability = lock.Replicated
-			txnHolder = l.holder.holder[lock.Replicated].txn
-		} else if l.holder.holder[lock.Unreplicated].txn != nil {
-			txnHolder = l.holder.holder[lock.Unreplicated].txn
+			txnHolder = holder[lock.Replicated].txn
+		} else if holder[lock.Unreplicated].txn != nil {
+			txnHolder = holder[lock.Unreplicated].txn
 		}
 	}
 
@@ -1134,7 +1203,7 @@ func (l *lockState) lockStateInfo(now time.Time) roachpb.LockStateInfo {
 		lockWaiters = append(lockWaiters, lock.Waiter{
 			WaitingTxn:   l.reservation.txn,
 			ActiveWaiter: true,
-			Strength:     lock.Exclusive,
+			Strength:     l.reservation.str,
 			WaitDuration: now.Sub(l.reservation.mu.curLockWaitStart),
 		})
 		l.reservation.mu.Unlock()
@@ -1161,7 +1230,7 @@ func (l *lockState) lockStateInfo(now time.Time) roachpb.LockStateInfo {
 		lockWaiters = append(lockWaiters, lock.Waiter{
 			WaitingTxn:   writerGuard.txn,
 			ActiveWaiter: qg.active,
-			Strength:     lock.Exclusive,
+			Strength:     writerGuard.str,
 			WaitDuration: now.Sub(writerGuard.mu.curLockWaitStart),
 		})
 		writerGuard.mu.Unlock()
@@ -1186,7 +1255,7 @@ func (l *lockState) addToMetrics(m *LockTableMetrics, now time.Time) {
 	totalWaitDuration, maxWaitDuration := l.totalAndMaxWaitDuration(now)
 	lm := LockMetrics{
 		Key:                  l.key,
-		Held:                 l.holder.locked,
+		Held:                 l.holder.locked || len(l.sharedHolders) > 0,
 		HoldDurationNanos:    l.lockHeldDuration(now).Nanoseconds(),
 		WaitingReaders:       int64(l.waitingReaders.Len()),
 		WaitingWriters:       int64(l.queuedWriters.Len()),
@@ -1228,6 +1297,10 @@ func (l *lockState) informActiveWaiters() {
 	if lockHolderTxn, _ := l.getLockHolder(); lockHolderTxn != nil {
 		waitForState.txn = lockHolderTxn
 		waitForState.held = true
+	} else if len(l.sharedHolders) > 0 {
+		// Each waiting writer waits for the first Shared lock holder that it
+		// conflicts with, which is determined below.
+		waitForState.held = true
 	} else {
 		waitForState.txn = l.reservation.txn
 		if !findDistinguished && l.distinguishedWaiter.isSameTxnAsReservation(waitForState) {
@@ -1262,6 +1335,11 @@ func (l *lockState) informActiveWaiters() {
 		}
 		g := qg.guard
 		state := waitForState
+		if len(l.sharedHolders) > 0 {
+			// All waiting writers conflict with one of the Shared lock holders,
+			// see releaseWritersCompatibleWithSharedHolders.
+			state.txn = l.conflictingSharedHolder(g)
+		}
 		if g.isSameTxnAsReservation(state) {
 			state.kind = waitSelf
 		} else {
@@ -1306,6 +1384,64 @@ func (l *lockState) releaseWritersFromTxn(txn *enginepb.TxnMeta) {
 	}
 }
 
+// releaseWritersCompatibleWithSharedHolders removes all waiting writers that
+// do not conflict with the lock, which is held with Shared strength. These are
+// the writers that acquire Shared locks themselves and are not queued behind a
+// conflicting writer, and the writers whose txn is the only holder of a Shared
+// lock. Returns true iff any writers were removed.
+// REQUIRES: l.mu is locked.
+func (l *lockState) releaseWritersCompatibleWithSharedHolders() (released bool) {
+	if len(l.sharedHolders) == 0 {
+		return false
+	}
+	for e := l.queuedWriters.Front(); e != nil; {
+		qg := e.Value.(*queuedGuard)
+		curr := e
+		e = e.Next()
+		g := qg.guard
+		if g.str == lock.Shared {
+			if l.sharedLockerMustQueue(g) {
+				continue
+			}
+		} else if l.conflictingSharedHolder(g) != nil {
+			continue
+		}
+		if qg.active {
+			if g == l.distinguishedWaiter {
+				l.distinguishedWaiter = nil
+			}
+			g.doneWaitingAtLock(false, l)
+		} else {
+			g.mu.Lock()
+			delete(g.mu.locks, l)
+			g.mu.Unlock()
+		}
+		l.queuedWriters.Remove(curr)
+		released = true
+	}
+	return released
+}
+
+// removeWriter removes the (active or inactive) waiting writer g from
+// queuedWriters, if present.
+// REQUIRES: l.mu is locked.
+func (l *lockState) removeWriter(g *lockTableGuardImpl) {
+	for e := l.queuedWriters.Front(); e != nil; e = e.Next() {
+		qg := e.Value.(*queuedGuard)
+		if qg.guard == g {
+			l.queuedWriters.Remove(e)
+			g.mu.Lock()
+			delete(g.mu.locks, l)
+			g.mu.Unlock()
+			if g == l.distinguishedWaiter {
+				l.distinguishedWaiter = nil
+				l.tryMakeNewDistinguished()
+			}
+			return
+		}
+	}
+}
+
 // When the active waiters have shrunk and the distinguished waiter has gone,
 // try to make a new distinguished waiter if there is at least 1 active
 // waiter.
@@ -1337,7 +1473,7 @@ func (l *lockState) tryMakeNewDistinguished() {
 // reservation.
 // REQUIRES: l.mu is locked.
 func (l *lockState) isEmptyLock() bool {
-	if !l.holder.locked && l.reservation == nil {
+	if !l.holder.locked && len(l.sharedHolders) == 0 && l.reservation == nil {
 		for i := range l.holder.holder {
 			if !l.holder.holder[i].isEmpty() {
 				panic("lockState with !locked but non-zero lockHolderInfo")
@@ -1355,6 +1491,9 @@ func (l *lockState) isEmptyLock() bool {
 // REQUIRES: l.mu is locked.
 func (l *lockState) lockHeldDuration(now time.Time) time.Duration {
 	if !l.holder.locked {
+		if len(l.sharedHolders) > 0 {
+			return now.Sub(l.sharedHolders[0].startTime)
+		}
 		return time.Duration(0)
 	}
 
@@ -1407,6 +1546,75 @@ func (l *lockState) isLockedBy(id uuid.UUID) bool {
 	return false
 }
 
+// Returns the index of the transaction with the given id in sharedHolders, or
+// -1 if the transaction does not hold a Shared lock.
+// REQUIRES: l.mu is locked.
+func (l *lockState) findSharedHolder(id uuid.UUID) int {
+	for i, sh := range l.sharedHolders {
+		if txn, _ := sh.getLockHolder(); txn.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// Returns the first Shared lock holder that belongs to a transaction other
+// than that of the request, or nil if there is no such holder.
+// REQUIRES: l.mu is locked.
+func (l *lockState) conflictingSharedHolder(g *lockTableGuardImpl) *enginepb.TxnMeta {
+	for _, sh := range l.sharedHolders {
+		if txn, _ := sh.getLockHolder(); !g.isSameTxn(txn) {
+			return txn
+		}
+	}
+	return nil
+}
+
+// Returns true iff the request g, which acquires a Shared lock on this key
+// while it is held with Shared strength, must wait in queuedWriters. Shared
+// locks are compatible with each other, but a request acquiring one queues
+// behind the waiting writers that conflict with the Shared lock holders, so
+// that a steady stream of Shared lock acquisitions cannot starve them. A
+// transaction that already holds a Shared lock on the key does not queue.
+// REQUIRES: l.mu is locked.
+func (l *lockState) sharedLockerMustQueue(g *lockTableGuardImpl) bool {
+	if g.txn != nil && l.findSharedHolder(g.txn.ID) >= 0 {
+		return false
+	}
+	// queuedWriters is sorted by seqNum.
+	for e := l.queuedWriters.Front(); e != nil; e = e.Next() {
+		w := e.Value.(*queuedGuard).guard
+		if w.seqNum >= g.seqNum {
+			break
+		}
+		if w.str != lock.Shared && (g.txn == nil || !w.isSameTxn(g.txn)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the first Shared lock holder that belongs to a transaction other
+// than the one with the given id, or nil if there is no such holder.
+// REQUIRES: l.mu is locked.
+func (l *lockState) conflictingSharedHolderOf(id uuid.UUID) *enginepb.TxnMeta {
+	for _, sh := range l.sharedHolders {
+		if txn, _ := sh.getLockHolder(); txn.ID != id {
+			return txn
+		}
+	}
+	return nil
+}
+
+// Removes the Shared lock holder at index i of sharedHolders.
+// REQUIRES: l.mu is locked.
+func (l *lockState) removeSharedHolder(i int) {
+	n := len(l.sharedHolders)
+	copy(l.sharedHolders[i:], l.sharedHolders[i+1:])
+	l.sharedHolders[n-1] = nil
+	l.sharedHolders = l.sharedHolders[:n-1]
+}
+
 // Returns information about the current lock holder if the lock is held, else
 // returns nil.
 // REQUIRES: l.mu is locked.
@@ -1432,7 +1640,7 @@ func (l *lockState) getLockHolder() (*enginepb.TxnMeta, hlc.Timestamp) {
 	return l.holder.holder[index].txn, l.holder.holder[index].ts
 }
 
-// Removes the current lock holder from the lock.
+// Removes the current lock holder, or Shared lock holders, from the lock.
 // REQUIRES: l.mu is locked.
 func (l *lockState) clearLockHolder() {
 	l.holder.locked = false
@@ -1440,6 +1648,7 @@ func (l *lockState) clearLockHolder() {
 	for i := range l.holder.holder {
 		l.holder.holder[i] = lockHolderInfo{}
 	}
+	l.sharedHolders = nil
 }
 
 // Decides whether the request g with access sa should actively wait at this
@@ -1536,6 +1745,29 @@ func (l *lockState) tryActiveWait(
 				replicatedLockFinalizedTxn = finalizedTxn
 			}
 		}
+	} else if len(l.sharedHolders) > 0 {
+		var transitionedToFree bool
+		lockHolderTxn, replicatedLockFinalizedTxn, transitionedToFree = l.sharedHolderToWaitOn(g, sa)
+		if transitionedToFree {
+			// Empty lock.
+			return false, true
+		}
+		if lockHolderTxn == nil && len(l.sharedHolders) > 0 {
+			// Compatible with the Shared lock holders. If the request was queued
+			// because it previously conflicted with the lock, it no longer needs
+			// to be.
+			g.mu.Lock()
+			_, inQueue := g.mu.locks[l]
+			g.mu.Unlock()
+			if inQueue && sa == spanset.SpanReadWrite {
+				l.removeWriter(g)
+			}
+			return false, false
+		}
+		// Else, either the request conflicts with a Shared lock holder, or all
+		// of the Shared lock holders were removed and there is a reservation
+		// holder, which may be the caller itself, so fall through to the
+		// processing below.
 	}
 
 	if sa == spanset.SpanReadOnly {
@@ -1717,6 +1949,72 @@ func (l *lockState) tryActiveWait(
 	return true, false
 }
 
+// Returns the Shared lock holder that the request g with access sa should wait
+// on, which is nil if the request does not conflict with any of the holders.
+//
+// Like in tryActiveWait, the finalizedTxnCache is used to remove Shared lock
+// holders that hold the lock only with Unreplicated durability and whose txn is
+// already finalized. If the request only conflicts with holders that are
+// finalized but hold the lock with Replicated durability, the first such holder
+// is returned along with its finalized txn. transitionedToFree is true iff all
+// of the Shared lock holders were removed and the lock became empty.
+// REQUIRES: l.mu is locked and len(l.sharedHolders) > 0.
+func (l *lockState) sharedHolderToWaitOn(
+	g *lockTableGuardImpl, sa spanset.SpanAccess,
+) (txn *enginepb.TxnMeta, finalizedTxn *roachpb.Transaction, transitionedToFree bool) {
+	removed := false
+	for i := 0; i < len(l.sharedHolders); {
+		sh := l.sharedHolders[i]
+		holderTxn, _ := sh.getLockHolder()
+		if _, ok := g.lt.finalizedTxnCache.get(holderTxn.ID); ok &&
+			sh.holder[lock.Replicated].txn == nil {
+			// Only held unreplicated. Release immediately.
+			l.removeSharedHolder(i)
+			removed = true
+			continue
+		}
+		i++
+	}
+	if len(l.sharedHolders) == 0 {
+		return nil, nil, l.lockIsFree()
+	}
+	if removed {
+		l.releaseWritersCompatibleWithSharedHolders()
+		l.informActiveWaiters()
+	}
+
+	if sa == spanset.SpanReadOnly {
+		// Non-locking reads are compatible with Shared locks.
+		return nil, nil, false
+	}
+	if g.str == lock.Shared {
+		// Shared locks are compatible with Shared locks, but the request may
+		// need to queue behind conflicting writers. If so, it waits on the
+		// first Shared lock holder, like the writers ahead of it.
+		if !l.sharedLockerMustQueue(g) {
+			return nil, nil, false
+		}
+		return l.conflictingSharedHolder(g), nil, false
+	}
+	for _, sh := range l.sharedHolders {
+		holderTxn, _ := sh.getLockHolder()
+		if g.isSameTxn(holderTxn) {
+			continue
+		}
+		finalized, ok := g.lt.finalizedTxnCache.get(holderTxn.ID)
+		if !ok {
+			return holderTxn, nil, false
+		}
+		if finalizedTxn == nil {
+			finalizedTxn = finalized
+		}
+	}
+	if finalizedTxn != nil {
+		return &finalizedTxn.TxnMeta, finalizedTxn, false
+	}
+	return nil, nil, false
+}
+
 func (l *lockState) isNonConflictingLock(g *lockTableGuardImpl, sa spanset.SpanAccess) bool {
 	l.mu.Lock()
 	defer l.mu.Unlock()
@@ -1727,6 +2025,18 @@ func (l *lockState) isNonConflictingLock(g *lockTableGuardImpl, sa spanset.SpanA
 	}
 	// Lock is not empty.
 	lockHolderTxn, lockHolderTS := l.getLockHolder()
+	if lockHolderTxn == nil && len(l.sharedHolders) > 0 {
+		// Shared lock holders only conflict with writers that don't acquire
+		// Shared locks themselves, and with the Shared lockers that queue behind
+		// such writers.
+		if sa == spanset.SpanReadOnly {
+			return true
+		}
+		if g.str == lock.Shared {
+			return !l.sharedLockerMustQueue(g)
+		}
+		return l.conflictingSharedHolder(g) == nil
+	}
 	if lockHolderTxn == nil {
 		// Reservation holders are non-conflicting.
 		//
@@ -1761,7 +2071,7 @@ func (l *lockState) isNonConflictingLock(g *lockTableGuardImpl, sa spanset.SpanA
 // that is acquiring the lock.
 // Acquires l.mu.
 func (l *lockState) acquireLock(
-	_ lock.Strength,
+	str lock.Strength,
 	durability lock.Durability,
 	txn *enginepb.TxnMeta,
 	ts hlc.Timestamp,
@@ -1769,6 +2079,9 @@ func (l *lockState) acquireLock(
 ) error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
+	if str == lock.Shared {
+		return l.acquireSharedLock(durability, txn, ts, clock)
+	}
 	if l.holder.locked {
 		// Already held.
 		beforeTxn, beforeTs := l.getLockHolder()
@@ -1846,6 +2159,26 @@ func (l *lockState) acquireLock(
 		}
 		return nil
 	}
+	if len(l.sharedHolders) > 0 {
+		// Held with Shared strength, which can only be upgraded to Exclusive
+		// strength by the transaction if it is the only Shared lock holder.
+		// Requests that conflict with the Shared lock are waiting in
+		// queuedWriters, and continue to do so once the lock is held with
+		// Exclusive strength.
+		if holderTxn, _ := l.sharedHolders[0].getLockHolder(); len(l.sharedHolders) > 1 || holderTxn.ID != txn.ID {
+			return errors.AssertionFailedf(
+				"existing shared lock cannot be upgraded while held by a different transaction")
+		}
+		l.sharedHolders = nil
+		l.holder.locked = true
+		l.holder.holder[durability].txn = txn
+		l.holder.holder[durability].ts = ts
+		l.holder.holder[durability].seqs = append([]enginepb.TxnSeq(nil), txn.Sequence)
+		l.holder.startTime = clock.PhysicalTime()
+		l.releaseWritersFromTxn(txn)
+		l.informActiveWaiters()
+		return nil
+	}
 	// Not already held, so may have been reserved by this request. There is also
 	// the possibility that some other request has broken this reservation because
 	// of a concurrent release but that is harmless since this request is
@@ -1892,12 +2225,89 @@ func (l *lockState) acquireLock(
 	return nil
 }
 
-// A replicated lock held by txn with timestamp ts was discovered by guard g
-// where g is trying to access this key with access sa.
+// Acquires this lock with Shared strength. See acquireLock.
+// REQUIRES: l.mu is locked.
+func (l *lockState) acquireSharedLock(
+	durability lock.Durability, txn *enginepb.TxnMeta, ts hlc.Timestamp, clock *hlc.Clock,
+) error {
+	if l.holder.locked {
+		if !l.isLockedBy(txn.ID) {
+			return errors.AssertionFailedf("existing lock cannot be acquired by different transaction")
+		}
+		// The transaction already holds an Exclusive lock, which is stronger.
+		return nil
+	}
+	var sh *sharedLockHolder
+	if i := l.findSharedHolder(txn.ID); i >= 0 {
+		sh = l.sharedHolders[i]
+	} else {
+		if len(l.sharedHolders) == 0 {
+			// Not already held, so may have been reserved by this request. See the
+			// comment in acquireLock.
+			if l.reservation != nil {
+				if l.reservation.txn.ID != txn.ID {
+					// Reservation is broken.
+					qg := &queuedGuard{
+						guard:  l.reservation,
+						active: false,
+					}
+					l.queuedWriters.PushFront(qg)
+				} else {
+					l.reservation.mu.Lock()
+					delete(l.reservation.mu.locks, l)
+					l.reservation.mu.Unlock()
+				}
+				if l.waitingReaders.Len() > 0 {
+					panic("lockTable bug")
+				}
+			} else {
+				if l.queuedWriters.Len() > 0 || l.waitingReaders.Len() > 0 {
+					panic("lockTable bug")
+				}
+			}
+			l.reservation = nil
+		}
+		sh = &sharedLockHolder{startTime: clock.PhysicalTime()}
+		l.sharedHolders = append(l.sharedHolders, sh)
+	}
+
+	h := &sh.holder[durability]
+	seqs := h.seqs
+	if h.txn != nil && h.txn.Epoch < txn.Epoch {
+		// Clear the sequences for the older epoch.
+		seqs = seqs[:0]
+	}
+	// Insert the sequence number into the sequence history, if it is not being
+	// tracked yet. The timestamp of a Shared lock does not affect which requests
+	// conflict with it, so it is simply forwarded.
+	if i := sort.Search(len(seqs), func(i int) bool {
+		return seqs[i] >= txn.Sequence
+	}); i == len(seqs) || seqs[i] != txn.Sequence {
+		seqs = append(seqs, 0)
+		copy(seqs[i+1:], seqs[i:])
+		seqs[i] = txn.Sequence
+	}
+	h.seqs = seqs
+	h.txn = txn
+	h.ts.Forward(ts)
+
+	// If there are waiting requests that are compatible with the lock, they no
+	// longer need to wait. Note that unlike in acquireLock, waiting requests
+	// from the same txn may still conflict with other Shared lock holders.
+	l.releaseWritersCompatibleWithSharedHolders()
+
+	// Inform active waiters since the set of lock holders has changed.
+	l.informActiveWaiters()
+	return nil
+}
+
+// A replicated lock with strength str held by txn with timestamp ts was
+// discovered by guard g where g is trying to access this key with access sa.
 // Acquires l.mu.
 func (l *lockState) discoveredLock(
 	txn *enginepb.TxnMeta,
 	ts hlc.Timestamp,
+	str lock.Strength,
 	g *lockTableGuardImpl,
 	sa spanset.SpanAccess,
 	notRemovable bool,
@@ -1909,18 +2319,42 @@ func (l *lockState) discoveredLock(
 	if notRemovable {
 		l.notRemovable++
 	}
+	var holder *lockHolderInfo
 	if l.holder.locked {
 		if !l.isLockedBy(txn.ID) {
 			return errors.AssertionFailedf(
 				"discovered lock by different transaction (%s) than existing lock (see issue #63592): %s",
 				txn, l)
 		}
+		if str == lock.Exclusive {
+			holder = &l.holder.holder[lock.Replicated]
+		}
+		// Else, the Exclusive lock held by the same transaction is stronger than
+		// the discovered Shared lock.
+	} else if str == lock.Shared {
+		if sa == spanset.SpanReadOnly || g.str == lock.Shared {
+			return errors.AssertionFailedf("discovered non-conflicting lock")
+		}
+		i := l.findSharedHolder(txn.ID)
+		if i < 0 {
+			l.sharedHolders = append(l.sharedHolders, &sharedLockHolder{startTime: clock.PhysicalTime()})
+			i = len(l.sharedHolders) - 1
+		}
+		holder = &l.sharedHolders[i].holder[lock.Replicated]
 	} else {
+		if conflictingTxn := l.conflictingSharedHolderOf(txn.ID); conflictingTxn != nil {
+			return errors.AssertionFailedf(
+				"discovered lock by different transaction (%s) than existing shared lock: %s",
+				txn, l)
+		}
+		// Any Shared lock held by the same transaction is subsumed by the
+		// discovered Exclusive lock.
+		l.sharedHolders = nil
 		l.holder.locked = true
 		l.holder.startTime = clock.PhysicalTime()
+		holder = &l.holder.holder[lock.Replicated]
 	}
-	holder := &l.holder.holder[lock.Replicated]
-	if holder.txn == nil {
+	if holder != nil && holder.txn == nil {
 		holder.txn = txn
 		holder.ts = ts
 		holder.seqs = append(holder.seqs, txn.Sequence)
@@ -1986,8 +2420,15 @@ func (l *lockState) discoveredLock(
 		}
 	}
 
-	// If there are waiting requests from the same txn, they no longer need to wait.
-	l.releaseWritersFromTxn(txn)
+	if len(l.sharedHolders) > 0 {
+		// If there are waiting requests that are compatible with the lock, they
+		// no longer need to wait.
+		l.releaseWritersCompatibleWithSharedHolders()
+	} else {
+		// If there are waiting requests from the same txn, they no longer need to
+		// wait.
+		l.releaseWritersFromTxn(txn)
+	}
 
 	// Active waiters need to be told about who they are waiting for.
 	l.informActiveWaiters()
@@ -2028,7 +2469,9 @@ func (l *lockState) tryClearLock(force bool) bool {
 		}
 	} else {
 		// !replicatedHeld || force. Both are handled as doneWaiting since the
-		// system is no longer tracking the lock that was possibly held.
+		// system is no longer tracking the lock that was possibly held. This
+		// includes Shared locks: waiters that conflict with a replicated Shared
+		// lock will discover it again during evaluation.
 		l.clearLockHolder()
 		waitState = waitingState{kind: doneWaiting}
 	}
@@ -2104,6 +2547,9 @@ func (l *lockState) tryUpdateLock(up *roachpb.LockUpdate) (heldByTxn, gc bool) {
 		// tryActiveWait due to the txn being in the finalizedTxnCache.
 		return false, true
 	}
+	if len(l.sharedHolders) > 0 {
+		return l.tryUpdateSharedLock(up)
+	}
 	if !l.isLockedBy(up.Txn.ID) {
 		return false, false
 	}
@@ -2180,6 +2626,49 @@ func (l *lockState) tryUpdateLock(up *roachpb.LockUpdate) (heldByTxn, gc bool) {
 	return true, false
 }
 
+// tryUpdateSharedLock is like tryUpdateLock, for a lock that is held with
+// Shared strength. Unreplicated Shared locks are released if the transaction
+// is finalized, has moved to a later epoch, or has rolled back all of the
+// sequence numbers at which it acquired the lock. Replicated Shared locks are
+// only released if the transaction is finalized, which mirrors their handling
+// during intent resolution (see storage.MVCCResolveWriteIntent). Since the
+// timestamp of a Shared lock does not affect which requests conflict with it,
+// timestamp updates are ignored.
+// REQUIRES: l.mu is locked and len(l.sharedHolders) > 0.
+func (l *lockState) tryUpdateSharedLock(up *roachpb.LockUpdate) (heldByTxn, gc bool) {
+	i := l.findSharedHolder(up.Txn.ID)
+	if i < 0 {
+		return false, false
+	}
+	sh := l.sharedHolders[i]
+	if !up.Status.IsFinalized() {
+		txn := &up.Txn
+		holder := &sh.holder[lock.Unreplicated]
+		if holder.txn != nil {
+			if txn.Epoch > holder.txn.Epoch {
+				*holder = lockHolderInfo{}
+			} else if txn.Epoch == holder.txn.Epoch {
+				holder.seqs = removeIgnored(holder.seqs, up.IgnoredSeqNums)
+				if len(holder.seqs) == 0 {
+					*holder = lockHolderInfo{}
+				}
+			}
+		}
+		if !sh.isEmpty() {
+			return true, false
+		}
+	}
+
+	l.removeSharedHolder(i)
+	if len(l.sharedHolders) == 0 {
+		return true, l.lockIsFree()
+	}
+	// Some of the waiting writers may have been waiting on this transaction.
+	l.releaseWritersCompatibleWithSharedHolders()
+	l.informActiveWaiters()
+	return true, false
+}
+
 // The lock holder timestamp has increased. Some of the waiters may no longer
 // need to wait.
 // REQUIRES: l.mu is locked.
@@ -2260,7 +2749,10 @@ func (l *lockState) requestDone(g *lockTableGuardImpl) (gc bool) {
 	if !doneRemoval {
 		panic("lockTable bug")
 	}
-	if distinguishedRemoved {
+	// Requests acquiring Shared locks may have been queued behind g.
+	if l.releaseWritersCompatibleWithSharedHolders() {
+		l.informActiveWaiters()
+	} else if distinguishedRemoved {
 		l.tryMakeNewDistinguished()
 	}
 	return false
@@ -2310,7 +2802,7 @@ func (l *lockState) tryFreeLockOnReplicatedAcquire() bool {
 // waiters, but there cannot be a reservation.
 // REQUIRES: l.mu is locked.
 func (l *lockState) lockIsFree() (gc bool) {
-	if l.holder.locked {
+	if l.holder.locked || len(l.sharedHolders) > 0 {
 		panic("called lockIsFree on lock with holder")
 	}
 	if l.reservation != nil {
@@ -2431,6 +2923,7 @@ func (t *lockTableImpl) newGuardForReq(req Request) *lockTableGuardImpl {
 	g.ts = req.Timestamp
 	g.spans = req.LockSpans
 	g.maxWaitQueueLength = req.MaxLockWaitQueueLength
+	g.str = req.lockStrength()
 	g.sa = spanset.NumSpanAccess - 1
 	g.index = -1
 	return g
@@ -2571,7 +3064,8 @@ func (t *lockTableImpl) AddDiscoveredLock(
 		g.notRemovableLock = l
 		notRemovableLock = true
 	}
-	err = l.discoveredLock(&intent.Txn, intent.Txn.WriteTimestamp, g, sa, notRemovableLock, g.lt.clock)
+	err = l.discoveredLock(
+		&intent.Txn, intent.Txn.WriteTimestamp, intent.LockStrength(), g, sa, notRemovableLock, g.lt.clock)
 	// Can't release tree.mu until call l.discoveredLock() since someone may
 	// find an empty lock and remove it from the tree.
 	tree.mu.Unlock()
@@ -2591,8 +3085,8 @@ func (t *lockTableImpl) AcquireLock(
 		// If not enabled, don't track any locks.
 		return nil
 	}
-	if strength != lock.Exclusive {
-		return errors.AssertionFailedf("lock strength not Exclusive")
+	if strength != lock.Exclusive && strength != lock.Shared {
+		return errors.AssertionFailedf("lock strength not Exclusive or Shared")
 	}
 	ss := spanset.SpanGlobal
 	if keys.IsLocal(key) {
@@ -2628,7 +3122,7 @@ func (t *lockTableImpl) AcquireLock(
 		atomic.AddInt64(&tree.numLocks, 1)
 	} else {
 		l = iter.Cur()
-		if durability == lock.Replicated && l.tryFreeLockOnReplicatedAcquire() {
+		if durability == lock.Replicated && strength == lock.Exclusive && l.tryFreeLockOnReplicatedAcquire() {
 			// Don't remember uncontended replicated locks. Just like in the
 			// case where the lock is initially added as replicated, we drop
 			// replicated locks from the lockTable when being upgraded from
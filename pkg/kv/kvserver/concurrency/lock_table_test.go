@@ -63,10 +63,11 @@ new-txn txn=<name> ts=<int>[,<int>] epoch=<int> [seq=<int>]
 
  Creates a TxnMeta.
 
-new-request r=<name> txn=<name>|none ts=<int>[,<int>] spans=r|w@<start>[,<end>]+... [max-lock-wait-queue-length=<int>]
+new-request r=<name> txn=<name>|none ts=<int>[,<int>] spans=r|w@<start>[,<end>]+... [max-lock-wait-queue-length=<int>] [strength=exclusive|shared]
 ----
 
- Creates a Request.
+ Creates a Request. If strength=shared is provided, the request acquires Shared
+ locks on the keys it declares with write access.
 
 scan r=<name>
 ----
@@ -82,7 +83,7 @@ start-waiting: <bool>
  Calls lockTable.ScanOptimistic. The request must not have an existing guard.
  If a guard is returned, stores it for later use.
 
-acquire r=<name> k=<key> durability=r|u
+acquire r=<name> k=<key> durability=r|u [strength=exclusive|shared]
 ----
 <error string>
 
@@ -105,7 +106,7 @@ txn-finalized txn=<name> status=committed|aborted
 
  Informs the lock table that the named transaction is finalized.
 
-add-discovered r=<name> k=<key> txn=<name> [lease-seq=<seq>] [consult-finalized-txn-cache=<bool>]
+add-discovered r=<name> k=<key> txn=<name> [lease-seq=<seq>] [consult-finalized-txn-cache=<bool>] [strength=exclusive|shared]
 ----
 <error string>
 
@@ -302,6 +303,11 @@ func TestLockTableBasic(t *testing.T) {
 					LatchSpans:             spans,
 					LockSpans:              spans,
 				}
+				if scanLockStrength(t, d) == lock.Shared {
+					var ru roachpb.RequestUnion
+					ru.MustSetInner(&roachpb.ScanRequest{KeyLocking: lock.Shared})
+					req.Requests = []roachpb.RequestUnion{ru}
+				}
 				if txnMeta != nil {
 					// Update the transaction's timestamp, if necessary. The transaction
 					// may have needed to move its timestamp for any number of reasons.
@@ -359,7 +365,8 @@ func TestLockTableBasic(t *testing.T) {
 				if s[0] == 'r' {
 					durability = lock.Replicated
 				}
-				if err := lt.AcquireLock(&req.Txn.TxnMeta, roachpb.Key(key), lock.Exclusive, durability); err != nil {
+				strength := scanLockStrength(t, d)
+				if err := lt.AcquireLock(&req.Txn.TxnMeta, roachpb.Key(key), strength, durability); err != nil {
 					return err.Error()
 				}
 				return lt.String()
@@ -448,6 +455,7 @@ func TestLockTableBasic(t *testing.T) {
 					d.Fatalf(t, "unknown txn %s", txnName)
 				}
 				intent := roachpb.MakeIntent(txnMeta, roachpb.Key(key))
+				intent.Strength = scanLockStrength(t, d)
 				seq := int(1)
 				if d.HasArg("lease-seq") {
 					d.ScanArgs(t, "lease-seq", &seq)
@@ -644,6 +652,23 @@ func scanTimestamp(t *testing.T, d *datadriven.TestData) hlc.Timestamp {
 	return ts
 }
 
+func scanLockStrength(t *testing.T, d *datadriven.TestData) lock.Strength {
+	if !d.HasArg("strength") {
+		return lock.Exclusive
+	}
+	var strS string
+	d.ScanArgs(t, "strength", &strS)
+	switch strS {
+	case "exclusive":
+		return lock.Exclusive
+	case "shared":
+		return lock.Shared
+	default:
+		d.Fatalf(t, "unknown lock strength: %s", strS)
+		return 0
+	}
+}
+
 func getSpan(t *testing.T, d *datadriven.TestData, str string) roachpb.Span {
 	parts := strings.Split(str, ",")
 	span := roachpb.Span{Key: roachpb.Key(parts[0])}
@@ -465,7 +465,9 @@ func (w *lockTableWaiterImpl) pushLockTxn(
 		// the lock holder's timestamp forward so the read request can read
 		// under the lock. For write-write conflicts, try to abort the lock
 		// holder entirely so the write request can revoke and replace the lock
-		// with its own lock.
+		// with its own lock. The latter also applies to requests waiting on the
+		// holder of a Shared lock: pushing its timestamp would not resolve the
+		// conflict, so the request waits for the holder to be finalized.
 		switch ws.guardAccess {
 		case spanset.SpanReadOnly:
 			pushType = roachpb.PUSH_TIMESTAMP
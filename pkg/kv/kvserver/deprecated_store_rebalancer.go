@@ -28,28 +28,28 @@ import (
 
 func (sr *StoreRebalancer) deprecatedChooseLeaseToTransfer(
 	ctx context.Context,
-	hottestRanges *[]replicaWithStats,
+	hottestRanges *[]CandidateReplica,
 	localDesc *roachpb.StoreDescriptor,
 	storeList storepool.StoreList,
 	storeMap map[roachpb.StoreID]*roachpb.StoreDescriptor,
 	minQPS float64,
 	maxQPS float64,
-) (replicaWithStats, roachpb.ReplicaDescriptor, []replicaWithStats) {
-	var considerForRebalance []replicaWithStats
-	now := sr.rq.store.Clock().NowAsClockTimestamp()
+) (CandidateReplica, roachpb.ReplicaDescriptor, []CandidateReplica) {
+	var considerForRebalance []CandidateReplica
+	now := sr.clock.NowAsClockTimestamp()
 	for {
 		if len(*hottestRanges) == 0 {
-			return replicaWithStats{}, roachpb.ReplicaDescriptor{}, considerForRebalance
+			return nil, roachpb.ReplicaDescriptor{}, considerForRebalance
 		}
-		replWithStats := (*hottestRanges)[0]
+		candidateReplica := (*hottestRanges)[0]
 		*hottestRanges = (*hottestRanges)[1:]
 
 		// We're all out of replicas.
-		if replWithStats.repl == nil {
-			return replicaWithStats{}, roachpb.ReplicaDescriptor{}, considerForRebalance
+		if candidateReplica == nil {
+			return nil, roachpb.ReplicaDescriptor{}, considerForRebalance
 		}
 
-		if shouldNotMoveAway(ctx, replWithStats, localDesc, now, minQPS) {
+		if shouldNotMoveAway(ctx, candidateReplica, localDesc, now, minQPS) {
 			continue
 		}
 
@@ -58,16 +58,16 @@ func (sr *StoreRebalancer) deprecatedChooseLeaseToTransfer(
 		// just unnecessary churn with no benefit to move leases responsible for,
 		// for example, 1 qps on a store with 5000 qps.
 		const minQPSFraction = .001
-		if replWithStats.qps < localDesc.Capacity.QueriesPerSecond*minQPSFraction &&
+		if candidateReplica.QPS() < localDesc.Capacity.QueriesPerSecond*minQPSFraction &&
 			float64(localDesc.Capacity.LeaseCount) <= storeList.CandidateLeases.Mean {
 			log.VEventf(ctx, 5, "r%d's %.2f qps is too little to matter relative to s%d's %.2f total qps",
-				replWithStats.repl.RangeID, replWithStats.qps, localDesc.StoreID, localDesc.Capacity.QueriesPerSecond)
+				candidateReplica.GetRangeID(), candidateReplica.QPS(), localDesc.StoreID, localDesc.Capacity.QueriesPerSecond)
 			continue
 		}
 
-		desc, conf := replWithStats.repl.DescAndSpanConfig()
+		desc, conf := candidateReplica.DescAndSpanConfig()
 		log.VEventf(ctx, 3, "considering lease transfer for r%d with %.2f qps",
-			desc.RangeID, replWithStats.qps)
+			desc.RangeID, candidateReplica.QPS())
 
 		// Check all the other voting replicas in order of increasing qps.
 		// Learners or non-voters aren't allowed to become leaseholders or raft
@@ -86,14 +86,14 @@ func (sr *StoreRebalancer) deprecatedChooseLeaseToTransfer(
 
 		var raftStatus *raft.Status
 
-		preferred := sr.rq.allocator.PreferredLeaseholders(conf, candidates)
+		preferred := sr.allocator.PreferredLeaseholders(conf, candidates)
 
 		// Filter both the list of preferred stores as well as the list of all
 		// candidate replicas to only consider live (non-suspect, non-draining)
 		// nodes.
 		const includeSuspectAndDrainingStores = false
-		preferred, _ = sr.rq.allocator.StorePool.LiveAndDeadReplicas(preferred, includeSuspectAndDrainingStores)
-		candidates, _ = sr.rq.allocator.StorePool.LiveAndDeadReplicas(candidates, includeSuspectAndDrainingStores)
+		preferred, _ = sr.allocator.StorePool.LiveAndDeadReplicas(preferred, includeSuspectAndDrainingStores)
+		candidates, _ = sr.allocator.StorePool.LiveAndDeadReplicas(candidates, includeSuspectAndDrainingStores)
 
 		for _, candidate := range candidates {
 			if candidate.StoreID == localDesc.StoreID {
@@ -101,12 +101,12 @@ func (sr *StoreRebalancer) deprecatedChooseLeaseToTransfer(
 			}
 
 			meanQPS := storeList.CandidateQueriesPerSecond.Mean
-			if sr.shouldNotMoveTo(ctx, storeMap, replWithStats, candidate.StoreID, meanQPS, minQPS, maxQPS) {
+			if sr.shouldNotMoveTo(ctx, storeMap, candidateReplica, candidate.StoreID, meanQPS, minQPS, maxQPS) {
 				continue
 			}
 
 			if raftStatus == nil {
-				raftStatus = sr.getRaftStatusFn(replWithStats.repl)
+				raftStatus = sr.getRaftStatusFn(candidateReplica)
 			}
 			if allocatorimpl.ReplicaIsBehind(raftStatus, candidate.ReplicaID) {
 				log.VEventf(ctx, 3, "%v is behind or this store isn't the raft leader for r%d; raftStatus: %v",
@@ -122,32 +122,32 @@ func (sr *StoreRebalancer) deprecatedChooseLeaseToTransfer(
 
 			filteredStoreList := storeList.ExcludeInvalid(conf.Constraints)
 			filteredStoreList = storeList.ExcludeInvalid(conf.VoterConstraints)
-			if sr.rq.allocator.FollowTheWorkloadPrefersLocal(
+			if sr.allocator.FollowTheWorkloadPrefersLocal(
 				ctx,
 				filteredStoreList,
 				*localDesc,
 				candidate.StoreID,
 				candidates,
-				replWithStats.repl.leaseholderStats,
+				candidateReplica.LeaseholderStats(),
 			) {
 				log.VEventf(ctx, 3, "r%d is on s%d due to follow-the-workload; skipping",
 					desc.RangeID, localDesc.StoreID)
 				continue
 			}
 
-			return replWithStats, candidate, considerForRebalance
+			return candidateReplica, candidate, considerForRebalance
 		}
 
 		// If none of the other replicas are valid lease transfer targets, consider
 		// this range for replica rebalancing.
-		considerForRebalance = append(considerForRebalance, replWithStats)
+		considerForRebalance = append(considerForRebalance, candidateReplica)
 	}
 }
 
 // rangeRebalanceContext represents a snapshot of a range's state during the
 // StoreRebalancer's attempt to rebalance it based on QPS.
 type deprecatedRebalanceContext struct {
-	replWithStats                         replicaWithStats
+	candidateReplica                      CandidateReplica
 	rangeDesc                             *roachpb.RangeDescriptor
 	conf                                  roachpb.SpanConfig
 	clusterNodes                          int
@@ -169,26 +169,26 @@ func (rbc *deprecatedRebalanceContext) numDesiredReplicas(
 
 func (sr *StoreRebalancer) deprecatedChooseRangeToRebalance(
 	ctx context.Context,
-	hottestRanges *[]replicaWithStats,
+	hottestRanges *[]CandidateReplica,
 	localDesc *roachpb.StoreDescriptor,
 	storeList storepool.StoreList,
 	storeMap map[roachpb.StoreID]*roachpb.StoreDescriptor,
 	minQPS float64,
 	maxQPS float64,
-) (replWithStats replicaWithStats, voterTargets, nonVoterTargets []roachpb.ReplicationTarget) {
-	now := sr.rq.store.Clock().NowAsClockTimestamp()
+) (candidateReplica CandidateReplica, voterTargets, nonVoterTargets []roachpb.ReplicationTarget) {
+	now := sr.clock.NowAsClockTimestamp()
 	for {
 		if len(*hottestRanges) == 0 {
-			return replicaWithStats{}, nil, nil
+			return nil, nil, nil
 		}
-		replWithStats := (*hottestRanges)[0]
+		candidateReplica := (*hottestRanges)[0]
 		*hottestRanges = (*hottestRanges)[1:]
 
-		if replWithStats.repl == nil {
-			return replicaWithStats{}, nil, nil
+		if candidateReplica == nil {
+			return nil, nil, nil
 		}
 
-		if shouldNotMoveAway(ctx, replWithStats, localDesc, now, minQPS) {
+		if shouldNotMoveAway(ctx, candidateReplica, localDesc, now, minQPS) {
 			continue
 		}
 
@@ -197,13 +197,13 @@ func (sr *StoreRebalancer) deprecatedChooseRangeToRebalance(
 		// just unnecessary churn with no benefit to move ranges responsible for,
 		// for example, 1 qps on a store with 5000 qps.
 		const minQPSFraction = .001
-		if replWithStats.qps < localDesc.Capacity.QueriesPerSecond*minQPSFraction {
+		if candidateReplica.QPS() < localDesc.Capacity.QueriesPerSecond*minQPSFraction {
 			log.VEventf(
 				ctx,
 				5,
 				"r%d's %.2f qps is too little to matter relative to s%d's %.2f total qps",
-				replWithStats.repl.RangeID,
-				replWithStats.qps,
+				candidateReplica.GetRangeID(),
+				candidateReplica.QPS(),
 				localDesc.StoreID,
 				localDesc.Capacity.QueriesPerSecond,
 			)
@@ -211,9 +211,9 @@ func (sr *StoreRebalancer) deprecatedChooseRangeToRebalance(
 		}
 
 		log.VEventf(ctx, 3, "considering replica rebalance for r%d with %.2f qps",
-			replWithStats.repl.GetRangeID(), replWithStats.qps)
-		rangeDesc, conf := replWithStats.repl.DescAndSpanConfig()
-		clusterNodes := sr.rq.allocator.StorePool.ClusterNodeCount()
+			candidateReplica.GetRangeID(), candidateReplica.QPS())
+		rangeDesc, conf := candidateReplica.DescAndSpanConfig()
+		clusterNodes := sr.allocator.StorePool.ClusterNodeCount()
 		numDesiredVoters := allocatorimpl.GetNeededVoters(conf.GetNumVoters(), clusterNodes)
 		numDesiredNonVoters := allocatorimpl.GetNeededNonVoters(
 			numDesiredVoters, int(conf.GetNumNonVoters()), clusterNodes,
@@ -229,7 +229,7 @@ func (sr *StoreRebalancer) deprecatedChooseRangeToRebalance(
 		}
 
 		rebalanceCtx := deprecatedRebalanceContext{
-			replWithStats:       replWithStats,
+			candidateReplica:    candidateReplica,
 			rangeDesc:           rangeDesc,
 			conf:                conf,
 			clusterNodes:        clusterNodes,
@@ -295,7 +295,7 @@ func (sr *StoreRebalancer) deprecatedChooseRangeToRebalance(
 			// in processing its raft log.
 			if replica, ok := rangeDesc.GetReplicaDescriptor(targetVoterRepls[i].StoreID); ok {
 				if raftStatus == nil {
-					raftStatus = sr.getRaftStatusFn(replWithStats.repl)
+					raftStatus = sr.getRaftStatusFn(candidateReplica)
 				}
 				if allocatorimpl.ReplicaIsBehind(raftStatus, replica.ReplicaID) {
 					continue
@@ -309,7 +309,7 @@ func (sr *StoreRebalancer) deprecatedChooseRangeToRebalance(
 			}
 		}
 		targetVoterRepls[0], targetVoterRepls[newLeaseIdx] = targetVoterRepls[newLeaseIdx], targetVoterRepls[0]
-		return replWithStats,
+		return candidateReplica,
 			roachpb.MakeReplicaSet(targetVoterRepls).ReplicationTargets(),
 			roachpb.MakeReplicaSet(targetNonVoterRepls).ReplicationTargets()
 	}
@@ -325,10 +325,10 @@ func (sr *StoreRebalancer) worsensDiversity(
 	onlyVoters bool,
 ) bool {
 	curDiversity := allocatorimpl.RangeDiversityScore(
-		sr.rq.allocator.StorePool.GetLocalitiesByStore(currentRepls),
+		sr.allocator.StorePool.GetLocalitiesByStore(currentRepls),
 	)
 	newDiversity := allocatorimpl.RangeDiversityScore(
-		sr.rq.allocator.StorePool.GetLocalitiesByStore(targetRepls),
+		sr.allocator.StorePool.GetLocalitiesByStore(targetRepls),
 	)
 	replicaStr := "replica"
 	if onlyVoters {
@@ -453,7 +453,7 @@ func (sr *StoreRebalancer) pickRemainingRepls(
 		// Use the preexisting Allocate{Non}Voter logic to ensure that
 		// considerations such as zone constraints, locality diversity, and full
 		// disk come into play.
-		target, _ := sr.rq.allocator.AllocateTargetFromList(
+		target, _ := sr.allocator.AllocateTargetFromList(
 			ctx,
 			storeList,
 			rebalanceCtx.conf,
@@ -478,7 +478,7 @@ func (sr *StoreRebalancer) pickRemainingRepls(
 		if sr.shouldNotMoveTo(
 			ctx,
 			storeMap,
-			rebalanceCtx.replWithStats,
+			rebalanceCtx.candidateReplica,
 			target.StoreID,
 			meanQPS,
 			minQPS,
@@ -571,18 +571,18 @@ func (sr *StoreRebalancer) pickReplsToKeep(
 
 func shouldNotMoveAway(
 	ctx context.Context,
-	replWithStats replicaWithStats,
+	candidateReplica CandidateReplica,
 	localDesc *roachpb.StoreDescriptor,
 	now hlc.ClockTimestamp,
 	minQPS float64,
 ) bool {
-	if !replWithStats.repl.OwnsValidLease(ctx, now) {
-		log.VEventf(ctx, 3, "store doesn't own the lease for r%d", replWithStats.repl.RangeID)
+	if !candidateReplica.OwnsValidLease(ctx, now) {
+		log.VEventf(ctx, 3, "store doesn't own the lease for r%d", candidateReplica.GetRangeID())
 		return true
 	}
-	if localDesc.Capacity.QueriesPerSecond-replWithStats.qps < minQPS {
+	if localDesc.Capacity.QueriesPerSecond-candidateReplica.QPS() < minQPS {
 		log.VEventf(ctx, 3, "moving r%d's %.2f qps would bring s%d below the min threshold (%.2f)",
-			replWithStats.repl.RangeID, replWithStats.qps, localDesc.StoreID, minQPS)
+			candidateReplica.GetRangeID(), candidateReplica.QPS(), localDesc.StoreID, minQPS)
 		return true
 	}
 	return false
@@ -591,7 +591,7 @@ func shouldNotMoveAway(
 func (sr *StoreRebalancer) shouldNotMoveTo(
 	ctx context.Context,
 	storeMap map[roachpb.StoreID]*roachpb.StoreDescriptor,
-	replWithStats replicaWithStats,
+	candidateReplica CandidateReplica,
 	candidateStoreID roachpb.StoreID,
 	meanQPS float64,
 	minQPS float64,
@@ -603,18 +603,18 @@ func (sr *StoreRebalancer) shouldNotMoveTo(
 		return true
 	}
 
-	newCandidateQPS := candidateStore.Capacity.QueriesPerSecond + replWithStats.qps
+	newCandidateQPS := candidateStore.Capacity.QueriesPerSecond + candidateReplica.QPS()
 	if candidateStore.Capacity.QueriesPerSecond < minQPS {
 		if newCandidateQPS > maxQPS {
 			log.VEventf(ctx, 3,
 				"r%d's %.2f qps would push s%d over the max threshold (%.2f) with %.2f qps afterwards",
-				replWithStats.repl.RangeID, replWithStats.qps, candidateStoreID, maxQPS, newCandidateQPS)
+				candidateReplica.GetRangeID(), candidateReplica.QPS(), candidateStoreID, maxQPS, newCandidateQPS)
 			return true
 		}
 	} else if newCandidateQPS > meanQPS {
 		log.VEventf(ctx, 3,
 			"r%d's %.2f qps would push s%d over the mean (%.2f) with %.2f qps afterwards",
-			replWithStats.repl.RangeID, replWithStats.qps, candidateStoreID, meanQPS, newCandidateQPS)
+			candidateReplica.GetRangeID(), candidateReplica.QPS(), candidateStoreID, meanQPS, newCandidateQPS)
 		return true
 	}
 
@@ -622,7 +622,7 @@ func (sr *StoreRebalancer) shouldNotMoveTo(
 	// about node liveness.
 	targetNodeID := candidateStore.Node.NodeID
 	if targetNodeID != sr.rq.store.Ident.NodeID {
-		if !sr.rq.allocator.StorePool.IsStoreReadyForRoutineReplicaTransfer(ctx, candidateStore.StoreID) {
+		if !sr.allocator.StorePool.IsStoreReadyForRoutineReplicaTransfer(ctx, candidateStore.StoreID) {
 			log.VEventf(ctx, 3,
 				"refusing to transfer replica to n%d/s%d", targetNodeID, candidateStore.StoreID)
 			return true
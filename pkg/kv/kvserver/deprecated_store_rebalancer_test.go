@@ -100,11 +100,11 @@ func TestDeprecatedChooseLeaseToTransfer(t *testing.T) {
 	// Rather than trying to populate every Replica with a real raft group in
 	// order to pass replicaIsBehind checks, fake out the function for getting
 	// raft status with one that always returns all replicas as up to date.
-	sr.getRaftStatusFn = func(r *Replica) *raft.Status {
+	sr.getRaftStatusFn = func(r CandidateReplica) *raft.Status {
 		status := &raft.Status{
 			Progress: make(map[uint64]tracker.Progress),
 		}
-		status.Lead = uint64(r.ReplicaID())
+		status.Lead = uint64(r.(rankedReplica).ReplicaID())
 		status.Commit = 1
 		for _, replica := range r.Desc().InternalReplicas {
 			status.Progress[uint64(replica.ReplicaID)] = tracker.Progress{
@@ -145,7 +145,7 @@ func TestDeprecatedChooseLeaseToTransfer(t *testing.T) {
 
 	for _, tc := range testCases {
 		loadRanges(rr, s, []testRange{{voters: tc.storeIDs, qps: tc.qps}})
-		hottestRanges := rr.topQPS()
+		hottestRanges := rankedReplicas(rr.topQPS())
 		_, target, _ := sr.deprecatedChooseLeaseToTransfer(
 			ctx, &hottestRanges, &localDesc, storeList, storeMap, minQPS, maxQPS)
 		if target.StoreID != tc.expectTarget {
@@ -214,11 +214,11 @@ func TestDeprecatedChooseRangeToRebalanceBalanceScore(t *testing.T) {
 	// Rather than trying to populate every Replica with a real raft group in
 	// order to pass replicaIsBehind checks, fake out the function for getting
 	// raft status with one that always returns all replicas as up to date.
-	sr.getRaftStatusFn = func(r *Replica) *raft.Status {
+	sr.getRaftStatusFn = func(r CandidateReplica) *raft.Status {
 		status := &raft.Status{
 			Progress: make(map[uint64]tracker.Progress),
 		}
-		status.Lead = uint64(r.ReplicaID())
+		status.Lead = uint64(r.(rankedReplica).ReplicaID())
 		status.Commit = 1
 		for _, replica := range r.Desc().InternalReplicas {
 			status.Progress[uint64(replica.ReplicaID)] = tracker.Progress{
@@ -237,14 +237,14 @@ func TestDeprecatedChooseRangeToRebalanceBalanceScore(t *testing.T) {
 	const qps = float64(50)
 	s.cfg.DefaultSpanConfig.NumReplicas = int32(len(voters))
 	loadRanges(rr, s, []testRange{{voters: voters, qps: qps}})
-	hottestRanges := rr.topQPS()
+	hottestRanges := rankedReplicas(rr.topQPS())
 	_, voterTargets, _ := sr.deprecatedChooseRangeToRebalance(
 		ctx, &hottestRanges, &localDesc, storeList, storeMap, minQPS, maxQPS,
 	)
 
 	require.Len(t, voterTargets, len(expectedRebalancedVoters))
 	if len(voterTargets) > 0 && voterTargets[0].StoreID != expectedRebalancedVoters[0] {
-		t.Errorf("chooseRangeToRebalance(existing=%v, qps=%f) chose s%v as leaseholder; want s%v",
+		t.Errorf("ChooseRangeToRebalance(existing=%v, qps=%f) chose s%v as leaseholder; want s%v",
 			voters, qps, voterTargets[0], expectedRebalancedVoters[0])
 	}
 
@@ -285,11 +285,11 @@ func TestDeprecatedChooseRangeToRebalance(t *testing.T) {
 	// Rather than trying to populate every Replica with a real raft group in
 	// order to pass replicaIsBehind checks, fake out the function for getting
 	// raft status with one that always returns all replicas as up to date.
-	sr.getRaftStatusFn = func(r *Replica) *raft.Status {
+	sr.getRaftStatusFn = func(r CandidateReplica) *raft.Status {
 		status := &raft.Status{
 			Progress: make(map[uint64]tracker.Progress),
 		}
-		status.Lead = uint64(r.ReplicaID())
+		status.Lead = uint64(r.(rankedReplica).ReplicaID())
 		status.Commit = 1
 		for _, replica := range r.Desc().InternalReplicas {
 			status.Progress[uint64(replica.ReplicaID)] = tracker.Progress{
@@ -588,14 +588,14 @@ func TestDeprecatedChooseRangeToRebalance(t *testing.T) {
 					{voters: tc.voters, nonVoters: tc.nonVoters, qps: tc.qps},
 				},
 			)
-			hottestRanges := rr.topQPS()
+			hottestRanges := rankedReplicas(rr.topQPS())
 			_, voterTargets, nonVoterTargets := sr.deprecatedChooseRangeToRebalance(
 				ctx, &hottestRanges, &localDesc, storeList, storeMap, minQPS, maxQPS,
 			)
 
 			require.Len(t, voterTargets, len(tc.expectedRebalancedVoters))
 			if len(voterTargets) > 0 && voterTargets[0].StoreID != tc.expectedRebalancedVoters[0] {
-				t.Errorf("chooseRangeToRebalance(existing=%v, qps=%f) chose s%d as leaseholder; want s%v",
+				t.Errorf("ChooseRangeToRebalance(existing=%v, qps=%f) chose s%d as leaseholder; want s%v",
 					tc.voters, tc.qps, voterTargets[0], tc.expectedRebalancedVoters[0])
 			}
 
@@ -642,16 +642,16 @@ func TestDeprecatedNoLeaseTransferToBehindReplicas(t *testing.T) {
 	// Load in a range with replicas on an overfull node, a slightly underfull
 	// node, and a very underfull node.
 	loadRanges(rr, s, []testRange{{voters: []roachpb.StoreID{1, 4, 5}, qps: 100}})
-	hottestRanges := rr.topQPS()
-	repl := hottestRanges[0].repl
+	hottestRanges := rankedReplicas(rr.topQPS())
+	repl := hottestRanges[0]
 
 	// Set up a fake RaftStatus that indicates s5 is behind (but all other stores
 	// are caught up). We thus shouldn't transfer a lease to s5.
-	sr.getRaftStatusFn = func(r *Replica) *raft.Status {
+	sr.getRaftStatusFn = func(r CandidateReplica) *raft.Status {
 		status := &raft.Status{
 			Progress: make(map[uint64]tracker.Progress),
 		}
-		status.Lead = uint64(r.ReplicaID())
+		status.Lead = uint64(r.(rankedReplica).ReplicaID())
 		status.Commit = 1
 		for _, replica := range r.Desc().InternalReplicas {
 			match := uint64(1)
@@ -679,8 +679,8 @@ func TestDeprecatedNoLeaseTransferToBehindReplicas(t *testing.T) {
 	// that's behind, and see how a new replica is preferred as the leaseholder
 	// over it.
 	loadRanges(rr, s, []testRange{{voters: []roachpb.StoreID{1, 3, 5}, qps: 100}})
-	hottestRanges = rr.topQPS()
-	repl = hottestRanges[0].repl
+	hottestRanges = rankedReplicas(rr.topQPS())
+	repl = hottestRanges[0]
 
 	_, targets, _ := sr.deprecatedChooseRangeToRebalance(
 		ctx, &hottestRanges, &localDesc, storeList, storeMap, minQPS, maxQPS)
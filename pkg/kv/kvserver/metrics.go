@@ -1259,6 +1259,54 @@ throttled they do count towards 'delay.total' and 'delay.enginebackpressure'.
 		Measurement: "Encryption At Rest",
 		Unit:        metric.Unit_CONST,
 	}
+	metaEncryptionTotalFiles = metric.Metadata{
+		Name:        "rocksdb.encryption.total-files",
+		Help:        "Number of files of an encrypted store",
+		Measurement: "Files",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaEncryptionTotalBytes = metric.Metadata{
+		Name:        "rocksdb.encryption.total-bytes",
+		Help:        "Size of files of an encrypted store",
+		Measurement: "Storage",
+		Unit:        metric.Unit_BYTES,
+	}
+	metaEncryptionActiveKeyFiles = metric.Metadata{
+		Name:        "rocksdb.encryption.active-key-files",
+		Help:        "Number of files encrypted with the active data key",
+		Measurement: "Files",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaEncryptionActiveKeyBytes = metric.Metadata{
+		Name:        "rocksdb.encryption.active-key-bytes",
+		Help:        "Size of files encrypted with the active data key",
+		Measurement: "Storage",
+		Unit:        metric.Unit_BYTES,
+	}
+	metaEncryptionReencryptionRunning = metric.Metadata{
+		Name:        "rocksdb.encryption.reencryption.running",
+		Help:        "1 while files not encrypted with the active data key are being rewritten, 0 otherwise",
+		Measurement: "Encryption At Rest",
+		Unit:        metric.Unit_CONST,
+	}
+	metaEncryptionReencryptedFiles = metric.Metadata{
+		Name:        "rocksdb.encryption.reencryption.rewritten-files",
+		Help:        "Number of files rewritten with the active data key since the store was opened",
+		Measurement: "Files",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaEncryptionReencryptedBytes = metric.Metadata{
+		Name:        "rocksdb.encryption.reencryption.rewritten-bytes",
+		Help:        "Size of files rewritten with the active data key since the store was opened",
+		Measurement: "Storage",
+		Unit:        metric.Unit_BYTES,
+	}
+	metaEncryptionReencryptionRemainingFiles = metric.Metadata{
+		Name:        "rocksdb.encryption.reencryption.remaining-files",
+		Help:        "Number of files not encrypted with the active data key after the last rewrite, because they cannot be rewritten while the store is open",
+		Measurement: "Files",
+		Unit:        metric.Unit_COUNT,
+	}
 
 	// Concurrency control metrics.
 	metaConcurrencyLocks = metric.Metadata{
@@ -1560,6 +1608,18 @@ type StoreMetrics struct {
 	// Encryption-at-rest stats.
 	// EncryptionAlgorithm is an enum representing the cipher in use, so we use a gauge.
 	EncryptionAlgorithm *metric.Gauge
+	// The following track how much of the store is encrypted with the active
+	// data key, the remainder still being encrypted with older data keys.
+	EncryptionTotalFiles     *metric.Gauge
+	EncryptionTotalBytes     *metric.Gauge
+	EncryptionActiveKeyFiles *metric.Gauge
+	EncryptionActiveKeyBytes *metric.Gauge
+	// The following track the progress of the rewrite of the files that are
+	// not encrypted with the active data key.
+	EncryptionReencryptionRunning        *metric.Gauge
+	EncryptionReencryptedFiles           *metric.Gauge
+	EncryptionReencryptedBytes           *metric.Gauge
+	EncryptionReencryptionRemainingFiles *metric.Gauge
 
 	// RangeFeed counts.
 	RangeFeedMetrics *rangefeed.Metrics
@@ -2020,7 +2080,16 @@ func newStoreMetrics(histogramWindow time.Duration) *StoreMetrics {
 		ExportRequestProposalTotalDelay: metric.NewCounter(metaExportEvalTotalDelay),
 
 		// Encryption-at-rest.
-		EncryptionAlgorithm: metric.NewGauge(metaEncryptionAlgorithm),
+		EncryptionAlgorithm:           metric.NewGauge(metaEncryptionAlgorithm),
+		EncryptionTotalFiles:          metric.NewGauge(metaEncryptionTotalFiles),
+		EncryptionTotalBytes:          metric.NewGauge(metaEncryptionTotalBytes),
+		EncryptionActiveKeyFiles:      metric.NewGauge(metaEncryptionActiveKeyFiles),
+		EncryptionActiveKeyBytes:      metric.NewGauge(metaEncryptionActiveKeyBytes),
+		EncryptionReencryptionRunning: metric.NewGauge(metaEncryptionReencryptionRunning),
+		EncryptionReencryptedFiles:    metric.NewGauge(metaEncryptionReencryptedFiles),
+		EncryptionReencryptedBytes:    metric.NewGauge(metaEncryptionReencryptedBytes),
+		EncryptionReencryptionRemainingFiles: metric.NewGauge(
+			metaEncryptionReencryptionRemainingFiles),
 
 		// RangeFeed counters.
 		RangeFeedMetrics: rangefeed.NewMetrics(),
@@ -2119,6 +2188,18 @@ func (sm *StoreMetrics) updateEngineMetrics(m storage.Metrics) {
 
 func (sm *StoreMetrics) updateEnvStats(stats storage.EnvStats) {
 	sm.EncryptionAlgorithm.Update(int64(stats.EncryptionType))
+	sm.EncryptionTotalFiles.Update(int64(stats.TotalFiles))
+	sm.EncryptionTotalBytes.Update(int64(stats.TotalBytes))
+	sm.EncryptionActiveKeyFiles.Update(int64(stats.ActiveKeyFiles))
+	sm.EncryptionActiveKeyBytes.Update(int64(stats.ActiveKeyBytes))
+	var reencryptionRunning int64
+	if stats.Reencryption.Running {
+		reencryptionRunning = 1
+	}
+	sm.EncryptionReencryptionRunning.Update(reencryptionRunning)
+	sm.EncryptionReencryptedFiles.Update(int64(stats.Reencryption.RewrittenFiles))
+	sm.EncryptionReencryptedBytes.Update(int64(stats.Reencryption.RewrittenBytes))
+	sm.EncryptionReencryptionRemainingFiles.Update(int64(stats.Reencryption.RemainingFiles))
 }
 
 func (sm *StoreMetrics) handleMetricsResult(ctx context.Context, metric result.Metrics) {
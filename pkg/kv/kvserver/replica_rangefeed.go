@@ -398,7 +398,9 @@ func (r *Replica) registerWithRangefeedRaftMuLocked(
 			LowerBound: lowerBound,
 			UpperBound: upperBound,
 		})
-		return rangefeed.NewSeparatedIntentScanner(iter)
+		// Shared locks do not carry provisional values, so they do not hold
+		// back the resolved timestamp.
+		return rangefeed.NewSeparatedIntentScanner(storage.NewIntentLockTableIterator(iter))
 	}
 
 	// NB: This only errors if the stopper is stopping, and we have to return here
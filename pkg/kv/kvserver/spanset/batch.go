@@ -491,6 +491,11 @@ func (s spanSetReader) PinEngineStateForIterators() error {
 	return s.r.PinEngineStateForIterators()
 }
 
+// SharedLocksMayExist implements the storage.Reader interface.
+func (s spanSetReader) SharedLocksMayExist() bool {
+	return s.r.SharedLocksMayExist()
+}
+
 type spanSetWriter struct {
 	w     storage.Writer
 	spans *SpanSet
@@ -542,6 +547,13 @@ func (s spanSetWriter) ClearIntent(
 	return s.w.ClearIntent(key, txnDidNotUpdateMeta, txnUUID)
 }
 
+func (s spanSetWriter) ClearSharedLock(key roachpb.Key, txnUUID uuid.UUID) error {
+	if err := s.checkAllowed(key); err != nil {
+		return err
+	}
+	return s.w.ClearSharedLock(key, txnUUID)
+}
+
 func (s spanSetWriter) ClearEngineKey(key storage.EngineKey) error {
 	if !s.spansOnly {
 		panic("cannot do timestamp checking for clearing EngineKey")
@@ -635,6 +647,13 @@ func (s spanSetWriter) PutIntent(
 	return s.w.PutIntent(ctx, key, value, txnUUID)
 }
 
+func (s spanSetWriter) PutSharedLock(key roachpb.Key, value []byte, txnUUID uuid.UUID) error {
+	if err := s.checkAllowed(key); err != nil {
+		return err
+	}
+	return s.w.PutSharedLock(key, value, txnUUID)
+}
+
 func (s spanSetWriter) PutEngineKey(key storage.EngineKey, value []byte) error {
 	if !s.spansOnly {
 		panic("cannot do timestamp checking for putting EngineKey")
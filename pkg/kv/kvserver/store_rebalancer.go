@@ -20,10 +20,12 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/allocator"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/allocator/allocatorimpl"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/allocator/storepool"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/replicastats"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/settings"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/util/contextutil"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/metric"
 	"github.com/cockroachdb/cockroach/pkg/util/stop"
@@ -106,7 +108,72 @@ type StoreRebalancer struct {
 	st              *cluster.Settings
 	rq              *replicateQueue
 	replRankings    *replicaRankings
-	getRaftStatusFn func(replica *Replica) *raft.Status
+	allocator       allocatorimpl.Allocator
+	clock           *hlc.Clock
+	getRaftStatusFn func(replica CandidateReplica) *raft.Status
+}
+
+// CandidateReplica is a replica whose lease or range the StoreRebalancer
+// considers moving away from the local store. It is implemented by the
+// replicas of the store, and by the replicas of the allocation simulator.
+type CandidateReplica interface {
+	// OwnsValidLease returns whether the replica holds a valid lease at the
+	// given timestamp.
+	OwnsValidLease(ctx context.Context, now hlc.ClockTimestamp) bool
+	// StoreID returns the ID of the store of the replica.
+	StoreID() roachpb.StoreID
+	// GetRangeID returns the ID of the range of the replica.
+	GetRangeID() roachpb.RangeID
+	// RaftStatus returns the raft status of the replica, or nil if it isn't
+	// the raft leader.
+	RaftStatus() *raft.Status
+	// Desc returns the descriptor of the range of the replica.
+	Desc() *roachpb.RangeDescriptor
+	// DescAndSpanConfig returns the descriptor of the range of the replica,
+	// along with its span config.
+	DescAndSpanConfig() (*roachpb.RangeDescriptor, roachpb.SpanConfig)
+	// LeaseholderStats returns the stats of the requests served by the
+	// leaseholder of the range.
+	LeaseholderStats() *replicastats.ReplicaStats
+	// RangeUsageInfo returns the usage info of the range.
+	RangeUsageInfo() allocator.RangeUsageInfo
+	// QPS returns the QPS of the range, as used to rank the replicas of the
+	// store.
+	QPS() float64
+}
+
+// rankedReplica is a CandidateReplica backed by a replica of the local store,
+// along with the QPS recorded for it by the replica rankings.
+type rankedReplica struct {
+	*Replica
+	qps float64
+}
+
+var _ CandidateReplica = rankedReplica{}
+
+// LeaseholderStats is part of the CandidateReplica interface.
+func (rr rankedReplica) LeaseholderStats() *replicastats.ReplicaStats {
+	return rr.leaseholderStats
+}
+
+// RangeUsageInfo is part of the CandidateReplica interface.
+func (rr rankedReplica) RangeUsageInfo() allocator.RangeUsageInfo {
+	return rangeUsageInfoForRepl(rr.Replica)
+}
+
+// QPS is part of the CandidateReplica interface.
+func (rr rankedReplica) QPS() float64 {
+	return rr.qps
+}
+
+// rankedReplicas returns the replicas of the rankings as candidates for the
+// StoreRebalancer.
+func rankedReplicas(repls []replicaWithStats) []CandidateReplica {
+	candidates := make([]CandidateReplica, len(repls))
+	for i := range repls {
+		candidates[i] = rankedReplica{Replica: repls[i].repl, qps: repls[i].qps}
+	}
+	return candidates
 }
 
 // NewStoreRebalancer creates a StoreRebalancer to work in tandem with the
@@ -123,7 +190,9 @@ func NewStoreRebalancer(
 		st:             st,
 		rq:             rq,
 		replRankings:   replRankings,
-		getRaftStatusFn: func(replica *Replica) *raft.Status {
+		allocator:      rq.allocator,
+		clock:          rq.store.Clock(),
+		getRaftStatusFn: func(replica CandidateReplica) *raft.Status {
 			return replica.RaftStatus()
 		},
 	}
@@ -132,6 +201,30 @@ func NewStoreRebalancer(
 	return sr
 }
 
+// NewSimulatorStoreRebalancer creates a StoreRebalancer for the allocation
+// simulator. It isn't attached to a store, so it can only be used to make
+// decisions with ChooseLeaseToTransfer and ChooseRangeToRebalance, which the
+// simulator then applies to its own state.
+func NewSimulatorStoreRebalancer(
+	ambientCtx log.AmbientContext,
+	st *cluster.Settings,
+	alloc allocatorimpl.Allocator,
+	clock *hlc.Clock,
+) *StoreRebalancer {
+	sr := &StoreRebalancer{
+		AmbientContext: ambientCtx,
+		metrics:        makeStoreRebalancerMetrics(),
+		st:             st,
+		allocator:      alloc,
+		clock:          clock,
+		getRaftStatusFn: func(replica CandidateReplica) *raft.Status {
+			return replica.RaftStatus()
+		},
+	}
+	sr.AddLogTag("store-rebalancer", nil)
+	return sr
+}
+
 // Start runs an infinite loop in a goroutine which regularly checks whether
 // the store is overloaded along any important dimension (e.g. range count,
 // QPS, disk usage), and if so attempts to correct that by moving leases or
@@ -184,7 +277,7 @@ func (sr *StoreRebalancer) Start(ctx context.Context, stopper *stop.Stopper) {
 // balance.
 func (sr *StoreRebalancer) scorerOptions(ctx context.Context) *allocatorimpl.QPSScorerOptions {
 	return &allocatorimpl.QPSScorerOptions{
-		StoreHealthOptions:    sr.rq.allocator.StoreHealthOptions(ctx),
+		StoreHealthOptions:    sr.allocator.StoreHealthOptions(ctx),
 		Deterministic:         sr.rq.store.cfg.StorePool.Deterministic,
 		QPSRebalanceThreshold: allocator.QPSRebalanceThreshold.Get(&sr.st.SV),
 		MinRequiredQPSDiff:    allocator.MinQPSDifferenceForTransfers.Get(&sr.st.SV),
@@ -226,16 +319,16 @@ func (sr *StoreRebalancer) rebalanceStore(
 		return
 	}
 
-	var replicasToMaybeRebalance []replicaWithStats
+	var replicasToMaybeRebalance []CandidateReplica
 	storeMap := allStoresList.ToMap()
 
 	// First check if we should transfer leases away to better balance QPS.
 	log.Infof(ctx,
 		"considering load-based lease transfers for s%d with %.2f qps (mean=%.2f, upperThreshold=%.2f)",
 		localDesc.StoreID, localDesc.Capacity.QueriesPerSecond, allStoresList.CandidateQueriesPerSecond.Mean, qpsMaxThreshold)
-	hottestRanges := sr.replRankings.topQPS()
+	hottestRanges := rankedReplicas(sr.replRankings.topQPS())
 	for localDesc.Capacity.QueriesPerSecond > qpsMaxThreshold {
-		replWithStats, target, considerForRebalance := sr.chooseLeaseToTransfer(
+		candidateReplica, target, considerForRebalance := sr.ChooseLeaseToTransfer(
 			ctx,
 			&hottestRanges,
 			localDesc,
@@ -244,13 +337,14 @@ func (sr *StoreRebalancer) rebalanceStore(
 			sr.scorerOptions(ctx),
 		)
 		replicasToMaybeRebalance = append(replicasToMaybeRebalance, considerForRebalance...)
-		if replWithStats.repl == nil {
+		if candidateReplica == nil {
 			break
 		}
 
-		timeout := sr.rq.processTimeoutFunc(sr.st, replWithStats.repl)
+		repl := candidateReplica.(rankedReplica).Replica
+		timeout := sr.rq.processTimeoutFunc(sr.st, repl)
 		if err := contextutil.RunWithTimeout(ctx, "transfer lease", timeout, func(ctx context.Context) error {
-			return sr.rq.transferLease(ctx, replWithStats.repl, target, replWithStats.qps)
+			return sr.rq.transferLease(ctx, repl, target, candidateReplica.QPS())
 		}); err != nil {
 			log.Errorf(ctx, "unable to transfer lease to s%d: %+v", target.StoreID, err)
 			continue
@@ -261,10 +355,10 @@ func (sr *StoreRebalancer) rebalanceStore(
 		// additional transfers are needed we'll be making the decisions with more
 		// up-to-date info. The StorePool copies are updated by transferLease.
 		localDesc.Capacity.LeaseCount--
-		localDesc.Capacity.QueriesPerSecond -= replWithStats.qps
+		localDesc.Capacity.QueriesPerSecond -= candidateReplica.QPS()
 		if otherDesc := storeMap[target.StoreID]; otherDesc != nil {
 			otherDesc.Capacity.LeaseCount++
-			otherDesc.Capacity.QueriesPerSecond += replWithStats.qps
+			otherDesc.Capacity.QueriesPerSecond += candidateReplica.QPS()
 		}
 	}
 
@@ -290,34 +384,34 @@ func (sr *StoreRebalancer) rebalanceStore(
 	replicasToMaybeRebalance = append(replicasToMaybeRebalance, hottestRanges...)
 
 	for localDesc.Capacity.QueriesPerSecond > qpsMaxThreshold {
-		replWithStats, voterTargets, nonVoterTargets := sr.chooseRangeToRebalance(
+		candidateReplica, voterTargets, nonVoterTargets := sr.ChooseRangeToRebalance(
 			ctx,
 			&replicasToMaybeRebalance,
 			localDesc,
 			allStoresList,
 			sr.scorerOptions(ctx),
 		)
-		if replWithStats.repl == nil {
+		if candidateReplica == nil {
 			log.Infof(ctx,
 				"ran out of replicas worth transferring and qps (%.2f) is still above desired threshold (%.2f); will check again soon",
 				localDesc.Capacity.QueriesPerSecond, qpsMaxThreshold)
 			return
 		}
 
-		descBeforeRebalance := replWithStats.repl.Desc()
+		descBeforeRebalance := candidateReplica.Desc()
 		log.VEventf(
 			ctx,
 			1,
 			"rebalancing r%d (%.2f qps) to better balance load: voters from %v to %v; non-voters from %v to %v",
-			replWithStats.repl.RangeID,
-			replWithStats.qps,
+			candidateReplica.GetRangeID(),
+			candidateReplica.QPS(),
 			descBeforeRebalance.Replicas().Voters(),
 			voterTargets,
 			descBeforeRebalance.Replicas().NonVoters(),
 			nonVoterTargets,
 		)
 
-		timeout := sr.rq.processTimeoutFunc(sr.st, replWithStats.repl)
+		timeout := sr.rq.processTimeoutFunc(sr.st, candidateReplica.(rankedReplica).Replica)
 		if err := contextutil.RunWithTimeout(ctx, "relocate range", timeout, func(ctx context.Context) error {
 			return sr.rq.store.DB().AdminRelocateRange(
 				ctx,
@@ -346,13 +440,13 @@ func (sr *StoreRebalancer) rebalanceStore(
 			}
 		}
 		localDesc.Capacity.LeaseCount--
-		localDesc.Capacity.QueriesPerSecond -= replWithStats.qps
+		localDesc.Capacity.QueriesPerSecond -= candidateReplica.QPS()
 		for i := range voterTargets {
 			if storeDesc := storeMap[voterTargets[i].StoreID]; storeDesc != nil {
 				storeDesc.Capacity.RangeCount++
 				if i == 0 {
 					storeDesc.Capacity.LeaseCount++
-					storeDesc.Capacity.QueriesPerSecond += replWithStats.qps
+					storeDesc.Capacity.QueriesPerSecond += candidateReplica.QPS()
 				}
 			}
 		}
@@ -363,14 +457,20 @@ func (sr *StoreRebalancer) rebalanceStore(
 		localDesc.StoreID, localDesc.Capacity.QueriesPerSecond, allStoresList.CandidateQueriesPerSecond.Mean, qpsMaxThreshold)
 }
 
-func (sr *StoreRebalancer) chooseLeaseToTransfer(
+// ChooseLeaseToTransfer consumes the hottest ranges of the local store, in
+// order, until it finds one whose lease can be transferred to another store to
+// better balance QPS. It returns the replica of that range and the target of
+// the transfer, along with the ranges it skipped that should be considered for
+// replica rebalancing instead. It returns a nil replica if there are no more
+// leases worth transferring.
+func (sr *StoreRebalancer) ChooseLeaseToTransfer(
 	ctx context.Context,
-	hottestRanges *[]replicaWithStats,
+	hottestRanges *[]CandidateReplica,
 	localDesc *roachpb.StoreDescriptor,
 	allStoresList storepool.StoreList,
 	storeMap map[roachpb.StoreID]*roachpb.StoreDescriptor,
 	options *allocatorimpl.QPSScorerOptions,
-) (replicaWithStats, roachpb.ReplicaDescriptor, []replicaWithStats) {
+) (CandidateReplica, roachpb.ReplicaDescriptor, []CandidateReplica) {
 	// NB: Don't switch over to the new locality-aware lease transfer scheme until
 	// the cluster version is finalized.
 	if !sr.st.Version.IsActive(ctx, clusterversion.EnableNewStoreRebalancer) {
@@ -389,22 +489,22 @@ func (sr *StoreRebalancer) chooseLeaseToTransfer(
 		)
 	}
 
-	var considerForRebalance []replicaWithStats
-	now := sr.rq.store.Clock().NowAsClockTimestamp()
+	var considerForRebalance []CandidateReplica
+	now := sr.clock.NowAsClockTimestamp()
 	for {
 		if len(*hottestRanges) == 0 {
-			return replicaWithStats{}, roachpb.ReplicaDescriptor{}, considerForRebalance
+			return nil, roachpb.ReplicaDescriptor{}, considerForRebalance
 		}
-		replWithStats := (*hottestRanges)[0]
+		candidateReplica := (*hottestRanges)[0]
 		*hottestRanges = (*hottestRanges)[1:]
 
 		// We're all out of replicas.
-		if replWithStats.repl == nil {
-			return replicaWithStats{}, roachpb.ReplicaDescriptor{}, considerForRebalance
+		if candidateReplica == nil {
+			return nil, roachpb.ReplicaDescriptor{}, considerForRebalance
 		}
 
-		if !replWithStats.repl.OwnsValidLease(ctx, now) {
-			log.VEventf(ctx, 3, "store doesn't own the lease for r%d", replWithStats.repl.RangeID)
+		if !candidateReplica.OwnsValidLease(ctx, now) {
+			log.VEventf(ctx, 3, "store doesn't own the lease for r%d", candidateReplica.GetRangeID())
 			continue
 		}
 
@@ -412,15 +512,15 @@ func (sr *StoreRebalancer) chooseLeaseToTransfer(
 		// store's QPS. It's just unnecessary churn with no benefit to move leases
 		// responsible for, for example, 1 qps on a store with 5000 qps.
 		const minQPSFraction = .001
-		if replWithStats.qps < localDesc.Capacity.QueriesPerSecond*minQPSFraction {
+		if candidateReplica.QPS() < localDesc.Capacity.QueriesPerSecond*minQPSFraction {
 			log.VEventf(ctx, 3, "r%d's %.2f qps is too little to matter relative to s%d's %.2f total qps",
-				replWithStats.repl.RangeID, replWithStats.qps, localDesc.StoreID, localDesc.Capacity.QueriesPerSecond)
+				candidateReplica.GetRangeID(), candidateReplica.QPS(), localDesc.StoreID, localDesc.Capacity.QueriesPerSecond)
 			continue
 		}
 
-		desc, conf := replWithStats.repl.DescAndSpanConfig()
+		desc, conf := candidateReplica.DescAndSpanConfig()
 		log.VEventf(ctx, 3, "considering lease transfer for r%d with %.2f qps",
-			desc.RangeID, replWithStats.qps)
+			desc.RangeID, candidateReplica.QPS())
 
 		// Check all the other voting replicas in order of increasing qps.
 		// Learners or non-voters aren't allowed to become leaseholders or raft
@@ -431,14 +531,14 @@ func (sr *StoreRebalancer) chooseLeaseToTransfer(
 		// avoid hurting QPS in the short term. This is a stronger check than what
 		// `TransferLeaseTarget` performs (it only excludes replicas that are
 		// waiting for a snapshot).
-		candidates = allocatorimpl.FilterBehindReplicas(ctx, sr.getRaftStatusFn(replWithStats.repl), candidates)
+		candidates = allocatorimpl.FilterBehindReplicas(ctx, sr.getRaftStatusFn(candidateReplica), candidates)
 
-		candidate := sr.rq.allocator.TransferLeaseTarget(
+		candidate := sr.allocator.TransferLeaseTarget(
 			ctx,
 			conf,
 			candidates,
-			replWithStats.repl,
-			replWithStats.repl.leaseholderStats,
+			candidateReplica,
+			candidateReplica.LeaseholderStats(),
 			true, /* forceDecisionWithoutStats */
 			allocator.TransferLeaseOptions{
 				Goal: allocator.QPSConvergence,
@@ -452,25 +552,25 @@ func (sr *StoreRebalancer) chooseLeaseToTransfer(
 				"could not find a better lease transfer target for r%d; considering replica rebalance instead",
 				desc.RangeID,
 			)
-			considerForRebalance = append(considerForRebalance, replWithStats)
+			considerForRebalance = append(considerForRebalance, candidateReplica)
 			continue
 		}
 
 		filteredStoreList := allStoresList.ExcludeInvalid(conf.Constraints)
 		filteredStoreList = allStoresList.ExcludeInvalid(conf.VoterConstraints)
-		if sr.rq.allocator.FollowTheWorkloadPrefersLocal(
+		if sr.allocator.FollowTheWorkloadPrefersLocal(
 			ctx,
 			filteredStoreList,
 			*localDesc,
 			candidate.StoreID,
 			candidates,
-			replWithStats.repl.leaseholderStats,
+			candidateReplica.LeaseholderStats(),
 		) {
 			log.VEventf(
 				ctx, 3, "r%d is on s%d due to follow-the-workload; considering replica rebalance instead",
 				desc.RangeID, localDesc.StoreID,
 			)
-			considerForRebalance = append(considerForRebalance, replWithStats)
+			considerForRebalance = append(considerForRebalance, candidateReplica)
 			continue
 		}
 		if targetStore, ok := storeMap[candidate.StoreID]; ok {
@@ -479,14 +579,14 @@ func (sr *StoreRebalancer) chooseLeaseToTransfer(
 				1,
 				"transferring lease for r%d (qps=%.2f) to store s%d (qps=%.2f) from local store s%d (qps=%.2f)",
 				desc.RangeID,
-				replWithStats.qps,
+				candidateReplica.QPS(),
 				targetStore.StoreID,
 				targetStore.Capacity.QueriesPerSecond,
 				localDesc.StoreID,
 				localDesc.Capacity.QueriesPerSecond,
 			)
 		}
-		return replWithStats, candidate, considerForRebalance
+		return candidateReplica, candidate, considerForRebalance
 	}
 }
 
@@ -494,18 +594,23 @@ func (sr *StoreRebalancer) chooseLeaseToTransfer(
 // the state of the cluster during the StoreRebalancer's attempt to rebalance it
 // based on QPS.
 type rangeRebalanceContext struct {
-	replWithStats replicaWithStats
-	rangeDesc     *roachpb.RangeDescriptor
-	conf          roachpb.SpanConfig
+	candidateReplica CandidateReplica
+	rangeDesc        *roachpb.RangeDescriptor
+	conf             roachpb.SpanConfig
 }
 
-func (sr *StoreRebalancer) chooseRangeToRebalance(
+// ChooseRangeToRebalance consumes the hottest ranges of the local store, in
+// order, until it finds one whose replicas can be moved to better balance
+// QPS. It returns the replica of that range and the new sets of voters and
+// non-voters of the range, with the voter that should get the lease first.
+// It returns a nil replica if there are no more ranges worth rebalancing.
+func (sr *StoreRebalancer) ChooseRangeToRebalance(
 	ctx context.Context,
-	hottestRanges *[]replicaWithStats,
+	hottestRanges *[]CandidateReplica,
 	localDesc *roachpb.StoreDescriptor,
 	allStoresList storepool.StoreList,
 	options *allocatorimpl.QPSScorerOptions,
-) (replWithStats replicaWithStats, voterTargets, nonVoterTargets []roachpb.ReplicationTarget) {
+) (candidateReplica CandidateReplica, voterTargets, nonVoterTargets []roachpb.ReplicationTarget) {
 	// NB: Don't switch over to the locality aware rebalancer until the cluster
 	// version is finalized.
 	if !sr.st.Version.IsActive(ctx, clusterversion.EnableNewStoreRebalancer) {
@@ -527,37 +632,37 @@ func (sr *StoreRebalancer) chooseRangeToRebalance(
 		)
 	}
 
-	now := sr.rq.store.Clock().NowAsClockTimestamp()
+	now := sr.clock.NowAsClockTimestamp()
 	for {
 		if len(*hottestRanges) == 0 {
-			return replicaWithStats{}, nil, nil
+			return nil, nil, nil
 		}
-		replWithStats := (*hottestRanges)[0]
+		candidateReplica := (*hottestRanges)[0]
 		*hottestRanges = (*hottestRanges)[1:]
 
-		if replWithStats.repl == nil {
-			return replicaWithStats{}, nil, nil
+		if candidateReplica == nil {
+			return nil, nil, nil
 		}
 
 		// Don't bother moving ranges whose QPS is below some small fraction of the
 		// store's QPS. It's just unnecessary churn with no benefit to move ranges
 		// responsible for, for example, 1 qps on a store with 5000 qps.
 		const minQPSFraction = .001
-		if replWithStats.qps < localDesc.Capacity.QueriesPerSecond*minQPSFraction {
+		if candidateReplica.QPS() < localDesc.Capacity.QueriesPerSecond*minQPSFraction {
 			log.VEventf(
 				ctx,
 				5,
 				"r%d's %.2f qps is too little to matter relative to s%d's %.2f total qps",
-				replWithStats.repl.RangeID,
-				replWithStats.qps,
+				candidateReplica.GetRangeID(),
+				candidateReplica.QPS(),
 				localDesc.StoreID,
 				localDesc.Capacity.QueriesPerSecond,
 			)
 			continue
 		}
 
-		rangeDesc, conf := replWithStats.repl.DescAndSpanConfig()
-		clusterNodes := sr.rq.allocator.StorePool.ClusterNodeCount()
+		rangeDesc, conf := candidateReplica.DescAndSpanConfig()
+		clusterNodes := sr.allocator.StorePool.ClusterNodeCount()
 		numDesiredVoters := allocatorimpl.GetNeededVoters(conf.GetNumVoters(), clusterNodes)
 		numDesiredNonVoters := allocatorimpl.GetNeededNonVoters(numDesiredVoters, int(conf.GetNumNonVoters()), clusterNodes)
 		if expected, actual := numDesiredVoters, len(rangeDesc.Replicas().VoterDescriptors()); expected != actual {
@@ -583,14 +688,14 @@ func (sr *StoreRebalancer) chooseRangeToRebalance(
 			continue
 		}
 		rebalanceCtx := rangeRebalanceContext{
-			replWithStats: replWithStats,
-			rangeDesc:     rangeDesc,
-			conf:          conf,
+			candidateReplica: candidateReplica,
+			rangeDesc:        rangeDesc,
+			conf:             conf,
 		}
 
 		// We ascribe the leaseholder's QPS to every follower replica. The store
 		// rebalancer first attempts to transfer the leases of its hot ranges away
-		// in `chooseLeaseToTransfer`. If it cannot move enough leases away to bring
+		// in `ChooseLeaseToTransfer`. If it cannot move enough leases away to bring
 		// down the store's QPS below the cluster-level overfullness threshold, it
 		// moves on to rebalancing replicas. In other words, for every hot range on
 		// the store, the StoreRebalancer first tries moving the load away to one of
@@ -601,10 +706,10 @@ func (sr *StoreRebalancer) chooseRangeToRebalance(
 		// Thus, we ideally want to base our replica rebalancing on the assumption
 		// that all of the load from the leaseholder's replica is going to shift to
 		// the new store that we end up rebalancing to.
-		options.QPSPerReplica = replWithStats.qps
+		options.QPSPerReplica = candidateReplica.QPS()
 
-		if !replWithStats.repl.OwnsValidLease(ctx, now) {
-			log.VEventf(ctx, 3, "store doesn't own the lease for r%d", replWithStats.repl.RangeID)
+		if !candidateReplica.OwnsValidLease(ctx, now) {
+			log.VEventf(ctx, 3, "store doesn't own the lease for r%d", candidateReplica.GetRangeID())
 			continue
 		}
 
@@ -612,8 +717,8 @@ func (sr *StoreRebalancer) chooseRangeToRebalance(
 			ctx,
 			3,
 			"considering replica rebalance for r%d with %.2f qps",
-			replWithStats.repl.GetRangeID(),
-			replWithStats.qps,
+			candidateReplica.GetRangeID(),
+			candidateReplica.QPS(),
 		)
 
 		targetVoterRepls, targetNonVoterRepls, foundRebalance := sr.getRebalanceTargetsBasedOnQPS(
@@ -625,8 +730,8 @@ func (sr *StoreRebalancer) chooseRangeToRebalance(
 		if !foundRebalance {
 			// Bail if there are no stores that are better for the existing replicas.
 			// If the range needs a lease transfer to enable better load distribution,
-			// it will be handled by the logic in `chooseLeaseToTransfer()`.
-			log.VEventf(ctx, 3, "could not find rebalance opportunities for r%d", replWithStats.repl.RangeID)
+			// it will be handled by the logic in `ChooseLeaseToTransfer()`.
+			log.VEventf(ctx, 3, "could not find rebalance opportunities for r%d", candidateReplica.GetRangeID())
 			continue
 		}
 
@@ -645,7 +750,7 @@ func (sr *StoreRebalancer) chooseRangeToRebalance(
 			// in processing its raft log.
 			if replica, ok := rangeDesc.GetReplicaDescriptor(targetVoterRepls[i].StoreID); ok {
 				if raftStatus == nil {
-					raftStatus = sr.getRaftStatusFn(replWithStats.repl)
+					raftStatus = sr.getRaftStatusFn(candidateReplica)
 				}
 				if allocatorimpl.ReplicaIsBehind(raftStatus, replica.ReplicaID) {
 					continue
@@ -659,7 +764,7 @@ func (sr *StoreRebalancer) chooseRangeToRebalance(
 			}
 		}
 		targetVoterRepls[0], targetVoterRepls[newLeaseIdx] = targetVoterRepls[newLeaseIdx], targetVoterRepls[0]
-		return replWithStats,
+		return candidateReplica,
 			roachpb.MakeReplicaSet(targetVoterRepls).ReplicationTargets(),
 			roachpb.MakeReplicaSet(targetNonVoterRepls).ReplicationTargets()
 	}
@@ -680,13 +785,13 @@ func (sr *StoreRebalancer) getRebalanceTargetsBasedOnQPS(
 	for i := 0; i < len(finalVoterTargets); i++ {
 		// TODO(aayush): Figure out a way to plumb the `details` here into
 		// `AdminRelocateRange` so that these decisions show up in system.rangelog
-		add, remove, _, shouldRebalance := sr.rq.allocator.RebalanceTarget(
+		add, remove, _, shouldRebalance := sr.allocator.RebalanceTarget(
 			ctx,
 			rbCtx.conf,
-			rbCtx.replWithStats.repl.RaftStatus(),
+			rbCtx.candidateReplica.RaftStatus(),
 			finalVoterTargets,
 			finalNonVoterTargets,
-			rangeUsageInfoForRepl(rbCtx.replWithStats.repl),
+			rbCtx.candidateReplica.RangeUsageInfo(),
 			storepool.StoreFilterSuspect,
 			allocatorimpl.VoterTarget,
 			options,
@@ -707,7 +812,7 @@ func (sr *StoreRebalancer) getRebalanceTargetsBasedOnQPS(
 			ctx,
 			3,
 			"rebalancing voter (qps=%.2f) for r%d on %v to %v in order to improve QPS balance",
-			rbCtx.replWithStats.qps,
+			rbCtx.candidateReplica.QPS(),
 			rbCtx.rangeDesc.RangeID,
 			remove,
 			add,
@@ -744,13 +849,13 @@ func (sr *StoreRebalancer) getRebalanceTargetsBasedOnQPS(
 	}
 
 	for i := 0; i < len(finalNonVoterTargets); i++ {
-		add, remove, _, shouldRebalance := sr.rq.allocator.RebalanceTarget(
+		add, remove, _, shouldRebalance := sr.allocator.RebalanceTarget(
 			ctx,
 			rbCtx.conf,
-			rbCtx.replWithStats.repl.RaftStatus(),
+			rbCtx.candidateReplica.RaftStatus(),
 			finalVoterTargets,
 			finalNonVoterTargets,
-			rangeUsageInfoForRepl(rbCtx.replWithStats.repl),
+			rbCtx.candidateReplica.RangeUsageInfo(),
 			storepool.StoreFilterSuspect,
 			allocatorimpl.NonVoterTarget,
 			options,
@@ -771,7 +876,7 @@ func (sr *StoreRebalancer) getRebalanceTargetsBasedOnQPS(
 			ctx,
 			3,
 			"rebalancing non-voter (qps=%.2f) for r%d on %v to %v in order to improve QPS balance",
-			rbCtx.replWithStats.qps,
+			rbCtx.candidateReplica.QPS(),
 			rbCtx.rangeDesc.RangeID,
 			remove,
 			add,
@@ -512,11 +512,11 @@ func TestChooseLeaseToTransfer(t *testing.T) {
 	// Rather than trying to populate every Replica with a real raft group in
 	// order to pass replicaIsBehind checks, fake out the function for getting
 	// raft status with one that always returns all replicas as up to date.
-	sr.getRaftStatusFn = func(r *Replica) *raft.Status {
+	sr.getRaftStatusFn = func(r CandidateReplica) *raft.Status {
 		status := &raft.Status{
 			Progress: make(map[uint64]tracker.Progress),
 		}
-		status.Lead = uint64(r.ReplicaID())
+		status.Lead = uint64(r.(rankedReplica).ReplicaID())
 		status.Commit = 1
 		for _, replica := range r.Desc().InternalReplicas {
 			status.Progress[uint64(replica.ReplicaID)] = tracker.Progress{
@@ -681,8 +681,8 @@ func TestChooseLeaseToTransfer(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run("", func(t *testing.T) {
 			loadRanges(rr, s, []testRange{{voters: tc.storeIDs, qps: tc.qps}})
-			hottestRanges := rr.topQPS()
-			_, target, _ := sr.chooseLeaseToTransfer(
+			hottestRanges := rankedReplicas(rr.topQPS())
+			_, target, _ := sr.ChooseLeaseToTransfer(
 				ctx,
 				&hottestRanges,
 				&localDesc,
@@ -788,11 +788,11 @@ func TestChooseRangeToRebalanceRandom(t *testing.T) {
 			// Rather than trying to populate every Replica with a real raft group in
 			// order to pass replicaIsBehind checks, fake out the function for getting
 			// raft status with one that always returns all replicas as up to date.
-			sr.getRaftStatusFn = func(r *Replica) *raft.Status {
+			sr.getRaftStatusFn = func(r CandidateReplica) *raft.Status {
 				status := &raft.Status{
 					Progress: make(map[uint64]tracker.Progress),
 				}
-				status.Lead = uint64(r.ReplicaID())
+				status.Lead = uint64(r.(rankedReplica).ReplicaID())
 				status.Commit = 1
 				for _, replica := range r.Desc().InternalReplicas {
 					status.Progress[uint64(replica.ReplicaID)] = tracker.Progress{
@@ -827,8 +827,8 @@ func TestChooseRangeToRebalanceRandom(t *testing.T) {
 					{voters: voterStores, nonVoters: nonVoterStores, qps: perReplicaQPS},
 				},
 			)
-			hottestRanges := rr.topQPS()
-			_, voterTargets, nonVoterTargets := sr.chooseRangeToRebalance(
+			hottestRanges := rankedReplicas(rr.topQPS())
+			_, voterTargets, nonVoterTargets := sr.ChooseRangeToRebalance(
 				ctx,
 				&hottestRanges,
 				&localDesc,
@@ -1066,11 +1066,11 @@ func TestChooseRangeToRebalanceAcrossHeterogeneousZones(t *testing.T) {
 			// Rather than trying to populate every Replica with a real raft group in
 			// order to pass replicaIsBehind checks, fake out the function for getting
 			// raft status with one that always returns all replicas as up to date.
-			sr.getRaftStatusFn = func(r *Replica) *raft.Status {
+			sr.getRaftStatusFn = func(r CandidateReplica) *raft.Status {
 				status := &raft.Status{
 					Progress: make(map[uint64]tracker.Progress),
 				}
-				status.Lead = uint64(r.ReplicaID())
+				status.Lead = uint64(r.(rankedReplica).ReplicaID())
 				status.Commit = 1
 				for _, replica := range r.Desc().InternalReplicas {
 					status.Progress[uint64(replica.ReplicaID)] = tracker.Progress{
@@ -1091,8 +1091,8 @@ func TestChooseRangeToRebalanceAcrossHeterogeneousZones(t *testing.T) {
 					{voters: tc.voters, nonVoters: tc.nonVoters, qps: testingQPS},
 				},
 			)
-			hottestRanges := rr.topQPS()
-			_, voterTargets, nonVoterTargets := sr.chooseRangeToRebalance(
+			hottestRanges := rankedReplicas(rr.topQPS())
+			_, voterTargets, nonVoterTargets := sr.ChooseRangeToRebalance(
 				ctx,
 				&hottestRanges,
 				&localDesc,
@@ -1106,7 +1106,7 @@ func TestChooseRangeToRebalanceAcrossHeterogeneousZones(t *testing.T) {
 
 			require.Len(t, voterTargets, len(tc.expRebalancedVoters))
 			if len(voterTargets) > 0 && voterTargets[0].StoreID != tc.expRebalancedVoters[0] {
-				t.Errorf("chooseRangeToRebalance(existing=%v, qps=%f) chose s%d as leaseholder; want s%v",
+				t.Errorf("ChooseRangeToRebalance(existing=%v, qps=%f) chose s%d as leaseholder; want s%v",
 					tc.voters, testingQPS, voterTargets[0], tc.expRebalancedVoters[0])
 			}
 
@@ -1171,8 +1171,8 @@ func TestChooseRangeToRebalanceIgnoresRangeOnBestStores(t *testing.T) {
 	// that the store rebalancer doesn't attempt to rebalance ranges that it
 	// cannot find better rebalance opportunities for.
 	loadRanges(rr, s, []testRange{{voters: []roachpb.StoreID{localDesc.StoreID}, qps: 100}})
-	hottestRanges := rr.topQPS()
-	sr.chooseRangeToRebalance(
+	hottestRanges := rankedReplicas(rr.topQPS())
+	sr.ChooseRangeToRebalance(
 		ctx, &hottestRanges, &localDesc, storeList, &allocatorimpl.QPSScorerOptions{
 			StoreHealthOptions:    allocatorimpl.StoreHealthOptions{EnforcementLevel: allocatorimpl.StoreHealthNoAction},
 			QPSRebalanceThreshold: 0.05,
@@ -1315,11 +1315,11 @@ func TestChooseRangeToRebalanceOffHotNodes(t *testing.T) {
 			// Rather than trying to populate every Replica with a real raft group in
 			// order to pass replicaIsBehind checks, fake out the function for getting
 			// raft status with one that always returns all replicas as up to date.
-			sr.getRaftStatusFn = func(r *Replica) *raft.Status {
+			sr.getRaftStatusFn = func(r CandidateReplica) *raft.Status {
 				status := &raft.Status{
 					Progress: make(map[uint64]tracker.Progress),
 				}
-				status.Lead = uint64(r.ReplicaID())
+				status.Lead = uint64(r.(rankedReplica).ReplicaID())
 				status.Commit = 1
 				for _, replica := range r.Desc().InternalReplicas {
 					status.Progress[uint64(replica.ReplicaID)] = tracker.Progress{
@@ -1332,8 +1332,8 @@ func TestChooseRangeToRebalanceOffHotNodes(t *testing.T) {
 
 			s.cfg.DefaultSpanConfig.NumReplicas = int32(len(tc.voters))
 			loadRanges(rr, s, []testRange{{voters: tc.voters, qps: tc.QPS}})
-			hottestRanges := rr.topQPS()
-			_, voterTargets, _ := sr.chooseRangeToRebalance(
+			hottestRanges := rankedReplicas(rr.topQPS())
+			_, voterTargets, _ := sr.ChooseRangeToRebalance(
 				ctx,
 				&hottestRanges,
 				&localDesc,
@@ -1392,16 +1392,16 @@ func TestNoLeaseTransferToBehindReplicas(t *testing.T) {
 	// Load in a range with replicas on an overfull node, a slightly underfull
 	// node, and a very underfull node.
 	loadRanges(rr, s, []testRange{{voters: []roachpb.StoreID{1, 4, 5}, qps: 100}})
-	hottestRanges := rr.topQPS()
-	repl := hottestRanges[0].repl
+	hottestRanges := rankedReplicas(rr.topQPS())
+	repl := hottestRanges[0]
 
 	// Set up a fake RaftStatus that indicates s5 is behind (but all other stores
 	// are caught up). We thus shouldn't transfer a lease to s5.
-	sr.getRaftStatusFn = func(r *Replica) *raft.Status {
+	sr.getRaftStatusFn = func(r CandidateReplica) *raft.Status {
 		status := &raft.Status{
 			Progress: make(map[uint64]tracker.Progress),
 		}
-		status.Lead = uint64(r.ReplicaID())
+		status.Lead = uint64(r.(rankedReplica).ReplicaID())
 		status.Commit = 1
 		for _, replica := range r.Desc().InternalReplicas {
 			match := uint64(1)
@@ -1416,7 +1416,7 @@ func TestNoLeaseTransferToBehindReplicas(t *testing.T) {
 		return status
 	}
 
-	_, target, _ := sr.chooseLeaseToTransfer(
+	_, target, _ := sr.ChooseLeaseToTransfer(
 		ctx,
 		&hottestRanges,
 		&localDesc,
@@ -1434,10 +1434,10 @@ func TestNoLeaseTransferToBehindReplicas(t *testing.T) {
 	// that's behind, and see how a new replica is preferred as the leaseholder
 	// over it.
 	loadRanges(rr, s, []testRange{{voters: []roachpb.StoreID{1, 3, 5}, qps: 100}})
-	hottestRanges = rr.topQPS()
-	repl = hottestRanges[0].repl
+	hottestRanges = rankedReplicas(rr.topQPS())
+	repl = hottestRanges[0]
 
-	_, targets, _ := sr.chooseRangeToRebalance(
+	_, targets, _ := sr.ChooseRangeToRebalance(
 		ctx,
 		&hottestRanges,
 		&localDesc,
@@ -1603,9 +1603,9 @@ func TestStoreRebalancerReadAmpCheck(t *testing.T) {
 			// Load in a range with replicas on an overfull node, a slightly underfull
 			// node, and a very underfull node.
 			loadRanges(rr, s, []testRange{{voters: []roachpb.StoreID{1, 3, 5}, qps: 100}})
-			hottestRanges := rr.topQPS()
+			hottestRanges := rankedReplicas(rr.topQPS())
 
-			_, targetVoters, _ := sr.chooseRangeToRebalance(
+			_, targetVoters, _ := sr.ChooseRangeToRebalance(
 				ctx,
 				&hottestRanges,
 				&localDesc,
@@ -87,6 +87,19 @@ func (m *MockTransactionalSender) SetDebugName(name string) {
 	m.txn.Name = name
 }
 
+// SetIsoLevel is part of the TxnSender interface.
+func (m *MockTransactionalSender) SetIsoLevel(isoLevel IsolationLevel) error {
+	if isoLevel != SerializableIsolation {
+		return errors.AssertionFailedf("unsupported isolation level: %s", isoLevel)
+	}
+	return nil
+}
+
+// IsoLevel is part of the TxnSender interface.
+func (m *MockTransactionalSender) IsoLevel() IsolationLevel {
+	return SerializableIsolation
+}
+
 // String is part of the TxnSender interface.
 func (m *MockTransactionalSender) String() string {
 	return m.txn.String()
@@ -196,6 +209,9 @@ func (m *MockTransactionalSender) Step(_ context.Context) error {
 	return nil
 }
 
+// StepReadTimestamp is part of the TxnSender interface.
+func (m *MockTransactionalSender) StepReadTimestamp(context.Context) error { return nil }
+
 // SetReadSeqNum is part of the TxnSender interface.
 func (m *MockTransactionalSender) SetReadSeqNum(_ enginepb.TxnSeq) error { return nil }
 
@@ -12,6 +12,7 @@ package kv
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/storage/enginepb"
@@ -129,6 +130,13 @@ type TxnSender interface {
 	// SetDebugName sets the txn's debug name.
 	SetDebugName(name string)
 
+	// SetIsoLevel sets the txn's isolation level. It must be called before the
+	// txn performs any operation.
+	SetIsoLevel(IsolationLevel) error
+
+	// IsoLevel returns the txn's isolation level.
+	IsoLevel() IsolationLevel
+
 	// String returns a string representation of the txn.
 	String() string
 
@@ -285,6 +293,16 @@ type TxnSender interface {
 	// The method is idempotent.
 	Step(context.Context) error
 
+	// StepReadTimestamp establishes a new read snapshot for a transaction
+	// whose isolation level reads from a new snapshot at each statement, by
+	// moving its read timestamp to the current time. The reads performed
+	// before the step no longer need to be refreshed. It is a no-op for other
+	// isolation levels, and for transactions whose commit timestamp is fixed.
+	//
+	// This method is only valid when called on RootTxns, in between
+	// statements.
+	StepReadTimestamp(context.Context) error
+
 	// SetReadSeqNum sets the read sequence point for the current transaction.
 	SetReadSeqNum(seq enginepb.TxnSeq) error
 
@@ -351,6 +369,40 @@ const (
 	SteppingEnabled SteppingMode = true
 )
 
+// IsolationLevel is the isolation level of a transaction.
+type IsolationLevel int
+
+const (
+	// SerializableIsolation is the default isolation level. All the reads of a
+	// transaction observe the same snapshot, and the transaction is restarted if
+	// it can't commit at a timestamp where its reads are still valid.
+	SerializableIsolation IsolationLevel = iota
+
+	// ReadCommittedIsolation is an isolation level where each statement of a
+	// transaction reads from a new snapshot, established when the statement
+	// starts. Only the reads of the current statement are refreshed when the
+	// timestamp of the transaction moves, and a conflict which can't be
+	// resolved by a refresh only requires the current statement to be retried.
+	ReadCommittedIsolation
+)
+
+// PerStatementReadSnapshot returns whether each statement of a transaction
+// at this isolation level reads from its own snapshot.
+func (l IsolationLevel) PerStatementReadSnapshot() bool {
+	return l == ReadCommittedIsolation
+}
+
+func (l IsolationLevel) String() string {
+	switch l {
+	case SerializableIsolation:
+		return "Serializable"
+	case ReadCommittedIsolation:
+		return "ReadCommitted"
+	default:
+		return fmt.Sprintf("IsolationLevel(%d)", int(l))
+	}
+}
+
 // SavepointToken represents a savepoint.
 type SavepointToken interface {
 	// Initial returns true if this savepoint has been created before performing
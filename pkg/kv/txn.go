@@ -321,6 +321,25 @@ func (txn *Txn) UserPriority() roachpb.UserPriority {
 	return txn.mu.userPriority
 }
 
+// SetIsoLevel sets the isolation level of the transaction. It must be called
+// before the transaction performs any operation.
+func (txn *Txn) SetIsoLevel(isoLevel IsolationLevel) error {
+	if txn.typ != RootTxn {
+		return errors.AssertionFailedf("SetIsoLevel() called on leaf txn")
+	}
+
+	txn.mu.Lock()
+	defer txn.mu.Unlock()
+	return txn.mu.sender.SetIsoLevel(isoLevel)
+}
+
+// IsoLevel returns the isolation level of the transaction.
+func (txn *Txn) IsoLevel() IsolationLevel {
+	txn.mu.Lock()
+	defer txn.mu.Unlock()
+	return txn.mu.sender.IsoLevel()
+}
+
 // SetDebugName sets the debug name associated with the transaction which will
 // appear in log files and the web UI.
 func (txn *Txn) SetDebugName(name string) {
@@ -1442,10 +1461,15 @@ func (txn *Txn) replaceRootSenderIfTxnAbortedLocked(
 	// transaction, even once the proto is reset.
 	txn.recordPreviousTxnIDLocked(txn.mu.ID)
 	txn.mu.ID = newTxn.ID
-	// Create a new txn sender. We need to preserve the stepping mode, if any.
+	// Create a new txn sender. We need to preserve the stepping mode and the
+	// isolation level, if any.
 	prevSteppingMode := txn.mu.sender.GetSteppingMode(ctx)
+	prevIsoLevel := txn.mu.sender.IsoLevel()
 	txn.mu.sender = txn.db.factory.RootTransactionalSender(newTxn, txn.mu.userPriority)
 	txn.mu.sender.ConfigureStepping(ctx, prevSteppingMode)
+	if err := txn.mu.sender.SetIsoLevel(prevIsoLevel); err != nil {
+		log.Fatalf(ctx, "%+v", err)
+	}
 }
 
 func (txn *Txn) recordPreviousTxnIDLocked(prevTxnID uuid.UUID) {
@@ -1590,6 +1614,19 @@ func (txn *Txn) Step(ctx context.Context) error {
 	return txn.mu.sender.Step(ctx)
 }
 
+// StepReadTimestamp establishes a new read snapshot for the transaction if
+// its isolation level reads from a new snapshot at each statement. See the
+// comment on TxnSender.StepReadTimestamp.
+func (txn *Txn) StepReadTimestamp(ctx context.Context) error {
+	if txn.typ != RootTxn {
+		return errors.WithContextTags(errors.AssertionFailedf(
+			"StepReadTimestamp() called on leaf txn"), ctx)
+	}
+	txn.mu.Lock()
+	defer txn.mu.Unlock()
+	return txn.mu.sender.StepReadTimestamp(ctx)
+}
+
 // SetReadSeqNum sets the read sequence number for this transaction.
 func (txn *Txn) SetReadSeqNum(seq enginepb.TxnSeq) error {
 	txn.mu.Lock()
@@ -143,6 +143,12 @@ var migrations = []migration.Migration{
 		NoPrecondition,
 		seedSpanCountTableMigration,
 	),
+	migration.NewTenantMigration(
+		"add the system.ldap_role_members table",
+		toCV(clusterversion.LDAPRoleMembersTable),
+		NoPrecondition,
+		ldapRoleMembersTableMigration,
+	),
 }
 
 func init() {
@@ -1219,9 +1219,20 @@ func flagForLockStrength(l lock.Strength) flag {
 	return 0
 }
 
+// flagForLockDurability returns the isWrite flag for locking requests that
+// acquire Replicated locks, since these locks are written to the replicated
+// lock table keyspace and so need to go through replication.
+func flagForLockDurability(l lock.Strength, d lock.Durability) flag {
+	if l != lock.None && d == lock.Replicated {
+		return isWrite
+	}
+	return 0
+}
+
 func (gr *GetRequest) flags() flag {
 	maybeLocking := flagForLockStrength(gr.KeyLocking)
-	return isRead | isTxn | maybeLocking | updatesTSCache | needsRefresh
+	maybeWrite := flagForLockDurability(gr.KeyLocking, gr.KeyLockingDurability)
+	return isRead | isTxn | maybeLocking | maybeWrite | updatesTSCache | needsRefresh
 }
 
 func (*PutRequest) flags() flag {
@@ -1303,12 +1314,14 @@ func (*RevertRangeRequest) flags() flag {
 
 func (sr *ScanRequest) flags() flag {
 	maybeLocking := flagForLockStrength(sr.KeyLocking)
-	return isRead | isRange | isTxn | maybeLocking | updatesTSCache | needsRefresh
+	maybeWrite := flagForLockDurability(sr.KeyLocking, sr.KeyLockingDurability)
+	return isRead | isRange | isTxn | maybeLocking | maybeWrite | updatesTSCache | needsRefresh
 }
 
 func (rsr *ReverseScanRequest) flags() flag {
 	maybeLocking := flagForLockStrength(rsr.KeyLocking)
-	return isRead | isRange | isReverse | isTxn | maybeLocking | updatesTSCache | needsRefresh
+	maybeWrite := flagForLockDurability(rsr.KeyLocking, rsr.KeyLockingDurability)
+	return isRead | isRange | isReverse | isTxn | maybeLocking | maybeWrite | updatesTSCache | needsRefresh
 }
 
 // EndTxn updates the timestamp cache to prevent replays.
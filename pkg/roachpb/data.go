@@ -2014,10 +2014,28 @@ func AsIntents(txn *enginepb.TxnMeta, keys []Key) []Intent {
 	return ret
 }
 
+// LockStrength returns the strength with which the intent's lock is held.
+func (i *Intent) LockStrength() lock.Strength {
+	if i.Strength == lock.None {
+		return lock.Exclusive
+	}
+	return i.Strength
+}
+
 // MakeLockAcquisition makes a lock acquisition message from the given
-// txn, key, and durability level.
-func MakeLockAcquisition(txn *Transaction, key Key, dur lock.Durability) LockAcquisition {
-	return LockAcquisition{Span: Span{Key: key}, Txn: txn.TxnMeta, Durability: dur}
+// txn, key, strength, and durability level.
+func MakeLockAcquisition(
+	txn *Transaction, key Key, str lock.Strength, dur lock.Durability,
+) LockAcquisition {
+	return LockAcquisition{Span: Span{Key: key}, Txn: txn.TxnMeta, Strength: str, Durability: dur}
+}
+
+// LockStrength returns the strength of the acquired lock.
+func (acq *LockAcquisition) LockStrength() lock.Strength {
+	if acq.Strength == lock.None {
+		return lock.Exclusive
+	}
+	return acq.Strength
 }
 
 // MakeLockUpdate makes a lock update from the given txn and span.
@@ -224,6 +224,9 @@ type Node struct {
 
 	admissionController kvserver.KVAdmissionController
 
+	// Rate limits the logging of failures to read the disk stats of a store.
+	diskStatsLogEvery log.EveryN
+
 	tenantUsage multitenant.TenantUsageServer
 
 	tenantSettingsWatcher *tenantsettingswatcher.Watcher
@@ -375,6 +378,7 @@ func NewNode(
 		clusterID:  clusterID,
 		admissionController: kvserver.MakeKVAdmissionController(
 			kvAdmissionQ, storeGrantCoords, cfg.Settings),
+		diskStatsLogEvery:     log.Every(time.Minute),
 		tenantUsage:           tenantUsage,
 		tenantSettingsWatcher: tenantSettingsWatcher,
 		spanConfigAccessor:    spanConfigAccessor,
@@ -772,8 +776,23 @@ func (n *Node) GetPebbleMetrics() []admission.StoreMetrics {
 	var metrics []admission.StoreMetrics
 	_ = n.stores.VisitStores(func(store *kvserver.Store) error {
 		m := store.Engine().GetMetrics()
-		metrics = append(
-			metrics, admission.StoreMetrics{StoreID: int32(store.StoreID()), Metrics: m.Metrics})
+		// The disk stats are unavailable for in-memory stores and on platforms
+		// other than linux, in which case the zero value is used, and the disk
+		// bandwidth is not used for admission control.
+		var diskStats admission.DiskStats
+		if !store.Engine().InMem() {
+			var err error
+			diskStats, err = admission.GetDiskStats(store.Engine().GetAuxiliaryDir())
+			if err != nil && n.diskStatsLogEvery.ShouldLog() {
+				log.Warningf(n.AnnotateCtx(context.Background()),
+					"unable to read disk stats of s%d: %v", store.StoreID(), err)
+			}
+		}
+		metrics = append(metrics, admission.StoreMetrics{
+			StoreID:   int32(store.StoreID()),
+			Metrics:   m.Metrics,
+			DiskStats: diskStats,
+		})
 		return nil
 	})
 	return metrics
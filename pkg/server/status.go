@@ -3172,33 +3172,161 @@ func (s *statusServer) Stores(
 
 	resp := &serverpb.StoresResponse{}
 	err = s.stores.VisitStores(func(store *kvserver.Store) error {
-		storeDetails := serverpb.StoreDetails{
-			StoreID: store.Ident.StoreID,
-		}
-
-		envStats, err := store.Engine().GetEnvStats()
+		storeDetails, err := makeStoreDetails(store)
 		if err != nil {
 			return err
 		}
+		resp.Stores = append(resp.Stores, storeDetails)
+		return nil
+	})
+	if err != nil {
+		return nil, serverError(ctx, err)
+	}
+	return resp, nil
+}
+
+// makeStoreDetails returns the encryption-at-rest details of the store.
+func makeStoreDetails(store *kvserver.Store) (serverpb.StoreDetails, error) {
+	storeDetails := serverpb.StoreDetails{
+		StoreID: store.Ident.StoreID,
+	}
 
-		if len(envStats.EncryptionStatus) > 0 {
-			storeDetails.EncryptionStatus = envStats.EncryptionStatus
+	envStats, err := store.Engine().GetEnvStats()
+	if err != nil {
+		return serverpb.StoreDetails{}, err
+	}
+
+	if len(envStats.EncryptionStatus) > 0 {
+		storeDetails.EncryptionStatus = envStats.EncryptionStatus
+	}
+	storeDetails.TotalFiles = envStats.TotalFiles
+	storeDetails.TotalBytes = envStats.TotalBytes
+	storeDetails.ActiveKeyFiles = envStats.ActiveKeyFiles
+	storeDetails.ActiveKeyBytes = envStats.ActiveKeyBytes
+	return storeDetails, nil
+}
+
+// RotateStoreKey rotates the active encryption-at-rest store key of the
+// requested stores, which must have their store keys managed by a KMS.
+func (s *statusServer) RotateStoreKey(
+	ctx context.Context, req *serverpb.RotateStoreKeyRequest,
+) (*serverpb.RotateStoreKeyResponse, error) {
+	ctx = propagateGatewayMetadata(ctx)
+	ctx = s.AnnotateCtx(ctx)
+
+	if _, err := s.privilegeChecker.requireAdminUser(ctx); err != nil {
+		// NB: not using serverError() here since the priv checker
+		// already returns a proper gRPC error status.
+		return nil, err
+	}
+
+	nodeID, local, err := s.parseNodeID(req.NodeId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	if !local {
+		status, err := s.dialNode(ctx, nodeID)
+		if err != nil {
+			return nil, serverError(ctx, err)
 		}
-		storeDetails.TotalFiles = envStats.TotalFiles
-		storeDetails.TotalBytes = envStats.TotalBytes
-		storeDetails.ActiveKeyFiles = envStats.ActiveKeyFiles
-		storeDetails.ActiveKeyBytes = envStats.ActiveKeyBytes
+		return status.RotateStoreKey(ctx, req)
+	}
 
+	stores, err := s.localStores(ctx, req.StoreIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &serverpb.RotateStoreKeyResponse{}
+	for _, store := range stores {
+		if err := store.Engine().RotateStoreKey(ctx); err != nil {
+			return nil, status.Errorf(codes.FailedPrecondition,
+				"rotating store key of s%d: %s", store.StoreID(), err)
+		}
+		log.Infof(ctx, "rotated the store key of s%d", store.StoreID())
+		storeDetails, err := makeStoreDetails(store)
+		if err != nil {
+			return nil, serverError(ctx, err)
+		}
 		resp.Stores = append(resp.Stores, storeDetails)
+	}
+	return resp, nil
+}
 
-		return nil
-	})
+// ReencryptStores starts rewriting the files of the requested stores that are
+// not encrypted with their active encryption-at-rest data key.
+func (s *statusServer) ReencryptStores(
+	ctx context.Context, req *serverpb.ReencryptStoresRequest,
+) (*serverpb.ReencryptStoresResponse, error) {
+	ctx = propagateGatewayMetadata(ctx)
+	ctx = s.AnnotateCtx(ctx)
+
+	if _, err := s.privilegeChecker.requireAdminUser(ctx); err != nil {
+		// NB: not using serverError() here since the priv checker
+		// already returns a proper gRPC error status.
+		return nil, err
+	}
+
+	nodeID, local, err := s.parseNodeID(req.NodeId)
 	if err != nil {
-		return nil, serverError(ctx, err)
+		return nil, status.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	if !local {
+		status, err := s.dialNode(ctx, nodeID)
+		if err != nil {
+			return nil, serverError(ctx, err)
+		}
+		return status.ReencryptStores(ctx, req)
+	}
+
+	stores, err := s.localStores(ctx, req.StoreIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &serverpb.ReencryptStoresResponse{}
+	for _, store := range stores {
+		if err := store.Engine().StartReencryption(ctx); err != nil {
+			return nil, status.Errorf(codes.FailedPrecondition,
+				"re-encrypting s%d: %s", store.StoreID(), err)
+		}
+		log.Infof(ctx, "started re-encrypting the files of s%d", store.StoreID())
+		storeDetails, err := makeStoreDetails(store)
+		if err != nil {
+			return nil, serverError(ctx, err)
+		}
+		resp.Stores = append(resp.Stores, storeDetails)
 	}
 	return resp, nil
 }
 
+// localStores returns the local stores with the given IDs, or all the local
+// stores if none are given.
+func (s *statusServer) localStores(
+	ctx context.Context, storeIDs []roachpb.StoreID,
+) ([]*kvserver.Store, error) {
+	var stores []*kvserver.Store
+	if len(storeIDs) == 0 {
+		if err := s.stores.VisitStores(func(store *kvserver.Store) error {
+			stores = append(stores, store)
+			return nil
+		}); err != nil {
+			return nil, serverError(ctx, err)
+		}
+		return stores, nil
+	}
+	for _, storeID := range storeIDs {
+		store, err := s.stores.GetStore(storeID)
+		if err != nil {
+			return nil, status.Errorf(codes.NotFound, err.Error())
+		}
+		stores = append(stores, store)
+	}
+	return stores, nil
+}
+
 // jsonWrapper provides a wrapper on any slice data type being
 // marshaled to JSON. This prevents a security vulnerability
 // where a phishing attack can trick a user's browser into
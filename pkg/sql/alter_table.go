@@ -73,6 +73,14 @@ func (p *planner) AlterTable(ctx context.Context, n *tree.AlterTable) (planNode,
 		return nil, err
 	}
 
+	for _, cmd := range n.Cmds {
+		if t, ok := cmd.(*tree.AlterTableAddConstraint); ok {
+			if err := checkConstraintDeferrability(ctx, p.ExecCfg().Settings, t.ConstraintDef); err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	prefix, tableDesc, err := p.ResolveMutableTableDescriptorEx(
 		ctx, n.Table, !n.IfExists, tree.ResolveRequireTableDesc,
 	)
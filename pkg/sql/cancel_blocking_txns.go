@@ -0,0 +1,117 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package sql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cockroachdb/cockroach/pkg/server/serverpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/clusterunique"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/log/eventpb"
+	"github.com/cockroachdb/errors"
+)
+
+// cancelBlockingTxnsNode cancels the sessions running the transactions at the
+// head of the chains of transactions waiting on locks.
+type cancelBlockingTxnsNode struct {
+	// numCanceled is the number of sessions canceled in startExec, which are
+	// reported as rows affected by Next.
+	numCanceled int
+}
+
+// CancelBlockingTransactions cancels the sessions running the head blockers of
+// lock wait chains.
+// Privileges: VIEWACTIVITY or VIEWACTIVITYREDACTED to find the head blockers,
+// and the privileges required by CANCEL SESSION to cancel them.
+func (p *planner) CancelBlockingTransactions(
+	ctx context.Context, n *tree.CancelBlockingTransactions,
+) (planNode, error) {
+	return &cancelBlockingTxnsNode{}, nil
+}
+
+func (n *cancelBlockingTxnsNode) startExec(params runParams) error {
+	p := params.p
+	scope, err := p.makeClusterLocksScope(params.ctx, "crdb_internal.cluster_lock_waits")
+	if err != nil {
+		return err
+	}
+	g, err := p.loadLockWaitGraph(params.ctx, &scope)
+	if err != nil {
+		return err
+	}
+	sessions, err := p.lockWaitSessions(params.ctx)
+	if err != nil {
+		return err
+	}
+
+	// Transactions in a deadlock don't have a head blocker; the deadlock is
+	// broken by the txnwait.Queue. A failure to cancel one of the sessions does
+	// not prevent the others from being canceled.
+	heads, numWaiters := g.headBlockers()
+	var errs error
+	for _, head := range heads {
+		session, ok := sessions[head.ID]
+		if !ok {
+			// The head blocker is not running in a session that we can cancel,
+			// for example because it is already committing or rolling back.
+			log.VEventf(params.ctx, 2, "no session found for blocking transaction %s", head.ID)
+			continue
+		}
+		sessionID := clusterunique.IDFromBytes(session.ID)
+		if sessionID == p.extendedEvalCtx.SessionID {
+			// Don't cancel the session running this statement.
+			log.VEventf(params.ctx, 2, "not canceling current session %s", sessionID)
+			continue
+		}
+		response, err := params.extendedEvalCtx.SQLStatusServer.CancelSession(params.ctx,
+			&serverpb.CancelSessionRequest{
+				NodeId:    fmt.Sprintf("%d", sessionID.GetNodeID()),
+				SessionID: session.ID,
+				Username:  params.SessionData().User().Normalized(),
+			})
+		if err != nil {
+			errs = errors.CombineErrors(errs, errors.Wrapf(err, "canceling session %s", sessionID))
+			continue
+		}
+		if !response.Canceled {
+			// The session may have finished since we listed it.
+			log.VEventf(params.ctx, 2, "could not cancel session %s: %s", sessionID, response.Error)
+			continue
+		}
+		n.numCanceled++
+		if err := p.logEvent(params.ctx,
+			0, /* no target */
+			&eventpb.CancelBlockingTransaction{
+				TxnID:          head.ID.String(),
+				SessionID:      sessionID.String(),
+				NumWaitingTxns: uint32(numWaiters[head.ID]),
+			}); err != nil {
+			errs = errors.CombineErrors(errs,
+				errors.Wrapf(err, "logging cancellation of session %s", sessionID))
+		}
+	}
+	return errs
+}
+
+func (n *cancelBlockingTxnsNode) Next(runParams) (bool, error) {
+	if n.numCanceled == 0 {
+		return false, nil
+	}
+	n.numCanceled--
+	return true, nil
+}
+
+func (*cancelBlockingTxnsNode) Values() tree.Datums { return nil }
+
+func (*cancelBlockingTxnsNode) Close(context.Context) {}
@@ -0,0 +1,92 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package sql_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCancelBlockingTransactions checks that CANCEL BLOCKING TRANSACTIONS
+// cancels the session of the transaction at the head of a lock wait chain,
+// except when it is run from that session, and records the cancellation in
+// the event log.
+func TestCancelBlockingTransactions(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	s, db, _ := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(ctx)
+
+	sqlDB := sqlutils.MakeSQLRunner(db)
+	sqlDB.Exec(t, `CREATE TABLE kv (k INT PRIMARY KEY, v INT)`)
+	sqlDB.Exec(t, `INSERT INTO kv VALUES (1, 0)`)
+
+	blocker, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer func() { _ = blocker.Close() }()
+	var blockerSessionID string
+	require.NoError(t, blocker.QueryRowContext(ctx, `SHOW session_id`).Scan(&blockerSessionID))
+	_, err = blocker.ExecContext(ctx, `BEGIN`)
+	require.NoError(t, err)
+	_, err = blocker.ExecContext(ctx, `UPDATE kv SET v = 1 WHERE k = 1`)
+	require.NoError(t, err)
+
+	waiter, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer func() { _ = waiter.Close() }()
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := waiter.ExecContext(ctx, `UPDATE kv SET v = 2 WHERE k = 1`)
+		errCh <- err
+	}()
+
+	testutils.SucceedsSoon(t, func() error {
+		var count int
+		sqlDB.QueryRow(t, `SELECT count(*) FROM crdb_internal.cluster_lock_waits`).Scan(&count)
+		if count == 0 {
+			return errors.New("waiter is not blocked yet")
+		}
+		return nil
+	})
+
+	// The head blocker does not cancel its own session.
+	res, err := blocker.ExecContext(ctx, `CANCEL BLOCKING TRANSACTIONS`)
+	require.NoError(t, err)
+	n, err := res.RowsAffected()
+	require.NoError(t, err)
+	require.Equal(t, int64(0), n)
+
+	res = sqlDB.Exec(t, `CANCEL BLOCKING TRANSACTIONS`)
+	n, err = res.RowsAffected()
+	require.NoError(t, err)
+	require.Equal(t, int64(1), n)
+
+	require.NoError(t, <-errCh)
+	sqlDB.CheckQueryResults(t, `SELECT v FROM kv WHERE k = 1`, [][]string{{"2"}})
+
+	var info string
+	sqlDB.QueryRow(t,
+		`SELECT info FROM system.eventlog WHERE "eventType" = 'cancel_blocking_transaction'`,
+	).Scan(&info)
+	require.Contains(t, info, blockerSessionID)
+	require.Contains(t, info, `"NumWaitingTxns":1`)
+}
@@ -68,6 +68,23 @@ var ForeignKeyReferenceActionValue = [...]catpb.ForeignKeyAction{
 	tree.Cascade:    catpb.ForeignKeyAction_CASCADE,
 }
 
+// ConstraintDeferrabilityValue allows the conversion from a
+// tree.ConstraintDeferrability to a ConstraintDeferrability.
+var ConstraintDeferrabilityValue = [...]ConstraintDeferrability{
+	tree.ConstraintNotDeferrable:                ConstraintDeferrability_NotDeferrable,
+	tree.ConstraintDeferrableInitiallyImmediate: ConstraintDeferrability_DeferrableInitiallyImmediate,
+	tree.ConstraintDeferrableInitiallyDeferred:  ConstraintDeferrability_DeferrableInitiallyDeferred,
+}
+
+// ConstraintDeferrabilityType allows the conversion from a
+// ConstraintDeferrability to a tree.ConstraintDeferrability. This should
+// match ConstraintDeferrabilityValue.
+var ConstraintDeferrabilityType = [...]tree.ConstraintDeferrability{
+	ConstraintDeferrability_NotDeferrable:                tree.ConstraintNotDeferrable,
+	ConstraintDeferrability_DeferrableInitiallyImmediate: tree.ConstraintDeferrableInitiallyImmediate,
+	ConstraintDeferrability_DeferrableInitiallyDeferred:  tree.ConstraintDeferrableInitiallyDeferred,
+}
+
 // ConstraintType is used to identify the type of a constraint.
 type ConstraintType string
 
@@ -106,3 +123,15 @@ type ConstraintDetail struct {
 	// Only populated for Check Constraints.
 	CheckConstraint *TableDescriptor_CheckConstraint
 }
+
+// Deferrability returns the deferrability of the constraint. Only foreign
+// keys and unique constraints without an index can be deferrable.
+func (c ConstraintDetail) Deferrability() ConstraintDeferrability {
+	switch {
+	case c.FK != nil:
+		return c.FK.Deferrability
+	case c.UniqueWithoutIndexConstraint != nil:
+		return c.UniqueWithoutIndexConstraint.Deferrability
+	}
+	return ConstraintDeferrability_NotDeferrable
+}
@@ -315,6 +315,10 @@ type TableDescriptor interface {
 	// created at, for materialized views and CREATE TABLE AS. Only valid if
 	// IsAs or MaterializedView returns true.
 	GetCreateAsOfTime() hlc.Timestamp
+	// GetRefreshAsOfTime returns the timestamp as of which the data of this
+	// materialized view was last computed, or an empty timestamp if it is
+	// unknown. Only valid if MaterializedView returns true.
+	GetRefreshAsOfTime() hlc.Timestamp
 
 	// GetViewQuery returns this view's CREATE VIEW declaration. Only valid if
 	// IsView is true.
@@ -676,6 +676,17 @@ CREATE TABLE system.span_count (
 	CONSTRAINT single_row CHECK (singleton),
 	FAMILY "primary" (singleton, span_count)
 );`
+
+	// ldap_role_members records the role memberships that were granted by LDAP
+	// group synchronization, so that the synchronization only ever revokes
+	// memberships it granted itself.
+	LDAPRoleMembersTableSchema = `
+CREATE TABLE system.ldap_role_members (
+	member STRING NOT NULL,
+	"role" STRING NOT NULL,
+	CONSTRAINT "primary" PRIMARY KEY (member, "role"),
+	FAMILY "primary" (member, "role")
+);`
 )
 
 func pk(name string) descpb.IndexDescriptor {
@@ -2396,6 +2407,38 @@ var (
 			}}
 		},
 	)
+
+	// LDAPRoleMembersTable is the descriptor for the LDAP role members table.
+	LDAPRoleMembersTable = registerSystemTable(
+		LDAPRoleMembersTableSchema,
+		systemTable(
+			catconstants.LDAPRoleMembersTableName,
+			descpb.InvalidID, // dynamically assigned
+			[]descpb.ColumnDescriptor{
+				{Name: "member", ID: 1, Type: types.String},
+				{Name: "role", ID: 2, Type: types.String},
+			},
+			[]descpb.ColumnFamilyDescriptor{
+				{
+					Name:        "primary",
+					ID:          0,
+					ColumnNames: []string{"member", "role"},
+					ColumnIDs:   []descpb.ColumnID{1, 2},
+				},
+			},
+			descpb.IndexDescriptor{
+				Name:           tabledesc.LegacyPrimaryKeyIndexName,
+				ID:             1,
+				Unique:         true,
+				KeyColumnNames: []string{"member", "role"},
+				KeyColumnDirections: []descpb.IndexDescriptor_Direction{
+					descpb.IndexDescriptor_ASC,
+					descpb.IndexDescriptor_ASC,
+				},
+				KeyColumnIDs: []descpb.ColumnID{1, 2},
+				Version:      descpb.StrictIndexColumnIDGuaranteesVersion,
+			},
+		))
 )
 
 type descRefByName struct {
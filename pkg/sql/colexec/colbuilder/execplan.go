@@ -251,6 +251,7 @@ var (
 	errBackupDataWrap                 = errors.New("core.BackupData is not supported")
 	errBackfillerWrap                 = errors.New("core.Backfiller is not supported (not an execinfra.RowSource)")
 	errExporterWrap                   = errors.New("core.Exporter is not supported (not an execinfra.RowSource)")
+	errIcebergCommitterWrap           = errors.New("core.IcebergCommitter is not supported (not an execinfra.RowSource)")
 	errSamplerWrap                    = errors.New("core.Sampler is not supported (not an execinfra.RowSource)")
 	errSampleAggregatorWrap           = errors.New("core.SampleAggregator is not supported (not an execinfra.RowSource)")
 	errExperimentalWrappingProhibited = errors.New("wrapping for non-JoinReader and non-LocalPlanNode cores is prohibited in vectorize=experimental_always")
@@ -315,6 +316,8 @@ func canWrap(mode sessiondatapb.VectorizeExecMode, spec *execinfrapb.ProcessorSp
 	case spec.Core.Filterer != nil:
 	case spec.Core.StreamIngestionData != nil:
 	case spec.Core.StreamIngestionFrontier != nil:
+	case spec.Core.IcebergCommitter != nil:
+		return errIcebergCommitterWrap
 	default:
 		return errors.AssertionFailedf("unexpected processor core %q", spec.Core)
 	}
@@ -511,7 +511,7 @@ func NewColIndexJoin(
 
 	fetcher := cFetcherPool.Get().(*cFetcher)
 	fetcher.cFetcherArgs = cFetcherArgs{
-		spec.LockingStrength,
+		row.GetScanLockingStrength(ctx, flowCtx.EvalCtx.Settings, spec.LockingStrength),
 		spec.LockingWaitPolicy,
 		flowCtx.EvalCtx.SessionData().LockTimeout,
 		memoryLimit,
@@ -39,6 +39,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/idxusage"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgnotice"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgwirecancel"
 	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scerrors"
 	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scrun"
@@ -999,6 +1000,7 @@ func (s *Server) newConnExecutorWithTxn(
 		txn.ReadTimestamp().GoTime(),
 		nil, /* historicalTimestamp */
 		roachpb.UnspecifiedUserPriority,
+		kv.SerializableIsolation, /* isoLevel */
 		tree.ReadWrite,
 		txn,
 		ex.transitionCtx,
@@ -1362,6 +1364,10 @@ type connExecutor struct {
 
 		schemaChangerState SchemaChangerState
 
+		// deferredConstraints contains the SET CONSTRAINTS modes of the
+		// transaction and the constraint checks deferred until it commits.
+		deferredConstraints deferredConstraints
+
 		// shouldCollectTxnExecutionStats specifies whether the statements in
 		// this transaction should collect execution stats.
 		shouldCollectTxnExecutionStats bool
@@ -1670,8 +1676,12 @@ func (ex *connExecutor) resetExtraTxnState(ctx context.Context, ev txnEvent) err
 			delete(ex.extraTxnState.prepStmtsNamespaceAtTxnRewindPos.portals, name)
 		}
 		ex.extraTxnState.savepoints.clear()
+		ex.extraTxnState.deferredConstraints = deferredConstraints{}
 		ex.onTxnFinish(ctx, ev)
 	case txnRestart:
+		// The statements of the transaction run again, so the checks they
+		// deferred are discarded; the SET CONSTRAINTS modes are kept.
+		ex.extraTxnState.deferredConstraints.pending = nil
 		ex.onTxnRestart(ctx)
 		ex.state.mu.Lock()
 		defer ex.state.mu.Unlock()
@@ -2575,9 +2585,14 @@ func (ex *connExecutor) setTransactionModes(
 			return err
 		}
 	}
-	if modes.Isolation != tree.UnspecifiedIsolation && modes.Isolation != tree.SerializableIsolation {
-		return errors.AssertionFailedf(
-			"unknown isolation level: %s", errors.Safe(modes.Isolation))
+	if modes.Isolation != tree.UnspecifiedIsolation {
+		level, upgraded := ex.txnIsolationLevelToKV(modes.Isolation)
+		if upgraded {
+			ex.planner.BufferClientNotice(ctx, readCommittedUpgradedNotice)
+		}
+		if err := ex.state.setIsolationLevel(level); err != nil {
+			return pgerror.WithCandidateCode(err, pgcode.ActiveSQLTransaction)
+		}
 	}
 	rwMode := modes.ReadWriteMode
 	if modes.AsOf.Expr != nil && asOfTs.IsEmpty() {
@@ -2618,6 +2633,43 @@ func (ex *connExecutor) txnPriorityWithSessionDefault(mode tree.UserPriority) ro
 	return txnPriorityToProto(mode)
 }
 
+// readCommittedUpgradedNotice is sent to the client when a transaction asks for
+// the READ COMMITTED isolation level while it is not enabled.
+var readCommittedUpgradedNotice = pgnotice.Newf(
+	"READ COMMITTED isolation level is not enabled; upgrading to SERIALIZABLE " +
+		"(see the sql.txn.read_committed_isolation.enabled cluster setting)",
+)
+
+// txnIsolationLevelToKV returns the isolation level of the KV transaction
+// running a SQL transaction at the given isolation level, using the session
+// default if the level is unspecified. READ COMMITTED is upgraded to
+// SERIALIZABLE unless it is enabled by the
+// sql.txn.read_committed_isolation.enabled cluster setting; the returned bool
+// reports whether such an upgrade took place.
+func (ex *connExecutor) txnIsolationLevelToKV(
+	level tree.IsolationLevel,
+) (_ kv.IsolationLevel, upgraded bool) {
+	if level == tree.UnspecifiedIsolation {
+		level = tree.IsolationLevel(ex.sessionData().DefaultTxnIsolationLevel)
+	}
+	if level != tree.ReadCommittedIsolation {
+		return kv.SerializableIsolation, false
+	}
+	if !allowReadCommittedIsolation.Get(&ex.server.cfg.Settings.SV) {
+		return kv.SerializableIsolation, true
+	}
+	return kv.ReadCommittedIsolation, false
+}
+
+// implicitTxnIsolationLevel returns the isolation level of the KV transaction
+// running an implicit transaction, which is the session default. No notice is
+// sent to the client if READ COMMITTED is upgraded to SERIALIZABLE, as it
+// would be repeated for every statement.
+func (ex *connExecutor) implicitTxnIsolationLevel() kv.IsolationLevel {
+	isoLevel, _ := ex.txnIsolationLevelToKV(tree.UnspecifiedIsolation)
+	return isoLevel
+}
+
 // QualityOfService returns the QoSLevel session setting if the session
 // settings are populated, otherwise the default QoSLevel.
 func (ex *connExecutor) QualityOfService() sessiondatapb.QoSLevel {
@@ -2723,6 +2775,13 @@ func (ex *connExecutor) resetEvalCtx(evalCtx *extendedEvalContext, txn *kv.Txn,
 	evalCtx.PrepareOnly = false
 	evalCtx.SkipNormalize = false
 	evalCtx.SchemaChangerState = &ex.extraTxnState.schemaChangerState
+	// Statements run by the internal executor always check constraints
+	// immediately, since they are not part of a user transaction that could
+	// run the deferred checks.
+	evalCtx.DeferredConstraints = nil
+	if ex.executorType != executorTypeInternal {
+		evalCtx.DeferredConstraints = &ex.extraTxnState.deferredConstraints
+	}
 
 	// If we are retrying due to an unsatisfiable timestamp bound which is
 	// retriable, it means we were unable to serve the previous minimum timestamp
@@ -118,7 +118,7 @@ func (ex *connExecutor) execStmt(
 		// Note: when not using explicit transactions, we go through this transition
 		// for every statement. It is important to minimize the amount of work and
 		// allocations performed up to this point.
-		ev, payload = ex.execStmtInNoTxnState(ctx, ast)
+		ev, payload = ex.execStmtInNoTxnState(ctx, ast, res)
 
 	case stateOpen:
 		if ex.server.cfg.Settings.CPUProfileType() == cluster.CPUProfileWithLabels {
@@ -685,7 +685,11 @@ func (ex *connExecutor) execStmtInOpenState(
 		stmtCtx = ctx
 	}
 
-	if err := ex.dispatchToExecutionEngine(stmtCtx, p, res); err != nil {
+	dispatch := ex.dispatchToExecutionEngine
+	if ex.state.mu.txn.IsoLevel().PerStatementReadSnapshot() {
+		dispatch = ex.dispatchReadCommittedStmtToExecutionEngine
+	}
+	if err := dispatch(stmtCtx, p, res); err != nil {
 		stmtThresholdSpan.Finish()
 		return nil, nil, err
 	}
@@ -939,6 +943,17 @@ func (ex *connExecutor) commitSQLTransactionInternal(ctx context.Context) error
 	ctx, sp := tracing.EnsureChildSpan(ctx, ex.server.cfg.AmbientCtx.Tracer, "commit sql txn")
 	defer sp.Finish()
 
+	// Under isolation levels that read from a new snapshot at each statement,
+	// the reads of the last statement don't need to be refreshed at commit
+	// time, so the commit can't fail because of them.
+	if err := ex.state.mu.txn.StepReadTimestamp(ctx); err != nil {
+		return err
+	}
+
+	if err := ex.runDeferredConstraintChecks(ctx); err != nil {
+		return err
+	}
+
 	if err := ex.createJobs(ctx); err != nil {
 		return err
 	}
@@ -1006,6 +1021,57 @@ func (ex *connExecutor) rollbackSQLTransaction(
 	return eventTxnFinishAborted{}, nil
 }
 
+// dispatchReadCommittedStmtToExecutionEngine executes a statement of a
+// transaction whose isolation level reads from a new snapshot at each
+// statement, like READ COMMITTED. The statement reads from a snapshot taken
+// when it starts. If it runs into a conflict that only requires the statement
+// to be retried, its writes are rolled back to a savepoint created before it
+// ran and it is executed again on a new snapshot, as long as none of its
+// results have been sent to the client and the
+// sql.txn.read_committed_isolation.max_statement_retries limit is not reached.
+// Otherwise the error is left on res and the whole transaction is retried.
+//
+// Like dispatchToExecutionEngine, it only returns errors that require the
+// connection to stop processing queries.
+func (ex *connExecutor) dispatchReadCommittedStmtToExecutionEngine(
+	ctx context.Context, p *planner, res RestrictedCommandResult,
+) error {
+	txn := ex.state.mu.txn
+	maxRetries := int(readCommittedMaxStatementRetries.Get(&ex.server.cfg.Settings.SV))
+	for attempt := 0; ; attempt++ {
+		if err := txn.StepReadTimestamp(ctx); err != nil {
+			res.SetError(err)
+			return nil
+		}
+		savepoint, err := txn.CreateSavepoint(ctx)
+		if err != nil {
+			res.SetError(err)
+			return nil
+		}
+		resultsLen := res.BufferedResultsLen()
+
+		if err := ex.dispatchToExecutionEngine(ctx, p, res); err != nil {
+			return err
+		}
+
+		var retryErr *roachpb.TransactionRetryWithProtoRefreshError
+		if !errors.As(res.Err(), &retryErr) || !retryErr.StatementRetry {
+			return nil
+		}
+		if attempt >= maxRetries || !res.TruncateBufferedResults(resultsLen) {
+			log.VEventf(ctx, 2, "not retrying statement after %d attempts: %v", attempt+1, retryErr)
+			return nil
+		}
+		if err := txn.RollbackToSavepoint(ctx, savepoint); err != nil {
+			// Leave the retry error on res; the transaction is retried instead.
+			log.VEventf(ctx, 2, "failed to roll back statement for retry: %v", err)
+			return nil
+		}
+		log.VEventf(ctx, 2, "retrying statement after: %v", retryErr)
+		res.SetError(nil)
+	}
+}
+
 // dispatchToExecutionEngine executes the statement, writes the result to res
 // and returns an event for the connection's state machine.
 //
@@ -1546,7 +1612,7 @@ var eventStartExplicitTxn fsm.Event = eventTxnStart{ImplicitTxn: fsm.False}
 // the cursor is not advanced. This means that the statement will run again in
 // stateOpen, at each point its results will also be flushed.
 func (ex *connExecutor) execStmtInNoTxnState(
-	ctx context.Context, ast tree.Statement,
+	ctx context.Context, ast tree.Statement, res RestrictedCommandResult,
 ) (_ fsm.Event, payload fsm.EventPayload) {
 	switch s := ast.(type) {
 	case *tree.BeginTransaction:
@@ -1560,10 +1626,15 @@ func (ex *connExecutor) execStmtInNoTxnState(
 		if err != nil {
 			return ex.makeErrEvent(err, s)
 		}
+		isoLevel, upgraded := ex.txnIsolationLevelToKV(s.Modes.Isolation)
+		if upgraded {
+			res.BufferNotice(readCommittedUpgradedNotice)
+		}
 		ex.sessionDataStack.PushTopClone()
 		return eventStartExplicitTxn,
 			makeEventTxnStartPayload(
 				ex.txnPriorityWithSessionDefault(s.Modes.UserPriority),
+				isoLevel,
 				mode,
 				sqlTs,
 				historicalTs,
@@ -1585,6 +1656,7 @@ func (ex *connExecutor) execStmtInNoTxnState(
 		return eventStartImplicitTxn,
 			makeEventTxnStartPayload(
 				ex.txnPriorityWithSessionDefault(tree.UnspecifiedUserPriority),
+				ex.implicitTxnIsolationLevel(),
 				mode,
 				sqlTs,
 				historicalTs,
@@ -1615,6 +1687,7 @@ func (ex *connExecutor) beginImplicitTxn(
 	return eventStartImplicitTxn,
 		makeEventTxnStartPayload(
 			ex.txnPriorityWithSessionDefault(tree.UnspecifiedUserPriority),
+			ex.implicitTxnIsolationLevel(),
 			mode,
 			sqlTs,
 			historicalTs,
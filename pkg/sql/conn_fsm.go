@@ -19,6 +19,7 @@ package sql
 import (
 	"time"
 
+	"github.com/cockroachdb/cockroach/pkg/kv"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sessiondatapb"
@@ -103,6 +104,8 @@ type eventTxnStartPayload struct {
 	tranCtx transitionCtx
 
 	pri roachpb.UserPriority
+	// isoLevel is the isolation level of the KV transaction.
+	isoLevel kv.IsolationLevel
 	// txnSQLTimestamp is the timestamp that statements executed in the
 	// transaction that is started by this event will report for now(),
 	// current_timestamp(), transaction_timestamp().
@@ -117,6 +120,7 @@ type eventTxnStartPayload struct {
 // makeEventTxnStartPayload creates an eventTxnStartPayload.
 func makeEventTxnStartPayload(
 	pri roachpb.UserPriority,
+	isoLevel kv.IsolationLevel,
 	readOnly tree.ReadWriteMode,
 	txnSQLTimestamp time.Time,
 	historicalTimestamp *hlc.Timestamp,
@@ -125,6 +129,7 @@ func makeEventTxnStartPayload(
 ) eventTxnStartPayload {
 	return eventTxnStartPayload{
 		pri:                 pri,
+		isoLevel:            isoLevel,
 		readOnly:            readOnly,
 		txnSQLTimestamp:     txnSQLTimestamp,
 		historicalTimestamp: historicalTimestamp,
@@ -480,6 +485,7 @@ func noTxnToOpen(args fsm.Args) error {
 		payload.txnSQLTimestamp,
 		payload.historicalTimestamp,
 		payload.pri,
+		payload.isoLevel,
 		payload.readOnly,
 		nil, /* txn */
 		payload.tranCtx,
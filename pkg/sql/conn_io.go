@@ -752,6 +752,18 @@ type RestrictedCommandResult interface {
 	// to this CommandResult, will be flushed immediately to the client.
 	// This is currently used for sinkless changefeeds.
 	DisableBuffering()
+
+	// BufferedResultsLen returns a position in the results buffered so far,
+	// which can be passed to TruncateBufferedResults to discard the results
+	// produced after it.
+	BufferedResultsLen() int
+
+	// TruncateBufferedResults discards the results buffered after the position
+	// idx, which was returned by BufferedResultsLen, and resets the number of
+	// rows affected. It returns false, without discarding anything, if some of
+	// these results have already been sent to the client. This is used to retry
+	// a statement after it produced some results.
+	TruncateBufferedResults(idx int) bool
 }
 
 // DescribeResult represents the result of a Describe command (for either
@@ -951,6 +963,18 @@ func (r *streamingCommandResult) DisableBuffering() {
 	panic("cannot disable buffering here")
 }
 
+// BufferedResultsLen is part of the RestrictedCommandResult interface.
+func (r *streamingCommandResult) BufferedResultsLen() int {
+	return 0
+}
+
+// TruncateBufferedResults is part of the RestrictedCommandResult interface.
+func (r *streamingCommandResult) TruncateBufferedResults(int) bool {
+	// The results are not buffered; they are streamed to the consumer as they
+	// are produced.
+	return false
+}
+
 // SetError is part of the RestrictedCommandResult interface.
 func (r *streamingCommandResult) SetError(err error) {
 	r.err = err
@@ -69,6 +69,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlstats/persistedsqlstats/sqlstatsutil"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlstats/sslocal"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/storage/enginepb"
 	"github.com/cockroachdb/cockroach/pkg/util/duration"
 	"github.com/cockroachdb/cockroach/pkg/util/errorutil"
 	"github.com/cockroachdb/cockroach/pkg/util/json"
@@ -80,6 +81,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util/tracing"
 	"github.com/cockroachdb/cockroach/pkg/util/tracing/collector"
 	"github.com/cockroachdb/cockroach/pkg/util/tracing/tracingpb"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
 	"github.com/cockroachdb/errors"
 )
 
@@ -109,6 +111,7 @@ var crdbInternal = virtualSchema{
 		catconstants.CrdbInternalClusterContentionEventsTableID:     crdbInternalClusterContentionEventsTable,
 		catconstants.CrdbInternalClusterDistSQLFlowsTableID:         crdbInternalClusterDistSQLFlowsTable,
 		catconstants.CrdbInternalClusterLocksTableID:                crdbInternalClusterLocksTable,
+		catconstants.CrdbInternalClusterLockWaitsTableID:            crdbInternalClusterLockWaitsTable,
 		catconstants.CrdbInternalClusterQueriesTableID:              crdbInternalClusterQueriesTable,
 		catconstants.CrdbInternalClusterTransactionsTableID:         crdbInternalClusterTxnsTable,
 		catconstants.CrdbInternalClusterSessionsTableID:             crdbInternalClusterSessionsTable,
@@ -133,6 +136,7 @@ var crdbInternal = virtualSchema{
 		catconstants.CrdbInternalJobsTableID:                        crdbInternalJobsTable,
 		catconstants.CrdbInternalKVNodeStatusTableID:                crdbInternalKVNodeStatusTable,
 		catconstants.CrdbInternalKVStoreStatusTableID:               crdbInternalKVStoreStatusTable,
+		catconstants.CrdbInternalKVStoreEncryptionViewID:            crdbInternalKVStoreEncryptionView,
 		catconstants.CrdbInternalLeasesTableID:                      crdbInternalLeasesTable,
 		catconstants.CrdbInternalLocalContentionEventsTableID:       crdbInternalLocalContentionEventsTable,
 		catconstants.CrdbInternalLocalDistSQLFlowsTableID:           crdbInternalLocalDistSQLFlowsTable,
@@ -4535,6 +4539,60 @@ CREATE TABLE crdb_internal.kv_store_status (
 	},
 }
 
+// crdbInternalKVStoreEncryptionView exposes, for every store, how much of its
+// data is encrypted with the active data key, based on the encryption-at-rest
+// metrics of kv_store_status. The remainder is still encrypted with older data
+// keys, and is re-encrypted with the active one as it is rewritten.
+var crdbInternalKVStoreEncryptionView = virtualSchemaView{
+	schema: `
+CREATE VIEW crdb_internal.kv_store_encryption (
+  node_id,
+  store_id,
+  encrypted,
+  total_files,
+  total_bytes,
+  active_key_files,
+  active_key_bytes,
+  old_key_bytes_fraction
+) AS
+  SELECT
+    node_id,
+    store_id,
+    encrypted,
+    total_files,
+    total_bytes,
+    active_key_files,
+    active_key_bytes,
+    CASE
+    WHEN total_bytes > 0 THEN 1 - (active_key_bytes::FLOAT8 / total_bytes::FLOAT8)
+    ELSE 0
+    END
+  FROM
+    (
+      SELECT
+        node_id,
+        store_id,
+        (properties->>'encrypted')::BOOL AS encrypted,
+        IFNULL((metrics->>'rocksdb.encryption.total-files')::FLOAT8::INT8, 0) AS total_files,
+        IFNULL((metrics->>'rocksdb.encryption.total-bytes')::FLOAT8::INT8, 0) AS total_bytes,
+        IFNULL((metrics->>'rocksdb.encryption.active-key-files')::FLOAT8::INT8, 0) AS active_key_files,
+        IFNULL((metrics->>'rocksdb.encryption.active-key-bytes')::FLOAT8::INT8, 0) AS active_key_bytes
+      FROM
+        crdb_internal.kv_store_status
+    )
+`,
+	resultColumns: colinfo.ResultColumns{
+		{Name: "node_id", Typ: types.Int},
+		{Name: "store_id", Typ: types.Int},
+		{Name: "encrypted", Typ: types.Bool},
+		{Name: "total_files", Typ: types.Int},
+		{Name: "total_bytes", Typ: types.Int},
+		{Name: "active_key_files", Typ: types.Int},
+		{Name: "active_key_bytes", Typ: types.Int},
+		{Name: "old_key_bytes_fraction", Typ: types.Float},
+	},
+}
+
 // crdbInternalPredefinedComments exposes the predefined
 // comments for virtual tables. This is used by SHOW TABLES WITH COMMENT
 // as fall-back when system.comments is silent.
@@ -5860,58 +5918,11 @@ CREATE TABLE crdb_internal.cluster_locks (
 );`,
 	indexes: nil,
 	generator: func(ctx context.Context, p *planner, db catalog.DatabaseDescriptor, stopper *stop.Stopper) (virtualTableGenerator, cleanupFunc, error) {
-		// TODO(sarkesian): remove gate for 22.2 release
-		if !p.ExecCfg().Settings.Version.IsActive(ctx, clusterversion.ClusterLocksVirtualTable) {
-			return nil, nil, pgerror.New(pgcode.FeatureNotSupported,
-				"table crdb_internal.cluster_locks is not supported on this version")
-		}
-
-		hasAdmin, err := p.HasAdminRole(ctx)
-		if err != nil {
-			return nil, nil, err
-		}
-		hasViewActivityOrViewActivityRedacted, err := p.HasViewActivityOrViewActivityRedactedRole(ctx)
+		scope, err := p.makeClusterLocksScope(ctx, "crdb_internal.cluster_locks")
 		if err != nil {
 			return nil, nil, err
 		}
-		if !hasViewActivityOrViewActivityRedacted {
-			return nil, nil, pgerror.Newf(pgcode.InsufficientPrivilege,
-				"user %s does not have %s or %s privilege", p.User(), roleoption.VIEWACTIVITY, roleoption.VIEWACTIVITYREDACTED)
-		}
-		shouldRedactKeys := false
-		if !hasAdmin {
-			shouldRedactKeys, err = p.HasRoleOption(ctx, roleoption.VIEWACTIVITYREDACTED)
-			if err != nil {
-				return nil, nil, err
-			}
-		}
-
-		all, err := p.Descriptors().GetAllDescriptors(ctx, p.txn)
-		if err != nil {
-			return nil, nil, err
-		}
-		descs := all.OrderedDescriptors()
-
-		privCheckerFunc := func(desc catalog.Descriptor) bool {
-			if hasAdmin {
-				return true
-			}
-			return p.CheckAnyPrivilege(ctx, desc) == nil
-		}
-
-		_, dbNames, tableNames, schemaNames, indexNames, schemaParents, parents :=
-			descriptorsByType(descs, privCheckerFunc)
-
-		var spansToQuery roachpb.Spans
-		for _, desc := range descs {
-			if !privCheckerFunc(desc) {
-				continue
-			}
-			switch desc := desc.(type) {
-			case catalog.TableDescriptor:
-				spansToQuery = append(spansToQuery, desc.TableSpan(p.execCfg.Codec))
-			}
-		}
+		spansToQuery := scope.spans
 
 		spanIdx := 0
 		spansRemain := func() bool {
@@ -5932,31 +5943,11 @@ CREATE TABLE crdb_internal.cluster_locks (
 		var resumeSpan *roachpb.Span
 
 		fetchLocks := func(key, endKey roachpb.Key) error {
-			b := kv.Batch{}
-			queryLocksRequest := &roachpb.QueryLocksRequest{
-				RequestHeader: roachpb.RequestHeader{
-					Key:    key,
-					EndKey: endKey,
-				},
-				IncludeUncontended: true,
-			}
-			b.AddRawRequest(queryLocksRequest)
-
-			b.Header.MaxSpanRequestKeys = int64(rowinfra.ProductionKVBatchSize)
-			b.Header.TargetBytes = int64(rowinfra.GetDefaultBatchBytesLimit(p.extendedEvalCtx.TestingKnobs.ForceProductionValues))
-
-			err := p.txn.Run(ctx, &b)
+			var err error
+			resp, err = p.queryLocks(ctx, roachpb.Span{Key: key, EndKey: endKey}, true /* includeUncontended */)
 			if err != nil {
 				return err
 			}
-
-			if len(b.RawResponse().Responses) != 1 {
-				return errors.AssertionFailedf(
-					"unexpected response length of %d for QueryLocksRequest", len(b.RawResponse().Responses),
-				)
-			}
-
-			resp = b.RawResponse().Responses[0].GetQueryLocks()
 			locks = resp.Locks
 			resumeSpan = resp.ResumeSpan
 			return nil
@@ -6046,17 +6037,8 @@ CREATE TABLE crdb_internal.cluster_locks (
 
 			waiterIdx++
 
-			tableID, dbName, schemaName, tableName, indexName := lookupNamesByKey(
-				p, curLock.Key, dbNames, tableNames, schemaNames,
-				indexNames, schemaParents, parents,
-			)
-
-			var keyOrRedacted roachpb.Key
-			var prettyKeyOrRedacted string
-			if !shouldRedactKeys {
-				keyOrRedacted, _, _ = keys.DecodeTenantPrefix(curLock.Key)
-				prettyKeyOrRedacted = keys.PrettyPrint(nil /* valDirs */, keyOrRedacted)
-			}
+			tableID, dbName, schemaName, tableName, indexName := scope.lookupNames(p, curLock.Key)
+			keyOrRedacted, prettyKeyOrRedacted := scope.redactKey(curLock.Key)
 
 			return tree.Datums{
 				tree.NewDInt(tree.DInt(curLock.RangeID)),     /* range_id */
@@ -6080,6 +6062,262 @@ CREATE TABLE crdb_internal.cluster_locks (
 	},
 }
 
+// clusterLocksScope describes the locks in the cluster's lock tables that the
+// current user is allowed to inspect, along with the names of the objects that
+// the locked keys belong to.
+type clusterLocksScope struct {
+	// spans are the spans of the tables that the user has privileges on.
+	spans            roachpb.Spans
+	hasAdmin         bool
+	shouldRedactKeys bool
+
+	dbNames, tableNames, schemaNames map[uint32]string
+	indexNames                       map[uint32]map[uint32]string
+	schemaParents, parents           map[uint32]uint32
+}
+
+// makeClusterLocksScope checks that the current user is allowed to read the
+// given virtual table, which exposes locks from the cluster's lock tables, and
+// returns the scope of the locks that the user can inspect.
+func (p *planner) makeClusterLocksScope(
+	ctx context.Context, vtableName string,
+) (clusterLocksScope, error) {
+	// TODO(sarkesian): remove gate for 22.2 release
+	if !p.ExecCfg().Settings.Version.IsActive(ctx, clusterversion.ClusterLocksVirtualTable) {
+		return clusterLocksScope{}, pgerror.Newf(pgcode.FeatureNotSupported,
+			"table %s is not supported on this version", vtableName)
+	}
+
+	hasAdmin, err := p.HasAdminRole(ctx)
+	if err != nil {
+		return clusterLocksScope{}, err
+	}
+	hasViewActivityOrViewActivityRedacted, err := p.HasViewActivityOrViewActivityRedactedRole(ctx)
+	if err != nil {
+		return clusterLocksScope{}, err
+	}
+	if !hasViewActivityOrViewActivityRedacted {
+		return clusterLocksScope{}, pgerror.Newf(pgcode.InsufficientPrivilege,
+			"user %s does not have %s or %s privilege", p.User(), roleoption.VIEWACTIVITY, roleoption.VIEWACTIVITYREDACTED)
+	}
+	scope := clusterLocksScope{hasAdmin: hasAdmin}
+	if !hasAdmin {
+		scope.shouldRedactKeys, err = p.HasRoleOption(ctx, roleoption.VIEWACTIVITYREDACTED)
+		if err != nil {
+			return clusterLocksScope{}, err
+		}
+	}
+
+	all, err := p.Descriptors().GetAllDescriptors(ctx, p.txn)
+	if err != nil {
+		return clusterLocksScope{}, err
+	}
+	descs := all.OrderedDescriptors()
+
+	privCheckerFunc := func(desc catalog.Descriptor) bool {
+		if hasAdmin {
+			return true
+		}
+		return p.CheckAnyPrivilege(ctx, desc) == nil
+	}
+
+	_, scope.dbNames, scope.tableNames, scope.schemaNames, scope.indexNames, scope.schemaParents, scope.parents =
+		descriptorsByType(descs, privCheckerFunc)
+
+	for _, desc := range descs {
+		if !privCheckerFunc(desc) {
+			continue
+		}
+		switch desc := desc.(type) {
+		case catalog.TableDescriptor:
+			scope.spans = append(scope.spans, desc.TableSpan(p.execCfg.Codec))
+		}
+	}
+	return scope, nil
+}
+
+// lookupNames returns the table ID, database name, schema name, table name,
+// and index name that the key belongs to.
+func (s *clusterLocksScope) lookupNames(
+	p *planner, key roachpb.Key,
+) (tableID uint32, dbName string, schemaName string, tableName string, indexName string) {
+	return lookupNamesByKey(
+		p, key, s.dbNames, s.tableNames, s.schemaNames, s.indexNames, s.schemaParents, s.parents,
+	)
+}
+
+// redactKey returns the key stripped of its tenant prefix along with its
+// pretty-printed form, or empty values if the user is not allowed to see keys.
+func (s *clusterLocksScope) redactKey(key roachpb.Key) (roachpb.Key, string) {
+	if s.shouldRedactKeys {
+		return nil, ""
+	}
+	key, _, _ = keys.DecodeTenantPrefix(key)
+	return key, keys.PrettyPrint(nil /* valDirs */, key)
+}
+
+// queryLocks returns a page of the locks in the lock tables of the ranges
+// overlapping the span. If includeUncontended is false, only locks with
+// waiters are returned.
+func (p *planner) queryLocks(
+	ctx context.Context, span roachpb.Span, includeUncontended bool,
+) (*roachpb.QueryLocksResponse, error) {
+	b := kv.Batch{}
+	queryLocksRequest := &roachpb.QueryLocksRequest{
+		RequestHeader:      roachpb.RequestHeaderFromSpan(span),
+		IncludeUncontended: includeUncontended,
+	}
+	b.AddRawRequest(queryLocksRequest)
+
+	b.Header.MaxSpanRequestKeys = int64(rowinfra.ProductionKVBatchSize)
+	b.Header.TargetBytes = int64(rowinfra.GetDefaultBatchBytesLimit(p.extendedEvalCtx.TestingKnobs.ForceProductionValues))
+
+	if err := p.txn.Run(ctx, &b); err != nil {
+		return nil, err
+	}
+
+	if len(b.RawResponse().Responses) != 1 {
+		return nil, errors.AssertionFailedf(
+			"unexpected response length of %d for QueryLocksRequest", len(b.RawResponse().Responses),
+		)
+	}
+	return b.RawResponse().Responses[0].GetQueryLocks(), nil
+}
+
+// crdbInternalClusterLockWaitsTable exposes the wait-for graph between the
+// transactions holding and waiting on locks across the cluster.
+var crdbInternalClusterLockWaitsTable = virtualSchemaTable{
+	comment: `transactions waiting on locks held by other transactions, along with
+		the head of each chain of waiting transactions. Querying this table is an
+		expensive operation since it creates a cluster-wide RPC-fanout.`,
+	schema: `
+CREATE TABLE crdb_internal.cluster_lock_waits (
+    waiting_txn_id              UUID NOT NULL,
+    waiting_session_id          STRING,
+    waiting_application_name    STRING,
+    waiting_statement           STRING,
+    blocking_txn_id             UUID NOT NULL,
+    blocking_session_id         STRING,
+    blocking_application_name   STRING,
+    blocking_statement          STRING,
+    blocking_txn_fingerprint_id BYTES,
+    head_blocker_txn_id         UUID,
+    head_blocker_session_id     STRING,
+    depth                       INT NOT NULL,
+    in_deadlock                 BOOL NOT NULL,
+    range_id                    INT NOT NULL,
+    database_name               STRING,
+    schema_name                 STRING,
+    table_name                  STRING,
+    index_name                  STRING,
+    lock_key_pretty             STRING NOT NULL,
+    lock_strength               STRING,
+    wait_duration               INTERVAL
+);`,
+	populate: func(ctx context.Context, p *planner, _ catalog.DatabaseDescriptor, addRow func(...tree.Datum) error) error {
+		scope, err := p.makeClusterLocksScope(ctx, "crdb_internal.cluster_lock_waits")
+		if err != nil {
+			return err
+		}
+		g, err := p.loadLockWaitGraph(ctx, &scope)
+		if err != nil {
+			return err
+		}
+		sessions, err := p.lockWaitSessions(ctx)
+		if err != nil {
+			return err
+		}
+
+		// Blocking transactions that are no longer running a session, typically
+		// because they are being committed or rolled back, can still be
+		// identified by their fingerprint. Resolving transaction IDs requires the
+		// admin role.
+		var fingerprintIDs map[uuid.UUID]roachpb.TransactionFingerprintID
+		if scope.hasAdmin {
+			var toResolve []*enginepb.TxnMeta
+			seen := make(map[uuid.UUID]struct{})
+			for i := range g.edges {
+				blocker := g.edges[i].blocker
+				if _, ok := sessions[blocker.ID]; ok {
+					continue
+				}
+				if _, ok := seen[blocker.ID]; !ok {
+					seen[blocker.ID] = struct{}{}
+					toResolve = append(toResolve, blocker)
+				}
+			}
+			if len(toResolve) > 0 {
+				fingerprintIDs = p.resolveTxnFingerprintIDs(ctx, toResolve)
+			}
+		}
+
+		sessionDatums := func(txnID uuid.UUID) (sessionID, appName, stmt tree.Datum) {
+			session, ok := sessions[txnID]
+			if !ok {
+				return tree.DNull, tree.DNull, tree.DNull
+			}
+			return getSessionID(*session),
+				tree.NewDString(session.ApplicationName),
+				tree.NewDString(lockWaitStatement(session))
+		}
+
+		for i := range g.edges {
+			e := &g.edges[i]
+			waitingTxnID := tree.NewDUuid(tree.DUuid{UUID: e.waiter.ID})
+			waitingSessionID, waitingAppName, waitingStmt := sessionDatums(e.waiter.ID)
+			blockingTxnID := tree.NewDUuid(tree.DUuid{UUID: e.blocker.ID})
+			blockingSessionID, blockingAppName, blockingStmt := sessionDatums(e.blocker.ID)
+
+			fingerprintIDDatum := tree.DNull
+			if fingerprintID, ok := fingerprintIDs[e.blocker.ID]; ok {
+				fingerprintIDDatum = tree.NewDBytes(
+					tree.DBytes(sqlstatsutil.EncodeUint64ToBytes(uint64(fingerprintID))))
+			}
+
+			headTxnIDDatum, headSessionIDDatum := tree.DNull, tree.DNull
+			head, depth, inDeadlock := g.headBlocker(e)
+			if head != nil {
+				headTxnIDDatum = tree.NewDUuid(tree.DUuid{UUID: head.ID})
+				headSessionIDDatum, _, _ = sessionDatums(head.ID)
+			}
+
+			_, dbName, schemaName, tableName, indexName := scope.lookupNames(p, e.lock.Key)
+			_, prettyKeyOrRedacted := scope.redactKey(e.lock.Key)
+			waitDuration := tree.NewDInterval(
+				duration.MakeDuration(e.wait.WaitDuration.Nanoseconds(), 0 /* days */, 0 /* months */),
+				types.DefaultIntervalTypeMetadata,
+			)
+
+			if err := addRow(
+				waitingTxnID,                              /* waiting_txn_id */
+				waitingSessionID,                          /* waiting_session_id */
+				waitingAppName,                            /* waiting_application_name */
+				waitingStmt,                               /* waiting_statement */
+				blockingTxnID,                             /* blocking_txn_id */
+				blockingSessionID,                         /* blocking_session_id */
+				blockingAppName,                           /* blocking_application_name */
+				blockingStmt,                              /* blocking_statement */
+				fingerprintIDDatum,                        /* blocking_txn_fingerprint_id */
+				headTxnIDDatum,                            /* head_blocker_txn_id */
+				headSessionIDDatum,                        /* head_blocker_session_id */
+				tree.NewDInt(tree.DInt(depth)),            /* depth */
+				tree.MakeDBool(tree.DBool(inDeadlock)),    /* in_deadlock */
+				tree.NewDInt(tree.DInt(e.lock.RangeID)),   /* range_id */
+				tree.NewDString(dbName),                   /* database_name */
+				tree.NewDString(schemaName),               /* schema_name */
+				tree.NewDString(tableName),                /* table_name */
+				tree.NewDString(indexName),                /* index_name */
+				tree.NewDString(prettyKeyOrRedacted),      /* lock_key_pretty */
+				tree.NewDString(e.wait.Strength.String()), /* lock_strength */
+				waitDuration,                              /* wait_duration */
+			); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
 var crdbInternalNodeExecutionOutliersTable = virtualSchemaTable{
 	schema: `
 CREATE TABLE crdb_internal.node_execution_outliers (
@@ -659,6 +659,7 @@ func addUniqueWithoutIndexColumnTableDef(
 		string(d.Unique.ConstraintName),
 		[]string{string(d.Name)},
 		"", /* predicate */
+		tree.ConstraintNotDeferrable,
 		ts,
 		validationBehavior,
 	); err != nil {
@@ -715,7 +716,7 @@ func addUniqueWithoutIndexTableDef(
 		colNames[i] = string(d.Columns[i].Column)
 	}
 	if err := ResolveUniqueWithoutIndexConstraint(
-		ctx, desc, string(d.Name), colNames, predicate, ts, validationBehavior,
+		ctx, desc, string(d.Name), colNames, predicate, d.Deferrability, ts, validationBehavior,
 	); err != nil {
 		return err
 	}
@@ -735,6 +736,7 @@ func ResolveUniqueWithoutIndexConstraint(
 	constraintName string,
 	colNames []string,
 	predicate string,
+	deferrability tree.ConstraintDeferrability,
 	ts TableState,
 	validationBehavior tree.ValidationBehavior,
 ) error {
@@ -788,12 +790,13 @@ func ResolveUniqueWithoutIndexConstraint(
 	}
 
 	uc := descpb.UniqueWithoutIndexConstraint{
-		Name:         constraintName,
-		TableID:      tbl.ID,
-		ColumnIDs:    columnIDs,
-		Predicate:    predicate,
-		Validity:     validity,
-		ConstraintID: tbl.NextConstraintID,
+		Name:          constraintName,
+		TableID:       tbl.ID,
+		ColumnIDs:     columnIDs,
+		Predicate:     predicate,
+		Validity:      validity,
+		ConstraintID:  tbl.NextConstraintID,
+		Deferrability: descpb.ConstraintDeferrabilityValue[deferrability],
 	}
 	tbl.NextConstraintID++
 	if ts == NewTable {
@@ -1049,6 +1052,7 @@ func ResolveFK(
 		OnUpdate:            descpb.ForeignKeyReferenceActionValue[d.Actions.Update],
 		Match:               descpb.CompositeKeyMatchMethodValue[d.Match],
 		ConstraintID:        tbl.NextConstraintID,
+		Deferrability:       descpb.ConstraintDeferrabilityValue[d.Deferrability],
 	}
 	tbl.NextConstraintID++
 	if ts == NewTable {
@@ -1305,6 +1309,12 @@ func NewTableDesc(
 		o(&opts)
 	}
 
+	for _, def := range n.Defs {
+		if err := checkConstraintDeferrability(ctx, st, def); err != nil {
+			return nil, err
+		}
+	}
+
 	var dbID descpb.ID
 	if db != nil {
 		dbID = db.GetID()
@@ -2867,6 +2877,35 @@ func validateUniqueConstraintParamsForCreateTable(n *tree.CreateTable) error {
 	return nil
 }
 
+// checkConstraintDeferrability returns an error if def is a constraint that
+// was declared DEFERRABLE but cannot be deferred. Only foreign keys and
+// UNIQUE WITHOUT INDEX constraints can be checked at COMMIT: a unique index
+// rejects duplicate keys as soon as they are written.
+func checkConstraintDeferrability(
+	ctx context.Context, st *cluster.Settings, def tree.TableDef,
+) error {
+	var deferrability tree.ConstraintDeferrability
+	switch d := def.(type) {
+	case *tree.UniqueConstraintTableDef:
+		deferrability = d.Deferrability
+		if deferrability != tree.ConstraintNotDeferrable && !d.WithoutIndex {
+			return errors.WithHint(
+				pgerror.Newf(pgcode.FeatureNotSupported,
+					"%s unique constraints backed by an index are not supported", deferrability),
+				"use UNIQUE WITHOUT INDEX for a deferrable unique constraint",
+			)
+		}
+	case *tree.ForeignKeyConstraintTableDef:
+		deferrability = d.Deferrability
+	}
+	if deferrability != tree.ConstraintNotDeferrable &&
+		!st.Version.IsActive(ctx, clusterversion.DeferrableConstraints) {
+		return pgerror.Newf(pgcode.FeatureNotSupported,
+			"%s constraints are only available once the cluster is fully upgraded", deferrability)
+	}
+	return nil
+}
+
 // validateUniqueConstraintParamsForCreateTableAs validate storage params of
 // unique constraints passed in through `CREATE TABLE...AS...` statement.
 func validateUniqueConstraintParamsForCreateTableAs(n *tree.CreateTable) error {
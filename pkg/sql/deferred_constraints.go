@@ -0,0 +1,425 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package sql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/kv"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descs"
+	"github.com/cockroachdb/cockroach/pkg/sql/opt/exec"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sessiondata"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlutil"
+)
+
+// maxDeferredConstraintKeys is the number of distinct keys recorded for a
+// deferred constraint check. Past this limit, the keys are discarded and the
+// whole constraint is validated when the check runs.
+const maxDeferredConstraintKeys = 1000
+
+// deferredConstraintBatchSize is the number of keys checked by each query of
+// a deferred constraint check.
+const deferredConstraintBatchSize = 100
+
+// deferredConstraints is the state of the deferrable constraints in a
+// transaction: the modes set by SET CONSTRAINTS, and the checks that were
+// postponed until COMMIT.
+//
+// A deferred check does not keep the rows written by the statement. Instead,
+// the check query of the statement runs as usual, and the keys of the rows it
+// returns, which violate the constraint at the end of the statement, are
+// recorded. When the deferred check runs, only those keys are checked again.
+// This is sufficient because any later statement that could cause a new
+// violation runs its own check query.
+type deferredConstraints struct {
+	// allMode is the mode set by the last SET CONSTRAINTS ALL, if allModeSet
+	// is true.
+	allMode    tree.ConstraintCheckMode
+	allModeSet bool
+
+	// modes contains the modes set by SET CONSTRAINTS for individual
+	// constraints since the last SET CONSTRAINTS ALL.
+	modes map[deferredConstraintID]tree.ConstraintCheckMode
+
+	// pending contains the checks to run before the transaction commits, in
+	// the order in which they were first deferred.
+	pending []*pendingConstraintCheck
+}
+
+// deferredConstraintID identifies a deferrable constraint by the table on
+// which it is defined and its name.
+type deferredConstraintID struct {
+	tableID descpb.ID
+	name    string
+}
+
+// pendingConstraintCheck is a deferred check of a constraint.
+type pendingConstraintCheck struct {
+	id deferredConstraintID
+	// check is the first check of the constraint that was deferred. Checks of
+	// values removed from the referenced table of a foreign key are tracked
+	// separately, since their violations are reported differently.
+	check *exec.DeferrableCheck
+
+	// keys are the constraint keys to check, and seen is used to deduplicate
+	// them.
+	keys []tree.Datums
+	seen map[string]struct{}
+
+	// checkAll is set when more than maxDeferredConstraintKeys keys were
+	// recorded, in which case the whole constraint is validated.
+	checkAll bool
+}
+
+// isDeferred returns whether the given check of a deferrable constraint is
+// currently deferred until the end of the transaction.
+func (dc *deferredConstraints) isDeferred(check *exec.DeferrableCheck) bool {
+	id := deferredConstraintID{tableID: descpb.ID(check.Table), name: check.Constraint}
+	if mode, ok := dc.modes[id]; ok {
+		return mode == tree.ConstraintCheckDeferred
+	}
+	if dc.allModeSet {
+		return dc.allMode == tree.ConstraintCheckDeferred
+	}
+	return check.InitiallyDeferred
+}
+
+// record adds a key that violates the constraint of the given deferred check
+// to the keys checked at the end of the transaction.
+func (dc *deferredConstraints) record(check *exec.DeferrableCheck, key tree.Datums) {
+	id := deferredConstraintID{tableID: descpb.ID(check.Table), name: check.Constraint}
+	var p *pendingConstraintCheck
+	for _, c := range dc.pending {
+		if c.id == id && c.check.Inbound == check.Inbound {
+			p = c
+			break
+		}
+	}
+	if p == nil {
+		p = &pendingConstraintCheck{id: id, check: check, seen: make(map[string]struct{})}
+		dc.pending = append(dc.pending, p)
+	}
+	if p.checkAll {
+		return
+	}
+	k := key.String()
+	if _, ok := p.seen[k]; ok {
+		return
+	}
+	if len(p.keys) >= maxDeferredConstraintKeys {
+		p.keys, p.seen, p.checkAll = nil, nil, true
+		return
+	}
+	p.seen[k] = struct{}{}
+	p.keys = append(p.keys, key)
+}
+
+// setMode implements SET CONSTRAINTS for the given constraints, or for all
+// constraints if ids is empty.
+func (dc *deferredConstraints) setMode(ids []deferredConstraintID, mode tree.ConstraintCheckMode) {
+	if len(ids) == 0 {
+		dc.allMode, dc.allModeSet = mode, true
+		dc.modes = nil
+		return
+	}
+	if dc.modes == nil {
+		dc.modes = make(map[deferredConstraintID]tree.ConstraintCheckMode)
+	}
+	for _, id := range ids {
+		dc.modes[id] = mode
+	}
+}
+
+// runChecks runs the pending checks of the constraints for which include
+// returns true, or of all constraints if include is nil, within the given
+// transaction. The checks that pass are removed from the pending checks; the
+// first violation found is returned as an error.
+func (dc *deferredConstraints) runChecks(
+	ctx context.Context,
+	ief sqlutil.SessionBoundInternalExecutorFactory,
+	sd *sessiondata.SessionData,
+	descsCol *descs.Collection,
+	txn *kv.Txn,
+	include func(deferredConstraintID) bool,
+) error {
+	if len(dc.pending) == 0 {
+		return nil
+	}
+	// The checks must see the descriptors written by the transaction.
+	var syntheticDescs []catalog.Descriptor
+	for _, tbl := range descsCol.GetUncommittedTables() {
+		syntheticDescs = append(syntheticDescs, tbl)
+	}
+	ie := ief(ctx, sd)
+	remaining := dc.pending[:0]
+	var err error
+	for _, p := range dc.pending {
+		if err != nil || (include != nil && !include(p.id)) {
+			remaining = append(remaining, p)
+			continue
+		}
+		err = ie.WithSyntheticDescriptors(syntheticDescs, func() error {
+			return p.run(ctx, ie, descsCol, txn)
+		})
+		if err != nil {
+			remaining = append(remaining, p)
+		}
+	}
+	dc.pending = remaining
+	return err
+}
+
+// run runs a deferred check.
+func (p *pendingConstraintCheck) run(
+	ctx context.Context, ie sqlutil.InternalExecutor, descsCol *descs.Collection, txn *kv.Txn,
+) error {
+	flags := tree.ObjectLookupFlags{
+		CommonLookupFlags: tree.CommonLookupFlags{
+			Required:       true,
+			AvoidLeased:    true,
+			IncludeDropped: true,
+		},
+	}
+	tbl, err := descsCol.GetImmutableTableByID(ctx, txn, p.id.tableID, flags)
+	if err != nil {
+		return err
+	}
+	// There is nothing to check if the table or the constraint was dropped
+	// later in the transaction.
+	if tbl.Dropped() {
+		return nil
+	}
+	if !p.check.ForeignKey {
+		ucs := tbl.GetUniqueWithoutIndexConstraints()
+		for i := range ucs {
+			if ucs[i].Name == p.id.name {
+				return p.runUnique(ctx, ie, tbl, &ucs[i], txn)
+			}
+		}
+		return nil
+	}
+	var fk *descpb.ForeignKeyConstraint
+	_ = tbl.ForeachOutboundFK(func(c *descpb.ForeignKeyConstraint) error {
+		if c.Name == p.id.name {
+			fk = c
+		}
+		return nil
+	})
+	if fk == nil {
+		return nil
+	}
+	targetTbl, err := descsCol.GetImmutableTableByID(ctx, txn, fk.ReferencedTableID, flags)
+	if err != nil {
+		return err
+	}
+	if targetTbl.Dropped() {
+		return nil
+	}
+	return p.runFK(ctx, ie, tbl, fk, targetTbl, txn)
+}
+
+// runFK checks that the recorded keys of a foreign key are either no longer
+// used by rows of the origin table, or present in the referenced table.
+func (p *pendingConstraintCheck) runFK(
+	ctx context.Context,
+	ie sqlutil.InternalExecutor,
+	srcTbl catalog.TableDescriptor,
+	fk *descpb.ForeignKeyConstraint,
+	targetTbl catalog.TableDescriptor,
+	txn *kv.Txn,
+) error {
+	if p.checkAll {
+		query, _, err := nonMatchingRowQuery(srcTbl, fk, targetTbl, true /* limitResults */)
+		if err != nil {
+			return err
+		}
+		row, err := ie.QueryRowEx(ctx, "check deferred fk constraint", txn,
+			sessiondata.NodeUserSessionDataOverride, query)
+		if err != nil {
+			return err
+		}
+		if row != nil {
+			return p.check.MkErr(row[:len(fk.OriginColumnIDs)])
+		}
+		return nil
+	}
+
+	srcColNames, err := srcTbl.NamesForColumnIDs(fk.OriginColumnIDs)
+	if err != nil {
+		return err
+	}
+	targetColNames, err := targetTbl.NamesForColumnIDs(fk.ReferencedColumnIDs)
+	if err != nil {
+		return err
+	}
+	srcCols := make([]string, len(srcColNames))
+	targetCols := make([]string, len(targetColNames))
+	on := make([]string, len(srcColNames))
+	for i := range srcColNames {
+		// s and t are table aliases used in the queries.
+		srcCols[i] = fmt.Sprintf("s.%s", tree.NameString(srcColNames[i]))
+		targetCols[i] = fmt.Sprintf("t.%s", tree.NameString(targetColNames[i]))
+		on[i] = fmt.Sprintf("%s = %s", targetCols[i], srcCols[i])
+	}
+
+	for _, batch := range p.keyBatches() {
+		in, args := keysInExpr(batch)
+		// Under isolation levels that read from a new snapshot at each
+		// statement, the referenced rows must be locked so that they cannot be
+		// removed before the transaction commits, as done by the checks of
+		// statements.
+		if txn.IsoLevel().PerStatementReadSnapshot() {
+			lockQuery := fmt.Sprintf(
+				`SELECT 1 FROM [%d AS t] WHERE (%s) IN (%s) FOR SHARE`,
+				targetTbl.GetID(), strings.Join(targetCols, ", "), in,
+			)
+			if _, err := ie.QueryBufferedEx(ctx, "lock deferred fk references", txn,
+				sessiondata.NodeUserSessionDataOverride, lockQuery, args...); err != nil {
+				return err
+			}
+		}
+		query := fmt.Sprintf(
+			`SELECT %[1]s FROM [%[2]d AS s]@{IGNORE_FOREIGN_KEYS}
+			  WHERE (%[1]s) IN (%[3]s)
+			    AND NOT EXISTS (SELECT 1 FROM [%[4]d AS t] WHERE %[5]s)
+			  LIMIT 1`,
+			strings.Join(srcCols, ", "), // 1
+			srcTbl.GetID(),              // 2
+			in,                          // 3
+			targetTbl.GetID(),           // 4
+			strings.Join(on, " AND "),   // 5
+		)
+		row, err := ie.QueryRowEx(ctx, "check deferred fk constraint", txn,
+			sessiondata.NodeUserSessionDataOverride, query, args...)
+		if err != nil {
+			return err
+		}
+		if row != nil {
+			return p.check.MkErr(row)
+		}
+	}
+	return nil
+}
+
+// runUnique checks that the recorded keys of a unique constraint are no
+// longer duplicated.
+func (p *pendingConstraintCheck) runUnique(
+	ctx context.Context,
+	ie sqlutil.InternalExecutor,
+	tbl catalog.TableDescriptor,
+	uc *descpb.UniqueWithoutIndexConstraint,
+	txn *kv.Txn,
+) error {
+	if p.checkAll {
+		query, _, err := duplicateRowQuery(tbl, uc.ColumnIDs, uc.Predicate, true /* limitResults */)
+		if err != nil {
+			return err
+		}
+		row, err := ie.QueryRowEx(ctx, "check deferred unique constraint", txn,
+			sessiondata.NodeUserSessionDataOverride, query)
+		if err != nil {
+			return err
+		}
+		if row != nil {
+			return p.check.MkErr(row)
+		}
+		return nil
+	}
+
+	colNames, err := tbl.NamesForColumnIDs(uc.ColumnIDs)
+	if err != nil {
+		return err
+	}
+	cols := make([]string, len(colNames))
+	for i, n := range colNames {
+		cols[i] = tree.NameString(n)
+	}
+	pred := ""
+	if uc.IsPartial() {
+		pred = fmt.Sprintf(" AND (%s)", uc.Predicate)
+	}
+	for _, batch := range p.keyBatches() {
+		in, args := keysInExpr(batch)
+		query := fmt.Sprintf(
+			`SELECT %[1]s FROM [%[2]d AS s] WHERE (%[1]s) IN (%[3]s)%[4]s
+			  GROUP BY %[1]s HAVING count(*) > 1 LIMIT 1`,
+			strings.Join(cols, ", "), // 1
+			tbl.GetID(),              // 2
+			in,                       // 3
+			pred,                     // 4
+		)
+		row, err := ie.QueryRowEx(ctx, "check deferred unique constraint", txn,
+			sessiondata.NodeUserSessionDataOverride, query, args...)
+		if err != nil {
+			return err
+		}
+		if row != nil {
+			return p.check.MkErr(row)
+		}
+	}
+	return nil
+}
+
+// keyBatches splits the recorded keys into batches of at most
+// deferredConstraintBatchSize keys.
+func (p *pendingConstraintCheck) keyBatches() [][]tree.Datums {
+	var batches [][]tree.Datums
+	for keys := p.keys; len(keys) > 0; {
+		n := len(keys)
+		if n > deferredConstraintBatchSize {
+			n = deferredConstraintBatchSize
+		}
+		batches = append(batches, keys[:n])
+		keys = keys[n:]
+	}
+	return batches
+}
+
+// keysInExpr returns the right side of an IN expression that matches the
+// given keys, and the values of its placeholders. For example, for two keys
+// of two columns, it returns "($1, $2), ($3, $4)".
+func keysInExpr(keys []tree.Datums) (string, []interface{}) {
+	var buf strings.Builder
+	args := make([]interface{}, 0, len(keys)*len(keys[0]))
+	for i, key := range keys {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteByte('(')
+		for j, d := range key {
+			if j > 0 {
+				buf.WriteString(", ")
+			}
+			args = append(args, d)
+			fmt.Fprintf(&buf, "$%d", len(args))
+		}
+		buf.WriteByte(')')
+	}
+	return buf.String(), args
+}
+
+// runDeferredConstraintChecks runs the deferred constraint checks of the
+// transaction. It is called before the transaction commits.
+func (ex *connExecutor) runDeferredConstraintChecks(ctx context.Context) error {
+	return ex.extraTxnState.deferredConstraints.runChecks(
+		ctx,
+		ex.server.cfg.InternalExecutorFactory,
+		ex.sessionData(),
+		&ex.extraTxnState.descCollection,
+		ex.state.mu.txn,
+		nil, /* include */
+	)
+}
@@ -130,6 +130,9 @@ func TryDelegate(
 	case *tree.ShowTransactions:
 		return d.delegateShowTransactions(t)
 
+	case *tree.ShowBlockingTransactions:
+		return d.delegateShowBlockingTransactions()
+
 	case *tree.ShowUsers:
 		return d.delegateShowRoles()
 
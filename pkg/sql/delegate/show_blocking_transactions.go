@@ -0,0 +1,39 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package delegate
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqltelemetry"
+)
+
+// delegateShowBlockingTransactions lists the head blockers of the lock wait
+// chains in the cluster. The application name and statement of a head blocker
+// are taken from the edges on which it is the immediate blocker, which always
+// exist. Transactions in a deadlock are omitted since they have no head
+// blocker and will be unblocked by deadlock detection.
+func (d *delegator) delegateShowBlockingTransactions() (tree.Statement, error) {
+	sqltelemetry.IncrementShowCounter(sqltelemetry.BlockingTransactions)
+	const query = `
+SELECT
+  head_blocker_txn_id AS txn_id,
+  head_blocker_session_id AS session_id,
+  max(blocking_application_name) FILTER (WHERE depth = 1) AS application_name,
+  max(blocking_statement) FILTER (WHERE depth = 1) AS statement,
+  count(DISTINCT waiting_txn_id) AS num_waiting_txns,
+  max(depth) AS max_depth,
+  max(wait_duration) AS max_wait_duration
+FROM "".crdb_internal.cluster_lock_waits
+WHERE NOT in_deadlock
+GROUP BY head_blocker_txn_id, head_blocker_session_id
+ORDER BY num_waiting_txns DESC, max_wait_duration DESC`
+	return parse(query)
+}
@@ -3889,7 +3889,7 @@ func (dsp *DistSQLPlanner) createPlanForExport(
 	}
 
 	var core execinfrapb.ProcessorCoreUnion
-	core.Exporter = &execinfrapb.ExportSpec{
+	exportSpec := execinfrapb.ExportSpec{
 		Destination: n.destination,
 		NamePattern: n.fileNamePattern,
 		Format:      n.format,
@@ -3898,6 +3898,8 @@ func (dsp *DistSQLPlanner) createPlanForExport(
 		ColNames:    n.colNames,
 		UserProto:   planCtx.planner.User().EncodeProto(),
 	}
+	colTypes := plan.GetResultTypes()
+	core.Exporter = &exportSpec
 
 	resTypes := make([]*types.T, len(colinfo.ExportColumns))
 	for i := range colinfo.ExportColumns {
@@ -3907,6 +3909,20 @@ func (dsp *DistSQLPlanner) createPlanForExport(
 		core, execinfrapb.PostProcessSpec{}, resTypes, execinfrapb.Ordering{},
 	)
 
+	// The files written by an EXPORT INTO ICEBERG are committed to the Iceberg
+	// table by a single processor on the gateway, once they are all written.
+	if n.iceberg {
+		plan.AddSingleGroupStage(
+			dsp.gatewaySQLInstanceID,
+			execinfrapb.ProcessorCoreUnion{IcebergCommitter: &execinfrapb.IcebergCommitterSpec{
+				Export:   exportSpec,
+				ColTypes: colTypes,
+			}},
+			execinfrapb.PostProcessSpec{},
+			resTypes,
+		)
+	}
+
 	// The CSVWriter produces the same columns as the EXPORT statement.
 	plan.PlanToStreamColMap = identityMap(plan.PlanToStreamColMap, len(colinfo.ExportColumns))
 	return plan, nil
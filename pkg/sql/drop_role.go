@@ -16,6 +16,7 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/cockroachdb/cockroach/pkg/clusterversion"
 	"github.com/cockroachdb/cockroach/pkg/security/username"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/catpb"
@@ -362,6 +363,19 @@ func (n *DropRoleNode) startExec(params runParams) error {
 		}
 		numRoleMembershipsDeleted += rowsDeleted
 
+		if params.p.ExecCfg().Settings.Version.IsActive(params.ctx, clusterversion.LDAPRoleMembersTable) {
+			// Forget the memberships granted by LDAP group synchronization.
+			if _, err := params.extendedEvalCtx.ExecCfg.InternalExecutor.Exec(
+				params.ctx,
+				"drop-ldap-role-membership",
+				params.p.txn,
+				`DELETE FROM system.ldap_role_members WHERE "role" = $1 OR "member" = $1`,
+				normalizedUsername,
+			); err != nil {
+				return err
+			}
+		}
+
 		_, err = params.extendedEvalCtx.ExecCfg.InternalExecutor.Exec(
 			params.ctx,
 			opName,
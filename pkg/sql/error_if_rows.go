@@ -26,6 +26,12 @@ type errorIfRowsNode struct {
 	// produced.
 	mkErr exec.MkErrFn
 
+	// deferrable is set if the wrapped node is the check query of a deferrable
+	// constraint. If the check is deferred, the keys of all the rows produced
+	// are recorded in the transaction's deferred constraint state instead of
+	// returning an error.
+	deferrable *exec.DeferrableCheck
+
 	nexted bool
 }
 
@@ -39,6 +45,11 @@ func (n *errorIfRowsNode) Next(params runParams) (bool, error) {
 	}
 	n.nexted = true
 
+	dc := params.extendedEvalCtx.DeferredConstraints
+	if n.deferrable != nil && dc != nil && dc.isDeferred(n.deferrable) {
+		return false, n.recordDeferred(params, dc)
+	}
+
 	ok, err := n.plan.Next(params)
 	if err != nil {
 		return false, err
@@ -49,6 +60,29 @@ func (n *errorIfRowsNode) Next(params runParams) (bool, error) {
 	return false, nil
 }
 
+// recordDeferred records the keys of all the rows produced by the check query
+// of a deferred constraint, so that they are checked again before the
+// transaction commits.
+func (n *errorIfRowsNode) recordDeferred(params runParams, dc *deferredConstraints) error {
+	for {
+		ok, err := n.plan.Next(params)
+		if err != nil || !ok {
+			return err
+		}
+		key := n.deferrable.MkKey(n.plan.Values())
+		// A key with NULL values can only be produced by a MATCH FULL foreign
+		// key, for a row with some but not all of its columns NULL. Such a row
+		// violates the constraint regardless of the other rows, so the error is
+		// returned right away.
+		for _, d := range key {
+			if d == tree.DNull {
+				return n.deferrable.MkErr(key)
+			}
+		}
+		dc.record(n.deferrable, key)
+	}
+}
+
 func (n *errorIfRowsNode) Values() tree.Datums {
 	return nil
 }
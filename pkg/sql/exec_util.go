@@ -212,6 +212,29 @@ var secondaryTenantZoneConfigsEnabled = settings.RegisterBoolSetting(
 	false,
 )
 
+// allowReadCommittedIsolation controls whether transactions may run under the
+// READ COMMITTED isolation level. When disabled, transactions requesting READ
+// COMMITTED are upgraded to SERIALIZABLE.
+var allowReadCommittedIsolation = settings.RegisterBoolSetting(
+	settings.TenantWritable,
+	"sql.txn.read_committed_isolation.enabled",
+	"set to true to allow transactions to use the READ COMMITTED isolation "+
+		"level if specified by BEGIN/SET commands",
+	false,
+).WithPublic()
+
+// readCommittedMaxStatementRetries is the number of times a statement in a
+// READ COMMITTED transaction is transparently retried after a conflict before
+// the error is returned to the client.
+var readCommittedMaxStatementRetries = settings.RegisterIntSetting(
+	settings.TenantWritable,
+	"sql.txn.read_committed_isolation.max_statement_retries",
+	"maximum number of times a statement in a READ COMMITTED transaction is "+
+		"retried after a conflict before the error is returned to the client",
+	10,
+	settings.NonNegativeInt,
+)
+
 // traceTxnThreshold can be used to log SQL transactions that take
 // longer than duration to complete. For example, traceTxnThreshold=1s
 // will log the trace for any transaction that takes 1s or longer. To
@@ -2906,6 +2929,10 @@ func (m *sessionDataMutator) SetDefaultTransactionPriority(val tree.UserPriority
 	m.data.DefaultTxnPriority = int64(val)
 }
 
+func (m *sessionDataMutator) SetDefaultTransactionIsolationLevel(val tree.IsolationLevel) {
+	m.data.DefaultTxnIsolationLevel = int64(val)
+}
+
 func (m *sessionDataMutator) SetDefaultTransactionReadOnly(val bool) {
 	m.data.DefaultTxnReadOnly = val
 }
@@ -551,6 +551,11 @@ func (s *ExportSpec) summary() (string, []string) {
 	return "Exporter", []string{s.Destination}
 }
 
+// summary implements the diagramCellType interface.
+func (s *IcebergCommitterSpec) summary() (string, []string) {
+	return "IcebergCommitter", []string{s.Export.Destination}
+}
+
 // summary implements the diagramCellType interface.
 func (s *BulkRowWriterSpec) summary() (string, []string) {
 	return "BulkRowWriterSpec", []string{}
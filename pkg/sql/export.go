@@ -45,6 +45,9 @@ type exportNode struct {
 	chunkRows       int
 	chunkSize       int64
 	colNames        []string
+	// iceberg is set if the exported Parquet files are committed to an Iceberg
+	// table at the destination.
+	iceberg bool
 }
 
 func (e *exportNode) startExec(params runParams) error {
@@ -79,6 +82,11 @@ const (
 	exportSnappyCodec     = "snappy"
 	csvSuffix             = "csv"
 	parquetSuffix         = "parquet"
+	icebergSuffix         = "iceberg"
+
+	// exportIcebergDataDir is the directory of the Iceberg table of an EXPORT
+	// INTO ICEBERG which holds the exported Parquet files.
+	exportIcebergDataDir = "data/"
 )
 
 var exportOptionExpectValues = map[string]KVStringOptValidate{
@@ -128,7 +136,7 @@ func (ef *execFactory) ConstructExport(
 		return nil, errors.Errorf("EXPORT cannot be used inside a multi-statement transaction")
 	}
 
-	if fileSuffix != csvSuffix && fileSuffix != parquetSuffix {
+	if fileSuffix != csvSuffix && fileSuffix != parquetSuffix && fileSuffix != icebergSuffix {
 		return nil, errors.Errorf("unsupported export format: %q", fileSuffix)
 	}
 
@@ -184,7 +192,7 @@ func (ef *execFactory) ConstructExport(
 		}
 		format.Format = roachpb.IOFileFormat_CSV
 		format.Csv = csvOpts
-	case parquetSuffix:
+	case parquetSuffix, icebergSuffix:
 		parquetOpts := roachpb.ParquetOptions{
 			ColNullability: colNullability,
 		}
@@ -221,7 +229,7 @@ func (ef *execFactory) ConstructExport(
 		switch {
 		case strings.EqualFold(name, exportGzipCodec):
 			codec = roachpb.IOFileFormat_Gzip
-		case strings.EqualFold(name, exportSnappyCodec) && fileSuffix == parquetSuffix:
+		case strings.EqualFold(name, exportSnappyCodec) && format.Format == roachpb.IOFileFormat_Parquet:
 			codec = roachpb.IOFileFormat_Snappy
 		default:
 			return nil, pgerror.Newf(pgcode.InvalidParameterValue,
@@ -231,8 +239,16 @@ func (ef *execFactory) ConstructExport(
 	}
 
 	exportID := ef.planner.stmt.QueryID.String()
-	exportFilePattern := exportFilePatternPart + "." + fileSuffix
-	namePattern := fmt.Sprintf("export%s-%s", exportID, exportFilePattern)
+	var namePattern string
+	if fileSuffix == icebergSuffix {
+		// Successive exports to the same destination append their files to the
+		// Iceberg table as new snapshots.
+		namePattern = fmt.Sprintf("%sexport%s-%s.%s",
+			exportIcebergDataDir, exportID, exportFilePatternPart, parquetSuffix)
+	} else {
+		exportFilePattern := exportFilePatternPart + "." + fileSuffix
+		namePattern = fmt.Sprintf("export%s-%s", exportID, exportFilePattern)
+	}
 	return &exportNode{
 		source:          input.(planNode),
 		destination:     string(*destination),
@@ -241,5 +257,6 @@ func (ef *execFactory) ConstructExport(
 		chunkRows:       chunkRows,
 		chunkSize:       chunkSize,
 		colNames:        colNames,
+		iceberg:         fileSuffix == icebergSuffix,
 	}, nil
 }
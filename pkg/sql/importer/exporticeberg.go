@@ -0,0 +1,155 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package importer
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/cloud"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/colinfo"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowexec"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/iceberg"
+	"github.com/cockroachdb/cockroach/pkg/util/tracing"
+	"github.com/cockroachdb/errors"
+	"github.com/fraugster/parquet-go/parquetschema"
+)
+
+// icebergCommitterProcessor commits the Parquet files written by the
+// parquetWriterProcessors of an EXPORT INTO ICEBERG to the Iceberg table at
+// the export destination, as a single snapshot. The rows describing the files
+// are only output once the snapshot is committed.
+type icebergCommitterProcessor struct {
+	flowCtx     *execinfra.FlowCtx
+	processorID int32
+	spec        execinfrapb.IcebergCommitterSpec
+	input       execinfra.RowSource
+	out         execinfra.ProcOutputHelper
+	output      execinfra.RowReceiver
+}
+
+var _ execinfra.Processor = &icebergCommitterProcessor{}
+
+func newIcebergCommitterProcessor(
+	flowCtx *execinfra.FlowCtx,
+	processorID int32,
+	spec execinfrapb.IcebergCommitterSpec,
+	input execinfra.RowSource,
+	output execinfra.RowReceiver,
+) (execinfra.Processor, error) {
+	c := &icebergCommitterProcessor{
+		flowCtx:     flowCtx,
+		processorID: processorID,
+		spec:        spec,
+		input:       input,
+		output:      output,
+	}
+	semaCtx := tree.MakeSemaContext()
+	if err := c.out.Init(&execinfrapb.PostProcessSpec{}, c.OutputTypes(), &semaCtx, flowCtx.NewEvalCtx()); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// OutputTypes implements the execinfra.Processor interface.
+func (ic *icebergCommitterProcessor) OutputTypes() []*types.T {
+	res := make([]*types.T, len(colinfo.ExportColumns))
+	for i := range res {
+		res[i] = colinfo.ExportColumns[i].Typ
+	}
+	return res
+}
+
+// MustBeStreaming implements the execinfra.Processor interface.
+func (ic *icebergCommitterProcessor) MustBeStreaming() bool {
+	return false
+}
+
+// Run implements the execinfra.Processor interface.
+func (ic *icebergCommitterProcessor) Run(ctx context.Context) {
+	ctx, span := tracing.ChildSpan(ctx, "icebergCommitter")
+	defer span.Finish()
+
+	err := func() error {
+		ic.input.Start(ctx)
+		input := execinfra.MakeNoMetadataRowSource(ic.input, ic.output)
+		alloc := &tree.DatumAlloc{}
+		typs := ic.OutputTypes()
+
+		location, err := iceberg.Location(ic.spec.Export.Destination)
+		if err != nil {
+			return err
+		}
+		var rows []rowenc.EncDatumRow
+		var files []iceberg.DataFile
+		for {
+			row, err := input.NextRow()
+			if err != nil {
+				return err
+			}
+			if row == nil {
+				break
+			}
+			for i := range row {
+				if err := row[i].EnsureDecoded(typs[i], alloc); err != nil {
+					return err
+				}
+			}
+			files = append(files, iceberg.DataFile{
+				Path:        location + "/" + string(tree.MustBeDString(row[0].Datum)),
+				RecordCount: int64(tree.MustBeDInt(row[1].Datum)),
+				SizeBytes:   int64(tree.MustBeDInt(row[2].Datum)),
+			})
+			rows = append(rows, row.Copy())
+		}
+
+		parquetColumns, err := newParquetColumns(ic.spec.ColTypes, ic.spec.Export)
+		if err != nil {
+			return err
+		}
+		conf, err := cloud.ExternalStorageConfFromURI(ic.spec.Export.Destination, ic.spec.Export.User())
+		if err != nil {
+			return err
+		}
+		es, err := ic.flowCtx.Cfg.ExternalStorage(ctx, conf)
+		if err != nil {
+			return err
+		}
+		defer es.Close()
+		table := iceberg.NewTable(es, "" /* dir */, location)
+		schema := []*parquetschema.SchemaDefinition{NewParquetSchema(parquetColumns)}
+		if err := table.Append(ctx, schema, files, nil /* properties */); err != nil {
+			return errors.Wrap(err, "committing exported files to iceberg table")
+		}
+
+		for _, row := range rows {
+			cs, err := ic.out.EmitRow(ctx, row, ic.output)
+			if err != nil {
+				return err
+			}
+			if cs != execinfra.NeedMoreRows {
+				return errors.New("unexpected closure of consumer")
+			}
+		}
+		return nil
+	}()
+
+	execinfra.DrainAndClose(
+		ctx, ic.output, err, func(context.Context) {} /* pushTrailingMeta */, ic.input)
+}
+
+func init() {
+	rowexec.NewIcebergCommitterProcessor = newIcebergCommitterProcessor
+}
@@ -0,0 +1,116 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package importer_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	goparquet "github.com/fraugster/parquet-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportIceberg(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	dir, dirCleanupFn := testutils.TempDir(t)
+	defer dirCleanupFn()
+	srv, db, _ := serverutils.StartServer(t, base.TestServerArgs{
+		UseDatabase:   "d",
+		ExternalIODir: dir,
+	})
+	ctx := context.Background()
+	defer srv.Stopper().Stop(ctx)
+	sqlDB := sqlutils.MakeSQLRunner(db)
+
+	sqlDB.Exec(t, `CREATE DATABASE d`)
+	sqlDB.Exec(t, `CREATE TABLE foo (i INT PRIMARY KEY, s STRING)`)
+	sqlDB.Exec(t, `INSERT INTO foo VALUES (1, 'one'), (2, 'two'), (3, 'three')`)
+
+	type metadata struct {
+		Location          string `json:"location"`
+		CurrentSnapshotID int64  `json:"current-snapshot-id"`
+		Schema            struct {
+			Fields []struct {
+				ID       int    `json:"id"`
+				Name     string `json:"name"`
+				Required bool   `json:"required"`
+				Type     string `json:"type"`
+			} `json:"fields"`
+		} `json:"schema"`
+		Snapshots []struct {
+			SnapshotID int64             `json:"snapshot-id"`
+			Summary    map[string]string `json:"summary"`
+		} `json:"snapshots"`
+	}
+	readMetadata := func() metadata {
+		hint, err := ioutil.ReadFile(filepath.Join(dir, "tbl", "metadata", "version-hint.text"))
+		require.NoError(t, err)
+		b, err := ioutil.ReadFile(filepath.Join(dir, "tbl", "metadata", fmt.Sprintf("v%s.metadata.json", hint)))
+		require.NoError(t, err)
+		var m metadata
+		require.NoError(t, json.Unmarshal(b, &m))
+		return m
+	}
+
+	const stmt = `EXPORT INTO ICEBERG 'nodelocal://0/tbl' WITH chunk_rows = 2 FROM SELECT * FROM foo`
+	rows := sqlDB.QueryStr(t, stmt)
+	require.Len(t, rows, 2)
+	for _, row := range rows {
+		require.Regexp(t, `^data/export.*\.parquet$`, row[0])
+		f, err := os.Open(filepath.Join(dir, "tbl", row[0]))
+		require.NoError(t, err)
+		fr, err := goparquet.NewFileReader(f)
+		require.NoError(t, err)
+		require.EqualValues(t, row[1], fmt.Sprint(fr.NumRows()))
+		require.NoError(t, f.Close())
+	}
+
+	m := readMetadata()
+	require.Equal(t, "nodelocal://0/tbl", m.Location)
+	require.Len(t, m.Snapshots, 1)
+	require.Equal(t, m.Snapshots[0].SnapshotID, m.CurrentSnapshotID)
+	require.Equal(t, "append", m.Snapshots[0].Summary["operation"])
+	require.Equal(t, "2", m.Snapshots[0].Summary["added-data-files"])
+	require.Equal(t, "3", m.Snapshots[0].Summary["total-records"])
+	require.Len(t, m.Schema.Fields, 2)
+	require.Equal(t, "i", m.Schema.Fields[0].Name)
+	require.Equal(t, "long", m.Schema.Fields[0].Type)
+	require.True(t, m.Schema.Fields[0].Required)
+	require.Equal(t, "s", m.Schema.Fields[1].Name)
+	require.Equal(t, "string", m.Schema.Fields[1].Type)
+	require.False(t, m.Schema.Fields[1].Required)
+
+	// A second export appends a snapshot to the table.
+	sqlDB.Exec(t, `INSERT INTO foo VALUES (4, 'four')`)
+	sqlDB.Exec(t, stmt)
+	m = readMetadata()
+	require.Len(t, m.Snapshots, 2)
+	require.Equal(t, m.Snapshots[1].SnapshotID, m.CurrentSnapshotID)
+	require.Equal(t, "2", m.Snapshots[1].Summary["added-data-files"])
+	require.Equal(t, "4", m.Snapshots[1].Summary["added-records"])
+	require.Equal(t, "7", m.Snapshots[1].Summary["total-records"])
+
+	// Exports with a schema that the table cannot evolve to are rejected.
+	sqlDB.ExpectErr(t, `column i changed type from long to string`,
+		`EXPORT INTO ICEBERG 'nodelocal://0/tbl' FROM SELECT s AS i FROM foo`)
+}
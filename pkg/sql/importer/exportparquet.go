@@ -131,7 +131,7 @@ func newParquetExporter(sp execinfrapb.ExportSpec, typs []*types.T) (*parquetExp
 	if err != nil {
 		return nil, err
 	}
-	schema := newParquetSchema(parquetColumns)
+	schema := NewParquetSchema(parquetColumns)
 
 	exporter = &parquetExporter{
 		buf:            buf,
@@ -159,6 +159,24 @@ type ParquetColumn struct {
 	DecodeFn func(interface{}) (tree.Datum, error)
 }
 
+// Name returns the name of the parquet column.
+func (c *ParquetColumn) Name() string {
+	return c.name
+}
+
+// Encode converts a datum of the column into the native go type that the
+// parquet vendor expects when adding data to a file. NULL datums are encoded
+// as nil.
+func (c *ParquetColumn) Encode(d tree.Datum) (interface{}, error) {
+	if d == tree.DNull {
+		return nil, nil
+	}
+	// If we're encoding a DOidWrapper, then we want to cast the wrapped datum.
+	// Note that we pass in nil as the first argument since we're not interested
+	// in evaluating the evalCtx's placeholders.
+	return c.encodeFn(eval.UnwrapDatum(nil, d))
+}
+
 // newParquetColumns creates a list of parquet columns, given the input relation's column types.
 func newParquetColumns(typs []*types.T, sp execinfrapb.ExportSpec) ([]ParquetColumn, error) {
 	parquetColumns := make([]ParquetColumn, len(typs))
@@ -657,12 +675,12 @@ func NewParquetColumn(typ *types.T, name string, nullable bool) (ParquetColumn,
 	return col, nil
 }
 
-// newParquetSchema creates the schema for the parquet file,
+// NewParquetSchema creates the schema for the parquet file,
 // see example schema:
 //     https://github.com/fraugster/parquet-go/issues/18#issuecomment-946013210
 // see docs here:
 //     https://pkg.go.dev/github.com/fraugster/parquet-go/parquetschema#SchemaDefinition
-func newParquetSchema(parquetFields []ParquetColumn) *parquetschema.SchemaDefinition {
+func NewParquetSchema(parquetFields []ParquetColumn) *parquetschema.SchemaDefinition {
 	schemaDefinition := new(parquetschema.SchemaDefinition)
 	schemaDefinition.RootColumn = new(parquetschema.ColumnDefinition)
 	schemaDefinition.RootColumn.SchemaElement = parquet.NewSchemaElement()
@@ -22,6 +22,7 @@ import (
 	"strings"
 
 	"github.com/cockroachdb/cockroach/pkg/cloud"
+	"github.com/cockroachdb/cockroach/pkg/clusterversion"
 	"github.com/cockroachdb/cockroach/pkg/featureflag"
 	"github.com/cockroachdb/cockroach/pkg/jobs"
 	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
@@ -168,6 +169,8 @@ var avroAllowedOptions = makeStringSet(
 	avroRecordsSeparatedBy, avroSchema, avroSchemaURI, optMaxRowSize, csvRowLimit,
 )
 
+var parquetAllowedOptions = makeStringSet(avroStrict, csvRowLimit)
+
 var csvAllowedOptions = makeStringSet(
 	csvDelimiter, csvComment, csvNullIf, csvSkip, csvStrictQuotes, csvRowLimit,
 )
@@ -194,6 +197,7 @@ var allowedIntoFormats = map[string]struct{}{
 	"AVRO":      {},
 	"DELIMITED": {},
 	"PGCOPY":    {},
+	"PARQUET":   {},
 }
 
 // featureImportEnabled is used to enable and disable the IMPORT feature.
@@ -730,6 +734,29 @@ func importPlanHook(
 			if err != nil {
 				return err
 			}
+		case "PARQUET":
+			// Nodes running an older version cannot process the import specs of
+			// Parquet files.
+			if !p.ExecCfg().Settings.Version.IsActive(ctx, clusterversion.ImportParquet) {
+				return pgerror.Newf(pgcode.FeatureNotSupported,
+					"IMPORT ... PARQUET is not supported until upgrade to version %s is finalized",
+					clusterversion.ImportParquet.String())
+			}
+			if err = validateFormatOptions(importStmt.FileFormat, opts, parquetAllowedOptions); err != nil {
+				return err
+			}
+			format.Format = roachpb.IOFileFormat_Parquet
+			_, format.Parquet.StrictMode = opts[avroStrict]
+			if override, ok := opts[csvRowLimit]; ok {
+				rowLimit, err := strconv.Atoi(override)
+				if err != nil {
+					return pgerror.Wrapf(err, pgcode.Syntax, "invalid numeric %s value", csvRowLimit)
+				}
+				if rowLimit <= 0 {
+					return pgerror.Newf(pgcode.Syntax, "%s must be > 0", csvRowLimit)
+				}
+				format.Parquet.RowLimit = int64(rowLimit)
+			}
 		default:
 			return unimplemented.Newf("import.format", "unsupported import format: %q", importStmt.FileFormat)
 		}
@@ -912,6 +939,17 @@ func importPlanHook(
 			telemetry.Count("import.into")
 		}
 
+		// The row groups of parquet files are split into separate inputs, so
+		// that large files are imported in parallel.
+		if format.Format == roachpb.IOFileFormat_Parquet {
+			files, err = splitParquetFiles(
+				ctx, p.ExecCfg().DistSQLSrv.ExternalStorageFromURI, p.User(), files, &format.Parquet,
+			)
+			if err != nil {
+				return err
+			}
+		}
+
 		// Here we create the job in a side transaction and then kick off the job.
 		// This is awful. Rather we should be disallowing this statement in an
 		// explicit transaction and then we should create the job in the user's
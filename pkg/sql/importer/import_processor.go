@@ -327,6 +327,10 @@ func makeInputConverter(
 		return newAvroInputReader(
 			semaCtx, kvCh, singleTable, spec.Format.Avro, spec.WalltimeNanos,
 			readerParallelism, evalCtx, db)
+	case roachpb.IOFileFormat_Parquet:
+		return newParquetInputReader(
+			semaCtx, kvCh, singleTable, spec.Format.Parquet, spec.WalltimeNanos,
+			readerParallelism, evalCtx, db)
 	default:
 		return nil, errors.Errorf(
 			"Requested IMPORT format (%d) not supported by this node", spec.Format.Format)
@@ -23,6 +23,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/jobs"
 	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/server"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
 	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
 	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
@@ -110,3 +111,32 @@ func TestImportMixedVersion(t *testing.T) {
 		sqlDB.Exec(t, `DROP TABLE IF EXISTS t`)
 	}
 }
+
+func TestImportParquetMixedVersion(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	dir, dirCleanupFn := testutils.TempDir(t)
+	defer dirCleanupFn()
+	tc := serverutils.StartNewTestCluster(
+		t, 1, base.TestClusterArgs{ServerArgs: base.TestServerArgs{
+			ExternalIODir: dir,
+			Knobs: base.TestingKnobs{
+				Server: &server.TestingKnobs{
+					DisableAutomaticVersionUpgrade: make(chan struct{}),
+					BinaryVersionOverride:          clusterversion.ByKey(clusterversion.ImportParquet - 1),
+				},
+			},
+		}})
+	defer tc.Stopper().Stop(ctx)
+	sqlDB := sqlutils.MakeSQLRunner(tc.ServerConn(0))
+
+	// Parquet files cannot be imported until all the nodes can read them.
+	sqlDB.Exec(t, `CREATE TABLE t (a INT PRIMARY KEY)`)
+	sqlDB.ExpectErr(t, `IMPORT \.\.\. PARQUET is not supported until upgrade to version`,
+		`IMPORT INTO t PARQUET DATA ('nodelocal://0/t.parquet')`)
+
+	sqlDB.Exec(t, `SET CLUSTER SETTING version = $1`, clusterversion.ByKey(clusterversion.ImportParquet).String())
+	sqlDB.ExpectErr(t, `no such file`, `IMPORT INTO t PARQUET DATA ('nodelocal://0/t.parquet')`)
+}
@@ -384,7 +384,8 @@ func formatHasNamedColumns(format roachpb.IOFileFormat_FileFormat) bool {
 	switch format {
 	case roachpb.IOFileFormat_Avro,
 		roachpb.IOFileFormat_Mysqldump,
-		roachpb.IOFileFormat_PgDump:
+		roachpb.IOFileFormat_PgDump,
+		roachpb.IOFileFormat_Parquet:
 		return true
 	}
 	return false
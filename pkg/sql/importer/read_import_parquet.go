@@ -0,0 +1,684 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package importer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/cockroachdb/apd/v3"
+	"github.com/cockroachdb/cockroach/pkg/cloud"
+	"github.com/cockroachdb/cockroach/pkg/geo"
+	"github.com/cockroachdb/cockroach/pkg/geo/geopb"
+	"github.com/cockroachdb/cockroach/pkg/kv"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/security/username"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
+	"github.com/cockroachdb/cockroach/pkg/sql/lexbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/row"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/eval"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/ctxgroup"
+	"github.com/cockroachdb/cockroach/pkg/util/timeofday"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil/pgdate"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+	"github.com/cockroachdb/errors"
+	goparquet "github.com/fraugster/parquet-go"
+	"github.com/fraugster/parquet-go/parquet"
+	"github.com/fraugster/parquet-go/parquetschema"
+)
+
+var parquetImportSplitSize int64 = 64 << 20
+
+// TestingSetParquetImportSplitSize is a testing knob to modify the size of the
+// row groups that IMPORT reads from parquet files in each input.
+// Returns a function that resets the value back to the default.
+func TestingSetParquetImportSplitSize(s int64) func() {
+	parquetImportSplitSize = s
+	return func() {
+		parquetImportSplitSize = 64 << 20
+	}
+}
+
+// splitParquetFiles splits the given parquet files into inputs which each hold
+// row groups of at most parquetImportSplitSize bytes, or a single row group,
+// so that the row groups of large files are read in parallel by different
+// import processors. It returns the URIs of the inputs, in which each file
+// appears once per range of its row groups, and sets the row groups of the
+// inputs in opts.
+func splitParquetFiles(
+	ctx context.Context,
+	makeExternalStorageFromURI cloud.ExternalStorageFromURIFactory,
+	user username.SQLUsername,
+	files []string,
+	opts *roachpb.ParquetOptions,
+) ([]string, error) {
+	// The row limit applies to each file, so files are not split when it is
+	// set.
+	if opts.RowLimit != 0 {
+		return files, nil
+	}
+	var inputs []string
+	var rowGroups []roachpb.ParquetOptions_RowGroupRange
+	for _, file := range files {
+		meta, err := func() (*parquet.FileMetaData, error) {
+			es, err := makeExternalStorageFromURI(ctx, file, user)
+			if err != nil {
+				return nil, err
+			}
+			defer es.Close()
+			r := cloud.NewReadSeeker(ctx, es, "")
+			defer r.Close()
+			return goparquet.ReadFileMetaData(r, false /* extraValidation */)
+		}()
+		if err != nil {
+			return nil, errors.Wrap(err, "reading parquet footer")
+		}
+		start, size := 0, int64(0)
+		for i, rg := range meta.RowGroups {
+			if i > start && size+rg.TotalByteSize > parquetImportSplitSize {
+				inputs = append(inputs, file)
+				rowGroups = append(rowGroups, roachpb.ParquetOptions_RowGroupRange{
+					Start: int32(start), End: int32(i),
+				})
+				start, size = i, 0
+			}
+			size += rg.TotalByteSize
+		}
+		inputs = append(inputs, file)
+		rowGroups = append(rowGroups, roachpb.ParquetOptions_RowGroupRange{
+			Start: int32(start), End: int32(len(meta.RowGroups)),
+		})
+	}
+	opts.RowGroups = rowGroups
+	return inputs, nil
+}
+
+// parquetInputReader is an inputConverter for parquet files. The columns of
+// the files are imported into the columns of the table with the same name,
+// and their values are converted to the types of the columns according to
+// their parquet types.
+type parquetInputReader struct {
+	importContext *parallelImportContext
+	opts          roachpb.ParquetOptions
+}
+
+var _ inputConverter = &parquetInputReader{}
+
+func newParquetInputReader(
+	semaCtx *tree.SemaContext,
+	kvCh chan row.KVBatch,
+	tableDesc catalog.TableDescriptor,
+	parquetOpts roachpb.ParquetOptions,
+	walltime int64,
+	parallelism int,
+	evalCtx *eval.Context,
+	db *kv.DB,
+) (*parquetInputReader, error) {
+	return &parquetInputReader{
+		importContext: &parallelImportContext{
+			semaCtx:    semaCtx,
+			walltime:   walltime,
+			numWorkers: parallelism,
+			evalCtx:    evalCtx,
+			tableDesc:  tableDesc,
+			kvCh:       kvCh,
+			db:         db,
+		},
+		opts: parquetOpts,
+	}, nil
+}
+
+func (p *parquetInputReader) start(group ctxgroup.Group) {}
+
+// readFiles implements the inputConverter interface. Unlike the other
+// formats, parquet files are not read as a stream by readInputFiles, since
+// their schema is in a footer at their end and their row groups are read
+// independently.
+func (p *parquetInputReader) readFiles(
+	ctx context.Context,
+	dataFiles map[int32]string,
+	resumePos map[int32]int64,
+	format roachpb.IOFileFormat,
+	makeExternalStorage cloud.ExternalStorageFactory,
+	user username.SQLUsername,
+) error {
+	for dataFileIndex, dataFile := range dataFiles {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		if err := p.readFile(
+			ctx, dataFile, dataFileIndex, resumePos[dataFileIndex], makeExternalStorage, user,
+		); err != nil {
+			return errors.Wrapf(err, "%s", dataFile)
+		}
+	}
+	return nil
+}
+
+func (p *parquetInputReader) readFile(
+	ctx context.Context,
+	dataFile string,
+	inputIdx int32,
+	resumePos int64,
+	makeExternalStorage cloud.ExternalStorageFactory,
+	user username.SQLUsername,
+) error {
+	conf, err := cloud.ExternalStorageConfFromURI(dataFile, user)
+	if err != nil {
+		return err
+	}
+	es, err := makeExternalStorage(ctx, conf)
+	if err != nil {
+		return err
+	}
+	defer es.Close()
+	r := cloud.NewReadSeeker(ctx, es, "")
+	defer r.Close()
+
+	meta, err := goparquet.ReadFileMetaData(r, false /* extraValidation */)
+	if err != nil {
+		return errors.Wrap(err, "reading parquet footer")
+	}
+	start, end := 0, len(meta.RowGroups)
+	if int(inputIdx) < len(p.opts.RowGroups) {
+		rg := p.opts.RowGroups[inputIdx]
+		start, end = int(rg.Start), int(rg.End)
+		if end > len(meta.RowGroups) {
+			return errors.Errorf("file has %d row groups, expected at least %d", len(meta.RowGroups), end)
+		}
+	}
+	var numRows int64
+	for _, rg := range meta.RowGroups[start:end] {
+		numRows += rg.NumRows
+	}
+	if numRows == 0 {
+		return nil
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	fr, err := goparquet.NewFileReader(r)
+	if err != nil {
+		return err
+	}
+	if start > 0 {
+		if err := fr.SeekToRowGroup(start); err != nil {
+			return err
+		}
+	}
+	consumer, err := newParquetConsumer(p.importContext, fr.GetSchemaDefinition(), p.opts.StrictMode)
+	if err != nil {
+		return err
+	}
+	producer := &parquetRowStream{fr: fr, total: numRows}
+	fileCtx := &importFileContext{
+		source:   inputIdx,
+		skip:     resumePos,
+		rowLimit: p.opts.RowLimit,
+	}
+	return runParallelImport(ctx, p.importContext, fileCtx, producer, consumer)
+}
+
+// parquetRowStream produces the records of a range of row groups of a parquet
+// file.
+type parquetRowStream struct {
+	fr *goparquet.FileReader
+	// total is the number of records in the row groups, and read the number
+	// of records read so far.
+	total, read int64
+	record      map[string]interface{}
+	err         error
+}
+
+var _ importRowProducer = &parquetRowStream{}
+
+// Scan implements the importRowProducer interface.
+func (s *parquetRowStream) Scan() bool {
+	if s.err != nil || s.read >= s.total {
+		return false
+	}
+	s.record, s.err = s.fr.NextRow()
+	if s.err != nil {
+		if s.err == io.EOF {
+			s.err = errors.Errorf("unexpected end of file after %d of %d records", s.read, s.total)
+		}
+		return false
+	}
+	s.read++
+	return true
+}
+
+// Err implements the importRowProducer interface.
+func (s *parquetRowStream) Err() error {
+	return s.err
+}
+
+// Skip implements the importRowProducer interface.
+func (s *parquetRowStream) Skip() error {
+	// Records are read by Scan already.
+	return nil
+}
+
+// Row implements the importRowProducer interface.
+func (s *parquetRowStream) Row() (interface{}, error) {
+	return s.record, nil
+}
+
+// Progress implements the importRowProducer interface.
+func (s *parquetRowStream) Progress() float32 {
+	return float32(s.read) / float32(s.total)
+}
+
+// parquetDecoder converts a value read from a parquet file into a datum.
+type parquetDecoder func(v interface{}, evalCtx *eval.Context) (tree.Datum, error)
+
+// parquetImportColumn is a column of a parquet file which is imported into a
+// column of the table.
+type parquetImportColumn struct {
+	name string
+	// idx is the index of the visible column of the table that the column is
+	// imported into.
+	idx    int
+	decode parquetDecoder
+}
+
+// parquetConsumer implements the importRowConsumer interface.
+type parquetConsumer struct {
+	cols   []parquetImportColumn
+	strict bool
+}
+
+var _ importRowConsumer = &parquetConsumer{}
+
+func newParquetConsumer(
+	importCtx *parallelImportContext, def *parquetschema.SchemaDefinition, strict bool,
+) (*parquetConsumer, error) {
+	visibleCols := importCtx.tableDesc.VisibleColumns()
+	colIdxByName := make(map[string]int, len(visibleCols))
+	for idx, col := range visibleCols {
+		colIdxByName[col.GetName()] = idx
+	}
+
+	c := &parquetConsumer{strict: strict}
+	for _, colDef := range def.RootColumn.Children {
+		name := colDef.SchemaElement.Name
+		idx, ok := colIdxByName[lexbase.NormalizeName(name)]
+		if !ok {
+			if strict {
+				return nil, errors.Errorf("could not find column for parquet column %s", name)
+			}
+			continue
+		}
+		decode, err := makeParquetDecoder(colDef, visibleCols[idx].GetType())
+		if err != nil {
+			return nil, errors.Wrapf(err, "parquet column %s", name)
+		}
+		c.cols = append(c.cols, parquetImportColumn{name: name, idx: idx, decode: decode})
+	}
+	return c, nil
+}
+
+// FillDatums implements the importRowConsumer interface.
+func (c *parquetConsumer) FillDatums(
+	native interface{}, rowIndex int64, conv *row.DatumRowConverter,
+) error {
+	record, ok := native.(map[string]interface{})
+	if !ok {
+		return errors.AssertionFailedf("unexpected parquet record of type %T", native)
+	}
+	for _, col := range c.cols {
+		// The values of the records are absent when they are null.
+		datum, err := col.decode(record[col.name], conv.EvalCtx)
+		if err != nil {
+			return errors.Wrapf(err, "parquet column %s", col.name)
+		}
+		conv.Datums[col.idx] = datum
+	}
+
+	for i := range conv.Datums {
+		if conv.TargetColOrds.Contains(i) && conv.Datums[i] == nil {
+			if c.strict {
+				return fmt.Errorf("column %s is not in the parquet file", conv.VisibleCols[i].GetName())
+			}
+			conv.Datums[i] = tree.DNull
+		}
+	}
+	return nil
+}
+
+// makeParquetDecoder returns the decoder of the values of a parquet column
+// into datums of the given type.
+func makeParquetDecoder(col *parquetschema.ColumnDefinition, typ *types.T) (parquetDecoder, error) {
+	el := col.SchemaElement
+	if isParquetList(col) {
+		if typ.Family() != types.ArrayFamily {
+			return nil, errors.Errorf("cannot import a parquet list into a column of type %s", typ)
+		}
+		listName := col.Children[0].SchemaElement.Name
+		elementName := col.Children[0].Children[0].SchemaElement.Name
+		decodeElement, err := makeParquetDecoder(col.Children[0].Children[0], typ.ArrayContents())
+		if err != nil {
+			return nil, err
+		}
+		return func(v interface{}, evalCtx *eval.Context) (tree.Datum, error) {
+			if v == nil {
+				return tree.DNull, nil
+			}
+			list, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, errors.Errorf("unexpected parquet list value of type %T", v)
+			}
+			elements, _ := list[listName].([]map[string]interface{})
+			arr := tree.NewDArray(typ.ArrayContents())
+			// Empty lists are read as a list of a single empty element.
+			if len(elements) == 1 && len(elements[0]) == 0 {
+				return arr, nil
+			}
+			for _, element := range elements {
+				d, err := decodeElement(element[elementName], evalCtx)
+				if err != nil {
+					return nil, err
+				}
+				if err := arr.Append(d); err != nil {
+					return nil, err
+				}
+			}
+			return arr, nil
+		}, nil
+	}
+	if len(col.Children) > 0 || el.Type == nil {
+		return nil, errors.New("parquet groups other than lists are not supported")
+	}
+	return func(v interface{}, evalCtx *eval.Context) (tree.Datum, error) {
+		return parquetValueToDatum(v, el, typ, evalCtx)
+	}, nil
+}
+
+// isParquetList returns whether the column is a list, made of a group holding
+// a repeated group of the elements of the list.
+func isParquetList(col *parquetschema.ColumnDefinition) bool {
+	el := col.SchemaElement
+	isList := (el.LogicalType != nil && el.LogicalType.LIST != nil) ||
+		(el.ConvertedType != nil && *el.ConvertedType == parquet.ConvertedType_LIST)
+	return isList && len(col.Children) == 1 && len(col.Children[0].Children) == 1
+}
+
+// parquetValueToDatum converts a value of a parquet column, as read by the
+// parquet library, into a datum of the given type. The logical type of the
+// column determines how the value is interpreted, and values which have no
+// direct conversion to the type are parsed from their string representation.
+func parquetValueToDatum(
+	v interface{}, el *parquet.SchemaElement, typ *types.T, evalCtx *eval.Context,
+) (tree.Datum, error) {
+	switch x := v.(type) {
+	case nil:
+		return tree.DNull, nil
+	case bool:
+		if typ.Family() == types.BoolFamily {
+			return tree.MakeDBool(tree.DBool(x)), nil
+		}
+		return rowenc.ParseDatumStringAs(typ, strconv.FormatBool(x), evalCtx)
+	case int32:
+		switch {
+		case isParquetDate(el):
+			d, err := pgdate.MakeDateFromUnixEpoch(int64(x))
+			if err != nil {
+				return nil, err
+			}
+			return dateToDatum(d, typ, evalCtx)
+		case parquetTimeUnit(el) != 0:
+			return timeOfDayToDatum(timeofday.TimeOfDay(int64(x)*int64(parquetTimeUnit(el)/time.Microsecond)), typ, evalCtx)
+		}
+		if scale, ok := parquetDecimalScale(el); ok {
+			return decimalToDatum(apd.New(int64(x), -scale), typ, evalCtx)
+		}
+		return intToDatum(int64(x), typ, evalCtx)
+	case int64:
+		if unit := parquetTimestampUnit(el); unit != 0 {
+			return timeToDatum(timeutil.Unix(0, x*int64(unit)), typ, evalCtx)
+		}
+		if unit := parquetTimeUnit(el); unit != 0 {
+			return timeOfDayToDatum(timeofday.TimeOfDay(x*int64(unit)/int64(time.Microsecond)), typ, evalCtx)
+		}
+		if scale, ok := parquetDecimalScale(el); ok {
+			return decimalToDatum(apd.New(x, -scale), typ, evalCtx)
+		}
+		return intToDatum(x, typ, evalCtx)
+	case [12]byte:
+		// INT96 values are legacy timestamps.
+		return timeToDatum(goparquet.Int96ToTime(x), typ, evalCtx)
+	case float32:
+		// Converting the float32 to a float64 directly would add digits which
+		// were not in the original value.
+		f, err := strconv.ParseFloat(strconv.FormatFloat(float64(x), 'g', -1, 32), 64)
+		if err != nil {
+			return nil, err
+		}
+		return floatToDatum(f, typ, evalCtx)
+	case float64:
+		return floatToDatum(x, typ, evalCtx)
+	case []byte:
+		if scale, ok := parquetDecimalScale(el); ok {
+			// EXPORT writes decimals as text, while other writers use the
+			// unscaled value in two's complement.
+			if *el.Type == parquet.Type_BYTE_ARRAY {
+				if d, _, err := apd.NewFromString(string(x)); err == nil {
+					return decimalToDatum(d, typ, evalCtx)
+				}
+			}
+			unscaled := new(big.Int).SetBytes(x)
+			if len(x) > 0 && x[0]&0x80 != 0 {
+				unscaled.Sub(unscaled, new(big.Int).Lsh(big.NewInt(1), uint(len(x))*8))
+			}
+			var coeff apd.BigInt
+			coeff.SetMathBigInt(unscaled)
+			return decimalToDatum(apd.NewWithBigInt(&coeff, -scale), typ, evalCtx)
+		}
+		if el.LogicalType != nil && el.LogicalType.UUID != nil {
+			u, err := uuid.FromBytes(x)
+			if err != nil {
+				return nil, err
+			}
+			if typ.Family() == types.UuidFamily {
+				return tree.NewDUuid(tree.DUuid{UUID: u}), nil
+			}
+			return rowenc.ParseDatumStringAs(typ, u.String(), evalCtx)
+		}
+		return bytesToDatum(x, el, typ, evalCtx)
+	default:
+		return nil, errors.Errorf("unsupported parquet value of type %T", v)
+	}
+}
+
+func intToDatum(x int64, typ *types.T, evalCtx *eval.Context) (tree.Datum, error) {
+	switch typ.Family() {
+	case types.IntFamily:
+		return tree.NewDInt(tree.DInt(x)), nil
+	case types.FloatFamily:
+		return tree.NewDFloat(tree.DFloat(x)), nil
+	case types.DecimalFamily:
+		d := &tree.DDecimal{}
+		d.SetInt64(x)
+		return d, nil
+	}
+	return rowenc.ParseDatumStringAs(typ, strconv.FormatInt(x, 10), evalCtx)
+}
+
+func floatToDatum(f float64, typ *types.T, evalCtx *eval.Context) (tree.Datum, error) {
+	switch typ.Family() {
+	case types.FloatFamily:
+		return tree.NewDFloat(tree.DFloat(f)), nil
+	case types.DecimalFamily:
+		d := &tree.DDecimal{}
+		if _, err := d.SetFloat64(f); err != nil {
+			return nil, err
+		}
+		return d, nil
+	}
+	return rowenc.ParseDatumStringAs(typ, strconv.FormatFloat(f, 'g', -1, 64), evalCtx)
+}
+
+func decimalToDatum(d *apd.Decimal, typ *types.T, evalCtx *eval.Context) (tree.Datum, error) {
+	if typ.Family() == types.DecimalFamily {
+		return &tree.DDecimal{Decimal: *d}, nil
+	}
+	return rowenc.ParseDatumStringAs(typ, d.String(), evalCtx)
+}
+
+func timeToDatum(t time.Time, typ *types.T, evalCtx *eval.Context) (tree.Datum, error) {
+	precision := tree.TimeFamilyPrecisionToRoundDuration(typ.Precision())
+	switch typ.Family() {
+	case types.TimestampFamily:
+		return tree.MakeDTimestamp(t.UTC(), precision)
+	case types.TimestampTZFamily:
+		return tree.MakeDTimestampTZ(t.UTC(), precision)
+	case types.DateFamily:
+		return tree.NewDDateFromTime(t.UTC())
+	}
+	return rowenc.ParseDatumStringAs(typ, t.UTC().Format(time.RFC3339Nano), evalCtx)
+}
+
+func dateToDatum(d pgdate.Date, typ *types.T, evalCtx *eval.Context) (tree.Datum, error) {
+	switch typ.Family() {
+	case types.DateFamily:
+		return tree.NewDDate(d), nil
+	case types.TimestampFamily, types.TimestampTZFamily:
+		t, err := d.ToTime()
+		if err != nil {
+			return nil, err
+		}
+		return timeToDatum(t, typ, evalCtx)
+	}
+	return rowenc.ParseDatumStringAs(typ, d.String(), evalCtx)
+}
+
+func timeOfDayToDatum(
+	t timeofday.TimeOfDay, typ *types.T, evalCtx *eval.Context,
+) (tree.Datum, error) {
+	if typ.Family() == types.TimeFamily {
+		return tree.MakeDTime(t.Round(tree.TimeFamilyPrecisionToRoundDuration(typ.Precision()))), nil
+	}
+	return rowenc.ParseDatumStringAs(typ, t.String(), evalCtx)
+}
+
+// bytesToDatum converts the value of a parquet binary column into a datum.
+// Unless the column is a string, geospatial columns hold EWKB, as written by
+// EXPORT.
+func bytesToDatum(
+	x []byte, el *parquet.SchemaElement, typ *types.T, evalCtx *eval.Context,
+) (tree.Datum, error) {
+	isString := (el.LogicalType != nil && (el.LogicalType.STRING != nil ||
+		el.LogicalType.JSON != nil || el.LogicalType.ENUM != nil)) ||
+		(el.ConvertedType != nil && (*el.ConvertedType == parquet.ConvertedType_UTF8 ||
+			*el.ConvertedType == parquet.ConvertedType_JSON || *el.ConvertedType == parquet.ConvertedType_ENUM))
+	switch typ.Family() {
+	case types.BytesFamily:
+		return tree.NewDBytes(tree.DBytes(x)), nil
+	case types.GeographyFamily:
+		if !isString {
+			g, err := geo.ParseGeographyFromEWKB(geopb.EWKB(x))
+			if err != nil {
+				return nil, err
+			}
+			return &tree.DGeography{Geography: g}, nil
+		}
+	case types.GeometryFamily:
+		if !isString {
+			g, err := geo.ParseGeometryFromEWKB(geopb.EWKB(x))
+			if err != nil {
+				return nil, err
+			}
+			return &tree.DGeometry{Geometry: g}, nil
+		}
+	}
+	return rowenc.ParseDatumStringAs(typ, string(x), evalCtx)
+}
+
+func isParquetDate(el *parquet.SchemaElement) bool {
+	return (el.LogicalType != nil && el.LogicalType.DATE != nil) ||
+		(el.ConvertedType != nil && *el.ConvertedType == parquet.ConvertedType_DATE)
+}
+
+// parquetTimeUnitDuration returns the duration of a parquet time unit.
+func parquetTimeUnitDuration(unit *parquet.TimeUnit) time.Duration {
+	switch {
+	case unit == nil:
+		return 0
+	case unit.MILLIS != nil:
+		return time.Millisecond
+	case unit.MICROS != nil:
+		return time.Microsecond
+	case unit.NANOS != nil:
+		return time.Nanosecond
+	}
+	return 0
+}
+
+// parquetTimestampUnit returns the unit of a timestamp column, or 0 if the
+// column does not hold timestamps.
+func parquetTimestampUnit(el *parquet.SchemaElement) time.Duration {
+	if el.LogicalType != nil && el.LogicalType.TIMESTAMP != nil {
+		return parquetTimeUnitDuration(el.LogicalType.TIMESTAMP.Unit)
+	}
+	if el.ConvertedType != nil {
+		switch *el.ConvertedType {
+		case parquet.ConvertedType_TIMESTAMP_MILLIS:
+			return time.Millisecond
+		case parquet.ConvertedType_TIMESTAMP_MICROS:
+			return time.Microsecond
+		}
+	}
+	return 0
+}
+
+// parquetTimeUnit returns the unit of a time of day column, or 0 if the
+// column does not hold times of day.
+func parquetTimeUnit(el *parquet.SchemaElement) time.Duration {
+	if el.LogicalType != nil && el.LogicalType.TIME != nil {
+		return parquetTimeUnitDuration(el.LogicalType.TIME.Unit)
+	}
+	if el.ConvertedType != nil {
+		switch *el.ConvertedType {
+		case parquet.ConvertedType_TIME_MILLIS:
+			return time.Millisecond
+		case parquet.ConvertedType_TIME_MICROS:
+			return time.Microsecond
+		}
+	}
+	return 0
+}
+
+// parquetDecimalScale returns the scale of a decimal column, and false if the
+// column does not hold decimals.
+func parquetDecimalScale(el *parquet.SchemaElement) (int32, bool) {
+	if el.LogicalType != nil && el.LogicalType.DECIMAL != nil {
+		return el.LogicalType.DECIMAL.Scale, true
+	}
+	if el.ConvertedType != nil && *el.ConvertedType == parquet.ConvertedType_DECIMAL {
+		var scale int32
+		if el.Scale != nil {
+			scale = *el.Scale
+		}
+		return scale, true
+	}
+	return 0, false
+}
@@ -0,0 +1,166 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package importer_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/sql/importer"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+	goparquet "github.com/fraugster/parquet-go"
+	"github.com/fraugster/parquet-go/parquetschema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportParquet(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	dir, dirCleanupFn := testutils.TempDir(t)
+	defer dirCleanupFn()
+	srv, db, _ := serverutils.StartServer(t, base.TestServerArgs{
+		UseDatabase:   "d",
+		ExternalIODir: dir,
+	})
+	ctx := context.Background()
+	defer srv.Stopper().Stop(ctx)
+	sqlDB := sqlutils.MakeSQLRunner(db)
+	sqlDB.Exec(t, `CREATE DATABASE d`)
+
+	t.Run("export-round-trip", func(t *testing.T) {
+		const schema = `(
+			id INT PRIMARY KEY, b BOOL, f FLOAT, s STRING, by BYTES, dec DECIMAL(10, 2),
+			d DATE, ts TIMESTAMP, tstz TIMESTAMPTZ, t TIME, iv INTERVAL, u UUID, j JSONB,
+			ip INET, arr INT[], sarr STRING[], e greeting
+		)`
+		sqlDB.Exec(t, `CREATE TYPE greeting AS ENUM ('hi', 'hello')`)
+		sqlDB.Exec(t, `CREATE TABLE src `+schema)
+		sqlDB.Exec(t, `INSERT INTO src VALUES
+			(1, true, 1.5, 'one', 'b1', 1.25, '2022-01-02', '2022-01-02 03:04:05.678901',
+			 '2022-01-02 03:04:05+00', '12:34:56.789', '1 day 2 hours', 'a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11',
+			 '{"a": [1, 2]}', '192.168.0.1', ARRAY[1, NULL, 3], ARRAY['x'], 'hi'),
+			(2, false, -2.25, '', '', -3.00, '1970-01-01', '1999-12-31 23:59:59',
+			 '2000-01-01 00:00:00+00', '00:00:00', '-3 seconds', '00000000-0000-0000-0000-000000000000',
+			 'null', '::1', ARRAY[]::INT[], ARRAY[]::STRING[], 'hello'),
+			(3, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL)`)
+		sqlDB.Exec(t, `EXPORT INTO PARQUET 'nodelocal://0/round-trip' WITH chunk_rows = 2 FROM TABLE src`)
+
+		sqlDB.Exec(t, `CREATE TABLE dst `+schema)
+		sqlDB.Exec(t, `IMPORT INTO dst PARQUET DATA ('nodelocal://0/round-trip/*.parquet')`)
+		sqlDB.CheckQueryResults(t, `SELECT * FROM dst ORDER BY id`,
+			sqlDB.QueryStr(t, `SELECT * FROM src ORDER BY id`))
+	})
+
+	writeParquet := func(t *testing.T, name, schema string, rowGroupSize int, records []map[string]interface{}) {
+		def, err := parquetschema.ParseSchemaDefinition(schema)
+		require.NoError(t, err)
+		f, err := os.Create(filepath.Join(dir, name))
+		require.NoError(t, err)
+		defer f.Close()
+		w := goparquet.NewFileWriter(f, goparquet.WithSchemaDefinition(def))
+		for i, record := range records {
+			require.NoError(t, w.AddData(record))
+			if (i+1)%rowGroupSize == 0 {
+				require.NoError(t, w.FlushRowGroup())
+			}
+		}
+		require.NoError(t, w.Close())
+	}
+
+	t.Run("logical-types", func(t *testing.T) {
+		// Split the file into an input per row group.
+		defer importer.TestingSetParquetImportSplitSize(1)()
+
+		const schema = `message root {
+			required int64 id;
+			optional int32 day (DATE);
+			optional int64 ts (TIMESTAMP(MICROS, true));
+			optional int64 amount (DECIMAL(10, 2));
+			optional fixed_len_byte_array(16) u (UUID);
+			optional group tags (LIST) {
+				repeated group list {
+					optional binary element (STRING);
+				}
+			}
+			optional binary unknown (STRING);
+		}`
+		u := uuid.MakeV4()
+		ts := time.Date(2022, 5, 6, 7, 8, 9, 123456000, time.UTC)
+		var records []map[string]interface{}
+		for i := int64(0); i < 10; i++ {
+			records = append(records, map[string]interface{}{
+				"id":     i,
+				"day":    int32(19000 + i),
+				"ts":     ts.Add(time.Duration(i)*time.Hour).UnixNano() / 1000,
+				"amount": 12345 * i,
+				"u":      u.GetBytes(),
+				"tags": map[string]interface{}{
+					"list": []map[string]interface{}{
+						{"element": []byte("a")},
+						{"element": []byte("b")},
+					},
+				},
+				"unknown": []byte("ignored"),
+			})
+		}
+		// The last record has nulls.
+		records = append(records, map[string]interface{}{"id": int64(10)})
+		writeParquet(t, "logical.parquet", schema, 3, records)
+
+		sqlDB.Exec(t, `CREATE TABLE logical (
+			id INT PRIMARY KEY, day DATE, ts TIMESTAMPTZ, amount DECIMAL(10, 2), u UUID, tags STRING[],
+			other STRING DEFAULT 'default'
+		)`)
+		sqlDB.Exec(t, `IMPORT INTO logical (id, day, ts, amount, u, tags) PARQUET DATA ('nodelocal://0/logical.parquet')`)
+		sqlDB.CheckQueryResults(t, `SELECT count(*) FROM logical`, [][]string{{"11"}})
+		sqlDB.CheckQueryResults(t,
+			`SELECT day::STRING, ts::STRING, amount::STRING, u::STRING, tags::STRING, other FROM logical WHERE id IN (1, 10) ORDER BY id`,
+			[][]string{
+				{"2022-01-09", "2022-05-06 08:08:09.123456+00", "123.45", u.String(), "{a,b}", "default"},
+				{"NULL", "NULL", "NULL", "NULL", "NULL", "default"},
+			})
+	})
+
+	t.Run("strict-validation", func(t *testing.T) {
+		writeParquet(t, "strict.parquet", `message root {
+			required int64 a;
+			optional binary b (STRING);
+		}`, 1, []map[string]interface{}{{"a": int64(1), "b": []byte("x")}})
+
+		sqlDB.Exec(t, `CREATE TABLE strict (a INT PRIMARY KEY)`)
+		sqlDB.ExpectErr(t, "could not find column for parquet column b",
+			`IMPORT INTO strict PARQUET DATA ('nodelocal://0/strict.parquet') WITH strict_validation`)
+		sqlDB.Exec(t, `IMPORT INTO strict PARQUET DATA ('nodelocal://0/strict.parquet')`)
+		sqlDB.CheckQueryResults(t, `SELECT * FROM strict`, [][]string{{"1"}})
+	})
+
+	t.Run("row-limit", func(t *testing.T) {
+		var records []map[string]interface{}
+		for i := int64(0); i < 10; i++ {
+			records = append(records, map[string]interface{}{"a": i})
+		}
+		writeParquet(t, "limit.parquet", `message root { required int64 a; }`, 4, records)
+
+		sqlDB.Exec(t, `CREATE TABLE limited (a INT PRIMARY KEY)`)
+		sqlDB.Exec(t, `IMPORT INTO limited PARQUET DATA ('nodelocal://0/limit.parquet') WITH row_limit = '5'`)
+		sqlDB.CheckQueryResults(t, `SELECT count(*) FROM limited`, [][]string{{"5"}})
+	})
+}
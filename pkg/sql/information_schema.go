@@ -1266,6 +1266,9 @@ https://www.postgresql.org/docs/9.5/infoschema-table-constraints.html`,
 				tbNameStr := tree.NewDString(table.GetName())
 
 				for conName, c := range conInfo {
+					deferrability := c.Deferrability()
+					isDeferrable := deferrability != descpb.ConstraintDeferrability_NotDeferrable
+					initiallyDeferred := deferrability == descpb.ConstraintDeferrability_DeferrableInitiallyDeferred
 					if err := addRow(
 						dbNameStr,                       // constraint_catalog
 						scNameStr,                       // constraint_schema
@@ -1274,8 +1277,8 @@ https://www.postgresql.org/docs/9.5/infoschema-table-constraints.html`,
 						scNameStr,                       // table_schema
 						tbNameStr,                       // table_name
 						tree.NewDString(string(c.Kind)), // constraint_type
-						yesOrNoDatum(false),             // is_deferrable
-						yesOrNoDatum(false),             // initially_deferred
+						yesOrNoDatum(isDeferrable),      // is_deferrable
+						yesOrNoDatum(initiallyDeferred), // initially_deferred
 					); err != nil {
 						return err
 					}
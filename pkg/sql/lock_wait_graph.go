@@ -0,0 +1,239 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package sql
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/concurrency/lock"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/server/serverpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+)
+
+// lockWaitEdge is an edge in the wait-for graph between transactions: the
+// waiting transaction is blocked on a lock held by the blocking transaction.
+type lockWaitEdge struct {
+	waiter  *enginepb.TxnMeta
+	blocker *enginepb.TxnMeta
+	// lock is the lock that the waiter is waiting on, and wait describes the
+	// waiter's request in the lock's wait-queue.
+	lock *roachpb.LockStateInfo
+	wait lock.Waiter
+}
+
+// lockWaitGraph is the wait-for graph between transactions, assembled from the
+// contended locks in the cluster's lock tables.
+//
+// Each transaction usually waits on at most one lock at a time, but requests
+// from the same transaction can be waiting on different locks concurrently. In
+// that case, the transaction has multiple outgoing edges and the first one
+// discovered is used to compute its head blocker (see headBlocker).
+//
+// A lock held with Shared strength can have multiple holders, but the lock
+// table only reports the first one. This matches the lock table's own
+// behavior: a waiter pushes the first conflicting Shared lock holder, and then
+// the next one once it has released its lock.
+type lockWaitGraph struct {
+	edges []lockWaitEdge
+	// blockedOn maps a waiting transaction to the transactions it waits on,
+	// in the order in which the edges were added.
+	blockedOn map[uuid.UUID][]*enginepb.TxnMeta
+}
+
+// makeLockWaitGraph builds the wait-for graph from the provided locks.
+// Non-transactional waiters, waiters that are not actively waiting, and locks
+// without a holder do not contribute edges to the graph.
+func makeLockWaitGraph(locks []roachpb.LockStateInfo) lockWaitGraph {
+	g := lockWaitGraph{blockedOn: make(map[uuid.UUID][]*enginepb.TxnMeta)}
+	for i := range locks {
+		l := &locks[i]
+		if l.LockHolder == nil {
+			continue
+		}
+		for _, w := range l.Waiters {
+			if w.WaitingTxn == nil || !w.ActiveWaiter || w.WaitingTxn.ID == l.LockHolder.ID {
+				continue
+			}
+			g.edges = append(g.edges, lockWaitEdge{
+				waiter:  w.WaitingTxn,
+				blocker: l.LockHolder,
+				lock:    l,
+				wait:    w,
+			})
+			g.blockedOn[w.WaitingTxn.ID] = append(g.blockedOn[w.WaitingTxn.ID], l.LockHolder)
+		}
+	}
+	return g
+}
+
+// headBlocker follows the chain of transactions that the edge's blocking
+// transaction is itself waiting on, and returns the transaction at the head of
+// the chain, which is not waiting on any lock. depth is the number of edges
+// between the waiting transaction and the head blocker; it is 1 if the
+// blocking transaction is the head blocker.
+//
+// If the chain loops back onto itself, the transactions are deadlocked and
+// will be unblocked by the deadlock detection in the txnwait.Queue. In that
+// case, inDeadlock is returned and head is nil.
+//
+// Transactions that wait on more than one lock have more than one chain; only
+// the one that starts with their first edge is followed. headBlockers returns
+// the heads of all of the chains.
+func (g *lockWaitGraph) headBlocker(
+	e *lockWaitEdge,
+) (head *enginepb.TxnMeta, depth int, inDeadlock bool) {
+	visited := map[uuid.UUID]struct{}{e.waiter.ID: {}}
+	cur, depth := e.blocker, 1
+	for {
+		if _, ok := visited[cur.ID]; ok {
+			return nil, depth, true
+		}
+		visited[cur.ID] = struct{}{}
+		next := g.blockedOn[cur.ID]
+		if len(next) == 0 {
+			return cur, depth, false
+		}
+		cur = next[0]
+		depth++
+	}
+}
+
+// headBlockers returns all of the transactions that block other transactions
+// but are not waiting on any lock themselves, in the order of their first
+// edge. numWaiters maps each of them to the number of transactions that wait
+// on it, either directly or transitively. Transactions in a deadlock that is
+// not waiting on any other transaction don't have a head blocker.
+func (g *lockWaitGraph) headBlockers() (
+	heads []*enginepb.TxnMeta,
+	numWaiters map[uuid.UUID]int,
+) {
+	waitedOnBy := make(map[uuid.UUID][]uuid.UUID)
+	for i := range g.edges {
+		e := &g.edges[i]
+		waitedOnBy[e.blocker.ID] = append(waitedOnBy[e.blocker.ID], e.waiter.ID)
+	}
+	numWaiters = make(map[uuid.UUID]int)
+	for i := range g.edges {
+		head := g.edges[i].blocker
+		if _, ok := numWaiters[head.ID]; ok || len(g.blockedOn[head.ID]) > 0 {
+			continue
+		}
+		heads = append(heads, head)
+		// Count the transactions from which the head blocker is reachable.
+		visited := map[uuid.UUID]struct{}{head.ID: {}}
+		queue := []uuid.UUID{head.ID}
+		for len(queue) > 0 {
+			cur := queue[0]
+			queue = queue[1:]
+			for _, waiter := range waitedOnBy[cur] {
+				if _, ok := visited[waiter]; !ok {
+					visited[waiter] = struct{}{}
+					queue = append(queue, waiter)
+				}
+			}
+		}
+		numWaiters[head.ID] = len(visited) - 1
+	}
+	return heads, numWaiters
+}
+
+// loadLockWaitGraph queries the contended locks within the scope's spans and
+// builds the wait-for graph between the transactions holding and waiting on
+// them.
+func (p *planner) loadLockWaitGraph(
+	ctx context.Context, scope *clusterLocksScope,
+) (lockWaitGraph, error) {
+	var locks []roachpb.LockStateInfo
+	for _, span := range scope.spans {
+		for resumeSpan := &span; resumeSpan != nil; {
+			resp, err := p.queryLocks(ctx, *resumeSpan, false /* includeUncontended */)
+			if err != nil {
+				return lockWaitGraph{}, err
+			}
+			locks = append(locks, resp.Locks...)
+			resumeSpan = resp.ResumeSpan
+		}
+	}
+	return makeLockWaitGraph(locks), nil
+}
+
+// lockWaitSessions returns the open sessions visible to the current user,
+// keyed by the ID of the transaction that they are running.
+func (p *planner) lockWaitSessions(ctx context.Context) (map[uuid.UUID]*serverpb.Session, error) {
+	req, err := p.makeSessionsRequest(ctx, true /* excludeClosed */)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.extendedEvalCtx.SQLStatusServer.ListSessions(ctx, &req)
+	if err != nil {
+		return nil, err
+	}
+	for _, rpcErr := range resp.Errors {
+		log.Warningf(ctx, "%v", rpcErr.Message)
+	}
+	sessions := make(map[uuid.UUID]*serverpb.Session, len(resp.Sessions))
+	for i := range resp.Sessions {
+		if txn := resp.Sessions[i].ActiveTxn; txn != nil {
+			sessions[txn.ID] = &resp.Sessions[i]
+		}
+	}
+	return sessions, nil
+}
+
+// lockWaitStatement returns the statements that the session is currently
+// executing, or the last statement it executed if it is idle in its
+// transaction.
+func lockWaitStatement(session *serverpb.Session) string {
+	if len(session.ActiveQueries) == 0 {
+		return session.LastActiveQuery
+	}
+	stmts := make([]string, len(session.ActiveQueries))
+	for i := range session.ActiveQueries {
+		stmts[i] = session.ActiveQueries[i].Sql
+	}
+	return strings.Join(stmts, "; ")
+}
+
+// resolveTxnFingerprintIDs resolves the IDs of the provided transactions into
+// transaction fingerprint IDs using the transaction ID caches on their
+// coordinators. The resolution is best-effort: transactions that cannot be
+// resolved, for example because they are still executing or because their
+// coordinator cannot be reached, are omitted from the result.
+func (p *planner) resolveTxnFingerprintIDs(
+	ctx context.Context, txns []*enginepb.TxnMeta,
+) map[uuid.UUID]roachpb.TransactionFingerprintID {
+	byCoordinator := make(map[int32][]uuid.UUID)
+	for _, txn := range txns {
+		byCoordinator[txn.CoordinatorNodeID] = append(byCoordinator[txn.CoordinatorNodeID], txn.ID)
+	}
+	res := make(map[uuid.UUID]roachpb.TransactionFingerprintID, len(txns))
+	for coordinatorID, txnIDs := range byCoordinator {
+		resp, err := p.extendedEvalCtx.SQLStatusServer.TxnIDResolution(ctx, &serverpb.TxnIDResolutionRequest{
+			CoordinatorID: strconv.Itoa(int(coordinatorID)),
+			TxnIDs:        txnIDs,
+		})
+		if err != nil {
+			log.Warningf(ctx, "unable to resolve transaction IDs on node %d: %v", coordinatorID, err)
+			continue
+		}
+		for _, resolved := range resp.ResolvedTxnIDs {
+			if resolved.TxnFingerprintID != roachpb.InvalidTransactionFingerprintID {
+				res[resolved.TxnID] = resolved.TxnFingerprintID
+			}
+		}
+	}
+	return res
+}
@@ -0,0 +1,201 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package sql
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/concurrency/lock"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockWaitGraph(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	txns := make([]*enginepb.TxnMeta, 4)
+	for i := range txns {
+		txns[i] = &enginepb.TxnMeta{ID: uuid.FastMakeV4()}
+	}
+	makeLock := func(key string, holder *enginepb.TxnMeta, waiters ...*enginepb.TxnMeta) roachpb.LockStateInfo {
+		l := roachpb.LockStateInfo{Key: roachpb.Key(key), LockHolder: holder}
+		for _, w := range waiters {
+			l.Waiters = append(l.Waiters, lock.Waiter{
+				WaitingTxn:   w,
+				ActiveWaiter: true,
+				Strength:     lock.Exclusive,
+			})
+		}
+		return l
+	}
+
+	type headBlocker struct {
+		waiter, blocker, head *enginepb.TxnMeta
+		depth                 int
+		inDeadlock            bool
+	}
+	testCases := []struct {
+		name  string
+		locks []roachpb.LockStateInfo
+		exp   []headBlocker
+	}{
+		{
+			name: "uncontended",
+			locks: []roachpb.LockStateInfo{
+				makeLock("a", txns[0]),
+			},
+		},
+		{
+			name: "non-transactional and inactive waiters",
+			locks: []roachpb.LockStateInfo{
+				makeLock("a", txns[0], nil),
+				{
+					Key: roachpb.Key("b"), LockHolder: txns[0],
+					Waiters: []lock.Waiter{{WaitingTxn: txns[1]}},
+				},
+			},
+		},
+		{
+			name: "fan-in",
+			locks: []roachpb.LockStateInfo{
+				makeLock("a", txns[0], txns[1], txns[2]),
+			},
+			exp: []headBlocker{
+				{waiter: txns[1], blocker: txns[0], head: txns[0], depth: 1},
+				{waiter: txns[2], blocker: txns[0], head: txns[0], depth: 1},
+			},
+		},
+		{
+			name: "chain",
+			locks: []roachpb.LockStateInfo{
+				makeLock("a", txns[0], txns[1]),
+				makeLock("b", txns[1], txns[2]),
+				makeLock("c", txns[2], txns[3]),
+			},
+			exp: []headBlocker{
+				{waiter: txns[1], blocker: txns[0], head: txns[0], depth: 1},
+				{waiter: txns[2], blocker: txns[1], head: txns[0], depth: 2},
+				{waiter: txns[3], blocker: txns[2], head: txns[0], depth: 3},
+			},
+		},
+		{
+			name: "deadlock",
+			locks: []roachpb.LockStateInfo{
+				makeLock("a", txns[0], txns[1]),
+				makeLock("b", txns[1], txns[0]),
+				makeLock("c", txns[1], txns[2]),
+			},
+			exp: []headBlocker{
+				{waiter: txns[1], blocker: txns[0], depth: 2, inDeadlock: true},
+				{waiter: txns[0], blocker: txns[1], depth: 2, inDeadlock: true},
+				{waiter: txns[2], blocker: txns[1], depth: 3, inDeadlock: true},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := makeLockWaitGraph(tc.locks)
+			var res []headBlocker
+			for i := range g.edges {
+				e := &g.edges[i]
+				head, depth, inDeadlock := g.headBlocker(e)
+				res = append(res, headBlocker{
+					waiter:     e.waiter,
+					blocker:    e.blocker,
+					head:       head,
+					depth:      depth,
+					inDeadlock: inDeadlock,
+				})
+			}
+			require.Equal(t, tc.exp, res)
+		})
+	}
+}
+
+func TestLockWaitGraphHeadBlockers(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	txns := make([]*enginepb.TxnMeta, 4)
+	for i := range txns {
+		txns[i] = &enginepb.TxnMeta{ID: uuid.FastMakeV4()}
+	}
+	makeLock := func(key string, holder *enginepb.TxnMeta, waiters ...*enginepb.TxnMeta) roachpb.LockStateInfo {
+		l := roachpb.LockStateInfo{Key: roachpb.Key(key), LockHolder: holder}
+		for _, w := range waiters {
+			l.Waiters = append(l.Waiters, lock.Waiter{
+				WaitingTxn:   w,
+				ActiveWaiter: true,
+				Strength:     lock.Exclusive,
+			})
+		}
+		return l
+	}
+
+	testCases := []struct {
+		name       string
+		locks      []roachpb.LockStateInfo
+		expHeads   []*enginepb.TxnMeta
+		expWaiters map[uuid.UUID]int
+	}{
+		{
+			name: "uncontended",
+			locks: []roachpb.LockStateInfo{
+				makeLock("a", txns[0]),
+			},
+			expWaiters: map[uuid.UUID]int{},
+		},
+		{
+			name: "chain",
+			locks: []roachpb.LockStateInfo{
+				makeLock("a", txns[0], txns[1]),
+				makeLock("b", txns[1], txns[2]),
+				makeLock("c", txns[2], txns[3]),
+			},
+			expHeads:   []*enginepb.TxnMeta{txns[0]},
+			expWaiters: map[uuid.UUID]int{txns[0].ID: 3},
+		},
+		{
+			// txns[2] waits on locks held by both txns[0] and txns[1], and
+			// txns[3] waits on txns[2].
+			name: "multiple blockers",
+			locks: []roachpb.LockStateInfo{
+				makeLock("a", txns[0], txns[2]),
+				makeLock("b", txns[1], txns[2]),
+				makeLock("c", txns[2], txns[3]),
+			},
+			expHeads:   []*enginepb.TxnMeta{txns[0], txns[1]},
+			expWaiters: map[uuid.UUID]int{txns[0].ID: 2, txns[1].ID: 2},
+		},
+		{
+			name: "deadlock",
+			locks: []roachpb.LockStateInfo{
+				makeLock("a", txns[0], txns[1]),
+				makeLock("b", txns[1], txns[0]),
+				makeLock("c", txns[1], txns[2]),
+			},
+			expWaiters: map[uuid.UUID]int{},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := makeLockWaitGraph(tc.locks)
+			heads, numWaiters := g.headBlockers()
+			require.Equal(t, tc.expHeads, heads)
+			require.Equal(t, tc.expWaiters, numWaiters)
+		})
+	}
+}
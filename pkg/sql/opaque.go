@@ -126,6 +126,8 @@ func planOpaque(ctx context.Context, p *planner, stmt tree.Statement) (planNode,
 		return p.AlterRoleSet(ctx, n)
 	case *tree.AlterSequence:
 		return p.AlterSequence(ctx, n)
+	case *tree.CancelBlockingTransactions:
+		return p.CancelBlockingTransactions(ctx, n)
 	case *tree.CloseCursor:
 		return p.CloseCursor(ctx, n)
 	case *tree.CommentOnColumn:
@@ -214,6 +216,8 @@ func planOpaque(ctx context.Context, p *planner, stmt tree.Statement) (planNode,
 		return p.SetZoneConfig(ctx, n)
 	case *tree.SetVar:
 		return p.SetVar(ctx, n)
+	case *tree.SetConstraints:
+		return p.SetConstraints(ctx, n)
 	case *tree.SetTransaction:
 		return p.SetTransaction(ctx, n)
 	case *tree.SetSessionAuthorizationDefault:
@@ -276,6 +280,7 @@ func init() {
 		&tree.AlterSequence{},
 		&tree.AlterRole{},
 		&tree.AlterRoleSet{},
+		&tree.CancelBlockingTransactions{},
 		&tree.CloseCursor{},
 		&tree.CommentOnColumn{},
 		&tree.CommentOnDatabase{},
@@ -320,6 +325,7 @@ func init() {
 		&tree.SetClusterSetting{},
 		&tree.SetZoneConfig{},
 		&tree.SetVar{},
+		&tree.SetConstraints{},
 		&tree.SetTransaction{},
 		&tree.SetSessionAuthorizationDefault{},
 		&tree.SetSessionCharacteristics{},
@@ -337,6 +343,7 @@ func init() {
 		// CCL statements (without Export which has an optimizer operator).
 		&tree.AlterBackup{},
 		&tree.Backup{},
+		&tree.CompactBackup{},
 		&tree.ShowBackup{},
 		&tree.Restore{},
 		&tree.CreateChangefeed{},
@@ -260,6 +260,12 @@ type ForeignKeyConstraint interface {
 	// UpdateReferenceAction returns the action to be performed if the foreign key
 	// constraint would be violated by an update.
 	UpdateReferenceAction() tree.ReferenceAction
+
+	// Deferrability returns whether the check of the constraint can be
+	// postponed until the end of the transaction. The existing data of a
+	// deferrable constraint may be temporarily inconsistent, so no assumptions
+	// should be made about it.
+	Deferrability() tree.ConstraintDeferrability
 }
 
 // UniqueConstraint represents a uniqueness constraint. UniqueConstraints may
@@ -305,6 +311,11 @@ type UniqueConstraint interface {
 	// satisfied when building functional dependencies for the table. This enables
 	// additional optimizations, such as omission of uniqueness checks.
 	UniquenessGuaranteedByAnotherIndex() bool
+
+	// Deferrability returns whether the check of the constraint can be
+	// postponed until the end of the transaction. Only constraints without an
+	// index can be deferrable.
+	Deferrability() tree.ConstraintDeferrability
 }
 
 // UniqueOrdinal identifies a unique constraint (in the context of a Table).
@@ -97,6 +97,12 @@ type Builder struct {
 	// by scans. See forUpdateLocking.
 	forceForUpdateLocking bool
 
+	// forceForShareLocking is conditionally passed through to factory methods
+	// for scan operators in foreign key checks that verify the existence of
+	// referenced rows. When set to true, it ensures that a FOR SHARE row-level
+	// locking mode is used by scans. See shouldLockFKCheck.
+	forceForShareLocking bool
+
 	// -- output --
 
 	// IsDDL is set to true if the statement contains DDL.
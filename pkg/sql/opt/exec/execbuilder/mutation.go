@@ -156,6 +156,18 @@ func (b *Builder) tryBuildFastPathInsert(ins *memo.InsertExpr) (_ execPlan, ok b
 		return execPlan{}, false, nil
 	}
 
+	// We cannot use the fast path if any FK checks need to lock the referenced
+	// rows, since it does not acquire locks, or if any FK checks may be
+	// deferred, since it can only return an error for a violation.
+	for i := range ins.FKChecks {
+		if b.shouldLockFKCheck(&ins.FKChecks[i]) {
+			return execPlan{}, false, nil
+		}
+		if fkForCheck(b.mem.Metadata(), &ins.FKChecks[i]).Deferrability() != tree.ConstraintNotDeferrable {
+			return execPlan{}, false, nil
+		}
+	}
+
 	md := b.mem.Metadata()
 	tab := md.Table(ins.Table)
 
@@ -692,14 +704,32 @@ func (b *Builder) buildUniqueChecks(checks memo.UniqueChecksExpr) error {
 			return err
 		}
 		// Wrap the query in an error node.
-		mkErr := func(row tree.Datums) error {
+		mkKey := func(row tree.Datums) tree.Datums {
 			keyVals := make(tree.Datums, len(c.KeyCols))
 			for i, col := range c.KeyCols {
 				keyVals[i] = row[query.getNodeColumnOrdinal(col)]
 			}
+			return keyVals
+		}
+		mkErr := func(keyVals tree.Datums) error {
 			return mkUniqueCheckErr(md, c, keyVals)
 		}
-		node, err := b.factory.ConstructErrorIfRows(query.root, mkErr)
+		var deferrable *exec.DeferrableCheck
+		tab := md.Table(c.Table)
+		if uc := tab.Unique(c.CheckOrdinal); uc.Deferrability() != tree.ConstraintNotDeferrable {
+			deferrable = &exec.DeferrableCheck{
+				Table:             tab.ID(),
+				Constraint:        uc.Name(),
+				InitiallyDeferred: uc.Deferrability() == tree.ConstraintDeferrableInitiallyDeferred,
+				MkKey:             mkKey,
+				MkErr:             mkErr,
+			}
+		}
+		node, err := b.factory.ConstructErrorIfRows(
+			query.root,
+			func(row tree.Datums) error { return mkErr(mkKey(row)) },
+			deferrable,
+		)
 		if err != nil {
 			return err
 		}
@@ -712,20 +742,43 @@ func (b *Builder) buildFKChecks(checks memo.FKChecksExpr) error {
 	md := b.mem.Metadata()
 	for i := range checks {
 		c := &checks[i]
-		// Construct the query that returns FK violations.
+		// Construct the query that returns FK violations. Re-entrance is not
+		// possible because FK checks are never nested.
+		b.forceForShareLocking = b.shouldLockFKCheck(c)
 		query, err := b.buildRelational(c.Check)
+		b.forceForShareLocking = false
 		if err != nil {
 			return err
 		}
 		// Wrap the query in an error node.
-		mkErr := func(row tree.Datums) error {
+		mkKey := func(row tree.Datums) tree.Datums {
 			keyVals := make(tree.Datums, len(c.KeyCols))
 			for i, col := range c.KeyCols {
 				keyVals[i] = row[query.getNodeColumnOrdinal(col)]
 			}
+			return keyVals
+		}
+		mkErr := func(keyVals tree.Datums) error {
 			return mkFKCheckErr(md, c, keyVals)
 		}
-		node, err := b.factory.ConstructErrorIfRows(query.root, mkErr)
+		var deferrable *exec.DeferrableCheck
+		if fk := fkForCheck(md, c); fk.Deferrability() != tree.ConstraintNotDeferrable &&
+			!isRestrictCheck(c, fk) {
+			deferrable = &exec.DeferrableCheck{
+				Table:             fk.OriginTableID(),
+				Constraint:        fk.Name(),
+				ForeignKey:        true,
+				Inbound:           !c.FKOutbound,
+				InitiallyDeferred: fk.Deferrability() == tree.ConstraintDeferrableInitiallyDeferred,
+				MkKey:             mkKey,
+				MkErr:             mkErr,
+			}
+		}
+		node, err := b.factory.ConstructErrorIfRows(
+			query.root,
+			func(row tree.Datums) error { return mkErr(mkKey(row)) },
+			deferrable,
+		)
 		if err != nil {
 			return err
 		}
@@ -734,6 +787,28 @@ func (b *Builder) buildFKChecks(checks memo.FKChecksExpr) error {
 	return nil
 }
 
+// fkForCheck returns the foreign key constraint verified by the given check.
+func fkForCheck(md *opt.Metadata, c *memo.FKChecksItem) cat.ForeignKeyConstraint {
+	if c.FKOutbound {
+		return md.Table(c.OriginTable).OutboundForeignKey(c.FKOrdinal)
+	}
+	return md.Table(c.ReferencedTable).InboundForeignKey(c.FKOrdinal)
+}
+
+// isRestrictCheck returns true if the given check verifies that no references
+// remain to values removed from the referenced table of a foreign key with
+// the RESTRICT action. As in Postgres, such checks are never deferred, even
+// if the constraint is deferrable.
+func isRestrictCheck(c *memo.FKChecksItem, fk cat.ForeignKeyConstraint) bool {
+	if c.FKOutbound {
+		return false
+	}
+	if c.OpName == "delete" {
+		return fk.DeleteReferenceAction() == tree.Restrict
+	}
+	return fk.UpdateReferenceAction() == tree.Restrict
+}
+
 // mkUniqueCheckErr generates a user-friendly error describing a uniqueness
 // violation. The keyVals are the values that correspond to the
 // cat.UniqueConstraint columns.
@@ -936,6 +1011,28 @@ func (b *Builder) canAutoCommit(rel memo.RelExpr) bool {
 // equivalent that used by a SELECT ... FOR UPDATE statement.
 var forUpdateLocking = opt.Locking{Strength: tree.ForUpdate}
 
+// forShareLocking is the row-level locking mode used by FK checks that verify
+// the existence of referenced rows, when such locking is deemed necessary. The
+// locking mode is equivalent to that used by a SELECT ... FOR SHARE statement.
+var forShareLocking = opt.Locking{Strength: tree.ForShare}
+
+// shouldLockFKCheck determines whether or not the builder should apply a FOR
+// SHARE row-level locking mode to the scans of the referenced table performed
+// by the given FK check.
+//
+// This is the case for checks that verify that the rows referenced by new
+// values exist, when the transaction reads each statement from a new snapshot
+// (READ COMMITTED). Such a transaction can commit at a timestamp at which its
+// earlier reads are no longer valid, so without a lock the referenced row could
+// be deleted or modified by a concurrent transaction before it commits. Under
+// SERIALIZABLE isolation, one of the transactions would be retried instead.
+func (b *Builder) shouldLockFKCheck(c *memo.FKChecksItem) bool {
+	if !c.FKOutbound || b.evalCtx.Txn == nil {
+		return false
+	}
+	return b.evalCtx.Txn.IsoLevel().PerStatementReadSnapshot()
+}
+
 // shouldApplyImplicitLockingToMutationInput determines whether or not the
 // builder should apply a FOR UPDATE row-level locking mode to the initial row
 // scan of a mutation expression.
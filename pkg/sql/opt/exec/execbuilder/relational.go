@@ -568,6 +568,8 @@ func (b *Builder) scanParams(
 	locking := scan.Locking
 	if b.forceForUpdateLocking {
 		locking = forUpdateLocking
+	} else if b.forceForShareLocking {
+		locking = forShareLocking
 	}
 
 	// Raise error if row-level locking is part of a read-only transaction.
@@ -1727,6 +1729,8 @@ func (b *Builder) buildIndexJoin(join *memo.IndexJoinExpr) (execPlan, error) {
 	locking := join.Locking
 	if b.forceForUpdateLocking {
 		locking = forUpdateLocking
+	} else if b.forceForShareLocking {
+		locking = forShareLocking
 	}
 
 	res := execPlan{outputCols: output}
@@ -1823,6 +1827,8 @@ func (b *Builder) buildLookupJoin(join *memo.LookupJoinExpr) (execPlan, error) {
 	locking := join.Locking
 	if b.forceForUpdateLocking {
 		locking = forUpdateLocking
+	} else if b.forceForShareLocking {
+		locking = forShareLocking
 	}
 
 	res.root, err = b.factory.ConstructLookupJoin(
@@ -1938,6 +1944,8 @@ func (b *Builder) buildInvertedJoin(join *memo.InvertedJoinExpr) (execPlan, erro
 	locking := join.Locking
 	if b.forceForUpdateLocking {
 		locking = forUpdateLocking
+	} else if b.forceForShareLocking {
+		locking = forShareLocking
 	}
 
 	res.root, err = b.factory.ConstructInvertedJoin(
@@ -2005,6 +2013,9 @@ func (b *Builder) buildZigzagJoin(join *memo.ZigzagJoinExpr) (execPlan, error) {
 	if b.forceForUpdateLocking {
 		leftLocking = forUpdateLocking
 		rightLocking = forUpdateLocking
+	} else if b.forceForShareLocking {
+		leftLocking = forShareLocking
+		rightLocking = forShareLocking
 	}
 
 	allCols := joinOutputMap(leftColMap, rightColMap)
@@ -271,6 +271,39 @@ type InsertFastPathFKCheck struct {
 // relevant row.
 type MkErrFn func(tree.Datums) error
 
+// DeferrableCheck describes the check of a deferrable foreign key or UNIQUE
+// WITHOUT INDEX constraint (see ConstructErrorIfRows). Whether the check is
+// deferred is only known at execution time, since it depends on the SET
+// CONSTRAINTS mode of the transaction.
+type DeferrableCheck struct {
+	// Table is the table on which the constraint is defined. For a foreign
+	// key, this is the origin (referencing) table.
+	Table cat.StableID
+
+	// Constraint is the name of the constraint.
+	Constraint string
+
+	// ForeignKey is true if the constraint is a foreign key, and false if it is
+	// a unique constraint.
+	ForeignKey bool
+
+	// Inbound is true if this is the foreign key check for values removed from
+	// the referenced table.
+	Inbound bool
+
+	// InitiallyDeferred is true if the constraint is deferred unless SET
+	// CONSTRAINTS makes it immediate.
+	InitiallyDeferred bool
+
+	// MkKey returns the values of the constraint columns, in the order of the
+	// constraint, for a row produced by the check query.
+	MkKey func(tree.Datums) tree.Datums
+
+	// MkErr generates the error for a key returned by MkKey that still
+	// violates the constraint when the deferred check runs.
+	MkErr MkErrFn
+}
+
 // ExplainFactory is an extension of Factory used when constructing a plan that
 // can be explained. It allows annotation of nodes with extra information.
 type ExplainFactory interface {
@@ -1814,6 +1814,12 @@ func MakeTableFuncDep(md *opt.Metadata, tabID opt.TableID) *props.FuncDepSet {
 			continue
 		}
 
+		if unique.Deferrability() != tree.ConstraintNotDeferrable {
+			// A deferred constraint may be violated until the transaction
+			// commits, so we cannot use it as a key.
+			continue
+		}
+
 		if _, isPartial := unique.Predicate(); isPartial {
 			// Partial constraints cannot be considered while building functional
 			// dependency keys for the table because their keys are only unique
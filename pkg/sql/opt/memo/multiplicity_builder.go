@@ -502,8 +502,9 @@ func checkForeignKeyCase(
 		leftBaseTable := md.Table(leftTableID)
 		for i, cnt := 0, leftBaseTable.OutboundForeignKeyCount(); i < cnt; i++ {
 			fk := leftBaseTable.OutboundForeignKey(i)
-			if !fk.Validated() {
-				// The data is not guaranteed to follow the foreign key constraint.
+			if !fk.Validated() || fk.Deferrability() != tree.ConstraintNotDeferrable {
+				// The data is not guaranteed to follow the foreign key constraint. A
+				// deferred constraint may be violated until the transaction commits.
 				continue
 			}
 			if rightTableIDs == nil {
@@ -0,0 +1,613 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package optbuilder
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree/treecmp"
+)
+
+// maxGroupingArgs is the maximum number of arguments of GROUPING, so that its
+// result fits in an INT4 as in Postgres.
+const maxGroupingArgs = 31
+
+// groupingSetTable is the name of the table that provides the grouping set ID
+// column when a query has multiple grouping sets, and groupingInputTable is
+// the name of the table that marks the input rows when one of the sets is
+// empty. See makeGroupingSetsClause.
+const (
+	groupingSetTable   = "crdb_internal_grouping_set"
+	groupingInputTable = "crdb_internal_grouping_input"
+)
+
+// groupingItem is an element of one of the grouping sets of a GROUP BY clause.
+type groupingItem struct {
+	// str identifies the item among the elements of all grouping sets.
+	str string
+	// ord is the index of the SELECT target that the item refers to by
+	// ordinal, or -1.
+	ord int
+	// alias is set when the item is a bare name, which may refer to a SELECT
+	// target by its alias.
+	alias tree.Name
+	// expr is the grouping expression: the SELECT target that the item refers
+	// to by ordinal or alias, or the item itself.
+	expr tree.Expr
+}
+
+// rewriteGroupingSets rewrites a SELECT clause that uses ROLLUP, CUBE,
+// GROUPING SETS or GROUPING(...) into a statement that only uses a plain
+// GROUP BY clause. It returns nil if sel doesn't use any of these constructs.
+//
+// A GROUP BY clause that denotes a single grouping set is rewritten into a
+// plain GROUP BY, and GROUPING(...) is replaced by a constant. Otherwise, all
+// the grouping sets are computed by a single aggregation that also groups by
+// a grouping set ID column (see makeGroupingSetsClause).
+func (b *Builder) rewriteGroupingSets(
+	sel *tree.SelectClause, orderBy tree.OrderBy,
+) (*tree.SelectClause, tree.OrderBy) {
+	hasGroupingOp := containsGroupingOperation(sel.Exprs, sel.Having, orderBy)
+	if !sel.GroupBy.HasGroupingSets() && (!hasGroupingOp || len(sel.GroupBy) == 0) {
+		// GROUPING in a query without GROUP BY is reported as an error when the
+		// expression is type checked.
+		return nil, orderBy
+	}
+	sets, err := sel.GroupBy.GroupingSets()
+	if err != nil {
+		panic(err)
+	}
+
+	// A bare name in GROUP BY can refer to a SELECT target by its alias. This
+	// is never the case for targets with aggregate functions, which cannot be
+	// used as grouping expressions.
+	aliases := make([]tree.Name, len(sel.Exprs))
+	for i, target := range sel.Exprs {
+		if target.As != "" && !b.containsAggregate(target.Expr) {
+			aliases[i] = tree.Name(target.As)
+		}
+	}
+
+	// Collect the distinct elements of all grouping sets.
+	var items []groupingItem
+	setItems := make([][]groupingItem, len(sets))
+	for i, set := range sets {
+		for _, e := range set {
+			item := b.makeGroupingItem(e, sel.Exprs, aliases)
+			setItems[i] = append(setItems[i], item)
+			if findGroupingItem(items, item.str) == -1 {
+				items = append(items, item)
+			}
+		}
+	}
+
+	// The output columns are named after the original targets, so that the
+	// targets that are replaced by other expressions keep their names.
+	named := *sel
+	named.Exprs = make(tree.SelectExprs, len(sel.Exprs))
+	for i, target := range sel.Exprs {
+		named.Exprs[i] = target
+		if target.As == "" && !isStarTarget(target.Expr) {
+			named.Exprs[i].As = tree.UnrestrictedName(b.getColName(target))
+		}
+	}
+
+	if len(sets) == 1 {
+		return b.makeGroupingSetClause(&named, sets[0], items),
+			b.replaceOrderByGroupingOps(orderBy, items)
+	}
+	return b.makeGroupingSetsClause(&named, aliases, sets, items, setItems, orderBy)
+}
+
+// makeGroupingItem returns the groupingItem for the element e of a grouping
+// set. aliases contains the aliases by which e may refer to the SELECT
+// targets.
+func (b *Builder) makeGroupingItem(
+	e tree.Expr, selects tree.SelectExprs, aliases []tree.Name,
+) groupingItem {
+	e = tree.StripParens(e)
+	item := groupingItem{
+		str:  symbolicExprStr(e),
+		ord:  colIndex(len(selects), e, "GROUP BY"),
+		expr: e,
+	}
+	if item.ord != -1 {
+		// An item that refers to a target by ordinal is the same grouping
+		// expression as the target itself.
+		item.expr = selects[item.ord].Expr
+		item.str = symbolicExprStr(tree.StripParens(item.expr))
+		return item
+	}
+	if name, ok := e.(*tree.UnresolvedName); ok && name.NumParts == 1 && !name.Star {
+		item.alias = tree.Name(name.Parts[0])
+		for i := range aliases {
+			if aliases[i] == item.alias {
+				item.expr = selects[i].Expr
+				break
+			}
+		}
+	}
+	return item
+}
+
+// makeGroupingSetClause returns a copy of sel that groups by the elements of
+// its single grouping set, with GROUPING(...) replaced by its value.
+func (b *Builder) makeGroupingSetClause(
+	sel *tree.SelectClause, set tree.Exprs, items []groupingItem,
+) *tree.SelectClause {
+	res := *sel
+	if len(set) == 0 {
+		// GROUP BY () aggregates all the rows into a single group, even when
+		// there are no input rows.
+		res.GroupBy = tree.GroupBy{&tree.Tuple{}}
+	} else {
+		res.GroupBy = tree.GroupBy(set)
+	}
+
+	res.Exprs = make(tree.SelectExprs, len(sel.Exprs))
+	for i, target := range sel.Exprs {
+		res.Exprs[i] = target
+		res.Exprs[i].Expr = b.replaceGroupingOps(target.Expr, items)
+	}
+	if sel.Having != nil {
+		res.Having = &tree.Where{
+			Type: sel.Having.Type,
+			Expr: b.replaceGroupingOps(sel.Having.Expr, items),
+		}
+	}
+	return &res
+}
+
+// replaceGroupingOps replaces GROUPING(...) in expr by its value for the only
+// grouping set of the query, whose elements are the given items.
+func (b *Builder) replaceGroupingOps(expr tree.Expr, items []groupingItem) tree.Expr {
+	newExpr, err := tree.SimpleVisit(expr, func(e tree.Expr) (bool, tree.Expr, error) {
+		if t, ok := e.(*tree.GroupingOperation); ok {
+			return false, makeGroupingValue(t, items, items), nil
+		}
+		return true, e, nil
+	})
+	if err != nil {
+		panic(err)
+	}
+	return newExpr
+}
+
+// replaceOrderByGroupingOps replaces GROUPING(...) in the expressions of an
+// ORDER BY clause by its value for the only grouping set of the query. Sort
+// keys that become constant are removed, since they don't affect the ordering
+// and would otherwise be interpreted as column ordinals.
+func (b *Builder) replaceOrderByGroupingOps(
+	orderBy tree.OrderBy, items []groupingItem,
+) tree.OrderBy {
+	var res tree.OrderBy
+	for _, o := range orderBy {
+		order := *o
+		if order.Expr != nil {
+			order.Expr = b.replaceGroupingOps(order.Expr, items)
+			if _, ok := tree.StripParens(order.Expr).(tree.Datum); ok {
+				continue
+			}
+		}
+		res = append(res, &order)
+	}
+	return res
+}
+
+// makeGroupingValue returns the value of GROUPING(...) for the grouping set
+// that contains the present items. The bit for an argument is set if the
+// argument is not part of the grouping set, and the last argument maps to the
+// least significant bit.
+func makeGroupingValue(g *tree.GroupingOperation, items, present []groupingItem) tree.Expr {
+	if len(g.Exprs) > maxGroupingArgs {
+		panic(pgerror.Newf(pgcode.TooManyArguments,
+			"GROUPING must have fewer than %d arguments", maxGroupingArgs+1))
+	}
+	var res tree.DInt
+	for _, arg := range g.Exprs {
+		str := symbolicExprStr(tree.StripParens(arg))
+		if findGroupingItem(items, str) == -1 {
+			panic(pgerror.New(pgcode.Grouping,
+				"arguments to GROUPING must be grouping expressions of the associated query level"))
+		}
+		res <<= 1
+		if findGroupingItem(present, str) == -1 {
+			res |= 1
+		}
+	}
+	return tree.NewDInt(res)
+}
+
+// makeGroupingSetsClause returns a copy of sel that computes all the given
+// grouping sets with a single aggregation. Each input row is joined with the
+// ID of every grouping set, given by the position of the set, and the rows
+// are grouped by the set ID and by every grouping item. An item that is not
+// part of all the sets is replaced by an expression that is NULL for the sets
+// that don't contain it. For example:
+//
+//   SELECT a, b, sum(c) FROM t GROUP BY ROLLUP (a, b)
+//
+// is planned as:
+//
+//   SELECT
+//     CASE WHEN s.id IN (0, 1) THEN a END AS a,
+//     CASE WHEN s.id IN (0) THEN b END AS b,
+//     sum(c)
+//   FROM t, (VALUES (0), (1), (2)) AS s (id)
+//   GROUP BY s.id, CASE WHEN s.id IN (0, 1) THEN a END, CASE WHEN s.id IN (0) THEN b END
+//
+// GROUPING(...) is replaced by an expression of the set ID. Since the result
+// is a regular grouped query, window functions, DISTINCT ON and ORDER BY are
+// evaluated over the rows of all the grouping sets, as in Postgres.
+//
+// An empty grouping set produces a row even when there are no input rows. If
+// there is one, the input rows are instead left joined to the set IDs, so that
+// every set gets a row of NULLs when the input is empty. These rows are only
+// kept for the empty sets, and are ignored by the aggregate functions. The
+// rows of the input are marked by a column of the joined side:
+//
+//   SELECT ..., sum(c) FILTER (WHERE i.present)
+//   FROM (VALUES (0), (1), (2)) AS s (id)
+//   LEFT JOIN (t CROSS JOIN (VALUES (true)) AS i (present)) ON <WHERE clause>
+//   WHERE i.present OR s.id IN (2)
+//   GROUP BY ...
+func (b *Builder) makeGroupingSetsClause(
+	sel *tree.SelectClause,
+	aliases []tree.Name,
+	sets []tree.Exprs,
+	items []groupingItem,
+	setItems [][]groupingItem,
+	orderBy tree.OrderBy,
+) (*tree.SelectClause, tree.OrderBy) {
+	r := groupingSetsRewriter{b: b, items: items, setItems: setItems}
+	var emptySets []int
+	for i, set := range sets {
+		if len(set) == 0 {
+			emptySets = append(emptySets, i)
+		}
+	}
+	r.markInput = len(emptySets) > 0
+
+	// Compute the grouping expression of each item.
+	r.grouped = make([]tree.Expr, len(items))
+	for i := range items {
+		var ids []int
+		for j := range setItems {
+			if findGroupingItem(setItems[j], items[i].str) != -1 {
+				ids = append(ids, j)
+			}
+		}
+		if len(ids) == len(sets) {
+			r.grouped[i] = items[i].expr
+			continue
+		}
+		r.grouped[i] = &tree.CaseExpr{
+			Whens: []*tree.When{{Cond: groupingSetIn(ids), Val: items[i].expr}},
+		}
+	}
+
+	res := *sel
+	res.GroupBy = make(tree.GroupBy, 0, len(items)+1)
+	res.GroupBy = append(res.GroupBy, groupingSetIDCol())
+	res.GroupBy = append(res.GroupBy, r.grouped...)
+
+	res.Exprs = make(tree.SelectExprs, len(sel.Exprs))
+	for i, target := range sel.Exprs {
+		res.Exprs[i] = target
+		if item := findTargetItem(i, aliases[i], items); item != -1 {
+			res.Exprs[i].Expr = r.grouped[item]
+			continue
+		}
+		res.Exprs[i].Expr = r.rewrite(target.Expr)
+	}
+	if sel.Having != nil {
+		res.Having = &tree.Where{Type: sel.Having.Type, Expr: r.rewrite(sel.Having.Expr)}
+	}
+	if len(sel.Window) > 0 {
+		res.Window = make(tree.Window, len(sel.Window))
+		for i, def := range sel.Window {
+			res.Window[i] = r.rewriteWindowDef(def)
+		}
+	}
+
+	// The expressions of DISTINCT ON and ORDER BY can also refer to the output
+	// columns by name or ordinal, in which case they are left as is.
+	outputNames := make(map[tree.Name]struct{}, len(sel.Exprs))
+	for _, target := range sel.Exprs {
+		outputNames[tree.Name(target.As)] = struct{}{}
+	}
+	rewriteSortExpr := func(e tree.Expr) tree.Expr {
+		if name, ok := tree.StripParens(e).(*tree.UnresolvedName); ok && name.NumParts == 1 {
+			if _, ok := outputNames[tree.Name(name.Parts[0])]; ok {
+				return e
+			}
+		}
+		return r.rewrite(e)
+	}
+	if len(sel.DistinctOn) > 0 {
+		res.DistinctOn = make(tree.DistinctOn, len(sel.DistinctOn))
+		for i, e := range sel.DistinctOn {
+			res.DistinctOn[i] = rewriteSortExpr(e)
+		}
+	}
+	var newOrderBy tree.OrderBy
+	for _, o := range orderBy {
+		order := *o
+		if order.Expr != nil {
+			order.Expr = rewriteSortExpr(order.Expr)
+		}
+		newOrderBy = append(newOrderBy, &order)
+	}
+
+	// Join the input with the grouping set IDs.
+	rows := make([]tree.Exprs, len(sets))
+	for i := range rows {
+		rows[i] = tree.Exprs{tree.NewDInt(tree.DInt(i))}
+	}
+	setIDs := makeValuesTable(rows, groupingSetTable, "id")
+	if !r.markInput {
+		res.From.Tables = append(sel.From.Tables[:len(sel.From.Tables):len(sel.From.Tables)], setIDs)
+		return &res, newOrderBy
+	}
+	input := makeValuesTable(
+		[]tree.Exprs{{tree.DBoolTrue}}, groupingInputTable, "present",
+	)
+	for i := len(sel.From.Tables) - 1; i >= 0; i-- {
+		input = &tree.JoinTableExpr{JoinType: tree.AstCross, Left: sel.From.Tables[i], Right: input}
+	}
+	var cond tree.Expr = tree.DBoolTrue
+	if sel.Where != nil {
+		cond = sel.Where.Expr
+	}
+	res.From.Tables = tree.TableExprs{&tree.JoinTableExpr{
+		JoinType: tree.AstLeft,
+		Left:     setIDs,
+		Right:    &tree.ParenTableExpr{Expr: input},
+		Cond:     &tree.OnJoinCond{Expr: cond},
+	}}
+	res.Where = &tree.Where{
+		Type: tree.AstWhere,
+		Expr: &tree.OrExpr{Left: groupingInputMarker(), Right: groupingSetIn(emptySets)},
+	}
+	return &res, newOrderBy
+}
+
+// groupingSetsRewriter rewrites the expressions of a query with multiple
+// grouping sets that are evaluated after the aggregation. See
+// makeGroupingSetsClause.
+type groupingSetsRewriter struct {
+	b *Builder
+	// items and setItems are the distinct elements of all grouping sets, and
+	// the elements of each set.
+	items    []groupingItem
+	setItems [][]groupingItem
+	// grouped contains the grouping expression of each item.
+	grouped []tree.Expr
+	// markInput is true if the rows of the input are marked by the column of
+	// groupingInputTable, which must be used to filter the input of the
+	// aggregate functions.
+	markInput bool
+}
+
+// rewrite replaces the grouping items in expr by their grouping expressions,
+// except within the arguments of aggregate functions, and replaces
+// GROUPING(...) by its value for the grouping set of each row.
+func (r *groupingSetsRewriter) rewrite(expr tree.Expr) tree.Expr {
+	newExpr, err := tree.SimpleVisit(expr, func(e tree.Expr) (bool, tree.Expr, error) {
+		switch t := e.(type) {
+		case *tree.GroupingOperation:
+			return false, r.groupingValue(t), nil
+		case *tree.Subquery:
+			return false, e, nil
+		case *tree.FuncExpr:
+			if r.b.isAggregateFunc(t) {
+				// The arguments of aggregate functions refer to the input rows.
+				if !r.markInput {
+					return false, e, nil
+				}
+				agg := *t
+				agg.Filter = groupingInputMarker()
+				if t.Filter != nil {
+					agg.Filter = &tree.AndExpr{Left: &tree.ParenExpr{Expr: t.Filter}, Right: agg.Filter}
+				}
+				return false, &agg, nil
+			}
+			if t.WindowDef != nil {
+				// The window definition is not visited by the walk of the function.
+				fn := *t
+				fn.WindowDef = r.rewriteWindowDef(t.WindowDef)
+				return true, &fn, nil
+			}
+		}
+		if i := findGroupingItem(r.items, symbolicExprStr(tree.StripParens(e))); i != -1 {
+			return false, r.grouped[i], nil
+		}
+		return true, e, nil
+	})
+	if err != nil {
+		panic(err)
+	}
+	return newExpr
+}
+
+// rewriteWindowDef rewrites the partitioning and ordering expressions of a
+// window definition.
+func (r *groupingSetsRewriter) rewriteWindowDef(def *tree.WindowDef) *tree.WindowDef {
+	res := *def
+	if len(def.Partitions) > 0 {
+		res.Partitions = make(tree.Exprs, len(def.Partitions))
+		for i, e := range def.Partitions {
+			res.Partitions[i] = r.rewrite(e)
+		}
+	}
+	if len(def.OrderBy) > 0 {
+		res.OrderBy = make(tree.OrderBy, len(def.OrderBy))
+		for i, o := range def.OrderBy {
+			order := *o
+			if order.Expr != nil {
+				order.Expr = r.rewrite(order.Expr)
+			}
+			res.OrderBy[i] = &order
+		}
+	}
+	return &res
+}
+
+// groupingValue returns an expression that computes the value of
+// GROUPING(...) from the grouping set ID column.
+func (r *groupingSetsRewriter) groupingValue(g *tree.GroupingOperation) tree.Expr {
+	values := make([]tree.Expr, len(r.setItems))
+	allEqual := true
+	for i := range r.setItems {
+		values[i] = makeGroupingValue(g, r.items, r.setItems[i])
+		if *values[i].(*tree.DInt) != *values[0].(*tree.DInt) {
+			allEqual = false
+		}
+	}
+	if allEqual {
+		return values[0]
+	}
+	res := &tree.CaseExpr{Expr: groupingSetIDCol()}
+	for i := range values {
+		res.Whens = append(res.Whens, &tree.When{Cond: tree.NewDInt(tree.DInt(i)), Val: values[i]})
+	}
+	return res
+}
+
+// findTargetItem returns the index of the grouping item that refers to the
+// SELECT target at index i, with the given alias, by ordinal or by alias, or
+// -1.
+func findTargetItem(i int, alias tree.Name, items []groupingItem) int {
+	for j := range items {
+		if items[j].ord == i || (items[j].alias != "" && items[j].alias == alias) {
+			return j
+		}
+	}
+	return -1
+}
+
+// groupingSetIDCol returns a reference to the grouping set ID column.
+func groupingSetIDCol() tree.Expr {
+	return tree.NewUnresolvedName(groupingSetTable, "id")
+}
+
+// groupingInputMarker returns a reference to the column that marks the input
+// rows when the left join of makeGroupingSetsClause is used.
+func groupingInputMarker() tree.Expr {
+	return tree.NewUnresolvedName(groupingInputTable, "present")
+}
+
+// groupingSetIn returns an expression that is true for the rows of the given
+// grouping sets.
+func groupingSetIn(ids []int) tree.Expr {
+	tuple := &tree.Tuple{Exprs: make(tree.Exprs, len(ids))}
+	for i, id := range ids {
+		tuple.Exprs[i] = tree.NewDInt(tree.DInt(id))
+	}
+	return &tree.ComparisonExpr{
+		Operator: treecmp.MakeComparisonOperator(treecmp.In),
+		Left:     groupingSetIDCol(),
+		Right:    tuple,
+	}
+}
+
+// makeValuesTable returns a FROM clause item for a VALUES clause with the
+// given rows, aliased to the given table and column names.
+func makeValuesTable(rows []tree.Exprs, table tree.Name, cols ...tree.Name) tree.TableExpr {
+	return &tree.AliasedTableExpr{
+		Expr: &tree.Subquery{
+			Select: &tree.ParenSelect{Select: &tree.Select{Select: &tree.ValuesClause{Rows: rows}}},
+		},
+		As: tree.AliasClause{Alias: table, Cols: cols},
+	}
+}
+
+// findGroupingItem returns the index of the item identified by str, or -1.
+func findGroupingItem(items []groupingItem, str string) int {
+	for i := range items {
+		if items[i].str == str {
+			return i
+		}
+	}
+	return -1
+}
+
+// isStarTarget returns true if e is a SELECT target that expands to several
+// columns.
+func isStarTarget(e tree.Expr) bool {
+	switch t := e.(type) {
+	case *tree.UnresolvedName:
+		return t.Star
+	case *tree.AllColumnsSelector, *tree.TupleStar:
+		return true
+	}
+	return false
+}
+
+// containsGroupingOperation returns true if GROUPING(...) is used in any of
+// the given SELECT targets, HAVING clause or ORDER BY clause.
+func containsGroupingOperation(
+	selects tree.SelectExprs, having *tree.Where, orderBy tree.OrderBy,
+) bool {
+	return containsExpr(selects, having, orderBy, func(e tree.Expr) bool {
+		_, ok := e.(*tree.GroupingOperation)
+		return ok
+	})
+}
+
+// containsAggregate returns true if expr contains an aggregate function.
+func (b *Builder) containsAggregate(expr tree.Expr) bool {
+	isAgg := func(e tree.Expr) bool {
+		f, ok := e.(*tree.FuncExpr)
+		return ok && b.isAggregateFunc(f)
+	}
+	return containsExpr(tree.SelectExprs{{Expr: expr}}, nil /* having */, nil /* orderBy */, isAgg)
+}
+
+// isAggregateFunc returns true if f is an aggregate function that is not used
+// as a window function. Functions that cannot be resolved are reported when
+// the expression is built.
+func (b *Builder) isAggregateFunc(f *tree.FuncExpr) bool {
+	if f.WindowDef != nil {
+		return false
+	}
+	def, err := f.Func.Resolve(b.semaCtx.SearchPath)
+	return err == nil && isAggregate(def)
+}
+
+// containsExpr returns true if fn returns true for any of the expressions in
+// the given SELECT targets, HAVING clause or ORDER BY clause. Subqueries are
+// not visited.
+func containsExpr(
+	selects tree.SelectExprs, having *tree.Where, orderBy tree.OrderBy, fn func(tree.Expr) bool,
+) bool {
+	found := false
+	visit := func(e tree.Expr) (bool, tree.Expr, error) {
+		if found || fn(e) {
+			found = true
+			return false, e, nil
+		}
+		return true, e, nil
+	}
+	for _, target := range selects {
+		_, _ = tree.SimpleVisit(target.Expr, visit)
+	}
+	if having != nil {
+		_, _ = tree.SimpleVisit(having.Expr, visit)
+	}
+	for _, o := range orderBy {
+		if o.Expr != nil {
+			_, _ = tree.SimpleVisit(o.Expr, visit)
+		}
+	}
+	return found
+}
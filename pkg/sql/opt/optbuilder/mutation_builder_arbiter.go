@@ -81,6 +81,9 @@ func (mb *mutationBuilder) findArbiters(onConflict *tree.OnConflict) arbiterSet
 				if _, partial := constraint.Predicate(); partial {
 					panic(partialIndexArbiterError(onConflict, mb.tab.Name()))
 				}
+				if constraint.Deferrability() != tree.ConstraintNotDeferrable {
+					panic(deferrableArbiterError())
+				}
 				return makeSingleUniqueConstraintArbiterSet(mb, i)
 			}
 		}
@@ -111,6 +114,17 @@ func (mb *mutationBuilder) findArbiters(onConflict *tree.OnConflict) arbiterSet
 	return mb.inferArbitersFromConflictOrds(ords, onConflict.ArbiterPredicate)
 }
 
+// deferrableArbiterError is returned when a deferrable unique constraint would
+// be used as an arbiter. A row that conflicts with an existing row is not a
+// violation of such a constraint until the constraint is checked, so it
+// cannot be used to detect conflicts.
+func deferrableArbiterError() error {
+	return pgerror.New(
+		pgcode.WrongObjectType,
+		"ON CONFLICT does not support deferrable unique constraints as arbiters",
+	)
+}
+
 func partialIndexArbiterError(onConflict *tree.OnConflict, tableName tree.Name) error {
 	return errors.WithHint(
 		pgerror.Newf(
@@ -167,6 +181,9 @@ func (mb *mutationBuilder) inferArbitersFromConflictOrds(
 		}
 		for uc, ucCount := 0, mb.tab.UniqueCount(); uc < ucCount; uc++ {
 			if mb.tab.Unique(uc).WithoutIndex() {
+				if mb.tab.Unique(uc).Deferrability() != tree.ConstraintNotDeferrable {
+					panic(deferrableArbiterError())
+				}
 				arbiters.AddUniqueConstraint(uc)
 			}
 		}
@@ -224,6 +241,10 @@ func (mb *mutationBuilder) inferArbitersFromConflictOrds(
 			// Unique constraints with an index were handled above.
 			continue
 		}
+		if uniqueConstraint.Deferrability() != tree.ConstraintNotDeferrable {
+			// Deferrable unique constraints cannot be arbiters.
+			continue
+		}
 
 		// Determine whether the conflict columns match the columns in the
 		// unique constraint. If not, the constraint cannot be an arbiter. We
@@ -978,6 +978,10 @@ func (b *Builder) buildSelectClause(
 	desiredTypes []*types.T,
 	inScope *scope,
 ) (outScope *scope) {
+	if rewritten, newOrderBy := b.rewriteGroupingSets(sel, orderBy); rewritten != nil {
+		sel, orderBy = rewritten, newOrderBy
+	}
+
 	fromScope := b.buildFrom(sel.From, locking, inScope)
 
 	b.processWindowDefs(sel, fromScope)
@@ -121,6 +121,7 @@ func TestContextualHelp(t *testing.T) {
 		{`CANCEL SESSIONS IF ??`, `CANCEL SESSIONS`},
 		{`CANCEL SESSIONS IF EXISTS ??`, `CANCEL SESSIONS`},
 		{`CANCEL ALL ??`, `CANCEL ALL JOBS`},
+		{`CANCEL BLOCKING ??`, `CANCEL BLOCKING TRANSACTIONS`},
 
 		{`CREATE UNIQUE ??`, `CREATE`},
 		{`CREATE UNIQUE INDEX ??`, `CREATE INDEX`},
@@ -310,6 +311,7 @@ func TestContextualHelp(t *testing.T) {
 
 		{`SHOW TRANSACTIONS ??`, `SHOW TRANSACTIONS`},
 		{`SHOW LOCAL TRANSACTIONS ??`, `SHOW TRANSACTIONS`},
+		{`SHOW BLOCKING TRANSACTIONS ??`, `SHOW BLOCKING TRANSACTIONS`},
 
 		{`SHOW STATISTICS ??`, `SHOW STATISTICS`},
 		{`SHOW STATISTICS FOR TABLE ??`, `SHOW STATISTICS`},
@@ -442,6 +444,10 @@ func TestContextualHelp(t *testing.T) {
 
 		{`SET TRANSACTION ??`, `SET TRANSACTION`},
 		{`SET TRANSACTION ISOLATION LEVEL SNAPSHOT ??`, `SET TRANSACTION`},
+
+		{`SET CONSTRAINTS ??`, `SET CONSTRAINTS`},
+		{`SET CONSTRAINTS ALL ??`, `SET CONSTRAINTS`},
+
 		{`SET TIME ??`, `SET SESSION`},
 		{`SET TIME ZONE 'UTC' ??`, `SET SESSION`},
 		{`SET blah TO ??`, `SET SESSION`},
@@ -482,6 +488,9 @@ func TestContextualHelp(t *testing.T) {
 		{`BACKUP DATABASE ??`, `BACKUP`},
 		{`BACKUP foo TO 'bar' AS OF ??`, `BACKUP`},
 
+		{`COMPACT BACKUP ??`, `COMPACT BACKUP`},
+		{`COMPACT BACKUP 'foo' IN 'bar' ??`, `COMPACT BACKUP`},
+
 		{`RESTORE foo FROM 'bar' ??`, `RESTORE`},
 		{`RESTORE DATABASE ??`, `RESTORE`},
 
@@ -101,6 +101,8 @@ func (l *lexer) Lex(lval *sqlSymType) int {
 			switch nextID {
 			case BETWEEN, IN, LIKE, ILIKE, SIMILAR:
 				lval.id = NOT_LA
+			case DEFERRABLE:
+				lval.id = NOT_DEFERRABLE
 			}
 		case GENERATED:
 			switch nextID {
@@ -591,6 +591,12 @@ func (u *sqlSymUnion) readWriteMode() tree.ReadWriteMode {
 func (u *sqlSymUnion) deferrableMode() tree.DeferrableMode {
     return u.val.(tree.DeferrableMode)
 }
+func (u *sqlSymUnion) constraintDeferrability() tree.ConstraintDeferrability {
+    return u.val.(tree.ConstraintDeferrability)
+}
+func (u *sqlSymUnion) constraintCheckMode() tree.ConstraintCheckMode {
+    return u.val.(tree.ConstraintCheckMode)
+}
 func (u *sqlSymUnion) idxElem() tree.IndexElem {
     return u.val.(tree.IndexElem)
 }
@@ -802,7 +808,7 @@ func (u *sqlSymUnion) asTenantClause() tree.TenantID {
 %token <str> ALL ALTER ALWAYS ANALYSE ANALYZE AND AND_AND ANY ANNOTATE_TYPE ARRAY AS ASC
 %token <str> ASENSITIVE ASYMMETRIC AT ATTRIBUTE AUTHORIZATION AUTOMATIC AVAILABILITY
 
-%token <str> BACKUP BACKUPS BACKWARD BEFORE BEGIN BETWEEN BIGINT BIGSERIAL BINARY BIT
+%token <str> BACKUP BACKUPS BACKWARD BEFORE BEGIN BETWEEN BIGINT BIGSERIAL BINARY BIT BLOCKING
 %token <str> BUCKET_COUNT
 %token <str> BOOLEAN BOTH BOX2D BUNDLE BY
 
@@ -926,8 +932,11 @@ func (u *sqlSymUnion) asTenantClause() tree.TenantID {
 // references.
 // - TENANT_ALL is used to differentiate `ALTER TENANT <id>` from
 // `ALTER TENANT ALL`.
+// - NOT_DEFERRABLE is needed to distinguish NOT DEFERRABLE from NOT VALID
+// after a constraint definition, and from NOT LIKE and friends after an
+// expression.
 %token NOT_LA NULLS_LA WITH_LA AS_LA GENERATED_ALWAYS GENERATED_BY_DEFAULT RESET_ALL ROLE_ALL
-%token USER_ALL ON_LA TENANT_ALL
+%token USER_ALL ON_LA TENANT_ALL NOT_DEFERRABLE
 
 %union {
   id    int32
@@ -1018,9 +1027,11 @@ func (u *sqlSymUnion) asTenantClause() tree.TenantID {
 %type <tree.Statement> alter_default_privileges_stmt
 
 %type <tree.Statement> backup_stmt
+%type <tree.Statement> compact_backup_stmt
 %type <tree.Statement> begin_stmt
 
 %type <tree.Statement> cancel_stmt
+%type <tree.Statement> cancel_blocking_transactions_stmt
 %type <tree.Statement> cancel_jobs_stmt
 %type <tree.Statement> cancel_queries_stmt
 %type <tree.Statement> cancel_sessions_stmt
@@ -1107,6 +1118,7 @@ func (u *sqlSymUnion) asTenantClause() tree.TenantID {
 %type <tree.Statement> set_session_stmt
 %type <tree.Statement> set_csetting_stmt set_or_reset_csetting_stmt
 %type <tree.Statement> set_transaction_stmt
+%type <tree.Statement> set_constraints_stmt
 %type <tree.Statement> set_exprs_internal
 %type <tree.Statement> generic_set
 %type <tree.Statement> set_rest_more
@@ -1115,6 +1127,7 @@ func (u *sqlSymUnion) asTenantClause() tree.TenantID {
 
 %type <tree.Statement> show_stmt
 %type <tree.Statement> show_backup_stmt
+%type <tree.Statement> show_blocking_transactions_stmt
 %type <tree.Statement> show_columns_stmt
 %type <tree.Statement> show_constraints_stmt
 %type <tree.Statement> show_create_stmt
@@ -1236,6 +1249,9 @@ func (u *sqlSymUnion) asTenantClause() tree.TenantID {
 %type <tree.UserPriority> transaction_user_priority
 %type <tree.ReadWriteMode> transaction_read_mode
 %type <tree.DeferrableMode> transaction_deferrable_mode
+%type <tree.ConstraintDeferrability> opt_deferrable
+%type <tree.ConstraintCheckMode> constraint_check_mode
+%type <tree.NameList> constraints_set_list
 
 %type <str> name opt_name opt_name_parens
 %type <str> privilege savepoint_name
@@ -2756,7 +2772,7 @@ alter_attribute_action:
 // %Help: REFRESH - recalculate a materialized view
 // %Category: Misc
 // %Text:
-// REFRESH MATERIALIZED VIEW [CONCURRENTLY] view_name [WITH [NO] DATA]
+// REFRESH MATERIALIZED VIEW [CONCURRENTLY] view_name [WITH [NO | INCREMENTAL] DATA]
 refresh_stmt:
   REFRESH MATERIALIZED VIEW opt_concurrently view_name opt_clear_data
   {
@@ -2777,6 +2793,10 @@ opt_clear_data:
   {
     $$.val = tree.RefreshDataClear
   }
+| WITH INCREMENTAL DATA
+  {
+    $$.val = tree.RefreshDataIncremental
+  }
 | /* EMPTY */
   {
     $$.val = tree.RefreshDataDefault
@@ -2927,6 +2947,37 @@ backup_options:
   $$.val = &tree.BackupOptions{IncrementalStorage: $3.stringOrPlaceholderOptList()}
   }
 
+// %Help: COMPACT BACKUP - merge a chain of backups into a new full backup
+// %Category: CCL
+// %Text:
+// COMPACT BACKUP <subdir> IN <collection>
+//        [ WITH <option> [= <value>] [, ...] ]
+//
+// Merges the full backup in <subdir> of the collection and all the incremental
+// backups appended to it into a new full backup of the collection, as of the
+// end time of the last incremental backup. <subdir> can be LATEST.
+//
+// Collection:
+//    "[scheme]://[host]/[path to collection]?[parameters]"
+//
+// Options:
+//    encryption_passphrase="secret": decrypt and encrypt backups
+//    kms="[kms_provider]://[kms_host]/[master_key_identifier]?[parameters]" : decrypt and encrypt backups using KMS
+//    detached: execute compaction job asynchronously, without waiting for its completion
+//    incremental_location: specify the path of the incremental backups
+//
+// %SeeAlso: BACKUP, RESTORE, WEBDOCS/backup.html
+compact_backup_stmt:
+  COMPACT BACKUP string_or_placeholder IN string_or_placeholder opt_with_backup_options
+  {
+    $$.val = &tree.CompactBackup{
+      Subdir: $3.expr(),
+      Collection: $5.expr(),
+      Options: *$6.backupOptions(),
+    }
+  }
+| COMPACT BACKUP error // SHOW HELP: COMPACT BACKUP
+
 
 // %Help: CREATE SCHEDULE FOR BACKUP - backup data periodically
 // %Category: CCL
@@ -3174,13 +3225,14 @@ restore_stmt:
       Options: *($9.restoreOptions()),
     }
   }
-| RESTORE targets FROM REPLICATION STREAM FROM string_or_placeholder_opt_list opt_as_of_clause opt_as_tenant_clause
+| RESTORE targets FROM REPLICATION STREAM FROM string_or_placeholder_opt_list opt_as_of_clause opt_as_tenant_clause opt_with_options
   {
    $$.val = &tree.StreamIngestion{
      Targets: $2.targetList(),
      From: $7.stringOrPlaceholderOptList(),
      AsOf: $8.asOfClause(),
      AsTenant: $9.asTenantClause(),
+     Options: $10.kvOptions(),
    }
   }
 | RESTORE error // SHOW HELP: RESTORE
@@ -3392,6 +3444,7 @@ import_stmt:
 // Formats:
 //    CSV
 //    Parquet
+//    Iceberg
 //
 // Options:
 //    delimiter = '...'   [CSV-specific]
@@ -3586,9 +3639,10 @@ copy_options:
 
 // %Help: CANCEL
 // %Category: Group
-// %Text: CANCEL JOBS, CANCEL QUERIES, CANCEL SESSIONS
+// %Text: CANCEL JOBS, CANCEL QUERIES, CANCEL SESSIONS, CANCEL BLOCKING TRANSACTIONS
 cancel_stmt:
   cancel_jobs_stmt      // EXTEND WITH HELP: CANCEL JOBS
+| cancel_blocking_transactions_stmt // EXTEND WITH HELP: CANCEL BLOCKING TRANSACTIONS
 | cancel_queries_stmt   // EXTEND WITH HELP: CANCEL QUERIES
 | cancel_sessions_stmt  // EXTEND WITH HELP: CANCEL SESSIONS
 | cancel_all_jobs_stmt  // EXTEND WITH HELP: CANCEL ALL JOBS
@@ -3693,6 +3747,21 @@ cancel_sessions_stmt:
   }
 | CANCEL SESSIONS error // SHOW HELP: CANCEL SESSIONS
 
+// %Help: CANCEL BLOCKING TRANSACTIONS - cancel the sessions running the head blockers of lock waits
+// %Category: Misc
+// %Text: CANCEL BLOCKING TRANSACTIONS
+//
+// Cancels the sessions running the transactions at the head of chains of
+// transactions waiting on locks, as listed by SHOW BLOCKING TRANSACTIONS.
+// Each cancellation is recorded in the event log.
+// %SeeAlso: SHOW BLOCKING TRANSACTIONS, CANCEL SESSIONS
+cancel_blocking_transactions_stmt:
+  CANCEL BLOCKING TRANSACTIONS
+  {
+    $$.val = &tree.CancelBlockingTransactions{}
+  }
+| CANCEL BLOCKING error // SHOW HELP: CANCEL BLOCKING TRANSACTIONS
+
 // %Help: CANCEL ALL JOBS
 // %Category: Misc
 // %Text:
@@ -3957,6 +4026,9 @@ create_stats_option:
 // CREATE CHANGEFEED
 // FOR <targets> [INTO sink] [WITH <options>]
 //
+// CREATE CHANGEFEED [INTO sink] [WITH <options>]
+// AS SELECT <targets> FROM <table> [WHERE <expr>]
+//
 // Sink: Data caputre stream stream destination.  Enterprise only.
 create_changefeed_stmt:
   CREATE CHANGEFEED FOR changefeed_targets opt_changefeed_sink opt_with_options
@@ -3967,6 +4039,19 @@ create_changefeed_stmt:
       Options: $6.kvOptions(),
     }
   }
+| CREATE CHANGEFEED opt_changefeed_sink opt_with_options AS SELECT target_list FROM table_name opt_where_clause
+  {
+    name := $9.unresolvedObjectName().ToTableName()
+    $$.val = &tree.CreateChangefeed{
+      SinkURI: $3.expr(),
+      Options: $4.kvOptions(),
+      Select: &tree.SelectClause{
+        Exprs: $7.selExprs(),
+        From:  tree.From{Tables: tree.TableExprs{&name}},
+        Where: tree.NewWhere(tree.AstWhere, $10.expr()),
+      },
+    }
+  }
 | EXPERIMENTAL CHANGEFEED FOR changefeed_targets opt_with_options
   {
     /* SKIP DOC */
@@ -4397,6 +4482,7 @@ preparable_stmt:
   alter_stmt     // help texts in sub-rule
 | backup_stmt    // EXTEND WITH HELP: BACKUP
 | cancel_stmt    // help texts in sub-rule
+| compact_backup_stmt // EXTEND WITH HELP: COMPACT BACKUP
 | create_stmt    // help texts in sub-rule
 | delete_stmt    // EXTEND WITH HELP: DELETE
 | drop_stmt      // help texts in sub-rule
@@ -4894,7 +4980,7 @@ use_stmt:
 nonpreparable_set_stmt:
   set_transaction_stmt // EXTEND WITH HELP: SET TRANSACTION
 | set_exprs_internal   { /* SKIP DOC */ }
-| SET CONSTRAINTS error { return unimplemented(sqllex, "set constraints") }
+| set_constraints_stmt // EXTEND WITH HELP: SET CONSTRAINTS
 
 // SET SESSION / SET LOCAL / SET CLUSTER SETTING
 preparable_set_stmt:
@@ -5064,7 +5150,7 @@ set_exprs_internal:
 // %Text:
 // SET [SESSION] <var> { TO | = } <values...>
 // SET [SESSION] TIME ZONE <tz>
-// SET [SESSION] CHARACTERISTICS AS TRANSACTION ISOLATION LEVEL { SNAPSHOT | SERIALIZABLE }
+// SET [SESSION] CHARACTERISTICS AS TRANSACTION ISOLATION LEVEL { READ COMMITTED | SNAPSHOT | SERIALIZABLE }
 // SET [SESSION] TRACING { TO | = } { on | off | cluster | kv | results } [,...]
 //
 // %SeeAlso: SHOW SESSION, RESET, DISCARD, SHOW, SET CLUSTER SETTING, SET TRANSACTION, SET LOCAL
@@ -5109,7 +5195,7 @@ set_local_stmt:
 // SET [SESSION] TRANSACTION <txnparameters...>
 //
 // Transaction parameters:
-//    ISOLATION LEVEL { SNAPSHOT | SERIALIZABLE }
+//    ISOLATION LEVEL { READ COMMITTED | SNAPSHOT | SERIALIZABLE }
 //    PRIORITY { LOW | NORMAL | HIGH }
 //    AS OF SYSTEM TIME <expr>
 //    [NOT] DEFERRABLE
@@ -5128,6 +5214,36 @@ set_transaction_stmt:
   }
 | SET SESSION TRANSACTION error // SHOW HELP: SET TRANSACTION
 
+// %Help: SET CONSTRAINTS - set constraint check timing for the current transaction
+// %Category: Txn
+// %Text:
+// SET CONSTRAINTS { ALL | <name> [, ...] } { DEFERRED | IMMEDIATE }
+//
+// %SeeAlso: SET TRANSACTION, WEBDOCS/set-constraints.html
+set_constraints_stmt:
+  SET CONSTRAINTS constraints_set_list constraint_check_mode
+  {
+    $$.val = &tree.SetConstraints{Names: $3.nameList(), Mode: $4.constraintCheckMode()}
+  }
+| SET CONSTRAINTS error // SHOW HELP: SET CONSTRAINTS
+
+constraints_set_list:
+  ALL
+  {
+    $$.val = tree.NameList(nil)
+  }
+| name_list
+
+constraint_check_mode:
+  DEFERRED
+  {
+    $$.val = tree.ConstraintCheckDeferred
+  }
+| IMMEDIATE
+  {
+    $$.val = tree.ConstraintCheckImmediate
+  }
+
 generic_set:
   var_name to_or_eq var_list
   {
@@ -5257,11 +5373,11 @@ var_list:
 iso_level:
   READ UNCOMMITTED
   {
-    $$.val = tree.SerializableIsolation
+    $$.val = tree.ReadCommittedIsolation
   }
 | READ COMMITTED
   {
-    $$.val = tree.SerializableIsolation
+    $$.val = tree.ReadCommittedIsolation
   }
 | SNAPSHOT
   {
@@ -5321,7 +5437,7 @@ zone_value:
 // %Help: SHOW
 // %Category: Group
 // %Text:
-// SHOW BACKUP, SHOW CLUSTER SETTING, SHOW COLUMNS, SHOW CONSTRAINTS,
+// SHOW BACKUP, SHOW BLOCKING TRANSACTIONS, SHOW CLUSTER SETTING, SHOW COLUMNS, SHOW CONSTRAINTS,
 // SHOW CREATE, SHOW CREATE SCHEDULES, SHOW DATABASES, SHOW ENUMS, SHOW HISTOGRAM, SHOW INDEXES, SHOW
 // PARTITIONS, SHOW JOBS, SHOW STATEMENTS, SHOW RANGE, SHOW RANGES, SHOW REGIONS, SHOW SURVIVAL GOAL,
 // SHOW ROLES, SHOW SCHEMAS, SHOW SEQUENCES, SHOW SESSION, SHOW SESSIONS,
@@ -5330,6 +5446,7 @@ zone_value:
 // SHOW SCHEDULES, SHOW LOCALITY, SHOW ZONE CONFIGURATION, SHOW FULL TABLE SCANS
 show_stmt:
   show_backup_stmt           // EXTEND WITH HELP: SHOW BACKUP
+| show_blocking_transactions_stmt // EXTEND WITH HELP: SHOW BLOCKING TRANSACTIONS
 | show_columns_stmt          // EXTEND WITH HELP: SHOW COLUMNS
 | show_constraints_stmt      // EXTEND WITH HELP: SHOW CONSTRAINTS
 | show_create_stmt           // EXTEND WITH HELP: SHOW CREATE
@@ -6231,6 +6348,21 @@ show_tables_stmt:
   }
 | SHOW TABLES error // SHOW HELP: SHOW TABLES
 
+// %Help: SHOW BLOCKING TRANSACTIONS - list transactions blocking other transactions on locks
+// %Category: Misc
+// %Text: SHOW BLOCKING TRANSACTIONS
+//
+// Lists the transactions at the head of chains of transactions waiting on
+// locks, along with the sessions running them and the number of transactions
+// they block.
+// %SeeAlso: CANCEL BLOCKING TRANSACTIONS, SHOW TRANSACTIONS
+show_blocking_transactions_stmt:
+  SHOW BLOCKING TRANSACTIONS
+  {
+    $$.val = &tree.ShowBlockingTransactions{}
+  }
+| SHOW BLOCKING TRANSACTIONS error // SHOW HELP: SHOW BLOCKING TRANSACTIONS
+
 // %Help: SHOW TRANSACTIONS - list open client transactions across the cluster
 // %Category: Misc
 // %Text: SHOW [ALL] [CLUSTER | LOCAL] TRANSACTIONS
@@ -7731,6 +7863,10 @@ table_constraint:
 constraint_elem:
   CHECK '(' a_expr ')' opt_deferrable
   {
+    if $5.constraintDeferrability() != tree.ConstraintNotDeferrable {
+      sqllex.Error("CHECK constraints cannot be marked DEFERRABLE")
+      return 1
+    }
     $$.val = &tree.CheckConstraintTableDef{
       Expr: $3.expr(),
     }
@@ -7746,6 +7882,7 @@ constraint_elem:
         PartitionByIndex: $7.partitionByIndex(),
         Predicate: $9.expr(),
       },
+      Deferrability: $8.constraintDeferrability(),
     }
   }
 | PRIMARY KEY '(' index_params ')' opt_hash_sharded opt_with_storage_parameter_list
@@ -7769,6 +7906,7 @@ constraint_elem:
       ToCols: $8.nameList(),
       Match: $9.compositeKeyMatchMethod(),
       Actions: $10.referenceActions(),
+      Deferrability: $11.constraintDeferrability(),
     }
   }
 | EXCLUDE USING error
@@ -7881,13 +8019,46 @@ create_as_col_qualification_elem:
     }
   }
 
+// opt_deferrable specifies whether checking a constraint can be postponed
+// until the end of the transaction. INITIALLY DEFERRED implies DEFERRABLE.
 opt_deferrable:
-  /* EMPTY */ { /* no error */ }
-| DEFERRABLE { return unimplementedWithIssueDetail(sqllex, 31632, "deferrable") }
-| DEFERRABLE INITIALLY DEFERRED { return unimplementedWithIssueDetail(sqllex, 31632, "def initially deferred") }
-| DEFERRABLE INITIALLY IMMEDIATE { return unimplementedWithIssueDetail(sqllex, 31632, "def initially immediate") }
-| INITIALLY DEFERRED { return unimplementedWithIssueDetail(sqllex, 31632, "initially deferred") }
-| INITIALLY IMMEDIATE { return unimplementedWithIssueDetail(sqllex, 31632, "initially immediate") }
+  /* EMPTY */
+  {
+    $$.val = tree.ConstraintNotDeferrable
+  }
+| NOT_DEFERRABLE DEFERRABLE
+  {
+    $$.val = tree.ConstraintNotDeferrable
+  }
+| NOT_DEFERRABLE DEFERRABLE INITIALLY IMMEDIATE
+  {
+    $$.val = tree.ConstraintNotDeferrable
+  }
+| NOT_DEFERRABLE DEFERRABLE INITIALLY DEFERRED
+  {
+    sqllex.Error("constraint declared INITIALLY DEFERRED must be DEFERRABLE")
+    return 1
+  }
+| INITIALLY IMMEDIATE
+  {
+    $$.val = tree.ConstraintNotDeferrable
+  }
+| DEFERRABLE
+  {
+    $$.val = tree.ConstraintDeferrableInitiallyImmediate
+  }
+| DEFERRABLE INITIALLY IMMEDIATE
+  {
+    $$.val = tree.ConstraintDeferrableInitiallyImmediate
+  }
+| DEFERRABLE INITIALLY DEFERRED
+  {
+    $$.val = tree.ConstraintDeferrableInitiallyDeferred
+  }
+| INITIALLY DEFERRED
+  {
+    $$.val = tree.ConstraintDeferrableInitiallyDeferred
+  }
 
 storing:
   COVERING
@@ -9287,7 +9458,7 @@ transaction_stmt:
 // START TRANSACTION [ <txnparameter> [[,] ...] ]
 //
 // Transaction parameters:
-//    ISOLATION LEVEL { SNAPSHOT | SERIALIZABLE }
+//    ISOLATION LEVEL { READ COMMITTED | SNAPSHOT | SERIALIZABLE }
 //    PRIORITY { LOW | NORMAL | HIGH }
 //
 // %SeeAlso: COMMIT, ROLLBACK, WEBDOCS/begin-transaction.html
@@ -9446,7 +9617,7 @@ transaction_deferrable_mode:
   {
     $$.val = tree.Deferrable
   }
-| NOT DEFERRABLE
+| NOT_DEFERRABLE DEFERRABLE
   {
     $$.val = tree.NotDeferrable
   }
@@ -10504,9 +10675,18 @@ group_by_list:
 // rather than reducing the conflicting unreserved_keyword rule.
 group_by_item:
   a_expr { $$.val = $1.expr() }
-| ROLLUP '(' error { return unimplementedWithIssueDetail(sqllex, 46280, "rollup") }
-| CUBE '(' error { return unimplementedWithIssueDetail(sqllex, 46280, "cube") }
-| GROUPING SETS error { return unimplementedWithIssueDetail(sqllex, 46280, "grouping sets") }
+| ROLLUP '(' expr_list ')'
+  {
+    $$.val = &tree.GroupingSet{Kind: tree.GroupingRollup, Exprs: $3.exprs()}
+  }
+| CUBE '(' expr_list ')'
+  {
+    $$.val = &tree.GroupingSet{Kind: tree.GroupingCube, Exprs: $3.exprs()}
+  }
+| GROUPING SETS '(' group_by_list ')'
+  {
+    $$.val = &tree.GroupingSet{Kind: tree.GroupingSets, Exprs: $4.exprs()}
+  }
 
 having_clause:
   HAVING a_expr
@@ -12437,7 +12617,10 @@ d_expr:
   {
     $$.val = $2.expr()
   }
-| GROUPING '(' expr_list ')' { return unimplemented(sqllex, "d_expr grouping") }
+| GROUPING '(' expr_list ')'
+  {
+    $$.val = &tree.GroupingOperation{Exprs: $3.exprs()}
+  }
 
 func_application:
   func_name '(' ')'
@@ -13996,6 +14179,7 @@ unreserved_keyword:
 | BEFORE
 | BEGIN
 | BINARY
+| BLOCKING
 | BUCKET_COUNT
 | BUNDLE
 | BY
@@ -963,6 +963,10 @@ func populateTableConstraints(
 				}
 				f.WriteString(strings.Join(colNames, ", "))
 				f.WriteByte(')')
+				if d := con.UniqueWithoutIndexConstraint.Deferrability; d != descpb.ConstraintDeferrability_NotDeferrable {
+					f.WriteByte(' ')
+					f.WriteString(descpb.ConstraintDeferrabilityType[d].String())
+				}
 				if con.UniqueWithoutIndexConstraint.Validity != descpb.ConstraintValidity_Validated {
 					f.WriteString(" NOT VALID")
 				}
@@ -999,13 +1003,20 @@ func populateTableConstraints(
 			condef = tree.NewDString(fmt.Sprintf("CHECK ((%s))%s", displayExpr, validity))
 		}
 
+		condeferrable := tree.MakeDBool(
+			tree.DBool(con.Deferrability() != descpb.ConstraintDeferrability_NotDeferrable),
+		)
+		condeferred := tree.MakeDBool(
+			tree.DBool(con.Deferrability() == descpb.ConstraintDeferrability_DeferrableInitiallyDeferred),
+		)
+
 		if err := addRow(
 			oid,                  // oid
 			dNameOrNull(conName), // conname
 			namespaceOid,         // connamespace
 			contype,              // contype
-			tree.DBoolFalse,      // condeferrable
-			tree.DBoolFalse,      // condeferred
+			condeferrable,        // condeferrable
+			condeferred,          // condeferred
 			tree.MakeDBool(tree.DBool(!con.Unvalidated)), // convalidated
 			tblOid,         // conrelid
 			oidZero,        // contypid
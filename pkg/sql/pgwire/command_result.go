@@ -247,6 +247,33 @@ func (r *commandResult) DisableBuffering() {
 	r.bufferingDisabled = true
 }
 
+// BufferedResultsLen is part of the sql.RestrictedCommandResult interface.
+func (r *commandResult) BufferedResultsLen() int {
+	r.assertNotReleased()
+	return r.conn.writerState.buf.Len()
+}
+
+// TruncateBufferedResults is part of the sql.RestrictedCommandResult interface.
+func (r *commandResult) TruncateBufferedResults(idx int) bool {
+	r.assertNotReleased()
+	fi := &r.conn.writerState.fi
+	if fi.lastFlushed >= r.pos || idx > fi.buf.Len() {
+		// Some of the results of this command were flushed to the client.
+		return false
+	}
+	// Forget the start of the results of this command if it is being discarded;
+	// it is registered again when the results are written again.
+	for !fi.cmdStarts.empty() {
+		if last := fi.cmdStarts.getLast(); last.pos < r.pos || last.idx < idx {
+			break
+		}
+		fi.cmdStarts.removeLast()
+	}
+	fi.buf.Truncate(idx)
+	r.rowsAffected = 0
+	return true
+}
+
 // BufferParamStatusUpdate is part of the sql.RestrictedCommandResult interface.
 func (r *commandResult) BufferParamStatusUpdate(param string, val string) {
 	r.buffer.paramStatusUpdates = append(
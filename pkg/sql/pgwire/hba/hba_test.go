@@ -72,6 +72,34 @@ func TestParseAndNormalizeAuthConfig(t *testing.T) {
 		})
 }
 
+func TestParseLDAPOptions(t *testing.T) {
+	datadriven.RunTest(t, testutils.TestDataPath(t, "ldap"),
+		func(t *testing.T, td *datadriven.TestData) string {
+			switch td.Cmd {
+			case "ldap":
+				tokens, err := tokenize(td.Input)
+				if err != nil {
+					td.Fatalf(t, "%v", err)
+				}
+				if len(tokens.lines) != 1 {
+					td.Fatalf(t, "ldap parse only valid with one line of input")
+				}
+				entry, err := parseHbaLine(tokens.lines[0])
+				if err != nil {
+					td.Fatalf(t, "%v", err)
+				}
+				opts, err := ParseLDAPOptions(entry)
+				if err != nil {
+					return fmt.Sprintf("error: %v\n", err)
+				}
+				return fmt.Sprintf("search+bind: %v\n%# v\n", opts.SearchBind(), pretty.Formatter(opts))
+
+			default:
+				return fmt.Sprintf("unknown directive: %s", td.Cmd)
+			}
+		})
+}
+
 func TestMatchConnType(t *testing.T) {
 	testCases := []struct {
 		conf, conn ConnType
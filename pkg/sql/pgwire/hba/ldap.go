@@ -0,0 +1,173 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package hba
+
+import (
+	"strconv"
+
+	"github.com/cockroachdb/errors"
+)
+
+// LDAPOptions is the parsed form of the options of an HBA entry using
+// the "ldap" method. Like in PostgreSQL, the options select one of two
+// modes:
+//
+//   - simple bind: the server is bound to directly with the DN
+//     ldapprefix + <user> + ldapsuffix and the client's password.
+//   - search+bind: the server is first bound to with ldapbinddn and
+//     ldapbindpasswd (or anonymously), the entry of the connecting user is
+//     searched for under ldapbasedn, and the server is then bound to with
+//     the DN of that entry and the client's password.
+//
+// See https://www.postgresql.org/docs/current/auth-ldap.html.
+type LDAPOptions struct {
+	// Server is the host name or address of the LDAP server.
+	Server string
+	// Port is the port of the LDAP server.
+	Port int
+	// Scheme is either "ldap" or "ldaps".
+	Scheme string
+	// TLS is set if the connection is upgraded using StartTLS.
+	TLS bool
+
+	// Prefix and Suffix surround the user name to form the DN in simple
+	// bind mode.
+	Prefix string
+	Suffix string
+
+	// BaseDN is the root of the search for the user in search+bind mode.
+	// A non-empty BaseDN selects search+bind mode.
+	BaseDN string
+	// BindDN and BindPasswd are used to bind to the server before the
+	// search. If both are empty, an anonymous bind is used.
+	BindDN     string
+	BindPasswd string
+	// SearchAttribute is the attribute matched against the user name in
+	// the search. It defaults to "uid".
+	SearchAttribute string
+	// SearchFilter, if set, replaces the default search filter. The
+	// occurrences of $username in it are replaced by the user name.
+	SearchFilter string
+
+	// GroupAttribute, if set, is the attribute of the user's entry listing
+	// the DNs of the groups of the user (e.g. memberOf). The role
+	// memberships of the user are then synchronized with these groups at
+	// login.
+	GroupAttribute string
+}
+
+// SearchBind returns true if the options select search+bind mode.
+func (o *LDAPOptions) SearchBind() bool {
+	return o.BaseDN != ""
+}
+
+// Default ports of the LDAP schemes.
+const (
+	defaultLDAPPort  = 389
+	defaultLDAPSPort = 636
+)
+
+// ParseLDAPOptions parses and validates the options of an HBA entry using
+// the "ldap" method.
+func ParseLDAPOptions(entry Entry) (*LDAPOptions, error) {
+	o := &LDAPOptions{Scheme: "ldap"}
+	seen := make(map[string]bool, len(entry.Options))
+	for _, op := range entry.Options {
+		name, val := op[0], op[1]
+		if seen[name] {
+			return nil, errors.Errorf("option %s specified more than once", name)
+		}
+		seen[name] = true
+		switch name {
+		case "ldapserver":
+			o.Server = val
+		case "ldapport":
+			port, err := strconv.Atoi(val)
+			if err != nil || port <= 0 || port > 65535 {
+				return nil, errors.Errorf("invalid LDAP port number: %q", val)
+			}
+			o.Port = port
+		case "ldapscheme":
+			if val != "ldap" && val != "ldaps" {
+				return nil, errors.Errorf("invalid ldapscheme value: %q", val)
+			}
+			o.Scheme = val
+		case "ldaptls":
+			switch val {
+			case "1":
+				o.TLS = true
+			case "0":
+				o.TLS = false
+			default:
+				return nil, errors.Errorf("ldaptls must be set to 0 or 1: %s", val)
+			}
+		case "ldapprefix":
+			o.Prefix = val
+		case "ldapsuffix":
+			o.Suffix = val
+		case "ldapbasedn":
+			o.BaseDN = val
+		case "ldapbinddn":
+			o.BindDN = val
+		case "ldapbindpasswd":
+			o.BindPasswd = val
+		case "ldapsearchattribute":
+			o.SearchAttribute = val
+		case "ldapsearchfilter":
+			o.SearchFilter = val
+		case "ldapgroupattribute":
+			o.GroupAttribute = val
+		default:
+			return nil, errors.Errorf("unsupported option %s", name)
+		}
+	}
+
+	if o.Server == "" {
+		return nil, errors.New(`the "ldapserver" option is required`)
+	}
+	if o.TLS && o.Scheme == "ldaps" {
+		return nil, errors.New("ldaptls cannot be used with ldapscheme ldaps")
+	}
+	if o.Port == 0 {
+		o.Port = defaultLDAPPort
+		if o.Scheme == "ldaps" {
+			o.Port = defaultLDAPSPort
+		}
+	}
+
+	if seen["ldapprefix"] || seen["ldapsuffix"] {
+		// Simple bind mode.
+		for _, opt := range []string{
+			"ldapbasedn", "ldapbinddn", "ldapbindpasswd", "ldapsearchattribute", "ldapsearchfilter",
+		} {
+			if seen[opt] {
+				return nil, errors.Errorf(
+					"cannot use ldapbasedn, ldapbinddn, ldapbindpasswd, ldapsearchattribute, "+
+						"or ldapsearchfilter together with ldapprefix: %s", opt)
+			}
+		}
+		return o, nil
+	}
+
+	if o.BaseDN == "" {
+		return nil, errors.New(`one of "ldapbasedn", "ldapprefix" or "ldapsuffix" options required`)
+	}
+	if o.SearchAttribute != "" && o.SearchFilter != "" {
+		return nil, errors.New("cannot use ldapsearchattribute together with ldapsearchfilter")
+	}
+	if o.BindPasswd != "" && o.BindDN == "" {
+		return nil, errors.New("ldapbindpasswd requires ldapbinddn")
+	}
+	if o.SearchAttribute == "" && o.SearchFilter == "" {
+		o.SearchAttribute = "uid"
+	}
+	return o, nil
+}
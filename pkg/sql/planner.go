@@ -105,6 +105,11 @@ type extendedEvalContext struct {
 
 	SchemaChangerState *SchemaChangerState
 
+	// DeferredConstraints refers to deferredConstraints in extraTxnState of
+	// sql.connExecutor. It is nil for the internal executor, which never
+	// defers constraint checks.
+	DeferredConstraints *deferredConstraints
+
 	statementPreparer statementPreparer
 }
 
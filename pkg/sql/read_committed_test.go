@@ -0,0 +1,172 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package sql_test
+
+import (
+	"context"
+	gosql "database/sql"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
+	"github.com/cockroachdb/cockroach/pkg/util/ctxgroup"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+// TestReadCommittedPerStatementSnapshot checks that each statement of a READ
+// COMMITTED transaction observes the writes committed before it started, while
+// a SERIALIZABLE transaction keeps reading from the same snapshot.
+func TestReadCommittedPerStatementSnapshot(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	s, db, _ := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(ctx)
+
+	sqlDB := sqlutils.MakeSQLRunner(db)
+	sqlDB.Exec(t, `SET CLUSTER SETTING sql.txn.read_committed_isolation.enabled = true`)
+	sqlDB.Exec(t, `CREATE TABLE kv (k INT PRIMARY KEY, v INT)`)
+
+	for _, tc := range []struct {
+		isoLevel    gosql.IsolationLevel
+		expIsoLevel string
+		expSecond   int
+	}{
+		{isoLevel: gosql.LevelSerializable, expIsoLevel: "serializable", expSecond: 0},
+		{isoLevel: gosql.LevelReadCommitted, expIsoLevel: "read committed", expSecond: 1},
+	} {
+		t.Run(tc.expIsoLevel, func(t *testing.T) {
+			sqlDB.Exec(t, `UPSERT INTO kv VALUES (1, 0)`)
+
+			tx, err := db.BeginTx(ctx, &gosql.TxOptions{Isolation: tc.isoLevel})
+			require.NoError(t, err)
+			var isoLevel string
+			require.NoError(t, tx.QueryRow(`SHOW transaction_isolation`).Scan(&isoLevel))
+			require.Equal(t, tc.expIsoLevel, isoLevel)
+
+			var v int
+			require.NoError(t, tx.QueryRow(`SELECT v FROM kv WHERE k = 1`).Scan(&v))
+			require.Equal(t, 0, v)
+
+			sqlDB.Exec(t, `UPDATE kv SET v = 1 WHERE k = 1`)
+
+			require.NoError(t, tx.QueryRow(`SELECT v FROM kv WHERE k = 1`).Scan(&v))
+			require.Equal(t, tc.expSecond, v)
+			require.NoError(t, tx.Rollback())
+		})
+	}
+}
+
+// TestReadCommittedContention checks that READ COMMITTED transactions which
+// read data written concurrently and update the same row don't need to be
+// retried by the client, and don't lose any update.
+func TestReadCommittedContention(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	s, db, _ := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(ctx)
+
+	sqlDB := sqlutils.MakeSQLRunner(db)
+	sqlDB.Exec(t, `SET CLUSTER SETTING sql.txn.read_committed_isolation.enabled = true`)
+	sqlDB.Exec(t, `CREATE TABLE kv (k INT PRIMARY KEY, v INT)`)
+	sqlDB.Exec(t, `INSERT INTO kv VALUES (0, 0)`)
+
+	const numWorkers = 8
+	const numIncrements = 20
+	g := ctxgroup.WithContext(ctx)
+	for i := 1; i <= numWorkers; i++ {
+		worker := i
+		g.GoCtx(func(ctx context.Context) error {
+			for j := 0; j < numIncrements; j++ {
+				tx, err := db.BeginTx(ctx, &gosql.TxOptions{Isolation: gosql.LevelReadCommitted})
+				if err != nil {
+					return err
+				}
+				// Read the rows written by the other workers, which would cause the
+				// transaction to fail to refresh under SERIALIZABLE isolation.
+				var count int
+				if err := tx.QueryRowContext(ctx, `SELECT count(*) FROM kv`).Scan(&count); err != nil {
+					_ = tx.Rollback()
+					return err
+				}
+				if _, err := tx.ExecContext(ctx, `UPSERT INTO kv VALUES ($1, $2)`, worker, j); err != nil {
+					_ = tx.Rollback()
+					return err
+				}
+				if _, err := tx.ExecContext(ctx, `UPDATE kv SET v = v + 1 WHERE k = 0`); err != nil {
+					_ = tx.Rollback()
+					return err
+				}
+				if err := tx.Commit(); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+	require.NoError(t, g.Wait())
+
+	sqlDB.CheckQueryResults(t, `SELECT v FROM kv WHERE k = 0`,
+		[][]string{{"160"}} /* numWorkers * numIncrements */)
+}
+
+// TestReadCommittedForeignKeyLocking checks that the FK checks of READ
+// COMMITTED transactions lock the referenced rows with shared locks, which
+// prevent them from being modified concurrently, but not from being referenced
+// by other transactions.
+func TestReadCommittedForeignKeyLocking(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	s, db, _ := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(ctx)
+
+	sqlDB := sqlutils.MakeSQLRunner(db)
+	sqlDB.Exec(t, `SET CLUSTER SETTING sql.txn.read_committed_isolation.enabled = true`)
+	sqlDB.Exec(t, `CREATE TABLE parent (k INT PRIMARY KEY, v INT)`)
+	sqlDB.Exec(t, `CREATE TABLE child (k INT PRIMARY KEY, p INT REFERENCES parent (k))`)
+	sqlDB.Exec(t, `INSERT INTO parent VALUES (1, 0)`)
+
+	tx1, err := db.BeginTx(ctx, &gosql.TxOptions{Isolation: gosql.LevelReadCommitted})
+	require.NoError(t, err)
+	defer func() { _ = tx1.Rollback() }()
+	_, err = tx1.Exec(`INSERT INTO child VALUES (1, 1)`)
+	require.NoError(t, err)
+
+	// Shared locks are compatible with each other.
+	tx2, err := db.BeginTx(ctx, &gosql.TxOptions{Isolation: gosql.LevelReadCommitted})
+	require.NoError(t, err)
+	defer func() { _ = tx2.Rollback() }()
+	_, err = tx2.Exec(`INSERT INTO child VALUES (2, 1)`)
+	require.NoError(t, err)
+
+	// But they prevent the referenced row from being written to.
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.ExecContext(ctx, `SET lock_timeout = '100ms'`)
+	require.NoError(t, err)
+	_, err = conn.ExecContext(ctx, `UPDATE parent SET v = 1 WHERE k = 1`)
+	require.Error(t, err)
+	require.Regexp(t, "lock timeout", err)
+
+	require.NoError(t, tx1.Commit())
+	require.NoError(t, tx2.Commit())
+	_, err = conn.ExecContext(ctx, `UPDATE parent SET v = 1 WHERE k = 1`)
+	require.NoError(t, err)
+}
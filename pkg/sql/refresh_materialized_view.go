@@ -12,6 +12,7 @@ package sql
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/cockroachdb/cockroach/pkg/server/telemetry"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
@@ -20,11 +21,14 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgnotice"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/volatility"
 )
 
 type refreshMaterializedViewNode struct {
 	n    *tree.RefreshMaterializedView
 	desc *tabledesc.Mutable
+	// incremental is set if the view is refreshed WITH INCREMENTAL DATA.
+	incremental *incrementalRefresh
 }
 
 func (p *planner) RefreshMaterializedView(
@@ -68,9 +72,128 @@ func (p *planner) RefreshMaterializedView(
 		)
 	}
 
+	if n.RefreshDataOption == tree.RefreshDataIncremental {
+		incremental, err := p.makeIncrementalRefresh(ctx, desc)
+		if err != nil {
+			return nil, err
+		}
+		return &refreshMaterializedViewNode{n: n, desc: desc, incremental: incremental}, nil
+	}
+
 	return &refreshMaterializedViewNode{n: n, desc: desc}, nil
 }
 
+// incrementalRefreshIneligibilityReason returns why a materialized view with
+// the given query cannot be refreshed incrementally, or an empty string if it
+// can. A view is eligible if its query filters and projects the rows of a
+// single table using immutable functions, optionally grouping them to compute
+// sum and count aggregates: the changes to such a view can be derived from the
+// changes to the rows of the table alone.
+func incrementalRefreshIneligibilityReason(
+	stmt tree.Statement, searchPath tree.SearchPath,
+) (string, error) {
+	sel, ok := stmt.(*tree.Select)
+	if !ok {
+		return "query is not a SELECT", nil
+	}
+	if sel.With != nil {
+		return "query uses WITH", nil
+	}
+	if sel.Limit != nil {
+		return "query uses LIMIT or OFFSET", nil
+	}
+	clause, ok := sel.Select.(*tree.SelectClause)
+	if !ok {
+		return "query is not a simple SELECT", nil
+	}
+	if clause.Distinct || clause.DistinctOn != nil {
+		return "query uses DISTINCT", nil
+	}
+	if clause.From.AsOf.Expr != nil {
+		return "query uses AS OF SYSTEM TIME", nil
+	}
+	if len(clause.From.Tables) != 1 {
+		return "query does not read from exactly one table", nil
+	}
+	switch t := clause.From.Tables[0].(type) {
+	case *tree.AliasedTableExpr:
+		switch t.Expr.(type) {
+		case *tree.TableName, *tree.TableRef:
+		default:
+			return "query does not read from exactly one table", nil
+		}
+	default:
+		return "query does not read from exactly one table", nil
+	}
+	if len(clause.Window) > 0 {
+		return "query uses window functions", nil
+	}
+	if clause.Having != nil {
+		return "query uses HAVING", nil
+	}
+
+	var reason string
+	visit := func(expr tree.Expr) (recurse bool, newExpr tree.Expr, err error) {
+		if reason != "" {
+			return false, expr, nil
+		}
+		switch t := expr.(type) {
+		case *tree.Subquery:
+			reason = "query uses subqueries"
+		case *tree.GroupingSet:
+			reason = "query uses grouping sets"
+		case *tree.FuncExpr:
+			if t.WindowDef != nil {
+				reason = "query uses window functions"
+				break
+			}
+			def, err := t.Func.Resolve(searchPath)
+			if err != nil {
+				return false, expr, err
+			}
+			switch def.Class {
+			case tree.AggregateClass:
+				switch def.Name {
+				case "sum", "count", "count_rows":
+					if t.Type == tree.DistinctFuncType || t.Filter != nil || len(t.OrderBy) > 0 {
+						reason = fmt.Sprintf("query uses DISTINCT, FILTER or ORDER BY in %s", def.Name)
+					}
+				default:
+					reason = fmt.Sprintf("query uses aggregate function %s", def.Name)
+				}
+			case tree.GeneratorClass:
+				reason = "query uses set-returning functions"
+			case tree.WindowClass:
+				reason = "query uses window functions"
+			default:
+				// The rows of the view that did not change must have the same
+				// values when the query is evaluated again.
+				for _, o := range def.Definition {
+					if overload, ok := o.(*tree.Overload); ok && overload.Volatility > volatility.Immutable {
+						reason = fmt.Sprintf("query uses function %s, which is not immutable", def.Name)
+						break
+					}
+				}
+			}
+		}
+		return reason == "", expr, nil
+	}
+	exprs := make(tree.Exprs, 0, len(clause.Exprs)+len(clause.GroupBy)+1)
+	for i := range clause.Exprs {
+		exprs = append(exprs, clause.Exprs[i].Expr)
+	}
+	exprs = append(exprs, clause.GroupBy...)
+	if clause.Where != nil {
+		exprs = append(exprs, clause.Where.Expr)
+	}
+	for _, expr := range exprs {
+		if _, err := tree.SimpleVisit(expr, visit); err != nil {
+			return "", err
+		}
+	}
+	return reason, nil
+}
+
 func (n *refreshMaterializedViewNode) startExec(params runParams) error {
 	// We refresh a materialized view by creating a new set of indexes to write
 	// the result of the view query into. The existing set of indexes will remain
@@ -89,6 +212,11 @@ func (n *refreshMaterializedViewNode) startExec(params runParams) error {
 		)
 	}
 
+	// An incremental refresh writes the changes to the existing indexes.
+	if n.incremental != nil {
+		return n.incremental.run(params, n.n)
+	}
+
 	// Prepare the new set of indexes by cloning all existing indexes on the view.
 	newPrimaryIndex := n.desc.GetPrimaryIndex().IndexDescDeepCopy()
 	newIndexes := make([]descpb.IndexDescriptor, len(n.desc.PublicNonPrimaryIndexes()))
@@ -0,0 +1,1049 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package sql
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/kv"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/security/username"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descs"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/schemaexpr"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/tabledesc"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/row"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/eval"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/transform"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree/treebin"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree/treecmp"
+	"github.com/cockroachdb/cockroach/pkg/sql/sessiondata"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/errors"
+)
+
+// incrementalRefreshBatchSize is the maximum number of rows or groups that a
+// single query issued by an incremental refresh filters on.
+const incrementalRefreshBatchSize = 1000
+
+// incrementalRefreshHint is the hint of the errors returned when a view
+// cannot be refreshed incrementally.
+const incrementalRefreshHint = "Use REFRESH MATERIALIZED VIEW without WITH INCREMENTAL DATA to recompute the view."
+
+// incrementalRefresh applies to a materialized view the changes made to its
+// underlying table since the view was last refreshed. The primary keys of the
+// rows that changed are read from the MVCC history of the table, the view
+// query is run over these rows only, once as of the last refresh and once in
+// the current transaction, and the difference between the two results is
+// written to the view.
+type incrementalRefresh struct {
+	// view is the materialized view, and table the table its query reads.
+	view  *tabledesc.Mutable
+	table catalog.TableDescriptor
+	// sel is the view query.
+	sel *tree.SelectClause
+	// outCols are the columns of the view that hold the results of its query,
+	// and extraCols the other ones, such as the hidden rowid column, which are
+	// filled with their default values. cols holds both, in that order.
+	outCols, extraCols, cols []catalog.Column
+
+	// grouped is set if the view query groups rows or computes aggregates, in
+	// which case the view holds one row per group, and:
+	//  - groupCols are the ordinals of the columns that hold the grouping
+	//    expressions;
+	//  - aggs are the other columns, which hold sum and count aggregates;
+	//  - countRowsCol is the ordinal of a count(*) column, or -1 if there is
+	//    none.
+	grouped      bool
+	groupCols    []int
+	aggs         []incrementalAgg
+	countRowsCol int
+
+	deleter      row.Deleter
+	inserter     row.Inserter
+	defaultExprs []tree.TypedExpr
+}
+
+// incrementalAgg is an aggregate computed by a grouped view.
+type incrementalAgg struct {
+	// col is the ordinal of the column of the view that holds the aggregate.
+	col int
+	// sum is set for sum(arg), and unset for count(arg) and count(*).
+	sum bool
+	// arg is the argument of the aggregate, or nil for count(*).
+	arg tree.Expr
+}
+
+// makeIncrementalRefresh checks that the given materialized view can be
+// refreshed incrementally, and prepares the refresh.
+func (p *planner) makeIncrementalRefresh(
+	ctx context.Context, desc *tabledesc.Mutable,
+) (*incrementalRefresh, error) {
+	ineligible := func(reason string) error {
+		return errors.WithHint(
+			pgerror.Newf(pgcode.FeatureNotSupported,
+				"materialized view %q cannot be refreshed incrementally: %s", desc.GetName(), reason),
+			incrementalRefreshHint,
+		)
+	}
+	stmt, err := parser.ParseOne(desc.GetViewQuery())
+	if err != nil {
+		return nil, err
+	}
+	searchPath := p.semaCtx.SearchPath
+	reason, err := incrementalRefreshIneligibilityReason(stmt.AST, searchPath)
+	if err != nil {
+		return nil, err
+	}
+	if reason != "" {
+		return nil, ineligible(reason)
+	}
+	if desc.GetRefreshAsOfTime().IsEmpty() {
+		return nil, errors.WithHint(
+			pgerror.Newf(pgcode.ObjectNotInPrerequisiteState,
+				"materialized view %q cannot be refreshed incrementally until it is refreshed with data",
+				desc.GetName()),
+			incrementalRefreshHint,
+		)
+	}
+	if len(desc.AllMutations()) > 0 {
+		return nil, ineligible("view has schema changes in progress")
+	}
+
+	r := &incrementalRefresh{
+		view:         desc,
+		sel:          stmt.AST.(*tree.Select).Select.(*tree.SelectClause),
+		countRowsCol: -1,
+	}
+	from := r.sel.From.Tables[0].(*tree.AliasedTableExpr)
+	if ref, ok := from.Expr.(*tree.TableRef); len(from.As.Cols) > 0 || (ok && ref.Columns != nil) {
+		return nil, ineligible("query renames the columns of its table")
+	}
+	if from.Ordinality {
+		return nil, ineligible("query uses WITH ORDINALITY")
+	}
+	// The table that the query reads is the only dependency of the view.
+	if len(desc.DependsOn) != 1 {
+		return nil, ineligible("query does not read from exactly one table")
+	}
+	if r.table, err = p.Descriptors().GetImmutableTableByID(
+		ctx, p.txn, desc.DependsOn[0], tree.ObjectLookupFlagsWithRequired(),
+	); err != nil {
+		return nil, err
+	}
+	if !r.table.IsPhysicalTable() || r.table.IsSequence() {
+		return nil, ineligible("query does not read from a table")
+	}
+
+	for _, col := range desc.PublicColumns() {
+		switch {
+		case col.IsComputed():
+			return nil, ineligible("view has computed columns")
+		case !col.IsHidden():
+			r.outCols = append(r.outCols, col)
+		case col.HasDefault():
+			r.extraCols = append(r.extraCols, col)
+		default:
+			return nil, ineligible("view has hidden columns without defaults")
+		}
+	}
+	r.cols = append(append(r.cols, r.outCols...), r.extraCols...)
+	for _, idx := range desc.NonDropIndexes() {
+		if idx.IsPartial() {
+			return nil, ineligible("view has partial indexes")
+		}
+	}
+
+	if reason, err := r.analyzeGrouping(searchPath); err != nil {
+		return nil, err
+	} else if reason != "" {
+		return nil, ineligible(reason)
+	}
+	return r, nil
+}
+
+// analyzeGrouping determines whether the view query groups rows, and if so
+// which columns of the view hold grouping expressions and which hold
+// aggregates. It returns why the view cannot be refreshed incrementally if
+// its aggregates cannot be maintained.
+func (r *incrementalRefresh) analyzeGrouping(searchPath tree.SearchPath) (string, error) {
+	exprs := r.sel.Exprs
+	r.grouped = len(r.sel.GroupBy) > 0
+	for i := range exprs {
+		hasAgg, err := containsAggregate(exprs[i].Expr, searchPath)
+		if err != nil {
+			return "", err
+		}
+		r.grouped = r.grouped || hasAgg
+	}
+	if !r.grouped {
+		return "", nil
+	}
+	if len(exprs) != len(r.outCols) {
+		return "", errors.AssertionFailedf(
+			"view has %d columns but its query has %d", len(r.outCols), len(exprs))
+	}
+
+	isGroupCol := make([]bool, len(exprs))
+	for _, g := range r.sel.GroupBy {
+		col := -1
+		key := tree.AsString(tree.StripParens(g))
+		for i := range exprs {
+			if tree.AsString(tree.StripParens(exprs[i].Expr)) == key {
+				col = i
+				break
+			}
+		}
+		if col == -1 {
+			col = r.groupByReference(g)
+		}
+		if col == -1 {
+			return "query groups by expressions that it does not select", nil
+		}
+		if !isGroupCol[col] {
+			isGroupCol[col] = true
+			r.groupCols = append(r.groupCols, col)
+		}
+	}
+	for i := range exprs {
+		if isGroupCol[i] {
+			continue
+		}
+		agg, ok, err := makeIncrementalAgg(i, exprs[i].Expr, searchPath)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			return "query selects expressions that are neither grouped nor sum or count aggregates", nil
+		}
+		if agg.arg == nil && r.countRowsCol == -1 {
+			r.countRowsCol = i
+		}
+		r.aggs = append(r.aggs, agg)
+	}
+	return "", nil
+}
+
+// groupByReference returns the ordinal of the select expression that the
+// given GROUP BY expression refers to by position or by alias, or -1.
+func (r *incrementalRefresh) groupByReference(g tree.Expr) int {
+	switch t := tree.StripParens(g).(type) {
+	case *tree.NumVal:
+		if i, err := t.AsInt64(); err == nil && i >= 1 && int(i) <= len(r.sel.Exprs) {
+			return int(i) - 1
+		}
+	case *tree.UnresolvedName:
+		if t.NumParts != 1 {
+			break
+		}
+		// Columns of the table take precedence over aliases.
+		if _, err := r.table.FindColumnWithName(tree.Name(t.Parts[0])); err == nil {
+			break
+		}
+		for i := range r.sel.Exprs {
+			if string(r.sel.Exprs[i].As) == t.Parts[0] {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// makeIncrementalAgg returns the aggregate computed by the given select
+// expression, if it is a sum or count aggregate.
+func makeIncrementalAgg(
+	col int, expr tree.Expr, searchPath tree.SearchPath,
+) (incrementalAgg, bool, error) {
+	f, ok := tree.StripParens(expr).(*tree.FuncExpr)
+	if !ok {
+		return incrementalAgg{}, false, nil
+	}
+	def, err := f.Func.Resolve(searchPath)
+	if err != nil {
+		return incrementalAgg{}, false, err
+	}
+	if def.Class != tree.AggregateClass {
+		return incrementalAgg{}, false, nil
+	}
+	agg := incrementalAgg{col: col}
+	switch def.Name {
+	case "sum":
+		if len(f.Exprs) != 1 {
+			return incrementalAgg{}, false, nil
+		}
+		agg.sum = true
+		agg.arg = f.Exprs[0]
+	case "count":
+		if len(f.Exprs) != 1 {
+			return incrementalAgg{}, false, nil
+		}
+		if _, ok := f.Exprs[0].(tree.UnqualifiedStar); !ok {
+			agg.arg = f.Exprs[0]
+		}
+	case "count_rows":
+	default:
+		return incrementalAgg{}, false, nil
+	}
+	return agg, true, nil
+}
+
+// containsAggregate returns whether the given expression calls an aggregate
+// function.
+func containsAggregate(expr tree.Expr, searchPath tree.SearchPath) (bool, error) {
+	var found bool
+	_, err := tree.SimpleVisit(expr, func(expr tree.Expr) (bool, tree.Expr, error) {
+		if f, ok := expr.(*tree.FuncExpr); ok && !found {
+			def, err := f.Func.Resolve(searchPath)
+			if err != nil {
+				return false, expr, err
+			}
+			found = def.Class == tree.AggregateClass
+		}
+		return !found, expr, nil
+	})
+	return found, err
+}
+
+// run applies to the view the changes made to its table between the last
+// refresh of the view and the read timestamp of the current transaction, and
+// records that timestamp as the time of the last refresh. All the reads are
+// issued before the writes to the view.
+func (r *incrementalRefresh) run(params runParams, n *tree.RefreshMaterializedView) error {
+	ctx, p := params.ctx, params.p
+	from, to := r.view.GetRefreshAsOfTime(), p.txn.ReadTimestamp()
+	if err := r.checkPrimaryIndex(ctx, p.ExecCfg(), from); err != nil {
+		return err
+	}
+	keys, err := r.changedKeys(ctx, p.ExecCfg(), from, to)
+	if err != nil {
+		return err
+	}
+	if err := r.initWriters(ctx, p); err != nil {
+		return err
+	}
+	b := p.txn.NewBatch()
+	if r.grouped {
+		err = r.applyGroupedChanges(ctx, p, b, keys, from)
+	} else {
+		err = r.applyChanges(ctx, p, b, keys, from)
+	}
+	if err != nil {
+		return err
+	}
+	if err := p.txn.Run(ctx, b); err != nil {
+		return err
+	}
+	r.view.RefreshAsOfTime = to
+	return p.writeSchemaChange(
+		ctx, r.view, descpb.InvalidMutationID, tree.AsStringWithFQNames(n, params.Ann()),
+	)
+}
+
+// checkPrimaryIndex returns an error if the primary index of the table was
+// replaced since the given time, for instance by ALTER PRIMARY KEY or
+// TRUNCATE, in which case the history of its rows is not available.
+func (r *incrementalRefresh) checkPrimaryIndex(
+	ctx context.Context, execCfg *ExecutorConfig, asOf hlc.Timestamp,
+) error {
+	var indexID descpb.IndexID
+	if err := DescsTxn(ctx, execCfg, func(ctx context.Context, txn *kv.Txn, col *descs.Collection) error {
+		if err := txn.SetFixedTimestamp(ctx, asOf); err != nil {
+			return err
+		}
+		table, err := col.GetImmutableTableByID(ctx, txn, r.table.GetID(), tree.ObjectLookupFlags{
+			CommonLookupFlags: tree.CommonLookupFlags{
+				Required:       true,
+				AvoidLeased:    true,
+				IncludeOffline: true,
+			},
+		})
+		if err != nil {
+			return err
+		}
+		indexID = table.GetPrimaryIndexID()
+		return nil
+	}); err != nil {
+		return wrapIncrementalRefreshError(err)
+	}
+	if indexID != r.table.GetPrimaryIndexID() {
+		return errors.WithHint(
+			pgerror.Newf(pgcode.ObjectNotInPrerequisiteState,
+				"materialized view %q cannot be refreshed incrementally: "+
+					"the primary index of %q was replaced since the last refresh",
+				r.view.GetName(), r.table.GetName()),
+			incrementalRefreshHint,
+		)
+	}
+	return nil
+}
+
+// changedKeysExportTargetSize is the size of the SSTs that are exported at a
+// time when looking for the rows that changed since the last refresh.
+const changedKeysExportTargetSize = 16 << 20 // 16 MiB
+
+// changedKeys returns the primary keys of the rows of the table that were
+// written between the two timestamps, including deleted rows. The primary
+// index is exported one SST at a time, so only the latest version of each
+// changed key in a bounded chunk of the span is held in memory at once.
+func (r *incrementalRefresh) changedKeys(
+	ctx context.Context, execCfg *ExecutorConfig, from, to hlc.Timestamp,
+) ([]tree.Datums, error) {
+	idx := r.table.GetPrimaryIndex()
+	colTypes := make([]*types.T, idx.NumKeyColumns())
+	for i := range colTypes {
+		col, err := r.table.FindColumnWithID(idx.GetKeyColumnID(i))
+		if err != nil {
+			return nil, err
+		}
+		colTypes[i] = col.GetType()
+	}
+	vals := make([]rowenc.EncDatum, len(colTypes))
+	seen := make(map[string]struct{})
+	var alloc tree.DatumAlloc
+	var keys []tree.Datums
+	addKey := func(k roachpb.Key) error {
+		remaining, _, err := rowenc.DecodeIndexKey(
+			execCfg.Codec, colTypes, vals, idx.IndexDesc().KeyColumnDirections, k,
+		)
+		if err != nil {
+			return err
+		}
+		// The keys of the column families of a row only differ by their
+		// suffix.
+		rowKey := string(k[:len(k)-len(remaining)])
+		if _, ok := seen[rowKey]; ok {
+			return nil
+		}
+		seen[rowKey] = struct{}{}
+		key := make(tree.Datums, len(vals))
+		for i := range vals {
+			if err := vals[i].EnsureDecoded(colTypes[i], &alloc); err != nil {
+				return err
+			}
+			key[i] = vals[i].Datum
+		}
+		keys = append(keys, key)
+		return nil
+	}
+
+	span := r.table.PrimaryIndexSpan(execCfg.Codec)
+	for span.Valid() {
+		// The sentinel value of 1 for TargetBytes makes the ExportRequest
+		// return after a single SST, along with the span left to export.
+		header := roachpb.Header{Timestamp: to, TargetBytes: 1}
+		req := &roachpb.ExportRequest{
+			RequestHeader:  roachpb.RequestHeaderFromSpan(span),
+			StartTime:      from,
+			MVCCFilter:     roachpb.MVCCFilter_Latest,
+			TargetFileSize: changedKeysExportTargetSize,
+			ReturnSST:      true,
+		}
+		rawResp, pErr := kv.SendWrappedWith(ctx, execCfg.DB.NonTransactionalSender(), header, req)
+		if pErr != nil {
+			return nil, wrapIncrementalRefreshError(pErr.GoError())
+		}
+		resp := rawResp.(*roachpb.ExportResponse)
+		for _, file := range resp.Files {
+			if err := func() error {
+				it, err := storage.NewMemSSTIterator(file.SST, false /* verify */)
+				if err != nil {
+					return err
+				}
+				defer it.Close()
+				for it.SeekGE(storage.NilKey); ; it.Next() {
+					if ok, err := it.Valid(); err != nil {
+						return err
+					} else if !ok {
+						return nil
+					}
+					if err := addKey(it.UnsafeKey().Key); err != nil {
+						return err
+					}
+				}
+			}(); err != nil {
+				return nil, err
+			}
+		}
+		if resp.ResumeSpan == nil {
+			break
+		}
+		span = *resp.ResumeSpan
+	}
+	return keys, nil
+}
+
+// initWriters prepares the writers of the rows of the view.
+func (r *incrementalRefresh) initWriters(ctx context.Context, p *planner) (err error) {
+	execCfg := p.ExecCfg()
+	internal := p.SessionData().Internal
+	r.deleter = row.MakeDeleter(
+		execCfg.Codec, r.view, r.cols, &execCfg.Settings.SV, internal, execCfg.GetRowMetrics(internal),
+	)
+	r.inserter, err = row.MakeInserter(
+		ctx,
+		p.txn,
+		execCfg.Codec,
+		r.view.ImmutableCopy().(catalog.TableDescriptor),
+		r.cols,
+		p.alloc,
+		&execCfg.Settings.SV,
+		internal,
+		execCfg.GetRowMetrics(internal),
+	)
+	if err != nil {
+		return err
+	}
+	r.defaultExprs, err = schemaexpr.MakeDefaultExprs(
+		ctx, r.extraCols, &transform.ExprTransformContext{}, p.EvalContext(), p.SemaCtx(),
+	)
+	return err
+}
+
+// deleteRow deletes a row of the view, given the values of all its columns.
+func (r *incrementalRefresh) deleteRow(
+	ctx context.Context, p *planner, b *kv.Batch, values tree.Datums,
+) error {
+	var pm row.PartialIndexUpdateHelper
+	return r.deleter.DeleteRow(ctx, b, values, pm, p.ExtendedEvalContext().Tracing.KVTracingEnabled())
+}
+
+// insertRow inserts a row of the view, given the values of its output
+// columns. The other columns are set to the given values if extra is not nil,
+// and to their defaults otherwise.
+func (r *incrementalRefresh) insertRow(
+	ctx context.Context, p *planner, b *kv.Batch, out, extra tree.Datums,
+) error {
+	if len(out) != len(r.outCols) {
+		return errors.AssertionFailedf(
+			"view has %d columns but its query returned %d", len(r.outCols), len(out))
+	}
+	values := make(tree.Datums, 0, len(r.cols))
+	values = append(values, out...)
+	if extra != nil {
+		values = append(values, extra...)
+	} else {
+		for i := range r.extraCols {
+			d, err := eval.Expr(p.EvalContext(), r.defaultExprs[i])
+			if err != nil {
+				return err
+			}
+			values = append(values, d)
+		}
+	}
+	var pm row.PartialIndexUpdateHelper
+	return r.inserter.InsertRow(
+		ctx, b, values, pm, false /* overwrite */, p.ExtendedEvalContext().Tracing.KVTracingEnabled(),
+	)
+}
+
+// applyChanges writes the changes to a view that does not group rows: the
+// rows returned by the view query over the changed rows of the table as of
+// the last refresh are removed from the view, and the rows it returns in the
+// current transaction are added to it.
+func (r *incrementalRefresh) applyChanges(
+	ctx context.Context, p *planner, b *kv.Batch, keys []tree.Datums, from hlc.Timestamp,
+) error {
+	// counts maps the rows returned by the view query to the number of times
+	// they must be added to, or removed from if negative, the view.
+	counts := make(map[string]int)
+	rows := make(map[string]tree.Datums)
+	for start := 0; start < len(keys); start += incrementalRefreshBatchSize {
+		filter := r.keysFilter(keys[start:batchEnd(start, len(keys))])
+		for _, asOf := range []hlc.Timestamp{from, {}} {
+			res, err := r.query(ctx, p, filter, asOf, false /* partials */)
+			if err != nil {
+				return err
+			}
+			delta := 1
+			if !asOf.IsEmpty() {
+				delta = -1
+			}
+			for _, values := range res {
+				key := datumsKey(values)
+				counts[key] += delta
+				rows[key] = values
+			}
+		}
+	}
+
+	var removed []tree.Datums
+	for key, count := range counts {
+		if count < 0 {
+			removed = append(removed, rows[key])
+		}
+	}
+	outNames := make(tree.Exprs, len(r.outCols))
+	for i, col := range r.outCols {
+		outNames[i] = tree.NewUnresolvedName(col.GetName())
+	}
+	for start := 0; start < len(removed); start += incrementalRefreshBatchSize {
+		batch := removed[start:batchEnd(start, len(removed))]
+		viewRows, err := r.viewRows(ctx, p, matchFilter(outNames, batch))
+		if err != nil {
+			return err
+		}
+		for _, values := range viewRows {
+			key := datumsKey(values[:len(r.outCols)])
+			if counts[key] >= 0 {
+				continue
+			}
+			counts[key]++
+			if err := r.deleteRow(ctx, p, b, values); err != nil {
+				return err
+			}
+		}
+	}
+	for key, count := range counts {
+		for ; count > 0; count-- {
+			if err := r.insertRow(ctx, p, b, rows[key], nil /* extra */); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// groupChange accumulates the changes to a group of a grouped view.
+type groupChange struct {
+	// values is a row of the view query for the group; only its grouping
+	// columns are relevant.
+	values tree.Datums
+	// rows is the change to the number of rows of the group.
+	rows int64
+	// old and new hold, for each aggregate, its value over the changed rows of
+	// the group as of the last refresh and in the current transaction.
+	old, new tree.Datums
+	// args holds, for each sum, the change to the number of its non-NULL
+	// arguments.
+	args []int64
+}
+
+// applyGroupedChanges writes the changes to a grouped view. The view query,
+// extended to also count the rows and the non-NULL sum arguments of each
+// group, is run over the changed rows of the table as of the last refresh and
+// in the current transaction, and the difference is applied to the affected
+// groups of the view. The groups whose new aggregates cannot be derived this
+// way, for instance because a sum may have lost all its non-NULL arguments,
+// are recomputed by running the view query over all their rows.
+func (r *incrementalRefresh) applyGroupedChanges(
+	ctx context.Context, p *planner, b *kv.Batch, keys []tree.Datums, from hlc.Timestamp,
+) error {
+	evalCtx := p.EvalContext()
+	numExprs := len(r.sel.Exprs)
+	changes := make(map[string]*groupChange)
+	var order []string
+	for start := 0; start < len(keys); start += incrementalRefreshBatchSize {
+		filter := r.keysFilter(keys[start:batchEnd(start, len(keys))])
+		for _, asOf := range []hlc.Timestamp{from, {}} {
+			res, err := r.query(ctx, p, filter, asOf, true /* partials */)
+			if err != nil {
+				return err
+			}
+			sign := int64(1)
+			if !asOf.IsEmpty() {
+				sign = -1
+			}
+			for _, values := range res {
+				key := r.groupKey(values)
+				c, ok := changes[key]
+				if !ok {
+					c = &groupChange{
+						old:  make(tree.Datums, len(r.aggs)),
+						new:  make(tree.Datums, len(r.aggs)),
+						args: make([]int64, len(r.aggs)),
+					}
+					for j := range r.aggs {
+						c.old[j], c.new[j] = tree.DNull, tree.DNull
+					}
+					changes[key] = c
+					order = append(order, key)
+				}
+				c.values = values[:numExprs]
+				c.rows += sign * int64(tree.MustBeDInt(values[numExprs]))
+				argsCol := numExprs + 1
+				for j, agg := range r.aggs {
+					if agg.sum {
+						c.args[j] += sign * int64(tree.MustBeDInt(values[argsCol]))
+						argsCol++
+					}
+					acc := &c.new[j]
+					if sign < 0 {
+						acc = &c.old[j]
+					}
+					if *acc, err = addDatums(evalCtx, *acc, values[agg.col], false /* negate */); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	// Read the current rows of the affected groups.
+	current := make(map[string]tree.Datums)
+	groupNames := make(tree.Exprs, len(r.groupCols))
+	groupExprs := make(tree.Exprs, len(r.groupCols))
+	for i, col := range r.groupCols {
+		groupNames[i] = tree.NewUnresolvedName(r.outCols[col].GetName())
+		groupExprs[i] = r.sel.Exprs[col].Expr
+	}
+	for start := 0; start < len(order); start += incrementalRefreshBatchSize {
+		var filter tree.Expr
+		if len(r.groupCols) > 0 {
+			batch := order[start:batchEnd(start, len(order))]
+			groups := make([]tree.Datums, len(batch))
+			for i, key := range batch {
+				groups[i] = r.groupValues(changes[key].values)
+			}
+			filter = matchFilter(groupNames, groups)
+		}
+		viewRows, err := r.viewRows(ctx, p, filter)
+		if err != nil {
+			return err
+		}
+		for _, values := range viewRows {
+			current[r.groupKey(values)] = values
+		}
+	}
+
+	var recompute []string
+	for _, key := range order {
+		cur := current[key]
+		values, ok, err := r.applyGroupChange(evalCtx, cur, changes[key])
+		if err != nil {
+			return err
+		}
+		if !ok {
+			recompute = append(recompute, key)
+			continue
+		}
+		if err := r.replaceGroupRow(ctx, p, b, cur, values); err != nil {
+			return err
+		}
+	}
+
+	for start := 0; start < len(recompute); start += incrementalRefreshBatchSize {
+		batch := recompute[start:batchEnd(start, len(recompute))]
+		var filter tree.Expr
+		if len(r.groupCols) > 0 {
+			groups := make([]tree.Datums, len(batch))
+			for i, key := range batch {
+				groups[i] = r.groupValues(changes[key].values)
+			}
+			filter = matchFilter(groupExprs, groups)
+		}
+		res, err := r.query(ctx, p, filter, hlc.Timestamp{}, false /* partials */)
+		if err != nil {
+			return err
+		}
+		recomputed := make(map[string]tree.Datums, len(res))
+		for _, values := range res {
+			recomputed[r.groupKey(values)] = values
+		}
+		for _, key := range batch {
+			if err := r.replaceGroupRow(ctx, p, b, current[key], recomputed[key]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// applyGroupChange returns the values of the output columns of the row of a
+// group once the given change is applied to its current row, which is nil if
+// the group had no rows. The returned values are nil if the group no longer
+// has rows. It returns false if the new row cannot be derived from the
+// current one, in which case the group must be recomputed.
+func (r *incrementalRefresh) applyGroupChange(
+	evalCtx *eval.Context, cur tree.Datums, c *groupChange,
+) (tree.Datums, bool, error) {
+	scalar := len(r.groupCols) == 0
+	if cur == nil {
+		// The group had no rows as of the last refresh, so the changed rows are
+		// all its rows.
+		if c.rows <= 0 {
+			return nil, true, nil
+		}
+		values := append(tree.Datums(nil), c.values...)
+		for j, agg := range r.aggs {
+			values[agg.col] = c.new[j]
+		}
+		return values, true, nil
+	}
+	if !scalar {
+		switch {
+		case r.countRowsCol >= 0:
+			if int64(tree.MustBeDInt(cur[r.countRowsCol]))+c.rows == 0 {
+				return nil, true, nil
+			}
+		case c.rows < 0:
+			// Without a count of its rows, we cannot tell whether the group lost
+			// all of them.
+			return nil, false, nil
+		}
+	}
+	values := append(tree.Datums(nil), cur[:len(r.outCols)]...)
+	for j, agg := range r.aggs {
+		d := cur[agg.col]
+		switch {
+		case !agg.sum:
+			count := int64(tree.MustBeDInt(d)) + datumToCount(c.new[j]) - datumToCount(c.old[j])
+			values[agg.col] = tree.NewDInt(tree.DInt(count))
+		case d == tree.DNull:
+			// None of the rows of the group had a non-NULL argument as of the
+			// last refresh, so the changed rows hold all of them.
+			values[agg.col] = c.new[j]
+		case c.args[j] >= 0:
+			// The sum still has non-NULL arguments.
+			sum, err := addDatums(evalCtx, d, c.new[j], false /* negate */)
+			if err != nil {
+				return nil, false, err
+			}
+			if values[agg.col], err = addDatums(evalCtx, sum, c.old[j], true /* negate */); err != nil {
+				return nil, false, err
+			}
+		default:
+			// The sum may have lost all its non-NULL arguments.
+			return nil, false, nil
+		}
+	}
+	return values, true, nil
+}
+
+// replaceGroupRow replaces the current row of a group of the view, if any,
+// with a row holding the given values, if any.
+func (r *incrementalRefresh) replaceGroupRow(
+	ctx context.Context, p *planner, b *kv.Batch, cur, values tree.Datums,
+) error {
+	var extra tree.Datums
+	if cur != nil {
+		if err := r.deleteRow(ctx, p, b, cur); err != nil {
+			return err
+		}
+		extra = cur[len(r.outCols):]
+	}
+	if values == nil {
+		return nil
+	}
+	return r.insertRow(ctx, p, b, values, extra)
+}
+
+// groupValues returns the values of the grouping columns of a row of the
+// view or of the view query.
+func (r *incrementalRefresh) groupValues(values tree.Datums) tree.Datums {
+	group := make(tree.Datums, len(r.groupCols))
+	for i, col := range r.groupCols {
+		group[i] = values[col]
+	}
+	return group
+}
+
+// groupKey returns a key identifying the group of a row of the view or of the
+// view query.
+func (r *incrementalRefresh) groupKey(values tree.Datums) string {
+	return datumsKey(r.groupValues(values))
+}
+
+// query runs the view query restricted by the given filter, if any, as of the
+// given timestamp if it is set and in the current transaction otherwise. If
+// partials is set, the query also returns the number of rows of each group,
+// followed by the number of non-NULL arguments of each sum.
+func (r *incrementalRefresh) query(
+	ctx context.Context, p *planner, filter tree.Expr, asOf hlc.Timestamp, partials bool,
+) ([]tree.Datums, error) {
+	sel := *r.sel
+	if filter != nil {
+		if sel.Where != nil {
+			filter = &tree.AndExpr{
+				Left:  &tree.ParenExpr{Expr: sel.Where.Expr},
+				Right: &tree.ParenExpr{Expr: filter},
+			}
+		}
+		sel.Where = tree.NewWhere(tree.AstWhere, filter)
+	}
+	if partials {
+		sel.Exprs = append(sel.Exprs[:len(sel.Exprs):len(sel.Exprs)], tree.SelectExpr{
+			Expr: &tree.FuncExpr{Func: tree.WrapFunction("count_rows")},
+		})
+		for _, agg := range r.aggs {
+			if agg.sum {
+				sel.Exprs = append(sel.Exprs, tree.SelectExpr{
+					Expr: &tree.FuncExpr{Func: tree.WrapFunction("count"), Exprs: tree.Exprs{agg.arg}},
+				})
+			}
+		}
+	}
+	txn := p.txn
+	if !asOf.IsEmpty() {
+		sel.From.AsOf = tree.AsOfClause{Expr: tree.NewStrVal(asOf.AsOfSystemTime())}
+		txn = nil
+	}
+	rows, err := p.ExecCfg().InternalExecutor.QueryBufferedEx(
+		ctx,
+		"refresh-materialized-view",
+		txn,
+		sessiondata.InternalExecutorOverride{User: username.RootUserName()},
+		tree.AsStringWithFlags(&tree.Select{Select: &sel}, tree.FmtParsable),
+	)
+	if err != nil {
+		return nil, wrapIncrementalRefreshError(err)
+	}
+	return rows, nil
+}
+
+// viewRows returns the values of all the columns of the rows of the view that
+// match the given filter, if any.
+func (r *incrementalRefresh) viewRows(
+	ctx context.Context, p *planner, filter tree.Expr,
+) ([]tree.Datums, error) {
+	f := tree.NewFmtCtx(tree.FmtParsable)
+	f.WriteString("SELECT ")
+	for i, col := range r.cols {
+		if i > 0 {
+			f.WriteString(", ")
+		}
+		name := tree.Name(col.GetName())
+		f.FormatNode(&name)
+	}
+	f.Printf(" FROM [%d AS v]", r.view.GetID())
+	if filter != nil {
+		f.WriteString(" WHERE ")
+		f.FormatNode(filter)
+	}
+	return p.ExecCfg().InternalExecutor.QueryBufferedEx(
+		ctx,
+		"refresh-materialized-view",
+		p.txn,
+		sessiondata.InternalExecutorOverride{User: username.RootUserName()},
+		f.CloseAndGetString(),
+	)
+}
+
+// keysFilter returns a filter that matches the rows of the table with the
+// given primary keys.
+func (r *incrementalRefresh) keysFilter(keys []tree.Datums) tree.Expr {
+	idx := r.table.GetPrimaryIndex()
+	cols := make(tree.Exprs, idx.NumKeyColumns())
+	for i := range cols {
+		cols[i] = tree.NewUnresolvedName(idx.GetKeyColumnName(i))
+	}
+	tuples := make(tree.Exprs, len(keys))
+	for i, key := range keys {
+		tuples[i] = &tree.Tuple{Exprs: datumsToExprs(key)}
+	}
+	return &tree.ComparisonExpr{
+		Operator: treecmp.MakeComparisonOperator(treecmp.In),
+		Left:     &tree.Tuple{Exprs: cols},
+		Right:    &tree.Tuple{Exprs: tuples},
+	}
+}
+
+// matchFilter returns a filter that matches the rows for which the given
+// expressions evaluate to one of the given tuples of values, NULLs included.
+func matchFilter(exprs tree.Exprs, tuples []tree.Datums) tree.Expr {
+	var filter tree.Expr
+	for _, tuple := range tuples {
+		var match tree.Expr = tree.DBoolTrue
+		for i := range exprs {
+			cmp := &tree.ComparisonExpr{
+				Operator: treecmp.MakeComparisonOperator(treecmp.IsNotDistinctFrom),
+				Left:     &tree.ParenExpr{Expr: exprs[i]},
+				Right:    tuple[i],
+			}
+			if i == 0 {
+				match = cmp
+			} else {
+				match = &tree.AndExpr{Left: match, Right: cmp}
+			}
+		}
+		if filter == nil {
+			filter = &tree.ParenExpr{Expr: match}
+		} else {
+			filter = &tree.OrExpr{Left: filter, Right: &tree.ParenExpr{Expr: match}}
+		}
+	}
+	return filter
+}
+
+func datumsToExprs(datums tree.Datums) tree.Exprs {
+	exprs := make(tree.Exprs, len(datums))
+	for i, d := range datums {
+		exprs[i] = d
+	}
+	return exprs
+}
+
+// datumsKey returns a string that identifies the given values.
+func datumsKey(datums tree.Datums) string {
+	f := tree.NewFmtCtx(tree.FmtParsable)
+	f.FormatNode(&datums)
+	return f.CloseAndGetString()
+}
+
+// addDatums returns a+b, or a-b if negate is set, where NULL stands for an
+// empty sum.
+func addDatums(evalCtx *eval.Context, a, b tree.Datum, negate bool) (tree.Datum, error) {
+	if b == tree.DNull {
+		return a, nil
+	}
+	op := treebin.Plus
+	if negate {
+		op = treebin.Minus
+	}
+	if a == tree.DNull {
+		if !negate {
+			return b, nil
+		}
+		return nil, errors.AssertionFailedf("cannot subtract %s from an empty sum", b)
+	}
+	return eval.Expr(evalCtx, tree.NewTypedBinaryExpr(treebin.MakeBinaryOperator(op), a, b, a.ResolvedType()))
+}
+
+// datumToCount returns the value of a count, where NULL stands for zero.
+func datumToCount(d tree.Datum) int64 {
+	if d == tree.DNull {
+		return 0
+	}
+	return int64(tree.MustBeDInt(d))
+}
+
+// wrapIncrementalRefreshError turns the errors returned when the history of
+// the table since the last refresh was garbage collected into a user-facing
+// error.
+func wrapIncrementalRefreshError(err error) error {
+	if errors.HasType(err, (*roachpb.BatchTimestampBeforeGCError)(nil)) {
+		return errors.WithHint(
+			pgerror.Wrap(err, pgcode.ObjectNotInPrerequisiteState,
+				"the changes made since the last refresh of the view are no longer available"),
+			incrementalRefreshHint,
+		)
+	}
+	return err
+}
+
+// batchEnd returns the end of the batch that starts at the given index of a
+// slice of the given length.
+func batchEnd(start, length int) int {
+	if end := start + incrementalRefreshBatchSize; end < length {
+		return end
+	}
+	return length
+}
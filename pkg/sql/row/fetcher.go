@@ -415,6 +415,7 @@ func (rf *Fetcher) StartScan(
 			firstBatchKeyLimit:         rf.rowLimitToKeyLimit(rowLimitHint),
 			lockStrength:               rf.lockStrength,
 			lockWaitPolicy:             rf.lockWaitPolicy,
+			isoLevel:                   txn.IsoLevel(),
 			lockTimeout:                rf.lockTimeout,
 			acc:                        rf.kvFetcherMemAcc,
 			forceProductionKVBatchSize: forceProductionKVBatchSize,
@@ -519,6 +520,7 @@ func (rf *Fetcher) StartInconsistentScan(
 			firstBatchKeyLimit:         rf.rowLimitToKeyLimit(rowLimitHint),
 			lockStrength:               rf.lockStrength,
 			lockWaitPolicy:             rf.lockWaitPolicy,
+			isoLevel:                   txn.IsoLevel(),
 			lockTimeout:                rf.lockTimeout,
 			acc:                        rf.kvFetcherMemAcc,
 			forceProductionKVBatchSize: forceProductionKVBatchSize,
@@ -15,6 +15,7 @@ import (
 
 	"github.com/cockroachdb/cockroach/pkg/clusterversion"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvclient/kvstreamer"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/concurrency/lock"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/settings"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
@@ -73,7 +74,9 @@ func NewTxnKVStreamer(
 		log.VEventf(ctx, 2, "Scan %s", spans)
 	}
 	keyLocking := getKeyLockingStrength(lockStrength)
-	reqs := spansToRequests(spans, false /* reverse */, keyLocking)
+	// The Streamer only supports read-only requests, so it never acquires
+	// replicated locks (which require the requests to be evaluated as writes).
+	reqs := spansToRequests(spans, false /* reverse */, keyLocking, lock.Unreplicated)
 	if err := streamer.Enqueue(ctx, reqs, spanIDs); err != nil {
 		return nil, err
 	}
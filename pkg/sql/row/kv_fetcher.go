@@ -110,6 +110,7 @@ func NewKVFetcher(
 			firstBatchKeyLimit:         firstBatchLimit,
 			lockStrength:               lockStrength,
 			lockWaitPolicy:             lockWaitPolicy,
+			isoLevel:                   txn.IsoLevel(),
 			lockTimeout:                lockTimeout,
 			acc:                        acc,
 			forceProductionKVBatchSize: forceProductionKVBatchSize,
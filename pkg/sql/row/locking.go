@@ -11,11 +11,33 @@
 package row
 
 import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/clusterversion"
+	"github.com/cockroachdb/cockroach/pkg/kv"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/concurrency/lock"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
 	"github.com/cockroachdb/errors"
 )
 
+// GetScanLockingStrength returns the locking strength that a processor uses for
+// the scans of a spec with the given locking strength. Until the SharedLocks
+// cluster version is active, nodes may not support Shared locks, so FOR SHARE
+// and FOR KEY SHARE are promoted to FOR UPDATE, which acquires unreplicated
+// Exclusive locks on every node.
+func GetScanLockingStrength(
+	ctx context.Context, st *cluster.Settings, lockStrength descpb.ScanLockingStrength,
+) descpb.ScanLockingStrength {
+	switch lockStrength {
+	case descpb.ScanLockingStrength_FOR_KEY_SHARE, descpb.ScanLockingStrength_FOR_SHARE:
+		if !st.Version.IsActive(ctx, clusterversion.SharedLocks) {
+			return descpb.ScanLockingStrength_FOR_UPDATE
+		}
+	}
+	return lockStrength
+}
+
 // getKeyLockingStrength returns the configured per-key locking strength to use
 // for key-value scans.
 func getKeyLockingStrength(lockStrength descpb.ScanLockingStrength) lock.Strength {
@@ -27,9 +49,7 @@ func getKeyLockingStrength(lockStrength descpb.ScanLockingStrength) lock.Strengt
 		// Promote to FOR_SHARE.
 		fallthrough
 	case descpb.ScanLockingStrength_FOR_SHARE:
-		// We currently perform no per-key locking when FOR_SHARE is used
-		// because Shared locks have not yet been implemented.
-		return lock.None
+		return lock.Shared
 
 	case descpb.ScanLockingStrength_FOR_NO_KEY_UPDATE:
 		// Promote to FOR_UPDATE.
@@ -44,6 +64,24 @@ func getKeyLockingStrength(lockStrength descpb.ScanLockingStrength) lock.Strengt
 	}
 }
 
+// getKeyLockingDurability returns the configured per-key locking durability to
+// use for key-value scans performed by a transaction with the given isolation
+// level.
+//
+// Shared locks acquired by transactions that run under weak isolation levels
+// are replicated, because these transactions rely on the locks to prevent
+// concurrent writes for correctness and unreplicated locks may be lost (for
+// instance, on lease transfers). All other locks are best-effort and remain
+// unreplicated.
+func getKeyLockingDurability(
+	lockStrength lock.Strength, isoLevel kv.IsolationLevel,
+) lock.Durability {
+	if lockStrength == lock.Shared && isoLevel == kv.ReadCommittedIsolation {
+		return lock.Replicated
+	}
+	return lock.Unreplicated
+}
+
 // GetWaitPolicy returns the configured lock wait policy to use for key-value
 // scans.
 func GetWaitPolicy(lockWaitPolicy descpb.ScanLockingWaitPolicy) lock.WaitPolicy {
@@ -291,7 +291,7 @@ func newInvertedJoiner(
 	if err := fetcher.Init(
 		flowCtx.EvalCtx.Context,
 		row.FetcherInitArgs{
-			LockStrength:   spec.LockingStrength,
+			LockStrength:   row.GetScanLockingStrength(flowCtx.EvalCtx.Context, flowCtx.EvalCtx.Settings, spec.LockingStrength),
 			LockWaitPolicy: spec.LockingWaitPolicy,
 			LockTimeout:    flowCtx.EvalCtx.SessionData().LockTimeout,
 			Alloc:          &ij.alloc,
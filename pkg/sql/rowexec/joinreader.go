@@ -319,7 +319,7 @@ func newJoinReader(
 		outputGroupContinuationForLeftRow: spec.OutputGroupContinuationForLeftRow,
 		shouldLimitBatches:                shouldLimitBatches,
 		readerType:                        readerType,
-		keyLocking:                        spec.LockingStrength,
+		keyLocking:                        row.GetScanLockingStrength(flowCtx.EvalCtx.Context, flowCtx.EvalCtx.Settings, spec.LockingStrength),
 		lockWaitPolicy:                    row.GetWaitPolicy(spec.LockingWaitPolicy),
 		usesStreamer:                      useStreamer,
 		lookupBatchBytesLimit:             rowinfra.BytesLimit(spec.LookupBatchBytesLimit),
@@ -387,7 +387,7 @@ func newJoinReader(
 	if err := fetcher.Init(
 		flowCtx.EvalCtx.Context,
 		row.FetcherInitArgs{
-			LockStrength:   spec.LockingStrength,
+			LockStrength:   jr.keyLocking,
 			LockWaitPolicy: spec.LockingWaitPolicy,
 			LockTimeout:    flowCtx.EvalCtx.SessionData().LockTimeout,
 			Alloc:          &jr.alloc,
@@ -286,6 +286,12 @@ func NewProcessor(
 		}
 		return NewCSVWriterProcessor(flowCtx, processorID, *core.Exporter, inputs[0], outputs[0])
 	}
+	if core.IcebergCommitter != nil {
+		if err := checkNumInOut(inputs, outputs, 1, 1); err != nil {
+			return nil, err
+		}
+		return NewIcebergCommitterProcessor(flowCtx, processorID, *core.IcebergCommitter, inputs[0], outputs[0])
+	}
 
 	if core.BulkRowWriter != nil {
 		if err := checkNumInOut(inputs, outputs, 1, 1); err != nil {
@@ -397,6 +403,9 @@ var NewCSVWriterProcessor func(*execinfra.FlowCtx, int32, execinfrapb.ExportSpec
 // NewParquetWriterProcessor is implemented in the non-free (CCL) codebase and then injected here via runtime initialization.
 var NewParquetWriterProcessor func(*execinfra.FlowCtx, int32, execinfrapb.ExportSpec, execinfra.RowSource, execinfra.RowReceiver) (execinfra.Processor, error)
 
+// NewIcebergCommitterProcessor is implemented in the non-free (CCL) codebase and then injected here via runtime initialization.
+var NewIcebergCommitterProcessor func(*execinfra.FlowCtx, int32, execinfrapb.IcebergCommitterSpec, execinfra.RowSource, execinfra.RowReceiver) (execinfra.Processor, error)
+
 // NewChangeAggregatorProcessor is implemented in the non-free (CCL) codebase and then injected here via runtime initialization.
 var NewChangeAggregatorProcessor func(*execinfra.FlowCtx, int32, execinfrapb.ChangeAggregatorSpec, *execinfrapb.PostProcessSpec, execinfra.RowReceiver) (execinfra.Processor, error)
 
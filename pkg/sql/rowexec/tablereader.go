@@ -149,7 +149,7 @@ func newTableReader(
 		flowCtx.EvalCtx.Context,
 		row.FetcherInitArgs{
 			Reverse:        spec.Reverse,
-			LockStrength:   spec.LockingStrength,
+			LockStrength:   row.GetScanLockingStrength(flowCtx.EvalCtx.Context, flowCtx.EvalCtx.Settings, spec.LockingStrength),
 			LockWaitPolicy: spec.LockingWaitPolicy,
 			LockTimeout:    flowCtx.EvalCtx.SessionData().LockTimeout,
 			Alloc:          &tr.alloc,
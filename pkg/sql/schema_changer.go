@@ -439,6 +439,11 @@ func (sc *SchemaChanger) maybeMakeAddTablePublic(
 		if !mut.Adding() {
 			return nil
 		}
+		if mut.MaterializedView() {
+			// The view was backfilled with the result of its query as of its
+			// creation time.
+			mut.RefreshAsOfTime = mut.GetCreateAsOfTime()
+		}
 		mut.State = descpb.DescriptorState_PUBLIC
 		return descsCol.WriteDesc(ctx, true /* kvTrace */, mut, txn)
 	})
@@ -1428,6 +1433,13 @@ func (sc *SchemaChanger) done(ctx context.Context) error {
 				// If we are mutation is in the ADD state, then start GC jobs for the
 				// existing indexes on the table.
 				if m.Adding() {
+					// Remember when the data of the view was computed, for the
+					// incremental refreshes to come. A refresh WITH NO DATA leaves
+					// the view empty, so that it cannot be refreshed incrementally.
+					scTable.RefreshAsOfTime = hlc.Timestamp{}
+					if refresh.ShouldBackfill() {
+						scTable.RefreshAsOfTime = refresh.AsOf()
+					}
 					desc := fmt.Sprintf("REFRESH MATERIALIZED VIEW %q cleanup", scTable.Name)
 					for _, idx := range scTable.ActiveIndexes() {
 						if err := sc.createIndexGCJob(ctx, idx.GetID(), txn, desc); err != nil {
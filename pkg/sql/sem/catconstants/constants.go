@@ -81,6 +81,7 @@ const (
 	SpanConfigurationsTableName            SystemTableName = "span_configurations"
 	TenantSettingsTableName                SystemTableName = "tenant_settings"
 	SpanCountTableName                     SystemTableName = "span_count"
+	LDAPRoleMembersTableName               SystemTableName = "ldap_role_members"
 )
 
 // Oid for virtual database and table.
@@ -156,6 +157,8 @@ const (
 	CrdbInternalTenantUsageDetailsViewID
 	CrdbInternalPgCatalogTableIsImplementedTableID
 	CrdbInternalSuperRegions
+	CrdbInternalKVStoreEncryptionViewID
+	CrdbInternalClusterLockWaitsTableID
 	InformationSchemaID
 	InformationSchemaAdministrableRoleAuthorizationsID
 	InformationSchemaApplicableRolesID
@@ -118,6 +118,31 @@ func (node Backup) Coverage() DescriptorCoverage {
 	return RequestedDescriptors
 }
 
+// CompactBackup represents a COMPACT BACKUP statement, which merges a full
+// backup and the incremental backups appended to it into a new full backup.
+type CompactBackup struct {
+	// Subdir is the path of the full backup within the collection.
+	Subdir Expr
+	// Collection is the collection containing the backup chain, into which
+	// the compacted backup is written.
+	Collection Expr
+	Options    BackupOptions
+}
+
+var _ Statement = &CompactBackup{}
+
+// Format implements the NodeFormatter interface.
+func (node *CompactBackup) Format(ctx *FmtCtx) {
+	ctx.WriteString("COMPACT BACKUP ")
+	ctx.FormatNode(node.Subdir)
+	ctx.WriteString(" IN ")
+	ctx.FormatNode(node.Collection)
+	if !node.Options.IsDefault() {
+		ctx.WriteString(" WITH ")
+		ctx.FormatNode(&node.Options)
+	}
+}
+
 // RestoreOptions describes options for the RESTORE execution.
 type RestoreOptions struct {
 	EncryptionPassphrase      Expr
@@ -15,21 +15,36 @@ type CreateChangefeed struct {
 	Targets ChangefeedTargets
 	SinkURI Expr
 	Options KVOptions
+	// Select is set for changefeeds created with CREATE CHANGEFEED ... AS
+	// SELECT, also known as CDC queries. Its FROM clause holds the single
+	// target table, and Targets is empty.
+	Select *SelectClause
 }
 
 var _ Statement = &CreateChangefeed{}
 
 // Format implements the NodeFormatter interface.
 func (node *CreateChangefeed) Format(ctx *FmtCtx) {
-	if node.SinkURI != nil {
+	if node.SinkURI != nil || node.Select != nil {
 		ctx.WriteString("CREATE ")
 	} else {
 		// Sinkless feeds don't really CREATE anything, so the syntax omits the
 		// prefix. They're also still EXPERIMENTAL, so they get marked as such.
 		ctx.WriteString("EXPERIMENTAL ")
 	}
+	if node.Select != nil {
+		ctx.WriteString("CHANGEFEED")
+		node.formatSinkAndOptions(ctx)
+		ctx.WriteString(" AS ")
+		ctx.FormatNode(node.Select)
+		return
+	}
 	ctx.WriteString("CHANGEFEED FOR ")
 	ctx.FormatNode(&node.Targets)
+	node.formatSinkAndOptions(ctx)
+}
+
+func (node *CreateChangefeed) formatSinkAndOptions(ctx *FmtCtx) {
 	if node.SinkURI != nil {
 		ctx.WriteString(" INTO ")
 		ctx.FormatNode(node.SinkURI)
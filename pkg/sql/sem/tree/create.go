@@ -1019,9 +1019,10 @@ func (*CheckConstraintTableDef) constraintTableDef()      {}
 // TABLE statement.
 type UniqueConstraintTableDef struct {
 	IndexTableDef
-	PrimaryKey   bool
-	WithoutIndex bool
-	IfNotExists  bool
+	PrimaryKey    bool
+	WithoutIndex  bool
+	IfNotExists   bool
+	Deferrability ConstraintDeferrability
 }
 
 // SetName implements the TableDef interface.
@@ -1066,6 +1067,7 @@ func (node *UniqueConstraintTableDef) Format(ctx *FmtCtx) {
 	if node.PartitionByIndex != nil {
 		ctx.FormatNode(node.PartitionByIndex)
 	}
+	ctx.FormatNode(&node.Deferrability)
 	if node.Predicate != nil {
 		ctx.WriteString(" WHERE ")
 		ctx.FormatNode(node.Predicate)
@@ -1139,15 +1141,48 @@ func (c CompositeKeyMatchMethod) String() string {
 	return compositeKeyMatchMethodName[c]
 }
 
+// ConstraintDeferrability specifies whether the checking of a constraint can
+// be postponed until the end of the transaction.
+type ConstraintDeferrability int
+
+// The values for ConstraintDeferrability.
+const (
+	// ConstraintNotDeferrable is the default, and is also what NOT DEFERRABLE
+	// and INITIALLY IMMEDIATE specify.
+	ConstraintNotDeferrable ConstraintDeferrability = iota
+	ConstraintDeferrableInitiallyImmediate
+	ConstraintDeferrableInitiallyDeferred
+)
+
+var constraintDeferrabilityName = [...]string{
+	ConstraintNotDeferrable:                "NOT DEFERRABLE",
+	ConstraintDeferrableInitiallyImmediate: "DEFERRABLE",
+	ConstraintDeferrableInitiallyDeferred:  "DEFERRABLE INITIALLY DEFERRED",
+}
+
+func (d ConstraintDeferrability) String() string {
+	return constraintDeferrabilityName[d]
+}
+
+// Format implements the NodeFormatter interface. Nothing is printed for
+// non-deferrable constraints.
+func (d *ConstraintDeferrability) Format(ctx *FmtCtx) {
+	if *d != ConstraintNotDeferrable {
+		ctx.WriteByte(' ')
+		ctx.WriteString(d.String())
+	}
+}
+
 // ForeignKeyConstraintTableDef represents a FOREIGN KEY constraint in the AST.
 type ForeignKeyConstraintTableDef struct {
-	Name        Name
-	Table       TableName
-	FromCols    NameList
-	ToCols      NameList
-	Actions     ReferenceActions
-	Match       CompositeKeyMatchMethod
-	IfNotExists bool
+	Name          Name
+	Table         TableName
+	FromCols      NameList
+	ToCols        NameList
+	Actions       ReferenceActions
+	Match         CompositeKeyMatchMethod
+	IfNotExists   bool
+	Deferrability ConstraintDeferrability
 }
 
 // Format implements the NodeFormatter interface.
@@ -1178,6 +1213,7 @@ func (node *ForeignKeyConstraintTableDef) Format(ctx *FmtCtx) {
 	}
 
 	ctx.FormatNode(&node.Actions)
+	ctx.FormatNode(&node.Deferrability)
 }
 
 // SetName implements the ConstraintTableDef interface.
@@ -2004,6 +2040,9 @@ const (
 	// RefreshDataClear refers to the WITH NO DATA option provided to the REFRESH
 	// MATERIALIZED VIEW statement.
 	RefreshDataClear
+	// RefreshDataIncremental refers to the WITH INCREMENTAL DATA option provided
+	// to the REFRESH MATERIALIZED VIEW statement.
+	RefreshDataIncremental
 )
 
 // Format implements the NodeFormatter interface.
@@ -2018,6 +2057,8 @@ func (node *RefreshMaterializedView) Format(ctx *FmtCtx) {
 		ctx.WriteString(" WITH DATA")
 	case RefreshDataClear:
 		ctx.WriteString(" WITH NO DATA")
+	case RefreshDataIncremental:
+		ctx.WriteString(" WITH INCREMENTAL DATA")
 	}
 }
 
@@ -75,6 +75,15 @@ func (node *CancelSessions) Format(ctx *FmtCtx) {
 	ctx.FormatNode(node.Sessions)
 }
 
+// CancelBlockingTransactions represents a CANCEL BLOCKING TRANSACTIONS
+// statement.
+type CancelBlockingTransactions struct{}
+
+// Format implements the NodeFormatter interface.
+func (node *CancelBlockingTransactions) Format(ctx *FmtCtx) {
+	ctx.WriteString("CANCEL BLOCKING TRANSACTIONS")
+}
+
 // ScheduleCommand determines which type of action to effect on the selected job(s).
 type ScheduleCommand int
 
@@ -692,11 +692,213 @@ func (node *GroupBy) Format(ctx *FmtCtx) {
 	prefix := "GROUP BY "
 	for _, n := range *node {
 		ctx.WriteString(prefix)
-		ctx.FormatNode(n)
+		formatGroupingElem(ctx, n)
 		prefix = ", "
 	}
 }
 
+// formatGroupingElem formats an element of a GROUP BY clause. Grouping sets
+// are never wrapped in parentheses, since the result would not parse.
+func formatGroupingElem(ctx *FmtCtx, e Expr) {
+	if gs, ok := e.(*GroupingSet); ok {
+		gs.Format(ctx)
+		return
+	}
+	ctx.FormatNode(e)
+}
+
+// maxCubeElems and maxGroupingSets limit the number of grouping sets that a
+// GROUP BY clause can expand to. They match the limits used by Postgres.
+const (
+	maxCubeElems    = 12
+	maxGroupingSets = 4096
+)
+
+// HasGroupingSets returns true if the GROUP BY clause uses ROLLUP, CUBE or
+// GROUPING SETS.
+func (node GroupBy) HasGroupingSets() bool {
+	for _, e := range node {
+		if _, ok := e.(*GroupingSet); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// GroupingSets expands the GROUP BY clause into the list of grouping sets it
+// denotes. For example:
+//
+//	GROUP BY a, ROLLUP (b, c)
+//
+// expands to the grouping sets (a, b, c), (a, b) and (a). A GROUP BY clause
+// without ROLLUP, CUBE or GROUPING SETS denotes a single grouping set. Tuples
+// inside ROLLUP, CUBE and GROUPING SETS denote several grouping columns at
+// once, and are flattened into their elements.
+func (node GroupBy) GroupingSets() ([]Exprs, error) {
+	sets := []Exprs{nil}
+	for _, e := range node {
+		elemSets, err := expandGroupingElem(e)
+		if err != nil {
+			return nil, err
+		}
+		// The grouping sets of a list of elements are the cross product of the
+		// grouping sets of each element.
+		if len(sets)*len(elemSets) > maxGroupingSets {
+			return nil, errTooManyGroupingSets
+		}
+		product := make([]Exprs, 0, len(sets)*len(elemSets))
+		for _, set := range sets {
+			for _, elemSet := range elemSets {
+				combined := make(Exprs, 0, len(set)+len(elemSet))
+				combined = append(combined, set...)
+				combined = append(combined, elemSet...)
+				product = append(product, combined)
+			}
+		}
+		sets = product
+	}
+	return sets, nil
+}
+
+var errTooManyGroupingSets = pgerror.Newf(
+	pgcode.ProgramLimitExceeded, "too many grouping sets present (maximum %d)", maxGroupingSets,
+)
+
+// expandGroupingElem returns the grouping sets denoted by a single element of
+// a GROUP BY clause.
+func expandGroupingElem(e Expr) ([]Exprs, error) {
+	gs, ok := e.(*GroupingSet)
+	if !ok {
+		return []Exprs{{e}}, nil
+	}
+	switch gs.Kind {
+	case GroupingRollup:
+		// ROLLUP (a, b, c) is (a, b, c), (a, b), (a), ().
+		sets := make([]Exprs, len(gs.Exprs)+1)
+		for i := range sets {
+			var set Exprs
+			for _, elem := range gs.Exprs[:len(gs.Exprs)-i] {
+				set = append(set, groupingSetCols(elem)...)
+			}
+			sets[i] = set
+		}
+		return sets, nil
+
+	case GroupingCube:
+		// CUBE (a, b) is (a, b), (a), (b), ().
+		n := len(gs.Exprs)
+		if n > maxCubeElems {
+			return nil, pgerror.Newf(pgcode.ProgramLimitExceeded,
+				"CUBE is limited to %d elements", maxCubeElems)
+		}
+		sets := make([]Exprs, 0, 1<<n)
+		for mask := (1 << n) - 1; mask >= 0; mask-- {
+			var set Exprs
+			for i := range gs.Exprs {
+				if mask&(1<<(n-1-i)) != 0 {
+					set = append(set, groupingSetCols(gs.Exprs[i])...)
+				}
+			}
+			sets = append(sets, set)
+		}
+		return sets, nil
+
+	case GroupingSets:
+		// GROUPING SETS is the concatenation of the grouping sets of each of its
+		// elements.
+		var sets []Exprs
+		for _, elem := range gs.Exprs {
+			elemSets := []Exprs{groupingSetCols(elem)}
+			if _, ok := elem.(*GroupingSet); ok {
+				var err error
+				if elemSets, err = expandGroupingElem(elem); err != nil {
+					return nil, err
+				}
+			}
+			if len(sets)+len(elemSets) > maxGroupingSets {
+				return nil, errTooManyGroupingSets
+			}
+			sets = append(sets, elemSets...)
+		}
+		return sets, nil
+
+	default:
+		return nil, errors.AssertionFailedf("unknown grouping set kind %d", gs.Kind)
+	}
+}
+
+// groupingSetCols returns the grouping columns denoted by an element of a
+// ROLLUP, CUBE or GROUPING SETS construct: a tuple denotes its elements, and
+// any other expression denotes itself.
+func groupingSetCols(e Expr) Exprs {
+	if t, ok := e.(*Tuple); ok {
+		return t.Exprs
+	}
+	return Exprs{e}
+}
+
+// GroupingSetKind is the type of a grouping set construct in a GROUP BY
+// clause.
+type GroupingSetKind int
+
+// GroupingSetKind values.
+const (
+	GroupingRollup GroupingSetKind = iota
+	GroupingCube
+	GroupingSets
+)
+
+var groupingSetKindName = [...]string{
+	GroupingRollup: "ROLLUP",
+	GroupingCube:   "CUBE",
+	GroupingSets:   "GROUPING SETS",
+}
+
+func (k GroupingSetKind) String() string {
+	return groupingSetKindName[k]
+}
+
+// GroupingSet represents a ROLLUP, CUBE or GROUPING SETS element of a GROUP BY
+// clause. The elements of ROLLUP and CUBE are expressions, where a tuple
+// denotes several columns that are grouped together. The elements of
+// GROUPING SETS can also be grouping sets themselves, and an empty tuple
+// denotes the grouping set with no columns.
+type GroupingSet struct {
+	Kind  GroupingSetKind
+	Exprs Exprs
+}
+
+// Format implements the NodeFormatter interface.
+func (node *GroupingSet) Format(ctx *FmtCtx) {
+	ctx.WriteString(node.Kind.String())
+	ctx.WriteString(" (")
+	for i, e := range node.Exprs {
+		if i > 0 {
+			ctx.WriteString(", ")
+		}
+		formatGroupingElem(ctx, e)
+	}
+	ctx.WriteByte(')')
+}
+
+// GroupingOperation represents a GROUPING(...) expression. Its value is a
+// bitmask that tells which of its arguments are not part of the grouping set
+// that produced the current row: the last argument maps to the least
+// significant bit, and a bit is set if the argument is not grouped. The
+// optimizer replaces it with a constant for each grouping set of the query.
+type GroupingOperation struct {
+	Exprs Exprs
+}
+
+// Format implements the NodeFormatter interface.
+func (node *GroupingOperation) Format(ctx *FmtCtx) {
+	ctx.WriteString("GROUPING(")
+	ctx.FormatNode(&node.Exprs)
+	ctx.WriteByte(')')
+}
+
+func (node *GroupingOperation) String() string { return AsString(node) }
+
 // DistinctOn represents a DISTINCT ON clause.
 type DistinctOn []Expr
 
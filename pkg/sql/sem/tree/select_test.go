@@ -0,0 +1,70 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package tree_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+func TestGroupingSets(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	testCases := []struct {
+		groupBy string
+		// sets is the expected list of grouping sets, separated by "; ".
+		sets string
+		err  string
+	}{
+		{`a, b`, `(a, b)`, ``},
+		{`(a, b)`, `((a, b))`, ``},
+		{`ROLLUP (a, b)`, `(a, b); (a); ()`, ``},
+		{`ROLLUP ((a, b), c)`, `(a, b, c); (a, b); ()`, ``},
+		{`CUBE (a, b)`, `(a, b); (a); (b); ()`, ``},
+		{`GROUPING SETS (a, (b, c), ())`, `(a); (b, c); ()`, ``},
+		{`GROUPING SETS (a, ROLLUP (b))`, `(a); (b); ()`, ``},
+		{`a, ROLLUP (b), CUBE (c)`, `(a, b, c); (a, b); (a, c); (a)`, ``},
+		{`CUBE (a, b, c, d, e, f, g, h, i, j, k, l, m)`, ``, `CUBE is limited to 12 elements`},
+		{
+			`CUBE (a, b, c, d, e, f, g, h, i, j, k, l), CUBE (m)`, ``,
+			`too many grouping sets present \(maximum 4096\)`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.groupBy, func(t *testing.T) {
+			stmt, err := parser.ParseOne("SELECT 1 FROM t GROUP BY " + tc.groupBy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			groupBy := stmt.AST.(*tree.Select).Select.(*tree.SelectClause).GroupBy
+			sets, err := groupBy.GroupingSets()
+			if !testutils.IsError(err, tc.err) {
+				t.Fatalf("expected error %q, got %v", tc.err, err)
+			}
+			if err != nil {
+				return
+			}
+			strs := make([]string, len(sets))
+			for i, set := range sets {
+				strs[i] = "(" + set.String() + ")"
+			}
+			if res := strings.Join(strs, "; "); res != tc.sets {
+				t.Errorf("expected %s, got %s", tc.sets, res)
+			}
+		})
+	}
+}
@@ -102,6 +102,43 @@ func (node *SetTransaction) Format(ctx *FmtCtx) {
 	ctx.FormatNode(&node.Modes)
 }
 
+// ConstraintCheckMode is the mode set by SET CONSTRAINTS.
+type ConstraintCheckMode int
+
+// ConstraintCheckMode values.
+const (
+	ConstraintCheckImmediate ConstraintCheckMode = iota
+	ConstraintCheckDeferred
+)
+
+var constraintCheckModeName = [...]string{
+	ConstraintCheckImmediate: "IMMEDIATE",
+	ConstraintCheckDeferred:  "DEFERRED",
+}
+
+func (m ConstraintCheckMode) String() string {
+	return constraintCheckModeName[m]
+}
+
+// SetConstraints represents a SET CONSTRAINTS statement.
+type SetConstraints struct {
+	// Names is empty when the statement applies to ALL constraints.
+	Names NameList
+	Mode  ConstraintCheckMode
+}
+
+// Format implements the NodeFormatter interface.
+func (node *SetConstraints) Format(ctx *FmtCtx) {
+	ctx.WriteString("SET CONSTRAINTS ")
+	if len(node.Names) == 0 {
+		ctx.WriteString("ALL")
+	} else {
+		ctx.FormatNode(&node.Names)
+	}
+	ctx.WriteByte(' ')
+	ctx.WriteString(node.Mode.String())
+}
+
 // SetSessionAuthorizationDefault represents a SET SESSION AUTHORIZATION DEFAULT
 // statement. This can be extended (and renamed) if we ever support names in the
 // last position.
@@ -455,6 +455,14 @@ func (node *ShowTransactions) Format(ctx *FmtCtx) {
 	}
 }
 
+// ShowBlockingTransactions represents a SHOW BLOCKING TRANSACTIONS statement.
+type ShowBlockingTransactions struct{}
+
+// Format implements the NodeFormatter interface.
+func (node *ShowBlockingTransactions) Format(ctx *FmtCtx) {
+	ctx.WriteString("SHOW BLOCKING TRANSACTIONS")
+}
+
 // ShowConstraints represents a SHOW CONSTRAINTS statement.
 type ShowConstraints struct {
 	Table       *UnresolvedObjectName
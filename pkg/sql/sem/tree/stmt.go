@@ -169,6 +169,7 @@ type CCLOnlyStatement interface {
 
 var _ CCLOnlyStatement = &AlterBackup{}
 var _ CCLOnlyStatement = &Backup{}
+var _ CCLOnlyStatement = &CompactBackup{}
 var _ CCLOnlyStatement = &ShowBackup{}
 var _ CCLOnlyStatement = &Restore{}
 var _ CCLOnlyStatement = &CreateChangefeed{}
@@ -515,6 +516,15 @@ func (n *ControlJobsOfType) StatementTag() string {
 	return fmt.Sprintf("%s ALL %s JOBS", JobCommandToStatement[n.Command], strings.ToUpper(n.Type))
 }
 
+// StatementReturnType implements the Statement interface.
+func (*CancelBlockingTransactions) StatementReturnType() StatementReturnType { return RowsAffected }
+
+// StatementType implements the Statement interface.
+func (*CancelBlockingTransactions) StatementType() StatementType { return TypeDML }
+
+// StatementTag returns a short string identifying the type of statement.
+func (*CancelBlockingTransactions) StatementTag() string { return "CANCEL BLOCKING TRANSACTIONS" }
+
 // StatementReturnType implements the Statement interface.
 func (*CancelQueries) StatementReturnType() StatementReturnType { return RowsAffected }
 
@@ -605,6 +615,17 @@ func (*CommentOnTable) StatementType() StatementType { return TypeDDL }
 // StatementTag returns a short string identifying the type of statement.
 func (*CommentOnTable) StatementTag() string { return "COMMENT ON TABLE" }
 
+// StatementReturnType implements the Statement interface.
+func (*CompactBackup) StatementReturnType() StatementReturnType { return Rows }
+
+// StatementType implements the Statement interface.
+func (*CompactBackup) StatementType() StatementType { return TypeDML }
+
+// StatementTag returns a short string identifying the type of statement.
+func (*CompactBackup) StatementTag() string { return "COMPACT BACKUP" }
+
+func (*CompactBackup) cclOnlyStatement() {}
+
 // StatementReturnType implements the Statement interface.
 func (*CommitTransaction) StatementReturnType() StatementReturnType { return Ack }
 
@@ -1207,6 +1228,15 @@ func (*SetClusterSetting) StatementType() StatementType { return TypeDCL }
 // StatementTag returns a short string identifying the type of statement.
 func (*SetClusterSetting) StatementTag() string { return "SET CLUSTER SETTING" }
 
+// StatementReturnType implements the Statement interface.
+func (*SetConstraints) StatementReturnType() StatementReturnType { return Ack }
+
+// StatementType implements the Statement interface.
+func (*SetConstraints) StatementType() StatementType { return TypeDCL }
+
+// StatementTag returns a short string identifying the type of statement.
+func (*SetConstraints) StatementTag() string { return "SET CONSTRAINTS" }
+
 // StatementReturnType implements the Statement interface.
 func (*SetTransaction) StatementReturnType() StatementReturnType { return Ack }
 
@@ -1300,6 +1330,15 @@ func (*ShowTenantClusterSettingList) StatementType() StatementType { return Type
 // StatementTag returns a short string identifying the type of statement.
 func (*ShowTenantClusterSettingList) StatementTag() string { return "SHOW" }
 
+// StatementReturnType implements the Statement interface.
+func (*ShowBlockingTransactions) StatementReturnType() StatementReturnType { return Rows }
+
+// StatementType implements the Statement interface.
+func (*ShowBlockingTransactions) StatementType() StatementType { return TypeDML }
+
+// StatementTag returns a short string identifying the type of statement.
+func (*ShowBlockingTransactions) StatementTag() string { return "SHOW BLOCKING TRANSACTIONS" }
+
 // StatementReturnType implements the Statement interface.
 func (*ShowColumns) StatementReturnType() StatementReturnType { return Rows }
 
@@ -1823,6 +1862,7 @@ func (n *ControlJobs) String() string                    { return AsString(n) }
 func (n *ControlSchedules) String() string               { return AsString(n) }
 func (n *ControlJobsForSchedules) String() string        { return AsString(n) }
 func (n *ControlJobsOfType) String() string              { return AsString(n) }
+func (n *CancelBlockingTransactions) String() string     { return AsString(n) }
 func (n *CancelQueries) String() string                  { return AsString(n) }
 func (n *CancelSessions) String() string                 { return AsString(n) }
 func (n *CannedOptPlan) String() string                  { return AsString(n) }
@@ -1834,6 +1874,7 @@ func (n *CommentOnSchema) String() string                { return AsString(n) }
 func (n *CommentOnIndex) String() string                 { return AsString(n) }
 func (n *CommentOnTable) String() string                 { return AsString(n) }
 func (n *CommitTransaction) String() string              { return AsString(n) }
+func (n *CompactBackup) String() string                  { return AsString(n) }
 func (n *CopyFrom) String() string                       { return AsString(n) }
 func (n *CreateChangefeed) String() string               { return AsString(n) }
 func (n *CreateDatabase) String() string                 { return AsString(n) }
@@ -1894,10 +1935,12 @@ func (n *SetClusterSetting) String() string              { return AsString(n) }
 func (n *SetZoneConfig) String() string                  { return AsString(n) }
 func (n *SetSessionAuthorizationDefault) String() string { return AsString(n) }
 func (n *SetSessionCharacteristics) String() string      { return AsString(n) }
+func (n *SetConstraints) String() string                 { return AsString(n) }
 func (n *SetTransaction) String() string                 { return AsString(n) }
 func (n *SetTracing) String() string                     { return AsString(n) }
 func (n *SetVar) String() string                         { return AsString(n) }
 func (n *ShowBackup) String() string                     { return AsString(n) }
+func (n *ShowBlockingTransactions) String() string       { return AsString(n) }
 func (n *ShowClusterSetting) String() string             { return AsString(n) }
 func (n *ShowClusterSettingList) String() string         { return AsString(n) }
 func (n *ShowTenantClusterSetting) String() string       { return AsString(n) }
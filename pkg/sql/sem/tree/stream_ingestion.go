@@ -16,6 +16,7 @@ type StreamIngestion struct {
 	From     StringOrPlaceholderOptList
 	AsOf     AsOfClause
 	AsTenant TenantID
+	Options  KVOptions
 }
 
 var _ Statement = &StreamIngestion{}
@@ -35,4 +36,8 @@ func (node *StreamIngestion) Format(ctx *FmtCtx) {
 		ctx.WriteString(" AS TENANT ")
 		ctx.FormatNode(&node.AsTenant)
 	}
+	if node.Options != nil {
+		ctx.WriteString(" WITH ")
+		ctx.FormatNode(&node.Options)
+	}
 }
@@ -25,17 +25,22 @@ type IsolationLevel int
 const (
 	UnspecifiedIsolation IsolationLevel = iota
 	SerializableIsolation
+	ReadCommittedIsolation
 )
 
 var isolationLevelNames = [...]string{
-	UnspecifiedIsolation:  "UNSPECIFIED",
-	SerializableIsolation: "SERIALIZABLE",
+	UnspecifiedIsolation:   "UNSPECIFIED",
+	SerializableIsolation:  "SERIALIZABLE",
+	ReadCommittedIsolation: "READ COMMITTED",
 }
 
 // IsolationLevelMap is a map from string isolation level name to isolation
-// level, in the lowercase format that set isolation_level supports.
+// level, in the lowercase format that set isolation_level supports. READ
+// UNCOMMITTED is upgraded to READ COMMITTED, like in Postgres.
 var IsolationLevelMap = map[string]IsolationLevel{
-	"serializable": SerializableIsolation,
+	"read uncommitted": ReadCommittedIsolation,
+	"read committed":   ReadCommittedIsolation,
+	"serializable":     SerializableIsolation,
 }
 
 func (i IsolationLevel) String() string {
@@ -1521,6 +1521,25 @@ func (expr PartitionMinVal) TypeCheck(
 	return nil, errInvalidMinUsage
 }
 
+// TypeCheck implements the Expr interface. Grouping sets are expanded before
+// the grouping expressions are type checked, so this is only reached when a
+// grouping set is used outside of GROUP BY.
+func (expr *GroupingSet) TypeCheck(
+	_ context.Context, _ *SemaContext, desired *types.T,
+) (TypedExpr, error) {
+	return nil, pgerror.Newf(pgcode.Syntax, "%s is only allowed in GROUP BY", expr.Kind)
+}
+
+// TypeCheck implements the Expr interface. GROUPING is replaced by a constant
+// when the grouping sets of a query are planned, so this is only reached when
+// it is used in a query without GROUP BY.
+func (expr *GroupingOperation) TypeCheck(
+	_ context.Context, _ *SemaContext, desired *types.T,
+) (TypedExpr, error) {
+	return nil, pgerror.New(pgcode.Grouping,
+		"arguments to GROUPING must be grouping expressions of the associated query level")
+}
+
 // TypeCheck implements the Expr interface.
 func (expr PartitionMaxVal) TypeCheck(
 	_ context.Context, _ *SemaContext, desired *types.T,
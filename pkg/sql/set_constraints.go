@@ -0,0 +1,124 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package sql
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/resolver"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+)
+
+// SetConstraints sets the constraint check mode for the current transaction.
+// Setting constraints to IMMEDIATE also runs the checks that were deferred
+// for them so far, as in Postgres.
+func (p *planner) SetConstraints(ctx context.Context, n *tree.SetConstraints) (planNode, error) {
+	dc := p.ExtendedEvalContext().DeferredConstraints
+	if dc == nil {
+		return newZeroNode(nil /* columns */), nil
+	}
+	var ids []deferredConstraintID
+	for _, name := range n.Names {
+		nameIDs, err := p.resolveDeferrableConstraint(ctx, string(name))
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, nameIDs...)
+	}
+	dc.setMode(ids, n.Mode)
+	if n.Mode != tree.ConstraintCheckImmediate {
+		return newZeroNode(nil /* columns */), nil
+	}
+
+	var include func(deferredConstraintID) bool
+	if len(ids) > 0 {
+		include = func(id deferredConstraintID) bool {
+			for i := range ids {
+				if ids[i] == id {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	if err := dc.runChecks(
+		ctx, p.ExecCfg().InternalExecutorFactory, p.SessionData(), p.Descriptors(), p.txn, include,
+	); err != nil {
+		return nil, err
+	}
+	return newZeroNode(nil /* columns */), nil
+}
+
+// resolveDeferrableConstraint returns the constraints with the given name on
+// the tables of the first schema in the search path that has any. As in
+// Postgres, constraint names are not unique, so several constraints can
+// match.
+func (p *planner) resolveDeferrableConstraint(
+	ctx context.Context, name string,
+) ([]deferredConstraintID, error) {
+	db, err := p.Descriptors().GetImmutableDatabaseByName(
+		ctx, p.txn, p.CurrentDatabase(), tree.DatabaseLookupFlags{Required: true},
+	)
+	if err != nil {
+		return nil, err
+	}
+	iter := p.CurrentSearchPath().Iter()
+	for scName, ok := iter.Next(); ok; scName, ok = iter.Next() {
+		sc, err := p.Descriptors().GetImmutableSchemaByName(
+			ctx, p.txn, db, scName, tree.SchemaLookupFlags{},
+		)
+		if err != nil {
+			return nil, err
+		}
+		if sc == nil || sc.SchemaKind() == catalog.SchemaVirtual {
+			continue
+		}
+		_, tableIDs, err := resolver.GetObjectNamesAndIDs(
+			ctx, p.txn, p, p.ExecCfg().Codec, db, scName, false, /* explicitPrefix */
+		)
+		if err != nil {
+			return nil, err
+		}
+		var ids []deferredConstraintID
+		for _, tableID := range tableIDs {
+			tbl, err := p.Descriptors().GetImmutableTableByID(
+				ctx, p.txn, tableID, tree.ObjectLookupFlagsWithRequired(),
+			)
+			if err != nil {
+				if catalog.HasAddingTableError(err) {
+					continue
+				}
+				return nil, err
+			}
+			info, err := tbl.GetConstraintInfo()
+			if err != nil {
+				return nil, err
+			}
+			detail, ok := info[name]
+			if !ok {
+				continue
+			}
+			if detail.Deferrability() == descpb.ConstraintDeferrability_NotDeferrable {
+				return nil, pgerror.Newf(pgcode.WrongObjectType,
+					"constraint %q is not deferrable", name)
+			}
+			ids = append(ids, deferredConstraintID{tableID: tbl.GetID(), name: name})
+		}
+		if len(ids) > 0 {
+			return ids, nil
+		}
+	}
+	return nil, pgerror.Newf(pgcode.UndefinedObject, "constraint %q does not exist", name)
+}
@@ -21,14 +21,17 @@ import (
 func (p *planner) SetSessionCharacteristics(n *tree.SetSessionCharacteristics) (planNode, error) {
 	// Note: We also support SET DEFAULT_TRANSACTION_ISOLATION TO ' .... '.
 	switch n.Modes.Isolation {
-	case tree.SerializableIsolation, tree.UnspecifiedIsolation:
-		// Do nothing. All transactions execute with serializable isolation.
+	case tree.SerializableIsolation, tree.ReadCommittedIsolation, tree.UnspecifiedIsolation:
 	default:
 		return nil, pgerror.Newf(pgcode.InvalidParameterValue,
 			"unsupported default isolation level: %s", n.Modes.Isolation)
 	}
 
 	if err := p.sessionDataMutatorIterator.applyOnEachMutatorError(func(m sessionDataMutator) error {
+		if n.Modes.Isolation != tree.UnspecifiedIsolation {
+			m.SetDefaultTransactionIsolationLevel(n.Modes.Isolation)
+		}
+
 		// Note: We also support SET DEFAULT_TRANSACTION_PRIORITY TO ' .... '.
 		switch n.Modes.UserPriority {
 		case tree.UnspecifiedUserPriority:
@@ -60,6 +60,8 @@ const (
 	FullTableScans
 	// SuperRegions represents the SHOW SUPER REGIONS command.
 	SuperRegions
+	// BlockingTransactions represents the SHOW BLOCKING TRANSACTIONS command.
+	BlockingTransactions
 )
 
 var showTelemetryNameMap = map[ShowTelemetryType]string{
@@ -82,6 +84,7 @@ var showTelemetryNameMap = map[ShowTelemetryType]string{
 	Schedules:               "schedules",
 	FullTableScans:          "full_table_scans",
 	SuperRegions:            "super_regions",
+	BlockingTransactions:    "blocking_transactions",
 }
 
 func (s ShowTelemetryType) String() string {
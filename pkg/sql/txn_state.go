@@ -145,6 +145,8 @@ const (
 //   and should be fixed to this timestamp.
 // priority: The transaction's priority. Pass roachpb.UnspecifiedUserPriority if the txn arg is
 //   not nil.
+// isoLevel: The transaction's isolation level. Ignored if the txn arg is not
+//   nil.
 // readOnly: The read-only character of the new txn.
 // txn: If not nil, this txn will be used instead of creating a new txn. If so,
 //   all the other arguments need to correspond to the attributes of this txn
@@ -158,6 +160,7 @@ func (ts *txnState) resetForNewSQLTxn(
 	sqlTimestamp time.Time,
 	historicalTimestamp *hlc.Timestamp,
 	priority roachpb.UserPriority,
+	isoLevel kv.IsolationLevel,
 	readOnly tree.ReadWriteMode,
 	txn *kv.Txn,
 	tranCtx transitionCtx,
@@ -204,6 +207,9 @@ func (ts *txnState) resetForNewSQLTxn(
 		if err := ts.setPriorityLocked(priority); err != nil {
 			panic(err)
 		}
+		if err := ts.mu.txn.SetIsoLevel(isoLevel); err != nil {
+			panic(err)
+		}
 	} else {
 		if priority != roachpb.UnspecifiedUserPriority {
 			panic(errors.AssertionFailedf("unexpected priority when using an existing txn: %s", priority))
@@ -316,6 +322,12 @@ func (ts *txnState) setPriorityLocked(userPriority roachpb.UserPriority) error {
 	return nil
 }
 
+func (ts *txnState) setIsolationLevel(isoLevel kv.IsolationLevel) error {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return ts.mu.txn.SetIsoLevel(isoLevel)
+}
+
 func (ts *txnState) setReadOnlyMode(mode tree.ReadWriteMode) error {
 	switch mode {
 	case tree.UnspecifiedReadWriteMode:
@@ -292,7 +292,7 @@ func TestTransitions(t *testing.T) {
 				return s, ts, emptyTxnID, nil
 			},
 			ev: eventTxnStart{ImplicitTxn: fsm.True},
-			evPayload: makeEventTxnStartPayload(pri, tree.ReadWrite, timeutil.Now(),
+			evPayload: makeEventTxnStartPayload(pri, kv.SerializableIsolation, tree.ReadWrite, timeutil.Now(),
 				nil /* historicalTimestamp */, tranCtx, sessiondatapb.Normal),
 			expState: stateOpen{ImplicitTxn: fsm.True},
 			expAdv: expAdvance{
@@ -317,7 +317,7 @@ func TestTransitions(t *testing.T) {
 				return s, ts, emptyTxnID, nil
 			},
 			ev: eventTxnStart{ImplicitTxn: fsm.False},
-			evPayload: makeEventTxnStartPayload(pri, tree.ReadWrite, timeutil.Now(),
+			evPayload: makeEventTxnStartPayload(pri, kv.SerializableIsolation, tree.ReadWrite, timeutil.Now(),
 				nil /* historicalTimestamp */, tranCtx, sessiondatapb.Normal),
 			expState: stateOpen{ImplicitTxn: fsm.False},
 			expAdv: expAdvance{
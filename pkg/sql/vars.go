@@ -45,6 +45,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/sqltelemetry"
 	"github.com/cockroachdb/cockroach/pkg/util/duration"
 	"github.com/cockroachdb/cockroach/pkg/util/errorutil/unimplemented"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/humanizeutil"
 	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
 	"github.com/cockroachdb/cockroach/pkg/util/timeutil/pgdate"
@@ -443,16 +444,24 @@ var varGen = map[string]sessionVar{
 	`default_transaction_isolation`: {
 		Set: func(_ context.Context, m sessionDataMutator, s string) error {
 			switch strings.ToUpper(s) {
-			case `READ UNCOMMITTED`, `READ COMMITTED`, `SNAPSHOT`, `REPEATABLE READ`, `SERIALIZABLE`, `DEFAULT`:
-				// Do nothing. All transactions execute with serializable isolation.
+			case `READ UNCOMMITTED`, `READ COMMITTED`:
+				// READ UNCOMMITTED is upgraded to READ COMMITTED, like in Postgres.
+				m.SetDefaultTransactionIsolationLevel(tree.ReadCommittedIsolation)
+			case `SNAPSHOT`, `REPEATABLE READ`, `SERIALIZABLE`, `DEFAULT`:
+				m.SetDefaultTransactionIsolationLevel(tree.SerializableIsolation)
 			default:
-				return newVarValueError(`default_transaction_isolation`, s, "serializable")
+				return newVarValueError(`default_transaction_isolation`, s,
+					"read committed", "serializable")
 			}
 
 			return nil
 		},
 		Get: func(evalCtx *extendedEvalContext, _ *kv.Txn) (string, error) {
-			return "serializable", nil
+			level := tree.IsolationLevel(evalCtx.SessionData().DefaultTxnIsolationLevel)
+			if level == tree.UnspecifiedIsolation {
+				level = tree.SerializableIsolation
+			}
+			return strings.ToLower(level.String()), nil
 		},
 		GlobalDefault: func(sv *settings.Values) string { return "default" },
 	},
@@ -1405,15 +1414,20 @@ var varGen = map[string]sessionVar{
 	// This is not directly documented in PG's docs but does indeed behave this way.
 	// See https://github.com/postgres/postgres/blob/REL_10_STABLE/src/backend/utils/misc/guc.c#L3401-L3409
 	`transaction_isolation`: {
-		Get: func(evalCtx *extendedEvalContext, _ *kv.Txn) (string, error) {
+		Get: func(evalCtx *extendedEvalContext, txn *kv.Txn) (string, error) {
+			if txn.IsoLevel() == kv.ReadCommittedIsolation {
+				return "read committed", nil
+			}
 			return "serializable", nil
 		},
-		RuntimeSet: func(_ context.Context, evalCtx *extendedEvalContext, local bool, s string) error {
-			_, ok := tree.IsolationLevelMap[s]
+		RuntimeSet: func(ctx context.Context, evalCtx *extendedEvalContext, local bool, s string) error {
+			level, ok := tree.IsolationLevelMap[strings.ToLower(s)]
 			if !ok {
-				return newVarValueError(`transaction_isolation`, s, "serializable")
+				return newVarValueError(`transaction_isolation`, s, "read committed", "serializable")
 			}
-			return nil
+			return evalCtx.TxnModesSetter.setTransactionModes(
+				ctx, tree.TransactionModes{Isolation: level}, hlc.Timestamp{},
+			)
 		},
 		GlobalDefault: func(_ *settings.Values) string { return "serializable" },
 	},
@@ -357,6 +357,7 @@ var planNodeNames = map[reflect.Type]string{
 	reflect.TypeOf(&alterRoleSetNode{}):                 "alter role set var",
 	reflect.TypeOf(&applyJoinNode{}):                    "apply join",
 	reflect.TypeOf(&bufferNode{}):                       "buffer",
+	reflect.TypeOf(&cancelBlockingTxnsNode{}):           "cancel blocking transactions",
 	reflect.TypeOf(&cancelQueriesNode{}):                "cancel queries",
 	reflect.TypeOf(&cancelSessionsNode{}):               "cancel sessions",
 	reflect.TypeOf(&changePrivilegesNode{}):             "change privileges",
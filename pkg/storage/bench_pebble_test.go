@@ -16,6 +16,7 @@ import (
 	"math/rand"
 	"testing"
 
+	"github.com/cockroachdb/cockroach/pkg/clusterversion"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/testutils/skip"
@@ -56,6 +57,18 @@ func setupMVCCInMemPebbleWithSeparatedIntents(b testing.TB) Engine {
 	return peb
 }
 
+func setupMVCCInMemPebbleWithSettings(b testing.TB, st *cluster.Settings) Engine {
+	peb, err := Open(
+		context.Background(),
+		InMemory(),
+		CacheSize(testCacheSize),
+		Settings(st))
+	if err != nil {
+		b.Fatalf("could not create new in-mem pebble instance: %+v", err)
+	}
+	return peb
+}
+
 func BenchmarkMVCCScan_Pebble(b *testing.B) {
 	skip.UnderShort(b)
 	ctx := context.Background()
@@ -197,6 +210,31 @@ func BenchmarkMVCCPut_Pebble(b *testing.B) {
 	}
 }
 
+// BenchmarkMVCCSharedLockChecks_Pebble measures the cost of looking for
+// replicated shared locks when writing and resolving intents. The lookups are
+// skipped until the SharedLockConflicts cluster version is active.
+func BenchmarkMVCCSharedLockChecks_Pebble(b *testing.B) {
+	ctx := context.Background()
+	for _, active := range []bool{false, true} {
+		b.Run(fmt.Sprintf("shared-locks=%t", active), func(b *testing.B) {
+			v := clusterversion.ByKey(clusterversion.SharedLockConflicts)
+			if !active {
+				v = clusterversion.ByKey(clusterversion.SharedLockConflicts - 1)
+			}
+			emk := func(b testing.TB, _ string) Engine {
+				st := cluster.MakeTestingClusterSettingsWithVersions(v, v, true /* initializeVersion */)
+				return setupMVCCInMemPebbleWithSettings(b, st)
+			}
+			b.Run("op=put", func(b *testing.B) {
+				runMVCCPut(ctx, b, emk, 100)
+			})
+			b.Run("op=resolve", func(b *testing.B) {
+				runMVCCResolveWriteIntent(ctx, b, emk, 100)
+			})
+		})
+	}
+}
+
 func BenchmarkMVCCBlindPut_Pebble(b *testing.B) {
 	ctx := context.Background()
 	for _, valueSize := range []int{10, 100, 1000, 10000} {
@@ -987,6 +987,38 @@ func runMVCCBlindPut(ctx context.Context, b *testing.B, emk engineMaker, valueSi
 	b.StopTimer()
 }
 
+func runMVCCResolveWriteIntent(ctx context.Context, b *testing.B, emk engineMaker, valueSize int) {
+	rng, _ := randutil.NewTestRand()
+	value := roachpb.MakeValueFromBytes(randutil.RandBytes(rng, valueSize))
+	keyBuf := append(make([]byte, 0, 64), []byte("key-")...)
+
+	eng := emk(b, fmt.Sprintf("resolve_%d", valueSize))
+	defer eng.Close()
+
+	ts := hlc.Timestamp{WallTime: timeutil.Now().UnixNano()}
+	txn := roachpb.MakeTransaction("test", nil, roachpb.NormalUserPriority, ts, 0, 0)
+	for i := 0; i < b.N; i++ {
+		key := roachpb.Key(encoding.EncodeUvarintAscending(keyBuf[:4], uint64(i)))
+		if err := MVCCPut(ctx, eng, nil, key, ts, value, &txn); err != nil {
+			b.Fatalf("failed put: %+v", err)
+		}
+	}
+	txn.Status = roachpb.COMMITTED
+	lockUpdate := roachpb.MakeLockUpdate(&txn, roachpb.Span{})
+
+	b.SetBytes(int64(valueSize))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		lockUpdate.Key = encoding.EncodeUvarintAscending(keyBuf[:4], uint64(i))
+		if found, err := MVCCResolveWriteIntent(ctx, eng, nil, lockUpdate); !found || err != nil {
+			b.Fatalf("intent not found or err %+v", err)
+		}
+	}
+
+	b.StopTimer()
+}
+
 func runMVCCConditionalPut(
 	ctx context.Context, b *testing.B, emk engineMaker, valueSize int, createFirst bool,
 ) {
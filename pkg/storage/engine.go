@@ -486,6 +486,10 @@ type Reader interface {
 	// the first call to PinEngineStateForIterators.
 	// REQUIRES: ConsistentIterators returns true.
 	PinEngineStateForIterators() error
+	// SharedLocksMayExist returns false if the lock table keyspace cannot hold
+	// replicated shared locks, because the cluster does not acquire them yet.
+	// Writes and intent resolution then skip looking for them.
+	SharedLocksMayExist() bool
 }
 
 // Writer is the write interface to an engine's data.
@@ -530,6 +534,11 @@ type Writer interface {
 	// decrease, we can stop tracking txnDidNotUpdateMeta and still optimize
 	// ClearIntent by always doing single-clear.
 	ClearIntent(key roachpb.Key, txnDidNotUpdateMeta bool, txnUUID uuid.UUID) error
+	// ClearSharedLock removes a replicated shared lock held by the transaction
+	// with the given ID on the given key from the lock table.
+	//
+	// It is safe to modify the contents of the arguments after it returns.
+	ClearSharedLock(key roachpb.Key, txnUUID uuid.UUID) error
 	// ClearEngineKey removes the item from the db with the given EngineKey.
 	// Note that clear actually removes entries from the storage engine. This is
 	// a general-purpose and low-level method that should be used sparingly,
@@ -614,6 +623,13 @@ type Writer interface {
 	//
 	// It is safe to modify the contents of the arguments after Put returns.
 	PutIntent(ctx context.Context, key roachpb.Key, value []byte, txnUUID uuid.UUID) error
+	// PutSharedLock puts a replicated shared lock held by the transaction with
+	// the given ID on the given key in the lock table. The value is an encoded
+	// MVCCMetadata whose Txn field describes the lock holder. Unlike intents,
+	// any number of transactions can hold a shared lock on the same key.
+	//
+	// It is safe to modify the contents of the arguments after Put returns.
+	PutSharedLock(key roachpb.Key, value []byte, txnUUID uuid.UUID) error
 	// PutEngineKey sets the given key to the value provided. This is a
 	// general-purpose and low-level method that should be used sparingly,
 	// only when the other Put* methods are not applicable.
@@ -688,6 +704,14 @@ type Engine interface {
 	// GetEncryptionRegistries returns the file and key registries when encryption is enabled
 	// on the store.
 	GetEncryptionRegistries() (*EncryptionRegistries, error)
+	// RotateStoreKey rotates the encryption-at-rest store key, if the store keys
+	// are managed by the store itself.
+	RotateStoreKey(ctx context.Context) error
+	// StartReencryption starts rewriting, in the background, the files of the
+	// store that are not encrypted with the active encryption-at-rest data key,
+	// so that retired keys stop being in use. Its progress is reported in
+	// EnvStats.
+	StartReencryption(ctx context.Context) error
 	// GetEnvStats retrieves stats about the engine's environment
 	// For RocksDB, this includes details of at-rest encryption.
 	GetEnvStats() (*EnvStats, error)
@@ -871,6 +895,9 @@ type EnvStats struct {
 	EncryptionType int32
 	// EncryptionStatus is a serialized enginepbccl/stats.proto::EncryptionStatus protobuf.
 	EncryptionStatus []byte
+	// Reencryption is the progress of the rewrite of the files that are not
+	// encrypted with the active data key.
+	Reencryption ReencryptionStats
 }
 
 // EncryptionRegistries contains the encryption-related registries:
@@ -914,7 +941,8 @@ func ScanIntents(
 
 	ltStart, _ := keys.LockTableSingleKey(start, nil)
 	ltEnd, _ := keys.LockTableSingleKey(end, nil)
-	iter := reader.NewEngineIterator(IterOptions{LowerBound: ltStart, UpperBound: ltEnd})
+	iter := NewIntentLockTableIterator(
+		reader.NewEngineIterator(IterOptions{LowerBound: ltStart, UpperBound: ltEnd}))
 	defer iter.Close()
 
 	var meta enginepb.MVCCMetadata
@@ -186,6 +186,14 @@ func (k EngineKey) ToLockTableKey() (LockTableKey, error) {
 	return key, nil
 }
 
+// isSharedLockTableKey returns whether the EngineKey is a LockTableKey for a
+// lock held with lock.Shared strength, without decoding the locked key. All
+// other lock table keys are intents.
+func (k EngineKey) isSharedLockTableKey() bool {
+	return len(k.Version) == engineKeyVersionLockTableLen &&
+		lock.Strength(k.Version[0]) == lock.Shared
+}
+
 // Validate checks if the EngineKey is a valid MVCCKey or LockTableKey.
 func (k EngineKey) Validate() error {
 	_, errMVCC := k.ToMVCCKey()
@@ -267,7 +275,7 @@ func (lk LockTableKey) ToEngineKey(buf []byte) (EngineKey, []byte) {
 	if len(lk.TxnUUID) != uuid.Size {
 		panic("invalid TxnUUID")
 	}
-	if lk.Strength != lock.Exclusive {
+	if lk.Strength != lock.Exclusive && lk.Strength != lock.Shared {
 		panic("unsupported lock strength")
 	}
 	// The first term in estimatedLen is for LockTableSingleKey.
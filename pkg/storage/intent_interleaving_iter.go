@@ -96,6 +96,8 @@ type intentInterleavingIter struct {
 
 	// intentIter is for iterating over separated intents, so that
 	// intentInterleavingIter can make them look as if they were interleaved.
+	// Replicated shared locks in the lock table are hidden by the
+	// intentLockTableIter wrapper.
 	intentIter      EngineIterator
 	intentIterState pebble.IterValidityState
 	// The decoded key from the lock table. This is an unsafe key
@@ -240,7 +242,7 @@ func newIntentInterleavingIterator(reader Reader, opts IterOptions) MVCCIterator
 		prefix:                               opts.Prefix,
 		constraint:                           constraint,
 		iter:                                 iter,
-		intentIter:                           intentIter,
+		intentIter:                           intentLockTableIter{EngineIterator: intentIter},
 		intentKeyAsNoTimestampMVCCKeyBacking: iiIter.intentKeyAsNoTimestampMVCCKeyBacking,
 		intentKeyBuf:                         intentKeyBuf,
 		intentLimitKeyBuf:                    intentLimitKeyBuf,
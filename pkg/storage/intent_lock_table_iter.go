@@ -0,0 +1,147 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package storage
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/pebble"
+)
+
+// intentLockTableIter wraps an EngineIterator over the lock table and hides
+// replicated shared locks from its user, such that only intents (i.e. locks
+// held with lock.Exclusive strength) are surfaced. Shared locks do not carry a
+// provisional value, so code that interprets the lock table as the set of
+// intents on a span (the intentInterleavingIter, intent resolution, rangefeed
+// catch-up scans, etc.) must not observe them.
+//
+// The lock table orders the locks on a given key by strength, so shared locks
+// on a key are positioned immediately before the intent on that key, if any.
+type intentLockTableIter struct {
+	EngineIterator
+}
+
+var _ EngineIterator = intentLockTableIter{}
+
+// NewIntentLockTableIterator wraps the provided lock table EngineIterator so
+// that replicated shared locks are skipped over and only intents are
+// surfaced. See intentLockTableIter.
+func NewIntentLockTableIterator(iter EngineIterator) EngineIterator {
+	return intentLockTableIter{EngineIterator: iter}
+}
+
+// atSharedLock returns whether the iterator is positioned at a shared lock.
+func (i intentLockTableIter) atSharedLock() (bool, error) {
+	k, err := i.EngineIterator.UnsafeEngineKey()
+	if err != nil {
+		return false, err
+	}
+	return k.isSharedLockTableKey(), nil
+}
+
+func (i intentLockTableIter) skipForward(valid bool, err error) (bool, error) {
+	for valid && err == nil {
+		var shared bool
+		if shared, err = i.atSharedLock(); err != nil || !shared {
+			break
+		}
+		valid, err = i.EngineIterator.NextEngineKey()
+	}
+	return valid, err
+}
+
+func (i intentLockTableIter) skipBackward(valid bool, err error) (bool, error) {
+	for valid && err == nil {
+		var shared bool
+		if shared, err = i.atSharedLock(); err != nil || !shared {
+			break
+		}
+		valid, err = i.EngineIterator.PrevEngineKey()
+	}
+	return valid, err
+}
+
+func (i intentLockTableIter) skipForwardWithLimit(
+	state pebble.IterValidityState, err error, limit roachpb.Key,
+) (pebble.IterValidityState, error) {
+	for state == pebble.IterValid && err == nil {
+		var shared bool
+		if shared, err = i.atSharedLock(); err != nil || !shared {
+			break
+		}
+		state, err = i.EngineIterator.NextEngineKeyWithLimit(limit)
+	}
+	return state, err
+}
+
+func (i intentLockTableIter) skipBackwardWithLimit(
+	state pebble.IterValidityState, err error, limit roachpb.Key,
+) (pebble.IterValidityState, error) {
+	for state == pebble.IterValid && err == nil {
+		var shared bool
+		if shared, err = i.atSharedLock(); err != nil || !shared {
+			break
+		}
+		state, err = i.EngineIterator.PrevEngineKeyWithLimit(limit)
+	}
+	return state, err
+}
+
+// SeekEngineKeyGE implements the EngineIterator interface.
+func (i intentLockTableIter) SeekEngineKeyGE(key EngineKey) (valid bool, err error) {
+	return i.skipForward(i.EngineIterator.SeekEngineKeyGE(key))
+}
+
+// SeekEngineKeyLT implements the EngineIterator interface.
+func (i intentLockTableIter) SeekEngineKeyLT(key EngineKey) (valid bool, err error) {
+	return i.skipBackward(i.EngineIterator.SeekEngineKeyLT(key))
+}
+
+// NextEngineKey implements the EngineIterator interface.
+func (i intentLockTableIter) NextEngineKey() (valid bool, err error) {
+	return i.skipForward(i.EngineIterator.NextEngineKey())
+}
+
+// PrevEngineKey implements the EngineIterator interface.
+func (i intentLockTableIter) PrevEngineKey() (valid bool, err error) {
+	return i.skipBackward(i.EngineIterator.PrevEngineKey())
+}
+
+// SeekEngineKeyGEWithLimit implements the EngineIterator interface.
+func (i intentLockTableIter) SeekEngineKeyGEWithLimit(
+	key EngineKey, limit roachpb.Key,
+) (state pebble.IterValidityState, err error) {
+	state, err = i.EngineIterator.SeekEngineKeyGEWithLimit(key, limit)
+	return i.skipForwardWithLimit(state, err, limit)
+}
+
+// SeekEngineKeyLTWithLimit implements the EngineIterator interface.
+func (i intentLockTableIter) SeekEngineKeyLTWithLimit(
+	key EngineKey, limit roachpb.Key,
+) (state pebble.IterValidityState, err error) {
+	state, err = i.EngineIterator.SeekEngineKeyLTWithLimit(key, limit)
+	return i.skipBackwardWithLimit(state, err, limit)
+}
+
+// NextEngineKeyWithLimit implements the EngineIterator interface.
+func (i intentLockTableIter) NextEngineKeyWithLimit(
+	limit roachpb.Key,
+) (state pebble.IterValidityState, err error) {
+	state, err = i.EngineIterator.NextEngineKeyWithLimit(limit)
+	return i.skipForwardWithLimit(state, err, limit)
+}
+
+// PrevEngineKeyWithLimit implements the EngineIterator interface.
+func (i intentLockTableIter) PrevEngineKeyWithLimit(
+	limit roachpb.Key,
+) (state pebble.IterValidityState, err error) {
+	state, err = i.EngineIterator.PrevEngineKeyWithLimit(limit)
+	return i.skipBackwardWithLimit(state, err, limit)
+}
@@ -68,6 +68,36 @@ func (idw intentDemuxWriter) PutIntent(
 	return buf, idw.w.PutEngineKey(engineKey, value)
 }
 
+// ClearSharedLock has the same behavior as Writer.ClearSharedLock. buf is used
+// as scratch-space to avoid allocations -- its contents will be overwritten and
+// not appended to, and a possibly different buf returned.
+func (idw intentDemuxWriter) ClearSharedLock(
+	key roachpb.Key, txnUUID uuid.UUID, buf []byte,
+) (_ []byte, _ error) {
+	var engineKey EngineKey
+	engineKey, buf = LockTableKey{
+		Key:      key,
+		Strength: lock.Shared,
+		TxnUUID:  txnUUID[:],
+	}.ToEngineKey(buf)
+	return buf, idw.w.ClearEngineKey(engineKey)
+}
+
+// PutSharedLock has the same behavior as Writer.PutSharedLock. buf is used as
+// scratch-space to avoid allocations -- its contents will be overwritten and
+// not appended to, and a possibly different buf returned.
+func (idw intentDemuxWriter) PutSharedLock(
+	key roachpb.Key, value []byte, txnUUID uuid.UUID, buf []byte,
+) (_ []byte, _ error) {
+	var engineKey EngineKey
+	engineKey, buf = LockTableKey{
+		Key:      key,
+		Strength: lock.Shared,
+		TxnUUID:  txnUUID[:],
+	}.ToEngineKey(buf)
+	return buf, idw.w.PutEngineKey(engineKey, value)
+}
+
 // ClearMVCCRangeAndIntents has the same behavior as
 // Writer.ClearMVCCRangeAndIntents. buf is used as scratch-space to avoid
 // allocations -- its contents will be overwritten and not appended to, and a
@@ -995,6 +995,9 @@ func MVCCPut(
 	var iter MVCCIterator
 	blind := ms == nil && timestamp.IsEmpty()
 	if !blind {
+		if err := mvccCheckForSharedLocks(rw, key, timestamp, txn); err != nil {
+			return err
+		}
 		iter = rw.NewMVCCIterator(MVCCKeyAndIntentsIterKind, IterOptions{Prefix: true})
 		defer iter.Close()
 	}
@@ -1037,6 +1040,9 @@ func MVCCDelete(
 	timestamp hlc.Timestamp,
 	txn *roachpb.Transaction,
 ) error {
+	if err := mvccCheckForSharedLocks(rw, key, timestamp, txn); err != nil {
+		return err
+	}
 	iter := newMVCCIterator(rw, timestamp.IsEmpty(), IterOptions{Prefix: true})
 	defer iter.Close()
 
@@ -1659,6 +1665,9 @@ func MVCCIncrement(
 	txn *roachpb.Transaction,
 	inc int64,
 ) (int64, error) {
+	if err := mvccCheckForSharedLocks(rw, key, timestamp, txn); err != nil {
+		return 0, err
+	}
 	iter := newMVCCIterator(rw, timestamp.IsEmpty(), IterOptions{Prefix: true})
 	defer iter.Close()
 
@@ -1731,6 +1740,9 @@ func MVCCConditionalPut(
 	allowIfDoesNotExist CPutMissingBehavior,
 	txn *roachpb.Transaction,
 ) error {
+	if err := mvccCheckForSharedLocks(rw, key, timestamp, txn); err != nil {
+		return err
+	}
 	iter := newMVCCIterator(rw, timestamp.IsEmpty(), IterOptions{Prefix: true})
 	defer iter.Close()
 
@@ -1809,6 +1821,9 @@ func MVCCInitPut(
 	failOnTombstones bool,
 	txn *roachpb.Transaction,
 ) error {
+	if err := mvccCheckForSharedLocks(rw, key, timestamp, txn); err != nil {
+		return err
+	}
 	iter := newMVCCIterator(rw, timestamp.IsEmpty(), IterOptions{Prefix: true})
 	defer iter.Close()
 	return mvccInitPutUsingIter(ctx, rw, iter, ms, key, timestamp, value, failOnTombstones, txn)
@@ -2189,6 +2204,9 @@ func MVCCDeleteRange(
 
 	var keys []roachpb.Key
 	for i, kv := range res.KVs {
+		if err := mvccCheckForSharedLocks(rw, kv.Key, timestamp, txn); err != nil {
+			return nil, nil, 0, err
+		}
 		if err := mvccPutInternal(ctx, rw, iter, ms, kv.Key, timestamp, nil, txn, buf, nil); err != nil {
 			return nil, nil, 0, err
 		}
@@ -2622,7 +2640,13 @@ func MVCCResolveWriteIntent(
 	ok, err := mvccResolveWriteIntent(ctx, rw, iterAndBuf.iter, ms, intent, iterAndBuf.buf)
 	// Using defer would be more convenient, but it is measurably slower.
 	iterAndBuf.Cleanup()
-	return ok, err
+	if err != nil {
+		return false, err
+	}
+	if _, err := mvccReleaseSharedLocks(rw, intent, 0 /* max */); err != nil {
+		return false, err
+	}
+	return ok, nil
 }
 
 // unsafeNextVersion positions the iterator at the successor to latestKey. If this value
@@ -3294,8 +3318,8 @@ func (b IterAndBuf) Cleanup() {
 // intents specified by start and end keys for a given txn.
 // ResolveWriteIntentRange will skip write intents of other txns. A max of zero
 // means unbounded. A max of -1 means resolve nothing and returns the entire
-// intent span as the resume span. Returns the number of intents resolved and a
-// resume span if the max keys limit was exceeded.
+// intent span as the resume span. Returns the number of intents and shared
+// locks resolved and a resume span if the max keys limit was exceeded.
 func MVCCResolveWriteIntentRange(
 	ctx context.Context, rw ReadWriter, ms *enginepb.MVCCStats, intent roachpb.LockUpdate, max int64,
 ) (int64, *roachpb.Span, error) {
@@ -3304,6 +3328,18 @@ func MVCCResolveWriteIntentRange(
 		return 0, &resumeSpan, nil
 	}
 
+	// Release the transaction's shared locks in the span first. They count
+	// towards max, and if they exhaust it the entire span is returned as the
+	// resume span, so that its intents are resolved by a later request.
+	num, err := mvccReleaseSharedLocks(rw, intent, max)
+	if err != nil {
+		return 0, nil, err
+	}
+	if max > 0 && num == max {
+		resumeSpan := intent.Span
+		return num, &resumeSpan, nil
+	}
+
 	var putBuf *putBuffer
 	// Exactly one of sepIter and mvccIter is non-nil. sepIter is used when
 	// onlySeparatedIntents=true and rw provides consistent iterators, else
@@ -3325,7 +3361,8 @@ func MVCCResolveWriteIntentRange(
 	if rw.ConsistentIterators() {
 		ltStart, _ := keys.LockTableSingleKey(intent.Key, nil)
 		ltEnd, _ := keys.LockTableSingleKey(intent.EndKey, nil)
-		engineIter := rw.NewEngineIterator(IterOptions{LowerBound: ltStart, UpperBound: ltEnd})
+		engineIter := NewIntentLockTableIterator(
+			rw.NewEngineIterator(IterOptions{LowerBound: ltStart, UpperBound: ltEnd}))
 		iterAndBuf :=
 			GetBufUsingIter(rw.NewMVCCIterator(MVCCKeyIterKind, IterOptions{UpperBound: intent.EndKey}))
 		defer func() {
@@ -3353,7 +3390,6 @@ func MVCCResolveWriteIntentRange(
 	intent.EndKey = nil
 
 	var keyBuf []byte
-	num := int64(0)
 	for {
 		if max > 0 && num == max {
 			return num, &roachpb.Span{Key: nextKey.Key, EndKey: intentEndKey}, nil
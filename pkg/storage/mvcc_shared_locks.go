@@ -0,0 +1,233 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/clusterversion"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/concurrency/lock"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/storage/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+	"github.com/cockroachdb/errors"
+)
+
+// Replicated shared locks are stored in the lock table keyspace next to
+// intents, keyed by <key, lock.Shared, txnID>. Unlike an intent, a shared lock
+// has no provisional value and any number of transactions can hold one on the
+// same key at the same time. The value of a shared lock is an MVCCMetadata
+// whose Txn field describes the lock holder.
+//
+// Shared locks are hidden from the intentInterleavingIter (see
+// intentLockTableIter), so reads ignore them entirely. Writes from other
+// transactions conflict with them, which is surfaced through a
+// WriteIntentError whose intents carry lock.Shared strength. Shared locks are
+// released when their transaction is finalized and its locks are resolved.
+//
+// Shared locks are not accounted for in MVCCStats, like the rest of the lock
+// table keyspace.
+//
+// Shared locks are only acquired once the SharedLocks cluster version is
+// active. Before that, writes and intent resolution do not look for them (see
+// Reader.SharedLocksMayExist).
+
+// sharedLocksMayExist returns whether the lock table keyspace of an engine
+// with the given settings may hold replicated shared locks. Writers look for
+// them from the SharedLockConflicts version on, which precedes the
+// SharedLocks version, so that no node skips a shared lock that was acquired
+// by a node on which SharedLocks was already active. Engines without settings
+// look for shared locks.
+func sharedLocksMayExist(st *cluster.Settings) bool {
+	return st == nil || st.Version.IsActive(context.TODO(), clusterversion.SharedLockConflicts)
+}
+
+// lockTableLocksOnKey calls fn for each lock in the lock table on the given
+// key, in strength order. The LockTableKey and MVCCMetadata passed to fn are
+// only valid for the duration of the call.
+func lockTableLocksOnKey(
+	reader Reader, key roachpb.Key, fn func(LockTableKey, *enginepb.MVCCMetadata) error,
+) error {
+	ltKey, _ := keys.LockTableSingleKey(key, nil)
+	iter := reader.NewEngineIterator(IterOptions{Prefix: true, LowerBound: ltKey})
+	defer iter.Close()
+
+	var meta enginepb.MVCCMetadata
+	valid, err := iter.SeekEngineKeyGE(EngineKey{Key: ltKey})
+	for ; valid; valid, err = iter.NextEngineKey() {
+		engineKey, err := iter.UnsafeEngineKey()
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(engineKey.Key, ltKey) {
+			break
+		}
+		lk, err := engineKey.ToLockTableKey()
+		if err != nil {
+			return err
+		}
+		if err := protoutil.Unmarshal(iter.UnsafeValue(), &meta); err != nil {
+			return err
+		}
+		if meta.Txn == nil {
+			return errors.AssertionFailedf("lock with no txn on key %s", key)
+		}
+		if err := fn(lk, &meta); err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// MVCCAcquireSharedLock acquires a replicated shared lock on the key on behalf
+// of the transaction. The lock is written to the lock table keyspace and is
+// held until the transaction is finalized and its locks are resolved.
+//
+// A WriteIntentError is returned if the key holds an intent written by a
+// different transaction. Acquiring the lock is a no-op if the transaction
+// already holds a shared lock on the key or has written an intent to it, since
+// an intent provides stronger protection than a shared lock.
+func MVCCAcquireSharedLock(
+	ctx context.Context, rw ReadWriter, txn *roachpb.Transaction, key roachpb.Key,
+) error {
+	if txn == nil {
+		return errors.AssertionFailedf("cannot acquire a shared lock on key %s outside of a transaction", key)
+	}
+	if len(key) == 0 {
+		return emptyKeyError()
+	}
+
+	var alreadyLocked bool
+	if err := lockTableLocksOnKey(rw, key, func(lk LockTableKey, meta *enginepb.MVCCMetadata) error {
+		if meta.Txn.ID != txn.ID {
+			if lk.Strength == lock.Exclusive {
+				return &roachpb.WriteIntentError{Intents: []roachpb.Intent{
+					roachpb.MakeIntent(meta.Txn, key),
+				}}
+			}
+			return nil
+		}
+		alreadyLocked = true
+		return nil
+	}); err != nil {
+		return err
+	}
+	if alreadyLocked {
+		return nil
+	}
+
+	meta := enginepb.MVCCMetadata{
+		Txn:       &txn.TxnMeta,
+		Timestamp: txn.WriteTimestamp.ToLegacyTimestamp(),
+	}
+	buf, err := protoutil.Marshal(&meta)
+	if err != nil {
+		return err
+	}
+	return rw.PutSharedLock(key, buf, txn.ID)
+}
+
+// MVCCCheckForSharedLocks returns a WriteIntentError if a transaction other
+// than txn holds a replicated shared lock on the key. It is used by requests
+// that acquire unreplicated exclusive locks, which do not write to the key but
+// must not be granted while a shared lock is held by a different transaction.
+func MVCCCheckForSharedLocks(reader Reader, key roachpb.Key, txn *roachpb.Transaction) error {
+	if txn == nil {
+		return errors.AssertionFailedf("cannot check for shared locks on key %s outside of a transaction", key)
+	}
+	return mvccCheckForSharedLocks(reader, key, txn.WriteTimestamp, txn)
+}
+
+// mvccCheckForSharedLocks returns a WriteIntentError if a transaction other
+// than txn holds a replicated shared lock on the key, which prevents the key
+// from being written to. Inline (non-MVCC) writes, signified by an empty
+// timestamp, never conflict with shared locks.
+func mvccCheckForSharedLocks(
+	reader Reader, key roachpb.Key, timestamp hlc.Timestamp, txn *roachpb.Transaction,
+) error {
+	if timestamp.IsEmpty() || !reader.SharedLocksMayExist() {
+		return nil
+	}
+	var intents []roachpb.Intent
+	if err := lockTableLocksOnKey(reader, key, func(lk LockTableKey, meta *enginepb.MVCCMetadata) error {
+		if lk.Strength != lock.Shared || (txn != nil && meta.Txn.ID == txn.ID) {
+			return nil
+		}
+		intent := roachpb.MakeIntent(meta.Txn, key)
+		intent.Strength = lock.Shared
+		intents = append(intents, intent)
+		return nil
+	}); err != nil {
+		return err
+	}
+	if len(intents) > 0 {
+		return &roachpb.WriteIntentError{Intents: intents}
+	}
+	return nil
+}
+
+// mvccReleaseSharedLocks releases the replicated shared locks held by the
+// transaction in the given span, if the transaction has been finalized. Shared
+// locks held by pending transactions are not affected by the update: they are
+// not associated with a timestamp or a sequence number, so they are retained
+// until the transaction commits or aborts.
+//
+// If the span has no EndKey, only the locks on its Key are considered. At most
+// max locks are released, unless max is zero. Returns the number of locks
+// released.
+func mvccReleaseSharedLocks(rw ReadWriter, update roachpb.LockUpdate, max int64) (int64, error) {
+	if !update.Status.IsFinalized() || !rw.SharedLocksMayExist() {
+		return 0, nil
+	}
+	endKey := update.EndKey
+	if len(endKey) == 0 {
+		endKey = update.Key.Next()
+	}
+	ltStart, _ := keys.LockTableSingleKey(update.Key, nil)
+	ltEnd, _ := keys.LockTableSingleKey(endKey, nil)
+	iter := rw.NewEngineIterator(IterOptions{LowerBound: ltStart, UpperBound: ltEnd})
+	defer iter.Close()
+
+	// Collect the keys before clearing them, so that the iterator does not
+	// need to observe its own writes.
+	var locked []roachpb.Key
+	valid, err := iter.SeekEngineKeyGE(EngineKey{Key: ltStart})
+	for ; valid && (max == 0 || int64(len(locked)) < max); valid, err = iter.NextEngineKey() {
+		engineKey, err := iter.UnsafeEngineKey()
+		if err != nil {
+			return 0, err
+		}
+		if !engineKey.isSharedLockTableKey() {
+			continue
+		}
+		lk, err := engineKey.ToLockTableKey()
+		if err != nil {
+			return 0, err
+		}
+		if !bytes.Equal(lk.TxnUUID, update.Txn.ID.GetBytes()) {
+			continue
+		}
+		locked = append(locked, lk.Key.Clone())
+	}
+	if err != nil {
+		return 0, err
+	}
+	for _, key := range locked {
+		if err := rw.ClearSharedLock(key, update.Txn.ID); err != nil {
+			return 0, err
+		}
+	}
+	return int64(len(locked)), nil
+}
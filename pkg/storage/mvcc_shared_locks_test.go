@@ -0,0 +1,145 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/clusterversion"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/concurrency/lock"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMVCCSharedLocks exercises the acquisition, conflict detection, and
+// release of replicated shared locks.
+func TestMVCCSharedLocks(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	for _, engineImpl := range mvccEngineImpls {
+		t.Run(engineImpl.name, func(t *testing.T) {
+			engine := engineImpl.create()
+			defer engine.Close()
+
+			// Both transactions can hold a shared lock on the same key, and
+			// re-acquiring a held lock is a no-op.
+			require.NoError(t, MVCCAcquireSharedLock(ctx, engine, txn1, testKey1))
+			require.NoError(t, MVCCAcquireSharedLock(ctx, engine, txn2, testKey1))
+			require.NoError(t, MVCCAcquireSharedLock(ctx, engine, txn1, testKey1))
+			require.NoError(t, MVCCAcquireSharedLock(ctx, engine, txn1, testKey2))
+
+			// Reads ignore shared locks.
+			value, intent, err := MVCCGet(ctx, engine, testKey1, txn2.WriteTimestamp, MVCCGetOptions{})
+			require.NoError(t, err)
+			require.Nil(t, value)
+			require.Nil(t, intent)
+
+			// Shared locks are not intents.
+			intents, err := ScanIntents(ctx, engine, testKey1, testKey4, 0, 0)
+			require.NoError(t, err)
+			require.Empty(t, intents)
+
+			// A write by txn2 conflicts with txn1's shared lock, but not with its
+			// own.
+			err = MVCCPut(ctx, engine, nil, testKey1, txn2.WriteTimestamp, value1, txn2)
+			var wiErr *roachpb.WriteIntentError
+			require.True(t, errors.As(err, &wiErr), "unexpected error: %v", err)
+			require.Len(t, wiErr.Intents, 1)
+			require.Equal(t, txn1.ID, wiErr.Intents[0].Txn.ID)
+			require.Equal(t, lock.Shared, wiErr.Intents[0].LockStrength())
+			require.Error(t, MVCCCheckForSharedLocks(engine, testKey1, txn2))
+			require.NoError(t, MVCCCheckForSharedLocks(engine, testKey3, txn2))
+
+			// Once txn1 is finalized and its lock is resolved, txn2 can write.
+			_, err = MVCCResolveWriteIntent(ctx, engine, nil,
+				roachpb.MakeLockUpdate(txn1Commit, roachpb.Span{Key: testKey1}))
+			require.NoError(t, err)
+			require.NoError(t, MVCCPut(ctx, engine, nil, testKey1, txn2.WriteTimestamp, value1, txn2))
+
+			// The intent written by txn2 conflicts with shared lock acquisition by
+			// other transactions.
+			err = MVCCAcquireSharedLock(ctx, engine, txn1, testKey1)
+			require.True(t, errors.As(err, &wiErr), "unexpected error: %v", err)
+			require.Equal(t, txn2.ID, wiErr.Intents[0].Txn.ID)
+
+			// Resolving a pending transaction's locks retains them.
+			_, _, err = MVCCResolveWriteIntentRange(ctx, engine, nil,
+				roachpb.MakeLockUpdate(txn1, roachpb.Span{Key: testKey1, EndKey: testKey4}), 0)
+			require.NoError(t, err)
+			require.Error(t, MVCCCheckForSharedLocks(engine, testKey2, txn2))
+
+			// Ranged resolution of the finalized transaction releases the rest of
+			// its locks.
+			_, _, err = MVCCResolveWriteIntentRange(ctx, engine, nil,
+				roachpb.MakeLockUpdate(txn1Commit, roachpb.Span{Key: testKey1, EndKey: testKey4}), 0)
+			require.NoError(t, err)
+			require.NoError(t, MVCCCheckForSharedLocks(engine, testKey2, txn2))
+		})
+	}
+}
+
+// TestMVCCSharedLocksResolveRangeLimit verifies that the shared locks released
+// by ranged intent resolution count towards its key limit.
+func TestMVCCSharedLocksResolveRangeLimit(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	engine := createTestPebbleEngine()
+	defer engine.Close()
+
+	for _, key := range []roachpb.Key{testKey1, testKey2, testKey3} {
+		require.NoError(t, MVCCAcquireSharedLock(ctx, engine, txn1, key))
+	}
+	span := roachpb.Span{Key: testKey1, EndKey: testKey4}
+
+	num, resumeSpan, err := MVCCResolveWriteIntentRange(ctx, engine, nil,
+		roachpb.MakeLockUpdate(txn1Commit, span), 2 /* max */)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), num)
+	require.Equal(t, &span, resumeSpan)
+	require.NoError(t, MVCCCheckForSharedLocks(engine, testKey2, txn2))
+	require.Error(t, MVCCCheckForSharedLocks(engine, testKey3, txn2))
+
+	num, resumeSpan, err = MVCCResolveWriteIntentRange(ctx, engine, nil,
+		roachpb.MakeLockUpdate(txn1Commit, *resumeSpan), 2 /* max */)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), num)
+	require.Nil(t, resumeSpan)
+	require.NoError(t, MVCCCheckForSharedLocks(engine, testKey3, txn2))
+}
+
+// TestMVCCSharedLocksBeforeVersion verifies that writes do not look for shared
+// locks until the SharedLockConflicts cluster version is active.
+func TestMVCCSharedLocksBeforeVersion(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	v := clusterversion.ByKey(clusterversion.SharedLockConflicts - 1)
+	st := cluster.MakeTestingClusterSettingsWithVersions(v, v, true /* initializeVersion */)
+	engine := createTestPebbleEngine(Settings(st))
+	defer engine.Close()
+	require.False(t, engine.SharedLocksMayExist())
+
+	// Shared locks are not acquired before the SharedLocks version is active,
+	// so the lock written here is not observed by writes.
+	require.NoError(t, MVCCAcquireSharedLock(ctx, engine, txn1, testKey1))
+	require.NoError(t, MVCCCheckForSharedLocks(engine, testKey1, txn2))
+	require.NoError(t, MVCCPut(ctx, engine, nil, testKey1, txn2.WriteTimestamp, value1, txn2))
+}
@@ -751,6 +751,49 @@ type EncryptionEnv struct {
 	FS vfs.FS
 	// StatsHandler exposes encryption-at-rest state for observability.
 	StatsHandler EncryptionStatsHandler
+	// StoreKeyRotator rotates the store key. It is nil if the store keys cannot
+	// be rotated while the store is open, e.g. when they are user-provided.
+	StoreKeyRotator StoreKeyRotator
+	// Reencryptor rewrites the files that are not encrypted with the active
+	// data key. It is nil for read-only environments.
+	Reencryptor Reencryptor
+}
+
+// Reencryptor rewrites the files of an encryption-at-rest environment that are
+// not encrypted with the active data key, e.g. following a key rotation.
+type Reencryptor interface {
+	// Start starts rewriting such files in the background. It returns an error
+	// if they are already being rewritten.
+	Start(ctx context.Context) error
+	// Stop stops the rewrite, if in progress, and waits for it to stop.
+	Stop()
+	// Stats returns the progress of the rewrite.
+	Stats() ReencryptionStats
+}
+
+// ReencryptionStats is the progress of a Reencryptor.
+type ReencryptionStats struct {
+	// Running is true while files are being rewritten.
+	Running bool
+	// RewrittenFiles is the number of files rewritten since the store was
+	// opened.
+	RewrittenFiles uint64
+	// RewrittenBytes is the size of the files rewritten since the store was
+	// opened.
+	RewrittenBytes uint64
+	// RemainingFiles is the number of files which were still not encrypted
+	// with the active data key at the end of the last rewrite, because they
+	// cannot be rewritten while the store is open. These are mostly files
+	// which are appended to, like the MANIFEST, which is replaced by a file
+	// encrypted with the active data key when the store is restarted.
+	RemainingFiles uint64
+}
+
+// StoreKeyRotator rotates the active store key of an encryption-at-rest
+// environment.
+type StoreKeyRotator interface {
+	// RotateStoreKey makes a newly generated store key the active one.
+	RotateStoreKey(ctx context.Context) error
 }
 
 var _ Engine = &Pebble{}
@@ -759,7 +802,7 @@ var _ Engine = &Pebble{}
 // and writing data. This should be initialized by calling engineccl.Init() before calling
 // NewPebble(). The optionBytes is a binary serialized baseccl.EncryptionOptions, so that non-CCL
 // code does not depend on CCL code.
-var NewEncryptedEnvFunc func(fs vfs.FS, fr *PebbleFileRegistry, dbDir string, readOnly bool, optionBytes []byte) (*EncryptionEnv, error)
+var NewEncryptedEnvFunc func(fs vfs.FS, fr *PebbleFileRegistry, dbDir string, readOnly bool, optionBytes []byte, st *cluster.Settings) (*EncryptionEnv, error)
 
 // StoreIDSetter is used to set the store id in the log.
 type StoreIDSetter interface {
@@ -813,6 +856,7 @@ func ResolveEncryptedEnvOptions(cfg *PebbleConfig) (*PebbleFileRegistry, *Encryp
 			cfg.Dir,
 			cfg.Opts.ReadOnly,
 			cfg.EncryptionOptions,
+			cfg.Settings,
 		)
 		if err != nil {
 			return nil, nil, err
@@ -1023,6 +1067,9 @@ func (p *Pebble) Close() {
 		return
 	}
 	p.closed = true
+	if p.encryption != nil && p.encryption.Reencryptor != nil {
+		p.encryption.Reencryptor.Stop()
+	}
 	_ = p.db.Close()
 	if p.fileRegistry != nil {
 		_ = p.fileRegistry.Close()
@@ -1142,6 +1189,11 @@ func (p *Pebble) PinEngineStateForIterators() error {
 		"PinEngineStateForIterators must not be called when ConsistentIterators returns false")
 }
 
+// SharedLocksMayExist implements the Reader interface.
+func (p *Pebble) SharedLocksMayExist() bool {
+	return sharedLocksMayExist(p.settings)
+}
+
 // ApplyBatchRepr implements the Engine interface.
 func (p *Pebble) ApplyBatchRepr(repr []byte, sync bool) error {
 	// batch.SetRepr takes ownership of the underlying slice, so make a copy.
@@ -1179,6 +1231,12 @@ func (p *Pebble) ClearIntent(key roachpb.Key, txnDidNotUpdateMeta bool, txnUUID
 	return err
 }
 
+// ClearSharedLock implements the Engine interface.
+func (p *Pebble) ClearSharedLock(key roachpb.Key, txnUUID uuid.UUID) error {
+	_, err := p.wrappedIntentWriter.ClearSharedLock(key, txnUUID, nil)
+	return err
+}
+
 // ClearEngineKey implements the Engine interface.
 func (p *Pebble) ClearEngineKey(key EngineKey) error {
 	if len(key.Key) == 0 {
@@ -1266,6 +1324,12 @@ func (p *Pebble) PutIntent(
 	return err
 }
 
+// PutSharedLock implements the Engine interface.
+func (p *Pebble) PutSharedLock(key roachpb.Key, value []byte, txnUUID uuid.UUID) error {
+	_, err := p.wrappedIntentWriter.PutSharedLock(key, value, txnUUID, nil)
+	return err
+}
+
 // PutEngineKey implements the Engine interface.
 func (p *Pebble) PutEngineKey(key EngineKey, value []byte) error {
 	if len(key.Key) == 0 {
@@ -1467,6 +1531,9 @@ func (p *Pebble) GetEnvStats() (*EnvStats, error) {
 	if err != nil {
 		return nil, err
 	}
+	if p.encryption.Reencryptor != nil {
+		stats.Reencryption = p.encryption.Reencryptor.Stats()
+	}
 	fr := p.fileRegistry.getRegistryCopy()
 	activeKeyID, err := p.encryption.StatsHandler.GetActiveDataKeyID()
 	if err != nil {
@@ -1520,6 +1587,33 @@ func (p *Pebble) GetEnvStats() (*EnvStats, error) {
 	return stats, nil
 }
 
+// RotateStoreKey implements the Engine interface.
+func (p *Pebble) RotateStoreKey(ctx context.Context) error {
+	if p.encryption == nil {
+		return errors.New("encryption-at-rest is not enabled for this store")
+	}
+	if p.encryption.StoreKeyRotator == nil {
+		return errors.New("store keys can only be rotated while the store is open when they are managed by a KMS")
+	}
+	return p.encryption.StoreKeyRotator.RotateStoreKey(ctx)
+}
+
+// StartReencryption implements the Engine interface.
+func (p *Pebble) StartReencryption(ctx context.Context) error {
+	if p.encryption == nil {
+		return errors.New("encryption-at-rest is not enabled for this store")
+	}
+	if p.encryption.Reencryptor == nil {
+		return errors.New("files cannot be re-encrypted while the store is read-only")
+	}
+	// Flushing switches to a new WAL, created with the active data key, so that
+	// the older WALs get deleted or recycled.
+	if err := p.db.Flush(); err != nil {
+		return err
+	}
+	return p.encryption.Reencryptor.Start(ctx)
+}
+
 // GetAuxiliaryDir implements the Engine interface.
 func (p *Pebble) GetAuxiliaryDir() string {
 	return p.auxDir
@@ -1527,7 +1621,7 @@ func (p *Pebble) GetAuxiliaryDir() string {
 
 // NewBatch implements the Engine interface.
 func (p *Pebble) NewBatch() Batch {
-	return newPebbleBatch(p.db, p.db.NewIndexedBatch(), false /* writeOnly */)
+	return newPebbleBatch(p.db, p.db.NewIndexedBatch(), false /* writeOnly */, p.settings)
 }
 
 // NewReadOnly implements the Engine interface.
@@ -1537,7 +1631,7 @@ func (p *Pebble) NewReadOnly(durability DurabilityRequirement) ReadWriter {
 
 // NewUnindexedBatch implements the Engine interface.
 func (p *Pebble) NewUnindexedBatch(writeOnly bool) Batch {
-	return newPebbleBatch(p.db, p.db.NewBatch(), writeOnly)
+	return newPebbleBatch(p.db, p.db.NewBatch(), writeOnly, p.settings)
 }
 
 // NewSnapshot implements the Engine interface.
@@ -2021,6 +2115,11 @@ func (p *pebbleReadOnly) PinEngineStateForIterators() error {
 	return nil
 }
 
+// SharedLocksMayExist implements the Reader interface.
+func (p *pebbleReadOnly) SharedLocksMayExist() bool {
+	return sharedLocksMayExist(p.parent.settings)
+}
+
 // Writer methods are not implemented for pebbleReadOnly. Ideally, the code
 // could be refactored so that a Reader could be supplied to evaluateBatch
 
@@ -2043,6 +2142,10 @@ func (p *pebbleReadOnly) ClearIntent(
 	panic("not implemented")
 }
 
+func (p *pebbleReadOnly) ClearSharedLock(key roachpb.Key, txnUUID uuid.UUID) error {
+	panic("not implemented")
+}
+
 func (p *pebbleReadOnly) ClearEngineKey(key EngineKey) error {
 	panic("not implemented")
 }
@@ -2085,6 +2188,10 @@ func (p *pebbleReadOnly) PutIntent(
 	panic("not implemented")
 }
 
+func (p *pebbleReadOnly) PutSharedLock(key roachpb.Key, value []byte, txnUUID uuid.UUID) error {
+	panic("not implemented")
+}
+
 func (p *pebbleReadOnly) PutEngineKey(key EngineKey, value []byte) error {
 	panic("not implemented")
 }
@@ -2210,6 +2317,11 @@ func (p *pebbleSnapshot) PinEngineStateForIterators() error {
 	return nil
 }
 
+// SharedLocksMayExist implements the Reader interface.
+func (p *pebbleSnapshot) SharedLocksMayExist() bool {
+	return sharedLocksMayExist(p.settings)
+}
+
 // pebbleGetProto uses Reader.MVCCGet, so it not as efficient as a function
 // that can unmarshal without copying bytes. But we don't care about
 // efficiency, since this is used to implement Reader.MVCCGetProto, which is
@@ -16,6 +16,7 @@ import (
 	"sync"
 
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/util"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
@@ -56,6 +57,10 @@ type pebbleBatch struct {
 	wrappedIntentWriter intentDemuxWriter
 	// scratch space for wrappedIntentWriter.
 	scratch []byte
+
+	// settings are the settings of the engine, used to determine whether
+	// replicated shared locks may exist. They can be nil in tests.
+	settings *cluster.Settings
 }
 
 var _ Batch = &pebbleBatch{}
@@ -67,7 +72,9 @@ var pebbleBatchPool = sync.Pool{
 }
 
 // Instantiates a new pebbleBatch.
-func newPebbleBatch(db *pebble.DB, batch *pebble.Batch, writeOnly bool) *pebbleBatch {
+func newPebbleBatch(
+	db *pebble.DB, batch *pebble.Batch, writeOnly bool, settings *cluster.Settings,
+) *pebbleBatch {
 	pb := pebbleBatchPool.Get().(*pebbleBatch)
 	*pb = pebbleBatch{
 		db:    db,
@@ -94,6 +101,7 @@ func newPebbleBatch(db *pebble.DB, batch *pebble.Batch, writeOnly bool) *pebbleB
 			reusable:      true,
 		},
 		writeOnly: writeOnly,
+		settings:  settings,
 	}
 	pb.wrappedIntentWriter = wrapIntentWriter(context.Background(), pb)
 	return pb
@@ -317,6 +325,11 @@ func (p *pebbleBatch) PinEngineStateForIterators() error {
 	return nil
 }
 
+// SharedLocksMayExist implements the Batch interface.
+func (p *pebbleBatch) SharedLocksMayExist() bool {
+	return sharedLocksMayExist(p.settings)
+}
+
 // NewMVCCIterator implements the Batch interface.
 func (p *pebbleBatch) ApplyBatchRepr(repr []byte, sync bool) error {
 	var batch pebble.Batch
@@ -349,6 +362,13 @@ func (p *pebbleBatch) ClearIntent(
 	return err
 }
 
+// ClearSharedLock implements the Batch interface.
+func (p *pebbleBatch) ClearSharedLock(key roachpb.Key, txnUUID uuid.UUID) error {
+	var err error
+	p.scratch, err = p.wrappedIntentWriter.ClearSharedLock(key, txnUUID, p.scratch)
+	return err
+}
+
 // ClearEngineKey implements the Batch interface.
 func (p *pebbleBatch) ClearEngineKey(key EngineKey) error {
 	if len(key.Key) == 0 {
@@ -459,6 +479,13 @@ func (p *pebbleBatch) PutIntent(
 	return err
 }
 
+// PutSharedLock implements the Batch interface.
+func (p *pebbleBatch) PutSharedLock(key roachpb.Key, value []byte, txnUUID uuid.UUID) error {
+	var err error
+	p.scratch, err = p.wrappedIntentWriter.PutSharedLock(key, value, txnUUID, p.scratch)
+	return err
+}
+
 // PutEngineKey implements the Batch interface.
 func (p *pebbleBatch) PutEngineKey(key EngineKey, value []byte) error {
 	if len(key.Key) == 0 {
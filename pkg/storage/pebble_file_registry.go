@@ -265,6 +265,18 @@ func (r *PebbleFileRegistry) GetFileEntry(filename string) *enginepb.FileEntry {
 	return r.mu.entries[filename]
 }
 
+// ListFiles returns the names of the files that have an entry in the registry.
+// The names of the files in the DB directory are relative to it.
+func (r *PebbleFileRegistry) ListFiles() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	names := make([]string, 0, len(r.mu.entries))
+	for name := range r.mu.entries {
+		names = append(names, name)
+	}
+	return names
+}
+
 // SetFileEntry sets filename => entry in the registry map and persists the registry.
 // It should not be called for entries corresponding to unencrypted files since the
 // absence of a file in the file registry implies that it is unencrypted.
@@ -190,6 +190,11 @@ func (fw *SSTWriter) PutIntent(
 	return fw.put(MVCCKey{Key: key}, value)
 }
 
+// PutSharedLock implements the Writer interface.
+func (fw *SSTWriter) PutSharedLock(key roachpb.Key, value []byte, txnUUID uuid.UUID) error {
+	panic("PutSharedLock is unsupported")
+}
+
 // PutEngineKey implements the Writer interface.
 // An error is returned if it is not greater than any previously added entry
 // (according to the comparator configured during writer creation). `Close`
@@ -249,6 +254,11 @@ func (fw *SSTWriter) ClearIntent(
 	panic("ClearIntent is unsupported")
 }
 
+// ClearSharedLock implements the Writer interface.
+func (fw *SSTWriter) ClearSharedLock(key roachpb.Key, txnUUID uuid.UUID) error {
+	panic("ClearSharedLock is unsupported")
+}
+
 // ClearEngineKey implements the Writer interface. An error is returned if it is
 // not greater than any previous point key passed to this Writer (according to
 // the comparator configured during writer creation). `Close` cannot have been
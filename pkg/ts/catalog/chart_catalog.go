@@ -2631,6 +2631,36 @@ var charts = []sectionDescription{
 				Title:   "Algorithm Enum",
 				Metrics: []string{"rocksdb.encryption.algorithm"},
 			},
+			{
+				Title: "Files",
+				Metrics: []string{
+					"rocksdb.encryption.active-key-files",
+					"rocksdb.encryption.total-files",
+				},
+			},
+			{
+				Title: "Size",
+				Metrics: []string{
+					"rocksdb.encryption.active-key-bytes",
+					"rocksdb.encryption.total-bytes",
+				},
+			},
+			{
+				Title:   "Re-encryption Running",
+				Metrics: []string{"rocksdb.encryption.reencryption.running"},
+			},
+			{
+				Title:   "Re-encrypted Files",
+				Metrics: []string{"rocksdb.encryption.reencryption.rewritten-files"},
+			},
+			{
+				Title:   "Re-encrypted Size",
+				Metrics: []string{"rocksdb.encryption.reencryption.rewritten-bytes"},
+			},
+			{
+				Title:   "Files Left To Re-encrypt",
+				Metrics: []string{"rocksdb.encryption.reencryption.remaining-files"},
+			},
 		},
 	},
 	{
@@ -2913,6 +2943,7 @@ var charts = []sectionDescription{
 					"jobs.auto_span_config_reconciliation.currently_running",
 					"jobs.auto_sql_stats_compaction.currently_running",
 					"jobs.stream_replication.currently_running",
+					"jobs.backup_validation.currently_running",
 				},
 			},
 			{
@@ -2922,6 +2953,7 @@ var charts = []sectionDescription{
 					"jobs.auto_span_config_reconciliation.currently_idle",
 					"jobs.auto_sql_stats_compaction.currently_idle",
 					"jobs.backup.currently_idle",
+					"jobs.backup_validation.currently_idle",
 					"jobs.changefeed.currently_idle",
 					"jobs.create_stats.currently_idle",
 					"jobs.import.currently_idle",
@@ -2959,6 +2991,18 @@ var charts = []sectionDescription{
 				},
 				Rate: DescribeDerivative_NON_NEGATIVE_DERIVATIVE,
 			},
+			{
+				Title: "Backup Validation",
+				Metrics: []string{
+					"jobs.backup_validation.fail_or_cancel_completed",
+					"jobs.backup_validation.fail_or_cancel_failed",
+					"jobs.backup_validation.fail_or_cancel_retry_error",
+					"jobs.backup_validation.resume_completed",
+					"jobs.backup_validation.resume_failed",
+					"jobs.backup_validation.resume_retry_error",
+				},
+				Rate: DescribeDerivative_NON_NEGATIVE_DERIVATIVE,
+			},
 			{
 				Title: "Changefeed",
 				Metrics: []string{
@@ -3137,6 +3181,9 @@ var charts = []sectionDescription{
 					"admission.requested.kv-stores",
 					"admission.admitted.kv-stores",
 					"admission.errored.kv-stores",
+					"admission.requested.elastic-stores",
+					"admission.admitted.elastic-stores",
+					"admission.errored.elastic-stores",
 					"admission.requested.sql-kv-response",
 					"admission.admitted.sql-kv-response",
 					"admission.errored.sql-kv-response",
@@ -3156,6 +3203,7 @@ var charts = []sectionDescription{
 				Metrics: []string{
 					"admission.wait_queue_length.kv",
 					"admission.wait_queue_length.kv-stores",
+					"admission.wait_queue_length.elastic-stores",
 					"admission.wait_queue_length.sql-kv-response",
 					"admission.wait_queue_length.sql-sql-response",
 					"admission.wait_queue_length.sql-leaf-start",
@@ -3167,6 +3215,7 @@ var charts = []sectionDescription{
 				Metrics: []string{
 					"admission.wait_sum.kv",
 					"admission.wait_sum.kv-stores",
+					"admission.wait_sum.elastic-stores",
 					"admission.wait_sum.sql-kv-response",
 					"admission.wait_sum.sql-sql-response",
 					"admission.wait_sum.sql-leaf-start",
@@ -3178,6 +3227,7 @@ var charts = []sectionDescription{
 				Metrics: []string{
 					"admission.wait_durations.kv",
 					"admission.wait_durations.kv-stores",
+					"admission.wait_durations.elastic-stores",
 					"admission.wait_durations.sql-kv-response",
 					"admission.wait_durations.sql-sql-response",
 					"admission.wait_durations.sql-leaf-start",
@@ -3199,6 +3249,12 @@ var charts = []sectionDescription{
 					"admission.granter.io_tokens_exhausted_duration.kv",
 				},
 			},
+			{
+				Title: "Elastic IO Tokens Exhausted Duration Sum",
+				Metrics: []string{
+					"admission.granter.elastic_io_tokens_exhausted_duration.kv",
+				},
+			},
 		},
 	},
 }
@@ -0,0 +1,212 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package admission
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/redact"
+)
+
+// The disk bandwidth of a store is a resource that is shared by foreground
+// writes, the background work of the LSM (flushes and compactions), reads,
+// and elastic work like backfills, IMPORTs and other bulk ingestions. Unlike
+// the health of L0 (see ioLoadListener), which degrades slowly and where
+// throttling all KVWork is the right response, saturating the provisioned
+// disk bandwidth degrades the latency of all the work on the store
+// immediately, and the best response is to throttle the work that can
+// tolerate it, i.e., admissionpb.ElasticWorkClass.
+//
+// The diskBandwidthLimiter computes tokens for elastic work every
+// adjustmentInterval, which are given out in addition to the IO tokens (see
+// kvStoreTokenGranter). The inputs are:
+// - The bytes read and written by the disk used by the store (see
+//   DiskStats), which are compared to the provisioned bandwidth of the disk.
+//   Provisioned bandwidth is common in cloud block storage (e.g. EBS gp3
+//   volumes, GCP persistent disks), and needs to be configured using the
+//   admission.kv.store.provisioned_bandwidth setting, since it is not
+//   discoverable.
+// - The bytes added to the LSM, via flushes and ingestions. Every byte added
+//   to the LSM results in multiple bytes written to disk, due to the WAL,
+//   flushes and compactions. This write amplification, which is estimated as
+//   the ratio of the bytes written to the disk and the bytes added to the
+//   LSM, translates the disk bandwidth into bytes that can be added to the
+//   LSM.
+// - The tokens used by regular and elastic work.
+//
+// The elastic tokens for the next interval are the bytes that can be added to
+// the LSM without the disk bandwidth utilization exceeding
+// admission.kv.store.elastic_disk_bandwidth_max_util, after taking out what
+// is expected to be added by work other than elastic work. Elastic work is
+// thus throttled before the disk is saturated, while regular work is never
+// throttled by the diskBandwidthLimiter.
+//
+// Known limitations:
+// - The disk is assumed to not be shared with other stores. Some usage by
+//   other processes is tolerable, since it is accounted for as if it was
+//   regular work, which reduces the elastic tokens.
+// - Tokens are not in the same units as the bytes added to the LSM: writes
+//   are admitted with their uncompressed size, and ingestions with the
+//   estimated bytes that will be ingested into L0 (see StoreWorkQueue), while
+//   flushed and ingested bytes are compressed. This inaccuracy is partially
+//   compensated by attributing the bytes added to the LSM that are not
+//   accounted for by elastic tokens to non-elastic work.
+// - A burst of reads, for example due to a large scan, can only be reacted to
+//   in the next interval.
+
+// provisionedBandwidth is the provisioned disk bandwidth of each store.
+var provisionedBandwidth = settings.RegisterByteSizeSetting(
+	settings.SystemOnly,
+	"admission.kv.store.provisioned_bandwidth",
+	"if set to a non-zero value, this is the provisioned disk bandwidth of each store (in "+
+		"bytes/s), and elastic work (like backfills and IMPORTs) is throttled when the disk "+
+		"bandwidth utilization approaches it",
+	0, settings.NonNegativeInt)
+
+// elasticDiskBandwidthMaxUtil is the disk bandwidth utilization up to which
+// elastic work can be admitted.
+var elasticDiskBandwidthMaxUtil = settings.RegisterFloatSetting(
+	settings.SystemOnly,
+	"admission.kv.store.elastic_disk_bandwidth_max_util",
+	"the maximum disk bandwidth utilization, as a fraction of "+
+		"admission.kv.store.provisioned_bandwidth, up to which elastic work is admitted",
+	0.8,
+	func(v float64) error {
+		if v <= 0 || v > 1 {
+			return errors.Errorf("%f is not in (0, 1]", v)
+		}
+		return nil
+	})
+
+// DiskStats are the cumulative stats of the disk (block device) used by a
+// store, as reported by the operating system. See GetDiskStats. The zero
+// value represents stats that are unavailable.
+type DiskStats struct {
+	// BytesRead is the cumulative number of bytes read from the disk.
+	BytesRead uint64
+	// BytesWritten is the cumulative number of bytes written to the disk.
+	BytesWritten uint64
+}
+
+// intervalDiskLoadInfo provides disk stats over an adjustmentInterval.
+type intervalDiskLoadInfo struct {
+	// readBytes and writeBytes are the bytes read from and written to the disk
+	// during the interval.
+	readBytes  int64
+	writeBytes int64
+	// provisionedBandwidth is the provisioned disk bandwidth in bytes/s. 0
+	// means that the disk bandwidth should not be used for admission control.
+	provisionedBandwidth int64
+	// elasticBandwidthMaxUtil is the disk bandwidth utilization up to which
+	// elastic work can be admitted.
+	elasticBandwidthMaxUtil float64
+}
+
+// intervalLSMInfo provides stats about the LSM and the usage of tokens over an
+// adjustmentInterval.
+type intervalLSMInfo struct {
+	// incomingBytes are the bytes added to the LSM, by flushes into L0 and
+	// ingestions into any level.
+	incomingBytes int64
+	// regularTokensUsed and elasticTokensUsed are the tokens used by the two
+	// admissionpb.WorkClasses.
+	regularTokensUsed int64
+	elasticTokensUsed int64
+}
+
+// diskBandwidthLimiter computes the tokens for elastic work based on the disk
+// bandwidth utilization of a store. See the comment at the top of this file.
+type diskBandwidthLimiter struct {
+	// Exponentially smoothed per interval values.
+	smoothedReadBytes float64
+	// smoothedWriteAmp is 0 until bytes have been added to the LSM.
+	smoothedWriteAmp float64
+	// smoothedNonElasticBytes are the bytes added to the LSM that were not
+	// accounted for by elastic tokens.
+	smoothedNonElasticBytes float64
+
+	// State of the last call to computeElasticTokens, for logging.
+	utilization   float64
+	elasticTokens int64
+}
+
+// computeElasticTokens is called every adjustmentInterval, and returns the
+// elastic tokens for the next interval.
+func (d *diskBandwidthLimiter) computeElasticTokens(
+	id intervalDiskLoadInfo, il intervalLSMInfo,
+) int64 {
+	const alpha = 0.5
+	d.smoothedReadBytes = alpha*float64(id.readBytes) + (1-alpha)*d.smoothedReadBytes
+	if il.incomingBytes > 0 {
+		// The disk stats and the LSM stats are not sampled at the same instant,
+		// so don't let the write amplification drop below 1.
+		writeAmp := math.Max(1, float64(id.writeBytes)/float64(il.incomingBytes))
+		if d.smoothedWriteAmp == 0 {
+			d.smoothedWriteAmp = writeAmp
+		} else {
+			d.smoothedWriteAmp = alpha*writeAmp + (1-alpha)*d.smoothedWriteAmp
+		}
+	}
+	nonElasticBytes := il.incomingBytes - il.elasticTokensUsed
+	if nonElasticBytes < 0 {
+		nonElasticBytes = 0
+	}
+	d.smoothedNonElasticBytes = alpha*float64(nonElasticBytes) + (1-alpha)*d.smoothedNonElasticBytes
+
+	d.utilization = 0
+	if id.provisionedBandwidth <= 0 || d.smoothedWriteAmp == 0 {
+		// The disk bandwidth is not used for admission control, or nothing has
+		// been added to the LSM so far, so there is no basis for throttling.
+		d.elasticTokens = unlimitedTokens
+		return d.elasticTokens
+	}
+	intervalBandwidth := float64(id.provisionedBandwidth) * adjustmentInterval
+	d.utilization = (float64(id.readBytes) + float64(id.writeBytes)) / intervalBandwidth
+	// The bytes that can be written to the disk in the next interval without
+	// exceeding the maximum utilization for elastic work, assuming that reads
+	// continue at their current rate.
+	writeBudget := id.elasticBandwidthMaxUtil*intervalBandwidth - d.smoothedReadBytes
+	// Translate that into bytes added to the LSM, and leave what is expected to
+	// be added by non-elastic work.
+	elasticTokens := writeBudget/d.smoothedWriteAmp - d.smoothedNonElasticBytes
+	if elasticTokens < 0 {
+		elasticTokens = 0
+	}
+	if elasticTokens >= float64(unlimitedTokens) {
+		d.elasticTokens = unlimitedTokens
+	} else {
+		d.elasticTokens = int64(elasticTokens)
+	}
+	return d.elasticTokens
+}
+
+func (d *diskBandwidthLimiter) String() string {
+	return redact.StringWithoutMarkers(d)
+}
+
+// SafeFormat implements the redact.SafeFormatter interface.
+func (d *diskBandwidthLimiter) SafeFormat(s redact.SafePrinter, verb rune) {
+	s.Printf("util: %.2f, smoothed: read-bytes: %d, write-amp: %.2f, non-elastic-bytes: %d, "+
+		"elastic-tokens: %s", d.utilization, int64(d.smoothedReadBytes), d.smoothedWriteAmp,
+		int64(d.smoothedNonElasticBytes), redact.SafeString(tokensToString(d.elasticTokens)))
+}
+
+// tokensToString returns a string representation of tokens for an
+// adjustmentInterval.
+func tokensToString(tokens int64) string {
+	if tokens == unlimitedTokens {
+		return "unlimited"
+	}
+	return fmt.Sprintf("%d", tokens)
+}
@@ -0,0 +1,100 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package admission
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/datadriven"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDiskBandwidthLimiter is a datadriven test with the following commands:
+//
+// init
+// compute read=<int> write=<int> provisioned=<int> incoming=<int>
+//   regular-used=<int> elastic-used=<int> [max-util=<float>]
+//
+// The values passed to compute are for an adjustmentInterval, except for
+// provisioned, which is in bytes/s.
+func TestDiskBandwidthLimiter(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	var dbl diskBandwidthLimiter
+	datadriven.RunTest(t, testutils.TestDataPath(t, "disk_bandwidth_limiter"),
+		func(t *testing.T, d *datadriven.TestData) string {
+			switch d.Cmd {
+			case "init":
+				dbl = diskBandwidthLimiter{}
+				return ""
+
+			case "compute":
+				scanInt64 := func(key string) int64 {
+					var v int
+					d.ScanArgs(t, key, &v)
+					return int64(v)
+				}
+				id := intervalDiskLoadInfo{
+					readBytes:               scanInt64("read"),
+					writeBytes:              scanInt64("write"),
+					provisionedBandwidth:    scanInt64("provisioned"),
+					elasticBandwidthMaxUtil: 0.8,
+				}
+				if d.HasArg("max-util") {
+					var maxUtilStr string
+					d.ScanArgs(t, "max-util", &maxUtilStr)
+					maxUtil, err := strconv.ParseFloat(maxUtilStr, 64)
+					require.NoError(t, err)
+					id.elasticBandwidthMaxUtil = maxUtil
+				}
+				il := intervalLSMInfo{
+					incomingBytes:     scanInt64("incoming"),
+					regularTokensUsed: scanInt64("regular-used"),
+					elasticTokensUsed: scanInt64("elastic-used"),
+				}
+				dbl.computeElasticTokens(id, il)
+				return dbl.String()
+
+			default:
+				return "unknown command: " + d.Cmd
+			}
+		})
+}
+
+func TestParseDiskStats(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	const diskStats = `
+   7       0 loop0 49 0 2200 12 0 0 0 0 0 40 12 0 0 0 0 0 0
+ 259       0 nvme0n1 10 20 30 40 50 60 70 80 0 90 100
+ 259       1 nvme0n1p1 1000 0 4000 100 2000 0 8000 200 0 300 300
+   8      16 sdb 5 0 6
+`
+	stats, err := parseDiskStats(strings.NewReader(diskStats), 259, 1)
+	require.NoError(t, err)
+	require.Equal(t, DiskStats{BytesRead: 4000 * 512, BytesWritten: 8000 * 512}, stats)
+
+	stats, err = parseDiskStats(strings.NewReader(diskStats), 259, 0)
+	require.NoError(t, err)
+	require.Equal(t, DiskStats{BytesRead: 30 * 512, BytesWritten: 70 * 512}, stats)
+
+	// Devices that are missing, or have too few fields, are not found.
+	_, err = parseDiskStats(strings.NewReader(diskStats), 8, 16)
+	require.Error(t, err)
+	_, err = parseDiskStats(strings.NewReader(diskStats), 8, 0)
+	require.Error(t, err)
+}
@@ -0,0 +1,62 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package admission
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+)
+
+// diskStatsSectorSize is the unit of the sector counts in /proc/diskstats,
+// regardless of the actual sector size of the device.
+const diskStatsSectorSize = 512
+
+// parseDiskStats parses the contents of /proc/diskstats, and returns the
+// stats of the device with the given major and minor numbers. Each line
+// describes a device, with the fields (see
+// https://www.kernel.org/doc/Documentation/ABI/testing/procfs-diskstats):
+//
+//   major minor name reads-completed reads-merged sectors-read time-reading
+//   writes-completed writes-merged sectors-written ...
+//
+func parseDiskStats(r io.Reader, major, minor uint32) (DiskStats, error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 {
+			continue
+		}
+		if fields[0] != strconv.FormatUint(uint64(major), 10) ||
+			fields[1] != strconv.FormatUint(uint64(minor), 10) {
+			continue
+		}
+		sectorsRead, err := strconv.ParseUint(fields[5], 10, 64)
+		if err != nil {
+			return DiskStats{}, errors.Wrapf(err, "parsing sectors read of %s", fields[2])
+		}
+		sectorsWritten, err := strconv.ParseUint(fields[9], 10, 64)
+		if err != nil {
+			return DiskStats{}, errors.Wrapf(err, "parsing sectors written of %s", fields[2])
+		}
+		return DiskStats{
+			BytesRead:    sectorsRead * diskStatsSectorSize,
+			BytesWritten: sectorsWritten * diskStatsSectorSize,
+		}, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return DiskStats{}, err
+	}
+	return DiskStats{}, errors.Errorf("device %d:%d not found in disk stats", major, minor)
+}
@@ -0,0 +1,40 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+//go:build linux
+// +build linux
+
+package admission
+
+import (
+	"os"
+
+	"github.com/cockroachdb/errors"
+	"golang.org/x/sys/unix"
+)
+
+// GetDiskStats returns the cumulative stats of the disk that holds the given
+// path, which is typically the directory of a store. The disk is the device
+// (or partition) that the file system of the path is mounted on, as reported
+// in /proc/diskstats.
+func GetDiskStats(path string) (DiskStats, error) {
+	var st unix.Stat_t
+	if err := unix.Stat(path, &st); err != nil {
+		return DiskStats{}, errors.Wrapf(err, "stat %s", path)
+	}
+	// The type of Stat_t.Dev differs across architectures.
+	dev := uint64(st.Dev) // nolint:unconvert
+	f, err := os.Open("/proc/diskstats")
+	if err != nil {
+		return DiskStats{}, err
+	}
+	defer f.Close()
+	return parseDiskStats(f, unix.Major(dev), unix.Minor(dev))
+}
@@ -0,0 +1,22 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+//go:build !linux
+// +build !linux
+
+package admission
+
+import "github.com/cockroachdb/errors"
+
+// GetDiskStats returns the cumulative stats of the disk that holds the given
+// path. It is only supported on Linux.
+func GetDiskStats(path string) (DiskStats, error) {
+	return DiskStats{}, errors.New("disk stats are only supported on linux")
+}
@@ -122,6 +122,8 @@
 
 // Additionally, each store has a single StoreWorkQueue and GrantCoordinator
 // for writes. See kvStoreTokenGranter and how its tokens are dynamically
-// adjusted based on Pebble metrics.
+// adjusted based on Pebble metrics. Elastic work (admissionpb.ElasticWorkClass)
+// is additionally throttled based on the disk bandwidth utilization of the
+// store, see diskBandwidthLimiter.
 
 package admission
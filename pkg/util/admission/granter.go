@@ -19,6 +19,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/base"
 	"github.com/cockroachdb/cockroach/pkg/settings"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/util/admission/admissionpb"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/metric"
 	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
@@ -80,6 +81,10 @@ type requester interface {
 	// were used.
 	// REQUIRES: count <= 1 for slots.
 	granted(grantChainID grantChainID) int64
+	requesterClose
+}
+
+type requesterClose interface {
 	close()
 }
 
@@ -313,26 +318,39 @@ const (
 	grantFailLocal
 )
 
-// granterWithLockedCalls is an extension of the granter and requester
-// interfaces that is used as an internal implementation detail of the
-// GrantCoordinator. Note that an implementer of granterWithLockedCalls is
-// mainly passing things through to the GrantCoordinator where the main logic
-// lives. The *Locked() methods are where the differences in slots and tokens
-// are handled.
+// granterWithLockedCalls is an encapsulation of typically one
+// granter-requester pair, and for kvStoreTokenGranter of two
+// granter-requester pairs (one for each admissionpb.WorkClass). It is used as
+// an internal implementation detail of the GrantCoordinator. An implementer
+// of granterWithLockedCalls responds to calls from its granter(s) by calling
+// into the GrantCoordinator, which then calls the various *Locked() methods.
+// The demuxHandle is meant to be opaque to the GrantCoordinator, and is used
+// when this interface encapsulates multiple granter-requester pairs -- it is
+// currently used only by kvStoreTokenGranter, where it is an
+// admissionpb.WorkClass. The *Locked() methods are where the differences in
+// slots and various kinds of tokens are handled.
 type granterWithLockedCalls interface {
-	granter
-	// tryGetLocked is the real implementation of tryGet in the granter interface.
-	// Additionally, it is also used when continuing a grant chain.
-	tryGetLocked(count int64) grantResult
-	// returnGrantLocked is the real implementation of returnGrant.
-	returnGrantLocked(count int64)
+	// tryGetLocked is the real implementation of tryGet from the granter
+	// interface. demuxHandle is an opaque handle that was passed into the
+	// GrantCoordinator.
+	tryGetLocked(count int64, demuxHandle int8) grantResult
+	// returnGrantLocked is the real implementation of returnGrant from the
+	// granter interface. demuxHandle is an opaque handle that was passed into
+	// the GrantCoordinator.
+	returnGrantLocked(count int64, demuxHandle int8)
 	// tookWithoutPermissionLocked is the real implementation of
-	// tookWithoutPermission.
-	tookWithoutPermissionLocked(count int64)
+	// tookWithoutPermission from the granter interface. demuxHandle is an
+	// opaque handle that was passed into the GrantCoordinator.
+	tookWithoutPermissionLocked(count int64, demuxHandle int8)
 
-	// getPairedRequester returns the requester implementation that this granter
-	// interacts with.
-	getPairedRequester() requester
+	// The following methods are for direct use by GrantCoordinator.
+
+	// requesterHasWaitingRequests returns whether some requester associated
+	// with the granter has waiting requests.
+	requesterHasWaitingRequests() bool
+	// tryGrantLocked is used to attempt to grant to some waiting requester.
+	// It returns grantSuccess iff a requester accepted the grant.
+	tryGrantLocked(grantChainID grantChainID) grantResult
 }
 
 // slotGranter implements granterWithLockedCalls.
@@ -353,20 +371,17 @@ type slotGranter struct {
 }
 
 var _ granterWithLockedCalls = &slotGranter{}
-
-func (sg *slotGranter) getPairedRequester() requester {
-	return sg.requester
-}
+var _ granter = &slotGranter{}
 
 func (sg *slotGranter) grantKind() grantKind {
 	return slot
 }
 
 func (sg *slotGranter) tryGet(count int64) bool {
-	return sg.coord.tryGet(sg.workKind, count)
+	return sg.coord.tryGet(sg.workKind, count, 0 /*arbitrary*/)
 }
 
-func (sg *slotGranter) tryGetLocked(count int64) grantResult {
+func (sg *slotGranter) tryGetLocked(count int64, _ int8) grantResult {
 	if count != 1 {
 		panic(errors.AssertionFailedf("unexpected count: %d", count))
 	}
@@ -385,10 +400,10 @@ func (sg *slotGranter) tryGetLocked(count int64) grantResult {
 }
 
 func (sg *slotGranter) returnGrant(count int64) {
-	sg.coord.returnGrant(sg.workKind, count)
+	sg.coord.returnGrant(sg.workKind, count, 0 /*arbitrary*/)
 }
 
-func (sg *slotGranter) returnGrantLocked(count int64) {
+func (sg *slotGranter) returnGrantLocked(count int64, _ int8) {
 	if count != 1 {
 		panic(errors.AssertionFailedf("unexpected count: %d", count))
 	}
@@ -400,10 +415,10 @@ func (sg *slotGranter) returnGrantLocked(count int64) {
 }
 
 func (sg *slotGranter) tookWithoutPermission(count int64) {
-	sg.coord.tookWithoutPermission(sg.workKind, count)
+	sg.coord.tookWithoutPermission(sg.workKind, count, 0 /*arbitrary*/)
 }
 
-func (sg *slotGranter) tookWithoutPermissionLocked(count int64) {
+func (sg *slotGranter) tookWithoutPermissionLocked(count int64, _ int8) {
 	if count != 1 {
 		panic(errors.AssertionFailedf("unexpected count: %d", count))
 	}
@@ -415,6 +430,25 @@ func (sg *slotGranter) continueGrantChain(grantChainID grantChainID) {
 	sg.coord.continueGrantChain(sg.workKind, grantChainID)
 }
 
+func (sg *slotGranter) requesterHasWaitingRequests() bool {
+	return sg.requester.hasWaitingRequests()
+}
+
+func (sg *slotGranter) tryGrantLocked(grantChainID grantChainID) grantResult {
+	res := sg.tryGetLocked(1, 0 /*arbitrary*/)
+	if res == grantSuccess {
+		slots := sg.requester.granted(grantChainID)
+		if slots == 0 {
+			// Did not accept grant.
+			sg.returnGrantLocked(1, 0 /*arbitrary*/)
+			return grantFailLocal
+		} else if slots != 1 {
+			panic(errors.AssertionFailedf("unexpected count %d", slots))
+		}
+	}
+	return res
+}
+
 // tokenGranter implements granterWithLockedCalls.
 type tokenGranter struct {
 	coord                *GrantCoordinator
@@ -432,10 +466,7 @@ type tokenGranter struct {
 }
 
 var _ granterWithLockedCalls = &tokenGranter{}
-
-func (tg *tokenGranter) getPairedRequester() requester {
-	return tg.requester
-}
+var _ granter = &tokenGranter{}
 
 func (tg *tokenGranter) refillBurstTokens(skipTokenEnforcement bool) {
 	tg.availableBurstTokens = tg.maxBurstTokens
@@ -447,10 +478,10 @@ func (tg *tokenGranter) grantKind() grantKind {
 }
 
 func (tg *tokenGranter) tryGet(count int64) bool {
-	return tg.coord.tryGet(tg.workKind, count)
+	return tg.coord.tryGet(tg.workKind, count, 0 /*arbitrary*/)
 }
 
-func (tg *tokenGranter) tryGetLocked(count int64) grantResult {
+func (tg *tokenGranter) tryGetLocked(count int64, _ int8) grantResult {
 	if tg.cpuOverload != nil && tg.cpuOverload.isOverloaded() {
 		return grantFailDueToSharedResource
 	}
@@ -462,10 +493,10 @@ func (tg *tokenGranter) tryGetLocked(count int64) grantResult {
 }
 
 func (tg *tokenGranter) returnGrant(count int64) {
-	tg.coord.returnGrant(tg.workKind, count)
+	tg.coord.returnGrant(tg.workKind, count, 0 /*arbitrary*/)
 }
 
-func (tg *tokenGranter) returnGrantLocked(count int64) {
+func (tg *tokenGranter) returnGrantLocked(count int64, _ int8) {
 	tg.availableBurstTokens += count
 	if tg.availableBurstTokens > tg.maxBurstTokens {
 		tg.availableBurstTokens = tg.maxBurstTokens
@@ -473,10 +504,10 @@ func (tg *tokenGranter) returnGrantLocked(count int64) {
 }
 
 func (tg *tokenGranter) tookWithoutPermission(count int64) {
-	tg.coord.tookWithoutPermission(tg.workKind, count)
+	tg.coord.tookWithoutPermission(tg.workKind, count, 0 /*arbitrary*/)
 }
 
-func (tg *tokenGranter) tookWithoutPermissionLocked(count int64) {
+func (tg *tokenGranter) tookWithoutPermissionLocked(count int64, _ int8) {
 	tg.availableBurstTokens -= count
 }
 
@@ -484,78 +515,178 @@ func (tg *tokenGranter) continueGrantChain(grantChainID grantChainID) {
 	tg.coord.continueGrantChain(tg.workKind, grantChainID)
 }
 
+func (tg *tokenGranter) requesterHasWaitingRequests() bool {
+	return tg.requester.hasWaitingRequests()
+}
+
+func (tg *tokenGranter) tryGrantLocked(grantChainID grantChainID) grantResult {
+	res := tg.tryGetLocked(1, 0 /*arbitrary*/)
+	if res == grantSuccess {
+		tokens := tg.requester.granted(grantChainID)
+		if tokens == 0 {
+			// Did not accept grant.
+			tg.returnGrantLocked(1, 0 /*arbitrary*/)
+			return grantFailLocal
+		} else if tokens > 1 {
+			tg.tookWithoutPermissionLocked(tokens-1, 0 /*arbitrary*/)
+		}
+	}
+	return res
+}
+
 // kvStoreTokenGranter implements granterWithLockedCalls. It is used for
-// grants to KVWork to a store, that is limited by IO tokens.
+// grants to KVWork to a store, that is limited by IO tokens. It encapsulates
+// two granter-requester pairs, one for each admissionpb.WorkClass. The
+// granter in these pairs is implemented by kvStoreTokenChildGranter, and the
+// requester by WorkQueue. We have separate WorkQueues for these work classes
+// so that we don't have a situation where tenant1's elastic work is queued
+// ahead of tenant2's regular work (due to inter-tenant fairness) and blocks
+// the latter from getting tokens, because elastic tokens are exhausted (and
+// tokens for regular work are not exhausted).
+//
+// The kvStoreTokenChildGranters delegate the actual interaction to their
+// "parent", kvStoreTokenGranter. Regular work only needs IO tokens, which
+// are based on the health of L0 (see ioLoadListener). Elastic work
+// additionally needs elastic disk bandwidth tokens, which are based on the
+// disk bandwidth utilization of the store (see diskBandwidthLimiter), and
+// is only granted after the waiting regular work. So elastic work is
+// throttled first, both when L0 is unhealthy and when the disk bandwidth is
+// close to saturation.
 type kvStoreTokenGranter struct {
-	coord     *GrantCoordinator
-	requester requester
+	coord *GrantCoordinator
+	// The requesters for each admissionpb.WorkClass.
+	requesters [admissionpb.NumWorkClasses]requester
 	// There is no rate limiting in granting these tokens. That is, they are all
 	// burst tokens.
-	availableIOTokens               int64
-	ioTokensExhaustedDurationMetric *metric.Counter
-	exhaustedStart                  time.Time
+	availableIOTokens int64
+	// availableElasticDiskBWTokens are the disk bandwidth tokens available to
+	// elastic work, which needs both these and availableIOTokens.
+	availableElasticDiskBWTokens int64
+	// diskBWTokensUsed are the tokens used by each work class since the last
+	// call to getDiskTokensUsedAndResetLocked.
+	diskBWTokensUsed [admissionpb.NumWorkClasses]int64
+
+	ioTokensExhausted            exhaustedDurationTracker
+	elasticDiskBWTokensExhausted exhaustedDurationTracker
 }
 
 var _ granterWithLockedCalls = &kvStoreTokenGranter{}
+var _ granterWithIOTokens = &kvStoreTokenGranter{}
 
-func (sg *kvStoreTokenGranter) getPairedRequester() requester {
-	return sg.requester
+// kvStoreTokenChildGranter handles a particular workClass. Its methods
+// pass-through to the parent after adding the workClass as a parameter.
+type kvStoreTokenChildGranter struct {
+	workClass admissionpb.WorkClass
+	parent    *kvStoreTokenGranter
 }
 
-func (sg *kvStoreTokenGranter) grantKind() grantKind {
+var _ granter = &kvStoreTokenChildGranter{}
+
+// grantKind implements granter.
+func (cg *kvStoreTokenChildGranter) grantKind() grantKind {
 	return token
 }
 
-func (sg *kvStoreTokenGranter) tryGet(count int64) bool {
-	return sg.coord.tryGet(KVWork, count)
+// tryGet implements granter.
+func (cg *kvStoreTokenChildGranter) tryGet(count int64) bool {
+	return cg.parent.coord.tryGet(KVWork, count, int8(cg.workClass))
 }
 
-func (sg *kvStoreTokenGranter) tryGetLocked(count int64) grantResult {
-	if sg.availableIOTokens > 0 {
-		sg.subtractTokens(count, false)
-		return grantSuccess
-	}
-	return grantFailLocal
+// returnGrant implements granter.
+func (cg *kvStoreTokenChildGranter) returnGrant(count int64) {
+	cg.parent.coord.returnGrant(KVWork, count, int8(cg.workClass))
 }
 
-func (sg *kvStoreTokenGranter) returnGrant(count int64) {
-	sg.coord.returnGrant(KVWork, count)
+// tookWithoutPermission implements granter.
+func (cg *kvStoreTokenChildGranter) tookWithoutPermission(count int64) {
+	cg.parent.coord.tookWithoutPermission(KVWork, count, int8(cg.workClass))
 }
 
-func (sg *kvStoreTokenGranter) returnGrantLocked(count int64) {
-	sg.subtractTokens(-count, false)
+// continueGrantChain implements granter.
+func (cg *kvStoreTokenChildGranter) continueGrantChain(grantChainID grantChainID) {
+	cg.parent.coord.continueGrantChain(KVWork, grantChainID)
 }
 
-func (sg *kvStoreTokenGranter) tookWithoutPermission(count int64) {
-	sg.coord.tookWithoutPermission(KVWork, count)
+func (sg *kvStoreTokenGranter) tryGetLocked(count int64, demuxHandle int8) grantResult {
+	wc := admissionpb.WorkClass(demuxHandle)
+	switch wc {
+	case admissionpb.RegularWorkClass:
+		if sg.availableIOTokens > 0 {
+			sg.subtractTokens(count, false)
+			sg.diskBWTokensUsed[wc] += count
+			return grantSuccess
+		}
+	case admissionpb.ElasticWorkClass:
+		// NB: we don't check whether regular work is waiting, which would be
+		// more important. Regular work can only be waiting if the IO tokens are
+		// exhausted, in which case elastic work is not granted either.
+		if sg.availableIOTokens > 0 && sg.availableElasticDiskBWTokens > 0 {
+			sg.subtractTokens(count, false)
+			sg.subtractElasticDiskBWTokens(count, false)
+			sg.diskBWTokensUsed[wc] += count
+			return grantSuccess
+		}
+	}
+	return grantFailLocal
 }
 
-func (sg *kvStoreTokenGranter) tookWithoutPermissionLocked(count int64) {
+func (sg *kvStoreTokenGranter) returnGrantLocked(count int64, demuxHandle int8) {
+	wc := admissionpb.WorkClass(demuxHandle)
+	sg.subtractTokens(-count, false)
+	if wc == admissionpb.ElasticWorkClass {
+		sg.subtractElasticDiskBWTokens(-count, false)
+	}
+	sg.diskBWTokensUsed[wc] -= count
+}
+
+func (sg *kvStoreTokenGranter) tookWithoutPermissionLocked(count int64, demuxHandle int8) {
+	wc := admissionpb.WorkClass(demuxHandle)
 	sg.subtractTokens(count, false)
+	if wc == admissionpb.ElasticWorkClass {
+		sg.subtractElasticDiskBWTokens(count, false)
+	}
+	sg.diskBWTokensUsed[wc] += count
 }
 
 func (sg *kvStoreTokenGranter) subtractTokens(count int64, forceTickMetric bool) {
-	avail := sg.availableIOTokens
-	sg.availableIOTokens -= count
-	if count > 0 && avail > 0 && sg.availableIOTokens <= 0 {
-		// Transition from > 0 to <= 0.
-		sg.exhaustedStart = timeutil.Now()
-	} else if count < 0 && avail <= 0 && (sg.availableIOTokens > 0 || forceTickMetric) {
-		// Transition from <= 0 to > 0, or forced to tick the metric. The latter
-		// ensures that if the available tokens stay <= 0, we don't show a sudden
-		// change in the metric after minutes of exhaustion (we had observed such
-		// behavior prior to this change).
-		now := timeutil.Now()
-		exhaustedMicros := now.Sub(sg.exhaustedStart).Microseconds()
-		sg.ioTokensExhaustedDurationMetric.Inc(exhaustedMicros)
-		if sg.availableIOTokens <= 0 {
-			sg.exhaustedStart = now
+	sg.ioTokensExhausted.subtract(&sg.availableIOTokens, count, forceTickMetric)
+}
+
+func (sg *kvStoreTokenGranter) subtractElasticDiskBWTokens(count int64, forceTickMetric bool) {
+	sg.elasticDiskBWTokensExhausted.subtract(&sg.availableElasticDiskBWTokens, count, forceTickMetric)
+}
+
+func (sg *kvStoreTokenGranter) requesterHasWaitingRequests() bool {
+	for _, req := range sg.requesters {
+		if req.hasWaitingRequests() {
+			return true
 		}
 	}
+	return false
 }
 
-func (sg *kvStoreTokenGranter) continueGrantChain(grantChainID grantChainID) {
-	sg.coord.continueGrantChain(KVWork, grantChainID)
+func (sg *kvStoreTokenGranter) tryGrantLocked(grantChainID grantChainID) grantResult {
+	// Regular work is granted before elastic work.
+	for wc, req := range sg.requesters {
+		if !req.hasWaitingRequests() {
+			continue
+		}
+		if sg.tryGetLocked(1, int8(wc)) != grantSuccess {
+			continue
+		}
+		tookCount := req.granted(grantChainID)
+		if tookCount == 0 {
+			// Did not accept grant.
+			sg.returnGrantLocked(1, int8(wc))
+			continue
+		}
+		// May have taken more.
+		if tookCount > 1 {
+			sg.tookWithoutPermissionLocked(tookCount-1, int8(wc))
+		}
+		return grantSuccess
+	}
+	return grantFailLocal
 }
 
 func (sg *kvStoreTokenGranter) setAvailableIOTokensLocked(tokens int64) {
@@ -570,6 +701,53 @@ func (sg *kvStoreTokenGranter) setAvailableIOTokensLocked(tokens int64) {
 	}
 }
 
+func (sg *kvStoreTokenGranter) setAvailableElasticDiskBandwidthTokensLocked(tokens int64) {
+	// Like availableIOTokens, availableElasticDiskBWTokens can be negative, and
+	// we remember the over-allocation.
+	sg.subtractElasticDiskBWTokens(-tokens, true)
+	if sg.availableElasticDiskBWTokens > tokens {
+		// Clamp to tokens.
+		sg.availableElasticDiskBWTokens = tokens
+	}
+}
+
+func (sg *kvStoreTokenGranter) getDiskTokensUsedAndResetLocked() [admissionpb.NumWorkClasses]int64 {
+	result := sg.diskBWTokensUsed
+	sg.diskBWTokensUsed = [admissionpb.NumWorkClasses]int64{}
+	return result
+}
+
+// exhaustedDurationTracker accumulates the duration for which some tokens
+// were exhausted, i.e., <= 0, in a metric.
+type exhaustedDurationTracker struct {
+	metric         *metric.Counter
+	exhaustedStart time.Time
+}
+
+// subtract subtracts count from *tokens, which can be negative, and updates
+// the metric if the tokens transition from > 0 to <= 0 or back. When
+// forceTickMetric is true and the tokens stay exhausted, the metric is
+// updated too.
+func (t *exhaustedDurationTracker) subtract(tokens *int64, count int64, forceTickMetric bool) {
+	avail := *tokens
+	*tokens -= count
+	if count > 0 && avail > 0 && *tokens <= 0 {
+		// Transition from > 0 to <= 0.
+		t.exhaustedStart = timeutil.Now()
+	} else if count < 0 && avail <= 0 && (*tokens > 0 || forceTickMetric) {
+		// Transition from <= 0 to > 0, or forced to tick the metric. The latter
+		// ensures that if the available tokens stay <= 0, we don't show a sudden
+		// change in the metric after minutes of exhaustion (we had observed such
+		// behavior prior to this change).
+		now := timeutil.Now()
+		exhaustedMicros := now.Sub(t.exhaustedStart).Microseconds()
+		t.metric.Inc(exhaustedMicros)
+		if *tokens <= 0 {
+			t.exhaustedStart = now
+		}
+	}
+}
+
 // GrantCoordinator is the top-level object that coordinates grants across
 // different WorkKinds (for more context see the comment in doc.go, and the
 // comment where WorkKind is declared). Typically there will one
@@ -589,8 +767,9 @@ type GrantCoordinator struct {
 	// NB: Some granters can be nil.
 	granters [numWorkKinds]granterWithLockedCalls
 	// The WorkQueues behaving as requesters in each granterWithLockedCalls.
-	// This is kept separately only to service GetWorkQueue calls.
-	queues [numWorkKinds]requester
+	// This is kept separately only to service GetWorkQueue calls and to call
+	// close().
+	queues [numWorkKinds]requesterClose
 	// The cpu fields can be nil, and the IO field can be nil, since a
 	// GrantCoordinator typically handles one of these two resources.
 	cpuOverloadIndicator cpuOverloadIndicator
@@ -690,8 +869,9 @@ type makeRequesterFunc func(
 	opts workQueueOptions) requester
 
 type makeStoreRequesterFunc func(
-	_ log.AmbientContext, granter granter, settings *cluster.Settings,
-	opts workQueueOptions) storeRequester
+	_ log.AmbientContext, granters [admissionpb.NumWorkClasses]granter,
+	settings *cluster.Settings, opts [admissionpb.NumWorkClasses]workQueueOptions,
+) storeRequester
 
 // NewGrantCoordinators constructs GrantCoordinators and WorkQueues for a
 // regular cluster node. Caller is responsible for hooking up
@@ -741,8 +921,9 @@ func NewGrantCoordinators(
 		usedSlotsMetric: metrics.KVUsedSlots,
 	}
 	kvSlotAdjuster.granter = kvg
-	coord.queues[KVWork] = makeRequester(ambientCtx, KVWork, kvg, st, makeWorkQueueOptions(KVWork))
-	kvg.requester = coord.queues[KVWork]
+	req := makeRequester(ambientCtx, KVWork, kvg, st, makeWorkQueueOptions(KVWork))
+	coord.queues[KVWork] = req
+	kvg.requester = req
 	coord.granters[KVWork] = kvg
 
 	tg := &tokenGranter{
@@ -752,9 +933,10 @@ func NewGrantCoordinators(
 		maxBurstTokens:       opts.SQLKVResponseBurstTokens,
 		cpuOverload:          kvSlotAdjuster,
 	}
-	coord.queues[SQLKVResponseWork] = makeRequester(
+	req = makeRequester(
 		ambientCtx, SQLKVResponseWork, tg, st, makeWorkQueueOptions(SQLKVResponseWork))
-	tg.requester = coord.queues[SQLKVResponseWork]
+	coord.queues[SQLKVResponseWork] = req
+	tg.requester = req
 	coord.granters[SQLKVResponseWork] = tg
 
 	tg = &tokenGranter{
@@ -764,9 +946,10 @@ func NewGrantCoordinators(
 		maxBurstTokens:       opts.SQLSQLResponseBurstTokens,
 		cpuOverload:          kvSlotAdjuster,
 	}
-	coord.queues[SQLSQLResponseWork] = makeRequester(ambientCtx,
+	req = makeRequester(ambientCtx,
 		SQLSQLResponseWork, tg, st, makeWorkQueueOptions(SQLSQLResponseWork))
-	tg.requester = coord.queues[SQLSQLResponseWork]
+	coord.queues[SQLSQLResponseWork] = req
+	tg.requester = req
 	coord.granters[SQLSQLResponseWork] = tg
 
 	sg := &slotGranter{
@@ -776,9 +959,10 @@ func NewGrantCoordinators(
 		cpuOverload:     kvSlotAdjuster,
 		usedSlotsMetric: metrics.SQLLeafStartUsedSlots,
 	}
-	coord.queues[SQLStatementLeafStartWork] = makeRequester(ambientCtx,
+	req = makeRequester(ambientCtx,
 		SQLStatementLeafStartWork, sg, st, makeWorkQueueOptions(SQLStatementLeafStartWork))
-	sg.requester = coord.queues[SQLStatementLeafStartWork]
+	coord.queues[SQLStatementLeafStartWork] = req
+	sg.requester = req
 	coord.granters[SQLStatementLeafStartWork] = sg
 
 	sg = &slotGranter{
@@ -788,24 +972,31 @@ func NewGrantCoordinators(
 		cpuOverload:     kvSlotAdjuster,
 		usedSlotsMetric: metrics.SQLRootStartUsedSlots,
 	}
-	coord.queues[SQLStatementRootStartWork] = makeRequester(ambientCtx,
+	req = makeRequester(ambientCtx,
 		SQLStatementRootStartWork, sg, st, makeWorkQueueOptions(SQLStatementRootStartWork))
-	sg.requester = coord.queues[SQLStatementRootStartWork]
+	coord.queues[SQLStatementRootStartWork] = req
+	sg.requester = req
 	coord.granters[SQLStatementRootStartWork] = sg
 
 	metricStructs = appendMetricStructsForQueues(metricStructs, coord)
 
-	storeWorkQueueMetrics := makeWorkQueueMetrics(string(workKindString(KVWork)) + "-stores")
-	metricStructs = append(metricStructs, storeWorkQueueMetrics)
+	var storeWorkQueueMetrics [admissionpb.NumWorkClasses]WorkQueueMetrics
+	storeWorkQueueMetrics[admissionpb.RegularWorkClass] =
+		makeWorkQueueMetrics(string(workKindString(KVWork)) + "-stores")
+	storeWorkQueueMetrics[admissionpb.ElasticWorkClass] = makeWorkQueueMetrics("elastic-stores")
+	for i := range storeWorkQueueMetrics {
+		metricStructs = append(metricStructs, storeWorkQueueMetrics[i])
+	}
 	makeStoreRequester := makeStoreWorkQueue
 	if opts.makeStoreRequesterFunc != nil {
 		makeStoreRequester = opts.makeStoreRequesterFunc
 	}
 	storeCoordinators := &StoreGrantCoordinators{
-		settings:                    st,
-		makeStoreRequesterFunc:      makeStoreRequester,
-		kvIOTokensExhaustedDuration: metrics.KVIOTokensExhaustedDuration,
-		workQueueMetrics:            storeWorkQueueMetrics,
+		settings:                               st,
+		makeStoreRequesterFunc:                 makeStoreRequester,
+		kvIOTokensExhaustedDuration:            metrics.KVIOTokensExhaustedDuration,
+		kvElasticDiskBWTokensExhaustedDuration: metrics.KVElasticDiskBWTokensExhaustedDuration,
+		workQueueMetrics:                       storeWorkQueueMetrics,
 	}
 
 	return GrantCoordinators{Stores: storeCoordinators, Regular: coord}, metricStructs
@@ -843,9 +1034,10 @@ func NewGrantCoordinatorSQL(
 		maxBurstTokens:       opts.SQLKVResponseBurstTokens,
 		cpuOverload:          sqlNodeCPU,
 	}
-	coord.queues[SQLKVResponseWork] = makeRequester(ambientCtx,
+	req := makeRequester(ambientCtx,
 		SQLKVResponseWork, tg, st, makeWorkQueueOptions(SQLKVResponseWork))
-	tg.requester = coord.queues[SQLKVResponseWork]
+	coord.queues[SQLKVResponseWork] = req
+	tg.requester = req
 	coord.granters[SQLKVResponseWork] = tg
 
 	tg = &tokenGranter{
@@ -855,9 +1047,10 @@ func NewGrantCoordinatorSQL(
 		maxBurstTokens:       opts.SQLSQLResponseBurstTokens,
 		cpuOverload:          sqlNodeCPU,
 	}
-	coord.queues[SQLSQLResponseWork] = makeRequester(ambientCtx,
+	req = makeRequester(ambientCtx,
 		SQLSQLResponseWork, tg, st, makeWorkQueueOptions(SQLSQLResponseWork))
-	tg.requester = coord.queues[SQLSQLResponseWork]
+	coord.queues[SQLSQLResponseWork] = req
+	tg.requester = req
 	coord.granters[SQLSQLResponseWork] = tg
 
 	sg := &slotGranter{
@@ -867,9 +1060,10 @@ func NewGrantCoordinatorSQL(
 		cpuOverload:     sqlNodeCPU,
 		usedSlotsMetric: metrics.SQLLeafStartUsedSlots,
 	}
-	coord.queues[SQLStatementLeafStartWork] = makeRequester(ambientCtx,
+	req = makeRequester(ambientCtx,
 		SQLStatementLeafStartWork, sg, st, makeWorkQueueOptions(SQLStatementLeafStartWork))
-	sg.requester = coord.queues[SQLStatementLeafStartWork]
+	coord.queues[SQLStatementLeafStartWork] = req
+	sg.requester = req
 	coord.granters[SQLStatementLeafStartWork] = sg
 
 	sg = &slotGranter{
@@ -879,9 +1073,10 @@ func NewGrantCoordinatorSQL(
 		cpuOverload:     sqlNodeCPU,
 		usedSlotsMetric: metrics.SQLRootStartUsedSlots,
 	}
-	coord.queues[SQLStatementRootStartWork] = makeRequester(ambientCtx,
+	req = makeRequester(ambientCtx,
 		SQLStatementRootStartWork, sg, st, makeWorkQueueOptions(SQLStatementRootStartWork))
-	sg.requester = coord.queues[SQLStatementRootStartWork]
+	coord.queues[SQLStatementRootStartWork] = req
+	sg.requester = req
 	coord.granters[SQLStatementRootStartWork] = sg
 
 	return coord, appendMetricStructsForQueues(metricStructs, coord)
@@ -902,7 +1097,7 @@ func appendMetricStructsForQueues(ms []metric.Struct, coord *GrantCoordinator) [
 // pebbleMetricsTick is called every adjustmentInterval seconds and passes
 // through to the ioLoadListener, so that it can adjust the plan for future IO
 // token allocations.
-func (coord *GrantCoordinator) pebbleMetricsTick(ctx context.Context, m *pebble.Metrics) {
+func (coord *GrantCoordinator) pebbleMetricsTick(ctx context.Context, m StoreMetrics) {
 	coord.ioLoadListener.pebbleMetricsTick(ctx, m)
 }
 
@@ -985,14 +1180,14 @@ func (coord *GrantCoordinator) CPULoad(runnable int, procs int, samplePeriod tim
 }
 
 // tryGet is called by granter.tryGet with the WorkKind.
-func (coord *GrantCoordinator) tryGet(workKind WorkKind, count int64) bool {
+func (coord *GrantCoordinator) tryGet(workKind WorkKind, count int64, demuxHandle int8) bool {
 	coord.mu.Lock()
 	defer coord.mu.Unlock()
 	// It is possible that a grant chain is active, and has not yet made its way
 	// to this workKind. So it may be more reasonable to queue. But we have some
 	// concerns about incurring the delay of multiple goroutine context switches
 	// so we ignore this case.
-	res := coord.granters[workKind].tryGetLocked(count)
+	res := coord.granters[workKind].tryGetLocked(count, demuxHandle)
 	switch res {
 	case grantSuccess:
 		// Grant chain may be active, but it did not get in the way of this grant,
@@ -1015,13 +1210,13 @@ func (coord *GrantCoordinator) tryGet(workKind WorkKind, count int64) bool {
 }
 
 // returnGrant is called by granter.returnGrant with the WorkKind.
-func (coord *GrantCoordinator) returnGrant(workKind WorkKind, count int64) {
+func (coord *GrantCoordinator) returnGrant(workKind WorkKind, count int64, demuxHandle int8) {
 	coord.mu.Lock()
 	defer coord.mu.Unlock()
-	coord.granters[workKind].returnGrantLocked(count)
+	coord.granters[workKind].returnGrantLocked(count, demuxHandle)
 	if coord.grantChainActive {
 		if coord.grantChainIndex > workKind &&
-			coord.granters[workKind].getPairedRequester().hasWaitingRequests() {
+			coord.granters[workKind].requesterHasWaitingRequests() {
 			// There are waiting requests that will not be served by the grant chain.
 			// Better to terminate it and start afresh.
 			if !coord.tryTerminateGrantChain() {
@@ -1037,10 +1232,12 @@ func (coord *GrantCoordinator) returnGrant(workKind WorkKind, count int64) {
 
 // tookWithoutPermission is called by granter.tookWithoutPermission with the
 // WorkKind.
-func (coord *GrantCoordinator) tookWithoutPermission(workKind WorkKind, count int64) {
+func (coord *GrantCoordinator) tookWithoutPermission(
+	workKind WorkKind, count int64, demuxHandle int8,
+) {
 	coord.mu.Lock()
 	defer coord.mu.Unlock()
-	coord.granters[workKind].tookWithoutPermissionLocked(count)
+	coord.granters[workKind].tookWithoutPermissionLocked(count, demuxHandle)
 }
 
 // continueGrantChain is called by granter.continueGrantChain with the
@@ -1134,33 +1331,22 @@ OuterLoop:
 			// remaining will be nil.
 			continue
 		}
-		req := granter.getPairedRequester()
-		for req.hasWaitingRequests() && !localDone {
-			// Get 1 token or slot.
-			res := granter.tryGetLocked(1)
+		for granter.requesterHasWaitingRequests() && !localDone {
+			chainID := noGrantChain
+			if grantBurstCount+1 == grantBurstLimit && coord.useGrantChains {
+				chainID = coord.grantChainID
+			}
+			// Grant 1 token or slot.
+			res := granter.tryGrantLocked(chainID)
 			switch res {
 			case grantSuccess:
-				chainID := noGrantChain
-				if grantBurstCount+1 == grantBurstLimit && coord.useGrantChains {
-					chainID = coord.grantChainID
-				}
-				tookCount := req.granted(chainID)
-				if tookCount == 0 {
-					// Did not accept grant.
-					granter.returnGrantLocked(1)
-				} else {
-					// May have taken more.
-					if tookCount > 1 {
-						granter.tookWithoutPermissionLocked(tookCount - 1)
-					}
-					grantBurstCount++
-					if grantBurstCount == grantBurstLimit && coord.useGrantChains {
-						coord.grantChainActive = true
-						if startingChain {
-							coord.grantChainStartTime = timeutil.Now()
-						}
-						return
+				grantBurstCount++
+				if grantBurstCount == grantBurstLimit && coord.useGrantChains {
+					coord.grantChainActive = true
+					if startingChain {
+						coord.grantChainStartTime = timeutil.Now()
 					}
+					return
 				}
 			case grantFailDueToSharedResource:
 				break OuterLoop
@@ -1214,7 +1400,8 @@ func (coord *GrantCoordinator) SafeFormat(s redact.SafePrinter, verb rune) {
 				s.Printf("%s%s: used: %d, total: %d", curSep, workKindString(kind), g.usedSlots,
 					g.totalSlots)
 			case *kvStoreTokenGranter:
-				s.Printf(" io-avail: %d", g.availableIOTokens)
+				s.Printf(" io-avail: %d, elastic-disk-bw-tokens-avail: %d", g.availableIOTokens,
+					g.availableElasticDiskBWTokens)
 			}
 		case SQLStatementLeafStartWork, SQLStatementRootStartWork:
 			if coord.granters[i] != nil {
@@ -1241,11 +1428,13 @@ func (coord *GrantCoordinator) SafeFormat(s redact.SafePrinter, verb rune) {
 type StoreGrantCoordinators struct {
 	ambientCtx log.AmbientContext
 
-	settings                    *cluster.Settings
-	makeStoreRequesterFunc      makeStoreRequesterFunc
-	kvIOTokensExhaustedDuration *metric.Counter
-	// These metrics are shared by WorkQueues across stores.
-	workQueueMetrics WorkQueueMetrics
+	settings                               *cluster.Settings
+	makeStoreRequesterFunc                 makeStoreRequesterFunc
+	kvIOTokensExhaustedDuration            *metric.Counter
+	kvElasticDiskBWTokensExhaustedDuration *metric.Counter
+	// These metrics are shared by WorkQueues across stores, for each
+	// admissionpb.WorkClass.
+	workQueueMetrics [admissionpb.NumWorkClasses]WorkQueueMetrics
 
 	gcMap syncutil.IntMap // map[int64(StoreID)]*GrantCoordinator
 	// numStores is used to track the number of stores which have been added
@@ -1278,7 +1467,7 @@ func (sgc *StoreGrantCoordinators) SetPebbleMetricsProvider(
 		if !loaded {
 			sgc.numStores++
 		}
-		gc.pebbleMetricsTick(startupCtx, m.Metrics)
+		gc.pebbleMetricsTick(startupCtx, m)
 		gc.allocateIOTokensTick()
 	}
 	if sgc.disableTickerForTesting {
@@ -1304,7 +1493,7 @@ func (sgc *StoreGrantCoordinators) SetPebbleMetricsProvider(
 					for _, m := range metrics {
 						if unsafeGc, ok := sgc.gcMap.Load(int64(m.StoreID)); ok {
 							gc := (*GrantCoordinator)(unsafeGc)
-							gc.pebbleMetricsTick(ctx, m.Metrics)
+							gc.pebbleMetricsTick(ctx, m)
 						} else {
 							log.Warningf(ctx,
 								"seeing metrics for unknown storeID %d", m.StoreID)
@@ -1351,19 +1540,35 @@ func (sgc *StoreGrantCoordinators) initGrantCoordinator(storeID int32) *GrantCoo
 		useGrantChains: false,
 		numProcs:       1,
 	}
+	now := timeutil.Now()
 	kvg := &kvStoreTokenGranter{
-		coord:                           coord,
-		ioTokensExhaustedDurationMetric: sgc.kvIOTokensExhaustedDuration,
-	}
-	opts := makeWorkQueueOptions(KVWork)
-	// This is IO work, so override the usesTokens value.
-	opts.usesTokens = true
-	// Share the WorkQueue metrics across all stores.
-	// TODO(sumeer): add per-store WorkQueue state for debug.zip and db console.
-	opts.metrics = &sgc.workQueueMetrics
-	storeReq := sgc.makeStoreRequesterFunc(sgc.ambientCtx, kvg, sgc.settings, opts)
+		coord: coord,
+		ioTokensExhausted: exhaustedDurationTracker{
+			metric:         sgc.kvIOTokensExhaustedDuration,
+			exhaustedStart: now,
+		},
+		elasticDiskBWTokensExhausted: exhaustedDurationTracker{
+			metric:         sgc.kvElasticDiskBWTokensExhaustedDuration,
+			exhaustedStart: now,
+		},
+	}
+	var granters [admissionpb.NumWorkClasses]granter
+	var opts [admissionpb.NumWorkClasses]workQueueOptions
+	for i := range granters {
+		granters[i] = &kvStoreTokenChildGranter{
+			workClass: admissionpb.WorkClass(i),
+			parent:    kvg,
+		}
+		opts[i] = makeWorkQueueOptions(KVWork)
+		// This is IO work, so override the usesTokens value.
+		opts[i].usesTokens = true
+		// Share the WorkQueue metrics across all stores.
+		// TODO(sumeer): add per-store WorkQueue state for debug.zip and db console.
+		opts[i].metrics = &sgc.workQueueMetrics[i]
+	}
+	storeReq := sgc.makeStoreRequesterFunc(sgc.ambientCtx, granters, sgc.settings, opts)
 	coord.queues[KVWork] = storeReq
-	kvg.requester = storeReq
+	kvg.requesters = storeReq.getRequesters()
 	coord.granters[KVWork] = kvg
 	coord.ioLoadListener = &ioLoadListener{
 		storeID:     storeID,
@@ -1524,6 +1729,10 @@ type PebbleMetricsProvider interface {
 type StoreMetrics struct {
 	StoreID int32
 	*pebble.Metrics
+	// DiskStats are the stats of the disk used by the store. They are used to
+	// throttle elastic work when the disk bandwidth is close to saturation,
+	// and can be left empty if they are unavailable.
+	DiskStats DiskStats
 }
 
 // granterWithIOTokens is used to abstract kvStoreTokenGranter for testing.
@@ -1535,6 +1744,13 @@ type granterWithIOTokens interface {
 	// increments that negative value with the value provided by tokens. This
 	// method needs to be called periodically.
 	setAvailableIOTokensLocked(tokens int64)
+	// setAvailableElasticDiskBandwidthTokensLocked bounds the available tokens
+	// for elastic work, in addition to the IO tokens, with the same semantics
+	// as setAvailableIOTokensLocked.
+	setAvailableElasticDiskBandwidthTokensLocked(tokens int64)
+	// getDiskTokensUsedAndResetLocked returns the tokens used by each work
+	// class since the previous call.
+	getDiskTokensUsedAndResetLocked() [admissionpb.NumWorkClasses]int64
 }
 
 // storeAdmissionStats are stats maintained by a storeRequester. The non-test
@@ -1584,7 +1800,9 @@ type storeRequestEstimates struct {
 
 // storeRequester is used to abstract *StoreWorkQueue for testing.
 type storeRequester interface {
-	requester
+	requesterClose
+	// getRequesters returns the requesters for each admissionpb.WorkClass.
+	getRequesters() [admissionpb.NumWorkClasses]requester
 	getStoreAdmissionStats() storeAdmissionStats
 	setStoreRequestEstimates(estimates storeRequestEstimates)
 }
@@ -1624,6 +1842,16 @@ type ioLoadListener struct {
 	// represents what has been given out. The units is bytes.
 	totalTokens     int64
 	tokensAllocated int64
+
+	// Cumulative stats used to compute the interval stats for the
+	// diskBandwidthLimiter.
+	diskStats        DiskStats
+	lsmIncomingBytes uint64
+	diskBWLimiter    diskBandwidthLimiter
+	// elasticDiskBWTokens are the tokens for elastic work until the next call
+	// to adjustTokens, which are given out with smoothing, like totalTokens.
+	elasticDiskBWTokens          int64
+	elasticDiskBWTokensAllocated int64
 }
 
 const unlimitedTokens = math.MaxInt64
@@ -1669,49 +1897,70 @@ const adjustmentInterval = 15
 
 // pebbleMetricsTicks is called every adjustmentInterval seconds, and decides
 // the token allocations until the next call.
-func (io *ioLoadListener) pebbleMetricsTick(ctx context.Context, m *pebble.Metrics) {
+func (io *ioLoadListener) pebbleMetricsTick(ctx context.Context, metrics StoreMetrics) {
+	m := metrics.Metrics
 	if !io.statsInitialized {
 		io.statsInitialized = true
 		// Initialize cumulative stats.
 		io.admissionStats = io.kvRequester.getStoreAdmissionStats()
 		io.l0Bytes = m.Levels[0].Size
 		io.l0AddedBytes = m.Levels[0].BytesFlushed + m.Levels[0].BytesIngested
+		io.diskStats = metrics.DiskStats
+		io.lsmIncomingBytes = cumLSMIncomingBytes(m)
 		// No initial limit, i.e, the first interval is unlimited.
 		io.totalTokens = unlimitedTokens
+		io.elasticDiskBWTokens = unlimitedTokens
 		// Reasonable starting fraction until we see some ingests.
 		io.smoothedFractionOfIngestIntoL0 = 0.5
 		return
 	}
 	io.adjustTokens(ctx, m)
+	io.adjustElasticDiskBWTokens(ctx, metrics)
 }
 
-// allocateTokensTick gives out 1/adjustmentInterval of the totalTokens every
-// 1s.
+// allocateTokensTick gives out 1/adjustmentInterval of the totalTokens and
+// elasticDiskBWTokens every 1s.
 func (io *ioLoadListener) allocateTokensTick() {
+	toAllocate := tokensToAllocateForTick(io.totalTokens, io.tokensAllocated)
+	toAllocateElastic := tokensToAllocateForTick(
+		io.elasticDiskBWTokens, io.elasticDiskBWTokensAllocated)
+	// INVARIANT: toAllocate >= 0 && toAllocateElastic >= 0.
+	io.mu.Lock()
+	defer io.mu.Unlock()
+	io.tokensAllocated += toAllocate
+	if io.tokensAllocated < 0 {
+		panic(errors.AssertionFailedf("tokens allocated is negative %d", io.tokensAllocated))
+	}
+	io.elasticDiskBWTokensAllocated += toAllocateElastic
+	if io.elasticDiskBWTokensAllocated < 0 {
+		panic(errors.AssertionFailedf("elastic disk bandwidth tokens allocated is negative %d",
+			io.elasticDiskBWTokensAllocated))
+	}
+	io.mu.kvGranter.setAvailableIOTokensLocked(toAllocate)
+	io.mu.kvGranter.setAvailableElasticDiskBandwidthTokensLocked(toAllocateElastic)
+}
+
+// tokensToAllocateForTick returns the tokens to give out in a 1s tick, out of
+// the totalTokens for the adjustmentInterval, of which tokensAllocated have
+// already been given out.
+func tokensToAllocateForTick(totalTokens int64, tokensAllocated int64) int64 {
 	var toAllocate int64
 	// unlimitedTokens==MaxInt64, so avoid overflow in the rounding up
 	// calculation.
-	if io.totalTokens >= unlimitedTokens-(adjustmentInterval-1) {
-		toAllocate = io.totalTokens / adjustmentInterval
+	if totalTokens >= unlimitedTokens-(adjustmentInterval-1) {
+		toAllocate = totalTokens / adjustmentInterval
 	} else {
 		// Round up so that we don't accumulate tokens to give in a burst on the
 		// last tick.
-		toAllocate = (io.totalTokens + adjustmentInterval - 1) / adjustmentInterval
+		toAllocate = (totalTokens + adjustmentInterval - 1) / adjustmentInterval
 		if toAllocate < 0 {
 			panic(errors.AssertionFailedf("toAllocate is negative %d", toAllocate))
 		}
-		if toAllocate+io.tokensAllocated > io.totalTokens {
-			toAllocate = io.totalTokens - io.tokensAllocated
+		if toAllocate+tokensAllocated > totalTokens {
+			toAllocate = totalTokens - tokensAllocated
 		}
 	}
-	// INVARIANT: toAllocate >= 0.
-	io.mu.Lock()
-	defer io.mu.Unlock()
-	io.tokensAllocated += toAllocate
-	if io.tokensAllocated < 0 {
-		panic(errors.AssertionFailedf("tokens allocated is negative %d", io.tokensAllocated))
-	}
-	io.mu.kvGranter.setAvailableIOTokensLocked(toAllocate)
+	return toAllocate
 }
 
 // adjustTokens computes a new value of totalTokens (and resets
@@ -1855,6 +2104,61 @@ func (io *ioLoadListener) adjustTokens(ctx context.Context, m *pebble.Metrics) {
 	io.kvRequester.setStoreRequestEstimates(requestEstimates)
 }
 
+// adjustElasticDiskBWTokens computes a new value of elasticDiskBWTokens (and
+// resets elasticDiskBWTokensAllocated) using the diskBandwidthLimiter.
+func (io *ioLoadListener) adjustElasticDiskBWTokens(ctx context.Context, metrics StoreMetrics) {
+	io.elasticDiskBWTokensAllocated = 0
+	io.mu.Lock()
+	tokensUsed := io.mu.kvGranter.getDiskTokensUsedAndResetLocked()
+	io.mu.Unlock()
+
+	// The deltas of cumulative stats should not be negative, but the disk
+	// stats can be reset, e.g. when the device is detached.
+	delta := func(cur, prev uint64) int64 {
+		if d := int64(cur - prev); cur >= prev && d >= 0 {
+			return d
+		}
+		return 0
+	}
+	diskLoad := intervalDiskLoadInfo{
+		readBytes:               delta(metrics.DiskStats.BytesRead, io.diskStats.BytesRead),
+		writeBytes:              delta(metrics.DiskStats.BytesWritten, io.diskStats.BytesWritten),
+		provisionedBandwidth:    provisionedBandwidth.Get(&io.settings.SV),
+		elasticBandwidthMaxUtil: elasticDiskBandwidthMaxUtil.Get(&io.settings.SV),
+	}
+	if metrics.DiskStats == (DiskStats{}) {
+		// The disk stats are unavailable, so the disk bandwidth cannot be used
+		// for admission control.
+		diskLoad.provisionedBandwidth = 0
+	}
+	lsmIncomingBytes := cumLSMIncomingBytes(metrics.Metrics)
+	lsm := intervalLSMInfo{
+		incomingBytes:     delta(lsmIncomingBytes, io.lsmIncomingBytes),
+		regularTokensUsed: tokensUsed[admissionpb.RegularWorkClass],
+		elasticTokensUsed: tokensUsed[admissionpb.ElasticWorkClass],
+	}
+	io.elasticDiskBWTokens = io.diskBWLimiter.computeElasticTokens(diskLoad, lsm)
+	if io.elasticDiskBWTokens != unlimitedTokens {
+		log.Infof(ctx,
+			"disk bandwidth on store %d: read %d, written %d, lsm-incoming %d, tokens-used: "+
+				"regular %d, elastic %d, %s", io.storeID, diskLoad.readBytes, diskLoad.writeBytes,
+			lsm.incomingBytes, lsm.regularTokensUsed, lsm.elasticTokensUsed, &io.diskBWLimiter)
+	}
+	// Install the latest cumulative stats.
+	io.diskStats = metrics.DiskStats
+	io.lsmIncomingBytes = lsmIncomingBytes
+}
+
+// cumLSMIncomingBytes returns the cumulative bytes added to the LSM, by
+// flushes into L0 and ingestions into all levels.
+func cumLSMIncomingBytes(m *pebble.Metrics) uint64 {
+	incomingBytes := m.Levels[0].BytesFlushed
+	for i := range m.Levels {
+		incomingBytes += m.Levels[i].BytesIngested
+	}
+	return incomingBytes
+}
+
 var _ cpuOverloadIndicator = &sqlNodeCPUOverloadIndicator{}
 var _ CPULoadListener = &sqlNodeCPUOverloadIndicator{}
 
@@ -1887,15 +2191,22 @@ var (
 		Measurement: "Microseconds",
 		Unit:        metric.Unit_COUNT,
 	}
+	kvElasticDiskBWTokensExhaustedDuration = metric.Metadata{
+		Name:        "admission.granter.elastic_io_tokens_exhausted_duration.kv",
+		Help:        "Total duration when elastic disk bandwidth tokens were exhausted, in micros",
+		Measurement: "Microseconds",
+		Unit:        metric.Unit_COUNT,
+	}
 )
 
 // GranterMetrics are metrics associated with a GrantCoordinator.
 type GranterMetrics struct {
-	KVTotalSlots                *metric.Gauge
-	KVUsedSlots                 *metric.Gauge
-	KVIOTokensExhaustedDuration *metric.Counter
-	SQLLeafStartUsedSlots       *metric.Gauge
-	SQLRootStartUsedSlots       *metric.Gauge
+	KVTotalSlots                           *metric.Gauge
+	KVUsedSlots                            *metric.Gauge
+	KVIOTokensExhaustedDuration            *metric.Counter
+	KVElasticDiskBWTokensExhaustedDuration *metric.Counter
+	SQLLeafStartUsedSlots                  *metric.Gauge
+	SQLRootStartUsedSlots                  *metric.Gauge
 }
 
 // MetricStruct implements the metric.Struct interface.
@@ -1906,6 +2217,8 @@ func makeGranterMetrics() GranterMetrics {
 		KVTotalSlots:                metric.NewGauge(totalSlots),
 		KVUsedSlots:                 metric.NewGauge(addName(string(workKindString(KVWork)), usedSlots)),
 		KVIOTokensExhaustedDuration: metric.NewCounter(kvIOTokensExhaustedDuration),
+		KVElasticDiskBWTokensExhaustedDuration: metric.NewCounter(
+			kvElasticDiskBWTokensExhaustedDuration),
 		SQLLeafStartUsedSlots: metric.NewGauge(
 			addName(string(workKindString(SQLStatementLeafStartWork)), usedSlots)),
 		SQLRootStartUsedSlots: metric.NewGauge(
@@ -23,6 +23,7 @@ import (
 
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/admission/admissionpb"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
@@ -32,10 +33,13 @@ import (
 )
 
 type testRequester struct {
-	workKind   WorkKind
-	granter    granter
-	usesTokens bool
-	buf        *strings.Builder
+	workKind WorkKind
+	// additionalID is appended to the WorkKind when printing, to distinguish
+	// requesters of the same WorkKind.
+	additionalID string
+	granter      granter
+	usesTokens   bool
+	buf          *strings.Builder
 
 	waitingRequests        bool
 	returnValueFromGranted int64
@@ -43,15 +47,14 @@ type testRequester struct {
 }
 
 var _ requester = &testRequester{}
-var _ storeRequester = &testRequester{}
 
 func (tr *testRequester) hasWaitingRequests() bool {
 	return tr.waitingRequests
 }
 
 func (tr *testRequester) granted(grantChainID grantChainID) int64 {
-	fmt.Fprintf(tr.buf, "%s: granted in chain %d, and returning %d\n", workKindString(tr.workKind),
-		grantChainID, tr.returnValueFromGranted)
+	fmt.Fprintf(tr.buf, "%s%s: granted in chain %d, and returning %d\n",
+		workKindString(tr.workKind), tr.additionalID, grantChainID, tr.returnValueFromGranted)
 	tr.grantChainID = grantChainID
 	return tr.returnValueFromGranted
 }
@@ -60,30 +63,52 @@ func (tr *testRequester) close() {}
 
 func (tr *testRequester) tryGet(count int64) {
 	rv := tr.granter.tryGet(count)
-	fmt.Fprintf(tr.buf, "%s: tryGet(%d) returned %t\n", workKindString(tr.workKind), count, rv)
+	fmt.Fprintf(tr.buf, "%s%s: tryGet(%d) returned %t\n", workKindString(tr.workKind),
+		tr.additionalID, count, rv)
 }
 
 func (tr *testRequester) returnGrant(count int64) {
-	fmt.Fprintf(tr.buf, "%s: returnGrant(%d)\n", workKindString(tr.workKind), count)
+	fmt.Fprintf(tr.buf, "%s%s: returnGrant(%d)\n", workKindString(tr.workKind), tr.additionalID,
+		count)
 	tr.granter.returnGrant(count)
 }
 
 func (tr *testRequester) tookWithoutPermission(count int64) {
-	fmt.Fprintf(tr.buf, "%s: tookWithoutPermission(%d)\n", workKindString(tr.workKind), count)
+	fmt.Fprintf(tr.buf, "%s%s: tookWithoutPermission(%d)\n", workKindString(tr.workKind), tr.additionalID,
+		count)
 	tr.granter.tookWithoutPermission(count)
 }
 
 func (tr *testRequester) continueGrantChain() {
-	fmt.Fprintf(tr.buf, "%s: continueGrantChain\n", workKindString(tr.workKind))
+	fmt.Fprintf(tr.buf, "%s%s: continueGrantChain\n", workKindString(tr.workKind),
+		tr.additionalID)
 	tr.granter.continueGrantChain(tr.grantChainID)
 }
 
-func (tr *testRequester) getStoreAdmissionStats() storeAdmissionStats {
+// storeTestRequester is a storeRequester with a testRequester for each
+// admissionpb.WorkClass.
+type storeTestRequester struct {
+	requesters [admissionpb.NumWorkClasses]*testRequester
+}
+
+var _ storeRequester = &storeTestRequester{}
+
+func (str *storeTestRequester) getRequesters() [admissionpb.NumWorkClasses]requester {
+	var rv [admissionpb.NumWorkClasses]requester
+	for i := range str.requesters {
+		rv[i] = str.requesters[i]
+	}
+	return rv
+}
+
+func (str *storeTestRequester) close() {}
+
+func (str *storeTestRequester) getStoreAdmissionStats() storeAdmissionStats {
 	// Only used by ioLoadListener, so don't bother.
 	return storeAdmissionStats{}
 }
 
-func (tr *testRequester) setStoreRequestEstimates(estimates storeRequestEstimates) {
+func (str *storeTestRequester) setStoreRequestEstimates(estimates storeRequestEstimates) {
 	// Only used by ioLoadListener, so don't bother.
 }
 
@@ -100,12 +125,18 @@ func (tr *testRequester) setStoreRequestEstimates(estimates storeRequestEstimate
 // cpu-load runnable=<int> procs=<int> [infrequent=<bool>]
 // init-store-grant-coordinator
 // set-io-tokens tokens=<int>
+// set-elastic-disk-bw-tokens tokens=<int>
+//
+// The store GrantCoordinator has a requester for each work class, where
+// work=kv is the regular one and work=kv-elastic is the elastic one.
 func TestGranterBasic(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	defer log.Scope(t).Close(t)
 
 	var ambientCtx log.AmbientContext
-	var requesters [numWorkKinds]*testRequester
+	// requesters[numWorkKinds] is used for kv elastic work, when working with
+	// a store grant coordinator.
+	var requesters [numWorkKinds + 1]*testRequester
 	var coord *GrantCoordinator
 	clearRequesterAndCoord := func() {
 		coord = nil
@@ -161,21 +192,37 @@ func TestGranterBasic(t *testing.T) {
 			storeCoordinators := &StoreGrantCoordinators{
 				settings: settings,
 				makeStoreRequesterFunc: func(
-					ambientCtx log.AmbientContext, granter granter, settings *cluster.Settings,
-					opts workQueueOptions) storeRequester {
-					req := &testRequester{
-						workKind:               KVWork,
-						granter:                granter,
-						usesTokens:             true,
-						buf:                    &buf,
-						returnValueFromGranted: 0,
+					ambientCtx log.AmbientContext, granters [admissionpb.NumWorkClasses]granter,
+					settings *cluster.Settings, opts [admissionpb.NumWorkClasses]workQueueOptions,
+				) storeRequester {
+					makeTestRequester := func(wc admissionpb.WorkClass) *testRequester {
+						req := &testRequester{
+							workKind:               KVWork,
+							granter:                granters[wc],
+							usesTokens:             true,
+							buf:                    &buf,
+							returnValueFromGranted: 0,
+						}
+						if wc == admissionpb.ElasticWorkClass {
+							req.additionalID = "-elastic"
+						}
+						return req
 					}
-					requesters[KVWork] = req
+					req := &storeTestRequester{}
+					req.requesters[admissionpb.RegularWorkClass] =
+						makeTestRequester(admissionpb.RegularWorkClass)
+					req.requesters[admissionpb.ElasticWorkClass] =
+						makeTestRequester(admissionpb.ElasticWorkClass)
+					requesters[KVWork] = req.requesters[admissionpb.RegularWorkClass]
+					requesters[numWorkKinds] = req.requesters[admissionpb.ElasticWorkClass]
 					return req
 				},
-				kvIOTokensExhaustedDuration: metrics.KVIOTokensExhaustedDuration,
-				workQueueMetrics:            makeWorkQueueMetrics(""),
-				disableTickerForTesting:     true,
+				kvIOTokensExhaustedDuration:            metrics.KVIOTokensExhaustedDuration,
+				kvElasticDiskBWTokensExhaustedDuration: metrics.KVElasticDiskBWTokensExhaustedDuration,
+				workQueueMetrics: [admissionpb.NumWorkClasses]WorkQueueMetrics{
+					makeWorkQueueMetrics(""), makeWorkQueueMetrics("elastic"),
+				},
+				disableTickerForTesting: true,
 			}
 			var testMetricsProvider testMetricsProvider
 			testMetricsProvider.setMetricsForStores([]int32{1}, pebble.Metrics{})
@@ -251,6 +298,18 @@ func TestGranterBasic(t *testing.T) {
 			coord.testingTryGrant()
 			return flushAndReset()
 
+		case "set-elastic-disk-bw-tokens":
+			var tokens int
+			d.ScanArgs(t, "tokens", &tokens)
+			// We are not using a real ioLoadListener, and simply setting the
+			// tokens (the ioLoadListener has its own test).
+			coord.mu.Lock()
+			coord.granters[KVWork].(*kvStoreTokenGranter).setAvailableElasticDiskBandwidthTokensLocked(
+				int64(tokens))
+			coord.mu.Unlock()
+			coord.testingTryGrant()
+			return flushAndReset()
+
 		default:
 			return fmt.Sprintf("unknown command: %s", d.Cmd)
 		}
@@ -263,6 +322,9 @@ func scanWorkKind(t *testing.T, d *datadriven.TestData) WorkKind {
 	switch kindStr {
 	case "kv":
 		return KVWork
+	case "kv-elastic":
+		// Not a real WorkKind, see the requesters in TestGranterBasic.
+		return numWorkKinds
 	case "sql-kv-response":
 		return SQLKVResponseWork
 	case "sql-sql-response":
@@ -323,10 +385,18 @@ func TestStoreCoordinators(t *testing.T) {
 		Settings:          settings,
 		makeRequesterFunc: makeRequesterFunc,
 		makeStoreRequesterFunc: func(
-			ctx log.AmbientContext, granter granter, settings *cluster.Settings,
-			opts workQueueOptions) storeRequester {
-			req := makeRequesterFunc(ctx, KVWork, granter, settings, opts)
-			return req.(*testRequester)
+			ctx log.AmbientContext, granters [admissionpb.NumWorkClasses]granter,
+			settings *cluster.Settings, opts [admissionpb.NumWorkClasses]workQueueOptions,
+		) storeRequester {
+			reqReg := makeRequesterFunc(ctx, KVWork, granters[admissionpb.RegularWorkClass], settings,
+				opts[admissionpb.RegularWorkClass])
+			reqElastic := makeRequesterFunc(ctx, KVWork, granters[admissionpb.ElasticWorkClass],
+				settings, opts[admissionpb.ElasticWorkClass])
+			str := &storeTestRequester{}
+			str.requesters[admissionpb.RegularWorkClass] = reqReg.(*testRequester)
+			str.requesters[admissionpb.ElasticWorkClass] = reqElastic.(*testRequester)
+			str.requesters[admissionpb.ElasticWorkClass].additionalID = "-elastic"
+			return str
 		},
 	}
 	coords, _ := NewGrantCoordinators(ambientCtx, opts)
@@ -340,8 +410,8 @@ func TestStoreCoordinators(t *testing.T) {
 	// Setting the metrics provider will cause the initialization of two
 	// GrantCoordinators for the two stores.
 	storeCoords.SetPebbleMetricsProvider(context.Background(), &mp)
-	// Now we have 1+2 = 3 KVWork requesters.
-	require.Equal(t, 3, len(requesters))
+	// Now we have 1+2*2 = 5 KVWork requesters.
+	require.Equal(t, 5, len(requesters))
 	// Confirm that the store IDs are as expected.
 	var actualStores []int32
 
@@ -363,7 +433,9 @@ func TestStoreCoordinators(t *testing.T) {
 		requesters[i].tryGet(1)
 	}
 	require.Equal(t,
-		"kv: tryGet(1) returned false\nkv: tryGet(1) returned true\nkv: tryGet(1) returned true\n",
+		"kv: tryGet(1) returned false\n"+
+			"kv: tryGet(1) returned true\nkv-elastic: tryGet(1) returned true\n"+
+			"kv: tryGet(1) returned true\nkv-elastic: tryGet(1) returned true\n",
 		buf.String())
 	coords.Close()
 }
@@ -375,11 +447,7 @@ type testRequesterForIOLL struct {
 
 var _ storeRequester = &testRequesterForIOLL{}
 
-func (r *testRequesterForIOLL) hasWaitingRequests() bool {
-	panic("unimplemented")
-}
-
-func (r *testRequesterForIOLL) granted(grantChainID grantChainID) int64 {
+func (r *testRequesterForIOLL) getRequesters() [admissionpb.NumWorkClasses]requester {
 	panic("unimplemented")
 }
 
@@ -396,13 +464,26 @@ func (r *testRequesterForIOLL) setStoreRequestEstimates(estimates storeRequestEs
 }
 
 type testGranterWithIOTokens struct {
-	buf strings.Builder
+	buf        strings.Builder
+	tokensUsed [admissionpb.NumWorkClasses]int64
 }
 
+var _ granterWithIOTokens = &testGranterWithIOTokens{}
+
 func (g *testGranterWithIOTokens) setAvailableIOTokensLocked(tokens int64) {
 	fmt.Fprintf(&g.buf, "setAvailableIOTokens: %s", tokensFor1sToString(tokens))
 }
 
+func (g *testGranterWithIOTokens) setAvailableElasticDiskBandwidthTokensLocked(tokens int64) {
+	fmt.Fprintf(&g.buf, " setAvailableElasticDiskTokens: %s", tokensFor1sToString(tokens))
+}
+
+func (g *testGranterWithIOTokens) getDiskTokensUsedAndResetLocked() [admissionpb.NumWorkClasses]int64 {
+	tokensUsed := g.tokensUsed
+	g.tokensUsed = [admissionpb.NumWorkClasses]int64{}
+	return tokensUsed
+}
+
 func tokensForIntervalToString(tokens int64) string {
 	if tokens == unlimitedTokens {
 		return "unlimited"
@@ -421,6 +502,10 @@ func tokensFor1sToString(tokens int64) string {
 // sets the state for token calculation and then ticks adjustmentInterval
 // times to cause tokens to be set in the testGranterWithIOTokens:
 // set-state admitted=<int> l0-bytes=<int> l0-added=<int> l0-files=<int> l0-sublevels=<int>
+//   [disk-read=<int> disk-write=<int> provisioned-bandwidth=<int>]
+//   [regular-tokens-used=<int> elastic-tokens-used=<int>]
+//
+// The disk stats are cumulative, and the tokens used are for the interval.
 func TestIOLoadListener(t *testing.T) {
 	req := &testRequesterForIOLL{}
 	kvGranter := &testGranterWithIOTokens{}
@@ -479,7 +564,28 @@ func TestIOLoadListener(t *testing.T) {
 				var l0SubLevels int
 				d.ScanArgs(t, "l0-sublevels", &l0SubLevels)
 				metrics.Levels[0].Sublevels = int32(l0SubLevels)
-				ioll.pebbleMetricsTick(ctx, &metrics)
+				var diskStats DiskStats
+				if d.HasArg("disk-read") {
+					d.ScanArgs(t, "disk-read", &diskStats.BytesRead)
+				}
+				if d.HasArg("disk-write") {
+					d.ScanArgs(t, "disk-write", &diskStats.BytesWritten)
+				}
+				provisionedBandwidth.Override(ctx, &st.SV, 0)
+				if d.HasArg("provisioned-bandwidth") {
+					var bandwidth int
+					d.ScanArgs(t, "provisioned-bandwidth", &bandwidth)
+					provisionedBandwidth.Override(ctx, &st.SV, int64(bandwidth))
+				}
+				for wc, arg := range [admissionpb.NumWorkClasses]string{
+					"regular-tokens-used", "elastic-tokens-used"} {
+					if d.HasArg(arg) {
+						var tokensUsed int
+						d.ScanArgs(t, arg, &tokensUsed)
+						kvGranter.tokensUsed[wc] = int64(tokensUsed)
+					}
+				}
+				ioll.pebbleMetricsTick(ctx, StoreMetrics{Metrics: &metrics, DiskStats: diskStats})
 				// Do the ticks until just before next adjustment.
 				var buf strings.Builder
 				fmt.Fprintf(&buf, "admitted: %d, bytes: %d, added-bytes: %d,\nsmoothed-removed: %d, "+
@@ -489,6 +595,10 @@ func TestIOLoadListener(t *testing.T) {
 					int64(ioll.smoothedNumByteTokens), int64(ioll.smoothedPerWorkUnaccountedBytesAdded),
 					tokensForIntervalToString(ioll.totalTokens),
 					tokensFor1sToString(ioll.tokensAllocated))
+				if ioll.elasticDiskBWTokens != unlimitedTokens {
+					fmt.Fprintf(&buf, "elastic-disk-bw-tokens: %d, %s\n",
+						ioll.elasticDiskBWTokens, ioll.diskBWLimiter.String())
+				}
 				if req.buf.Len() > 0 {
 					fmt.Fprintf(&buf, "%s\n", req.buf.String())
 					req.buf.Reset()
@@ -532,8 +642,8 @@ func TestIOLoadListenerOverflow(t *testing.T) {
 		Sublevels: 100,
 		NumFiles:  10000,
 	}
-	ioll.pebbleMetricsTick(ctx, &m)
-	ioll.pebbleMetricsTick(ctx, &m)
+	ioll.pebbleMetricsTick(ctx, StoreMetrics{Metrics: &m})
+	ioll.pebbleMetricsTick(ctx, StoreMetrics{Metrics: &m})
 	ioll.allocateTokensTick()
 }
 
@@ -541,14 +651,25 @@ type testGranterNonNegativeTokens struct {
 	t *testing.T
 }
 
+var _ granterWithIOTokens = &testGranterNonNegativeTokens{}
+
 func (g *testGranterNonNegativeTokens) setAvailableIOTokensLocked(tokens int64) {
 	require.LessOrEqual(g.t, int64(0), tokens)
 }
 
+func (g *testGranterNonNegativeTokens) setAvailableElasticDiskBandwidthTokensLocked(tokens int64) {
+	require.LessOrEqual(g.t, int64(0), tokens)
+}
+
+func (g *testGranterNonNegativeTokens) getDiskTokensUsedAndResetLocked() [admissionpb.NumWorkClasses]int64 {
+	return [admissionpb.NumWorkClasses]int64{}
+}
+
 // TestBadIOLoadListenerStats tests that bad stats (non-monotonic cumulative
 // stats and negative values) don't cause panics or tokens to be negative.
 func TestBadIOLoadListenerStats(t *testing.T) {
 	var m pebble.Metrics
+	var d DiskStats
 	req := &testRequesterForIOLL{}
 	ctx := context.Background()
 
@@ -559,6 +680,8 @@ func TestBadIOLoadListenerStats(t *testing.T) {
 		m.Levels[0].Size = int64(rand.Uint64())
 		m.Levels[0].BytesFlushed = rand.Uint64()
 		m.Levels[0].BytesIngested = rand.Uint64()
+		d.BytesRead = rand.Uint64()
+		d.BytesWritten = rand.Uint64()
 		req.stats.admittedCount = rand.Uint64()
 		req.stats.admittedWithBytesCount = rand.Uint64()
 		req.stats.admittedBytes = rand.Uint64()
@@ -567,6 +690,7 @@ func TestBadIOLoadListenerStats(t *testing.T) {
 	}
 	kvGranter := &testGranterNonNegativeTokens{t: t}
 	st := cluster.MakeTestingClusterSettings()
+	provisionedBandwidth.Override(ctx, &st.SV, 1<<30)
 	ioll := ioLoadListener{
 		settings:    st,
 		kvRequester: req,
@@ -575,7 +699,7 @@ func TestBadIOLoadListenerStats(t *testing.T) {
 	ioll.mu.kvGranter = kvGranter
 	for i := 0; i < 100; i++ {
 		randomValues()
-		ioll.pebbleMetricsTick(ctx, &m)
+		ioll.pebbleMetricsTick(ctx, StoreMetrics{Metrics: &m, DiskStats: d})
 		for j := 0; j < adjustmentInterval; j++ {
 			ioll.allocateTokensTick()
 			require.LessOrEqual(t, int64(0), ioll.smoothedBytesRemoved)
@@ -584,6 +708,8 @@ func TestBadIOLoadListenerStats(t *testing.T) {
 			require.LessOrEqual(t, float64(0), ioll.smoothedNumByteTokens)
 			require.LessOrEqual(t, int64(0), ioll.totalTokens)
 			require.LessOrEqual(t, int64(0), ioll.tokensAllocated)
+			require.LessOrEqual(t, int64(0), ioll.elasticDiskBWTokens)
+			require.LessOrEqual(t, int64(0), ioll.elasticDiskBWTokensAllocated)
 		}
 	}
 }
@@ -1562,9 +1562,11 @@ type StoreWriteWorkInfo struct {
 	IngestRequest bool
 }
 
-// StoreWorkQueue is responsible for admission to a store.
+// StoreWorkQueue is responsible for admission to a store. It has a WorkQueue
+// for each admissionpb.WorkClass, since elastic work is subject to additional
+// throttling (see kvStoreTokenGranter).
 type StoreWorkQueue struct {
-	q  WorkQueue
+	q  [admissionpb.NumWorkClasses]WorkQueue
 	mu struct {
 		syncutil.RWMutex
 		estimates storeRequestEstimates
@@ -1576,7 +1578,8 @@ type StoreWorkQueue struct {
 // needed by the caller (see StoreWorkHandle.AdmissionEnabled) and by
 // StoreWorkQueue.AdmittedWorkDone.
 type StoreWorkHandle struct {
-	tenantID roachpb.TenantID
+	tenantID  roachpb.TenantID
+	workClass admissionpb.WorkClass
 	// Equal to StoreWriteWorkInfo.WriteBytes.
 	writeBytes int64
 	// The writeTokens acquired by this request.
@@ -1607,6 +1610,7 @@ func (q *StoreWorkQueue) Admit(
 	}
 	h := StoreWorkHandle{
 		tenantID:      info.TenantID,
+		workClass:     admissionpb.WorkClassFromPri(info.Priority),
 		writeBytes:    info.WriteBytes,
 		ingestRequest: info.IngestRequest,
 	}
@@ -1621,7 +1625,7 @@ func (q *StoreWorkQueue) Admit(
 	h.writeTokens += estimates.workByteAddition
 	h.workByteAdditionTokens = estimates.workByteAddition
 	info.WorkInfo.requestedCount = h.writeTokens
-	enabled, err := q.q.Admit(ctx, info.WorkInfo)
+	enabled, err := q.q[h.workClass].Admit(ctx, info.WorkInfo)
 	if err != nil {
 		return StoreWorkHandle{}, err
 	}
@@ -1668,26 +1672,30 @@ func (q *StoreWorkQueue) AdmittedWorkDone(h StoreWorkHandle, ingestedIntoL0Bytes
 	// can be negative.
 	tokensToAllocate := ingestedIntoL0Bytes - (h.writeTokens - h.workByteAdditionTokens)
 	if tokensToAllocate != 0 {
-		q.q.forceAllocateTokens(h.tenantID, tokensToAllocate)
+		q.q[h.workClass].forceAllocateTokens(h.tenantID, tokensToAllocate)
 	}
 	return err
 }
 
 // SetTenantWeights passes through to WorkQueue.SetTenantWeights.
 func (q *StoreWorkQueue) SetTenantWeights(tenantWeights map[uint64]uint32) {
-	q.q.SetTenantWeights(tenantWeights)
-}
-
-func (q *StoreWorkQueue) hasWaitingRequests() bool {
-	return q.q.hasWaitingRequests()
+	for i := range q.q {
+		q.q[i].SetTenantWeights(tenantWeights)
+	}
 }
 
-func (q *StoreWorkQueue) granted(grantChainID grantChainID) int64 {
-	return q.q.granted(grantChainID)
+func (q *StoreWorkQueue) getRequesters() [admissionpb.NumWorkClasses]requester {
+	var result [admissionpb.NumWorkClasses]requester
+	for i := range q.q {
+		result[i] = &q.q[i]
+	}
+	return result
 }
 
 func (q *StoreWorkQueue) close() {
-	q.q.close()
+	for i := range q.q {
+		q.q[i].close()
+	}
 }
 
 func (q *StoreWorkQueue) getStoreAdmissionStats() storeAdmissionStats {
@@ -1703,10 +1711,15 @@ func (q *StoreWorkQueue) setStoreRequestEstimates(estimates storeRequestEstimate
 }
 
 func makeStoreWorkQueue(
-	ambientCtx log.AmbientContext, granter granter, settings *cluster.Settings, opts workQueueOptions,
+	ambientCtx log.AmbientContext,
+	granters [admissionpb.NumWorkClasses]granter,
+	settings *cluster.Settings,
+	opts [admissionpb.NumWorkClasses]workQueueOptions,
 ) storeRequester {
 	q := &StoreWorkQueue{}
-	initWorkQueue(&q.q, ambientCtx, KVWork, granter, settings, opts)
+	for i := range q.q {
+		initWorkQueue(&q.q[i], ambientCtx, KVWork, granters[i], settings, opts[i])
+	}
 	// Arbitrary initial values. These will be replaced before any meaningful
 	// token constraints are enforced.
 	q.mu.estimates = storeRequestEstimates{
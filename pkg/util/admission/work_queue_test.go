@@ -55,6 +55,8 @@ func (b *builderWithMu) stringAndReset() string {
 }
 
 type testGranter struct {
+	// name, when non-empty, prefixes what is printed by the testGranter.
+	name                  string
 	buf                   *builderWithMu
 	r                     requester
 	returnValueFromTryGet bool
@@ -62,21 +64,28 @@ type testGranter struct {
 
 var _ granter = &testGranter{}
 
+func (tg *testGranter) printf(format string, a ...interface{}) {
+	if tg.name != "" {
+		format = tg.name + ": " + format
+	}
+	tg.buf.printf(format, a...)
+}
+
 func (tg *testGranter) grantKind() grantKind {
 	return slot
 }
 func (tg *testGranter) tryGet(count int64) bool {
-	tg.buf.printf("tryGet: returning %t", tg.returnValueFromTryGet)
+	tg.printf("tryGet: returning %t", tg.returnValueFromTryGet)
 	return tg.returnValueFromTryGet
 }
 func (tg *testGranter) returnGrant(count int64) {
-	tg.buf.printf("returnGrant %d", count)
+	tg.printf("returnGrant %d", count)
 }
 func (tg *testGranter) tookWithoutPermission(count int64) {
-	tg.buf.printf("tookWithoutPermission %d", count)
+	tg.printf("tookWithoutPermission %d", count)
 }
 func (tg *testGranter) continueGrantChain(grantChainID grantChainID) {
-	tg.buf.printf("continueGrantChain %d", grantChainID)
+	tg.printf("continueGrantChain %d", grantChainID)
 }
 func (tg *testGranter) grant(grantChainID grantChainID) {
 	rv := tg.r.granted(grantChainID)
@@ -87,7 +96,7 @@ func (tg *testGranter) grant(grantChainID grantChainID) {
 		// concurrency_manager_test.go.
 		time.Sleep(50 * time.Millisecond)
 	}
-	tg.buf.printf("granted: returned %d", rv)
+	tg.printf("granted: returned %d", rv)
 }
 
 type testWork struct {
@@ -444,11 +453,14 @@ TestStoreWorkQueueBasic is a datadriven test with the following commands:
 init
 admit id=<int> tenant=<int> priority=<int> create-time-millis=<int> bypass=<bool>
   [write-bytes=<int>] [ingest-request=<bool>]
-set-try-get-return-value v=<bool>
-granted
+set-try-get-return-value v=<bool> [elastic=<bool>]
+granted [elastic=<bool>]
 cancel-work id=<int>
 work-done id=<int> [ingested-into-l0=<int>]
 print
+
+Work with priority < admissionpb.NormalPri is elastic, and is queued in the
+elastic WorkQueue, which has its own testGranter.
 */
 func TestStoreWorkQueueBasic(t *testing.T) {
 	defer leaktest.AfterTest(t)()
@@ -461,15 +473,27 @@ func TestStoreWorkQueueBasic(t *testing.T) {
 		}
 	}
 	defer closeFn()
-	var tg *testGranter
+	var tg [admissionpb.NumWorkClasses]*testGranter
 	var wrkMap workMap
 	var buf builderWithMu
 	var st *cluster.Settings
 	printQueue := func() string {
 		q.mu.Lock()
 		defer q.mu.Unlock()
-		return fmt.Sprintf("%s\nstats:%+v\nestimates:%+v", q.q.String(), q.mu.stats,
-			q.mu.estimates)
+		return fmt.Sprintf("regular workqueue: %s\nelastic workqueue: %s\nstats:%+v\nestimates:%+v",
+			q.q[admissionpb.RegularWorkClass].String(), q.q[admissionpb.ElasticWorkClass].String(),
+			q.mu.stats, q.mu.estimates)
+	}
+	scanWorkClass := func(d *datadriven.TestData) admissionpb.WorkClass {
+		wc := admissionpb.RegularWorkClass
+		if d.HasArg("elastic") {
+			var elastic bool
+			d.ScanArgs(t, "elastic", &elastic)
+			if elastic {
+				wc = admissionpb.ElasticWorkClass
+			}
+		}
+		return wc
 	}
 
 	datadriven.RunTest(t, testutils.TestDataPath(t, "store_work_queue"),
@@ -477,15 +501,23 @@ func TestStoreWorkQueueBasic(t *testing.T) {
 			switch d.Cmd {
 			case "init":
 				closeFn()
-				tg = &testGranter{buf: &buf}
-				opts := makeWorkQueueOptions(KVWork)
-				opts.usesTokens = true
-				opts.timeSource = timeutil.NewManualTime(timeutil.FromUnixMicros(0))
-				opts.disableEpochClosingGoroutine = true
+				var granters [admissionpb.NumWorkClasses]granter
+				var opts [admissionpb.NumWorkClasses]workQueueOptions
+				for i := range tg {
+					tg[i] = &testGranter{name: admissionpb.WorkClass(i).String(), buf: &buf}
+					granters[i] = tg[i]
+					opts[i] = makeWorkQueueOptions(KVWork)
+					opts[i].usesTokens = true
+					opts[i].timeSource = timeutil.NewManualTime(timeutil.FromUnixMicros(0))
+					opts[i].disableEpochClosingGoroutine = true
+				}
 				st = cluster.MakeTestingClusterSettings()
 				q = makeStoreWorkQueue(log.MakeTestingAmbientContext(tracing.NewTracer()),
-					tg, st, opts).(*StoreWorkQueue)
-				tg.r = q
+					granters, st, opts).(*StoreWorkQueue)
+				requesters := q.getRequesters()
+				for i := range tg {
+					tg[i].r = requesters[i]
+				}
 				wrkMap.resetMap()
 				return ""
 
@@ -539,7 +571,7 @@ func TestStoreWorkQueueBasic(t *testing.T) {
 			case "set-try-get-return-value":
 				var v bool
 				d.ScanArgs(t, "v", &v)
-				tg.returnValueFromTryGet = v
+				tg[scanWorkClass(d)].returnValueFromTryGet = v
 				return ""
 
 			case "set-store-request-estimates":
@@ -554,7 +586,7 @@ func TestStoreWorkQueueBasic(t *testing.T) {
 				return printQueue()
 
 			case "granted":
-				tg.grant(0)
+				tg[scanWorkClass(d)].grant(0)
 				return buf.stringAndReset()
 
 			case "cancel-work":
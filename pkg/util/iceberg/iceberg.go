@@ -0,0 +1,409 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+// Package iceberg maintains Apache Iceberg tables of Parquet files in cloud
+// storage, so that the files can be queried in place by engines which read
+// Iceberg tables.
+//
+// Tables use version 1 of the Iceberg table format, are unpartitioned and are
+// laid out like the tables of a Hadoop catalog: the metadata of a table lives
+// in the metadata directory of the table, in files named v<N>.metadata.json,
+// and metadata/version-hint.text holds the current version N. Each commit
+// appends a new snapshot referencing the data files added by the commit.
+//
+// A table must only be written by one writer at a time.
+package iceberg
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/cloud"
+	"github.com/cockroachdb/cockroach/pkg/util/ioctx"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+	"github.com/cockroachdb/errors"
+	"github.com/fraugster/parquet-go/parquetschema"
+)
+
+const (
+	metadataDir     = "metadata"
+	versionHintFile = "version-hint.text"
+
+	// nameMappingProperty is the table property holding the name mapping of
+	// the table.
+	nameMappingProperty = "schema.name-mapping.default"
+)
+
+// DataFile is a Parquet file appended to a table.
+type DataFile struct {
+	// Path is the URI of the file.
+	Path string
+	// RecordCount is the number of records in the file.
+	RecordCount int64
+	// SizeBytes is the size of the file.
+	SizeBytes int64
+}
+
+// Table is an Iceberg table whose metadata is stored in a directory of an
+// ExternalStorage.
+type Table struct {
+	store cloud.ExternalStorage
+	// dir is the directory of the table in store.
+	dir string
+	// location is the URI of dir, without credentials, which the metadata of
+	// the table uses to reference its files.
+	location string
+}
+
+// NewTable returns the table stored in dir of store, which is located at the
+// given URI. The table is created by its first commit.
+func NewTable(store cloud.ExternalStorage, dir, location string) *Table {
+	return &Table{store: store, dir: dir, location: strings.TrimSuffix(location, "/")}
+}
+
+// Location returns the URI of the given storage URI that can be used in the
+// metadata of tables, which strips it of its credentials and options.
+func Location(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+	u.User = nil
+	u.RawQuery = ""
+	u.Fragment = ""
+	return strings.TrimSuffix(u.String(), "/"), nil
+}
+
+// FileLocation returns the URI of a file in the directory of the table.
+func (t *Table) FileLocation(name string) string {
+	return t.location + "/" + name
+}
+
+// CurrentSnapshotSummary returns the summary of the current snapshot of the
+// table, or nil if the table does not exist yet.
+func (t *Table) CurrentSnapshotSummary(ctx context.Context) (map[string]string, error) {
+	m, _, err := t.readMetadata(ctx)
+	if err != nil || m == nil {
+		return nil, err
+	}
+	if s := m.currentSnapshot(); s != nil {
+		return s.Summary, nil
+	}
+	return nil, nil
+}
+
+// Append commits a snapshot of the table which appends the given files to
+// the table. Each file must have one of the given Parquet schemas, which the
+// schema of the table evolves through in order. The given properties are
+// added to the summary of the snapshot.
+func (t *Table) Append(
+	ctx context.Context,
+	defs []*parquetschema.SchemaDefinition,
+	files []DataFile,
+	properties map[string]string,
+) error {
+	m, version, err := t.readMetadata(ctx)
+	if err != nil {
+		return err
+	}
+	now := timeutil.Now().UnixNano() / 1e6
+	if m == nil {
+		m = newTableMetadata(t.location)
+	} else {
+		m.MetadataLog = append(m.MetadataLog, metadataLogEntry{
+			TimestampMs:  m.LastUpdatedMs,
+			MetadataFile: t.FileLocation(metadataFilename(version)),
+		})
+	}
+	for _, def := range defs {
+		schema, err := SchemaFromParquet(def)
+		if err != nil {
+			return err
+		}
+		if err := m.setSchema(schema); err != nil {
+			return err
+		}
+	}
+
+	snapshotID := newSnapshotID()
+	var manifests []map[string]interface{}
+	parent := m.currentSnapshot()
+	if parent != nil {
+		if manifests, err = t.readManifestList(ctx, parent.ManifestList); err != nil {
+			return err
+		}
+	}
+	manifest, err := t.writeManifest(ctx, snapshotID, m.currentSchema(), files)
+	if err != nil {
+		return err
+	}
+	manifests = append(manifests, manifest)
+	manifestListName := path.Join(metadataDir, fmt.Sprintf("snap-%d-1-%s.avro", snapshotID, uuid.MakeV4()))
+	if err := t.writeManifestList(ctx, manifestListName, snapshotID, manifests); err != nil {
+		return err
+	}
+
+	snapshot := snapshot{
+		SnapshotID:   snapshotID,
+		TimestampMs:  now,
+		Summary:      snapshotSummary(parent, files, properties),
+		ManifestList: t.FileLocation(manifestListName),
+		SchemaID:     m.CurrentSchemaID,
+	}
+	if parent != nil {
+		snapshot.ParentSnapshotID = &parent.SnapshotID
+	}
+	m.Snapshots = append(m.Snapshots, snapshot)
+	m.CurrentSnapshotID = snapshotID
+	m.SnapshotLog = append(m.SnapshotLog, snapshotLogEntry{TimestampMs: now, SnapshotID: snapshotID})
+	m.LastUpdatedMs = now
+	return t.writeMetadata(ctx, m, version+1)
+}
+
+// snapshotSummary returns the summary of a snapshot appending the files to
+// the parent snapshot.
+func snapshotSummary(
+	parent *snapshot, files []DataFile, properties map[string]string,
+) map[string]string {
+	var records, size int64
+	for _, f := range files {
+		records += f.RecordCount
+		size += f.SizeBytes
+	}
+	total := func(key string, added int64) string {
+		var prev int64
+		if parent != nil {
+			prev, _ = strconv.ParseInt(parent.Summary[key], 10, 64)
+		}
+		return strconv.FormatInt(prev+added, 10)
+	}
+	summary := map[string]string{
+		"operation":          "append",
+		"added-data-files":   strconv.Itoa(len(files)),
+		"added-records":      strconv.FormatInt(records, 10),
+		"added-files-size":   strconv.FormatInt(size, 10),
+		"total-data-files":   total("total-data-files", int64(len(files))),
+		"total-records":      total("total-records", records),
+		"total-files-size":   total("total-files-size", size),
+		"total-delete-files": "0",
+	}
+	for k, v := range properties {
+		summary[k] = v
+	}
+	return summary
+}
+
+// newSnapshotID returns a random, positive snapshot ID.
+func newSnapshotID() int64 {
+	id := uuid.MakeV4()
+	var n int64
+	for _, b := range id.GetBytes()[:8] {
+		n = n<<8 | int64(b)
+	}
+	return n & math.MaxInt64
+}
+
+func metadataFilename(version int) string {
+	return path.Join(metadataDir, fmt.Sprintf("v%d.metadata.json", version))
+}
+
+// readMetadata returns the current metadata of the table and its version, or
+// nil if the table does not exist yet.
+func (t *Table) readMetadata(ctx context.Context) (*tableMetadata, int, error) {
+	hint, err := t.readFile(ctx, path.Join(metadataDir, versionHintFile))
+	if errors.Is(err, cloud.ErrFileDoesNotExist) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	version, err := strconv.Atoi(strings.TrimSpace(string(hint)))
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "parsing %s", versionHintFile)
+	}
+	b, err := t.readFile(ctx, metadataFilename(version))
+	if err != nil {
+		return nil, 0, err
+	}
+	m := &tableMetadata{}
+	if err := json.Unmarshal(b, m); err != nil {
+		return nil, 0, errors.Wrapf(err, "parsing %s", metadataFilename(version))
+	}
+	if m.FormatVersion != 1 {
+		return nil, 0, errors.Newf("unsupported iceberg format version %d", m.FormatVersion)
+	}
+	return m, version, nil
+}
+
+// writeMetadata writes the given version of the metadata of the table, and
+// then makes it the current version.
+func (t *Table) writeMetadata(ctx context.Context, m *tableMetadata, version int) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	if err := t.writeFile(ctx, metadataFilename(version), b); err != nil {
+		return err
+	}
+	return t.writeFile(ctx, path.Join(metadataDir, versionHintFile), []byte(strconv.Itoa(version)))
+}
+
+// relativePath returns the path in the directory of the table of a file
+// referenced by the metadata of the table.
+func (t *Table) relativePath(location string) (string, error) {
+	if !strings.HasPrefix(location, t.location+"/") {
+		return "", errors.Newf("%s is not located in the table at %s", location, t.location)
+	}
+	return strings.TrimPrefix(location, t.location+"/"), nil
+}
+
+func (t *Table) readFile(ctx context.Context, name string) ([]byte, error) {
+	r, err := t.store.ReadFile(ctx, path.Join(t.dir, name))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close(ctx)
+	return ioctx.ReadAll(ctx, r)
+}
+
+func (t *Table) writeFile(ctx context.Context, name string, b []byte) error {
+	return cloud.WriteFile(ctx, t.store, path.Join(t.dir, name), bytes.NewReader(b))
+}
+
+// tableMetadata is the metadata of a table, serialized as a
+// v<N>.metadata.json file. Both the fields of the format version 1 and their
+// replacements in the following versions are set, as recommended by the
+// specification.
+type tableMetadata struct {
+	FormatVersion      int                `json:"format-version"`
+	TableUUID          string             `json:"table-uuid"`
+	Location           string             `json:"location"`
+	LastUpdatedMs      int64              `json:"last-updated-ms"`
+	LastColumnID       int                `json:"last-column-id"`
+	Schema             Schema             `json:"schema"`
+	CurrentSchemaID    int                `json:"current-schema-id"`
+	Schemas            []Schema           `json:"schemas"`
+	PartitionSpec      []json.RawMessage  `json:"partition-spec"`
+	DefaultSpecID      int                `json:"default-spec-id"`
+	PartitionSpecs     []partitionSpec    `json:"partition-specs"`
+	LastPartitionID    int                `json:"last-partition-id"`
+	DefaultSortOrderID int                `json:"default-sort-order-id"`
+	SortOrders         []sortOrder        `json:"sort-orders"`
+	Properties         map[string]string  `json:"properties"`
+	CurrentSnapshotID  int64              `json:"current-snapshot-id"`
+	Snapshots          []snapshot         `json:"snapshots"`
+	SnapshotLog        []snapshotLogEntry `json:"snapshot-log"`
+	MetadataLog        []metadataLogEntry `json:"metadata-log"`
+}
+
+type partitionSpec struct {
+	SpecID int               `json:"spec-id"`
+	Fields []json.RawMessage `json:"fields"`
+}
+
+type sortOrder struct {
+	OrderID int               `json:"order-id"`
+	Fields  []json.RawMessage `json:"fields"`
+}
+
+type snapshot struct {
+	SnapshotID       int64             `json:"snapshot-id"`
+	ParentSnapshotID *int64            `json:"parent-snapshot-id,omitempty"`
+	TimestampMs      int64             `json:"timestamp-ms"`
+	Summary          map[string]string `json:"summary"`
+	ManifestList     string            `json:"manifest-list"`
+	SchemaID         int               `json:"schema-id"`
+}
+
+type snapshotLogEntry struct {
+	TimestampMs int64 `json:"timestamp-ms"`
+	SnapshotID  int64 `json:"snapshot-id"`
+}
+
+type metadataLogEntry struct {
+	TimestampMs  int64  `json:"timestamp-ms"`
+	MetadataFile string `json:"metadata-file"`
+}
+
+// noSnapshotID is the current snapshot ID of tables without snapshots.
+const noSnapshotID = -1
+
+func newTableMetadata(location string) *tableMetadata {
+	return &tableMetadata{
+		FormatVersion:     1,
+		TableUUID:         uuid.MakeV4().String(),
+		Location:          location,
+		PartitionSpec:     []json.RawMessage{},
+		PartitionSpecs:    []partitionSpec{{SpecID: 0, Fields: []json.RawMessage{}}},
+		SortOrders:        []sortOrder{{OrderID: 0, Fields: []json.RawMessage{}}},
+		Properties:        map[string]string{},
+		CurrentSnapshotID: noSnapshotID,
+		Snapshots:         []snapshot{},
+		SnapshotLog:       []snapshotLogEntry{},
+		MetadataLog:       []metadataLogEntry{},
+	}
+}
+
+// currentSchema returns the current schema of the table, or nil if the table
+// has no schema yet.
+func (m *tableMetadata) currentSchema() *Schema {
+	for i := range m.Schemas {
+		if m.Schemas[i].SchemaID == m.CurrentSchemaID {
+			return &m.Schemas[i]
+		}
+	}
+	return nil
+}
+
+// currentSnapshot returns the current snapshot of the table, or nil if the
+// table has no snapshot yet.
+func (m *tableMetadata) currentSnapshot() *snapshot {
+	for i := range m.Snapshots {
+		if m.Snapshots[i].SnapshotID == m.CurrentSnapshotID {
+			return &m.Snapshots[i]
+		}
+	}
+	return nil
+}
+
+// setSchema makes the table use a schema which can read files with schema s.
+func (m *tableMetadata) setSchema(s Schema) error {
+	evolved, err := m.evolve(s)
+	if err != nil {
+		return err
+	}
+	if cur := m.currentSchema(); cur == nil || cur.SchemaID != evolved.SchemaID {
+		m.Schemas = append(m.Schemas, evolved)
+	}
+	m.CurrentSchemaID = evolved.SchemaID
+	m.Schema = evolved
+	for _, f := range evolved.Fields {
+		if f.ID > m.LastColumnID {
+			m.LastColumnID = f.ID
+		}
+		if f.Type.List != nil && f.Type.List.ElementID > m.LastColumnID {
+			m.LastColumnID = f.Type.List.ElementID
+		}
+	}
+	mapping, err := m.nameMapping()
+	if err != nil {
+		return err
+	}
+	m.Properties[nameMappingProperty] = string(mapping)
+	return nil
+}
@@ -0,0 +1,210 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package iceberg
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/blobs"
+	"github.com/cockroachdb/cockroach/pkg/cloud"
+	"github.com/cockroachdb/cockroach/pkg/cloud/nodelocal"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	goparquet "github.com/fraugster/parquet-go"
+	"github.com/fraugster/parquet-go/parquetschema"
+	"github.com/linkedin/goavro/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func makeTestStore(t *testing.T) (cloud.ExternalStorage, func()) {
+	dir, cleanup := testutils.TempDir(t)
+	store, err := nodelocal.TestingMakeLocalStorage(
+		context.Background(),
+		roachpb.ExternalStorage_LocalFilePath{Path: "/"},
+		cluster.MakeTestingClusterSettings(),
+		blobs.TestBlobServiceClient(dir),
+		base.ExternalIODirConfig{},
+	)
+	require.NoError(t, err)
+	return store, func() {
+		require.NoError(t, store.Close())
+		cleanup()
+	}
+}
+
+func parseSchema(t *testing.T, text string) *parquetschema.SchemaDefinition {
+	def, err := parquetschema.ParseSchemaDefinition(text)
+	require.NoError(t, err)
+	return def
+}
+
+func TestTableAppend(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	store, cleanup := makeTestStore(t)
+	defer cleanup()
+	table := NewTable(store, "tbl", "nodelocal://0/tbl")
+
+	summary, err := table.CurrentSnapshotSummary(ctx)
+	require.NoError(t, err)
+	require.Nil(t, summary)
+
+	readMetadata := func() (*tableMetadata, int) {
+		m, version, err := table.readMetadata(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, m)
+		return m, version
+	}
+	fieldNames := func(m *tableMetadata) map[string]int {
+		names := make(map[string]int)
+		for _, f := range m.currentSchema().Fields {
+			names[f.Name] = f.ID
+		}
+		return names
+	}
+
+	def := parseSchema(t, `message root {
+		required int32 a;
+		optional binary b (STRING);
+		optional group c (LIST) {
+			repeated group list {
+				optional double element;
+			}
+		}
+	}`)
+	require.NoError(t, table.Append(ctx, []*parquetschema.SchemaDefinition{def}, []DataFile{
+		{Path: "nodelocal://0/tbl/data/1.parquet", RecordCount: 10, SizeBytes: 100},
+		{Path: "nodelocal://0/tbl/data/2.parquet", RecordCount: 5, SizeBytes: 50},
+	}, map[string]string{"crdb.test": "first"}))
+
+	m, version := readMetadata()
+	require.Equal(t, 1, version)
+	require.Equal(t, "nodelocal://0/tbl", m.Location)
+	require.Equal(t, map[string]int{"a": 1, "b": 2, "c": 3}, fieldNames(m))
+	require.Equal(t, Type{List: &ListType{ElementID: 4, Element: "double"}}, m.currentSchema().Fields[2].Type)
+	require.True(t, m.currentSchema().Fields[0].Required)
+	require.Equal(t, 4, m.LastColumnID)
+	require.Len(t, m.Snapshots, 1)
+	first := m.Snapshots[0]
+	require.Nil(t, first.ParentSnapshotID)
+	require.Equal(t, "15", first.Summary["total-records"])
+	require.Equal(t, "first", first.Summary["crdb.test"])
+	require.JSONEq(t,
+		`[{"field-id":1,"names":["a"]},{"field-id":2,"names":["b"]},`+
+			`{"field-id":3,"names":["c"],"fields":[{"field-id":4,"names":["element"]}]}]`,
+		m.Properties[nameMappingProperty])
+
+	// A second commit with files of a new schema adds a snapshot which includes
+	// the files of the first one, and evolves the schema of the table.
+	def = parseSchema(t, `message root {
+		required int64 a;
+		optional binary d (STRING);
+		optional binary b (STRING);
+	}`)
+	require.NoError(t, table.Append(ctx, []*parquetschema.SchemaDefinition{def}, []DataFile{
+		{Path: "nodelocal://0/tbl/data/3.parquet", RecordCount: 1, SizeBytes: 10},
+	}, map[string]string{"crdb.test": "second"}))
+
+	m, version = readMetadata()
+	require.Equal(t, 2, version)
+	require.Len(t, m.Schemas, 2)
+	require.Equal(t, 1, m.CurrentSchemaID)
+	require.Equal(t, map[string]int{"a": 1, "d": 5, "b": 2}, fieldNames(m))
+	require.Equal(t, "long", m.currentSchema().Fields[0].Type.Primitive)
+	// The new column is optional, since the previous files do not have it.
+	require.False(t, m.currentSchema().Fields[1].Required)
+	require.Equal(t, 5, m.LastColumnID)
+	require.Len(t, m.Snapshots, 2)
+	require.Equal(t, first.SnapshotID, *m.Snapshots[1].ParentSnapshotID)
+	require.Equal(t, "16", m.Snapshots[1].Summary["total-records"])
+	require.Equal(t, "1", m.Snapshots[1].Summary["added-data-files"])
+	require.Len(t, m.MetadataLog, 1)
+	require.Equal(t, "nodelocal://0/tbl/metadata/v1.metadata.json", m.MetadataLog[0].MetadataFile)
+
+	summary, err = table.CurrentSnapshotSummary(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "second", summary["crdb.test"])
+
+	// The manifest list of the second snapshot references the manifests of
+	// both commits.
+	manifests, err := table.readManifestList(ctx, m.Snapshots[1].ManifestList)
+	require.NoError(t, err)
+	require.Len(t, manifests, 2)
+	var paths []string
+	for _, manifest := range manifests {
+		name, err := table.relativePath(manifest["manifest_path"].(string))
+		require.NoError(t, err)
+		b, err := table.readFile(ctx, name)
+		require.NoError(t, err)
+		r, err := goavro.NewOCFReader(bytes.NewReader(b))
+		require.NoError(t, err)
+		for r.Scan() {
+			datum, err := r.Read()
+			require.NoError(t, err)
+			dataFile := datum.(map[string]interface{})["data_file"].(map[string]interface{})
+			paths = append(paths, dataFile["file_path"].(string))
+		}
+		require.NoError(t, r.Err())
+	}
+	require.Equal(t, []string{
+		"nodelocal://0/tbl/data/1.parquet",
+		"nodelocal://0/tbl/data/2.parquet",
+		"nodelocal://0/tbl/data/3.parquet",
+	}, paths)
+
+	// Appending files with the same schema does not add a schema.
+	require.NoError(t, table.Append(ctx, []*parquetschema.SchemaDefinition{def}, nil, nil))
+	m, _ = readMetadata()
+	require.Len(t, m.Schemas, 2)
+
+	// Columns cannot change to an incompatible type.
+	def = parseSchema(t, `message root { required binary a; }`)
+	require.EqualError(t, table.Append(ctx, []*parquetschema.SchemaDefinition{def}, nil, nil),
+		"column a changed type from long to binary")
+
+	// The metadata is valid JSON that other writers can read.
+	b, err := table.readFile(ctx, metadataFilename(3))
+	require.NoError(t, err)
+	var parsed map[string]interface{}
+	require.NoError(t, json.Unmarshal(b, &parsed))
+	require.EqualValues(t, 1, parsed["format-version"])
+	require.EqualValues(t, m.CurrentSnapshotID, parsed["current-snapshot-id"])
+}
+
+func TestReadParquetFooter(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	store, cleanup := makeTestStore(t)
+	defer cleanup()
+
+	def := parseSchema(t, `message root { required int64 a; }`)
+	var buf bytes.Buffer
+	w := goparquet.NewFileWriter(&buf, goparquet.WithSchemaDefinition(def))
+	for i := int64(0); i < 3; i++ {
+		require.NoError(t, w.AddData(map[string]interface{}{"a": i}))
+	}
+	require.NoError(t, w.Close())
+	require.NoError(t, cloud.WriteFile(ctx, store, "f.parquet", bytes.NewReader(buf.Bytes())))
+
+	records, size, readDef, err := ReadParquetFooter(ctx, store, "f.parquet")
+	require.NoError(t, err)
+	require.EqualValues(t, 3, records)
+	require.EqualValues(t, buf.Len(), size)
+	require.Equal(t, def.String(), readDef.String())
+}
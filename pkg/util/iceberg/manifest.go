@@ -0,0 +1,201 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package iceberg
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+	"github.com/linkedin/goavro/v2"
+)
+
+// manifestEntrySchema is the Avro schema of the entries of manifests in
+// version 1 of the format. The optional column statistics are not written.
+const manifestEntrySchema = `{
+  "type": "record",
+  "name": "manifest_entry",
+  "fields": [
+    {"name": "status", "type": "int", "field-id": 0},
+    {"name": "snapshot_id", "type": "long", "field-id": 1},
+    {"name": "data_file", "field-id": 2, "type": {
+      "type": "record",
+      "name": "r2",
+      "fields": [
+        {"name": "file_path", "type": "string", "field-id": 100},
+        {"name": "file_format", "type": "string", "field-id": 101},
+        {"name": "partition", "field-id": 102, "type": {"type": "record", "name": "r102", "fields": []}},
+        {"name": "record_count", "type": "long", "field-id": 103},
+        {"name": "file_size_in_bytes", "type": "long", "field-id": 104},
+        {"name": "block_size_in_bytes", "type": "long", "field-id": 105}
+      ]
+    }}
+  ]
+}`
+
+// manifestFileSchema is the Avro schema of the entries of manifest lists in
+// version 1 of the format.
+const manifestFileSchema = `{
+  "type": "record",
+  "name": "manifest_file",
+  "fields": [
+    {"name": "manifest_path", "type": "string", "field-id": 500},
+    {"name": "manifest_length", "type": "long", "field-id": 501},
+    {"name": "partition_spec_id", "type": "int", "field-id": 502},
+    {"name": "added_snapshot_id", "type": ["null", "long"], "default": null, "field-id": 503},
+    {"name": "added_data_files_count", "type": ["null", "int"], "default": null, "field-id": 504},
+    {"name": "existing_data_files_count", "type": ["null", "int"], "default": null, "field-id": 505},
+    {"name": "deleted_data_files_count", "type": ["null", "int"], "default": null, "field-id": 506},
+    {"name": "partitions", "default": null, "field-id": 507, "type": ["null", {
+      "type": "array",
+      "element-id": 508,
+      "items": {
+        "type": "record",
+        "name": "r508",
+        "fields": [
+          {"name": "contains_null", "type": "boolean", "field-id": 509},
+          {"name": "lower_bound", "type": ["null", "bytes"], "default": null, "field-id": 510},
+          {"name": "upper_bound", "type": ["null", "bytes"], "default": null, "field-id": 511}
+        ]
+      }
+    }]},
+    {"name": "added_rows_count", "type": ["null", "long"], "default": null, "field-id": 512},
+    {"name": "existing_rows_count", "type": ["null", "long"], "default": null, "field-id": 513},
+    {"name": "deleted_rows_count", "type": ["null", "long"], "default": null, "field-id": 514}
+  ]
+}`
+
+// manifestEntryStatusAdded is the status of the manifest entries of files
+// added by the snapshot of the manifest.
+const manifestEntryStatusAdded = 1
+
+// defaultBlockSize is written as the block size of data files, which the
+// format version 1 requires but readers ignore.
+const defaultBlockSize = 64 << 20
+
+// writeManifest writes a manifest of the files added by a snapshot, and
+// returns its entry in the manifest list of the snapshot.
+func (t *Table) writeManifest(
+	ctx context.Context, snapshotID int64, schema *Schema, files []DataFile,
+) (map[string]interface{}, error) {
+	schemaJSON, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	w, err := goavro.NewOCFWriter(goavro.OCFConfig{
+		W:      &buf,
+		Schema: manifestEntrySchema,
+		MetaData: map[string][]byte{
+			"schema":            schemaJSON,
+			"partition-spec":    []byte("[]"),
+			"partition-spec-id": []byte("0"),
+			"format-version":    []byte("1"),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var records int64
+	entries := make([]interface{}, len(files))
+	for i, f := range files {
+		records += f.RecordCount
+		entries[i] = map[string]interface{}{
+			"status":      int32(manifestEntryStatusAdded),
+			"snapshot_id": snapshotID,
+			"data_file": map[string]interface{}{
+				"file_path":           f.Path,
+				"file_format":         "PARQUET",
+				"partition":           map[string]interface{}{},
+				"record_count":        f.RecordCount,
+				"file_size_in_bytes":  f.SizeBytes,
+				"block_size_in_bytes": int64(defaultBlockSize),
+			},
+		}
+	}
+	if err := w.Append(entries); err != nil {
+		return nil, err
+	}
+	name := path.Join(metadataDir, fmt.Sprintf("%s-m0.avro", uuid.MakeV4()))
+	if err := t.writeFile(ctx, name, buf.Bytes()); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"manifest_path":             t.FileLocation(name),
+		"manifest_length":           int64(buf.Len()),
+		"partition_spec_id":         int32(0),
+		"added_snapshot_id":         goavro.Union("long", snapshotID),
+		"added_data_files_count":    goavro.Union("int", int32(len(files))),
+		"existing_data_files_count": goavro.Union("int", int32(0)),
+		"deleted_data_files_count":  goavro.Union("int", int32(0)),
+		"partitions":                nil,
+		"added_rows_count":          goavro.Union("long", records),
+		"existing_rows_count":       goavro.Union("long", int64(0)),
+		"deleted_rows_count":        goavro.Union("long", int64(0)),
+	}, nil
+}
+
+// readManifestList returns the entries of the manifest list at the given
+// location.
+func (t *Table) readManifestList(
+	ctx context.Context, location string,
+) ([]map[string]interface{}, error) {
+	name, err := t.relativePath(location)
+	if err != nil {
+		return nil, err
+	}
+	b, err := t.readFile(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	r, err := goavro.NewOCFReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	var manifests []map[string]interface{}
+	for r.Scan() {
+		datum, err := r.Read()
+		if err != nil {
+			return nil, err
+		}
+		manifests = append(manifests, datum.(map[string]interface{}))
+	}
+	return manifests, r.Err()
+}
+
+// writeManifestList writes the manifest list of a snapshot.
+func (t *Table) writeManifestList(
+	ctx context.Context, name string, snapshotID int64, manifests []map[string]interface{},
+) error {
+	var buf bytes.Buffer
+	w, err := goavro.NewOCFWriter(goavro.OCFConfig{
+		W:      &buf,
+		Schema: manifestFileSchema,
+		MetaData: map[string][]byte{
+			"snapshot-id":    []byte(fmt.Sprint(snapshotID)),
+			"format-version": []byte("1"),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	entries := make([]interface{}, len(manifests))
+	for i, m := range manifests {
+		entries[i] = m
+	}
+	if err := w.Append(entries); err != nil {
+		return err
+	}
+	return t.writeFile(ctx, name, buf.Bytes())
+}
@@ -0,0 +1,38 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package iceberg
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/cloud"
+	"github.com/cockroachdb/errors"
+	goparquet "github.com/fraugster/parquet-go"
+	"github.com/fraugster/parquet-go/parquetschema"
+)
+
+// ReadParquetFooter returns the number of records and the schema of a Parquet
+// file in store, and its size. Only the footer of the file is read.
+func ReadParquetFooter(
+	ctx context.Context, store cloud.ExternalStorage, name string,
+) (records int64, size int64, _ *parquetschema.SchemaDefinition, _ error) {
+	r := cloud.NewReadSeeker(ctx, store, name)
+	defer r.Close()
+	size, err := r.Size()
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	fr, err := goparquet.NewFileReader(r)
+	if err != nil {
+		return 0, 0, nil, errors.Wrapf(err, "reading parquet footer of %s", name)
+	}
+	return fr.NumRows(), size, fr.GetSchemaDefinition(), nil
+}
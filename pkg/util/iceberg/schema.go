@@ -0,0 +1,317 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package iceberg
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/cockroachdb/errors"
+	"github.com/fraugster/parquet-go/parquet"
+	"github.com/fraugster/parquet-go/parquetschema"
+)
+
+// Schema is the schema of an Iceberg table, serialized as in the table
+// metadata.
+type Schema struct {
+	Type     string  `json:"type"`
+	SchemaID int     `json:"schema-id"`
+	Fields   []Field `json:"fields"`
+}
+
+// Field is a field of a Schema.
+type Field struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Required bool   `json:"required"`
+	Type     Type   `json:"type"`
+}
+
+// Type is the type of a Field. It is either a primitive type, e.g. "long",
+// or a list of a primitive type.
+type Type struct {
+	Primitive string
+	List      *ListType
+}
+
+// ListType is an Iceberg list type.
+type ListType struct {
+	ElementID       int
+	Element         string
+	ElementRequired bool
+}
+
+type jsonListType struct {
+	Type            string `json:"type"`
+	ElementID       int    `json:"element-id"`
+	Element         string `json:"element"`
+	ElementRequired bool   `json:"element-required"`
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (t Type) MarshalJSON() ([]byte, error) {
+	if t.List == nil {
+		return json.Marshal(t.Primitive)
+	}
+	return json.Marshal(jsonListType{
+		Type:            "list",
+		ElementID:       t.List.ElementID,
+		Element:         t.List.Element,
+		ElementRequired: t.List.ElementRequired,
+	})
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (t *Type) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, &t.Primitive); err == nil {
+		return nil
+	}
+	var l jsonListType
+	if err := json.Unmarshal(b, &l); err != nil {
+		return err
+	}
+	if l.Type != "list" {
+		return errors.Newf("unsupported iceberg type %q", l.Type)
+	}
+	t.List = &ListType{ElementID: l.ElementID, Element: l.Element, ElementRequired: l.ElementRequired}
+	return nil
+}
+
+// String returns the name of the type.
+func (t Type) String() string {
+	if t.List == nil {
+		return t.Primitive
+	}
+	return fmt.Sprintf("list<%s>", t.List.Element)
+}
+
+// equalIgnoringIDs returns whether the types are equal, ignoring the IDs of
+// list elements.
+func (t Type) equalIgnoringIDs(o Type) bool {
+	if t.List == nil || o.List == nil {
+		return t.List == nil && o.List == nil && t.Primitive == o.Primitive
+	}
+	return t.List.Element == o.List.Element && t.List.ElementRequired == o.List.ElementRequired
+}
+
+// SchemaFromParquet returns the Iceberg schema of Parquet files with the
+// given schema. Fields are numbered from 1, in the order of the columns.
+//
+// Parquet files written by CockroachDB do not carry field IDs, so tables
+// reference their columns by name through the default name mapping of the
+// table (see nameMapping).
+func SchemaFromParquet(def *parquetschema.SchemaDefinition) (Schema, error) {
+	s := Schema{Type: "struct"}
+	columns := def.RootColumn.Children
+	nextID := len(columns) + 1
+	for i, col := range columns {
+		f := Field{
+			ID:       i + 1,
+			Name:     col.SchemaElement.Name,
+			Required: isRequired(col.SchemaElement),
+		}
+		if len(col.Children) == 0 {
+			typ, err := primitiveType(col.SchemaElement)
+			if err != nil {
+				return Schema{}, errors.Wrapf(err, "column %s", f.Name)
+			}
+			f.Type.Primitive = typ
+		} else {
+			// Lists are written as a group holding a repeated group of elements,
+			// as recommended by the Parquet specification.
+			if len(col.Children) != 1 || len(col.Children[0].Children) != 1 {
+				return Schema{}, errors.Newf("column %s: unsupported parquet group", f.Name)
+			}
+			element := col.Children[0].Children[0].SchemaElement
+			typ, err := primitiveType(element)
+			if err != nil {
+				return Schema{}, errors.Wrapf(err, "column %s", f.Name)
+			}
+			f.Type.List = &ListType{
+				ElementID:       nextID,
+				Element:         typ,
+				ElementRequired: isRequired(element),
+			}
+			nextID++
+		}
+		s.Fields = append(s.Fields, f)
+	}
+	return s, nil
+}
+
+func isRequired(el *parquet.SchemaElement) bool {
+	return el.RepetitionType != nil && *el.RepetitionType == parquet.FieldRepetitionType_REQUIRED
+}
+
+// primitiveType returns the Iceberg type of a primitive Parquet column.
+func primitiveType(el *parquet.SchemaElement) (string, error) {
+	if el.Type == nil {
+		return "", errors.New("missing parquet type")
+	}
+	lt := el.LogicalType
+	switch *el.Type {
+	case parquet.Type_BOOLEAN:
+		return "boolean", nil
+	case parquet.Type_INT32:
+		return "int", nil
+	case parquet.Type_INT64:
+		if lt != nil && lt.TIME != nil {
+			return "time", nil
+		}
+		return "long", nil
+	case parquet.Type_FLOAT:
+		return "float", nil
+	case parquet.Type_DOUBLE:
+		return "double", nil
+	case parquet.Type_BYTE_ARRAY:
+		// CockroachDB writes decimals as their text representation, so they are
+		// exposed as strings.
+		if lt != nil && (lt.STRING != nil || lt.JSON != nil || lt.ENUM != nil || lt.DECIMAL != nil) {
+			return "string", nil
+		}
+		return "binary", nil
+	case parquet.Type_FIXED_LEN_BYTE_ARRAY:
+		if lt != nil && lt.UUID != nil {
+			return "uuid", nil
+		}
+		if el.TypeLength == nil {
+			return "", errors.New("missing length of fixed length parquet type")
+		}
+		return fmt.Sprintf("fixed[%d]", *el.TypeLength), nil
+	default:
+		return "", errors.Newf("unsupported parquet type %s", el.Type)
+	}
+}
+
+// isPromotion returns whether a column of type from can be read as type to.
+func isPromotion(from, to Type) bool {
+	if from.List != nil || to.List != nil {
+		return false
+	}
+	return (from.Primitive == "int" && to.Primitive == "long") ||
+		(from.Primitive == "float" && to.Primitive == "double")
+}
+
+// evolve returns the schema of the table after files with schema s are
+// appended to it. Fields keep the IDs of the fields of the previous schemas
+// with the same name, so that files written with any schema are read
+// consistently through the name mapping of the table. Fields that are new to
+// the table are optional, since the files that the table already holds do
+// not have them.
+func (m *tableMetadata) evolve(s Schema) (Schema, error) {
+	cur := m.currentSchema()
+	if cur == nil {
+		s.SchemaID = 0
+		return s, nil
+	}
+	byName := make(map[string]Field)
+	for _, prev := range m.Schemas {
+		for _, f := range prev.Fields {
+			byName[f.Name] = f
+		}
+	}
+	evolved := Schema{Type: "struct", SchemaID: cur.SchemaID}
+	nextID := m.LastColumnID + 1
+	for _, f := range s.Fields {
+		prev, ok := byName[f.Name]
+		if !ok {
+			f.ID = nextID
+			nextID++
+			f.Required = false
+			if f.Type.List != nil {
+				f.Type.List.ElementID = nextID
+				nextID++
+			}
+			evolved.Fields = append(evolved.Fields, f)
+			continue
+		}
+		switch {
+		case prev.Type.equalIgnoringIDs(f.Type):
+			f.Type = prev.Type
+		case isPromotion(prev.Type, f.Type):
+		case isPromotion(f.Type, prev.Type):
+			// The table already reads the column with the wider type.
+			f.Type = prev.Type
+		default:
+			return Schema{}, errors.Newf("column %s changed type from %s to %s", f.Name, prev.Type, f.Type)
+		}
+		f.ID = prev.ID
+		// Files appended to the table before may not hold the column, or hold
+		// NULLs in it.
+		f.Required = f.Required && prev.Required && cur.field(f.ID) != nil
+		evolved.Fields = append(evolved.Fields, f)
+	}
+	if evolved.equal(cur) {
+		return *cur, nil
+	}
+	for _, prev := range m.Schemas {
+		if prev.SchemaID >= evolved.SchemaID {
+			evolved.SchemaID = prev.SchemaID + 1
+		}
+	}
+	return evolved, nil
+}
+
+// field returns the field with the given ID, or nil.
+func (s *Schema) field(id int) *Field {
+	for i := range s.Fields {
+		if s.Fields[i].ID == id {
+			return &s.Fields[i]
+		}
+	}
+	return nil
+}
+
+// equal returns whether the schemas have the same fields.
+func (s *Schema) equal(o *Schema) bool {
+	if len(s.Fields) != len(o.Fields) {
+		return false
+	}
+	for i, f := range s.Fields {
+		g := o.Fields[i]
+		if f.ID != g.ID || f.Name != g.Name || f.Required != g.Required ||
+			!f.Type.equalIgnoringIDs(g.Type) ||
+			(f.Type.List != nil && f.Type.List.ElementID != g.Type.List.ElementID) {
+			return false
+		}
+	}
+	return true
+}
+
+// nameMappingEntry is an entry of the name mapping of a table, which maps
+// the names of the columns of data files without field IDs to the IDs of
+// the fields of the table.
+type nameMappingEntry struct {
+	FieldID int                `json:"field-id"`
+	Names   []string           `json:"names"`
+	Fields  []nameMappingEntry `json:"fields,omitempty"`
+}
+
+// nameMapping returns the name mapping of the fields of all the schemas of
+// the table, so that files written with previous schemas remain readable.
+func (m *tableMetadata) nameMapping() ([]byte, error) {
+	var entries []nameMappingEntry
+	seen := make(map[int]bool)
+	for i := len(m.Schemas) - 1; i >= 0; i-- {
+		for _, f := range m.Schemas[i].Fields {
+			if seen[f.ID] {
+				continue
+			}
+			seen[f.ID] = true
+			e := nameMappingEntry{FieldID: f.ID, Names: []string{f.Name}}
+			if f.Type.List != nil {
+				e.Fields = []nameMappingEntry{{FieldID: f.Type.List.ElementID, Names: []string{"element"}}}
+			}
+			entries = append(entries, e)
+		}
+	}
+	return json.Marshal(entries)
+}